@@ -17,6 +17,14 @@ class SessionHandle:
   strategy: str = ""
   created_at: str = ""
 
+# 客户端使用的平台 API 版本，连接时与服务端 /api/v1/version 返回的 api_versions 比较
+SUPPORTED_API_VERSIONS = ("1",)
+
+
+class IncompatibleServerError(RuntimeError):
+  """服务端不提供客户端所需的 API 版本。"""
+
+
 class PlatformApiClient:
   def __init__(self, base_url: str):
     self.base_url = base_url.rstrip("/")
@@ -28,6 +36,27 @@ class PlatformApiClient:
   def health(self) -> dict[str, Any]:
     return self._client.get(f"{self.base_url}/health").json()
 
+  def server_version(self) -> dict[str, Any] | None:
+    """返回服务端构建信息，旧版本服务端没有 /version 接口时返回 None。"""
+    resp = self._client.get(f"{self.base_url}/api/v1/version")
+    if resp.status_code == 404:
+      return None
+    resp.raise_for_status()
+    return resp.json()
+
+  def check_compatibility(self) -> dict[str, Any] | None:
+    """检查服务端是否提供客户端所需的 API 版本，不兼容时抛出 IncompatibleServerError。"""
+    info = self.server_version()
+    if info is None:
+      return None
+    offered = info.get("api_versions") or []
+    if not any(v in offered for v in SUPPORTED_API_VERSIONS):
+      raise IncompatibleServerError(
+        f"Platform {info.get('version', 'unknown')} offers API versions {offered}, "
+        f"client requires one of {list(SUPPORTED_API_VERSIONS)}"
+      )
+    return info
+
   # Session CRUD 操作
   def create_session(
     self,
@@ -140,24 +169,68 @@ class PlatformApiClient:
     resp = self._client.delete(f"{self.base_url}/api/v1/sessions/{session_id}")
     resp.raise_for_status()
 
-  def list_files(self, session_id: str) -> dict[str, Any]:
-    resp = self._client.get(f"{self.base_url}/api/v1/sessions/{session_id}/files")
+  def list_files(self, session_id: str, path: str = "", recursive: bool = False) -> dict[str, Any]:
+    params: dict[str, Any] = {}
+    if path:
+      params["path"] = path
+    if recursive:
+      params["recursive"] = "true"
+    resp = self._client.get(f"{self.base_url}/api/v1/sessions/{session_id}/files", params=params)
     resp.raise_for_status()
     return resp.json()
 
-  def read_file(self, session_id: str, path: str) -> dict[str, Any]:
+  def read_file(self, session_id: str, path: str, encoding: str = "") -> dict[str, Any]:
+    params = {"path": path}
+    if encoding:
+      params["encoding"] = encoding
     resp = self._client.get(
       f"{self.base_url}/api/v1/sessions/{session_id}/files/read",
-      params={"path": path},
+      params=params,
     )
     resp.raise_for_status()
     return resp.json()
 
+  def read_file_bytes(self, session_id: str, path: str, start: int = 0, end: int | None = None) -> bytes:
+    """读取文件的原始字节，可选只读取 [start, end] 区间。"""
+    headers = {}
+    if start or end is not None:
+      headers["Range"] = f"bytes={start}-{'' if end is None else end}"
+    resp = self._client.get(
+      f"{self.base_url}/api/v1/sessions/{session_id}/files/raw",
+      params={"path": path},
+      headers=headers,
+    )
+    resp.raise_for_status()
+    return resp.content
+
+  def download_archive(self, session_id: str, path: str, dest: str) -> int:
+    """将工作区目录以 tar.gz 下载到本地文件 dest，返回写入的字节数。"""
+    params = {"path": path} if path else {}
+    written = 0
+    with self._client.stream(
+      "GET",
+      f"{self.base_url}/api/v1/sessions/{session_id}/files/archive",
+      params=params,
+      timeout=None,
+    ) as resp:
+      resp.raise_for_status()
+      with open(dest, "wb") as f:
+        for chunk in resp.iter_bytes():
+          f.write(chunk)
+          written += len(chunk)
+    return written
+
   def sync_files(self, session_id: str) -> dict[str, Any]:
     resp = self._client.post(f"{self.base_url}/api/v1/sessions/{session_id}/sync", json={})
     resp.raise_for_status()
     return resp.json()
 
+  def get_operation(self, operation_id: str) -> dict[str, Any]:
+    """查询终止、重启、同步等异步操作的状态。"""
+    resp = self._client.get(f"{self.base_url}/api/v1/operations/{operation_id}")
+    resp.raise_for_status()
+    return resp.json()
+
   # SSE 流处理
   def stream_events(self, session_id: str) -> Iterator[dict[str, Any]]:
     with self._client.stream(
@@ -371,8 +371,9 @@ HELP_TEXT = """
   [green]/status[/green]           Show current session status
 
 [bold cyan]File Operations[/bold cyan]
-  [green]/files[/green]            List files in the sandbox workspace
+  [green]/files[/green] [dim][dir][/dim]      List files in the sandbox workspace
   [green]/read[/green] [dim]<path>[/dim]     Read a file from the sandbox
+  [green]/download[/green] [dim][dir][/dim]   Download a workspace directory as a .tar.gz
   [green]/sync[/green]             Copy sandbox files to your local machine
 
 [bold cyan]History & Info[/bold cyan]
@@ -688,8 +689,26 @@ class SessionContext:
     # ── /files ──
     if cmd_name == "/files":
       try:
-        data = self.api.list_files(self.session_id)
-        console.print(data.get("output", "(empty)"))
+        data = self.api.list_files(self.session_id, cmd_arg or "")
+        files = data.get("files") or []
+        if not files:
+          console.print("[dim](empty)[/dim]")
+          return False
+        table = Table(show_header=True, header_style="bold magenta", border_style="bright_blue")
+        table.add_column("Mode", style="dim")
+        table.add_column("Size", justify="right")
+        table.add_column("Modified", style="dim")
+        table.add_column("Name", style="bright_cyan")
+        for f in files:
+          name = f.get("path", "")
+          if f.get("is_dir"):
+            name += "/"
+          elif f.get("link"):
+            name += f" -> {f['link']}"
+          table.add_row(f.get("mode", ""), str(f.get("size", 0)), f.get("mod_time", "")[:19], name)
+        console.print(table)
+        if data.get("next_offset"):
+          console.print(f"[dim]Showing {len(files)} of {data.get('total')} entries[/dim]")
       except Exception as e:
         console.print(f"[red]{e}[/red]")
       return False
@@ -708,6 +727,17 @@ class SessionContext:
         console.print(f"[red]{e}[/red]")
       return False
 
+    # ── /download [dir] ──
+    if cmd_name == "/download":
+      name = cmd_arg.strip("/").replace("/", "_") or "workspace"
+      dest = f"{self.session_id[:12]}-{name}.tar.gz"
+      try:
+        size = self.api.download_archive(self.session_id, cmd_arg, dest)
+        console.print(f"[green]Saved {dest}[/green] [dim]({size} bytes)[/dim]")
+      except Exception as e:
+        console.print(f"[red]{e}[/red]")
+      return False
+
     # ── /sync ──
     if cmd_name == "/sync":
       try:
@@ -878,6 +908,12 @@ def run(
     health = api.health()
     if health.get("status") != "ok":
       raise RuntimeError(f"Platform unhealthy: {health}")
+    server = api.check_compatibility()
+    if server is None:
+      console.print("[yellow]Platform does not report its version; compatibility not checked.[/yellow]")
+    else:
+      commit = f" ({server['commit']})" if server.get("commit") else ""
+      console.print(f"[dim]Platform {server.get('version', 'unknown')}{commit}[/dim]")
 
     history = SessionHistory(max_records=cfg.cli.history_max_records)
 
@@ -22,6 +22,7 @@ class PoolConfig(BaseModel):
   host_root: str | None = None  # defaults to ~/.agent-platform/projects
   container_mem_mb: int = 512
   container_cpu: float = 0.5
+  runtime: str | None = None  # 容器 OCI 运行时，如 runsc（gVisor），None 使用 Docker 默认
 
 
 class PlatformConfig(BaseModel):
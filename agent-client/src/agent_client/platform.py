@@ -77,6 +77,8 @@ def _platform_env(config: ClientConfig) -> dict[str, str]:
   env.setdefault("POOL_NETWORK_NAME", pool.network_name)
   env.setdefault("POOL_CONTAINER_MEM_MB", str(pool.container_mem_mb))
   env.setdefault("POOL_CONTAINER_CPU", str(pool.container_cpu))
+  if pool.runtime:
+    env.setdefault("POOL_RUNTIME", pool.runtime)
 
   default_host_root = str((Path.home() / ".agent-platform/projects").resolve())
   host_root = pool.host_root or env.get("POOL_HOST_ROOT", default_host_root)
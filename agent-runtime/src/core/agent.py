@@ -85,7 +85,9 @@ SANDBOX_CONTEXT = (
   "The tool returns the IP addresses of every service.\n"
   "3. **Use the returned IPs** (not `localhost`) to connect to services from your code.\n"
   "4. **Test your application** end-to-end against the running services to verify correctness.\n"
-  "5. **Use `get_compose_stack`** if you need to re-check service IPs or status.\n"
+  "5. **Use `get_compose_stack`** if you need to re-check service IPs or status. "
+  "The platform also keeps `/app/.platform/services.json` up to date with every "
+  "running service's host IP, ports, environment (credentials) and health status.\n"
   "6. **Do NOT install service daemons locally** "
   "(e.g., no `apt-get install postgresql`, no `brew install redis`).\n"
   "7. **Do NOT use `teardown_compose_stack`** unless you are completely done with the task "
@@ -144,6 +144,20 @@ class BaseAgent(ABC):
     """
     pass
 
+  async def replay_history(self, messages: List[Dict[str, str]]) -> None:
+    """
+    恢复平台重放的对话历史。
+
+    Agent 容器被替换（重启、迁移）后，平台在 configure() 之后调用，
+    messages 为按时间排序的 {"role", "content"} 列表。
+    默认写入 memory / _memory，自定义记忆结构的 Agent 应覆盖此方法。
+    """
+    memory = getattr(self, "memory", None) or getattr(self, "_memory", None)
+    if memory is None or not hasattr(memory, "add_message"):
+      return
+    for msg in messages:
+      memory.add_message(msg.get("role", "user"), msg.get("content", ""))
+
   async def on_error(self, error: Exception) -> Optional[str]:
     """
     step() 遇到异常时的钩子。
@@ -2,6 +2,7 @@ import asyncio
 import logging
 import grpc
 from concurrent import futures
+from grpc_health.v1 import health, health_pb2, health_pb2_grpc
 from src.config import settings
 from src.service import AgentService
 from src.pb import agent_pb2_grpc
@@ -25,10 +26,15 @@ async def serve():
     ],
   )
   agent_pb2_grpc.add_AgentServiceServicer_to_server(AgentService(), server)
+  # grpc.health.v1：平台预热池据此判断 agent 能否处理请求，而不只是端口可连接
+  health_servicer = health.aio.HealthServicer()
+  health_pb2_grpc.add_HealthServicer_to_server(health_servicer, server)
   port = settings.GRPC_PORT
   server.add_insecure_port(f'[::]:{port}')
   logger.info(f"Starting Agent Runtime gRPC server on port {port}...")
   await server.start()
+  for service in ('', 'agent.AgentService'):
+    await health_servicer.set(service, health_pb2.HealthCheckResponse.SERVING)
   
   # 等待终止
   await server.wait_for_termination()
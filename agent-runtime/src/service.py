@@ -12,6 +12,9 @@ from src.registry import ensure_builtin_agents
 
 logger = logging.getLogger(__name__)
 
+# ConfigureRequest.agent_config 中携带重放对话历史的键，与平台 service.ConversationHistoryKey 一致
+HISTORY_KEY = "conversation_history"
+
 # 只要实现了 AgentService 的接口方法，就可以通过 gRPC 提供服务
 class AgentService(agent_pb2_grpc.AgentServiceServicer):
   def __init__(self, agent_factory=None):
@@ -55,14 +58,26 @@ class AgentService(agent_pb2_grpc.AgentServiceServicer):
         },
       })
 
+    # 平台在容器替换后通过 agent_config 携带持久化的对话历史
+    agent_config = dict(request.agent_config) if request.agent_config else None
+    history = None
+    if agent_config and HISTORY_KEY in agent_config:
+      try:
+        history = json.loads(agent_config.pop(HISTORY_KEY))
+      except json.JSONDecodeError:
+        logger.warning("Ignoring malformed conversation history for session %s", request.session_id)
+
     try:
       available = await agent.configure(
         session_id=request.session_id,
         system_prompt=request.system_prompt,
         builtin_tools=list(request.builtin_tools),
         extra_tools=extra_tools if extra_tools else None,
-        agent_config=dict(request.agent_config) if request.agent_config else None,
+        agent_config=agent_config or None,
       )
+      if history:
+        await agent.replay_history(history)
+        logger.info("Replayed %d messages for session %s", len(history), request.session_id)
       return agent_pb2.ConfigureResponse(
         success=True,
         message="Agent configured",
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"platform/internal/api"
+)
+
+// client 平台 API 的最小客户端，请求和响应使用 api 包中与服务端共享的类型
+type client struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+// apiError 平台返回的非 2xx 响应，status 用于按状态码归类错误
+type apiError struct {
+	status int
+	msg    string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.status, e.msg)
+}
+
+func (c *client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		var e api.ErrorResponse
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if json.Unmarshal(raw, &e) != nil || e.Error == "" {
+			e.Error = strings.TrimSpace(string(raw))
+		}
+		return &apiError{status: resp.StatusCode, msg: e.Error}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *client) createSession(ctx context.Context, req api.CreateSessionRequest) (*api.SessionResponse, error) {
+	var sess api.SessionResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/sessions", req, &sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// waitReady 阻塞到 session 就绪，session 进入 error 状态时返回错误
+func (c *client) waitReady(ctx context.Context, id string) (*api.SessionResponse, error) {
+	var sess api.SessionResponse
+	if err := c.do(ctx, http.MethodGet, "/api/v1/sessions/"+id+"/wait", nil, &sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+func (c *client) configure(ctx context.Context, id string, req api.ConfigureAgentRequest) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/sessions/"+id+"/configure", req, nil)
+}
+
+func (c *client) sendMessage(ctx context.Context, id, message string) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/sessions/"+id+"/chat", api.ChatRequest{Message: message}, nil)
+}
+
+// waitEvent 等待 session 的下一个 types 类型的事件，只能收到调用之后发布的事件
+func (c *client) waitEvent(ctx context.Context, id string, types []string, timeout time.Duration) (*api.SSEEvent, error) {
+	q := url.Values{"type": {strings.Join(types, ",")}, "timeout": {timeout.String()}}
+	var event api.SSEEvent
+	if err := c.do(ctx, http.MethodGet, "/api/v1/sessions/"+id+"/events/wait?"+q.Encode(), nil, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (c *client) terminate(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/sessions/"+id, nil, nil)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"platform/internal/api"
+)
+
+func TestPercentile(t *testing.T) {
+	var lat []time.Duration
+	for i := 1; i <= 100; i++ {
+		lat = append(lat, time.Duration(i)*time.Millisecond)
+	}
+	cases := map[float64]time.Duration{0.5: 50 * time.Millisecond, 0.9: 90 * time.Millisecond, 0.99: 99 * time.Millisecond, 1: 100 * time.Millisecond}
+	for q, want := range cases {
+		if got := percentile(lat, q); got != want {
+			t.Errorf("percentile(%v) = %s, want %s", q, got, want)
+		}
+	}
+	if got := percentile(nil, 0.5); got != 0 {
+		t.Errorf("percentile of no samples = %s", got)
+	}
+}
+
+func TestIterationReportsStages(t *testing.T) {
+	var terminated []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/sessions", func(w http.ResponseWriter, r *http.Request) {
+		var req api.CreateSessionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.ProjectID != "lt" {
+			http.Error(w, "bad project", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(api.SessionResponse{ID: "s1", Status: "initializing"})
+	})
+	mux.HandleFunc("GET /api/v1/sessions/s1/wait", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(api.SessionResponse{ID: "s1", Status: "ready"})
+	})
+	mux.HandleFunc("GET /api/v1/sessions/s1/events/wait", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(api.SSEEvent{Type: "agent.answer", SessionID: "s1"})
+	})
+	mux.HandleFunc("POST /api/v1/sessions/s1/chat", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("DELETE /api/v1/sessions/s1", func(w http.ResponseWriter, r *http.Request) {
+		terminated = append(terminated, "s1")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(api.ErrorResponse{Error: "shutting down", Code: http.StatusServiceUnavailable})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := &client{base: srv.URL, http: srv.Client()}
+	rec := newRecorder()
+	o := options{project: "lt", message: "hi", readyTimeout: time.Second, chatTimeout: time.Second}
+	runIteration(context.Background(), c, o, rec)
+
+	rep := rec.report(1, time.Second)
+	if rep.Iterations != 1 || len(terminated) != 1 {
+		t.Fatalf("iterations = %d, terminated = %v", rep.Iterations, terminated)
+	}
+	got := make(map[string]StageReport)
+	for _, st := range rep.Stages {
+		got[st.Stage] = st
+	}
+	for _, stage := range []string{stageCreate, stageReady, stageChat} {
+		if got[stage].OK != 1 || got[stage].Errors != 0 {
+			t.Errorf("%s = %+v, want one success", stage, got[stage])
+		}
+	}
+	// 终止失败使整次迭代失败
+	if got[stageTerminate].Errors != 1 || got[stageTotal].Errors != 1 {
+		t.Errorf("terminate = %+v, total = %+v, want failures", got[stageTerminate], got[stageTotal])
+	}
+	for msg := range got[stageTerminate].TopErrors {
+		if !strings.Contains(msg, "HTTP 503: shutting down") {
+			t.Errorf("unexpected terminate error %q", msg)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+// loadgen 以可配置的并发驱动平台 API（创建 session、等待就绪、对话、终止），
+// 统计各阶段的耗时分位数和错误率，用于上线前验证预热池大小和队列配置。
+//
+//	go run ./cmd/loadgen -api http://localhost:8080 -concurrency 20 -n 200 -message "say hi"
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"platform/internal/api"
+)
+
+// subscribeDelay 发送消息前等待事件订阅建立的时间，/events/wait 只能收到订阅之后发布的事件
+const subscribeDelay = 200 * time.Millisecond
+
+type options struct {
+	api          string
+	token        string
+	concurrency  int
+	iterations   int
+	duration     time.Duration
+	ramp         time.Duration
+	project      string
+	user         string
+	strategy     string
+	image        string
+	priority     string
+	message      string
+	systemPrompt string
+	readyTimeout time.Duration
+	chatTimeout  time.Duration
+	keep         bool
+	jsonOut      bool
+}
+
+func parseFlags() options {
+	var o options
+	flag.StringVar(&o.api, "api", "http://localhost:8080", "platform API base URL")
+	flag.StringVar(&o.token, "token", os.Getenv("LOADGEN_TOKEN"), "bearer token (service account key or OIDC token), defaults to $LOADGEN_TOKEN")
+	flag.IntVar(&o.concurrency, "concurrency", 10, "number of concurrent workers")
+	flag.IntVar(&o.iterations, "n", 100, "total number of create/chat/terminate iterations (ignored when -duration is set)")
+	flag.DurationVar(&o.duration, "duration", 0, "run iterations until this duration has elapsed")
+	flag.DurationVar(&o.ramp, "ramp", 0, "spread worker start-up evenly over this duration")
+	flag.StringVar(&o.project, "project", "loadgen", "project ID of the created sessions")
+	flag.StringVar(&o.user, "user", "loadgen", "user ID of the created sessions (ignored when the token determines the user)")
+	flag.StringVar(&o.strategy, "strategy", "", "container strategy: Warm-Strategy or Cold-Strategy (default: user preference)")
+	flag.StringVar(&o.image, "image", "", "image or warm pool of the created sessions")
+	flag.StringVar(&o.priority, "priority", "", "acquisition priority: interactive or batch")
+	flag.StringVar(&o.message, "message", "", "chat message sent once the session is ready; empty skips the chat stage")
+	flag.StringVar(&o.systemPrompt, "system-prompt", "", "configure the agent with this system prompt before chatting")
+	flag.DurationVar(&o.readyTimeout, "ready-timeout", 5*time.Minute, "maximum time to wait for a session to become ready")
+	flag.DurationVar(&o.chatTimeout, "chat-timeout", 2*time.Minute, "maximum time to wait for the agent's answer")
+	flag.BoolVar(&o.keep, "keep", false, "keep sessions running instead of terminating them")
+	flag.BoolVar(&o.jsonOut, "json", false, "print the report as JSON")
+	flag.Parse()
+
+	o.api = strings.TrimRight(o.api, "/")
+	if o.concurrency < 1 {
+		o.concurrency = 1
+	}
+	return o
+}
+
+func main() {
+	o := parseFlags()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	if o.duration > 0 {
+		var stop context.CancelFunc
+		ctx, stop = context.WithTimeout(ctx, o.duration)
+		defer stop()
+	}
+
+	c := &client{base: o.api, token: o.token, http: &http.Client{}}
+	rec := newRecorder()
+
+	// remaining 剩余的迭代次数，按时长运行时不限制
+	var remaining atomic.Int64
+	remaining.Store(int64(o.iterations))
+	next := func() bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		return o.duration > 0 || remaining.Add(-1) >= 0
+	}
+
+	fmt.Fprintf(os.Stderr, "Running against %s with %d workers\n", o.api, o.concurrency)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := range o.concurrency {
+		wg.Go(func() {
+			if o.ramp > 0 {
+				select {
+				case <-time.After(o.ramp * time.Duration(i) / time.Duration(o.concurrency)):
+				case <-ctx.Done():
+					return
+				}
+			}
+			for next() {
+				runIteration(ctx, c, o, rec)
+			}
+		})
+	}
+	wg.Wait()
+
+	rep := rec.report(o.concurrency, time.Since(start))
+	if o.jsonOut {
+		if err := rep.writeJSON(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	} else {
+		rep.writeText(os.Stdout)
+	}
+	for _, st := range rep.Stages {
+		if st.Stage == stageTotal && st.Errors > 0 {
+			os.Exit(2)
+		}
+	}
+}
+
+// runIteration 完成一次创建、等待就绪、对话和终止，各阶段分别计时。
+// 压测被中断（Ctrl-C 或 -duration 到期）时已创建的 session 仍会被终止
+func runIteration(ctx context.Context, c *client, o options, rec *recorder) {
+	start := time.Now()
+	err := iterate(ctx, c, o, rec)
+	if ctx.Err() != nil {
+		// 被中断的迭代不计入统计
+		return
+	}
+	rec.record(stageTotal, time.Since(start), err)
+}
+
+func iterate(ctx context.Context, c *client, o options, rec *recorder) (err error) {
+	stage := func(name string, fn func() error) error {
+		t := time.Now()
+		err := fn()
+		if ctx.Err() == nil {
+			rec.record(name, time.Since(t), err)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	}
+
+	var sess *api.SessionResponse
+	if err := stage(stageCreate, func() (err error) {
+		sess, err = c.createSession(ctx, api.CreateSessionRequest{
+			ProjectID: o.project,
+			UserID:    o.user,
+			Strategy:  o.strategy,
+			Image:     o.image,
+			Priority:  o.priority,
+		})
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if !o.keep {
+		defer func() {
+			// 中断后仍需终止 session，避免遗留容器
+			termCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+			defer cancel()
+			t := time.Now()
+			termErr := c.terminate(termCtx, sess.ID)
+			rec.record(stageTerminate, time.Since(t), termErr)
+			if err == nil && termErr != nil {
+				err = fmt.Errorf("%s: %w", stageTerminate, termErr)
+			}
+		}()
+	}
+
+	if err := stage(stageReady, func() error {
+		readyCtx, cancel := context.WithTimeout(ctx, o.readyTimeout)
+		defer cancel()
+		ready, err := c.waitReady(readyCtx, sess.ID)
+		if err == nil && ready.Status != "ready" {
+			err = fmt.Errorf("session is %s", ready.Status)
+		}
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if o.message == "" {
+		return nil
+	}
+	if o.systemPrompt != "" {
+		if err := c.configure(ctx, sess.ID, api.ConfigureAgentRequest{SystemPrompt: o.systemPrompt}); err != nil {
+			return fmt.Errorf("configure: %w", err)
+		}
+	}
+
+	type result struct {
+		event *api.SSEEvent
+		err   error
+	}
+	waitCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan result, 1)
+	go func() {
+		event, err := c.waitEvent(waitCtx, sess.ID, []string{"agent.answer", "agent.error"}, o.chatTimeout)
+		done <- result{event, err}
+	}()
+	time.Sleep(subscribeDelay)
+
+	// 对话耗时从发送消息算起，到收到回答或错误为止
+	return stage(stageChat, func() error {
+		if err := c.sendMessage(ctx, sess.ID, o.message); err != nil {
+			return err
+		}
+		res := <-done
+		if res.err != nil {
+			return res.err
+		}
+		if res.event.Type == "agent.error" {
+			return fmt.Errorf("agent error: %v", res.event.Payload)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// 一次迭代依次经过的阶段，total 为整次迭代
+const (
+	stageCreate    = "create"
+	stageReady     = "ready"
+	stageChat      = "chat"
+	stageTerminate = "terminate"
+	stageTotal     = "total"
+)
+
+var stages = []string{stageCreate, stageReady, stageChat, stageTerminate, stageTotal}
+
+// maxErrorKinds 报告中每个阶段最多列出的不同错误数
+const maxErrorKinds = 5
+
+// recorder 并发记录各阶段的耗时和错误
+type recorder struct {
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+	errors    map[string]map[string]int
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		latencies: make(map[string][]time.Duration),
+		errors:    make(map[string]map[string]int),
+	}
+}
+
+// record 记录一次阶段调用，失败的调用只计入错误，不计入耗时分布
+func (r *recorder) record(stage string, d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		r.latencies[stage] = append(r.latencies[stage], d)
+		return
+	}
+	if r.errors[stage] == nil {
+		r.errors[stage] = make(map[string]int)
+	}
+	r.errors[stage][err.Error()]++
+}
+
+// StageReport 一个阶段的统计
+type StageReport struct {
+	Stage     string         `json:"stage"`
+	OK        int            `json:"ok"`
+	Errors    int            `json:"errors"`
+	ErrorRate float64        `json:"error_rate"`
+	P50Ms     int64          `json:"p50_ms"`
+	P90Ms     int64          `json:"p90_ms"`
+	P99Ms     int64          `json:"p99_ms"`
+	MaxMs     int64          `json:"max_ms"`
+	TopErrors map[string]int `json:"top_errors,omitempty"`
+}
+
+// Report 一次压测的结果
+type Report struct {
+	Concurrency int           `json:"concurrency"`
+	Iterations  int           `json:"iterations"`
+	ElapsedMs   int64         `json:"elapsed_ms"`
+	Throughput  float64       `json:"iterations_per_second"`
+	Stages      []StageReport `json:"stages"`
+}
+
+func (r *recorder) report(concurrency int, elapsed time.Duration) Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rep := Report{Concurrency: concurrency, ElapsedMs: elapsed.Milliseconds()}
+	for _, stage := range stages {
+		lat := slices.Clone(r.latencies[stage])
+		slices.Sort(lat)
+		st := StageReport{Stage: stage, OK: len(lat)}
+		for _, n := range r.errors[stage] {
+			st.Errors += n
+		}
+		if st.OK+st.Errors == 0 {
+			continue
+		}
+		st.ErrorRate = float64(st.Errors) / float64(st.OK+st.Errors)
+		st.P50Ms = percentile(lat, 0.50).Milliseconds()
+		st.P90Ms = percentile(lat, 0.90).Milliseconds()
+		st.P99Ms = percentile(lat, 0.99).Milliseconds()
+		st.MaxMs = percentile(lat, 1).Milliseconds()
+		st.TopErrors = topErrors(r.errors[stage], maxErrorKinds)
+		if stage == stageTotal {
+			rep.Iterations = st.OK + st.Errors
+		}
+		rep.Stages = append(rep.Stages, st)
+	}
+	if elapsed > 0 {
+		rep.Throughput = float64(rep.Iterations) / elapsed.Seconds()
+	}
+	return rep
+}
+
+// percentile 返回已排序样本的 q 分位数（nearest-rank），没有样本时为 0
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(q*float64(len(sorted))+0.5) - 1
+	return sorted[min(max(idx, 0), len(sorted)-1)]
+}
+
+// topErrors 返回出现次数最多的 n 种错误
+func topErrors(counts map[string]int, n int) map[string]int {
+	if len(counts) <= n {
+		return counts
+	}
+	msgs := make([]string, 0, len(counts))
+	for msg := range counts {
+		msgs = append(msgs, msg)
+	}
+	sort.Slice(msgs, func(i, j int) bool {
+		if counts[msgs[i]] != counts[msgs[j]] {
+			return counts[msgs[i]] > counts[msgs[j]]
+		}
+		return msgs[i] < msgs[j]
+	})
+	top := make(map[string]int, n)
+	for _, msg := range msgs[:n] {
+		top[msg] = counts[msg]
+	}
+	return top
+}
+
+func (rep Report) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rep)
+}
+
+func (rep Report) writeText(w io.Writer) {
+	fmt.Fprintf(w, "%d iterations at concurrency %d in %s (%.2f/s)\n\n",
+		rep.Iterations, rep.Concurrency, time.Duration(rep.ElapsedMs)*time.Millisecond, rep.Throughput)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STAGE\tOK\tERRORS\tERROR%\tP50\tP90\tP99\tMAX")
+	for _, st := range rep.Stages {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%dms\t%dms\t%dms\t%dms\n",
+			st.Stage, st.OK, st.Errors, st.ErrorRate*100, st.P50Ms, st.P90Ms, st.P99Ms, st.MaxMs)
+	}
+	tw.Flush()
+
+	for _, st := range rep.Stages {
+		if len(st.TopErrors) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s errors:\n", st.Stage)
+		msgs := make([]string, 0, len(st.TopErrors))
+		for msg := range st.TopErrors {
+			msgs = append(msgs, msg)
+		}
+		sort.Slice(msgs, func(i, j int) bool { return st.TopErrors[msgs[i]] > st.TopErrors[msgs[j]] })
+		for _, msg := range msgs {
+			fmt.Fprintf(w, "  %6d  %s\n", st.TopErrors[msg], msg)
+		}
+	}
+}
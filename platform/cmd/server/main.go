@@ -2,22 +2,57 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"platform/internal/config"
+	"platform/internal/doctor"
+	"platform/internal/egress"
+	"platform/internal/errreport"
+	"platform/internal/logging"
 	"platform/internal/server"
 )
 
 func main() {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+	cfg := config.Load()
+
+	// platform-server doctor：只做环境诊断，不启动服务
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		runDoctor(cfg)
+		return
+	}
+	// platform-server egress-proxy：作为受限网络策略的 sidecar 运行出网代理
+	if len(os.Args) > 1 && os.Args[1] == "egress-proxy" {
+		runEgressProxy(cfg)
+		return
+	}
+
+	logger, levels, err := logging.Setup(os.Stdout, cfg.Log.Level, cfg.Log.ComponentLevels, logging.SamplingConfig{
+		Initial:    cfg.Log.SamplingInitial,
+		Thereafter: cfg.Log.SamplingThereafter,
+		Interval:   cfg.Log.SamplingInterval,
+	})
+	if err != nil {
+		slog.Error("Invalid log configuration", "error", err)
+		os.Exit(1)
+	}
 	slog.SetDefault(logger)
 
-	cfg := config.Load()
+	flushReports, err := errreport.Init(errreport.Config{
+		DSN:         cfg.Sentry.DSN,
+		Environment: cfg.Sentry.Environment,
+		Release:     cfg.Sentry.Release,
+		SampleRate:  cfg.Sentry.SampleRate,
+	})
+	if err != nil {
+		logger.Error("Failed to initialise error reporting", "error", err)
+		os.Exit(1)
+	}
+	defer flushReports()
 
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
@@ -28,6 +63,7 @@ func main() {
 		os.Exit(1)
 	}
 	defer deps.Close()
+	deps.LogLevels = levels
 
 	srv := server.NewServer(cfg, deps)
 	if err := srv.Start(ctx); err != nil {
@@ -35,3 +71,39 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+func runDoctor(cfg *config.Config) {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	results := doctor.New(cfg).Run(ctx)
+	if failed := doctor.Print(os.Stdout, results); failed {
+		os.Exit(1)
+	}
+}
+
+func runEgressProxy(cfg *config.Config) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	policy, err := egress.ParsePolicy(cfg.Egress.AllowCIDRs, cfg.Egress.AllowDomains)
+	if err != nil {
+		logger.Error("Invalid egress policy", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	srv := &http.Server{Addr: cfg.Egress.Listen, Handler: egress.NewProxy(policy, logger)}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	logger.Info("Egress proxy listening", "addr", cfg.Egress.Listen,
+		"allow_cidrs", cfg.Egress.AllowCIDRs, "allow_domains", cfg.Egress.AllowDomains)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("Egress proxy error", "error", err)
+		os.Exit(1)
+	}
+}
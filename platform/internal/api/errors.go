@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"strings"
 
+	"platform/internal/sandbox"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -15,40 +17,90 @@ var (
 )
 
 func respondError(c *gin.Context, code int, err error) {
-	c.JSON(code, ErrorResponse{
+	c.JSON(code, errorBody(c, ErrorResponse{
 		Error: err.Error(),
 		Code:  code,
-	})
+	}))
 }
 
 func respondErrorWithDetails(c *gin.Context, code int, err error, details string) {
-	c.JSON(code, ErrorResponse{
+	c.JSON(code, errorBody(c, ErrorResponse{
 		Error:   err.Error(),
 		Code:    code,
 		Details: details,
-	})
+	}))
 }
 
 func abortWithError(c *gin.Context, code int, err error) {
-	c.AbortWithStatusJSON(code, ErrorResponse{
+	c.AbortWithStatusJSON(code, errorBody(c, ErrorResponse{
 		Error: err.Error(),
 		Code:  code,
-	})
+	}))
 }
 
 func mapServiceError(err error) int {
 	if err == nil {
 		return http.StatusOK
 	}
+	if code, ok := sandboxErrorStatus(err); ok {
+		return code
+	}
 	errMsg := err.Error()
 	switch {
 	case strings.Contains(errMsg, "not found"):
 		return http.StatusNotFound
 	case strings.Contains(errMsg, "not ready"):
 		return http.StatusConflict
+	case strings.Contains(errMsg, "not hibernated"), strings.Contains(errMsg, "not paused"):
+		return http.StatusConflict
+	case strings.Contains(errMsg, "session is paused"), strings.Contains(errMsg, "session is suspended"):
+		return http.StatusConflict
 	case strings.Contains(errMsg, "already"):
 		return http.StatusConflict
+	case strings.Contains(errMsg, "invalid"):
+		return http.StatusBadRequest
+	case strings.Contains(errMsg, "too large"):
+		return http.StatusRequestEntityTooLarge
+	case strings.Contains(errMsg, "no free host port"), strings.Contains(errMsg, "shutting down"):
+		return http.StatusServiceUnavailable
+	case strings.Contains(errMsg, "denied by policy"):
+		return http.StatusForbidden
+	case strings.Contains(errMsg, "quota exceeded"):
+		return http.StatusForbidden
+	case strings.Contains(errMsg, "too many concurrent"):
+		return http.StatusTooManyRequests
+	case strings.Contains(errMsg, "checkpoint not supported"):
+		return http.StatusNotImplemented
+	case strings.Contains(errMsg, "timed out waiting for event"):
+		return http.StatusRequestTimeout
+	case strings.Contains(errMsg, "timed out waiting for agent"):
+		return http.StatusGatewayTimeout
 	default:
 		return http.StatusInternalServerError
 	}
 }
+
+// sandboxErrorStatus 按沙箱错误的分类映射状态码，错误链中没有沙箱错误或分类为 fatal 时返回 false
+func sandboxErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, sandbox.ErrTooManyExecs):
+		return http.StatusTooManyRequests, true
+	case errors.Is(err, sandbox.ErrCheckpointUnsupported):
+		return http.StatusNotImplemented, true
+	}
+	var se *sandbox.Error
+	if !errors.As(err, &se) && !errors.Is(err, sandbox.ErrContainerNotFound) && !errors.Is(err, sandbox.ErrInvalidPath) {
+		return 0, false
+	}
+	switch sandbox.CategoryOf(err) {
+	case sandbox.CategoryNotFound:
+		return http.StatusNotFound, true
+	case sandbox.CategoryConflict:
+		return http.StatusConflict, true
+	case sandbox.CategoryInvalid:
+		return http.StatusBadRequest, true
+	case sandbox.CategoryTransient:
+		return http.StatusServiceUnavailable, true
+	}
+	return 0, false
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGzipAndETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(GzipMiddleware())
+	r.GET("/list", ETagMiddleware(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"items": strings.Repeat("x", 1024)})
+	})
+	r.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		c.String(http.StatusOK, "data: hi\n\n")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/list", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected gzip response, headers: %v", w.Header())
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(zr)
+	if !strings.Contains(string(body), `"items"`) {
+		t.Errorf("Unexpected decompressed body %q", body)
+	}
+
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected ETag header")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/list", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("Expected empty 304, got %d with %d bytes", w.Code, w.Body.Len())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/stream", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != "data: hi\n\n" {
+		t.Errorf("SSE response should not be compressed: %v %q", w.Header(), w.Body.String())
+	}
+}
+
+func TestGzipSkipsUnsuitableResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		gzip    bool
+	}{
+		{"text", func(c *gin.Context) { c.String(http.StatusOK, "text body") }, true},
+		{"svg", func(c *gin.Context) { c.Data(http.StatusOK, "image/svg+xml", []byte("<svg/>")) }, true},
+		{"png", func(c *gin.Context) { c.Data(http.StatusOK, "image/png", []byte("png")) }, false},
+		{"tarball", func(c *gin.Context) { c.Data(http.StatusOK, "application/gzip", []byte("gz")) }, false},
+		{"zip with params", func(c *gin.Context) { c.Data(http.StatusOK, "Application/Zip; name=a.zip", []byte("zip")) }, false},
+		{"partial content", func(c *gin.Context) {
+			c.Header("Content-Range", "bytes 0-3/10")
+			c.Data(http.StatusPartialContent, "text/plain", []byte("abcd"))
+		}, false},
+		{"content range", func(c *gin.Context) {
+			c.Header("Content-Range", "bytes */10")
+			c.Data(http.StatusRequestedRangeNotSatisfiable, "text/plain", []byte("bad range"))
+		}, false},
+		{"already encoded", func(c *gin.Context) {
+			c.Header("Content-Encoding", "br")
+			c.Data(http.StatusOK, "text/plain", []byte("br"))
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(GzipMiddleware())
+			r.GET("/", tt.handler)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding") == "gzip"; got != tt.gzip {
+				t.Fatalf("gzip = %v, want %v (headers %v)", got, tt.gzip, w.Header())
+			}
+		})
+	}
+}
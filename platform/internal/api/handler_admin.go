@@ -0,0 +1,350 @@
+package api
+
+import (
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"platform/internal/featureflag"
+	"platform/internal/projectstack"
+	"platform/internal/sandbox"
+	"platform/internal/service"
+	"platform/internal/taskstatus"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler 处理 /api/v1/admin 下的运维接口
+type AdminHandler struct {
+	svc *service.Service
+}
+
+func NewAdminHandler(svc *service.Service) *AdminHandler {
+	return &AdminHandler{svc: svc}
+}
+
+// ListTasks GET /api/v1/admin/tasks?stale=true
+// 返回正在处理的会话创建任务（阶段、耗时、最近心跳），stale=true 时只返回心跳超时的任务
+func (h *AdminHandler) ListTasks(c *gin.Context) {
+	tasks, err := h.svc.ListWorkerTasks(c.Request.Context())
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	staleOnly := c.Query("stale") == "true"
+	out := make([]taskstatus.TaskView, 0, len(tasks))
+	stale := 0
+	for _, t := range tasks {
+		if t.Stale {
+			stale++
+		} else if staleOnly {
+			continue
+		}
+		out = append(out, t)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tasks": out,
+		"total": len(tasks),
+		"stale": stale,
+	})
+}
+
+// GetStorageUsage GET /api/v1/admin/storage?refresh=true
+// 返回平台占用的磁盘空间（项目目录、日志、镜像、卷），默认返回最近一次后台统计结果
+func (h *AdminHandler) GetStorageUsage(c *gin.Context) {
+	refresh := c.Query("refresh") == "true"
+
+	report, err := h.svc.StorageReport(c.Request.Context(), refresh)
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetLogLevels GET /api/v1/admin/log-levels
+// 返回默认日志级别和各组件的覆盖级别
+func (h *AdminHandler) GetLogLevels(c *gin.Context) {
+	levels, err := h.svc.GetLogLevels()
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	c.JSON(http.StatusOK, levels)
+}
+
+// UpdateLogLevel PUT /api/v1/admin/log-levels
+// 运行时修改默认或某个组件（如 pool、api）的日志级别，无需重启
+func (h *AdminHandler) UpdateLogLevel(c *gin.Context) {
+	var req UpdateLogLevelRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	levels, err := h.svc.SetLogLevel(req.Component, req.Level)
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	c.JSON(http.StatusOK, levels)
+}
+
+// ResetLogLevel DELETE /api/v1/admin/log-levels/:component
+// 移除组件的覆盖级别，恢复使用默认级别
+func (h *AdminHandler) ResetLogLevel(c *gin.Context) {
+	levels, err := h.svc.ResetLogLevel(c.Param("component"))
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	c.JSON(http.StatusOK, levels)
+}
+
+// ListFeatureFlags GET /api/v1/admin/feature-flags
+// 返回所有功能开关的默认值和租户 / 项目覆盖
+func (h *AdminHandler) ListFeatureFlags(c *gin.Context) {
+	flags, err := h.svc.ListFeatureFlags()
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	c.JSON(http.StatusOK, FeatureFlagListResponse{Flags: flags})
+}
+
+// SetFeatureFlag PUT /api/v1/admin/feature-flags
+// 运行时开关实验性行为，无需重新部署
+func (h *AdminHandler) SetFeatureFlag(c *gin.Context) {
+	var req SetFeatureFlagRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	override, err := h.svc.SetFeatureFlag(c.Request.Context(), featureflag.Override{
+		Flag:    featureflag.Flag(req.Flag),
+		Scope:   featureflag.Scope{Tenant: req.Tenant, Project: req.Project},
+		Enabled: *req.Enabled,
+	})
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	c.JSON(http.StatusOK, override)
+}
+
+// DeleteFeatureFlag DELETE /api/v1/admin/feature-flags/:flag?tenant=&project=
+// 移除指定范围的覆盖
+func (h *AdminHandler) DeleteFeatureFlag(c *gin.Context) {
+	scope := featureflag.Scope{Tenant: c.Query("tenant"), Project: c.Query("project")}
+	if err := h.svc.DeleteFeatureFlag(c.Request.Context(), featureflag.Flag(c.Param("flag")), scope); err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// TransferSession POST /api/v1/admin/sessions/:id/transfer
+// 将 :id 租用的预热容器转给仍在排队的目标 session，:id 随之终止
+func (h *AdminHandler) TransferSession(c *gin.Context) {
+	var req TransferSessionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	sess, err := h.svc.TransferContainer(c.Request.Context(), c.Param("id"), req.TargetSessionID)
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	c.JSON(http.StatusOK, toSessionResponse(sess))
+}
+
+// DownloadDebugBundle GET /api/v1/admin/sessions/:id/debug-bundle
+// 下载 session 进入 error 状态时收集的调试包（tar.gz）
+func (h *AdminHandler) DownloadDebugBundle(c *gin.Context) {
+	id := c.Param("id")
+	bundle, name, err := h.svc.OpenDebugBundle(c.Request.Context(), id)
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	defer bundle.Close()
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	c.Status(http.StatusOK)
+	c.Writer.Header().Set("Content-Type", "application/gzip")
+	if _, err := io.Copy(c.Writer, bundle); err != nil {
+		slog.Warn("Debug bundle download aborted", "session_id", id, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// GetPool GET /api/v1/admin/pool?probe=false
+// 返回各预热池的空闲/租出数量、每个容器的年龄和健康状态以及冷却状态，probe=false 时不检查容器是否仍在运行
+func (h *AdminHandler) GetPool(c *gin.Context) {
+	pools, err := h.svc.PoolStatus(c.Request.Context(), c.Query("probe") != "false")
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pools": pools})
+}
+
+// GetSLO GET /api/v1/admin/slo
+// 返回会话创建和对话分发在各时间窗口内的成功率、耗时分位数、burn rate 和剩余错误预算
+func (h *AdminHandler) GetSLO(c *gin.Context) {
+	reports, err := h.svc.SLOReport(c.Request.Context())
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"objectives": reports})
+}
+
+// ReplenishPool POST /api/v1/admin/pool/replenish?pool=<name>
+// 结束冷却、解除排空并立即补充空闲容器，省略 pool 时作用于所有预热池；补充在后台进行
+func (h *AdminHandler) ReplenishPool(c *gin.Context) {
+	pools, err := h.svc.ReplenishPool(c.Request.Context(), c.Query("pool"))
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"pools": pools})
+}
+
+// DrainPool POST /api/v1/admin/pool/drain?pool=<name>
+// 删除空闲容器并暂停分配和补充，直到调用 replenish，省略 pool 时作用于所有预热池。租出的容器归还时删除
+func (h *AdminHandler) DrainPool(c *gin.Context) {
+	pools, err := h.svc.DrainPool(c.Request.Context(), c.Query("pool"))
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pools": pools})
+}
+
+// containerQueryLabels GET /admin/containers 支持按这些标签过滤，查询参数名与标签名相同
+var containerQueryLabels = []string{
+	sandbox.LabelSessionID,
+	sandbox.LabelProjectID,
+	sandbox.LabelTenant,
+	sandbox.LabelTemplate,
+	sandbox.LabelRunID,
+	sandbox.LabelStrategy,
+	sandbox.LabelVersion,
+}
+
+// ListContainers GET /api/v1/admin/containers?tenant=&strategy=&run_id=&all=true
+// 按标签列出平台创建的容器（沙箱、伴随服务），all=true 时包含已停止的容器
+func (h *AdminHandler) ListContainers(c *gin.Context) {
+	q := sandbox.LabelQuery{}
+	for _, label := range containerQueryLabels {
+		if v := c.Query(label); v != "" {
+			q[label] = v
+		}
+	}
+
+	containers, err := h.svc.ListContainers(c.Request.Context(), q, c.Query("all") == "true")
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	resp := ContainerListResponse{Containers: make([]ContainerResponse, 0, len(containers))}
+	for _, ct := range containers {
+		name := ""
+		if len(ct.Names) > 0 {
+			name = strings.TrimPrefix(ct.Names[0], "/")
+		}
+		resp.Containers = append(resp.Containers, ContainerResponse{
+			ID:        ct.ID,
+			Name:      name,
+			Image:     ct.Image,
+			State:     string(ct.State),
+			Status:    ct.Status,
+			CreatedAt: formatTime(time.Unix(ct.Created, 0)),
+			Labels:    ct.Labels,
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetProjectStack GET /api/v1/admin/projects/:project_id/stack
+func (h *AdminHandler) GetProjectStack(c *gin.Context) {
+	stack, err := h.svc.GetProjectStack(c.Request.Context(), c.Param("project_id"))
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	c.JSON(http.StatusOK, stack)
+}
+
+// UpdateProjectStack PUT /api/v1/admin/projects/:project_id/stack
+// 整体替换项目的默认服务，之后就绪的 session 自动启动这些服务，已运行的 session 不受影响
+func (h *AdminHandler) UpdateProjectStack(c *gin.Context) {
+	var req UpdateProjectStackRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	stack := &projectstack.Stack{
+		ProjectID: c.Param("project_id"),
+		Services:  make([]projectstack.Service, 0, len(req.Services)),
+	}
+	for _, svc := range req.Services {
+		stack.Services = append(stack.Services, projectstack.Service{
+			Name:        svc.Name,
+			Image:       svc.Image,
+			EnvVars:     svc.EnvVars,
+			Cmd:         svc.Cmd,
+			ExposePorts: svc.ExposePorts,
+			Scope:       svc.Scope,
+			MemoryMB:    svc.MemoryMB,
+			CPUs:        svc.CPUs,
+		})
+	}
+	if req.Compose != nil {
+		stack.Compose = &projectstack.Compose{
+			Name:        req.Compose.Name,
+			Content:     req.Compose.ComposeContent,
+			ExposePorts: req.Compose.ExposePorts,
+		}
+	}
+
+	if err := h.svc.UpdateProjectStack(c.Request.Context(), stack); err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	c.JSON(http.StatusOK, stack)
+}
+
+// DeleteProjectStack DELETE /api/v1/admin/projects/:project_id/stack
+func (h *AdminHandler) DeleteProjectStack(c *gin.Context) {
+	if err := h.svc.DeleteProjectStack(c.Request.Context(), c.Param("project_id")); err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"platform/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+const oidcStateCookie = "oidc_state"
+
+// AuthHandler 处理 OIDC 授权码登录流程
+type AuthHandler struct {
+	provider *auth.OIDCProvider
+}
+
+func NewAuthHandler(provider *auth.OIDCProvider) *AuthHandler {
+	return &AuthHandler{provider: provider}
+}
+
+// Login GET /api/v1/auth/login
+// 生成 state 并重定向到 IdP 登录页
+func (h *AuthHandler) Login(c *gin.Context) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	state := hex.EncodeToString(buf)
+
+	redirect, err := h.provider.AuthCodeURL(c.Request.Context(), state)
+	if err != nil {
+		respondError(c, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, 600, "/api/v1/auth", "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, redirect)
+}
+
+// Callback GET /api/v1/auth/callback?code=...&state=...
+// 校验 state，用授权码换取 token，返回 id_token 供后续请求作为 Bearer 使用
+func (h *AuthHandler) Callback(c *gin.Context) {
+	if errMsg := c.Query("error"); errMsg != "" {
+		respondErrorWithDetails(c, http.StatusUnauthorized, errors.New("login failed"), errMsg+": "+c.Query("error_description"))
+		return
+	}
+
+	state, err := c.Cookie(oidcStateCookie)
+	if err != nil || state == "" || state != c.Query("state") {
+		respondError(c, http.StatusBadRequest, errors.New("invalid login state"))
+		return
+	}
+	c.SetCookie(oidcStateCookie, "", -1, "/api/v1/auth", "", c.Request.TLS != nil, true)
+
+	code := c.Query("code")
+	if code == "" {
+		respondErrorWithDetails(c, http.StatusBadRequest, ErrInvalidRequest, "code is required")
+		return
+	}
+
+	token, err := h.provider.Exchange(c.Request.Context(), code)
+	if err != nil {
+		respondError(c, http.StatusBadGateway, err)
+		return
+	}
+
+	ident, err := h.provider.Verify(c.Request.Context(), token.IDToken)
+	if err != nil {
+		respondError(c, http.StatusUnauthorized, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, LoginResponse{
+		IDToken:      token.IDToken,
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		ExpiresAt:    formatTime(ident.ExpiresAt),
+		User:         ident,
+	})
+}
+
+// Me GET /api/v1/auth/me
+func (h *AuthHandler) Me(c *gin.Context) {
+	c.JSON(http.StatusOK, identityFrom(c))
+}
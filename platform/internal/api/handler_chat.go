@@ -2,11 +2,14 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"platform/internal/eventbus"
+	"platform/internal/featureflag"
 	"platform/internal/service"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -26,8 +29,7 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 	sessionID := c.Param("id")
 
 	var req ChatRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		respondErrorWithDetails(c, http.StatusBadRequest, ErrInvalidRequest, err.Error())
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -43,6 +45,66 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 	})
 }
 
+const (
+	defaultChatSyncTimeout = 2 * time.Minute
+	maxChatSyncTimeout     = 10 * time.Minute
+)
+
+// ChatAction POST /api/v1/sessions/:id/chat:<action>
+// gin 不支持路径段内的字面冒号，chat:sync 按参数路由后在这里分发
+func (h *ChatHandler) ChatAction(c *gin.Context) {
+	if c.Param("action") != ":sync" {
+		respondError(c, http.StatusNotFound, fmt.Errorf("unknown chat action %q", c.Param("action")))
+		return
+	}
+	h.SendMessageSync(c)
+}
+
+// SendMessageSync POST /api/v1/sessions/:id/chat:sync?timeout=120s
+// 发送消息并等待 Agent 完成，返回最终回答和工具调用摘要，适合不需要流式输出的 CLI / CI 集成
+func (h *ChatHandler) SendMessageSync(c *gin.Context) {
+	sessionID := c.Param("id")
+
+	var req ChatRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	timeout, ok := queryTimeout(c, defaultChatSyncTimeout, maxChatSyncTimeout)
+	if !ok {
+		return
+	}
+
+	result, err := h.svc.SendMessageSync(c.Request.Context(), sessionID, req.Message, timeout)
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// queryTimeout 解析 timeout 查询参数并按超时放宽写截止时间，参数无效时已写入 400 响应
+func queryTimeout(c *gin.Context, def, max time.Duration) (time.Duration, bool) {
+	timeout := def
+	if raw := c.Query("timeout"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 || d > max {
+			respondError(c, http.StatusBadRequest, fmt.Errorf("invalid timeout %q (expected a duration up to %s)", raw, max))
+			return 0, false
+		}
+		timeout = d
+	}
+
+	// 等待时间可能超过 http.Server.WriteTimeout，按本次请求的超时放宽写截止时间
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetWriteDeadline(time.Now().Add(timeout + 10*time.Second)); err != nil {
+		slog.Warn("Failed to extend write deadline", "error", err)
+	}
+	return timeout, true
+}
+
 // StreamEvents GET /api/v1/sessions/:id/stream
 // 通过 SSE 向客户端推送 Session 事件流
 func (h *ChatHandler) StreamEvents(c *gin.Context) {
@@ -68,6 +130,33 @@ func (h *ChatHandler) StreamEvents(c *gin.Context) {
 		slog.Warn("Failed to disable write deadline for SSE", "error", err)
 	}
 
+	// 开启 coalesced_streaming 时一次写出所有已到达的事件，c.Stream 每轮回调后 flush 一次
+	coalesce := h.svc.SessionFeatureEnabled(c.Request.Context(), sessionID, featureflag.CoalescedStreaming)
+
+	// writeEvent 写出一个事件，返回 false 表示应结束 SSE 连接
+	writeEvent := func(event eventbus.Event) bool {
+		// stream.done 内部信号，关闭 SSE 连接
+		if event.Type == eventbus.EventStreamDone {
+			return false
+		}
+
+		sseEvent := SSEEvent{
+			Type:      string(event.Type),
+			SessionID: event.SessionID,
+			Payload:   event.Payload,
+			Timestamp: formatTime(event.Timestamp),
+		}
+
+		data, err := json.Marshal(sseEvent)
+		if err != nil {
+			return false
+		}
+
+		c.SSEvent("message", string(data))
+		eventbus.ObserveDeliveryLag(eventbus.StageEmitted, event)
+		return true
+	}
+
 	c.Stream(func(w io.Writer) bool {
 		select {
 		case event, ok := <-eventCh:
@@ -75,25 +164,19 @@ func (h *ChatHandler) StreamEvents(c *gin.Context) {
 				// 事件通道关闭，结束 SSE 连接
 				return false
 			}
-
-			// stream.done 内部信号，关闭 SSE 连接
-			if event.Type == eventbus.EventStreamDone {
+			if !writeEvent(event) {
 				return false
 			}
-
-			sseEvent := SSEEvent{
-				Type:      string(event.Type),
-				SessionID: event.SessionID,
-				Payload:   event.Payload,
-				Timestamp: formatTime(event.Timestamp),
-			}
-
-			data, err := json.Marshal(sseEvent)
-			if err != nil {
-				return false
+			for coalesce {
+				select {
+				case event, ok := <-eventCh:
+					if !ok || !writeEvent(event) {
+						return false
+					}
+				default:
+					return true
+				}
 			}
-
-			c.SSEvent("message", string(data))
 			return true
 
 		case <-c.Request.Context().Done():
@@ -107,3 +190,52 @@ func (h *ChatHandler) StreamEvents(c *gin.Context) {
 		}
 	})
 }
+
+const (
+	defaultEventWaitTimeout = 30 * time.Second
+	maxEventWaitTimeout     = 5 * time.Minute
+)
+
+// WaitEvent GET /api/v1/sessions/:id/events/wait?type=agent.answer,agent.error&timeout=60s
+// 长轮询等待下一个匹配的事件并以 JSON 返回，适合不想维护 SSE 连接的脚本客户端。
+// 超时返回 408，客户端可直接重试
+func (h *ChatHandler) WaitEvent(c *gin.Context) {
+	sessionID := c.Param("id")
+
+	timeout, ok := queryTimeout(c, defaultEventWaitTimeout, maxEventWaitTimeout)
+	if !ok {
+		return
+	}
+
+	var types []eventbus.EventType
+	for _, t := range strings.Split(c.Query("type"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, eventbus.EventType(t))
+		}
+	}
+
+	event, err := h.svc.WaitForEvent(c.Request.Context(), sessionID, types, timeout)
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	eventbus.ObserveDeliveryLag(eventbus.StageEmitted, *event)
+	c.JSON(http.StatusOK, SSEEvent{
+		Type:      string(event.Type),
+		SessionID: event.SessionID,
+		Payload:   event.Payload,
+		Timestamp: formatTime(event.Timestamp),
+	})
+}
+
+// GetEventPayload 返回事件中 PayloadRef 引用的完整负载
+func (h *ChatHandler) GetEventPayload(c *gin.Context) {
+	data, err := h.svc.GetEventPayload(c.Request.Context(), c.Param("id"), c.Param("ref"))
+	if err != nil {
+		respondError(c, mapServiceError(err), err)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", data)
+}
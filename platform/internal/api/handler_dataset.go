@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+	"platform/internal/sandbox"
+	"platform/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DatasetHandler 列出 session 可按名称挂载的只读数据集
+type DatasetHandler struct {
+	svc *service.Service
+}
+
+func NewDatasetHandler(svc *service.Service) *DatasetHandler {
+	return &DatasetHandler{svc: svc}
+}
+
+// ListDatasets GET /api/v1/datasets
+func (h *DatasetHandler) ListDatasets(c *gin.Context) {
+	resp := DatasetListResponse{Datasets: []DatasetResponse{}}
+	for _, d := range h.svc.Datasets.List() {
+		resp.Datasets = append(resp.Datasets, DatasetResponse{
+			Name:          d.Name,
+			Description:   d.Description,
+			ContainerPath: d.Target(),
+			Mount:         sandbox.DatasetPrefix + d.Name,
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
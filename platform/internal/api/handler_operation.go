@@ -0,0 +1,38 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"platform/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OperationHandler 查询异步操作（终止、重启、同步等）的执行状态
+type OperationHandler struct {
+	svc *service.Service
+}
+
+func NewOperationHandler(svc *service.Service) *OperationHandler {
+	return &OperationHandler{svc: svc}
+}
+
+// GetOperation GET /api/v1/operations/:id
+func (h *OperationHandler) GetOperation(c *gin.Context) {
+	op, err := h.svc.GetOperation(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	// 已认证时只能查询自己 session 上的操作；session 已删除时不再校验
+	if ident := identityFrom(c); ident != nil && op.SessionID != "" {
+		if sess, err := h.svc.GetSession(c.Request.Context(), op.SessionID); err == nil && sess.UserID != ident.UserID {
+			respondError(c, http.StatusForbidden, errors.New("operation belongs to another user"))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, op)
+}
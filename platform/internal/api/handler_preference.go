@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"platform/internal/preference"
+	"platform/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PreferenceHandler struct {
+	svc *service.Service
+}
+
+func NewPreferenceHandler(svc *service.Service) *PreferenceHandler {
+	return &PreferenceHandler{svc: svc}
+}
+
+// GetPreferences GET /api/v1/users/:user_id/preferences
+func (h *PreferenceHandler) GetPreferences(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	prefs, err := h.svc.GetUserPreferences(c.Request.Context(), userID)
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferences PUT /api/v1/users/:user_id/preferences
+// 整体替换用户偏好
+func (h *PreferenceHandler) UpdatePreferences(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	var req UpdatePreferencesRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	prefs := &preference.Preferences{
+		UserID:          userID,
+		DefaultStrategy: mapStrategyType(req.DefaultStrategy),
+		DefaultImage:    req.DefaultImage,
+		DefaultEnvVars:  req.DefaultEnvVars,
+		Notifications: preference.NotificationSettings{
+			WebhookURL:     req.Notifications.WebhookURL,
+			Email:          req.Notifications.Email,
+			OnSessionReady: req.Notifications.OnSessionReady,
+			OnSessionError: req.Notifications.OnSessionError,
+		},
+	}
+
+	if err := h.svc.UpdateUserPreferences(c.Request.Context(), prefs); err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// DeletePreferences DELETE /api/v1/users/:user_id/preferences
+func (h *PreferenceHandler) DeletePreferences(c *gin.Context) {
+	userID := c.Param("user_id")
+
+	if err := h.svc.DeleteUserPreferences(c.Request.Context(), userID); err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "deleted",
+		"user_id": userID,
+	})
+}
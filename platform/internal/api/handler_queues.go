@@ -0,0 +1,279 @@
+package api
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"platform/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+)
+
+const queueTaskPageSize = 50
+
+// QueueHandler 处理 /admin/queues 下的队列面板和接口
+type QueueHandler struct {
+	svc *service.Service
+}
+
+func NewQueueHandler(svc *service.Service) *QueueHandler {
+	return &QueueHandler{svc: svc}
+}
+
+// ListQueues GET /admin/queues/api/queues
+func (h *QueueHandler) ListQueues(c *gin.Context) {
+	infos, err := h.svc.ListQueues()
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	out := make([]QueueInfoResponse, 0, len(infos))
+	for _, info := range infos {
+		out = append(out, newQueueInfoResponse(info))
+	}
+	c.JSON(http.StatusOK, gin.H{"queues": out})
+}
+
+// ListTasks GET /admin/queues/api/queues/:queue/tasks?state=retry&page=1
+func (h *QueueHandler) ListTasks(c *gin.Context) {
+	queue := c.Param("queue")
+	state := c.DefaultQuery("state", "pending")
+	page := queryPage(c)
+
+	tasks, err := h.svc.ListQueueTasks(queue, state, page, queueTaskPageSize)
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	out := make([]QueueTaskResponse, 0, len(tasks))
+	for _, t := range tasks {
+		out = append(out, newQueueTaskResponse(t))
+	}
+	c.JSON(http.StatusOK, gin.H{"queue": queue, "state": state, "page": page, "tasks": out})
+}
+
+// RunTask POST /admin/queues/api/queues/:queue/tasks/:task_id/run
+func (h *QueueHandler) RunTask(c *gin.Context) {
+	if err := h.svc.RunQueueTask(c.Param("queue"), c.Param("task_id")); err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteTask DELETE /admin/queues/api/queues/:queue/tasks/:task_id
+func (h *QueueHandler) DeleteTask(c *gin.Context) {
+	if err := h.svc.DeleteQueueTask(c.Param("queue"), c.Param("task_id")); err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Dashboard GET /admin/queues?queue=default&state=retry
+// 服务端渲染的只读面板：队列统计 + 选中队列某个状态的任务列表，运行/删除通过表单提交
+func (h *QueueHandler) Dashboard(c *gin.Context) {
+	infos, err := h.svc.ListQueues()
+	if err != nil {
+		c.String(mapServiceError(err), "failed to load queues: %v", err)
+		return
+	}
+
+	data := queueDashboardData{
+		States: service.QueueTaskStates,
+		Queue:  c.Query("queue"),
+		State:  c.DefaultQuery("state", "retry"),
+		Page:   queryPage(c),
+		Notice: c.Query("notice"),
+	}
+	for _, info := range infos {
+		data.Queues = append(data.Queues, newQueueInfoResponse(info))
+	}
+	if data.Queue == "" && len(infos) > 0 {
+		data.Queue = infos[0].Queue
+	}
+
+	if data.Queue != "" {
+		tasks, err := h.svc.ListQueueTasks(data.Queue, data.State, data.Page, queueTaskPageSize)
+		if err != nil {
+			data.Error = err.Error()
+		}
+		for _, t := range tasks {
+			data.Tasks = append(data.Tasks, newQueueTaskResponse(t))
+		}
+		data.HasNext = len(tasks) == queueTaskPageSize
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+	if err := queueDashboardTemplate.Execute(c.Writer, data); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// DashboardAction POST /admin/queues/actions/:queue/:task_id/:action
+// 面板表单提交的运行/删除操作，完成后重定向回面板
+func (h *QueueHandler) DashboardAction(c *gin.Context) {
+	queue := c.Param("queue")
+	taskID := c.Param("task_id")
+
+	var err error
+	switch c.Param("action") {
+	case "run":
+		err = h.svc.RunQueueTask(queue, taskID)
+	case "delete":
+		err = h.svc.DeleteQueueTask(queue, taskID)
+	default:
+		err = errors.New("invalid action")
+	}
+
+	notice := "ok: " + c.Param("action") + " " + taskID
+	if err != nil {
+		notice = "error: " + err.Error()
+	}
+	back := url.Values{
+		"queue":  {queue},
+		"state":  {c.PostForm("state")},
+		"notice": {notice},
+	}
+	c.Redirect(http.StatusSeeOther, "/admin/queues?"+back.Encode())
+}
+
+func queryPage(c *gin.Context) int {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		return 1
+	}
+	return page
+}
+
+func newQueueInfoResponse(info *asynq.QueueInfo) QueueInfoResponse {
+	return QueueInfoResponse{
+		Queue:          info.Queue,
+		Size:           info.Size,
+		Pending:        info.Pending,
+		Active:         info.Active,
+		Scheduled:      info.Scheduled,
+		Retry:          info.Retry,
+		Archived:       info.Archived,
+		Completed:      info.Completed,
+		ProcessedToday: info.Processed,
+		FailedToday:    info.Failed,
+		Paused:         info.Paused,
+		LatencyMs:      info.Latency.Milliseconds(),
+	}
+}
+
+func newQueueTaskResponse(t *asynq.TaskInfo) QueueTaskResponse {
+	return QueueTaskResponse{
+		ID:            t.ID,
+		Type:          t.Type,
+		State:         t.State.String(),
+		Payload:       string(t.Payload),
+		Retried:       t.Retried,
+		MaxRetry:      t.MaxRetry,
+		LastError:     t.LastErr,
+		LastFailedAt:  formatOptionalTime(t.LastFailedAt),
+		NextProcessAt: formatOptionalTime(t.NextProcessAt),
+	}
+}
+
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return formatTime(t)
+}
+
+type queueDashboardData struct {
+	Queues  []QueueInfoResponse
+	States  []string
+	Queue   string
+	State   string
+	Page    int
+	HasNext bool
+	Tasks   []QueueTaskResponse
+	Notice  string
+	Error   string
+}
+
+var queueDashboardTemplate = template.Must(template.New("queues").Funcs(template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+	"dec": func(i int) int { return i - 1 },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Queues - Agent Platform</title>
+<style>
+body { font-family: -apple-system, sans-serif; margin: 24px; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 24px; font-size: 13px; }
+th, td { border: 1px solid #ddd; padding: 6px 8px; text-align: left; vertical-align: top; }
+th { background: #f5f5f5; }
+td.num { text-align: right; }
+nav a { margin-right: 12px; }
+nav a.current { font-weight: bold; }
+pre { margin: 0; max-width: 480px; white-space: pre-wrap; word-break: break-all; }
+.notice { padding: 8px; background: #eef6ee; margin-bottom: 16px; }
+.error { padding: 8px; background: #fbeaea; margin-bottom: 16px; }
+form { display: inline; }
+</style>
+</head>
+<body>
+<h1>Queues</h1>
+{{if .Notice}}<div class="notice">{{.Notice}}</div>{{end}}
+<table>
+<tr><th>Queue</th><th>Size</th><th>Pending</th><th>Active</th><th>Scheduled</th><th>Retry</th><th>Archived</th><th>Completed</th><th>Processed today</th><th>Failed today</th><th>Latency</th><th>Paused</th></tr>
+{{range .Queues}}
+<tr>
+<td><a href="?queue={{.Queue}}&state={{$.State}}">{{.Queue}}</a></td>
+<td class="num">{{.Size}}</td><td class="num">{{.Pending}}</td><td class="num">{{.Active}}</td>
+<td class="num">{{.Scheduled}}</td><td class="num">{{.Retry}}</td><td class="num">{{.Archived}}</td>
+<td class="num">{{.Completed}}</td><td class="num">{{.ProcessedToday}}</td><td class="num">{{.FailedToday}}</td>
+<td class="num">{{.LatencyMs}} ms</td><td>{{.Paused}}</td>
+</tr>
+{{else}}
+<tr><td colspan="12">No queues yet</td></tr>
+{{end}}
+</table>
+{{if .Queue}}
+<h2>{{.Queue}} / {{.State}}</h2>
+<nav>{{range .States}}<a href="?queue={{$.Queue}}&state={{.}}"{{if eq . $.State}} class="current"{{end}}>{{.}}</a>{{end}}</nav>
+{{if .Error}}<div class="error">{{.Error}}</div>{{end}}
+<table>
+<tr><th>ID</th><th>Type</th><th>Payload</th><th>Retried</th><th>Last error</th><th>Last failed</th><th>Next run</th><th></th></tr>
+{{range .Tasks}}
+<tr>
+<td>{{.ID}}</td><td>{{.Type}}</td><td><pre>{{.Payload}}</pre></td>
+<td class="num">{{.Retried}}/{{.MaxRetry}}</td><td>{{.LastError}}</td><td>{{.LastFailedAt}}</td><td>{{.NextProcessAt}}</td>
+<td>
+{{if ne $.State "active"}}
+{{if ne $.State "pending"}}{{if ne $.State "completed"}}<form method="post" action="/admin/queues/actions/{{$.Queue}}/{{.ID}}/run"><input type="hidden" name="state" value="{{$.State}}"><button>Run</button></form>{{end}}{{end}}
+<form method="post" action="/admin/queues/actions/{{$.Queue}}/{{.ID}}/delete" onsubmit="return confirm('Delete task {{.ID}}?')"><input type="hidden" name="state" value="{{$.State}}"><button>Delete</button></form>
+{{end}}
+</td>
+</tr>
+{{else}}
+<tr><td colspan="8">No {{.State}} tasks</td></tr>
+{{end}}
+</table>
+<nav>
+{{if gt .Page 1}}<a href="?queue={{.Queue}}&state={{.State}}&page={{dec .Page}}">&laquo; Prev</a>{{end}}
+{{if .HasNext}}<a href="?queue={{.Queue}}&state={{.State}}&page={{inc .Page}}">Next &raquo;</a>{{end}}
+</nav>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"platform/internal/service"
+)
+
+func TestQueueDashboardTemplate(t *testing.T) {
+	var buf bytes.Buffer
+	err := queueDashboardTemplate.Execute(&buf, queueDashboardData{
+		Queues: []QueueInfoResponse{{Queue: "default", Size: 3, Retry: 1}},
+		States: service.QueueTaskStates,
+		Queue:  "default",
+		State:  "retry",
+		Page:   2,
+		Tasks: []QueueTaskResponse{{
+			ID:        "task-1",
+			Type:      "session:create",
+			Payload:   `{"session_id":"<script>"}`,
+			LastError: "boom",
+		}},
+		Notice: "ok: run task-0",
+	})
+	if err != nil {
+		t.Fatalf("Template execution failed: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"/admin/queues/actions/default/task-1/run",
+		"/admin/queues/actions/default/task-1/delete",
+		"&lt;script&gt;",
+		"&laquo; Prev",
+		"ok: run task-0",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in dashboard output", want)
+		}
+	}
+	if strings.Contains(out, "<script>") {
+		t.Error("Payload must be escaped")
+	}
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"platform/internal/auth"
+	"platform/internal/service"
+	"platform/internal/serviceaccount"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRotateBodySize 轮换请求体的上限，请求体只有两个时长字段
+const maxRotateBodySize = 4 << 10
+
+// ServiceAccountHandler 处理 /api/v1/admin/service-accounts 下的服务账号管理接口
+type ServiceAccountHandler struct {
+	svc *service.Service
+}
+
+func NewServiceAccountHandler(svc *service.Service) *ServiceAccountHandler {
+	return &ServiceAccountHandler{svc: svc}
+}
+
+// Create POST /api/v1/admin/service-accounts
+func (h *ServiceAccountHandler) Create(c *gin.Context) {
+	var req CreateServiceAccountRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ttl, _ := parseOptionalDuration(req.ExpiresIn)
+
+	scopes := make([]auth.Scope, 0, len(req.Scopes))
+	for _, s := range req.Scopes {
+		scopes = append(scopes, auth.Scope(s))
+	}
+
+	account, token, err := h.svc.CreateServiceAccount(c.Request.Context(), serviceaccount.CreateParams{
+		Name:   req.Name,
+		UserID: req.UserID,
+		Scopes: scopes,
+		TTL:    ttl,
+	})
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, ServiceAccountTokenResponse{Account: account, Token: token})
+}
+
+// List GET /api/v1/admin/service-accounts
+func (h *ServiceAccountHandler) List(c *gin.Context) {
+	accounts, err := h.svc.ListServiceAccounts(c.Request.Context())
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"service_accounts": accounts})
+}
+
+// Get GET /api/v1/admin/service-accounts/:id
+func (h *ServiceAccountHandler) Get(c *gin.Context) {
+	account, err := h.svc.GetServiceAccount(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// Rotate POST /api/v1/admin/service-accounts/:id/rotate
+// 签发新令牌，grace_period 内旧令牌仍可使用
+func (h *ServiceAccountHandler) Rotate(c *gin.Context) {
+	// 请求体可省略。先读出请求体再判断是否为空：分块传输时 ContentLength 为 -1，不能据此判断
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRotateBodySize)
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			respondErrorWithDetails(c, http.StatusRequestEntityTooLarge, ErrInvalidRequest, "request body too large")
+			return
+		}
+		respondErrorWithDetails(c, http.StatusBadRequest, ErrInvalidRequest, "failed to read request body: "+err.Error())
+		return
+	}
+	var req RotateServiceAccountRequest
+	if len(bytes.TrimSpace(body)) > 0 {
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if !bindJSON(c, &req) {
+			return
+		}
+	}
+
+	grace, _ := parseOptionalDuration(req.GracePeriod)
+	ttl, _ := parseOptionalDuration(req.ExpiresIn)
+
+	account, token, err := h.svc.RotateServiceAccountToken(c.Request.Context(), c.Param("id"), grace, ttl)
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ServiceAccountTokenResponse{Account: account, Token: token})
+}
+
+// Delete DELETE /api/v1/admin/service-accounts/:id
+func (h *ServiceAccountHandler) Delete(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.svc.DeleteServiceAccount(c.Request.Context(), id); err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "deleted",
+		"id":     id,
+	})
+}
+
+// parseOptionalDuration 解析已通过 validateDuration 校验的可选时长
+func parseOptionalDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// chunkedBody 隐藏具体类型，httptest 不会据此推断 ContentLength
+type chunkedBody struct{ io.Reader }
+
+func TestRotateRejectsBadBodies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name string
+		body io.Reader
+		want int
+	}{
+		{"too large", strings.NewReader(`{"grace_period":"` + strings.Repeat("1", maxRotateBodySize) + `s"}`), http.StatusRequestEntityTooLarge},
+		{"too large chunked", chunkedBody{strings.NewReader(strings.Repeat(" ", maxRotateBodySize+1))}, http.StatusRequestEntityTooLarge},
+		{"invalid duration chunked", chunkedBody{strings.NewReader(`{"grace_period":"soon"}`)}, http.StatusBadRequest},
+		{"malformed", strings.NewReader(`{`), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/:id/rotate", (&ServiceAccountHandler{}).Rotate)
+
+			req := httptest.NewRequest(http.MethodPost, "/sa-1/rotate", tt.body)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
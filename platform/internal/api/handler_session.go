@@ -1,13 +1,24 @@
 package api
 
 import (
+	"bufio"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"mime"
 	"net/http"
+	"os"
+	"path/filepath"
 	"platform/internal/agentproto"
 	"platform/internal/orchestrator"
 	"platform/internal/service"
 	"platform/internal/session"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -30,6 +41,7 @@ func (h *SessionHandler) ListSessions(c *gin.Context) {
 			respondError(c, http.StatusInternalServerError, err)
 			return
 		}
+		sessions = filterOwnSessions(c, sessions)
 
 		var resp []SessionResponse
 		for _, sess := range sessions {
@@ -39,9 +51,14 @@ func (h *SessionHandler) ListSessions(c *gin.Context) {
 				UserID:      sess.UserID,
 				ContainerID: sess.ContainerID,
 				NodeIP:      sess.NodeIP,
+				NodeID:      sess.NodeID,
 				Status:      string(sess.Status),
 				Strategy:    string(sess.Strategy),
 				CreatedAt:   formatTime(sess.CreatedAt),
+				ActiveAt:    formatTime(sess.ActiveAt),
+
+				EffectiveStrategy: string(sess.ContainerStrategy()),
+				ParentSessionID:   sess.ParentSessionID,
 			})
 		}
 
@@ -55,6 +72,7 @@ func (h *SessionHandler) ListSessions(c *gin.Context) {
 		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
+	sessions = filterOwnSessions(c, sessions)
 
 	var resp []SessionResponse
 	for _, sess := range sessions {
@@ -64,9 +82,14 @@ func (h *SessionHandler) ListSessions(c *gin.Context) {
 			UserID:      sess.UserID,
 			ContainerID: sess.ContainerID,
 			NodeIP:      sess.NodeIP,
+			NodeID:      sess.NodeID,
 			Status:      string(sess.Status),
 			Strategy:    string(sess.Strategy),
 			CreatedAt:   formatTime(sess.CreatedAt),
+			ActiveAt:    formatTime(sess.ActiveAt),
+
+			EffectiveStrategy: string(sess.ContainerStrategy()),
+			ParentSessionID:   sess.ParentSessionID,
 		})
 	}
 
@@ -75,8 +98,19 @@ func (h *SessionHandler) ListSessions(c *gin.Context) {
 
 func (h *SessionHandler) CreateSession(c *gin.Context) {
 	var req CreateSessionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		respondErrorWithDetails(c, http.StatusBadRequest, ErrInvalidRequest, err.Error())
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if ident := identityFrom(c); ident != nil {
+		req.UserID = ident.UserID
+	} else if req.UserID == "" {
+		respondErrorWithDetails(c, http.StatusBadRequest, ErrInvalidRequest, "user_id is required")
+		return
+	}
+	priority, err := orchestrator.ParsePriority(req.Priority)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -89,12 +123,29 @@ func (h *SessionHandler) CreateSession(c *gin.Context) {
 			Image:     req.Image,
 			ProjectID: req.ProjectID,
 			EnvVars:   req.EnvVars,
+
+			NetworkPolicy: req.NetworkPolicy,
+			GPUCount:      req.GPUCount,
+			GPUDeviceIDs:  req.GPUDeviceIDs,
+			Mounts:        req.Mounts,
+			Priority:      priority,
 		},
+		SnapshotID: req.SnapshotID,
+		Fallback:   req.Fallback,
+	}
+	if req.GitURL != "" {
+		params.Git = &session.GitSource{
+			URL:      req.GitURL,
+			Ref:      req.GitRef,
+			Username: req.GitUsername,
+			Token:    req.GitToken,
+		}
 	}
 
 	sess, err := h.svc.CreateSession(c.Request.Context(), params)
 	if err != nil {
-		respondError(c, http.StatusInternalServerError, err)
+		status := mapServiceError(err)
+		respondError(c, status, err)
 		return
 	}
 
@@ -108,6 +159,21 @@ func (h *SessionHandler) CreateSession(c *gin.Context) {
 	})
 }
 
+// filterOwnSessions 已认证时只保留当前用户的 session
+func filterOwnSessions(c *gin.Context, sessions []*session.Session) []*session.Session {
+	ident := identityFrom(c)
+	if ident == nil {
+		return sessions
+	}
+	own := sessions[:0]
+	for _, sess := range sessions {
+		if sess.UserID == ident.UserID {
+			own = append(own, sess)
+		}
+	}
+	return own
+}
+
 func (h *SessionHandler) GetSession(c *gin.Context) {
 	id := c.Param("id")
 
@@ -124,13 +190,20 @@ func (h *SessionHandler) GetSession(c *gin.Context) {
 		UserID:      sess.UserID,
 		ContainerID: sess.ContainerID,
 		NodeIP:      sess.NodeIP,
+		NodeID:      sess.NodeID,
 		Status:      string(sess.Status),
 		Strategy:    string(sess.Strategy),
 		CreatedAt:   formatTime(sess.CreatedAt),
+		ActiveAt:    formatTime(sess.ActiveAt),
+
+		EffectiveStrategy: string(sess.ContainerStrategy()),
+		ParentSessionID:   sess.ParentSessionID,
+		Queue:             h.svc.SessionQueuePosition(c.Request.Context(), sess),
 	})
 }
 
-// 立即返回响应，在后台 goroutine 中执行容器清理，避免 CLI 退出延迟。
+// 立即返回 200，在后台执行容器清理，避免 CLI 退出延迟。
+// 客户端可通过返回的 operation 查询清理结果。
 func (h *SessionHandler) TerminateSession(c *gin.Context) {
 	id := c.Param("id")
 
@@ -140,27 +213,24 @@ func (h *SessionHandler) TerminateSession(c *gin.Context) {
 		return
 	}
 
-	// 立即返回
+	op, err := h.svc.TerminateSessionAsync(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":     "terminating",
 		"session_id": id,
+		"operation":  op,
 	})
-
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-		if err := h.svc.TerminateSession(ctx, id); err != nil {
-			slog.Error("Background terminate failed", "session_id", id, "error", err)
-		}
-	}()
 }
 
 func (h *SessionHandler) ConfigureAgent(c *gin.Context) {
 	id := c.Param("id")
 
 	var req ConfigureAgentRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		respondErrorWithDetails(c, http.StatusBadRequest, ErrInvalidRequest, err.Error())
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -193,7 +263,7 @@ func (h *SessionHandler) ConfigureAgent(c *gin.Context) {
 	})
 }
 
-// 立即返回响应，在后台 goroutine 中执行 gRPC Stop
+// 立即返回 200，在后台执行 gRPC Stop，可通过返回的 operation 查询结果
 func (h *SessionHandler) StopAgent(c *gin.Context) {
 	id := c.Param("id")
 
@@ -203,26 +273,49 @@ func (h *SessionHandler) StopAgent(c *gin.Context) {
 		return
 	}
 
-	// 立即返回
+	op, err := h.svc.StopAgentAsync(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success":    true,
 		"message":    "Stop signal sent",
 		"session_id": id,
+		"operation":  op,
 	})
-
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-		defer cancel()
-		if _, err := h.svc.StopAgent(ctx, id); err != nil {
-			slog.Error("Background stop agent failed", "session_id", id, "error", err)
-		}
-	}()
 }
 
+// RestartSession POST /sessions/:id/restart[?async=true][&fresh=true]
+// async=true 时立即返回 202 和 operation，否则等待重启完成；
+// fresh=true 时不原地启动原容器，而是保存工作区快照后重新分配容器
 func (h *SessionHandler) RestartSession(c *gin.Context) {
 	id := c.Param("id")
+	fresh := c.Query("fresh") == "true"
+
+	if c.Query("async") == "true" {
+		if _, err := h.svc.GetSession(c.Request.Context(), id); err != nil {
+			status := mapServiceError(err)
+			respondError(c, status, err)
+			return
+		}
+
+		op, err := h.svc.RestartSessionAsync(c.Request.Context(), id, fresh)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"status":     "restarting",
+			"session_id": id,
+			"operation":  op,
+		})
+		return
+	}
 
-	if err := h.svc.RestartSession(c.Request.Context(), id); err != nil {
+	if err := h.svc.RestartSession(c.Request.Context(), id, fresh); err != nil {
 		status := mapServiceError(err)
 		respondError(c, status, err)
 		return
@@ -235,6 +328,131 @@ func (h *SessionHandler) RestartSession(c *gin.Context) {
 	})
 }
 
+// ForkSession POST /sessions/:id/fork
+// 以 session 当前的工作区和 Agent 配置创建一个新 session，立即返回初始化中的新 session
+func (h *SessionHandler) ForkSession(c *gin.Context) {
+	sess, err := h.svc.ForkSession(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toSessionResponse(sess))
+}
+
+// Hibernate 将 session 容器保存为检查点并停止，需要 Docker experimental 与 CRIU
+func (h *SessionHandler) Hibernate(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.svc.HibernateSession(c.Request.Context(), id); err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "hibernated",
+		"session_id": id,
+	})
+}
+
+// Pause 冻结 session 容器，恢复前对话和命令执行返回 409
+func (h *SessionHandler) Pause(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.svc.PauseSession(c.Request.Context(), id); err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "paused",
+		"session_id": id,
+	})
+}
+
+// Pauses 返回 session 的暂停时间段，用于用量统计
+func (h *SessionHandler) Pauses(c *gin.Context) {
+	id := c.Param("id")
+
+	usage, err := h.svc.GetPauseUsage(c.Request.Context(), id)
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// Quota 返回 session 及其租户的伴随服务 / compose 服务配额和当前占用
+func (h *SessionHandler) Quota(c *gin.Context) {
+	id := c.Param("id")
+
+	status, err := h.svc.GetQuota(c.Request.Context(), id)
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Resume 恢复已暂停的 session，或从休眠检查点恢复 session 容器
+func (h *SessionHandler) Resume(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.svc.ResumeSession(c.Request.Context(), id); err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "resumed",
+		"session_id": id,
+	})
+}
+
+// Snapshot 将 session 工作区保存为内容寻址的快照
+func (h *SessionHandler) Snapshot(c *gin.Context) {
+	id := c.Param("id")
+
+	info, err := h.svc.SnapshotWorkspace(c.Request.Context(), id)
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, info)
+}
+
+// RestoreSnapshot 用快照替换 session 工作区的内容
+func (h *SessionHandler) RestoreSnapshot(c *gin.Context) {
+	id := c.Param("id")
+
+	var req RestoreSnapshotRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.svc.RestoreWorkspace(c.Request.Context(), id, req.SnapshotID); err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":      "restored",
+		"session_id":  id,
+		"snapshot_id": req.SnapshotID,
+	})
+}
+
 func (h *SessionHandler) SyncFiles(c *gin.Context) {
 	id := c.Param("id")
 
@@ -242,6 +460,28 @@ func (h *SessionHandler) SyncFiles(c *gin.Context) {
 	// Body is optional, allow empty JSON
 	_ = c.ShouldBindJSON(&req)
 
+	// async=true 时立即返回 202 和 operation，否则等待同步完成
+	if c.Query("async") == "true" {
+		if _, err := h.svc.GetSession(c.Request.Context(), id); err != nil {
+			status := mapServiceError(err)
+			respondError(c, status, err)
+			return
+		}
+
+		op, err := h.svc.SyncFilesToHostAsync(c.Request.Context(), id, req.SrcPath, req.DestPath)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"status":     "syncing",
+			"session_id": id,
+			"operation":  op,
+		})
+		return
+	}
+
 	if err := h.svc.SyncFilesToHost(c.Request.Context(), id, req.SrcPath, req.DestPath); err != nil {
 		status := mapServiceError(err)
 		respondError(c, status, err)
@@ -255,11 +495,44 @@ func (h *SessionHandler) SyncFiles(c *gin.Context) {
 	})
 }
 
+// SyncUp POST /api/v1/sessions/:id/sync-up
+// 将宿主机项目目录增量同步到容器工作区，只传输变化的文件
+func (h *SessionHandler) SyncUp(c *gin.Context) {
+	id := c.Param("id")
+
+	var req SyncUpRequest
+	// Body is optional, allow empty JSON
+	_ = c.ShouldBindJSON(&req)
+
+	result, err := h.svc.SyncToContainer(c.Request.Context(), id, req.Path, req.Delete)
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "synced",
+		"session_id": id,
+		"result":     result,
+	})
+}
+
+// ListFiles GET /sessions/:id/files?path=&recursive=&glob=&offset=&limit=
 func (h *SessionHandler) ListFiles(c *gin.Context) {
 	id := c.Param("id")
-	path := c.DefaultQuery("path", "")
+	var q ListFilesQuery
+	if !bindQuery(c, &q) {
+		return
+	}
 
-	output, err := h.svc.ListContainerFiles(c.Request.Context(), id, path)
+	list, err := h.svc.ListContainerFiles(c.Request.Context(), id, service.ListFilesOptions{
+		Path:      q.Path,
+		Recursive: q.Recursive,
+		Glob:      q.Glob,
+		Offset:    q.Offset,
+		Limit:     q.Limit,
+	})
 	if err != nil {
 		status := mapServiceError(err)
 		respondError(c, status, err)
@@ -267,19 +540,104 @@ func (h *SessionHandler) ListFiles(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, FilesListResponse{
-		SessionID: id,
-		Output:    output,
+		SessionID:  id,
+		Path:       list.Path,
+		Files:      list.Files,
+		Total:      list.Total,
+		NextOffset: list.NextOffset,
+	})
+}
+
+// DownloadArchive GET /api/v1/sessions/:id/files/archive?path=output
+// 以 tar.gz 下载工作区中的目录，path 为空时下载整个工作区
+func (h *SessionHandler) DownloadArchive(c *gin.Context) {
+	id := c.Param("id")
+
+	archive, err := h.svc.ArchiveContainerFiles(c.Request.Context(), id, c.Query("path"))
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	defer archive.Close()
+
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		slog.Warn("Failed to disable write deadline for archive download", "error", err)
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": archive.Name}))
+	c.Header("X-Uncompressed-Size", strconv.FormatInt(archive.Size, 10))
+	c.Status(http.StatusOK)
+	c.Writer.Header().Set("Content-Type", "application/gzip")
+	if _, err := io.Copy(c.Writer, archive); err != nil {
+		// 响应头已发送，只能中断连接，客户端会收到不完整的 gzip 流
+		slog.Warn("Workspace archive download aborted", "session_id", id, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// WatchFiles GET /api/v1/sessions/:id/files/watch?path=src
+// 以 SSE 推送工作区文件变更，事件名为 create / modify / delete，data 为 sandbox.FileEvent
+func (h *SessionHandler) WatchFiles(c *gin.Context) {
+	id := c.Param("id")
+
+	eventCh, err := h.svc.WatchContainerFiles(c.Request.Context(), id, c.Query("path"))
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("X-Accel-Buffering", "no")
+
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		slog.Warn("Failed to disable write deadline for SSE", "error", err)
+	}
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-eventCh:
+			if !ok {
+				// 监听结束（容器不可用等），关闭连接由客户端决定是否重连
+				return false
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				return false
+			}
+			c.SSEvent(string(event.Op), string(data))
+			return true
+
+		case <-c.Request.Context().Done():
+			return false
+
+		case <-time.After(30 * time.Second):
+			c.SSEvent("ping", "")
+			return true
+		}
 	})
 }
 
+// ReadFile GET /api/v1/sessions/:id/files/read?path=main.py&encoding=base64
+// 以 JSON 返回文件内容，二进制文件应使用 encoding=base64 或 /files/raw
 func (h *SessionHandler) ReadFile(c *gin.Context) {
 	id := c.Param("id")
 	path := c.Query("path")
+	encoding := c.DefaultQuery("encoding", "utf-8")
 
 	if path == "" {
 		respondErrorWithDetails(c, http.StatusBadRequest, ErrInvalidRequest, "path query parameter required")
 		return
 	}
+	if encoding != "utf-8" && encoding != "base64" {
+		respondErrorWithDetails(c, http.StatusBadRequest, ErrInvalidRequest, "encoding must be utf-8 or base64")
+		return
+	}
 
 	content, err := h.svc.ReadContainerFile(c.Request.Context(), id, path)
 	if err != nil {
@@ -288,10 +646,152 @@ func (h *SessionHandler) ReadFile(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, FileContentResponse{
+	resp := FileContentResponse{
 		SessionID: id,
 		Path:      path,
 		Content:   string(content),
+	}
+	if encoding == "base64" {
+		resp.Content = base64.StdEncoding.EncodeToString(content)
+		resp.Encoding = encoding
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RawFile GET /api/v1/sessions/:id/files/raw?path=out/plot.png
+// 以原始字节流返回文件，按扩展名或内容推断 Content-Type，支持单区间 Range 请求
+func (h *SessionHandler) RawFile(c *gin.Context) {
+	id := c.Param("id")
+	filePath := c.Query("path")
+
+	if filePath == "" {
+		respondErrorWithDetails(c, http.StatusBadRequest, ErrInvalidRequest, "path query parameter required")
+		return
+	}
+
+	f, err := h.svc.OpenContainerFile(c.Request.Context(), id, filePath)
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	defer f.Close()
+
+	br := bufio.NewReaderSize(f, 512)
+	contentType := mime.TypeByExtension(filepath.Ext(f.Name))
+	if contentType == "" {
+		head, _ := br.Peek(512)
+		contentType = http.DetectContentType(head)
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("Accept-Ranges", "bytes")
+	header.Set("Last-Modified", f.ModTime.UTC().Format(http.TimeFormat))
+	header.Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": f.Name}))
+
+	status := http.StatusOK
+	var body io.Reader = br
+	length := f.Size
+	r, ok, err := parseByteRange(c.GetHeader("Range"), f.Size)
+	switch {
+	case err != nil:
+		header.Set("Content-Range", fmt.Sprintf("bytes */%d", f.Size))
+		respondErrorWithDetails(c, http.StatusRequestedRangeNotSatisfiable, ErrInvalidRequest, err.Error())
+		return
+	case ok:
+		if _, err := io.CopyN(io.Discard, br, r.start); err != nil {
+			respondError(c, http.StatusInternalServerError, err)
+			return
+		}
+		status = http.StatusPartialContent
+		length = r.length()
+		body = io.LimitReader(br, length)
+		header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", r.start, r.end, f.Size))
+	}
+	header.Set("Content-Length", strconv.FormatInt(length, 10))
+
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		slog.Warn("Failed to disable write deadline for file download", "error", err)
+	}
+
+	c.Status(status)
+	if c.Request.Method == http.MethodHead {
+		return
+	}
+	if _, err := io.Copy(c.Writer, body); err != nil {
+		slog.Warn("Raw file download aborted", "session_id", id, "path", filePath, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// maxFileUploadSize PUT /files 单个文件的大小上限
+const maxFileUploadSize = 512 << 20
+
+// WriteFile PUT /api/v1/sessions/:id/files?path=src/main.py&mode=0755
+// 请求体为文件原始内容，或 multipart 表单的 file 字段；multipart 上传时 path 为空或以 / 结尾则使用上传的文件名
+func (h *SessionHandler) WriteFile(c *gin.Context) {
+	id := c.Param("id")
+	filePath := c.Query("path")
+
+	var perm os.FileMode
+	if mode := c.Query("mode"); mode != "" {
+		v, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil || v > 0777 {
+			respondErrorWithDetails(c, http.StatusBadRequest, ErrInvalidRequest, "mode must be an octal permission such as 0644")
+			return
+		}
+		perm = os.FileMode(v)
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxFileUploadSize)
+
+	var body io.Reader = c.Request.Body
+	size := c.Request.ContentLength
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			respondErrorWithDetails(c, http.StatusBadRequest, ErrInvalidRequest, "multipart upload requires a file field: "+err.Error())
+			return
+		}
+		if filePath == "" || strings.HasSuffix(filePath, "/") {
+			filePath += header.Filename
+		}
+		f, err := header.Open()
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, err)
+			return
+		}
+		defer f.Close()
+		body, size = f, header.Size
+	}
+
+	if filePath == "" {
+		respondErrorWithDetails(c, http.StatusBadRequest, ErrInvalidRequest, "path query parameter required")
+		return
+	}
+	if size > maxFileUploadSize {
+		respondErrorWithDetails(c, http.StatusRequestEntityTooLarge, ErrInvalidRequest, "file exceeds the upload size limit")
+		return
+	}
+
+	written, err := h.svc.WriteContainerFile(c.Request.Context(), id, filePath, body, size, perm)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			respondErrorWithDetails(c, http.StatusRequestEntityTooLarge, ErrInvalidRequest, "file exceeds the upload size limit")
+			return
+		}
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, FileWriteResponse{
+		SessionID: id,
+		Path:      filePath,
+		Size:      written,
 	})
 }
 
@@ -335,9 +835,67 @@ func (h *SessionHandler) WaitReady(c *gin.Context) {
 		UserID:      sess.UserID,
 		ContainerID: sess.ContainerID,
 		NodeIP:      sess.NodeIP,
+		NodeID:      sess.NodeID,
 		Status:      string(sess.Status),
 		Strategy:    string(sess.Strategy),
 		CreatedAt:   formatTime(sess.CreatedAt),
+		ActiveAt:    formatTime(sess.ActiveAt),
+
+		EffectiveStrategy: string(sess.ContainerStrategy()),
+		ParentSessionID:   sess.ParentSessionID,
+	})
+}
+
+// Stats GET /api/v1/sessions/:id/stats
+// 默认以 SSE 推送实时资源使用（约每秒一次）；stream=false 时只返回一个采样
+func (h *SessionHandler) Stats(c *gin.Context) {
+	id := c.Param("id")
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	statsCh, err := h.svc.StreamSessionStats(ctx, id)
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	if c.Query("stream") == "false" {
+		stats, ok := <-statsCh
+		if !ok {
+			respondError(c, http.StatusConflict, ErrSessionNotReady)
+			return
+		}
+		c.JSON(http.StatusOK, SessionStatsResponse{SessionID: id, ResourceStats: stats})
+		return
+	}
+
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("X-Accel-Buffering", "no")
+
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		slog.Warn("Failed to disable write deadline for SSE", "error", err)
+	}
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case stats, ok := <-statsCh:
+			if !ok {
+				return false
+			}
+			data, err := json.Marshal(SessionStatsResponse{SessionID: id, ResourceStats: stats})
+			if err != nil {
+				return false
+			}
+			c.SSEvent("stats", string(data))
+			return true
+		case <-ctx.Done():
+			return false
+		}
 	})
 }
 
@@ -347,16 +905,17 @@ func (h *SessionHandler) CreateService(c *gin.Context) {
 	id := c.Param("id")
 
 	var req CreateServiceAPIRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		respondErrorWithDetails(c, http.StatusBadRequest, ErrInvalidRequest, err.Error())
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	svc, err := h.svc.CreateCompanionService(c.Request.Context(), id, service.CreateServiceRequest{
-		Name:    req.Name,
-		Image:   req.Image,
-		EnvVars: req.EnvVars,
-		Cmd:     req.Cmd,
+		Name:        req.Name,
+		Image:       req.Image,
+		EnvVars:     req.EnvVars,
+		Cmd:         req.Cmd,
+		ExposePorts: req.ExposePorts,
+		Scope:       req.Scope,
 	})
 	if err != nil {
 		status := mapServiceError(err)
@@ -364,14 +923,7 @@ func (h *SessionHandler) CreateService(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, ServiceResponse{
-		ServiceID: svc.ID,
-		Name:      svc.Name,
-		Image:     svc.Image,
-		IP:        svc.IP,
-		Status:    svc.Status,
-		SessionID: id,
-	})
+	c.JSON(http.StatusCreated, newServiceResponse(id, svc))
 }
 
 func (h *SessionHandler) RemoveService(c *gin.Context) {
@@ -398,14 +950,7 @@ func (h *SessionHandler) ListServices(c *gin.Context) {
 
 	var respServices []ServiceResponse
 	for _, svc := range services {
-		respServices = append(respServices, ServiceResponse{
-			ServiceID: svc.ID,
-			Name:      svc.Name,
-			Image:     svc.Image,
-			IP:        svc.IP,
-			Status:    svc.Status,
-			SessionID: id,
-		})
+		respServices = append(respServices, newServiceResponse(id, svc))
 	}
 
 	c.JSON(http.StatusOK, ServiceListResponse{
@@ -419,14 +964,14 @@ func (h *SessionHandler) CreateComposeStack(c *gin.Context) {
 	id := c.Param("id")
 
 	var req CreateComposeAPIRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		respondErrorWithDetails(c, http.StatusBadRequest, ErrInvalidRequest, err.Error())
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	stack, err := h.svc.CreateComposeStack(c.Request.Context(), id, service.CreateComposeRequest{
+	stack, err := h.svc.CreateComposeStack(c.Request.Context(), id, stackNameParam(c), service.CreateComposeRequest{
 		ComposeContent: req.ComposeContent,
 		ComposeFile:    req.ComposeFile,
+		ExposePorts:    req.ExposePorts,
 	})
 	if err != nil {
 		status := mapServiceError(err)
@@ -434,63 +979,216 @@ func (h *SessionHandler) CreateComposeStack(c *gin.Context) {
 		return
 	}
 
-	var services []ComposeServiceResponse
-	for _, svc := range stack.Services {
-		services = append(services, ComposeServiceResponse{
-			Name:        svc.Name,
-			ContainerID: svc.ContainerID,
-			IP:          svc.IP,
-			Status:      svc.Status,
-		})
+	c.JSON(http.StatusCreated, newComposeStackResponse(id, stack))
+}
+
+// UpdateComposeStack PUT /api/v1/sessions/:id/compose
+// 将新的 compose 内容原地应用到运行中的堆栈，只重建有变化的服务
+func (h *SessionHandler) UpdateComposeStack(c *gin.Context) {
+	id := c.Param("id")
+
+	var req CreateComposeAPIRequest
+	if !bindJSON(c, &req) {
+		return
 	}
 
-	c.JSON(http.StatusCreated, ComposeStackResponse{
-		SessionID:   id,
-		ProjectName: stack.ProjectName,
-		Status:      stack.Status,
-		Services:    services,
+	result, err := h.svc.UpdateComposeStack(c.Request.Context(), id, stackNameParam(c), service.CreateComposeRequest{
+		ComposeContent: req.ComposeContent,
+		ComposeFile:    req.ComposeFile,
+		ExposePorts:    req.ExposePorts,
+	})
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ComposeUpdateResponse{
+		ComposeStackResponse: newComposeStackResponse(id, result.Stack),
+		Updated:              result.HasChanges(),
+		Added:                result.Added,
+		Removed:              result.Removed,
+		Changed:              result.Changed,
+		Unchanged:            result.Unchanged,
 	})
 }
 
 func (h *SessionHandler) GetComposeStack(c *gin.Context) {
 	id := c.Param("id")
 
-	stack, err := h.svc.RefreshComposeStack(c.Request.Context(), id)
+	stack, err := h.svc.RefreshComposeStack(c.Request.Context(), id, stackNameParam(c))
 	if err != nil {
 		status := mapServiceError(err)
 		respondError(c, status, err)
 		return
 	}
 
-	var services []ComposeServiceResponse
-	for _, svc := range stack.Services {
-		services = append(services, ComposeServiceResponse{
-			Name:        svc.Name,
-			ContainerID: svc.ContainerID,
-			IP:          svc.IP,
-			Status:      svc.Status,
-		})
+	c.JSON(http.StatusOK, newComposeStackResponse(id, stack))
+}
+
+func (h *SessionHandler) TeardownComposeStack(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.svc.TeardownComposeStack(c.Request.Context(), id, stackNameParam(c)); err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
 	}
 
-	c.JSON(http.StatusOK, ComposeStackResponse{
-		SessionID:   id,
-		ProjectName: stack.ProjectName,
-		Status:      stack.Status,
-		Services:    services,
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "torn_down",
+		"session_id": id,
+		"stack":      stackNameParam(c),
 	})
 }
 
-func (h *SessionHandler) TeardownComposeStack(c *gin.Context) {
+// RestartComposeService POST /api/v1/sessions/:id/compose/services/:name/restart
+func (h *SessionHandler) RestartComposeService(c *gin.Context) {
+	id := c.Param("id")
+
+	stack, err := h.svc.RestartComposeService(c.Request.Context(), id, stackNameParam(c), c.Param("name"))
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newComposeStackResponse(id, stack))
+}
+
+// ScaleComposeService POST /api/v1/sessions/:id/compose/services/:name/scale
+func (h *SessionHandler) ScaleComposeService(c *gin.Context) {
+	id := c.Param("id")
+
+	var req ScaleComposeServiceRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	stack, err := h.svc.ScaleComposeService(c.Request.Context(), id, stackNameParam(c), c.Param("name"), *req.Replicas)
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newComposeStackResponse(id, stack))
+}
+
+// ListComposeStacks GET /api/v1/sessions/:id/compose-stacks
+func (h *SessionHandler) ListComposeStacks(c *gin.Context) {
+	id := c.Param("id")
+
+	stacks := make([]ComposeStackResponse, 0)
+	for _, stack := range h.svc.ListComposeStacks(id) {
+		stacks = append(stacks, newComposeStackResponse(id, stack))
+	}
+
+	c.JSON(http.StatusOK, ComposeStackListResponse{
+		SessionID: id,
+		Stacks:    stacks,
+	})
+}
+
+// ListEndpoints GET /api/v1/sessions/:id/endpoints
+// 返回 session 所有 compose / companion 服务的连接信息，与写入 Agent 容器的 services.json 一致
+func (h *SessionHandler) ListEndpoints(c *gin.Context) {
 	id := c.Param("id")
 
-	if err := h.svc.TeardownComposeStack(c.Request.Context(), id); err != nil {
+	endpoints, err := h.svc.ListServiceEndpoints(c.Request.Context(), id)
+	if err != nil {
 		status := mapServiceError(err)
 		respondError(c, status, err)
 		return
 	}
+	if endpoints == nil {
+		endpoints = []service.ServiceEndpoint{}
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status":     "torn_down",
 		"session_id": id,
+		"file":       service.EndpointsFile,
+		"services":   endpoints,
+	})
+}
+
+// ListHostPorts GET /api/v1/sessions/:id/ports
+// 列出 session 的 companion / compose 服务发布到宿主机的端口
+func (h *SessionHandler) ListHostPorts(c *gin.Context) {
+	id := c.Param("id")
+
+	allocs, err := h.svc.ListHostPorts(c.Request.Context(), id)
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	ports := make([]HostPortResponse, 0, len(allocs))
+	for _, a := range allocs {
+		ports = append(ports, HostPortResponse{
+			HostPort:      a.Port,
+			ContainerPort: a.ContainerPort,
+			Owner:         a.Owner,
+			CreatedAt:     a.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, HostPortListResponse{
+		SessionID: id,
+		Ports:     ports,
 	})
 }
+
+// stackNameParam 返回路由中的堆栈名，/sessions/:id/compose 系列路由使用默认堆栈
+func stackNameParam(c *gin.Context) string {
+	if name := c.Param("stack_name"); name != "" {
+		return name
+	}
+	return service.DefaultStackName
+}
+
+func newServiceResponse(sessionID string, svc *service.CompanionService) ServiceResponse {
+	return ServiceResponse{
+		ServiceID: svc.ID,
+		Name:      svc.Name,
+		Image:     svc.Image,
+		IP:        svc.IP,
+		Status:    svc.Status,
+		SessionID: sessionID,
+		Ports:     newPortMappingResponses(svc.Ports),
+		Scope:     svc.Scope,
+		ProjectID: svc.ProjectID,
+		RefCount:  svc.RefCount,
+	}
+}
+
+func newPortMappingResponses(ports []service.PortMapping) []PortMappingResponse {
+	var out []PortMappingResponse
+	for _, p := range ports {
+		out = append(out, PortMappingResponse{ContainerPort: p.ContainerPort, HostPort: p.HostPort})
+	}
+	return out
+}
+
+func newComposeStackResponse(sessionID string, stack *service.ComposeStack) ComposeStackResponse {
+	var services []ComposeServiceResponse
+	for _, svc := range stack.Services {
+		services = append(services, ComposeServiceResponse{
+			Name:        svc.Name,
+			Replica:     svc.Replica,
+			ContainerID: svc.ContainerID,
+			IP:          svc.IP,
+			Status:      svc.Status,
+			Ports:       newPortMappingResponses(svc.Ports),
+		})
+	}
+
+	return ComposeStackResponse{
+		SessionID:   sessionID,
+		Name:        stack.Name,
+		ProjectName: stack.ProjectName,
+		Status:      stack.Status,
+		Services:    services,
+	}
+}
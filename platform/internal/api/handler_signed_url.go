@@ -0,0 +1,94 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"platform/internal/auth"
+	"platform/internal/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SignedURLHandler 签发可直接交给浏览器使用的限时 URL
+type SignedURLHandler struct {
+	svc        *service.Service
+	signer     *auth.URLSigner
+	defaultTTL time.Duration
+}
+
+func NewSignedURLHandler(svc *service.Service, signer *auth.URLSigner, defaultTTL time.Duration) *SignedURLHandler {
+	if defaultTTL == 0 {
+		defaultTTL = 15 * time.Minute
+	}
+	return &SignedURLHandler{svc: svc, signer: signer, defaultTTL: defaultTTL}
+}
+
+// CreateSignedURL POST /api/v1/sessions/:id/signed-url
+// 为文件下载（files/raw）、事件流（stream）或终端（terminal）签发 URL，持有者在有效期内无需其他凭证即可访问
+func (h *SignedURLHandler) CreateSignedURL(c *gin.Context) {
+	if h.signer == nil {
+		respondError(c, http.StatusNotImplemented, errors.New("signed URLs are not enabled"))
+		return
+	}
+
+	id := c.Param("id")
+
+	var req CreateSignedURLRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ttl := h.defaultTTL
+	if d, _ := parseOptionalDuration(req.ExpiresIn); d > 0 {
+		ttl = d
+	}
+
+	var (
+		path  string
+		query = url.Values{}
+		scope auth.Scope
+	)
+	switch req.Resource {
+	case "file":
+		// 原始内容带 Content-Type 和 Range 支持，浏览器可直接下载或预览
+		path = "/api/v1/sessions/" + id + "/files/raw"
+		query.Set("path", req.Path)
+		scope = auth.ScopeFilesRead
+	case "stream":
+		path = "/api/v1/sessions/" + id + "/stream"
+		scope = auth.ScopeChat
+	case "terminal":
+		// 浏览器的 WebSocket 无法设置 Authorization 头
+		path = "/api/v1/sessions/" + id + "/terminal"
+		scope = auth.ScopeTerminal
+	}
+
+	// 签名 URL 不能超出签发者自身的权限
+	ident := identityFrom(c)
+	if ident != nil && !ident.HasScope(scope) {
+		abortWithError(c, http.StatusForbidden, fmt.Errorf("token lacks required scope %q", scope))
+		return
+	}
+
+	sess, err := h.svc.GetSession(c.Request.Context(), id)
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	// 未启用认证时以 session 所有者身份签发
+	userID := sess.UserID
+	if ident != nil {
+		userID = ident.UserID
+	}
+
+	signed, expiresAt := h.signer.Sign(path, query, userID, ttl)
+	c.JSON(http.StatusOK, SignedURLResponse{
+		URL:       signed,
+		ExpiresAt: formatTime(expiresAt),
+	})
+}
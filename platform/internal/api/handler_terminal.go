@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"platform/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+var terminalUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 32 * 1024,
+	// 鉴权使用 Bearer 令牌或签名 URL，不依赖 Cookie，与 CORS 一样允许任意来源
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type TerminalHandler struct {
+	svc *service.Service
+}
+
+func NewTerminalHandler(svc *service.Service) *TerminalHandler {
+	return &TerminalHandler{svc: svc}
+}
+
+// Terminal GET /api/v1/sessions/:id/terminal
+// 升级为 WebSocket 并桥接到 session 容器内的 TTY shell。
+// 二进制帧为原始输入/输出；文本帧为 TerminalMessage（input、resize），
+// shell 退出时服务端发送 type=exit 的文本帧后关闭连接。
+func (h *TerminalHandler) Terminal(c *gin.Context) {
+	sessionID := c.Param("id")
+
+	if !websocket.IsWebSocketUpgrade(c.Request) {
+		respondError(c, http.StatusBadRequest, fmt.Errorf("%w: websocket upgrade required", ErrInvalidRequest))
+		return
+	}
+
+	term, err := h.svc.OpenTerminal(c.Request.Context(), sessionID, service.TerminalOptions{})
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+	defer term.Close()
+
+	conn, err := terminalUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade 已向客户端写入错误响应
+		slog.Warn("Terminal websocket upgrade failed", "session_id", sessionID, "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// 终端连接长期存在，清除 http.Server 设置的读写超时
+	_ = conn.SetReadDeadline(time.Time{})
+	_ = conn.SetWriteDeadline(time.Time{})
+
+	ctx := c.Request.Context()
+	go h.pumpInput(ctx, conn, term)
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := term.Read(buf)
+		if n > 0 {
+			if werr := conn.WriteMessage(websocket.BinaryMessage, buf[:n]); werr != nil {
+				return
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	msg := TerminalMessage{Type: "exit"}
+	if code, ok := waitTerminalExit(ctx, term); ok {
+		msg.ExitCode = &code
+	}
+	if data, err := json.Marshal(msg); err == nil {
+		_ = conn.WriteMessage(websocket.TextMessage, data)
+	}
+	_ = conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, "terminal exited"),
+		time.Now().Add(time.Second))
+}
+
+// pumpInput 将客户端输入写入终端；客户端断开时关闭终端，使 shell 收到 SIGHUP 退出
+func (h *TerminalHandler) pumpInput(ctx context.Context, conn *websocket.Conn, term *service.Terminal) {
+	defer term.Close()
+
+	for {
+		msgType, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		if msgType == websocket.BinaryMessage {
+			if _, err := term.Write(data); err != nil {
+				return
+			}
+			continue
+		}
+
+		var msg TerminalMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		switch msg.Type {
+		case "input":
+			if _, err := term.Write([]byte(msg.Data)); err != nil {
+				return
+			}
+		case "resize":
+			if msg.Cols > 0 && msg.Rows > 0 {
+				if err := term.Resize(ctx, msg.Cols, msg.Rows); err != nil {
+					slog.Warn("Failed to resize terminal", "error", err)
+				}
+			}
+		}
+	}
+}
+
+// waitTerminalExit 输出结束后进程可能尚未被 Docker 标记为退出，短暂等待退出码
+func waitTerminalExit(ctx context.Context, term *service.Terminal) (int, bool) {
+	for i := 0; i < 10; i++ {
+		code, running, err := term.ExitCode(ctx)
+		if err != nil {
+			return 0, false
+		}
+		if !running {
+			return code, true
+		}
+		select {
+		case <-ctx.Done():
+			return 0, false
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTerminalRequiresUpgrade(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/sessions/:id/terminal", NewTerminalHandler(nil).Terminal)
+
+	req := httptest.NewRequest(http.MethodGet, "/sessions/s1/terminal", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for plain HTTP request, got %d", w.Code)
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"platform/internal/service"
+	"platform/internal/session"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionV2Handler /api/v2/sessions 下的接口，响应统一为 Envelope 格式
+type SessionV2Handler struct {
+	svc *service.Service
+}
+
+func NewSessionV2Handler(svc *service.Service) *SessionV2Handler {
+	return &SessionV2Handler{svc: svc}
+}
+
+// ListSessions GET /api/v2/sessions?project_id=...
+// 未指定 project_id 时列出活跃 session；空列表返回 [] 而不是 null
+func (h *SessionV2Handler) ListSessions(c *gin.Context) {
+	var (
+		sessions []*session.Session
+		err      error
+	)
+	if projectID := c.Query("project_id"); projectID != "" {
+		sessions, err = h.svc.ListSessionsByProject(c.Request.Context(), projectID)
+	} else {
+		sessions, err = h.svc.ListActiveSessions(c.Request.Context())
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	sessions = filterOwnSessions(c, sessions)
+	resp := make([]SessionResponse, 0, len(sessions))
+	for _, sess := range sessions {
+		resp = append(resp, toSessionResponse(sess))
+	}
+
+	respondData(c, http.StatusOK, resp)
+}
+
+// GetSession GET /api/v2/sessions/:id
+func (h *SessionV2Handler) GetSession(c *gin.Context) {
+	sess, err := h.svc.GetSession(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		status := mapServiceError(err)
+		respondError(c, status, err)
+		return
+	}
+
+	respondData(c, http.StatusOK, toSessionResponse(sess))
+}
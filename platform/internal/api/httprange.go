@@ -0,0 +1,60 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var errUnsatisfiableRange = errors.New("unsatisfiable range")
+
+// byteRange Range 请求头中的一个区间，end 包含在内
+type byteRange struct {
+	start, end int64
+}
+
+func (r byteRange) length() int64 {
+	return r.end - r.start + 1
+}
+
+// parseByteRange 解析只含单个区间的 Range 请求头。
+// 头部为空、不是 bytes 单位或包含多个区间时返回 ok=false，按 RFC 9110 可以忽略 Range 返回完整内容；
+// 区间超出文件大小时返回 errUnsatisfiableRange
+func parseByteRange(header string, size int64) (r byteRange, ok bool, err error) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return byteRange{}, false, nil
+	}
+	startStr, endStr, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return byteRange{}, false, nil
+	}
+
+	if startStr == "" {
+		// bytes=-N 表示最后 N 个字节
+		n, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || n < 0 {
+			return byteRange{}, false, nil
+		}
+		if n == 0 || size == 0 {
+			return byteRange{}, false, errUnsatisfiableRange
+		}
+		return byteRange{start: max(size-n, 0), end: size - 1}, true, nil
+	}
+
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 {
+		return byteRange{}, false, nil
+	}
+	end := size - 1
+	if endStr != "" {
+		if end, err = strconv.ParseInt(endStr, 10, 64); err != nil || end < start {
+			return byteRange{}, false, nil
+		}
+		end = min(end, size-1)
+	}
+	if start >= size {
+		return byteRange{}, false, errUnsatisfiableRange
+	}
+	return byteRange{start: start, end: end}, true, nil
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseByteRange(t *testing.T) {
+	tests := []struct {
+		header string
+		want   byteRange
+		ok     bool
+		err    error
+	}{
+		{header: "", ok: false},
+		{header: "bytes=0-99", want: byteRange{0, 99}, ok: true},
+		{header: "bytes=100-", want: byteRange{100, 999}, ok: true},
+		{header: "bytes=900-5000", want: byteRange{900, 999}, ok: true},
+		{header: "bytes=-10", want: byteRange{990, 999}, ok: true},
+		{header: "bytes=-5000", want: byteRange{0, 999}, ok: true},
+		{header: "bytes=0-1,5-6", ok: false},
+		{header: "items=0-1", ok: false},
+		{header: "bytes=5-1", ok: false},
+		{header: "bytes=1000-", err: errUnsatisfiableRange},
+		{header: "bytes=-0", err: errUnsatisfiableRange},
+	}
+
+	for _, tt := range tests {
+		got, ok, err := parseByteRange(tt.header, 1000)
+		if !errors.Is(err, tt.err) || ok != tt.ok || got != tt.want {
+			t.Errorf("parseByteRange(%q) = %+v, %v, %v", tt.header, got, ok, err)
+		}
+	}
+}
@@ -1,13 +1,28 @@
 package api
 
 import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
+	"net/url"
+	"platform/internal/auth"
+	"platform/internal/errreport"
+	"platform/internal/logging"
+	"platform/internal/reqid"
+	"platform/internal/service"
+	"platform/internal/serviceaccount"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+const identityKey = "identity"
+
 func LoggerMiddleware() gin.HandlerFunc {
+	logger := slog.Default().With(logging.ComponentKey, "api")
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
@@ -33,20 +48,50 @@ func LoggerMiddleware() gin.HandlerFunc {
 		}
 
 		if status >= 500 {
-			slog.Error("Request", attrs...)
+			logger.Error("Request", attrs...)
 		} else if status >= 400 {
-			slog.Warn("Request", attrs...)
+			logger.Warn("Request", attrs...)
 		} else {
-			slog.Info("Request", attrs...)
+			logger.Info("Request", attrs...)
 		}
 	}
 }
 
+// RecoveryMiddleware 捕获 handler 的 panic，连同 session、路由和请求 ID 一起上报，返回 500
+func RecoveryMiddleware() gin.HandlerFunc {
+	logger := slog.Default().With(logging.ComponentKey, "api")
+	return func(c *gin.Context) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			// http.ErrAbortHandler 用于主动中断响应，交给 net/http 处理
+			if err, ok := r.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(r)
+			}
+
+			errreport.ReportPanic(c.Request.Context(), logger, "api", r, errreport.Tags{
+				"session_id": c.Param("id"),
+				"route":      c.FullPath(),
+				"method":     c.Request.Method,
+			})
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			abortWithError(c, http.StatusInternalServerError, errors.New("internal server error"))
+		}()
+		c.Next()
+	}
+}
+
 func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, X-Admin-Token")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, API-Version, Deprecation, Sunset, Link")
 		c.Writer.Header().Set("Access-Control-Max-Age", "86400")
 
 		if c.Request.Method == "OPTIONS" {
@@ -60,12 +105,14 @@ func CORSMiddleware() gin.HandlerFunc {
 
 func RequestIDMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		requestID := c.GetHeader("X-Request-ID")
+		requestID := c.GetHeader(reqid.Header)
 		if requestID == "" {
 			requestID = generateRequestID()
 		}
-		c.Writer.Header().Set("X-Request-ID", requestID)
+		c.Writer.Header().Set(reqid.Header, requestID)
 		c.Set("request_id", requestID)
+		// 写入请求 context，随 service 调用传递到任务载荷、事件和 exec 日志
+		c.Request = c.Request.WithContext(reqid.WithContext(c.Request.Context(), requestID))
 		c.Next()
 	}
 }
@@ -73,3 +120,171 @@ func RequestIDMiddleware() gin.HandlerFunc {
 func generateRequestID() string {
 	return time.Now().Format("20060102150405.000000")
 }
+
+// AdminAuthMiddleware 校验管理接口令牌（Authorization: Bearer <token> 或 X-Admin-Token）。
+// 浏览器访问的页面（如队列面板）使用 HTTP Basic 认证，密码为令牌，用户名任意。
+// 未配置令牌时管理接口整体禁用。
+func AdminAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			abortWithError(c, http.StatusForbidden, errors.New("admin API is disabled (ADMIN_TOKEN not set)"))
+			return
+		}
+
+		provided := c.GetHeader("X-Admin-Token")
+		if provided == "" {
+			if _, password, ok := c.Request.BasicAuth(); ok {
+				provided = password
+			} else {
+				provided = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+			}
+		}
+
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Basic realm="agent-platform admin"`)
+			abortWithError(c, http.StatusUnauthorized, errors.New("invalid admin token"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// SameOriginMiddleware 拒绝来自其他站点的表单提交。
+// 浏览器会自动附带 Basic 认证凭证，面板上的写操作需要防止跨站请求伪造
+func SameOriginMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			origin = c.GetHeader("Referer")
+		}
+		u, err := url.Parse(origin)
+		if origin == "" || err != nil || u.Host != c.Request.Host {
+			abortWithError(c, http.StatusForbidden, errors.New("cross-origin request rejected"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// AuthMiddleware 校验请求凭证并将身份写入上下文：
+//   - GET 请求携带 signature 参数时按签名 URL 校验，身份为签发时绑定的用户
+//   - Bearer 凭证以 sat_ 开头时按服务账号令牌校验
+//   - 其余 Bearer 凭证按 OIDC JWT 校验
+//
+// 未配置 OIDC 且未携带签名或服务账号令牌时直接放行，user_id 沿用请求中的值。
+func AuthMiddleware(provider *auth.OIDCProvider, accounts *serviceaccount.Manager, signer *auth.URLSigner) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if signer != nil && c.Request.Method == http.MethodGet && auth.HasSignature(c.Request.URL.Query()) {
+			userID, err := signer.Verify(c.Request.URL.Path, c.Request.URL.Query())
+			if err != nil {
+				abortWithError(c, http.StatusUnauthorized, err)
+				return
+			}
+			c.Set(identityKey, &auth.Identity{UserID: userID, Subject: "signed-url"})
+			c.Next()
+			return
+		}
+
+		token, hasToken := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		hasToken = hasToken && token != ""
+		isServiceAccount := hasToken && serviceaccount.IsToken(token)
+
+		if provider == nil && !isServiceAccount {
+			c.Next()
+			return
+		}
+		if !hasToken {
+			abortWithError(c, http.StatusUnauthorized, errors.New("missing bearer token"))
+			return
+		}
+
+		var ident *auth.Identity
+		var err error
+		switch {
+		case isServiceAccount && accounts == nil:
+			err = fmt.Errorf("%w: service accounts are not enabled", auth.ErrInvalidToken)
+		case isServiceAccount:
+			ident, err = accounts.Authenticate(c.Request.Context(), token)
+		default:
+			ident, err = provider.Verify(c.Request.Context(), token)
+		}
+		if err != nil {
+			if errors.Is(err, auth.ErrInvalidToken) {
+				abortWithError(c, http.StatusUnauthorized, err)
+				return
+			}
+			slog.Error("Authentication backend unavailable", "error", err)
+			abortWithError(c, http.StatusServiceUnavailable, errors.New("authentication unavailable"))
+			return
+		}
+
+		c.Set(identityKey, ident)
+		c.Next()
+	}
+}
+
+// RequireScope 服务账号缺少对应 scope 时拒绝请求，交互式用户不受限制
+func RequireScope(scope auth.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ident := identityFrom(c); ident != nil && !ident.HasScope(scope) {
+			abortWithError(c, http.StatusForbidden, fmt.Errorf("token lacks required scope %q", scope))
+			return
+		}
+		c.Next()
+	}
+}
+
+// SessionOwnerMiddleware 已认证时只允许访问自己的 session（/sessions/:id 下的路由）
+func SessionOwnerMiddleware(svc *service.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ident := identityFrom(c)
+		id := c.Param("id")
+		if ident == nil || id == "" {
+			c.Next()
+			return
+		}
+
+		// 查询失败交给 handler 处理（返回 404 等）
+		sess, err := svc.GetSession(c.Request.Context(), id)
+		if err == nil && sess.UserID != ident.UserID {
+			abortWithError(c, http.StatusForbidden, errors.New("session belongs to another user"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ActivityMiddleware 把对话、终端和文件操作记为 session 的一次活动，供空闲挂起判断。
+// 在 handler 之前记录，长连接（终端、事件流）从建立时算起
+func ActivityMiddleware(svc *service.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if id := c.Param("id"); id != "" {
+			svc.TouchSession(c.Request.Context(), id)
+		}
+		c.Next()
+	}
+}
+
+// UserScopeMiddleware 已认证时只允许访问自己的 /users/:user_id 资源
+func UserScopeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ident := identityFrom(c)
+		if ident != nil && c.Param("user_id") != ident.UserID {
+			abortWithError(c, http.StatusForbidden, errors.New("cannot access another user's resources"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// identityFrom 返回当前请求的认证身份，未启用 OIDC 时为 nil
+func identityFrom(c *gin.Context) *auth.Identity {
+	v, ok := c.Get(identityKey)
+	if !ok {
+		return nil
+	}
+	ident, _ := v.(*auth.Identity)
+	return ident
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"platform/internal/reqid"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestIDPropagatesToContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestIDMiddleware())
+	r.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, reqid.FromContext(c.Request.Context()))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set(reqid.Header, "req-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if got := w.Body.String(); got != "req-123" {
+		t.Errorf("Expected request ID in context, got %q", got)
+	}
+	if got := w.Header().Get(reqid.Header); got != "req-123" {
+		t.Errorf("Expected request ID echoed in header, got %q", got)
+	}
+}
+
+func TestRecoveryMiddlewareReturns500(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RecoveryMiddleware())
+	r.GET("/sessions/:id", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/sessions/sess-1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 after panic, got %d", w.Code)
+	}
+}
+
+func TestAdminAuthAcceptsBasicAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin", AdminAuthMiddleware("secret"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req.SetBasicAuth("ops", "secret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 with basic auth, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req.SetBasicAuth("ops", "wrong")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with wrong password, got %d", w.Code)
+	}
+	if w.Header().Get("WWW-Authenticate") == "" {
+		t.Error("Expected WWW-Authenticate challenge")
+	}
+}
+
+func TestAdminAuthTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		token   string
+		headers map[string]string
+		want    int
+	}{
+		{"bearer", "secret", map[string]string{"Authorization": "Bearer secret"}, http.StatusOK},
+		{"header", "secret", map[string]string{"X-Admin-Token": "secret"}, http.StatusOK},
+		{"header takes precedence", "secret", map[string]string{"X-Admin-Token": "wrong", "Authorization": "Bearer secret"}, http.StatusUnauthorized},
+		{"wrong token", "secret", map[string]string{"Authorization": "Bearer nope"}, http.StatusUnauthorized},
+		{"missing token", "secret", nil, http.StatusUnauthorized},
+		{"disabled", "", map[string]string{"Authorization": "Bearer "}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/admin", AdminAuthMiddleware(tt.token), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameOriginMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/action", SameOriginMiddleware(), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	cases := []struct {
+		origin string
+		want   int
+	}{
+		{"http://example.com", http.StatusNoContent},
+		{"http://evil.test", http.StatusForbidden},
+		{"", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/action", nil)
+		if tc.origin != "" {
+			req.Header.Set("Origin", tc.origin)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("Origin %q: expected %d, got %d", tc.origin, tc.want, w.Code)
+		}
+	}
+}
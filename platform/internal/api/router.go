@@ -2,20 +2,36 @@ package api
 
 import (
 	"net/http"
+	"platform/internal/auth"
 	"platform/internal/service"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func NewRouter(svc *service.Service) *gin.Engine {
+// RouterConfig 路由层配置
+type RouterConfig struct {
+	AdminToken string
+	// OIDC 为 nil 时不校验用户身份，user_id 取自请求
+	OIDC *auth.OIDCProvider
+	// URLSigner 为 nil 时不支持签名 URL
+	URLSigner        *auth.URLSigner
+	SignedURLDefault time.Duration
+	// Compression 是否对响应启用 gzip 压缩
+	Compression bool
+}
+
+func NewRouter(svc *service.Service, cfg RouterConfig) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 
 	r := gin.New()
-	r.Use(gin.Recovery())
+	r.Use(RecoveryMiddleware())
 	r.Use(LoggerMiddleware())
 	r.Use(CORSMiddleware())
 	r.Use(RequestIDMiddleware())
+	if cfg.Compression {
+		r.Use(GzipMiddleware())
+	}
 
 	// Global health check
 	r.GET("/health", func(c *gin.Context) {
@@ -25,38 +41,175 @@ func NewRouter(svc *service.Service) *gin.Engine {
 		})
 	})
 
+	// 预热池达到目标空闲数之前返回 503，负载均衡器据此暂不转发流量
+	r.GET("/readyz", func(c *gin.Context) {
+		ready := svc.Readiness()
+		code := http.StatusOK
+		if ready.State != service.ReadinessReady {
+			code = http.StatusServiceUnavailable
+		}
+		c.JSON(code, ReadinessResponse{
+			Status: string(ready.State),
+			Reason: ready.Reason,
+			Since:  formatTime(ready.Since),
+		})
+	})
+
 	sessionHandler := NewSessionHandler(svc)
 	chatHandler := NewChatHandler(svc)
+	adminHandler := NewAdminHandler(svc)
+	preferenceHandler := NewPreferenceHandler(svc)
+	authHandler := NewAuthHandler(cfg.OIDC)
+	serviceAccountHandler := NewServiceAccountHandler(svc)
+	signedURLHandler := NewSignedURLHandler(svc, cfg.URLSigner, cfg.SignedURLDefault)
+	sessionV2Handler := NewSessionV2Handler(svc)
+	operationHandler := NewOperationHandler(svc)
+	datasetHandler := NewDatasetHandler(svc)
+	terminalHandler := NewTerminalHandler(svc)
+	requireUser := AuthMiddleware(cfg.OIDC, svc.ServiceAccounts, cfg.URLSigner)
+	etag := ETagMiddleware()
+	activity := ActivityMiddleware(svc)
+
+	// companion 服务已由 compose stack 取代
+	companionDeprecated := Deprecated(Deprecation{Successor: "/api/v1/sessions/:id/compose"})
+
+	v1 := r.Group("/api/v1", APIVersionMiddleware(APIVersionV1))
+	{
+		v1.GET("/version", GetVersion)
+
+		authGroup := v1.Group("/auth")
+		{
+			if cfg.OIDC != nil {
+				authGroup.GET("/login", authHandler.Login)
+				authGroup.GET("/callback", authHandler.Callback)
+			}
+			authGroup.GET("/me", requireUser, authHandler.Me)
+		}
+
+		sessions := v1.Group("/sessions", requireUser, SessionOwnerMiddleware(svc))
+		{
+			sessions.POST("", RequireScope(auth.ScopeSessionsCreate), sessionHandler.CreateSession)
+			sessions.GET("", RequireScope(auth.ScopeSessionsRead), etag, sessionHandler.ListSessions)
+			sessions.GET("/:id", RequireScope(auth.ScopeSessionsRead), etag, sessionHandler.GetSession)
+			sessions.DELETE("/:id", RequireScope(auth.ScopeSessionsManage), sessionHandler.TerminateSession)
+			sessions.GET("/:id/health", RequireScope(auth.ScopeSessionsRead), sessionHandler.HealthCheckSession)
+			sessions.GET("/:id/wait", RequireScope(auth.ScopeSessionsRead), sessionHandler.WaitReady)
+			sessions.GET("/:id/stats", RequireScope(auth.ScopeSessionsRead), sessionHandler.Stats)
+			sessions.GET("/:id/pauses", RequireScope(auth.ScopeSessionsRead), sessionHandler.Pauses)
+			sessions.GET("/:id/quota", RequireScope(auth.ScopeSessionsRead), sessionHandler.Quota)
+			sessions.POST("/:id/signed-url", signedURLHandler.CreateSignedURL)
+
+			sessions.POST("/:id/configure", RequireScope(auth.ScopeSessionsManage), sessionHandler.ConfigureAgent)
+			sessions.POST("/:id/stop", RequireScope(auth.ScopeSessionsManage), sessionHandler.StopAgent)
+			sessions.POST("/:id/restart", RequireScope(auth.ScopeSessionsManage), sessionHandler.RestartSession)
+			sessions.POST("/:id/fork", RequireScope(auth.ScopeSessionsCreate), sessionHandler.ForkSession)
+			sessions.POST("/:id/hibernate", RequireScope(auth.ScopeSessionsManage), sessionHandler.Hibernate)
+			sessions.POST("/:id/pause", RequireScope(auth.ScopeSessionsManage), sessionHandler.Pause)
+			sessions.POST("/:id/resume", RequireScope(auth.ScopeSessionsManage), sessionHandler.Resume)
+
+			sessions.POST("/:id/chat", RequireScope(auth.ScopeChat), activity, chatHandler.SendMessage)
+			sessions.POST("/:id/chat:action", RequireScope(auth.ScopeChat), activity, chatHandler.ChatAction)
+			sessions.GET("/:id/stream", RequireScope(auth.ScopeChat), chatHandler.StreamEvents)
+			sessions.GET("/:id/events/wait", RequireScope(auth.ScopeChat), chatHandler.WaitEvent)
+			sessions.GET("/:id/events/payloads/:ref", RequireScope(auth.ScopeChat), chatHandler.GetEventPayload)
+
+			sessions.POST("/:id/sync", RequireScope(auth.ScopeFilesWrite), activity, sessionHandler.SyncFiles)
+			sessions.POST("/:id/sync-up", RequireScope(auth.ScopeFilesWrite), activity, sessionHandler.SyncUp)
+			sessions.POST("/:id/snapshot", RequireScope(auth.ScopeFilesRead), activity, sessionHandler.Snapshot)
+			sessions.POST("/:id/restore", RequireScope(auth.ScopeFilesWrite), activity, sessionHandler.RestoreSnapshot)
+			sessions.GET("/:id/files", RequireScope(auth.ScopeFilesRead), activity, etag, sessionHandler.ListFiles)
+			sessions.GET("/:id/files/read", RequireScope(auth.ScopeFilesRead), activity, etag, sessionHandler.ReadFile)
+			sessions.GET("/:id/files/raw", RequireScope(auth.ScopeFilesRead), activity, sessionHandler.RawFile)
+			sessions.HEAD("/:id/files/raw", RequireScope(auth.ScopeFilesRead), activity, sessionHandler.RawFile)
+			sessions.GET("/:id/files/archive", RequireScope(auth.ScopeFilesRead), activity, sessionHandler.DownloadArchive)
+			sessions.GET("/:id/files/watch", RequireScope(auth.ScopeFilesRead), activity, sessionHandler.WatchFiles)
+			sessions.PUT("/:id/files", RequireScope(auth.ScopeFilesWrite), activity, sessionHandler.WriteFile)
+
+			sessions.GET("/:id/terminal", RequireScope(auth.ScopeTerminal), activity, terminalHandler.Terminal)
+
+			sessions.POST("/:id/services", companionDeprecated, RequireScope(auth.ScopeServices), sessionHandler.CreateService)
+			sessions.GET("/:id/services", companionDeprecated, RequireScope(auth.ScopeServices), etag, sessionHandler.ListServices)
+			sessions.DELETE("/:id/services/:service_id", companionDeprecated, RequireScope(auth.ScopeServices), sessionHandler.RemoveService)
+
+			sessions.POST("/:id/compose", RequireScope(auth.ScopeServices), sessionHandler.CreateComposeStack)
+			sessions.GET("/:id/compose", RequireScope(auth.ScopeServices), etag, sessionHandler.GetComposeStack)
+			sessions.PUT("/:id/compose", RequireScope(auth.ScopeServices), sessionHandler.UpdateComposeStack)
+			sessions.POST("/:id/compose/services/:name/restart", RequireScope(auth.ScopeServices), sessionHandler.RestartComposeService)
+			sessions.POST("/:id/compose/services/:name/scale", RequireScope(auth.ScopeServices), sessionHandler.ScaleComposeService)
+
+			sessions.GET("/:id/endpoints", RequireScope(auth.ScopeServices), etag, sessionHandler.ListEndpoints)
+			sessions.GET("/:id/ports", RequireScope(auth.ScopeServices), etag, sessionHandler.ListHostPorts)
+
+			// 命名 compose 堆栈，/:id/compose 等价于名为 default 的堆栈
+			sessions.GET("/:id/compose-stacks", RequireScope(auth.ScopeServices), etag, sessionHandler.ListComposeStacks)
+			sessions.POST("/:id/compose/:stack_name", RequireScope(auth.ScopeServices), sessionHandler.CreateComposeStack)
+			sessions.GET("/:id/compose/:stack_name", RequireScope(auth.ScopeServices), etag, sessionHandler.GetComposeStack)
+			sessions.PUT("/:id/compose/:stack_name", RequireScope(auth.ScopeServices), sessionHandler.UpdateComposeStack)
+			sessions.DELETE("/:id/compose/:stack_name", RequireScope(auth.ScopeServices), sessionHandler.TeardownComposeStack)
+			sessions.POST("/:id/compose/:stack_name/services/:name/restart", RequireScope(auth.ScopeServices), sessionHandler.RestartComposeService)
+			sessions.POST("/:id/compose/:stack_name/services/:name/scale", RequireScope(auth.ScopeServices), sessionHandler.ScaleComposeService)
+			sessions.DELETE("/:id/compose", RequireScope(auth.ScopeServices), sessionHandler.TeardownComposeStack)
+		}
+
+		v1.GET("/operations/:id", requireUser, RequireScope(auth.ScopeSessionsRead), operationHandler.GetOperation)
+		v1.GET("/datasets", requireUser, RequireScope(auth.ScopeSessionsRead), datasetHandler.ListDatasets)
+
+		users := v1.Group("/users", requireUser, UserScopeMiddleware(), RequireScope(auth.ScopePreferences))
+		{
+			users.GET("/:user_id/preferences", etag, preferenceHandler.GetPreferences)
+			users.PUT("/:user_id/preferences", preferenceHandler.UpdatePreferences)
+			users.DELETE("/:user_id/preferences", preferenceHandler.DeletePreferences)
+		}
+
+		admin := v1.Group("/admin", AdminAuthMiddleware(cfg.AdminToken))
+		{
+			admin.GET("/storage", adminHandler.GetStorageUsage)
+			admin.GET("/tasks", adminHandler.ListTasks)
+			admin.GET("/log-levels", adminHandler.GetLogLevels)
+			admin.PUT("/log-levels", adminHandler.UpdateLogLevel)
+			admin.DELETE("/log-levels/:component", adminHandler.ResetLogLevel)
+			admin.GET("/feature-flags", adminHandler.ListFeatureFlags)
+			admin.PUT("/feature-flags", adminHandler.SetFeatureFlag)
+			admin.DELETE("/feature-flags/:flag", adminHandler.DeleteFeatureFlag)
+			admin.GET("/projects/:project_id/stack", adminHandler.GetProjectStack)
+			admin.PUT("/projects/:project_id/stack", adminHandler.UpdateProjectStack)
+			admin.DELETE("/projects/:project_id/stack", adminHandler.DeleteProjectStack)
+			admin.GET("/containers", adminHandler.ListContainers)
+			admin.GET("/pool", adminHandler.GetPool)
+			admin.POST("/pool/replenish", adminHandler.ReplenishPool)
+			admin.POST("/pool/drain", adminHandler.DrainPool)
+			admin.GET("/slo", adminHandler.GetSLO)
+			admin.POST("/sessions/:id/transfer", adminHandler.TransferSession)
+			admin.GET("/sessions/:id/debug-bundle", adminHandler.DownloadDebugBundle)
+
+			admin.POST("/service-accounts", serviceAccountHandler.Create)
+			admin.GET("/service-accounts", serviceAccountHandler.List)
+			admin.GET("/service-accounts/:id", serviceAccountHandler.Get)
+			admin.POST("/service-accounts/:id/rotate", serviceAccountHandler.Rotate)
+			admin.DELETE("/service-accounts/:id", serviceAccountHandler.Delete)
+		}
+	}
+
+	// asynq 队列面板，浏览器通过 Basic 认证访问（密码为 ADMIN_TOKEN）
+	queueHandler := NewQueueHandler(svc)
+	queues := r.Group("/admin/queues", AdminAuthMiddleware(cfg.AdminToken))
+	{
+		queues.GET("", queueHandler.Dashboard)
+		queues.POST("/actions/:queue/:task_id/:action", SameOriginMiddleware(), queueHandler.DashboardAction)
+
+		queues.GET("/api/queues", queueHandler.ListQueues)
+		queues.GET("/api/queues/:queue/tasks", queueHandler.ListTasks)
+		queues.POST("/api/queues/:queue/tasks/:task_id/run", queueHandler.RunTask)
+		queues.DELETE("/api/queues/:queue/tasks/:task_id", queueHandler.DeleteTask)
+	}
 
-	v1 := r.Group("/api/v1")
+	// v2 响应统一包装为 Envelope，破坏性变更只在 v2 中发布，v1 保持兼容
+	v2 := r.Group("/api/v2", APIVersionMiddleware(APIVersionV2))
 	{
-		sessions := v1.Group("/sessions")
+		sessions := v2.Group("/sessions", requireUser, SessionOwnerMiddleware(svc))
 		{
-			sessions.POST("", sessionHandler.CreateSession)
-			sessions.GET("", sessionHandler.ListSessions)
-			sessions.GET("/:id", sessionHandler.GetSession)
-			sessions.DELETE("/:id", sessionHandler.TerminateSession)
-			sessions.GET("/:id/health", sessionHandler.HealthCheckSession)
-			sessions.GET("/:id/wait", sessionHandler.WaitReady)
-
-			sessions.POST("/:id/configure", sessionHandler.ConfigureAgent)
-			sessions.POST("/:id/stop", sessionHandler.StopAgent)
-			sessions.POST("/:id/restart", sessionHandler.RestartSession)
-
-			sessions.POST("/:id/chat", chatHandler.SendMessage)
-			sessions.GET("/:id/stream", chatHandler.StreamEvents)
-
-			sessions.POST("/:id/sync", sessionHandler.SyncFiles)
-			sessions.GET("/:id/files", sessionHandler.ListFiles)
-			sessions.GET("/:id/files/read", sessionHandler.ReadFile)
-
-			sessions.POST("/:id/services", sessionHandler.CreateService)
-			sessions.GET("/:id/services", sessionHandler.ListServices)
-			sessions.DELETE("/:id/services/:service_id", sessionHandler.RemoveService)
-
-			sessions.POST("/:id/compose", sessionHandler.CreateComposeStack)
-			sessions.GET("/:id/compose", sessionHandler.GetComposeStack)
-			sessions.DELETE("/:id/compose", sessionHandler.TeardownComposeStack)
+			sessions.GET("", RequireScope(auth.ScopeSessionsRead), etag, sessionV2Handler.ListSessions)
+			sessions.GET("/:id", RequireScope(auth.ScopeSessionsRead), etag, sessionV2Handler.GetSession)
 		}
 	}
 
@@ -1,17 +1,47 @@
 package api
 
 import (
+	"platform/internal/auth"
+	"platform/internal/buildinfo"
+	"platform/internal/featureflag"
 	"platform/internal/orchestrator"
+	"platform/internal/queuepos"
+	"platform/internal/sandbox"
+	"platform/internal/serviceaccount"
+	"platform/internal/session"
 	"time"
 )
 
 type CreateSessionRequest struct {
 	ProjectID string   `json:"project_id" binding:"required"`
-	UserID    string   `json:"user_id" binding:"required"`
-	Strategy  string   `json:"strategy" binding:"required,oneof=Warm-Strategy Cold-Strategy"`
+	UserID    string   `json:"user_id"`                                                        // 启用 OIDC 时由 token 决定，忽略请求值
+	Strategy  string   `json:"strategy" binding:"omitempty,oneof=Warm-Strategy Cold-Strategy"` // 省略时使用用户偏好
 	Image     string   `json:"image"`
 	EnvVars   []string `json:"env_vars"`
 	AgentType string   `json:"agent_type"`
+	// NetworkPolicy 网络隔离策略，省略时不限制出网；受限模式只支持 Cold-Strategy
+	NetworkPolicy sandbox.NetworkPolicy `json:"network_policy"`
+	// GPUCount 请求的 GPU 数量（-1 表示全部），GPUDeviceIDs 指定设备，二者互斥；只支持 Cold-Strategy
+	GPUCount     int      `json:"gpu_count" binding:"min=-1"`
+	GPUDeviceIDs []string `json:"gpu_device_ids"`
+	// Mounts 工作区之外的额外挂载（只读数据集、依赖缓存、密钥等）；bind 挂载的宿主机路径须位于允许的根目录下，只支持 Cold-Strategy。
+	// 平台目录中的数据集可直接写作 "dataset:<name>"，见 GET /datasets
+	Mounts []sandbox.MountSpec `json:"mounts"`
+	// SnapshotID 用 POST /sessions/:id/snapshot 保存的工作区快照初始化新 session 的工作区
+	SnapshotID string `json:"snapshot_id"`
+	// GitURL 非空时将仓库浅克隆到工作区；GitRef 为分支、标签或提交，GitUsername / GitToken 用于私有仓库
+	GitURL      string `json:"git_url"`
+	GitRef      string `json:"ref"`
+	GitUsername string `json:"git_username"`
+	GitToken    string `json:"git_token"`
+	// Priority 预热池名额用尽时的排队优先级，batch 让位于 interactive（默认）
+	Priority string `json:"priority" binding:"omitempty,oneof=interactive batch"`
+	// Fallback 首选策略取不到容器时是否改用另一种策略（预热池用尽时冷启动，镜像不可用时改用预热池），省略时使用平台配置
+	Fallback *bool `json:"fallback"`
+}
+
+type RestoreSnapshotRequest struct {
+	SnapshotID string `json:"snapshot_id" binding:"required"`
 }
 
 type ChatRequest struct {
@@ -22,7 +52,7 @@ type ChatRequest struct {
 type ConfigureAgentRequest struct {
 	SystemPrompt string            `json:"system_prompt"`
 	BuiltinTools []string          `json:"builtin_tools"` // e.g. ["bash","file_read","file_write","list_files"]
-	Tools        []ToolDefRequest  `json:"tools"`
+	Tools        []ToolDefRequest  `json:"tools" binding:"dive"`
 	AgentConfig  map[string]string `json:"agent_config"` // e.g. {"max_loops":"10"}
 }
 
@@ -37,15 +67,37 @@ type SyncFilesRequest struct {
 	DestPath string `json:"dest_path"`
 }
 
+// SyncUpRequest 宿主机到容器的增量同步，Path 为项目内的子目录，为空时同步整个项目
+type SyncUpRequest struct {
+	Path   string `json:"path"`
+	Delete bool   `json:"delete"`
+}
+
+// FileWriteResponse PUT /sessions/:id/files 的结果
+type FileWriteResponse struct {
+	SessionID string `json:"session_id"`
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+}
+
 type SessionResponse struct {
 	ID          string `json:"id"`
 	ProjectID   string `json:"project_id"`
 	UserID      string `json:"user_id"`
 	ContainerID string `json:"container_id,omitempty"`
 	NodeIP      string `json:"node_ip,omitempty"`
+	NodeID      string `json:"node_id,omitempty"`
 	Status      string `json:"status"`
 	Strategy    string `json:"strategy"`
 	CreatedAt   string `json:"created_at"`
+	// ActiveAt 最近一次对话、终端或文件操作的时间
+	ActiveAt string `json:"active_at,omitempty"`
+	// EffectiveStrategy 容器实际使用的策略，改用另一种策略时与 Strategy 不同
+	EffectiveStrategy string `json:"effective_strategy,omitempty"`
+	// ParentSessionID 由 fork 创建时的源 session
+	ParentSessionID string `json:"parent_session_id,omitempty"`
+	// Queue 初始化中的 session 的排队位置和预计就绪时间，只在 GET /sessions/:id 中返回
+	Queue *queuepos.Position `json:"queue,omitempty"`
 }
 
 type ChatResponse struct {
@@ -69,15 +121,78 @@ type SyncFilesResponse struct {
 	Message   string `json:"message,omitempty"`
 }
 
-type FilesListResponse struct {
+// QueueInfoResponse asynq 队列统计，ProcessedToday / FailedToday 为当天计数
+type QueueInfoResponse struct {
+	Queue          string `json:"queue"`
+	Size           int    `json:"size"`
+	Pending        int    `json:"pending"`
+	Active         int    `json:"active"`
+	Scheduled      int    `json:"scheduled"`
+	Retry          int    `json:"retry"`
+	Archived       int    `json:"archived"`
+	Completed      int    `json:"completed"`
+	ProcessedToday int    `json:"processed_today"`
+	FailedToday    int    `json:"failed_today"`
+	Paused         bool   `json:"paused"`
+	LatencyMs      int64  `json:"latency_ms"`
+}
+
+type QueueTaskResponse struct {
+	ID            string `json:"id"`
+	Type          string `json:"type"`
+	State         string `json:"state"`
+	Payload       string `json:"payload"`
+	Retried       int    `json:"retried"`
+	MaxRetry      int    `json:"max_retry"`
+	LastError     string `json:"last_error,omitempty"`
+	LastFailedAt  string `json:"last_failed_at,omitempty"`
+	NextProcessAt string `json:"next_process_at,omitempty"`
+}
+
+// SessionStatsResponse 资源使用采样，字段与 sandbox.ResourceStats 一致
+type SessionStatsResponse struct {
 	SessionID string `json:"session_id"`
-	Output    string `json:"output"`
+	sandbox.ResourceStats
+}
+
+// ListFilesQuery GET /sessions/:id/files 的查询参数
+type ListFilesQuery struct {
+	Path      string `form:"path"`
+	Recursive bool   `form:"recursive"`
+	// Glob 不含 / 时匹配文件名，否则匹配相对 path 的路径
+	Glob   string `form:"glob"`
+	Offset int    `form:"offset" binding:"min=0"`
+	Limit  int    `form:"limit" binding:"min=0,max=10000"`
+}
+
+type FilesListResponse struct {
+	SessionID string             `json:"session_id"`
+	Path      string             `json:"path"`
+	Files     []sandbox.FileInfo `json:"files"`
+	Total     int                `json:"total"`
+	// NextOffset 下一页的 offset，没有下一页时省略
+	NextOffset int `json:"next_offset,omitempty"`
 }
 
 type FileContentResponse struct {
 	SessionID string `json:"session_id"`
 	Path      string `json:"path"`
 	Content   string `json:"content"`
+	// Encoding 为 base64 时 Content 为 base64 编码的原始字节，省略时为 UTF-8 文本
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// VersionResponse GET /version 的响应
+type VersionResponse struct {
+	buildinfo.Info
+	APIVersions []string `json:"api_versions"`
+}
+
+// ReadinessResponse /readyz 的响应，Status 为 warming 或 ready
+type ReadinessResponse struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	Since  string `json:"since,omitempty"`
 }
 
 type HealthResponse struct {
@@ -87,9 +202,10 @@ type HealthResponse struct {
 }
 
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Code    int    `json:"code"`
-	Details string `json:"details,omitempty"`
+	Error   string       `json:"error"`
+	Code    int          `json:"code"`
+	Details string       `json:"details,omitempty"`
+	Fields  []FieldError `json:"fields,omitempty"` // 请求体校验失败时的字段级错误
 }
 
 type CreateServiceAPIRequest struct {
@@ -97,15 +213,30 @@ type CreateServiceAPIRequest struct {
 	Image   string   `json:"image" binding:"required"`
 	EnvVars []string `json:"env_vars"`
 	Cmd     []string `json:"cmd"`
+	// ExposePorts 需要从 Docker 外部访问的容器端口，平台分配宿主机端口
+	ExposePorts []int `json:"expose_ports" binding:"omitempty,max=16,unique,dive,min=1,max=65535"`
+	// Scope session（默认）或 project；project 级服务由同一项目的 session 共享，
+	// 同名服务已存在时直接引用，最后一个引用的 session 释放后才删除
+	Scope string `json:"scope" binding:"omitempty,oneof=session project"`
+}
+
+// PortMappingResponse 容器端口到宿主机端口的映射
+type PortMappingResponse struct {
+	ContainerPort int `json:"container_port"`
+	HostPort      int `json:"host_port"`
 }
 
 type ServiceResponse struct {
-	ServiceID string `json:"service_id"`
-	Name      string `json:"name"`
-	Image     string `json:"image"`
-	IP        string `json:"ip"`
-	Status    string `json:"status"`
-	SessionID string `json:"session_id"`
+	ServiceID string                `json:"service_id"`
+	Name      string                `json:"name"`
+	Image     string                `json:"image"`
+	IP        string                `json:"ip"`
+	Status    string                `json:"status"`
+	SessionID string                `json:"session_id"`
+	Ports     []PortMappingResponse `json:"ports,omitempty"`
+	Scope     string                `json:"scope"`
+	ProjectID string                `json:"project_id,omitempty"`
+	RefCount  int                   `json:"ref_count,omitempty"` // 引用该项目级服务的 session 数
 }
 
 type ServiceListResponse struct {
@@ -116,20 +247,195 @@ type ServiceListResponse struct {
 type CreateComposeAPIRequest struct {
 	ComposeContent string `json:"compose_content"` // docker-compose.yml 文件内容
 	ComposeFile    string `json:"compose_file"`    // 或宿主机上的文件路径（二选一）
+	// ExposePorts 服务名 -> 需要从 Docker 外部访问的容器端口
+	ExposePorts map[string][]int `json:"expose_ports"`
 }
 
 type ComposeStackResponse struct {
 	SessionID   string                   `json:"session_id"`
+	Name        string                   `json:"name"`
 	ProjectName string                   `json:"project_name"`
 	Status      string                   `json:"status"`
 	Services    []ComposeServiceResponse `json:"services"`
 }
 
+type ComposeStackListResponse struct {
+	SessionID string                 `json:"session_id"`
+	Stacks    []ComposeStackResponse `json:"stacks"`
+}
+
+// ComposeUpdateResponse PUT /sessions/:id/compose 的返回，列出按服务名对比得到的变更
+type ComposeUpdateResponse struct {
+	ComposeStackResponse
+	Updated   bool     `json:"updated"` // false 表示内容无变化，未执行 compose up
+	Added     []string `json:"added"`
+	Removed   []string `json:"removed"`
+	Changed   []string `json:"changed"`
+	Unchanged []string `json:"unchanged"`
+}
+
+// ScaleComposeServiceRequest POST /sessions/:id/compose/services/:name/scale
+type ScaleComposeServiceRequest struct {
+	Replicas *int `json:"replicas" binding:"required,min=0,max=20"`
+}
+
 type ComposeServiceResponse struct {
-	Name        string `json:"name"`
-	ContainerID string `json:"container_id"`
-	IP          string `json:"ip"`
-	Status      string `json:"status"`
+	Name        string                `json:"name"`
+	Replica     int                   `json:"replica,omitempty"`
+	ContainerID string                `json:"container_id"`
+	IP          string                `json:"ip"`
+	Status      string                `json:"status"`
+	Ports       []PortMappingResponse `json:"ports,omitempty"`
+}
+
+// HostPortResponse session 占用的一个宿主机端口
+type HostPortResponse struct {
+	HostPort      int       `json:"host_port"`
+	ContainerPort int       `json:"container_port"`
+	Owner         string    `json:"owner"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type HostPortListResponse struct {
+	SessionID string             `json:"session_id"`
+	Ports     []HostPortResponse `json:"ports"`
+}
+
+type UpdatePreferencesRequest struct {
+	DefaultStrategy string                   `json:"default_strategy" binding:"omitempty,oneof=Warm-Strategy Cold-Strategy"`
+	DefaultImage    string                   `json:"default_image"`
+	DefaultEnvVars  []string                 `json:"default_env_vars"`
+	Notifications   NotificationSettingsBody `json:"notifications"`
+}
+
+type NotificationSettingsBody struct {
+	WebhookURL     string `json:"webhook_url"`
+	Email          string `json:"email"`
+	OnSessionReady bool   `json:"on_session_ready"`
+	OnSessionError bool   `json:"on_session_error"`
+}
+
+type LoginResponse struct {
+	IDToken      string         `json:"id_token"`
+	AccessToken  string         `json:"access_token,omitempty"`
+	RefreshToken string         `json:"refresh_token,omitempty"`
+	ExpiresAt    string         `json:"expires_at"`
+	User         *auth.Identity `json:"user"`
+}
+
+type CreateServiceAccountRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	UserID    string   `json:"user_id" binding:"required"` // 服务账号代表的平台用户
+	Scopes    []string `json:"scopes" binding:"required,min=1"`
+	ExpiresIn string   `json:"expires_in"` // Go duration，如 "720h"；为空表示不过期
+}
+
+type RotateServiceAccountRequest struct {
+	GracePeriod string `json:"grace_period"` // 旧令牌继续有效的时长，如 "1h"
+	ExpiresIn   string `json:"expires_in"`   // 重置新令牌有效期
+}
+
+// ServiceAccountTokenResponse 创建或轮换后返回明文令牌，令牌只展示这一次
+type ServiceAccountTokenResponse struct {
+	Account *serviceaccount.Account `json:"account"`
+	Token   string                  `json:"token"`
+}
+
+// UpdateLogLevelRequest 修改日志级别，component 为空时修改默认级别
+type UpdateLogLevelRequest struct {
+	Component string `json:"component"`
+	Level     string `json:"level" binding:"required,oneof=debug info warn error"`
+}
+
+// SetFeatureFlagRequest 覆盖功能开关，tenant 和 project 均为空时为全局覆盖
+type SetFeatureFlagRequest struct {
+	Flag    string `json:"flag" binding:"required"`
+	Tenant  string `json:"tenant"`
+	Project string `json:"project"`
+	Enabled *bool  `json:"enabled" binding:"required"`
+}
+
+// FeatureFlagListResponse 所有已知开关的默认值和覆盖
+type FeatureFlagListResponse struct {
+	Flags []featureflag.State `json:"flags"`
+}
+
+// UpdateProjectStackRequest 项目的默认伴随服务和 compose stack，该项目的 session 就绪后自动启动
+type UpdateProjectStackRequest struct {
+	Services []ProjectServiceRequest `json:"services" binding:"omitempty,max=8,dive"`
+	Compose  *ProjectComposeRequest  `json:"compose"`
+}
+
+// ProjectServiceRequest 字段与 CreateServiceAPIRequest 相同，另外可以指定资源上限
+type ProjectServiceRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Image       string   `json:"image" binding:"required"`
+	EnvVars     []string `json:"env_vars"`
+	Cmd         []string `json:"cmd"`
+	ExposePorts []int    `json:"expose_ports" binding:"omitempty,max=16,unique,dive,min=1,max=65535"`
+	Scope       string   `json:"scope" binding:"omitempty,oneof=session project"`
+	MemoryMB    int      `json:"memory_mb" binding:"omitempty,min=0"`
+	CPUs        float64  `json:"cpus" binding:"omitempty,min=0"`
+}
+
+type ProjectComposeRequest struct {
+	Name           string           `json:"name" binding:"required"`
+	ComposeContent string           `json:"compose_content" binding:"required"`
+	ExposePorts    map[string][]int `json:"expose_ports"`
+}
+
+// ContainerResponse 平台创建的容器，Labels 包含 tenant、strategy、run_id 等标准标签
+type ContainerResponse struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Image     string            `json:"image"`
+	State     string            `json:"state"`
+	Status    string            `json:"status"`
+	CreatedAt string            `json:"created_at"`
+	Labels    map[string]string `json:"labels"`
+}
+
+type ContainerListResponse struct {
+	Containers []ContainerResponse `json:"containers"`
+}
+
+// TransferSessionRequest 将容器转给的目标 session，须为仍在初始化的预热 session
+type TransferSessionRequest struct {
+	TargetSessionID string `json:"target_session_id" binding:"required"`
+}
+
+// CreateSignedURLRequest 为文件下载、事件流或终端生成签名 URL
+type CreateSignedURLRequest struct {
+	Resource  string `json:"resource" binding:"required,oneof=file stream terminal"`
+	Path      string `json:"path"`       // resource=file 时必填，容器内文件路径
+	ExpiresIn string `json:"expires_in"` // Go duration，为空时使用默认有效期
+}
+
+type SignedURLResponse struct {
+	URL       string `json:"url"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// DatasetResponse 目录中的数据集，不返回来源和卷驱动凭据
+type DatasetResponse struct {
+	Name          string `json:"name"`
+	Description   string `json:"description,omitempty"`
+	ContainerPath string `json:"container_path"`
+	Mount         string `json:"mount"` // 在 mounts 中引用时使用的值
+}
+
+type DatasetListResponse struct {
+	Datasets []DatasetResponse `json:"datasets"`
+}
+
+// TerminalMessage 终端 WebSocket 的文本帧。客户端发送 input / resize，
+// 服务端在 shell 退出时发送 exit
+type TerminalMessage struct {
+	Type     string `json:"type"`
+	Data     string `json:"data,omitempty"` // type=input
+	Cols     uint   `json:"cols,omitempty"` // type=resize
+	Rows     uint   `json:"rows,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"` // type=exit，无法获取时为空
 }
 
 // SSEEvent 是服务器发送事件的结构体
@@ -140,8 +446,11 @@ type SSEEvent struct {
 	Timestamp string `json:"timestamp"`
 }
 
+// mapStrategyType 将请求中的策略名映射为 StrategyType，空字符串保持为空以便应用用户偏好
 func mapStrategyType(s string) orchestrator.StrategyType {
 	switch s {
+	case "":
+		return ""
 	case string(orchestrator.WarmStrategyType):
 		return orchestrator.WarmStrategyType
 	case string(orchestrator.ColdStrategyType):
@@ -151,6 +460,24 @@ func mapStrategyType(s string) orchestrator.StrategyType {
 	}
 }
 
+func toSessionResponse(sess *session.Session) SessionResponse {
+	return SessionResponse{
+		ID:          sess.ID,
+		ProjectID:   sess.ProjectID,
+		UserID:      sess.UserID,
+		ContainerID: sess.ContainerID,
+		NodeIP:      sess.NodeIP,
+		NodeID:      sess.NodeID,
+		Status:      string(sess.Status),
+		Strategy:    string(sess.Strategy),
+		CreatedAt:   formatTime(sess.CreatedAt),
+		ActiveAt:    formatTime(sess.ActiveAt),
+
+		EffectiveStrategy: string(sess.ContainerStrategy()),
+		ParentSessionID:   sess.ParentSessionID,
+	}
+}
+
 func formatTime(t time.Time) string {
 	if t.IsZero() {
 		return ""
@@ -0,0 +1,344 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"platform/internal/auth"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field string `json:"field"`
+	Error string `json:"error"`
+}
+
+// validatable 由需要跨字段校验的请求类型实现，在 binding 标签校验通过后调用
+type validatable interface {
+	Validate() []FieldError
+}
+
+func init() {
+	// 校验错误中使用 JSON 字段名而不是 Go 字段名
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return f.Name
+			}
+			return name
+		})
+	}
+}
+
+// bindJSON 解析并校验请求体，失败时返回字段级错误并终止请求
+func bindJSON(c *gin.Context, req any) bool {
+	return bindWith(c, req, c.ShouldBindJSON)
+}
+
+// bindQuery 同 bindJSON，绑定查询参数
+func bindQuery(c *gin.Context, req any) bool {
+	return bindWith(c, req, c.ShouldBindQuery)
+}
+
+func bindWith(c *gin.Context, req any, bind func(any) error) bool {
+	var fields []FieldError
+	if err := bind(req); err != nil {
+		fields = bindingErrors(err)
+	} else if v, ok := req.(validatable); ok {
+		fields = v.Validate()
+	}
+
+	if len(fields) == 0 {
+		return true
+	}
+
+	c.AbortWithStatusJSON(http.StatusBadRequest, errorBody(c, ErrorResponse{
+		Error:   ErrInvalidRequest.Error(),
+		Code:    http.StatusBadRequest,
+		Details: summarizeFieldErrors(fields),
+		Fields:  fields,
+	}))
+	return false
+}
+
+func bindingErrors(err error) []FieldError {
+	var verrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+
+	switch {
+	case errors.As(err, &verrs):
+		fields := make([]FieldError, 0, len(verrs))
+		for _, fe := range verrs {
+			fields = append(fields, FieldError{
+				Field: fieldPath(fe.Namespace()),
+				Error: validationMessage(fe),
+			})
+		}
+		return fields
+	case errors.As(err, &typeErr):
+		return []FieldError{{Field: typeErr.Field, Error: "must be " + jsonTypeName(typeErr.Type)}}
+	case errors.As(err, &syntaxErr):
+		return []FieldError{{Error: fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset)}}
+	case errors.Is(err, io.EOF):
+		return []FieldError{{Error: "request body is required"}}
+	default:
+		return []FieldError{{Error: err.Error()}}
+	}
+}
+
+// fieldPath 去掉命名空间中的顶层结构体名，如 ConfigureAgentRequest.tools[0].name -> tools[0].name
+func fieldPath(namespace string) string {
+	if _, rest, ok := strings.Cut(namespace, "."); ok {
+		return rest
+	}
+	return namespace
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "min":
+		if fe.Kind() == reflect.Slice || fe.Kind() == reflect.Map {
+			return "must contain at least " + fe.Param() + " item(s)"
+		}
+		if fe.Kind() == reflect.String {
+			return "must be at least " + fe.Param() + " characters"
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return "must be at most " + fe.Param() + " characters"
+		}
+		return "must be at most " + fe.Param()
+	case "unique":
+		return "must not contain duplicates"
+	case "url":
+		return "must be a valid URL"
+	case "email":
+		return "must be a valid email address"
+	default:
+		return fmt.Sprintf("failed %q validation", fe.Tag())
+	}
+}
+
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	default:
+		return "a " + t.String()
+	}
+}
+
+func summarizeFieldErrors(fields []FieldError) string {
+	parts := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f.Field == "" {
+			parts = append(parts, f.Error)
+		} else {
+			parts = append(parts, f.Field+" "+f.Error)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ---- 通用字段校验 ----
+
+func validateEnvVars(field string, envVars []string) []FieldError {
+	var fields []FieldError
+	for i, kv := range envVars {
+		key, _, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			fields = append(fields, FieldError{
+				Field: fmt.Sprintf("%s[%d]", field, i),
+				Error: "must be in KEY=VALUE form",
+			})
+		}
+	}
+	return fields
+}
+
+func validateDuration(field, value string) []FieldError {
+	if value == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return []FieldError{{Field: field, Error: "must be a positive duration such as \"15m\" or \"24h\""}}
+	}
+	return nil
+}
+
+func validateHTTPURL(field, value string) []FieldError {
+	if value == "" {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return []FieldError{{Field: field, Error: "must be an absolute http(s) URL"}}
+	}
+	return nil
+}
+
+// ---- 请求类型的跨字段校验 ----
+
+func (r *CreateSessionRequest) Validate() []FieldError {
+	return validateEnvVars("env_vars", r.EnvVars)
+}
+
+func (r *ConfigureAgentRequest) Validate() []FieldError {
+	var fields []FieldError
+	seen := make(map[string]bool, len(r.Tools))
+	for i, td := range r.Tools {
+		if seen[td.Name] {
+			fields = append(fields, FieldError{
+				Field: fmt.Sprintf("tools[%d].name", i),
+				Error: fmt.Sprintf("duplicate tool name %q", td.Name),
+			})
+		}
+		seen[td.Name] = true
+
+		if td.ParametersJSON != "" && !json.Valid([]byte(td.ParametersJSON)) {
+			fields = append(fields, FieldError{
+				Field: fmt.Sprintf("tools[%d].parameters_json", i),
+				Error: "must be valid JSON",
+			})
+		}
+	}
+	return fields
+}
+
+func (r *CreateServiceAPIRequest) Validate() []FieldError {
+	return validateEnvVars("env_vars", r.EnvVars)
+}
+
+func (r *CreateComposeAPIRequest) Validate() []FieldError {
+	hasContent := strings.TrimSpace(r.ComposeContent) != ""
+	hasFile := r.ComposeFile != ""
+	if hasContent == hasFile {
+		return []FieldError{{
+			Field: "compose_content",
+			Error: "exactly one of compose_content or compose_file must be set",
+		}}
+	}
+	return validateExposePorts(r.ExposePorts)
+}
+
+// composeServiceNamePattern compose 服务名的合法字符
+var composeServiceNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+func validateExposePorts(expose map[string][]int) []FieldError {
+	var fields []FieldError
+	total := 0
+	for name, ports := range expose {
+		if !composeServiceNamePattern.MatchString(name) {
+			fields = append(fields, FieldError{
+				Field: fmt.Sprintf("expose_ports[%s]", name),
+				Error: "must be a valid compose service name",
+			})
+			continue
+		}
+		seen := make(map[int]bool, len(ports))
+		for i, p := range ports {
+			if p < 1 || p > 65535 || seen[p] {
+				fields = append(fields, FieldError{
+					Field: fmt.Sprintf("expose_ports[%s][%d]", name, i),
+					Error: "must be a unique port between 1 and 65535",
+				})
+			}
+			seen[p] = true
+		}
+		total += len(ports)
+	}
+	if total > 32 {
+		fields = append(fields, FieldError{Field: "expose_ports", Error: "must publish at most 32 ports"})
+	}
+	return fields
+}
+
+func (r *UpdatePreferencesRequest) Validate() []FieldError {
+	fields := validateEnvVars("default_env_vars", r.DefaultEnvVars)
+	return append(fields, validateHTTPURL("notifications.webhook_url", r.Notifications.WebhookURL)...)
+}
+
+func (r *UpdateProjectStackRequest) Validate() []FieldError {
+	var fields []FieldError
+	seen := make(map[string]bool, len(r.Services))
+	for i, svc := range r.Services {
+		if seen[svc.Name] {
+			fields = append(fields, FieldError{
+				Field: fmt.Sprintf("services[%d].name", i),
+				Error: fmt.Sprintf("duplicate service name %q", svc.Name),
+			})
+		}
+		seen[svc.Name] = true
+		fields = append(fields, validateEnvVars(fmt.Sprintf("services[%d].env_vars", i), svc.EnvVars)...)
+	}
+	if r.Compose != nil {
+		fields = append(fields, validateExposePorts(r.Compose.ExposePorts)...)
+	}
+	return fields
+}
+
+func (r *CreateServiceAccountRequest) Validate() []FieldError {
+	var fields []FieldError
+	for i, s := range r.Scopes {
+		if !auth.ValidScope(auth.Scope(s)) {
+			fields = append(fields, FieldError{
+				Field: fmt.Sprintf("scopes[%d]", i),
+				Error: "must be one of: " + joinScopes(auth.AllScopes),
+			})
+		}
+	}
+	return append(fields, validateDuration("expires_in", r.ExpiresIn)...)
+}
+
+func (r *RotateServiceAccountRequest) Validate() []FieldError {
+	fields := validateDuration("grace_period", r.GracePeriod)
+	return append(fields, validateDuration("expires_in", r.ExpiresIn)...)
+}
+
+func (r *CreateSignedURLRequest) Validate() []FieldError {
+	var fields []FieldError
+	if r.Resource == "file" && r.Path == "" {
+		fields = append(fields, FieldError{Field: "path", Error: "is required when resource is \"file\""})
+	}
+	return append(fields, validateDuration("expires_in", r.ExpiresIn)...)
+}
+
+func joinScopes(scopes []auth.Scope) string {
+	parts := make([]string, len(scopes))
+	for i, s := range scopes {
+		parts[i] = string(s)
+	}
+	return strings.Join(parts, ", ")
+}
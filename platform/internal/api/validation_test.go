@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func bindForTest(t *testing.T, body string, req any) (int, ErrorResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	if bindJSON(c, req) {
+		return http.StatusOK, ErrorResponse{}
+	}
+
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Invalid error body %q: %v", w.Body.String(), err)
+	}
+	return w.Code, resp
+}
+
+func TestBindJSONFieldErrors(t *testing.T) {
+	code, resp := bindForTest(t, `{"strategy":"Hot-Strategy","env_vars":["OK=1"]}`, &CreateSessionRequest{})
+	if code != http.StatusBadRequest {
+		t.Fatalf("Expected 400, got %d", code)
+	}
+	want := map[string]string{
+		"project_id": "is required",
+		"strategy":   "must be one of: Warm-Strategy, Cold-Strategy",
+	}
+	if len(resp.Fields) != len(want) {
+		t.Fatalf("Expected %d field errors, got %+v", len(want), resp.Fields)
+	}
+	for _, f := range resp.Fields {
+		if want[f.Field] != f.Error {
+			t.Errorf("Unexpected error for %s: %q", f.Field, f.Error)
+		}
+	}
+
+	_, resp = bindForTest(t, `{"project_id":"p","env_vars":["NOEQUALS"]}`, &CreateSessionRequest{})
+	if len(resp.Fields) != 1 || resp.Fields[0].Field != "env_vars[0]" {
+		t.Errorf("Expected env_vars[0] error, got %+v", resp.Fields)
+	}
+
+	_, resp = bindForTest(t, `{"tools":[{"name":"a"},{"name":""}]}`, &ConfigureAgentRequest{})
+	if len(resp.Fields) != 1 || resp.Fields[0].Field != "tools[1].name" {
+		t.Errorf("Expected nested field path, got %+v", resp.Fields)
+	}
+
+	_, resp = bindForTest(t, `{"project_id": 42}`, &CreateSessionRequest{})
+	if len(resp.Fields) != 1 || resp.Fields[0].Field != "project_id" || resp.Fields[0].Error != "must be a string" {
+		t.Errorf("Expected type error, got %+v", resp.Fields)
+	}
+}
+
+func TestBindJSONCrossField(t *testing.T) {
+	for _, body := range []string{`{}`, `{"compose_content":"services: {}","compose_file":"/tmp/c.yml"}`} {
+		code, resp := bindForTest(t, body, &CreateComposeAPIRequest{})
+		if code != http.StatusBadRequest || len(resp.Fields) != 1 {
+			t.Errorf("%s: expected XOR error, got %d %+v", body, code, resp.Fields)
+		}
+	}
+
+	if code, _ := bindForTest(t, `{"compose_file":"/tmp/c.yml"}`, &CreateComposeAPIRequest{}); code != http.StatusOK {
+		t.Errorf("Expected valid compose request, got %d", code)
+	}
+
+	_, resp := bindForTest(t, `{"services":[{"name":"db","image":"postgres:16"},{"name":"db","image":"redis:7","env_vars":["X"]}]}`, &UpdateProjectStackRequest{})
+	if len(resp.Fields) != 2 || resp.Fields[0].Field != "services[1].name" || resp.Fields[1].Field != "services[1].env_vars[0]" {
+		t.Errorf("Expected duplicate name and env errors, got %+v", resp.Fields)
+	}
+	_, resp = bindForTest(t, `{"notifications":{"webhook_url":"ftp://hooks.example.com"}}`, &UpdatePreferencesRequest{})
+	if len(resp.Fields) != 1 || resp.Fields[0].Field != "notifications.webhook_url" {
+		t.Errorf("Expected webhook URL error, got %+v", resp.Fields)
+	}
+	var prefs UpdatePreferencesRequest
+	if code, _ := bindForTest(t, `{"notifications":{"webhook_url":"https://hooks.example.com/x","on_session_error":true}}`, &prefs); code != http.StatusOK || !prefs.Notifications.OnSessionError {
+		t.Errorf("Expected valid notification settings, got %d %+v", code, prefs.Notifications)
+	}
+}
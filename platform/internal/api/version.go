@@ -0,0 +1,112 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"platform/internal/buildinfo"
+	"platform/internal/monitor"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	APIVersionV1 = "1"
+	APIVersionV2 = "2"
+
+	apiVersionKey    = "api_version"
+	apiVersionHeader = "API-Version"
+)
+
+// SupportedAPIVersions 当前服务端提供的 API 版本，客户端据此检查兼容性
+var SupportedAPIVersions = []string{APIVersionV1, APIVersionV2}
+
+// GetVersion GET /api/v1/version
+// 返回构建信息和支持的 API / agent 协议版本，无需认证
+func GetVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, VersionResponse{
+		Info:        buildinfo.Get(),
+		APIVersions: SupportedAPIVersions,
+	})
+}
+
+// APIVersionMiddleware 标记路由组的 API 版本，写入响应头并决定错误响应的格式
+func APIVersionMiddleware(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(apiVersionKey, version)
+		c.Writer.Header().Set(apiVersionHeader, version)
+		c.Next()
+	}
+}
+
+// Deprecation 描述一个已弃用的路由
+type Deprecation struct {
+	// Since 弃用时间，零值时 Deprecation 头为 "true"
+	Since time.Time
+	// Sunset 计划下线时间，零值时不设置 Sunset 头
+	Sunset time.Time
+	// Successor 替代接口路径，可包含 :id 等路由参数，会被替换为当前请求的值
+	Successor string
+}
+
+// Deprecated 为已弃用路由添加 Deprecation / Sunset / Link 响应头（RFC 9745、RFC 8594），
+// 并记录告警日志和指标，便于在下线前找出仍在使用的客户端。
+func Deprecated(d Deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		if d.Since.IsZero() {
+			h.Set("Deprecation", "true")
+		} else {
+			h.Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+		}
+		if !d.Sunset.IsZero() {
+			h.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+
+		successor := d.Successor
+		for _, p := range c.Params {
+			successor = strings.ReplaceAll(successor, ":"+p.Key, p.Value)
+		}
+		if successor != "" {
+			h.Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		}
+
+		monitor.APIDeprecatedRequests.WithLabelValues(c.Request.Method, c.FullPath()).Inc()
+		slog.Warn("Deprecated API route used",
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"successor", successor,
+			"ip", c.ClientIP(),
+			"user_agent", c.Request.UserAgent(),
+		)
+
+		c.Next()
+	}
+}
+
+// Envelope v2 响应的统一外层结构，成功时只有 data，失败时只有 error
+type Envelope struct {
+	APIVersion string         `json:"api_version"`
+	Data       any            `json:"data,omitempty"`
+	Error      *ErrorResponse `json:"error,omitempty"`
+}
+
+func isV2(c *gin.Context) bool {
+	return c.GetString(apiVersionKey) == APIVersionV2
+}
+
+// respondData v2 接口返回成功响应
+func respondData(c *gin.Context, code int, data any) {
+	c.JSON(code, Envelope{APIVersion: APIVersionV2, Data: data})
+}
+
+// errorBody 按请求所属 API 版本包装错误响应
+func errorBody(c *gin.Context, resp ErrorResponse) any {
+	if isV2(c) {
+		return Envelope{APIVersion: APIVersionV2, Error: &resp}
+	}
+	return resp
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeprecatedHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.GET("/api/v1/sessions/:id/services",
+		APIVersionMiddleware(APIVersionV1),
+		Deprecated(Deprecation{Sunset: sunset, Successor: "/api/v1/sessions/:id/compose"}),
+		func(c *gin.Context) { c.Status(http.StatusOK) },
+	)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/sessions/s1/services", nil))
+
+	h := w.Header()
+	if h.Get("Deprecation") != "true" {
+		t.Errorf("Unexpected Deprecation header %q", h.Get("Deprecation"))
+	}
+	if h.Get("Sunset") != "Fri, 01 Jan 2027 00:00:00 GMT" {
+		t.Errorf("Unexpected Sunset header %q", h.Get("Sunset"))
+	}
+	if h.Get("Link") != `</api/v1/sessions/s1/compose>; rel="successor-version"` {
+		t.Errorf("Unexpected Link header %q", h.Get("Link"))
+	}
+	if h.Get(apiVersionHeader) != APIVersionV1 {
+		t.Errorf("Unexpected API-Version header %q", h.Get(apiVersionHeader))
+	}
+}
+
+func TestV2ErrorEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/v2/x", APIVersionMiddleware(APIVersionV2), func(c *gin.Context) {
+		respondError(c, http.StatusNotFound, errors.New("session not found"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/x", nil))
+
+	var env Envelope
+	if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+		t.Fatal(err)
+	}
+	if env.APIVersion != APIVersionV2 || env.Error == nil || env.Error.Code != http.StatusNotFound {
+		t.Errorf("Unexpected envelope: %s", w.Body.String())
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/v1/version", GetVersion)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/version", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status %d", w.Code)
+	}
+
+	var resp struct {
+		Version        string   `json:"version"`
+		APIVersions    []string `json:"api_versions"`
+		AgentProtocols []string `json:"agent_protocols"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Version == "" || len(resp.APIVersions) != 2 || len(resp.AgentProtocols) == 0 {
+		t.Errorf("Unexpected version response %s", w.Body.String())
+	}
+}
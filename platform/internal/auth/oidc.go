@@ -0,0 +1,354 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// OIDCConfig OIDC 身份提供方配置
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// Audience 校验 token 的 aud，默认为 ClientID
+	Audience string
+	// UserClaim 映射为平台 user_id 的 claim，默认 sub
+	UserClaim string
+	// TenantClaim 映射为租户 ID 的 claim，为空时不提取租户
+	TenantClaim string
+}
+
+// Identity 从已校验的 token 中解析出的平台用户身份
+type Identity struct {
+	UserID    string    `json:"user_id"`
+	TenantID  string    `json:"tenant_id,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Subject   string    `json:"subject"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// ServiceAccountID 非空表示请求来自服务账号，权限受 Scopes 限制
+	ServiceAccountID string  `json:"service_account_id,omitempty"`
+	Scopes           []Scope `json:"scopes,omitempty"`
+}
+
+// TokenResponse 授权码换取的 token
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	IDToken      string `json:"id_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+const (
+	jwksMinRefresh = time.Minute
+	// jwksRetryInterval 拉取 JWKS 失败后的最短重试间隔
+	jwksRetryInterval = 5 * time.Second
+	clockSkew         = 30 * time.Second
+)
+
+// OIDCProvider 实现授权码登录和 JWT 校验。
+// discovery 文档和 JWKS 在首次使用时拉取，遇到未知 kid 时刷新 JWKS（限频）。
+// 网络请求不持有 mu，同一时间只有一个 JWKS 请求，其他调用方等待它的结果。
+type OIDCProvider struct {
+	config OIDCConfig
+	client *http.Client
+	logger *slog.Logger
+
+	mu        sync.Mutex
+	discovery *discoveryDocument
+	keys      map[string]any
+	// keysNextFetch 之前不再拉取 JWKS，成功和失败都会推后；keysFetching 非 nil 时有请求进行中，结束时关闭
+	keysNextFetch time.Time
+	keysFetching  chan struct{}
+}
+
+func NewOIDCProvider(config OIDCConfig, logger *slog.Logger) *OIDCProvider {
+	if config.Audience == "" {
+		config.Audience = config.ClientID
+	}
+	if config.UserClaim == "" {
+		config.UserClaim = "sub"
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "profile", "email"}
+	}
+	return &OIDCProvider{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+		logger: logger.With("component", "oidc"),
+	}
+}
+
+// AuthCodeURL 返回跳转到 IdP 登录页的地址
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state string) (string, error) {
+	doc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.config.ClientID)
+	q.Set("redirect_uri", p.config.RedirectURL)
+	q.Set("scope", strings.Join(p.config.Scopes, " "))
+	q.Set("state", state)
+
+	sep := "?"
+	if strings.Contains(doc.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return doc.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange 用授权码换取 token
+func (p *OIDCProvider) Exchange(ctx context.Context, code string) (*TokenResponse, error) {
+	doc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("client_id", p.config.ClientID)
+	form.Set("client_secret", p.config.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token exchange: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("token exchange failed: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var token TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("decode token response: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+	return &token, nil
+}
+
+// Verify 校验 JWT 的签名、issuer、audience 和有效期，并映射为平台身份
+func (p *OIDCProvider) Verify(ctx context.Context, rawToken string) (*Identity, error) {
+	doc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	header, claims, signed, sig, err := parseJWT(rawToken)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := p.getKey(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, signed, sig); err != nil {
+		return nil, err
+	}
+
+	if iss, _ := claims["iss"].(string); iss != doc.Issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, iss)
+	}
+	if !hasAudience(claims["aud"], p.config.Audience) {
+		return nil, fmt.Errorf("%w: audience mismatch", ErrInvalidToken)
+	}
+
+	now := time.Now()
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return nil, fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+	if now.After(exp.Add(clockSkew)) {
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(clockSkew).Before(nbf) {
+		return nil, fmt.Errorf("%w: token not yet valid", ErrInvalidToken)
+	}
+
+	return p.mapIdentity(claims, exp)
+}
+
+func (p *OIDCProvider) mapIdentity(claims map[string]any, exp time.Time) (*Identity, error) {
+	userID, _ := claims[p.config.UserClaim].(string)
+	if userID == "" {
+		return nil, fmt.Errorf("%w: missing %s claim", ErrInvalidToken, p.config.UserClaim)
+	}
+
+	ident := &Identity{
+		UserID:    userID,
+		ExpiresAt: exp,
+	}
+	ident.Subject, _ = claims["sub"].(string)
+	ident.Email, _ = claims["email"].(string)
+	ident.Name, _ = claims["name"].(string)
+	if p.config.TenantClaim != "" {
+		ident.TenantID, _ = claims[p.config.TenantClaim].(string)
+	}
+	return ident, nil
+}
+
+func (p *OIDCProvider) getDiscovery(ctx context.Context) (*discoveryDocument, error) {
+	p.mu.Lock()
+	cached := p.discovery
+	p.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	// 首次拉取时并发的请求可能各拉一次，结果相同
+	wellKnown := strings.TrimSuffix(p.config.IssuerURL, "/") + "/.well-known/openid-configuration"
+	var doc discoveryDocument
+	if err := p.getJSON(ctx, wellKnown, &doc); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != strings.TrimSuffix(p.config.IssuerURL, "/") {
+		return nil, fmt.Errorf("oidc discovery: issuer mismatch: %q", doc.Issuer)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery == nil {
+		p.discovery = &doc
+	}
+	return p.discovery, nil
+}
+
+func (p *OIDCProvider) getKey(ctx context.Context, kid string) (any, error) {
+	p.mu.Lock()
+	if key, ok := p.lookupKey(kid); ok {
+		p.mu.Unlock()
+		return key, nil
+	}
+
+	// 已有请求在拉取 JWKS 时等待它完成，不再重复请求
+	if wait := p.keysFetching; wait != nil {
+		p.mu.Unlock()
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if key, ok := p.lookupKey(kid); ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, kid)
+	}
+
+	if time.Now().Before(p.keysNextFetch) {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, kid)
+	}
+	done := make(chan struct{})
+	p.keysFetching = done
+	jwksURI := p.discovery.JWKSURI
+	p.mu.Unlock()
+
+	var set jwkSet
+	err := p.getJSON(ctx, jwksURI, &set)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keysFetching = nil
+	close(done)
+	if err != nil {
+		p.keysNextFetch = time.Now().Add(jwksRetryInterval)
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	p.keys = set.publicKeys(p.logger)
+	p.keysNextFetch = time.Now().Add(jwksMinRefresh)
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, kid)
+}
+
+// lookupKey 按 kid 查找公钥；token 未携带 kid 且只有一把公钥时直接使用它
+func (p *OIDCProvider) lookupKey(kid string) (any, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, k := range p.keys {
+			return k, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, u string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func hasAudience(aud any, want string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == want
+	case []any:
+		for _, a := range v {
+			if s, ok := a.(string); ok && s == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func numericClaim(claims map[string]any, name string) (time.Time, bool) {
+	v, ok := claims[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(v), 0), true
+}
@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp := &testIdP{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(discoveryDocument{
+			Issuer:                idp.server.URL,
+			AuthorizationEndpoint: idp.server.URL + "/authorize",
+			TokenEndpoint:         idp.server.URL + "/token",
+			JWKSURI:               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jwkSet{Keys: []jwk{{
+			Kty: "RSA",
+			Kid: "k1",
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *testIdP) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(jwtHeader{Alg: "RS256", Kid: "k1"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestOIDCVerify(t *testing.T) {
+	idp := newTestIdP(t)
+	p := NewOIDCProvider(OIDCConfig{
+		IssuerURL:   idp.server.URL,
+		ClientID:    "platform",
+		TenantClaim: "org",
+	}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+
+	valid := map[string]any{
+		"iss":   idp.server.URL,
+		"aud":   "platform",
+		"sub":   "user-1",
+		"email": "u1@example.com",
+		"org":   "acme",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+
+	ident, err := p.Verify(context.Background(), idp.sign(t, valid))
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if ident.UserID != "user-1" || ident.TenantID != "acme" || ident.Email != "u1@example.com" {
+		t.Fatalf("Unexpected identity: %+v", ident)
+	}
+
+	cases := map[string]func(map[string]any){
+		"wrong audience": func(c map[string]any) { c["aud"] = "other" },
+		"wrong issuer":   func(c map[string]any) { c["iss"] = "https://evil.example.com" },
+		"expired":        func(c map[string]any) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"missing sub":    func(c map[string]any) { delete(c, "sub") },
+	}
+	for name, mutate := range cases {
+		claims := make(map[string]any, len(valid))
+		for k, v := range valid {
+			claims[k] = v
+		}
+		mutate(claims)
+		if _, err := p.Verify(context.Background(), idp.sign(t, claims)); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+
+	tampered := idp.sign(t, valid) + "x"
+	if _, err := p.Verify(context.Background(), tampered); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("tampered signature: expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestOIDCJWKSFetch(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hits, failing atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if failing.Load() > 0 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+		json.NewEncoder(w).Encode(jwkSet{Keys: []jwk{{
+			Kty: "RSA",
+			Kid: "k1",
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	newProvider := func() *OIDCProvider {
+		p := NewOIDCProvider(OIDCConfig{IssuerURL: server.URL, ClientID: "platform"}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+		p.discovery = &discoveryDocument{Issuer: server.URL, JWKSURI: server.URL + "/jwks"}
+		return p
+	}
+	ctx := context.Background()
+
+	// 失败的拉取同样限频，间隔内不再请求 IdP
+	failing.Store(1)
+	p := newProvider()
+	for range 3 {
+		if _, err := p.getKey(ctx, "k1"); err == nil {
+			t.Fatal("Expected error while JWKS is unavailable")
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("JWKS requests after failure = %d, want 1", n)
+	}
+	failing.Store(0)
+	p.mu.Lock()
+	p.keysNextFetch = time.Now().Add(-time.Second)
+	p.mu.Unlock()
+	if _, err := p.getKey(ctx, "k1"); err != nil {
+		t.Fatalf("Expected key after retry interval, got %v", err)
+	}
+
+	// 并发的未知 kid 共享同一次拉取
+	hits.Store(0)
+	p = newProvider()
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := p.getKey(ctx, "k1")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Concurrent getKey failed: %v", err)
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("Concurrent JWKS requests = %d, want 1", n)
+	}
+}
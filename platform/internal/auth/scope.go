@@ -0,0 +1,51 @@
+package auth
+
+// Scope 服务账号令牌可被授予的权限
+type Scope string
+
+const (
+	ScopeSessionsCreate Scope = "sessions:create"
+	ScopeSessionsRead   Scope = "sessions:read"
+	ScopeSessionsManage Scope = "sessions:manage" // 终止、重启、配置 Agent
+	ScopeChat           Scope = "chat"
+	ScopeFilesRead      Scope = "files:read"
+	ScopeFilesWrite     Scope = "files:write"
+	ScopeServices       Scope = "services" // companion 服务和 compose stack
+	ScopePreferences    Scope = "preferences"
+	ScopeTerminal       Scope = "terminal" // 容器内交互式 shell
+)
+
+var AllScopes = []Scope{
+	ScopeSessionsCreate,
+	ScopeSessionsRead,
+	ScopeSessionsManage,
+	ScopeChat,
+	ScopeFilesRead,
+	ScopeFilesWrite,
+	ScopeServices,
+	ScopePreferences,
+	ScopeTerminal,
+}
+
+// ValidScope 判断 scope 是否为已知权限
+func ValidScope(s Scope) bool {
+	for _, known := range AllScopes {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope 交互式用户（OIDC 登录）不受 scope 限制，服务账号只拥有被授予的权限
+func (i *Identity) HasScope(s Scope) bool {
+	if i.ServiceAccountID == "" {
+		return true
+	}
+	for _, granted := range i.Scopes {
+		if granted == s {
+			return true
+		}
+	}
+	return false
+}
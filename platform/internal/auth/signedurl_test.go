@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestURLSigner(t *testing.T) {
+	signer := NewURLSigner("secret", time.Hour)
+
+	signed, expires := signer.Sign("/api/v1/sessions/s1/files/raw", url.Values{"path": {"/app/main.go"}}, "u1", 24*time.Hour)
+	if time.Until(expires) > time.Hour {
+		t.Errorf("TTL should be capped at max, got expiry %v", expires)
+	}
+
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID, err := signer.Verify(u.Path, u.Query())
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if userID != "u1" {
+		t.Errorf("Expected user u1, got %q", userID)
+	}
+
+	tampered := u.Query()
+	tampered.Set("path", "/etc/passwd")
+	if _, err := signer.Verify(u.Path, tampered); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Tampered query should be rejected, got %v", err)
+	}
+	if _, err := signer.Verify(strings.Replace(u.Path, "s1", "s2", 1), u.Query()); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Different path should be rejected, got %v", err)
+	}
+	if _, err := NewURLSigner("other", time.Hour).Verify(u.Path, u.Query()); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Different secret should be rejected, got %v", err)
+	}
+
+	expired, _ := signer.Sign("/api/v1/sessions/s1/stream", nil, "u1", -time.Minute)
+	u, _ = url.Parse(expired)
+	if _, err := signer.Verify(u.Path, u.Query()); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expired URL should be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+// Package buildinfo 平台的构建信息，发布构建通过 -ldflags 注入：
+//
+//	go build -ldflags "-X platform/internal/buildinfo.Version=v1.2.0 -X platform/internal/buildinfo.Commit=$(git rev-parse --short HEAD)"
+package buildinfo
+
+import (
+	"runtime"
+	"strings"
+)
+
+// Version 平台版本，未注入时为 dev
+var Version = "dev"
+
+// Commit 构建时的 git 提交
+var Commit = ""
+
+// Date 构建时间（RFC 3339）
+var Date = ""
+
+// AgentProtocols 兼容的 agent gRPC 协议版本（agentproto），逗号分隔
+var AgentProtocols = "1"
+
+// Info 构建信息快照
+type Info struct {
+	Version        string   `json:"version"`
+	Commit         string   `json:"commit,omitempty"`
+	Date           string   `json:"build_date,omitempty"`
+	GoVersion      string   `json:"go_version"`
+	AgentProtocols []string `json:"agent_protocols"`
+}
+
+// Get 返回当前二进制的构建信息
+func Get() Info {
+	var protocols []string
+	for _, p := range strings.Split(AgentProtocols, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			protocols = append(protocols, p)
+		}
+	}
+	return Info{
+		Version:        Version,
+		Commit:         Commit,
+		Date:           Date,
+		GoVersion:      runtime.Version(),
+		AgentProtocols: protocols,
+	}
+}
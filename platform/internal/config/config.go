@@ -4,24 +4,46 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Redis    RedisConfig
-	Postgres PostgresConfig
-	Pool     PoolConfig
-	Worker   WorkerConfig
-	Metrics  MetricsConfig
-	Log      LogConfig
-	Session  SessionCleanupConfig
+	Server    ServerConfig
+	Redis     RedisConfig
+	Postgres  PostgresConfig
+	Pool      PoolConfig
+	Worker    WorkerConfig
+	Metrics   MetricsConfig
+	Log       LogConfig
+	Session   SessionCleanupConfig
+	Outbox    OutboxConfig
+	GC        GCConfig
+	Drift     DriftConfig
+	Admin     AdminConfig
+	DiskUsage DiskUsageConfig
+	OIDC      OIDCConfig
+	SignedURL SignedURLConfig
+	HostPorts HostPortConfig
+	Sandbox   SandboxConfig
+	Cache     CacheConfig
+	Sentry    SentryConfig
+	Egress    EgressConfig
+	Storage   StorageConfig
+	Quota     QuotaConfig
+	Features  FeatureConfig
+	Notify    NotifyConfig
+	Events    EventsConfig
+	Nodes     NodesConfig
+	SLO       SLOConfig
 }
 
 type ServerConfig struct {
 	Addr         string
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	// Compression 对接受 gzip 的客户端压缩响应
+	Compression bool
 }
 
 type RedisConfig struct {
@@ -46,11 +68,165 @@ type PoolConfig struct {
 	HostRoot            string
 	ContainerMem        int64
 	ContainerCPU        float64
+	// 每个沙箱工作区的磁盘上限（MB），0 表示不限制
+	ContainerDisk int64
+	// 沙箱容器的 OCI 运行时，如 runsc（gVisor）；为空时使用 daemon 默认的 runc
+	Runtime string
+	// 受限网络策略下出网代理 sidecar 的镜像，需包含 platform-server
+	EgressProxyImage string
+
+	// 沙箱的 seccomp profile（文件路径、JSON 或 unconfined），为空时使用 Docker 默认 profile
+	SeccompProfile string
+	// 移除的 capability，未设置时使用 sandbox.DefaultCapDrop，设为 none 时不移除
+	CapDrop         []string
+	CapAdd          []string
+	NoNewPrivileges bool
+	// 不应用加固配置的项目（仅对冷启动容器生效），用于需要特殊权限的项目
+	SecurityExemptProjects []string
+	// 沙箱根文件系统只读，只有工作区和 /tmp 等目录可写
+	ReadOnlyRootFS bool
+	// 沙箱、出网代理和伴随服务容器共享的 cgroup parent（如 agent-sandbox.slice），为空时使用 daemon 默认。
+	// compose 堆栈由 docker compose 创建，不受影响
+	CgroupParent string
+	// cgroup parent 的总资源上限，0 表示不限制；仅在设置了 CgroupParent 时生效
+	CgroupMemoryMB int64
+	CgroupCPUs     float64
+	CgroupPids     int64
+	// 预热容器的 Cmd / Entrypoint（以空白分隔），都为空时只保持容器存活，由 worker 启动 agent
+	WarmCmd        []string
+	WarmEntrypoint []string
+	// 启动时烘焙预热镜像（预编译字节码、导入依赖后 docker commit），预热容器改用该镜像
+	BakeWarmImage bool
+	// 烘焙时以 sh -c 执行的命令，为空时使用内置命令
+	BakeCommand string
+	// 冷容器工作区所在的网络存储（nfs / cifs / local），为空时绑定挂载 HostRoot 下的项目目录。
+	// 所有项目共用名为 WorkspaceVolumeName 的卷，各自挂载其中的项目子目录
+	WorkspaceVolumeType     string
+	WorkspaceVolumeName     string
+	WorkspaceVolumeServer   string
+	WorkspaceVolumePath     string
+	WorkspaceVolumeFSType   string
+	WorkspaceVolumeOptions  string
+	WorkspaceVolumeUsername string
+	WorkspaceVolumePassword string
+
+	// 默认池之外按镜像划分的预热池，格式见 orchestrator.ParseWarmPools
+	WarmPools string
+
+	// 按获取压力在 AutoscaleMinIdle 与 AutoscaleMaxIdle 之间调整目标空闲数，MinIdle 作为初始值
+	Autoscale               bool
+	AutoscaleMinIdle        int
+	AutoscaleMaxIdle        int
+	AutoscaleInterval       time.Duration
+	AutoscaleWaitThreshold  time.Duration
+	AutoscaleScaleDownDelay time.Duration
+
+	// 归还的预热容器清理后放回空闲列表，而不是删除后重建
+	RecycleOnRelease bool
+
+	// DrainTimeout 关闭时等待租出的预热容器归还的时长
+	DrainTimeout time.Duration
+	// DrainForceTerminate 等待超时后由 session 清理器强制终止仍租用容器的 session（需启用 session 清理）
+	DrainForceTerminate bool
+
+	// 预热容器启动即运行 agent 时（配置了 POOL_WARM_CMD / POOL_WARM_ENTRYPOINT），
+	// 空闲容器 gRPC 健康检查的超时和移出预热池前允许的连续失败次数
+	AgentHealthTimeout  time.Duration
+	AgentHealthFailures int
+
+	// 预热容器的最长存活时间和最长连续空闲时间，0 表示不限制
+	MaxContainerAge time.Duration
+	IdleTTL         time.Duration
+
+	// 补充连续失败后暂停补充的初始时长和上限，连续进入冷却时时长翻倍
+	CooldownBase time.Duration
+	CooldownMax  time.Duration
+
+	// 按时间窗口调整默认池的最少空闲数，格式见 orchestrator.ParsePrewarmSchedule
+	PrewarmSchedule string
+	// 解释预热窗口使用的 IANA 时区，为空时使用本地时区
+	PrewarmTimezone string
+
+	// 启动后在预热池达到目标空闲数之前 /readyz 报告 warming，超过该时间仍视为就绪；0 表示不等待
+	WarmupTimeout time.Duration
+
+	// 批处理 session 排队获取预热容器超过该时间后不再让位于交互式 session
+	BatchMaxWait time.Duration
+	// 预热池调度策略：default 或 fair_share（名额用尽时按租户占用公平分配）
+	Scheduler string
+}
+
+// EgressConfig platform-server egress-proxy 子命令的配置，由 sidecar 的环境变量传入
+type EgressConfig struct {
+	Listen       string
+	AllowCIDRs   []string
+	AllowDomains []string
+}
+
+// SentryConfig 错误上报（Sentry 或兼容服务），DSN 为空时不上报
+type SentryConfig struct {
+	DSN         string
+	Environment string
+	Release     string
+	SampleRate  float64
+}
+
+type CacheConfig struct {
+	// SessionEnabled 是否使用 Redis 缓存 session 读取；关闭后每次读取都查询数据库
+	SessionEnabled bool
+}
+
+// SandboxConfig 沙箱后端：docker（默认）或 podman
+type SandboxConfig struct {
+	Backend string
+	// PodmanSocket Podman API 地址，为空时使用 $XDG_RUNTIME_DIR/podman/podman.sock
+	PodmanSocket string
+
+	// 每个容器同时运行的 exec 上限（0 表示不限制）、排队上限和排队超时
+	ExecMaxConcurrent int
+	ExecMaxQueued     int
+	ExecQueueTimeout  time.Duration
+
+	// 终端启动命令的策略，列表未设置时使用 execpolicy 的默认值
+	ExecPolicyEnabled   bool
+	ExecDeniedBinaries  []string
+	ExecAllowedWorkDirs []string
+	ExecScrubEnv        []string
+
+	// 允许 session 通过 mounts 绑定挂载的宿主机目录，为空时不允许 bind 挂载
+	MountRoots []string
+	// 允许 session 通过 mounts 挂载的命名卷，以 * 结尾时按前缀匹配，为空时不允许挂载命名卷
+	MountVolumes []string
+	// DatasetCatalog 只读数据集目录文件（JSON 数组），session 以 "dataset:<name>" 引用
+	DatasetCatalog string
+	// ArchiveMaxMB 工作区归档下载的未压缩大小上限
+	ArchiveMaxMB int64
+
+	// SecretFilePatterns 视为凭据的文件名，同步回宿主机和打包归档时排除，为空时使用 secretfile 的默认值
+	SecretFilePatterns []string
+	// SecretKey base64 编码的 32 字节主密钥，设置后凭据文件按租户加密同步回宿主机
+	SecretKey string
+
+	// 停止沙箱容器时等待进程退出的时间、删除运行中的容器时是否强制删除，
+	// 以及启动失败的容器是否只停止不删除，保留给排查
+	StopTimeout          time.Duration
+	ForceRemove          bool
+	KeepFailedContainers bool
 }
 
 type WorkerConfig struct {
 	ProjectDir  string
 	Concurrency int
+	// 会话创建任务的心跳间隔
+	HeartbeatInterval time.Duration
+	// 超过此时长没有心跳的任务在 /admin/tasks 中标记为 stale
+	StaleAfter time.Duration
+	// 首选策略取不到容器时是否默认改用另一种策略，请求中的 fallback 优先
+	StrategyFallback bool
+	// 允许改用冷启动时，从预热池等待容器的最长时间
+	FallbackWait time.Duration
+	// 向排队中的 session 发布排队位置事件的间隔，0 表示不发布（GET /sessions/:id 仍返回排队位置）
+	QueueEventInterval time.Duration
 }
 
 type MetricsConfig struct {
@@ -67,8 +243,20 @@ type LogConfig struct {
 	// 默认使用 LogConfig.Dir。
 	ContainerLogDir string
 
+	// DebugBundles 失败 session 调试包的保存位置：log（Dir/debug-bundles）、storage（对象存储）或 off
+	DebugBundles string
+
 	// Level 日志级别：debug, info, warn, error
 	Level string
+
+	// ComponentLevels 按组件覆盖级别，如 "pool=debug,api=info"
+	ComponentLevels string
+
+	// 采样：每个周期内同一条日志前 SamplingInitial 次全部输出，之后每 SamplingThereafter 次输出一次；
+	// SamplingInitial 为 0 时不采样
+	SamplingInitial    int
+	SamplingThereafter int
+	SamplingInterval   time.Duration
 }
 
 type SessionCleanupConfig struct {
@@ -77,6 +265,189 @@ type SessionCleanupConfig struct {
 	MaxAge time.Duration
 	// 是否启用自动清理
 	Enabled bool
+	// CheckpointDir 休眠检查点目录（宿主机路径），为空时使用 Docker 默认目录
+	CheckpointDir string
+	// IdleTimeout 无对话、终端和文件操作超过此时长的就绪 session 被挂起（停止容器，可恢复），0 表示不挂起
+	IdleTimeout time.Duration
+	// IdleCheckInterval 检查空闲 session 的间隔
+	IdleCheckInterval time.Duration
+	// HealDeadContainers 预热容器租出后意外退出时用工作区快照重新分配容器，false 时将 session 标记为 error
+	HealDeadContainers bool
+}
+
+type StorageConfig struct {
+	// Driver 对象存储驱动：local 或 s3
+	Driver string
+	// LocalDir local 驱动的根目录，可以是多个节点共享的挂载目录
+	LocalDir string
+	// Projects 为 true 时项目文件同步到对象存储，worker 优先从对象存储初始化工作区
+	Projects bool
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool
+}
+
+// QuotaConfig 伴随服务与 compose 服务的配额以及 session 并发配额，上限为 0 表示该项不限制
+type QuotaConfig struct {
+	Enabled bool
+
+	SessionServices int
+	SessionMemoryMB int64
+	SessionCPUs     float64
+
+	// 租户为 session 所属用户，统计其所有 session 和项目级服务的占用
+	TenantServices int
+	TenantMemoryMB int64
+	TenantCPUs     float64
+
+	// 每个用户、每个项目同时运行的 session 数量和沙箱资源总量，按沙箱容器的资源上限计入
+	UserSessions    int
+	UserMemoryMB    int64
+	UserCPUs        float64
+	ProjectSessions int
+	ProjectMemoryMB int64
+	ProjectCPUs     float64
+}
+
+// FeatureConfig 功能开关的默认值，运行时覆盖通过管理接口写入 Redis
+type FeatureConfig struct {
+	// Defaults 覆盖内置默认值，如 "pool_fifo_acquire=true,coalesced_streaming=false"
+	Defaults string
+	// RefreshInterval 从 Redis 重新加载覆盖的间隔
+	RefreshInterval time.Duration
+}
+
+// NotifyConfig 运维告警的发送渠道和阈值，Slack 与邮件均未配置时不发送告警
+type NotifyConfig struct {
+	SlackWebhook string
+
+	SMTPAddr     string
+	SMTPFrom     string
+	SMTPTo       []string
+	SMTPUsername string
+	SMTPPassword string
+
+	// Throttle 同一告警的最小发送间隔
+	Throttle time.Duration
+	// Alerts 启用的告警类型，为空时全部启用
+	Alerts []string
+
+	// CreateFailures 容器连续创建失败达到此次数时告警
+	CreateFailures int
+	// SessionErrorRate 窗口内 session 创建失败比例达到此值（0-1）时告警，0 表示不告警
+	SessionErrorRate       float64
+	SessionErrorWindow     time.Duration
+	SessionErrorMinSamples int
+	// DiskPercent 平台目录所在文件系统使用率达到此百分比时告警，0 表示不告警
+	DiskPercent float64
+}
+
+// EventsConfig 事件总线的负载大小限制
+type EventsConfig struct {
+	// MaxPayloadBytes 事件负载 JSON 的大小上限，超出的负载转存到 Redis，事件中只保留引用和预览；0 表示不限制
+	MaxPayloadBytes int
+	// OverflowTTL 转存负载的保留时长
+	OverflowTTL time.Duration
+}
+
+// SLOConfig 会话创建和对话分发的服务等级目标
+type SLOConfig struct {
+	// SessionCreateTarget session 创建成功率目标（0-1），SessionCreateLatency 为 session 从创建到就绪的耗时目标，超过的也计为不达标；0 表示不考虑耗时
+	SessionCreateTarget  float64
+	SessionCreateLatency time.Duration
+	// ChatTarget / ChatLatency 对话分发的成功率和耗时目标
+	ChatTarget  float64
+	ChatLatency time.Duration
+	// Windows 计算成功率和 burn rate 的时间窗口
+	Windows []time.Duration
+	// Interval 刷新 burn rate 指标的间隔
+	Interval time.Duration
+}
+
+// NodesConfig 放置冷启动容器的远程 Docker 节点
+type NodesConfig struct {
+	// Remote 远程节点列表，格式 id=tcp://host:2376?max=20；为空时只使用本机
+	Remote []string
+	// TLSDir 每个节点的客户端证书放在 <TLSDir>/<id> 下，为空时不使用 TLS
+	TLSDir string
+	// LocalMaxContainers 本机平台容器的上限，0 表示不限制
+	LocalMaxContainers int
+}
+
+type OutboxConfig struct {
+	// 扫描未投递任务的间隔
+	Interval time.Duration
+	// 任务写入后超过此时长仍未投递才由 relay 补投
+	Grace time.Duration
+	// 已投递记录的保留时长
+	Retention time.Duration
+}
+
+type HostPortConfig struct {
+	// companion / compose 服务发布到宿主机的端口范围（闭区间）
+	Min int
+	Max int
+}
+
+type GCConfig struct {
+	// 是否启用宿主机目录 GC
+	Enabled  bool
+	Interval time.Duration
+	// 会话终止超过此时长后，其项目目录、compose 目录和 exec 日志被删除
+	Retention time.Duration
+	// 受管目录总大小上限（MB），0 表示不限制
+	SizeBudgetMB int64
+	// 只记录将要删除的目录，不实际删除
+	DryRun bool
+}
+
+type DriftConfig struct {
+	// 是否启用 session 容器状态对账
+	Enabled  bool
+	Interval time.Duration
+	// Repair 为 false 时只报告 IP / 资源限制漂移，不修复
+	Repair bool
+}
+
+type AdminConfig struct {
+	// Token 管理接口（/api/v1/admin）的访问令牌，为空时禁用管理接口
+	Token string
+}
+
+type DiskUsageConfig struct {
+	// 存储占用统计（Prometheus 指标）的刷新间隔
+	Interval time.Duration
+	// 绑定挂载工作区的磁盘配额检查间隔，POOL_CONTAINER_DISK_MB 为 0 时不检查
+	QuotaInterval time.Duration
+	// 工作区超出配额后终止 session，否则只发布事件
+	QuotaTerminate bool
+}
+
+type OIDCConfig struct {
+	// IssuerURL 为空时不启用 OIDC，API 沿用请求体中的 user_id
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL 授权码回调地址，应指向 /api/v1/auth/callback
+	RedirectURL string
+	Scopes      []string
+	// Audience 校验 token 的 aud，默认为 ClientID
+	Audience string
+	// UserClaim 映射为平台 user_id 的 claim，默认 sub
+	UserClaim string
+	// TenantClaim 映射为租户 ID 的 claim，为空时不提取
+	TenantClaim string
+}
+
+type SignedURLConfig struct {
+	// Secret HMAC 密钥，为空时每次启动随机生成（多实例部署需显式配置）
+	Secret     string
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
 }
 
 // Load 加载配置
@@ -87,6 +458,7 @@ func Load() *Config {
 			Addr:         getEnv("SERVER_ADDR", ":8080"),
 			ReadTimeout:  getDurationEnv("SERVER_READ_TIMEOUT", 30*time.Second),
 			WriteTimeout: getDurationEnv("SERVER_WRITE_TIMEOUT", 120*time.Second),
+			Compression:  getBoolEnv("SERVER_COMPRESSION", true),
 		},
 		Redis: RedisConfig{
 			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
@@ -108,10 +480,75 @@ func Load() *Config {
 			HostRoot:            getEnv("POOL_HOST_ROOT", defaultHostRoot()),
 			ContainerMem:        int64(getIntEnv("POOL_CONTAINER_MEM_MB", 512)),
 			ContainerCPU:        getFloatEnv("POOL_CONTAINER_CPU", 0.5),
+			ContainerDisk:       int64(getIntEnv("POOL_CONTAINER_DISK_MB", 0)),
+			Runtime:             getEnv("POOL_RUNTIME", ""),
+			EgressProxyImage:    getEnv("POOL_EGRESS_PROXY_IMAGE", "agent-platform-server:latest"),
+
+			SeccompProfile:         getEnv("POOL_SECCOMP_PROFILE", ""),
+			CapDrop:                getListEnv("POOL_CAP_DROP", nil),
+			CapAdd:                 getListEnv("POOL_CAP_ADD", nil),
+			NoNewPrivileges:        getBoolEnv("POOL_NO_NEW_PRIVILEGES", true),
+			SecurityExemptProjects: getListEnv("POOL_SECURITY_EXEMPT_PROJECTS", nil),
+			ReadOnlyRootFS:         getBoolEnv("POOL_READONLY_ROOTFS", false),
+			CgroupParent:           getEnv("POOL_CGROUP_PARENT", ""),
+			CgroupMemoryMB:         int64(getIntEnv("POOL_CGROUP_MEMORY_MB", 0)),
+			CgroupCPUs:             getFloatEnv("POOL_CGROUP_CPUS", 0),
+			CgroupPids:             int64(getIntEnv("POOL_CGROUP_PIDS", 0)),
+			WarmCmd:                strings.Fields(getEnv("POOL_WARM_CMD", "")),
+			WarmEntrypoint:         strings.Fields(getEnv("POOL_WARM_ENTRYPOINT", "")),
+			BakeWarmImage:          getBoolEnv("POOL_BAKE_WARM_IMAGE", false),
+			BakeCommand:            getEnv("POOL_BAKE_COMMAND", ""),
+
+			WorkspaceVolumeType:     getEnv("POOL_WORKSPACE_VOLUME_TYPE", ""),
+			WorkspaceVolumeName:     getEnv("POOL_WORKSPACE_VOLUME_NAME", "agent-workspaces"),
+			WorkspaceVolumeServer:   getEnv("POOL_WORKSPACE_VOLUME_SERVER", ""),
+			WorkspaceVolumePath:     getEnv("POOL_WORKSPACE_VOLUME_PATH", ""),
+			WorkspaceVolumeFSType:   getEnv("POOL_WORKSPACE_VOLUME_FS_TYPE", ""),
+			WorkspaceVolumeOptions:  getEnv("POOL_WORKSPACE_VOLUME_OPTIONS", ""),
+			WorkspaceVolumeUsername: getEnv("POOL_WORKSPACE_VOLUME_USERNAME", ""),
+			WorkspaceVolumePassword: getEnv("POOL_WORKSPACE_VOLUME_PASSWORD", ""),
+
+			WarmPools: getEnv("POOL_WARM_POOLS", ""),
+
+			Autoscale:               getBoolEnv("POOL_AUTOSCALE", false),
+			AutoscaleMinIdle:        getIntEnv("POOL_AUTOSCALE_MIN_IDLE", 0),
+			AutoscaleMaxIdle:        getIntEnv("POOL_AUTOSCALE_MAX_IDLE", 0),
+			AutoscaleInterval:       getDurationEnv("POOL_AUTOSCALE_INTERVAL", time.Minute),
+			AutoscaleWaitThreshold:  getDurationEnv("POOL_AUTOSCALE_WAIT_THRESHOLD", 500*time.Millisecond),
+			AutoscaleScaleDownDelay: getDurationEnv("POOL_AUTOSCALE_SCALE_DOWN_DELAY", 10*time.Minute),
+
+			RecycleOnRelease: getBoolEnv("POOL_RECYCLE_ON_RELEASE", false),
+
+			DrainTimeout:        getDurationEnv("POOL_DRAIN_TIMEOUT", 30*time.Second),
+			DrainForceTerminate: getBoolEnv("POOL_DRAIN_FORCE_TERMINATE", true),
+
+			AgentHealthTimeout:  getDurationEnv("POOL_AGENT_HEALTH_TIMEOUT", time.Second),
+			AgentHealthFailures: getIntEnv("POOL_AGENT_HEALTH_FAILURES", 3),
+
+			MaxContainerAge: getDurationEnv("POOL_MAX_CONTAINER_AGE", 0),
+			IdleTTL:         getDurationEnv("POOL_IDLE_TTL", 0),
+
+			CooldownBase: getDurationEnv("POOL_COOLDOWN_BASE", time.Minute),
+			CooldownMax:  getDurationEnv("POOL_COOLDOWN_MAX", 30*time.Minute),
+
+			PrewarmSchedule: getEnv("POOL_PREWARM_SCHEDULE", ""),
+			PrewarmTimezone: getEnv("POOL_PREWARM_TIMEZONE", ""),
+
+			WarmupTimeout: getDurationEnv("POOL_WARMUP_TIMEOUT", time.Minute),
+			BatchMaxWait:  getDurationEnv("POOL_BATCH_MAX_WAIT", 30*time.Second),
+			Scheduler:     getEnv("POOL_SCHEDULER", "default"),
 		},
 		Worker: WorkerConfig{
 			ProjectDir:  getEnv("WORKER_PROJECT_DIR", defaultProjectDir()),
 			Concurrency: getIntEnv("WORKER_CONCURRENCY", 5),
+
+			HeartbeatInterval: getDurationEnv("WORKER_HEARTBEAT_INTERVAL", 5*time.Second),
+			StaleAfter:        getDurationEnv("WORKER_STALE_AFTER", 30*time.Second),
+
+			StrategyFallback: getBoolEnv("SESSION_STRATEGY_FALLBACK", false),
+			FallbackWait:     getDurationEnv("WORKER_FALLBACK_WAIT", 10*time.Second),
+
+			QueueEventInterval: getDurationEnv("WORKER_QUEUE_EVENT_INTERVAL", 5*time.Second),
 		},
 		Metrics: MetricsConfig{
 			Addr: getEnv("METRICS_ADDR", ":9090"),
@@ -119,12 +556,171 @@ func Load() *Config {
 		Log: LogConfig{
 			Dir:             logDir,
 			ContainerLogDir: getEnv("CONTAINER_LOG_DIR", filepath.Join(logDir, "containers")),
+			DebugBundles:    getEnv("DEBUG_BUNDLES", "log"),
 			Level:           getEnv("LOG_LEVEL", "info"),
+			ComponentLevels: getEnv("LOG_LEVELS", ""),
+
+			SamplingInitial:    getIntEnv("LOG_SAMPLING_INITIAL", 20),
+			SamplingThereafter: getIntEnv("LOG_SAMPLING_THEREAFTER", 100),
+			SamplingInterval:   getDurationEnv("LOG_SAMPLING_INTERVAL", time.Second),
 		},
 		Session: SessionCleanupConfig{
-			Interval: getDurationEnv("SESSION_CLEANUP_INTERVAL", 2*time.Minute),
-			MaxAge:   getDurationEnv("SESSION_MAX_AGE", 30*time.Minute),
-			Enabled:  getBoolEnv("SESSION_CLEANUP_ENABLED", true),
+			Interval:      getDurationEnv("SESSION_CLEANUP_INTERVAL", 2*time.Minute),
+			MaxAge:        getDurationEnv("SESSION_MAX_AGE", 30*time.Minute),
+			Enabled:       getBoolEnv("SESSION_CLEANUP_ENABLED", true),
+			CheckpointDir: getEnv("SESSION_CHECKPOINT_DIR", ""),
+
+			IdleTimeout:       getDurationEnv("SESSION_IDLE_TIMEOUT", 0),
+			IdleCheckInterval: getDurationEnv("SESSION_IDLE_CHECK_INTERVAL", time.Minute),
+
+			HealDeadContainers: getBoolEnv("SESSION_HEAL_DEAD_CONTAINERS", false),
+		},
+		Storage: StorageConfig{
+			Driver:   getEnv("STORAGE_DRIVER", "local"),
+			LocalDir: getEnv("STORAGE_LOCAL_DIR", defaultStorageDir()),
+			Projects: getBoolEnv("STORAGE_PROJECTS", false),
+
+			S3Endpoint:  getEnv("STORAGE_S3_ENDPOINT", ""),
+			S3Region:    getEnv("STORAGE_S3_REGION", "us-east-1"),
+			S3Bucket:    getEnv("STORAGE_S3_BUCKET", ""),
+			S3AccessKey: getEnv("STORAGE_S3_ACCESS_KEY", ""),
+			S3SecretKey: getEnv("STORAGE_S3_SECRET_KEY", ""),
+			S3PathStyle: getBoolEnv("STORAGE_S3_PATH_STYLE", true),
+		},
+		Quota: QuotaConfig{
+			Enabled:         getBoolEnv("QUOTA_ENABLED", true),
+			SessionServices: getIntEnv("QUOTA_SESSION_SERVICES", 8),
+			SessionMemoryMB: int64(getIntEnv("QUOTA_SESSION_MEMORY_MB", 4096)),
+			SessionCPUs:     getFloatEnv("QUOTA_SESSION_CPUS", 4),
+			TenantServices:  getIntEnv("QUOTA_TENANT_SERVICES", 32),
+			TenantMemoryMB:  int64(getIntEnv("QUOTA_TENANT_MEMORY_MB", 16384)),
+			TenantCPUs:      getFloatEnv("QUOTA_TENANT_CPUS", 16),
+			UserSessions:    getIntEnv("QUOTA_USER_SESSIONS", 0),
+			UserMemoryMB:    int64(getIntEnv("QUOTA_USER_MEMORY_MB", 0)),
+			UserCPUs:        getFloatEnv("QUOTA_USER_CPUS", 0),
+			ProjectSessions: getIntEnv("QUOTA_PROJECT_SESSIONS", 0),
+			ProjectMemoryMB: int64(getIntEnv("QUOTA_PROJECT_MEMORY_MB", 0)),
+			ProjectCPUs:     getFloatEnv("QUOTA_PROJECT_CPUS", 0),
+		},
+		Notify: NotifyConfig{
+			SlackWebhook: getEnv("NOTIFY_SLACK_WEBHOOK", ""),
+			SMTPAddr:     getEnv("NOTIFY_SMTP_ADDR", ""),
+			SMTPFrom:     getEnv("NOTIFY_SMTP_FROM", ""),
+			SMTPTo:       getListEnv("NOTIFY_SMTP_TO", nil),
+			SMTPUsername: getEnv("NOTIFY_SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("NOTIFY_SMTP_PASSWORD", ""),
+			Throttle:     getDurationEnv("NOTIFY_THROTTLE", 15*time.Minute),
+			Alerts:       getListEnv("NOTIFY_ALERTS", nil),
+
+			CreateFailures:         getIntEnv("NOTIFY_CREATE_FAILURES", 5),
+			SessionErrorRate:       getFloatEnv("NOTIFY_SESSION_ERROR_RATE", 0.5),
+			SessionErrorWindow:     getDurationEnv("NOTIFY_SESSION_ERROR_WINDOW", 10*time.Minute),
+			SessionErrorMinSamples: getIntEnv("NOTIFY_SESSION_ERROR_MIN_SAMPLES", 10),
+			DiskPercent:            getFloatEnv("NOTIFY_DISK_PERCENT", 90),
+		},
+		Features: FeatureConfig{
+			Defaults:        getEnv("FEATURE_FLAGS", ""),
+			RefreshInterval: getDurationEnv("FEATURE_FLAGS_REFRESH_INTERVAL", 10*time.Second),
+		},
+		Events: EventsConfig{
+			MaxPayloadBytes: getIntEnv("EVENTBUS_MAX_PAYLOAD_BYTES", 64<<10),
+			OverflowTTL:     getDurationEnv("EVENTBUS_OVERFLOW_TTL", time.Hour),
+		},
+		Nodes: NodesConfig{
+			Remote:             getListEnv("NODES", nil),
+			TLSDir:             getEnv("NODES_TLS_DIR", ""),
+			LocalMaxContainers: getIntEnv("NODE_LOCAL_MAX_CONTAINERS", 0),
+		},
+		SLO: SLOConfig{
+			SessionCreateTarget:  getFloatEnv("SLO_SESSION_CREATE_TARGET", 0.99),
+			SessionCreateLatency: getDurationEnv("SLO_SESSION_CREATE_LATENCY", time.Minute),
+			ChatTarget:           getFloatEnv("SLO_CHAT_TARGET", 0.999),
+			ChatLatency:          getDurationEnv("SLO_CHAT_LATENCY", 5*time.Second),
+			Windows:              getDurationListEnv("SLO_WINDOWS", []time.Duration{5 * time.Minute, time.Hour, 6 * time.Hour}),
+			Interval:             getDurationEnv("SLO_INTERVAL", 30*time.Second),
+		},
+		Outbox: OutboxConfig{
+			Interval:  getDurationEnv("OUTBOX_RELAY_INTERVAL", 5*time.Second),
+			Grace:     getDurationEnv("OUTBOX_RELAY_GRACE", 10*time.Second),
+			Retention: getDurationEnv("OUTBOX_RETENTION", 24*time.Hour),
+		},
+		GC: GCConfig{
+			Enabled:      getBoolEnv("GC_ENABLED", true),
+			Interval:     getDurationEnv("GC_INTERVAL", 1*time.Hour),
+			Retention:    getDurationEnv("GC_RETENTION", 7*24*time.Hour),
+			SizeBudgetMB: int64(getIntEnv("GC_SIZE_BUDGET_MB", 0)),
+			DryRun:       getBoolEnv("GC_DRY_RUN", false),
+		},
+		Drift: DriftConfig{
+			Enabled:  getBoolEnv("DRIFT_RECONCILE_ENABLED", true),
+			Interval: getDurationEnv("DRIFT_RECONCILE_INTERVAL", time.Minute),
+			Repair:   getBoolEnv("DRIFT_REPAIR", true),
+		},
+		Admin: AdminConfig{
+			Token: getEnv("ADMIN_TOKEN", ""),
+		},
+		DiskUsage: DiskUsageConfig{
+			Interval:       getDurationEnv("DISK_USAGE_INTERVAL", 5*time.Minute),
+			QuotaInterval:  getDurationEnv("DISK_QUOTA_INTERVAL", time.Minute),
+			QuotaTerminate: getBoolEnv("DISK_QUOTA_TERMINATE", false),
+		},
+		OIDC: OIDCConfig{
+			IssuerURL:    getEnv("OIDC_ISSUER_URL", ""),
+			ClientID:     getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:  getEnv("OIDC_REDIRECT_URL", ""),
+			Scopes:       getListEnv("OIDC_SCOPES", []string{"openid", "profile", "email"}),
+			Audience:     getEnv("OIDC_AUDIENCE", ""),
+			UserClaim:    getEnv("OIDC_USER_CLAIM", "sub"),
+			TenantClaim:  getEnv("OIDC_TENANT_CLAIM", ""),
+		},
+		SignedURL: SignedURLConfig{
+			Secret:     getEnv("SIGNED_URL_SECRET", ""),
+			DefaultTTL: getDurationEnv("SIGNED_URL_DEFAULT_TTL", 15*time.Minute),
+			MaxTTL:     getDurationEnv("SIGNED_URL_MAX_TTL", 24*time.Hour),
+		},
+		HostPorts: HostPortConfig{
+			Min: getIntEnv("HOST_PORT_MIN", 20000),
+			Max: getIntEnv("HOST_PORT_MAX", 20999),
+		},
+		Sandbox: SandboxConfig{
+			Backend:      getEnv("SANDBOX_BACKEND", "docker"),
+			PodmanSocket: getEnv("PODMAN_SOCKET", ""),
+
+			ExecMaxConcurrent: getIntEnv("SANDBOX_EXEC_MAX_CONCURRENT", 8),
+			ExecMaxQueued:     getIntEnv("SANDBOX_EXEC_MAX_QUEUED", 16),
+			ExecQueueTimeout:  getDurationEnv("SANDBOX_EXEC_QUEUE_TIMEOUT", 30*time.Second),
+
+			ExecPolicyEnabled:   getBoolEnv("EXEC_POLICY_ENABLED", true),
+			ExecDeniedBinaries:  getListEnv("EXEC_POLICY_DENIED_BINARIES", nil),
+			ExecAllowedWorkDirs: getListEnv("EXEC_POLICY_ALLOWED_WORKDIRS", nil),
+			ExecScrubEnv:        getListEnv("EXEC_POLICY_SCRUB_ENV", nil),
+
+			MountRoots:     getListEnv("SANDBOX_MOUNT_ROOTS", nil),
+			MountVolumes:   getListEnv("SANDBOX_MOUNT_VOLUMES", nil),
+			DatasetCatalog: getEnv("SANDBOX_DATASET_CATALOG", ""),
+			ArchiveMaxMB:   int64(getIntEnv("SANDBOX_ARCHIVE_MAX_MB", 1024)),
+
+			SecretFilePatterns: getListEnv("SANDBOX_SECRET_FILE_PATTERNS", nil),
+			SecretKey:          getEnv("SANDBOX_SECRET_KEY", ""),
+
+			StopTimeout:          getDurationEnv("SANDBOX_STOP_TIMEOUT", 10*time.Second),
+			ForceRemove:          getBoolEnv("SANDBOX_FORCE_REMOVE", true),
+			KeepFailedContainers: getBoolEnv("SANDBOX_KEEP_FAILED_CONTAINERS", false),
+		},
+		Sentry: SentryConfig{
+			DSN:         getEnv("SENTRY_DSN", ""),
+			Environment: getEnv("SENTRY_ENVIRONMENT", "production"),
+			Release:     getEnv("SENTRY_RELEASE", ""),
+			SampleRate:  getFloatEnv("SENTRY_SAMPLE_RATE", 1.0),
+		},
+		Cache: CacheConfig{
+			SessionEnabled: getBoolEnv("SESSION_CACHE_ENABLED", true),
+		},
+		Egress: EgressConfig{
+			Listen:       getEnv("EGRESS_LISTEN", ":3128"),
+			AllowCIDRs:   getListEnv("EGRESS_ALLOW_CIDRS", nil),
+			AllowDomains: getListEnv("EGRESS_ALLOW_DOMAINS", nil),
 		},
 	}
 }
@@ -163,6 +759,38 @@ func getDurationEnv(key string, defaultVal time.Duration) time.Duration {
 	return defaultVal
 }
 
+// getListEnv 解析逗号分隔的列表
+func getListEnv(key string, defaultVal []string) []string {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultVal
+	}
+	var out []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// getDurationListEnv 解析逗号分隔的时长列表，任一项无效时使用默认值
+func getDurationListEnv(key string, defaultVal []time.Duration) []time.Duration {
+	items := getListEnv(key, nil)
+	if len(items) == 0 {
+		return defaultVal
+	}
+	out := make([]time.Duration, 0, len(items))
+	for _, item := range items {
+		d, err := time.ParseDuration(item)
+		if err != nil || d <= 0 {
+			return defaultVal
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
 func getBoolEnv(key string, defaultVal bool) bool {
 	if val := os.Getenv(key); val != "" {
 		switch val {
@@ -193,6 +821,15 @@ func defaultProjectDir() string {
 	return filepath.Join(home, ".agent-platform", "projects")
 }
 
+// defaultStorageDir 返回 local 对象存储驱动的默认根目录。
+func defaultStorageDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "/tmp/agent-platform/storage"
+	}
+	return filepath.Join(home, ".agent-platform", "storage")
+}
+
 // defaultLogDir 返回默认的日志目录。
 func defaultLogDir() string {
 	home, err := os.UserHomeDir()
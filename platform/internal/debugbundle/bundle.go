@@ -0,0 +1,216 @@
+// Package debugbundle 在 session 创建失败时收集排查所需的现场信息：
+// 容器日志、agent 日志、容器 inspect、最近的 exec 记录和 worker 各阶段耗时，打包为 tar.gz 保存到存储中
+package debugbundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/client"
+
+	"platform/internal/nodes"
+	"platform/internal/sandbox"
+	"platform/internal/session"
+	"platform/internal/storage"
+	"platform/internal/taskstatus"
+)
+
+// ErrNotFound 调试包不存在
+var ErrNotFound = errors.New("debug bundle not found")
+
+const (
+	// DefaultLogTail 收集的容器日志行数
+	DefaultLogTail = 500
+	// DefaultExecEntries 收集的最近 exec 记录条数
+	DefaultExecEntries = 50
+	// agentLogPath agent 服务在容器内的日志文件
+	agentLogPath = "/tmp/agent.log"
+)
+
+// Summary 调试包中的 summary.json
+type Summary struct {
+	SessionID   string                   `json:"session_id"`
+	ProjectID   string                   `json:"project_id"`
+	UserID      string                   `json:"user_id"`
+	Status      session.SessionStatus    `json:"status"`
+	Strategy    string                   `json:"strategy"`
+	ContainerID string                   `json:"container_id,omitempty"`
+	NodeID      string                   `json:"node_id,omitempty"`
+	CollectedAt time.Time                `json:"collected_at"`
+	Phases      []taskstatus.PhaseTiming `json:"phases,omitempty"`
+	// Errors 未能收集的条目及原因，如容器已被删除
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// Collector 收集并保存调试包，保存为存储中的 debug-bundles/<session_id>/<时间>.tar.gz
+type Collector struct {
+	blob   storage.Blob
+	docker *client.Client
+	logger *slog.Logger
+
+	// Nodes 多节点部署时按 session 的 NodeID 选择 Docker 客户端
+	Nodes *nodes.Registry
+	// LogTail 收集的容器日志行数，<=0 时使用 DefaultLogTail
+	LogTail int
+	// ExecEntries 收集的最近 exec 记录条数，<=0 时使用 DefaultExecEntries
+	ExecEntries int
+}
+
+func NewCollector(blob storage.Blob, docker *client.Client, logger *slog.Logger) *Collector {
+	return &Collector{blob: blob, docker: docker, logger: logger.With("component", "debug-bundle")}
+}
+
+// Collect 收集 sess 的调试包并返回其 key。单个条目收集失败只记录在 summary 中，不影响其他条目
+func (c *Collector) Collect(ctx context.Context, sess *session.Session, phases []taskstatus.PhaseTiming) (string, error) {
+	now := time.Now()
+	summary := Summary{
+		SessionID:   sess.ID,
+		ProjectID:   sess.ProjectID,
+		UserID:      sess.UserID,
+		Status:      sess.Status,
+		Strategy:    string(sess.Strategy),
+		ContainerID: sess.ContainerID,
+		NodeID:      sess.NodeID,
+		CollectedAt: now,
+		Phases:      phases,
+		Errors:      make(map[string]string),
+	}
+
+	files := make(map[string][]byte)
+	if sess.ContainerID == "" {
+		summary.Errors["container"] = "session has no container"
+	} else {
+		c.collectContainer(ctx, sess, files, summary.Errors)
+	}
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal summary: %w", err)
+	}
+	files["summary.json"] = data
+
+	archive, err := buildArchive(sess.ID, now, files)
+	if err != nil {
+		return "", err
+	}
+	key := path.Join("debug-bundles", sess.ID, now.UTC().Format("20060102T150405Z")+".tar.gz")
+	if err := c.blob.Put(ctx, key, bytes.NewReader(archive)); err != nil {
+		return "", fmt.Errorf("failed to store debug bundle: %w", err)
+	}
+	c.logger.Info("Debug bundle collected", "session_id", sess.ID, "key", key, "size", len(archive), "skipped", len(summary.Errors))
+	return key, nil
+}
+
+func (c *Collector) collectContainer(ctx context.Context, sess *session.Session, files map[string][]byte, errs map[string]string) {
+	docker := c.docker
+	if c.Nodes != nil && sess.NodeID != "" {
+		node, err := c.Nodes.Node(sess.NodeID)
+		if err != nil {
+			errs["container"] = err.Error()
+			return
+		}
+		docker = node.Client
+	}
+
+	inspect, inspectErr := docker.ContainerInspect(ctx, sess.ContainerID)
+	if inspectErr != nil {
+		errs["inspect.json"] = inspectErr.Error()
+	} else if data, err := json.MarshalIndent(inspect, "", "  "); err == nil {
+		files["inspect.json"] = data
+	}
+
+	ctr := sandbox.NewContainer(docker, sandbox.ContainerConfig{
+		SessionID:       sess.ID,
+		ProjectID:       sess.ProjectID,
+		UseAnonymousVol: true,
+	}, "", c.logger)
+	ctr.ID = sess.ContainerID
+
+	tail := c.LogTail
+	if tail <= 0 {
+		tail = DefaultLogTail
+	}
+	if logs, err := ctr.GetLogs(ctx, tail); err != nil {
+		errs["container.log"] = err.Error()
+	} else {
+		files["container.log"] = []byte(logs.Stdout + logs.Stderr)
+	}
+
+	// exec 记录先于读取 agent 日志收集，避免把收集调试包本身的 exec 计入
+	if entries, err := ctr.GetExecLogs(ctx); err != nil {
+		errs["exec_log.jsonl"] = err.Error()
+	} else {
+		limit := c.ExecEntries
+		if limit <= 0 {
+			limit = DefaultExecEntries
+		}
+		if len(entries) > limit {
+			entries = entries[len(entries)-limit:]
+		}
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, e := range entries {
+			_ = enc.Encode(e)
+		}
+		files["exec_log.jsonl"] = buf.Bytes()
+	}
+
+	// agent 日志只能在运行中的容器里读取
+	if inspectErr != nil || inspect.State == nil || !inspect.State.Running {
+		errs["agent.log"] = "container is not running"
+		return
+	}
+	if res, err := ctr.Exec(ctx, []string{"cat", agentLogPath}, nil, "/"); err != nil {
+		errs["agent.log"] = err.Error()
+	} else {
+		files["agent.log"] = []byte(res.Stdout + res.Stderr)
+	}
+}
+
+// buildArchive 打包为 tar.gz，条目放在 <session_id>/ 目录下
+func buildArchive(sessionID string, modTime time.Time, files map[string][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range []string{"summary.json", "inspect.json", "container.log", "agent.log", "exec_log.jsonl"} {
+		data, ok := files[name]
+		if !ok {
+			continue
+		}
+		header := &tar.Header{Name: sessionID + "/" + name, Mode: 0644, Size: int64(len(data)), ModTime: modTime}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, fmt.Errorf("failed to write debug bundle: %w", err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to write debug bundle: %w", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write debug bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write debug bundle: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Open 打开调试包，调用方负责关闭
+func (c *Collector) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(key, "debug-bundles/") {
+		return nil, ErrNotFound
+	}
+	r, err := c.blob.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return r, err
+}
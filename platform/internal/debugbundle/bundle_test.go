@@ -0,0 +1,70 @@
+package debugbundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"platform/internal/session"
+	"platform/internal/storage"
+	"platform/internal/taskstatus"
+)
+
+func TestCollectWithoutContainer(t *testing.T) {
+	c := NewCollector(storage.NewLocalBlob(t.TempDir()), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+	sess := &session.Session{ID: "s1", ProjectID: "p1", Status: session.StatusError}
+	phases := []taskstatus.PhaseTiming{{Phase: taskstatus.PhaseAcquiring, Duration: 2 * time.Second}}
+
+	key, err := c.Collect(ctx, sess, phases)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if !strings.HasPrefix(key, "debug-bundles/s1/") {
+		t.Errorf("unexpected key %q", key)
+	}
+
+	r, err := c.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer r.Close()
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	header, err := tr.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Name != "s1/summary.json" {
+		t.Fatalf("first entry = %q, want s1/summary.json", header.Name)
+	}
+	var summary Summary
+	if err := json.NewDecoder(tr).Decode(&summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.SessionID != "s1" || len(summary.Phases) != 1 || summary.Errors["container"] == "" {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if _, err := tr.Next(); err != io.EOF {
+		t.Errorf("expected only summary.json, got err %v", err)
+	}
+}
+
+func TestOpenRejectsOtherKeys(t *testing.T) {
+	c := NewCollector(storage.NewLocalBlob(t.TempDir()), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	for _, key := range []string{"snapshots/abc.tar", "debug-bundles/s1/missing.tar.gz"} {
+		if _, err := c.Open(context.Background(), key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Open(%q) err = %v, want ErrNotFound", key, err)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package diskusage
+
+import "errors"
+
+func fsUsage(path string) (dev, total, used uint64, err error) {
+	return 0, 0, 0, errors.New("filesystem usage not supported on this platform")
+}
@@ -0,0 +1,21 @@
+//go:build unix
+
+package diskusage
+
+import "syscall"
+
+// fsUsage 返回 path 所在文件系统的设备号、总容量和已用字节数
+func fsUsage(path string) (dev, total, used uint64, err error) {
+	var stat syscall.Stat_t
+	if err := syscall.Stat(path, &stat); err != nil {
+		return 0, 0, 0, err
+	}
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, 0, err
+	}
+	bsize := uint64(st.Bsize)
+	total = st.Blocks * bsize
+	used = total - st.Bfree*bsize
+	return uint64(stat.Dev), total, used, nil
+}
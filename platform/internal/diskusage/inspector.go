@@ -0,0 +1,337 @@
+package diskusage
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"platform/internal/monitor"
+	"platform/internal/notify"
+	"platform/internal/sandbox"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+)
+
+// DirGroup 一类宿主机目录，如 projects / exec_logs / compose
+type DirGroup struct {
+	Category string
+	Roots    []string
+}
+
+type Config struct {
+	Dirs []DirGroup
+	// Images 始终计入平台占用的镜像（如 warm pool 镜像），即使当前没有容器在使用
+	Images []string
+	// Interval 后台刷新 Prometheus 指标的间隔
+	Interval time.Duration
+	// AlertPercent 目录所在文件系统的使用率达到此百分比时告警，0 表示不告警
+	AlertPercent float64
+}
+
+type DirUsage struct {
+	Category string `json:"category"`
+	Path     string `json:"path"`
+	Bytes    int64  `json:"bytes"`
+	Files    int    `json:"files"`
+}
+
+type ImageUsage struct {
+	ID         string   `json:"id"`
+	Tags       []string `json:"tags"`
+	Bytes      int64    `json:"bytes"`
+	Containers int      `json:"containers"`
+}
+
+type VolumeUsage struct {
+	Name     string `json:"name"`
+	Bytes    int64  `json:"bytes"`
+	RefCount int64  `json:"ref_count"`
+}
+
+// Report 平台存储占用快照
+type Report struct {
+	Dirs                []DirUsage       `json:"dirs"`
+	Images              []ImageUsage     `json:"images"`
+	Volumes             []VolumeUsage    `json:"volumes"`
+	Containers          int              `json:"containers"`
+	ContainerWriteBytes int64            `json:"container_write_bytes"` // 容器可写层
+	Totals              map[string]int64 `json:"totals"`                // category -> bytes
+	TotalBytes          int64            `json:"total_bytes"`
+	CollectedAt         time.Time        `json:"collected_at"`
+}
+
+// Inspector 统计平台在宿主机和 Docker 中占用的磁盘空间
+type Inspector struct {
+	docker *client.Client
+	config Config
+	logger *slog.Logger
+
+	// Notifier 磁盘使用率告警，nil 时不发送
+	Notifier *notify.Notifier
+
+	mu     sync.RWMutex
+	last   *Report
+	stopCh chan struct{}
+}
+
+func NewInspector(docker *client.Client, config Config, logger *slog.Logger) *Inspector {
+	if config.Interval == 0 {
+		config.Interval = 5 * time.Minute
+	}
+	return &Inspector{
+		docker: docker,
+		config: config,
+		logger: logger.With("component", "disk-usage"),
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Start 启动定期统计循环（阻塞，应在 goroutine 中调用）
+func (i *Inspector) Start() {
+	ticker := time.NewTicker(i.config.Interval)
+	defer ticker.Stop()
+
+	i.refresh()
+	for {
+		select {
+		case <-i.stopCh:
+			return
+		case <-ticker.C:
+			i.refresh()
+		}
+	}
+}
+
+// checkFilesystems 检查各目录所在文件系统的使用率，同一文件系统只告警一次
+func (i *Inspector) checkFilesystems(report *Report) {
+	if i.Notifier == nil || i.config.AlertPercent <= 0 {
+		return
+	}
+	seen := make(map[uint64]bool)
+	for _, d := range report.Dirs {
+		dev, total, used, err := fsUsage(d.Path)
+		if err != nil || total == 0 || seen[dev] {
+			continue
+		}
+		seen[dev] = true
+
+		percent := float64(used) / float64(total) * 100
+		if percent < i.config.AlertPercent {
+			continue
+		}
+		i.Notifier.Notify(notify.Alert{
+			Kind:     notify.KindDiskThreshold,
+			Key:      d.Path,
+			Severity: notify.SeverityCritical,
+			Title:    "Disk usage above threshold",
+			Message:  fmt.Sprintf("The filesystem holding %s is %.1f%% full (threshold %.0f%%).", d.Path, percent, i.config.AlertPercent),
+			Fields: map[string]string{
+				"category":   d.Category,
+				"used_bytes": strconv.FormatUint(used, 10),
+				"size_bytes": strconv.FormatUint(total, 10),
+			},
+		})
+	}
+}
+
+// Stop 停止统计循环
+func (i *Inspector) Stop() {
+	select {
+	case <-i.stopCh:
+	default:
+		close(i.stopCh)
+	}
+}
+
+func (i *Inspector) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if _, err := i.Inspect(ctx); err != nil {
+		i.logger.Warn("Failed to collect disk usage", "error", err)
+	}
+}
+
+// Last 返回最近一次统计结果，尚未统计时为 nil
+func (i *Inspector) Last() *Report {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.last
+}
+
+// Inspect 重新统计存储占用并更新指标
+func (i *Inspector) Inspect(ctx context.Context) (*Report, error) {
+	report := &Report{
+		Totals:      make(map[string]int64),
+		CollectedAt: time.Now(),
+	}
+
+	var allRoots []string
+	for _, g := range i.config.Dirs {
+		for _, r := range g.Roots {
+			if r != "" {
+				allRoots = append(allRoots, filepath.Clean(r))
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, g := range i.config.Dirs {
+		for _, r := range g.Roots {
+			root := filepath.Clean(r)
+			if r == "" || seen[root] {
+				continue
+			}
+			seen[root] = true
+
+			size, files := DirSize(root, nestedRoots(root, allRoots)...)
+			report.Dirs = append(report.Dirs, DirUsage{
+				Category: g.Category,
+				Path:     root,
+				Bytes:    size,
+				Files:    files,
+			})
+			report.Totals[g.Category] += size
+		}
+	}
+
+	if i.docker != nil {
+		if err := i.inspectDocker(ctx, report); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, v := range report.Totals {
+		report.TotalBytes += v
+	}
+	for category, v := range report.Totals {
+		monitor.StorageBytes.WithLabelValues(category).Set(float64(v))
+	}
+
+	i.mu.Lock()
+	i.last = report
+	i.mu.Unlock()
+
+	i.checkFilesystems(report)
+	return report, nil
+}
+
+func (i *Inspector) inspectDocker(ctx context.Context, report *Report) error {
+	du, err := i.docker.DiskUsage(ctx, types.DiskUsageOptions{
+		Types: []types.DiskUsageObject{types.ContainerObject, types.ImageObject, types.VolumeObject},
+	})
+	if err != nil {
+		return err
+	}
+
+	imageRefs := make(map[string]int) // image ID -> 平台容器数
+	volumeNames := make(map[string]bool)
+	for _, c := range du.Containers {
+		if !isPlatformResource(c.Labels) {
+			continue
+		}
+		report.Containers++
+		report.ContainerWriteBytes += c.SizeRw
+		imageRefs[c.ImageID]++
+		for _, m := range c.Mounts {
+			if m.Type == "volume" && m.Name != "" {
+				volumeNames[m.Name] = true
+			}
+		}
+	}
+
+	for _, img := range du.Images {
+		_, used := imageRefs[img.ID]
+		if !used && !matchesAny(img.RepoTags, i.config.Images) {
+			continue
+		}
+		report.Images = append(report.Images, ImageUsage{
+			ID:         img.ID,
+			Tags:       img.RepoTags,
+			Bytes:      img.Size,
+			Containers: imageRefs[img.ID],
+		})
+		report.Totals["images"] += img.Size
+	}
+
+	for _, v := range du.Volumes {
+		if !volumeNames[v.Name] && !isPlatformResource(v.Labels) {
+			continue
+		}
+		usage := VolumeUsage{Name: v.Name}
+		if v.UsageData != nil {
+			usage.Bytes = v.UsageData.Size
+			usage.RefCount = v.UsageData.RefCount
+		}
+		report.Volumes = append(report.Volumes, usage)
+		if usage.Bytes > 0 {
+			report.Totals["volumes"] += usage.Bytes
+		}
+	}
+
+	report.Totals["containers"] = report.ContainerWriteBytes
+	return nil
+}
+
+// isPlatformResource 判断容器或卷是否由平台创建（沙箱、companion 或 compose stack）
+func isPlatformResource(labels map[string]string) bool {
+	if sandbox.IsManaged(labels) {
+		return true
+	}
+	return strings.HasPrefix(labels["com.docker.compose.project"], "agent-")
+}
+
+func matchesAny(tags []string, images []string) bool {
+	for _, t := range tags {
+		for _, img := range images {
+			if t == img || (!strings.Contains(img, ":") && t == img+":latest") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nestedRoots 返回位于 root 之下的其他受管目录，避免重复统计
+func nestedRoots(root string, all []string) []string {
+	var nested []string
+	for _, r := range all {
+		if r != root && strings.HasPrefix(r, root+string(filepath.Separator)) {
+			nested = append(nested, r)
+		}
+	}
+	return nested
+}
+
+// DirSize 递归统计目录大小和文件数，跳过 exclude 中的子目录和读取失败的文件
+func DirSize(path string, exclude ...string) (int64, int) {
+	var size int64
+	var files int
+	_ = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			for _, ex := range exclude {
+				if p == ex {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+				files++
+			}
+		}
+		return nil
+	})
+	return size, files
+}
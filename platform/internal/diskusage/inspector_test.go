@@ -0,0 +1,116 @@
+package diskusage
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path string, size int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDirSize(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a"), 10)
+	writeFile(t, filepath.Join(root, "sub", "b"), 20)
+	writeFile(t, filepath.Join(root, "logs", "c"), 40)
+	if err := os.Symlink(filepath.Join(root, "a"), filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		exclude   []string
+		wantBytes int64
+		wantFiles int
+	}{
+		{"whole tree, symlinks not followed", root, nil, 70, 3},
+		{"nested root excluded", root, []string{filepath.Join(root, "logs")}, 30, 2},
+		{"missing directory", filepath.Join(root, "missing"), nil, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size, files := DirSize(tt.path, tt.exclude...)
+			if size != tt.wantBytes || files != tt.wantFiles {
+				t.Errorf("DirSize = %d bytes / %d files, want %d / %d", size, files, tt.wantBytes, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestInspectDirs(t *testing.T) {
+	root := t.TempDir()
+	projects := filepath.Join(root, "projects")
+	logs := filepath.Join(projects, "logs")
+	writeFile(t, filepath.Join(projects, "p1", "main.py"), 100)
+	writeFile(t, filepath.Join(logs, "exec.log"), 30)
+
+	i := NewInspector(nil, Config{Dirs: []DirGroup{
+		// 重复的根目录只统计一次，嵌套的根目录归入自己的类别
+		{Category: "projects", Roots: []string{projects, projects + "/", ""}},
+		{Category: "exec_logs", Roots: []string{logs}},
+	}}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+
+	report, err := i.Inspect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Dirs) != 2 {
+		t.Fatalf("Dirs = %+v, want 2 entries", report.Dirs)
+	}
+	want := map[string]int64{"projects": 100, "exec_logs": 30}
+	for category, bytes := range want {
+		if got := report.Totals[category]; got != bytes {
+			t.Errorf("Totals[%s] = %d, want %d", category, got, bytes)
+		}
+	}
+	if report.TotalBytes != 130 {
+		t.Errorf("TotalBytes = %d, want 130", report.TotalBytes)
+	}
+	if i.Last() != report {
+		t.Error("Last should return the latest report")
+	}
+}
+
+func TestPlatformResourceMatching(t *testing.T) {
+	resources := []struct {
+		labels map[string]string
+		want   bool
+	}{
+		{map[string]string{"managed_by": "agent-platform"}, true},
+		{map[string]string{"com.docker.compose.project": "agent-1234abcd"}, true},
+		{map[string]string{"com.docker.compose.project": "other"}, false},
+		{nil, false},
+	}
+	for _, tt := range resources {
+		if got := isPlatformResource(tt.labels); got != tt.want {
+			t.Errorf("isPlatformResource(%v) = %v, want %v", tt.labels, got, tt.want)
+		}
+	}
+
+	images := []struct {
+		tags []string
+		want bool
+	}{
+		{[]string{"agent-runtime:latest"}, true},
+		{[]string{"agent-runtime:v2", "agent-runtime:latest"}, true},
+		{[]string{"agent-runtime:v2"}, false},
+		{[]string{"python:3.12"}, true},
+		{nil, false},
+	}
+	for _, tt := range images {
+		if got := matchesAny(tt.tags, []string{"agent-runtime", "python:3.12"}); got != tt.want {
+			t.Errorf("matchesAny(%v) = %v, want %v", tt.tags, got, tt.want)
+		}
+	}
+}
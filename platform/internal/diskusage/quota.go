@@ -0,0 +1,173 @@
+package diskusage
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"platform/internal/eventbus"
+	"platform/internal/monitor"
+	"platform/internal/orchestrator"
+	"platform/internal/sandbox"
+	"platform/internal/session"
+)
+
+// QuotaConfig 绑定挂载工作区的磁盘配额
+type QuotaConfig struct {
+	// Limit 每个工作区的上限（字节），0 表示不检查
+	Limit    int64
+	Interval time.Duration
+	// HostRoot 冷容器工作区的宿主机根目录
+	HostRoot string
+	// Terminate 超出配额后终止 session，否则只发布事件
+	Terminate bool
+}
+
+// QuotaExceeded 超出配额事件的负载
+type QuotaExceeded struct {
+	Path       string `json:"path"`
+	UsedBytes  int64  `json:"used_bytes"`
+	LimitBytes int64  `json:"limit_bytes"`
+	Terminated bool   `json:"terminated"`
+}
+
+// QuotaWatcher 定期统计 Cold 策略 session 的宿主机工作区大小，超出配额时发布事件并按配置终止 session。
+// Warm 策略的容器使用 tmpfs 卷，上限由内核强制，不在此检查。
+type QuotaWatcher struct {
+	repo        session.SessionRepository
+	bus         eventbus.EventBus
+	terminateFn func(ctx context.Context, sessionID string) error
+	config      QuotaConfig
+	logger      *slog.Logger
+	stopCh      chan struct{}
+
+	// notified 已发布过超限事件的 session，回落到配额以内后移除，避免每轮重复通知
+	notified map[string]bool
+}
+
+// NewQuotaWatcher terminateFn 通常传入 service.Service.TerminateSession
+func NewQuotaWatcher(
+	repo session.SessionRepository,
+	bus eventbus.EventBus,
+	terminateFn func(ctx context.Context, sessionID string) error,
+	config QuotaConfig,
+	logger *slog.Logger,
+) *QuotaWatcher {
+	if config.Interval == 0 {
+		config.Interval = time.Minute
+	}
+	return &QuotaWatcher{
+		repo:        repo,
+		bus:         bus,
+		terminateFn: terminateFn,
+		config:      config,
+		logger:      logger.With("component", "disk-quota"),
+		stopCh:      make(chan struct{}),
+		notified:    make(map[string]bool),
+	}
+}
+
+// Start 启动检查循环（阻塞，应在 goroutine 中调用）
+func (w *QuotaWatcher) Start() {
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	w.logger.Info("Disk quota watcher started", "limit_bytes", w.config.Limit, "interval", w.config.Interval)
+
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
+
+// Stop 停止检查循环
+func (w *QuotaWatcher) Stop() {
+	select {
+	case <-w.stopCh:
+	default:
+		close(w.stopCh)
+	}
+}
+
+func (w *QuotaWatcher) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	sessions, err := w.repo.ListByStatus(ctx, []session.SessionStatus{
+		session.StatusReady,
+		session.StatusRunning,
+	})
+	if err != nil {
+		w.logger.Error("Failed to list active sessions", "error", err)
+		return
+	}
+
+	// 同一项目的 session 共享工作区目录，每个目录只统计一次
+	sizes := make(map[string]int64)
+	active := make(map[string]bool, len(sessions))
+	for _, sess := range sessions {
+		if sess.ContainerStrategy() != orchestrator.ColdStrategyType {
+			continue
+		}
+		active[sess.ID] = true
+
+		path := sandbox.DefaultHostPath(w.config.HostRoot, sess.ProjectID)
+		used, ok := sizes[path]
+		if !ok {
+			used, _ = DirSize(path)
+			sizes[path] = used
+		}
+
+		if used <= w.config.Limit {
+			delete(w.notified, sess.ID)
+			continue
+		}
+		if w.notified[sess.ID] {
+			continue
+		}
+		w.notified[sess.ID] = true
+		w.handleExceeded(ctx, sess.ID, path, used)
+	}
+
+	for id := range w.notified {
+		if !active[id] {
+			delete(w.notified, id)
+		}
+	}
+}
+
+func (w *QuotaWatcher) handleExceeded(ctx context.Context, sessionID, path string, used int64) {
+	monitor.DiskQuotaExceeded.Inc()
+	w.logger.Warn("Session workspace exceeded disk quota",
+		"session_id", sessionID,
+		"path", path,
+		"used_bytes", used,
+		"limit_bytes", w.config.Limit,
+	)
+
+	payload := QuotaExceeded{
+		Path:       path,
+		UsedBytes:  used,
+		LimitBytes: w.config.Limit,
+		Terminated: w.config.Terminate,
+	}
+	if err := w.bus.Publish(ctx, sessionID, eventbus.Event{
+		Type:      eventbus.EventDiskQuotaExceeded,
+		SessionID: sessionID,
+		Payload:   payload,
+		Timestamp: time.Now(),
+	}); err != nil {
+		w.logger.Warn("Failed to publish disk quota event", "session_id", sessionID, "error", err)
+	}
+
+	if !w.config.Terminate {
+		return
+	}
+	if err := w.terminateFn(ctx, sessionID); err != nil {
+		w.logger.Error("Failed to terminate session over disk quota", "session_id", sessionID, "error", err)
+	}
+}
@@ -0,0 +1,89 @@
+package diskusage
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"platform/internal/eventbus"
+	"platform/internal/orchestrator"
+	"platform/internal/session"
+)
+
+type fakeRepo struct {
+	session.SessionRepository
+	sessions []*session.Session
+}
+
+func (r *fakeRepo) ListByStatus(ctx context.Context, statuses []session.SessionStatus) ([]*session.Session, error) {
+	var out []*session.Session
+	for _, s := range r.sessions {
+		for _, st := range statuses {
+			if s.Status == st {
+				out = append(out, s)
+			}
+		}
+	}
+	return out, nil
+}
+
+type fakeBus struct {
+	eventbus.EventBus
+	events []eventbus.Event
+}
+
+func (b *fakeBus) Publish(ctx context.Context, sessionID string, event eventbus.Event) error {
+	b.events = append(b.events, event)
+	return nil
+}
+
+func TestQuotaWatcherPublishesOnceAndTerminates(t *testing.T) {
+	root := t.TempDir()
+	for project, size := range map[string]int{"big": 4096, "small": 100} {
+		dir := filepath.Join(root, project)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "data"), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo := &fakeRepo{sessions: []*session.Session{
+		{ID: "s-big", ProjectID: "big", Status: session.StatusRunning, Strategy: orchestrator.ColdStrategyType},
+		{ID: "s-small", ProjectID: "small", Status: session.StatusRunning, Strategy: orchestrator.ColdStrategyType},
+		// Warm 容器由 tmpfs 限制，不检查宿主机目录
+		{ID: "s-warm", ProjectID: "big", Status: session.StatusRunning, Strategy: orchestrator.WarmStrategyType},
+	}}
+	bus := &fakeBus{}
+	var terminated []string
+	terminate := func(ctx context.Context, id string) error {
+		terminated = append(terminated, id)
+		return nil
+	}
+
+	w := NewQuotaWatcher(repo, bus, terminate, QuotaConfig{
+		Limit:     1024,
+		HostRoot:  root,
+		Terminate: true,
+	}, slog.Default())
+
+	w.check()
+	w.check()
+
+	if len(bus.events) != 1 {
+		t.Fatalf("Expected one quota event, got %d", len(bus.events))
+	}
+	ev := bus.events[0]
+	if ev.Type != eventbus.EventDiskQuotaExceeded || ev.SessionID != "s-big" {
+		t.Errorf("Unexpected event: %+v", ev)
+	}
+	if p, ok := ev.Payload.(QuotaExceeded); !ok || p.UsedBytes != 4096 || !p.Terminated {
+		t.Errorf("Unexpected payload: %+v", ev.Payload)
+	}
+	if len(terminated) != 1 || terminated[0] != "s-big" {
+		t.Errorf("Expected only s-big to be terminated, got %v", terminated)
+	}
+}
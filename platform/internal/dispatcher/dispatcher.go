@@ -6,7 +6,9 @@ import (
 	"io"
 	"log/slog"
 	"platform/internal/agentproto"
+	"platform/internal/errreport"
 	"platform/internal/eventbus"
+	"platform/internal/reqid"
 	"platform/internal/sandbox"
 	"sync"
 	"time"
@@ -15,6 +17,7 @@ import (
 	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
 )
 
 type Dispatcher struct {
@@ -22,6 +25,9 @@ type Dispatcher struct {
 	connections map[string]*grpc.ClientConn
 	bus         eventbus.EventBus
 	logger      *slog.Logger
+
+	// OnEvent 在每个 Agent 事件发布后调用，用于持久化对话记录；为 nil 时不调用
+	OnEvent func(ctx context.Context, event eventbus.Event)
 }
 
 func NewDispatcher(bus eventbus.EventBus, logger *slog.Logger) *Dispatcher {
@@ -29,7 +35,7 @@ func NewDispatcher(bus eventbus.EventBus, logger *slog.Logger) *Dispatcher {
 		mu:          sync.RWMutex{},
 		connections: make(map[string]*grpc.ClientConn),
 		bus:         bus,
-		logger:      logger,
+		logger:      logger.With("component", "dispatcher"),
 	}
 }
 
@@ -79,8 +85,9 @@ func (d *Dispatcher) Dispatch(ctx context.Context, container *sandbox.Container,
 	}
 
 	// 使用后台上下文作为 gRPC 流的上下文，避免在 HTTP 请求处理返回时被取消。
-	// 该流必须比短时的 POST /chat 请求存活更久。
-	streamCtx := context.Background()
+	// 该流必须比短时的 POST /chat 请求存活更久。请求 ID 保留下来，
+	// 流中产生的所有事件都关联到发起对话的请求。
+	streamCtx := outgoingContext(reqid.WithContext(context.Background(), reqid.FromContext(ctx)))
 
 	stream, err := client.RunStep(streamCtx, req)
 	if err != nil {
@@ -88,6 +95,7 @@ func (d *Dispatcher) Dispatch(ctx context.Context, container *sandbox.Container,
 	}
 
 	go func() {
+		defer errreport.Recover(streamCtx, d.logger, "dispatcher", errreport.Tags{"session_id": container.Config.SessionID})
 		defer func() {
 			// 发布一个 stream-done 事件，以便 SSE 处理程序可以优雅地关闭
 			// 而不是在代理完成后在 Redis 订阅上挂起。
@@ -107,7 +115,7 @@ func (d *Dispatcher) Dispatch(ctx context.Context, container *sandbox.Container,
 
 			if err != nil {
 				d.logger.Error("Stream error", "error", err, "session_id", container.Config.SessionID)
-				d.publishError(container.Config.SessionID, err)
+				d.publishError(streamCtx, container.Config.SessionID, err)
 				return
 			}
 
@@ -121,6 +129,9 @@ func (d *Dispatcher) Dispatch(ctx context.Context, container *sandbox.Container,
 			if err := d.bus.Publish(streamCtx, container.Config.SessionID, event); err != nil {
 				d.logger.Error("Failed to publish event", "error", err, "session_id", container.Config.SessionID)
 			}
+			if d.OnEvent != nil {
+				d.OnEvent(streamCtx, event)
+			}
 		}
 	}()
 
@@ -132,7 +143,7 @@ func (d *Dispatcher) Configure(ctx context.Context, container *sandbox.Container
 	if err != nil {
 		return nil, fmt.Errorf("failed to get agent client: %w", err)
 	}
-	return client.Configure(ctx, req)
+	return client.Configure(outgoingContext(ctx), req)
 }
 
 func (d *Dispatcher) Stop(ctx context.Context, container *sandbox.Container, sessionID string) (*agentproto.StopResponse, error) {
@@ -140,11 +151,19 @@ func (d *Dispatcher) Stop(ctx context.Context, container *sandbox.Container, ses
 	if err != nil {
 		return nil, fmt.Errorf("failed to get agent client: %w", err)
 	}
-	return client.Stop(ctx, &agentproto.StopRequest{SessionId: sessionID})
+	return client.Stop(outgoingContext(ctx), &agentproto.StopRequest{SessionId: sessionID})
+}
+
+// outgoingContext 将请求 ID 写入 gRPC metadata，Agent 端日志可以据此关联
+func outgoingContext(ctx context.Context) context.Context {
+	if id := reqid.FromContext(ctx); id != "" {
+		return metadata.AppendToOutgoingContext(ctx, reqid.MetadataKey, id)
+	}
+	return ctx
 }
 
-func (d *Dispatcher) publishError(sessionID string, err error) {
-	d.bus.Publish(context.Background(), sessionID, eventbus.Event{
+func (d *Dispatcher) publishError(ctx context.Context, sessionID string, err error) {
+	d.bus.Publish(ctx, sessionID, eventbus.Event{
 		Type:      eventbus.EventSessionError,
 		SessionID: sessionID,
 		Payload:   map[string]string{"error": err.Error()},
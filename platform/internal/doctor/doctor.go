@@ -0,0 +1,313 @@
+// Package doctor 启动前的环境诊断：依次检查容器运行时、网络、镜像、Redis、Postgres、
+// 目录权限和 cgroup 支持，输出可读的报告，用于排查服务无法启动的问题。
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"platform/internal/config"
+	"platform/internal/sandbox"
+
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/versions"
+	"github.com/docker/docker/client"
+	"github.com/go-pg/pg/v10"
+	"github.com/redis/go-redis/v9"
+)
+
+// MinDockerAPIVersion 平台依赖的最低 Docker API 版本（exec ConsoleSize 等字段自 1.42 起支持）
+const MinDockerAPIVersion = "1.42"
+
+// checkTimeout 单项检查的超时时间
+const checkTimeout = 10 * time.Second
+
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+// Result 单项检查结果
+type Result struct {
+	Name   string
+	Status Status
+	Detail string
+}
+
+// Doctor 按配置执行诊断
+type Doctor struct {
+	cfg    *config.Config
+	docker *client.Client
+}
+
+func New(cfg *config.Config) *Doctor {
+	return &Doctor{cfg: cfg}
+}
+
+// Run 执行全部检查。容器运行时不可用时跳过依赖它的检查，其余检查照常进行。
+func (d *Doctor) Run(ctx context.Context) []Result {
+	var results []Result
+
+	results = append(results, d.checkDocker(ctx)...)
+	if d.docker != nil {
+		defer d.docker.Close()
+		results = append(results,
+			d.checkNetwork(ctx),
+			d.checkImage(ctx),
+			d.checkRuntime(ctx),
+			d.checkCgroups(ctx),
+		)
+	} else {
+		for _, name := range []string{"network", "image", "runtime", "cgroups"} {
+			results = append(results, Result{Name: name, Status: StatusSkip, Detail: "container runtime unavailable"})
+		}
+	}
+
+	results = append(results, d.checkRedis(ctx), d.checkPostgres(ctx))
+	results = append(results, d.checkDirectories()...)
+	return results
+}
+
+func (d *Doctor) checkDocker(ctx context.Context) []Result {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	var cli *client.Client
+	var err error
+	switch d.cfg.Sandbox.Backend {
+	case sandbox.BackendDocker:
+		cli, err = client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	case sandbox.BackendPodman:
+		cli, err = sandbox.NewPodmanClient(ctx, d.cfg.Sandbox.PodmanSocket)
+	default:
+		return []Result{{Name: "backend", Status: StatusFail,
+			Detail: fmt.Sprintf("SANDBOX_BACKEND %q is not supported (expected docker or podman)", d.cfg.Sandbox.Backend)}}
+	}
+	if err != nil {
+		return []Result{{Name: "docker", Status: StatusFail, Detail: err.Error()}}
+	}
+
+	version, err := cli.ServerVersion(ctx)
+	if err != nil {
+		cli.Close()
+		return []Result{{Name: "docker", Status: StatusFail,
+			Detail: fmt.Sprintf("cannot reach %s: %v", cli.DaemonHost(), err)}}
+	}
+	d.docker = cli
+
+	results := []Result{{Name: "docker", Status: StatusOK,
+		Detail: fmt.Sprintf("%s %s (%s/%s) at %s", d.cfg.Sandbox.Backend, version.Version, version.Os, version.Arch, cli.DaemonHost())}}
+
+	api := Result{Name: "docker api", Status: StatusOK,
+		Detail: fmt.Sprintf("server %s, client %s", version.APIVersion, cli.ClientVersion())}
+	if versions.LessThan(version.APIVersion, MinDockerAPIVersion) {
+		api.Status = StatusFail
+		api.Detail = fmt.Sprintf("server API %s is older than required %s; upgrade the container engine",
+			version.APIVersion, MinDockerAPIVersion)
+	}
+	return append(results, api)
+}
+
+func (d *Doctor) checkNetwork(ctx context.Context) Result {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	name := d.cfg.Pool.NetworkName
+	res, err := d.docker.NetworkInspect(ctx, name, network.InspectOptions{})
+	if err != nil {
+		return Result{Name: "network", Status: StatusFail,
+			Detail: fmt.Sprintf("network %q not found; create it with `docker network create %s`: %v", name, name, err)}
+	}
+	return Result{Name: "network", Status: StatusOK, Detail: fmt.Sprintf("%s (driver %s)", name, res.Driver)}
+}
+
+func (d *Doctor) checkImage(ctx context.Context) Result {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	ref := d.cfg.Pool.WarmupImage
+	inspect, err := d.docker.ImageInspect(ctx, ref)
+	if err != nil {
+		return Result{Name: "image", Status: StatusWarn,
+			Detail: fmt.Sprintf("%s is not present locally; the first container start will try to pull it", ref)}
+	}
+	return Result{Name: "image", Status: StatusOK,
+		Detail: fmt.Sprintf("%s (%s, %d MB)", ref, shortID(inspect.ID), inspect.Size/(1<<20))}
+}
+
+func (d *Doctor) checkRuntime(ctx context.Context) Result {
+	if d.cfg.Pool.Runtime == "" {
+		return Result{Name: "runtime", Status: StatusOK, Detail: "daemon default runtime"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	info, err := d.docker.Info(ctx)
+	if err != nil {
+		return Result{Name: "runtime", Status: StatusFail, Detail: err.Error()}
+	}
+	if _, ok := info.Runtimes[d.cfg.Pool.Runtime]; ok {
+		return Result{Name: "runtime", Status: StatusOK, Detail: d.cfg.Pool.Runtime}
+	}
+
+	available := make([]string, 0, len(info.Runtimes))
+	for name := range info.Runtimes {
+		available = append(available, name)
+	}
+	sort.Strings(available)
+	return Result{Name: "runtime", Status: StatusFail,
+		Detail: fmt.Sprintf("POOL_RUNTIME %q is not registered (available: %s)", d.cfg.Pool.Runtime, strings.Join(available, ", "))}
+}
+
+// checkCgroups 容器的内存、CPU 限制依赖 cgroup 控制器，缺失时限制会被静默忽略
+func (d *Doctor) checkCgroups(ctx context.Context) Result {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	info, err := d.docker.Info(ctx)
+	if err != nil {
+		return Result{Name: "cgroups", Status: StatusFail, Detail: err.Error()}
+	}
+
+	var missing []string
+	if !info.MemoryLimit {
+		missing = append(missing, "memory limit")
+	}
+	if !info.CPUCfsQuota {
+		missing = append(missing, "cpu quota")
+	}
+	if !info.PidsLimit {
+		missing = append(missing, "pids limit")
+	}
+
+	detail := fmt.Sprintf("cgroup v%s, driver %s", info.CgroupVersion, info.CgroupDriver)
+	if len(missing) > 0 {
+		return Result{Name: "cgroups", Status: StatusWarn,
+			Detail: fmt.Sprintf("%s; unsupported: %s", detail, strings.Join(missing, ", "))}
+	}
+	return Result{Name: "cgroups", Status: StatusOK, Detail: detail}
+}
+
+func (d *Doctor) checkRedis(ctx context.Context) Result {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     d.cfg.Redis.Addr,
+		Password: d.cfg.Redis.Password,
+		DB:       d.cfg.Redis.DB,
+		// 诊断只需要一次结果，不重试
+		MaxRetries: -1,
+	})
+	defer rdb.Close()
+
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		return Result{Name: "redis", Status: StatusFail, Detail: fmt.Sprintf("%s: %v", d.cfg.Redis.Addr, err)}
+	}
+	return Result{Name: "redis", Status: StatusOK, Detail: d.cfg.Redis.Addr}
+}
+
+func (d *Doctor) checkPostgres(ctx context.Context) Result {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	db := pg.Connect(&pg.Options{
+		Addr:     d.cfg.Postgres.Addr,
+		User:     d.cfg.Postgres.User,
+		Password: d.cfg.Postgres.Password,
+		Database: d.cfg.Postgres.Database,
+	})
+	defer db.Close()
+
+	if err := db.Ping(ctx); err != nil {
+		return Result{Name: "postgres", Status: StatusFail,
+			Detail: fmt.Sprintf("%s/%s: %v", d.cfg.Postgres.Addr, d.cfg.Postgres.Database, err)}
+	}
+	return Result{Name: "postgres", Status: StatusOK,
+		Detail: fmt.Sprintf("%s/%s", d.cfg.Postgres.Addr, d.cfg.Postgres.Database)}
+}
+
+// checkDirectories 服务运行时需要创建和写入的宿主机目录
+func (d *Doctor) checkDirectories() []Result {
+	dirs := []struct{ name, path string }{
+		{"dir: pool host root", d.cfg.Pool.HostRoot},
+		{"dir: worker projects", d.cfg.Worker.ProjectDir},
+		{"dir: container logs", d.cfg.Log.ContainerLogDir},
+	}
+
+	results := make([]Result, 0, len(dirs))
+	for _, dir := range dirs {
+		if err := checkWritable(dir.path); err != nil {
+			results = append(results, Result{Name: dir.name, Status: StatusFail, Detail: err.Error()})
+			continue
+		}
+		results = append(results, Result{Name: dir.name, Status: StatusOK, Detail: dir.path})
+	}
+	return results
+}
+
+// checkWritable 确认目录存在（不存在则创建）且当前用户可写
+func checkWritable(dir string) error {
+	if dir == "" {
+		return fmt.Errorf("path is empty")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create %s: %w", dir, err)
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, err)
+	}
+	name := f.Name()
+	f.Close()
+	os.Remove(name)
+	return nil
+}
+
+func shortID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// Print 输出报告，返回是否存在失败项
+func Print(w io.Writer, results []Result) bool {
+	width := 0
+	for _, r := range results {
+		width = max(width, len(r.Name))
+	}
+
+	var failed, warned int
+	for _, r := range results {
+		fmt.Fprintf(w, "[%-4s] %-*s  %s\n", strings.ToUpper(string(r.Status)), width, r.Name, r.Detail)
+		switch r.Status {
+		case StatusFail:
+			failed++
+		case StatusWarn:
+			warned++
+		}
+	}
+
+	fmt.Fprintln(w)
+	switch {
+	case failed > 0:
+		fmt.Fprintf(w, "%d check(s) failed, %d warning(s)\n", failed, warned)
+	case warned > 0:
+		fmt.Fprintf(w, "All checks passed with %d warning(s)\n", warned)
+	default:
+		fmt.Fprintln(w, "All checks passed")
+	}
+	return failed > 0
+}
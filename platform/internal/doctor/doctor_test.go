@@ -0,0 +1,62 @@
+package doctor
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrintReportsFailures(t *testing.T) {
+	var buf bytes.Buffer
+	failed := Print(&buf, []Result{
+		{Name: "docker", Status: StatusOK, Detail: "docker 27.0"},
+		{Name: "image", Status: StatusWarn, Detail: "not present"},
+		{Name: "redis", Status: StatusFail, Detail: "connection refused"},
+	})
+
+	if !failed {
+		t.Error("Expected failure to be reported")
+	}
+	out := buf.String()
+	for _, want := range []string{"[OK  ] docker", "[WARN] image ", "[FAIL] redis ", "1 check(s) failed, 1 warning(s)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in report:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrintAllPassed(t *testing.T) {
+	var buf bytes.Buffer
+	if Print(&buf, []Result{{Name: "redis", Status: StatusOK}}) {
+		t.Error("Expected no failure")
+	}
+	if !strings.Contains(buf.String(), "All checks passed") {
+		t.Errorf("Unexpected summary: %s", buf.String())
+	}
+}
+
+func TestCheckWritable(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested", "logs")
+	if err := checkWritable(dir); err != nil {
+		t.Fatalf("Expected writable dir, got %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("Expected probe file to be removed, found %d entries", len(entries))
+	}
+
+	if os.Geteuid() != 0 {
+		readonly := t.TempDir()
+		os.Chmod(readonly, 0555)
+		defer os.Chmod(readonly, 0755)
+		if err := checkWritable(readonly); err == nil {
+			t.Error("Expected error for read-only dir")
+		}
+	}
+
+	if err := checkWritable(""); err == nil {
+		t.Error("Expected error for empty path")
+	}
+}
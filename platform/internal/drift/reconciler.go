@@ -0,0 +1,297 @@
+// Package drift 定期对比 session 记录与容器的实际状态，修复 Docker 重启后 IP 变化、
+// 资源限制被外部修改等漂移，并发布事件。容器与宿主机共享内核时钟，不存在需要校正的时钟漂移。
+package drift
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+
+	"platform/internal/eventbus"
+	"platform/internal/monitor"
+	"platform/internal/nodes"
+	"platform/internal/sandbox"
+	"platform/internal/session"
+)
+
+// Docker 对账所需的 Docker API 子集，*client.Client 满足该接口
+type Docker interface {
+	ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error)
+	ContainerUpdate(ctx context.Context, containerID string, updateConfig container.UpdateConfig) (container.UpdateResponse, error)
+}
+
+// Config 对账配置
+type Config struct {
+	Interval time.Duration
+	// NetworkName 沙箱所在的共享网络，用于从多个网络中选出 session 的 IP
+	NetworkName string
+	// MemoryLimit / CPULimit 沙箱应有的资源限制（字节 / 核心数），0 表示不检查
+	MemoryLimit int64
+	CPULimit    float64
+	// Repair 为 false 时只报告漂移，不修改数据库和容器
+	Repair bool
+}
+
+// Field 漂移的字段
+type Field string
+
+const (
+	FieldIP      Field = "ip"
+	FieldMemory  Field = "memory"
+	FieldCPU     Field = "cpu"
+	FieldMissing Field = "missing"
+	FieldStopped Field = "stopped"
+)
+
+// Drift 一处不一致，作为 EventSessionDrift 的负载
+type Drift struct {
+	ContainerID string `json:"container_id"`
+	Field       Field  `json:"field"`
+	Recorded    string `json:"recorded"`
+	Actual      string `json:"actual"`
+	Repaired    bool   `json:"repaired"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Reconciler 定期检查 Ready/Running session 的容器
+type Reconciler struct {
+	repo   session.SessionRepository
+	docker Docker
+	bus    eventbus.EventBus
+	config Config
+	logger *slog.Logger
+	stopCh chan struct{}
+
+	// unrepaired 已报告但未修复的漂移（session/field → actual），值不变时不重复报告
+	unrepaired map[string]string
+
+	// OnIPChanged 在 session 的 IP 被修正后调用，通常用于断开指向旧地址的 gRPC 连接
+	OnIPChanged func(sessionID string)
+	// NodeDocker 按 session 的 NodeID 返回容器所在节点的客户端（空 ID 为本机）。
+	// 为 nil 时只检查本机上的 session，其他节点上的 session 跳过
+	NodeDocker func(nodeID string) (Docker, error)
+}
+
+func NewReconciler(repo session.SessionRepository, docker Docker, bus eventbus.EventBus, config Config, logger *slog.Logger) *Reconciler {
+	if config.Interval == 0 {
+		config.Interval = time.Minute
+	}
+	return &Reconciler{
+		repo:       repo,
+		docker:     docker,
+		bus:        bus,
+		config:     config,
+		logger:     logger.With("component", "drift-reconciler"),
+		stopCh:     make(chan struct{}),
+		unrepaired: make(map[string]string),
+	}
+}
+
+// Start 启动对账循环（阻塞，应在 goroutine 中调用）
+func (r *Reconciler) Start() {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	r.logger.Info("Drift reconciler started", "interval", r.config.Interval, "repair", r.config.Repair)
+
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case <-ticker.C:
+			r.reconcile()
+		}
+	}
+}
+
+// Stop 停止对账循环
+func (r *Reconciler) Stop() {
+	select {
+	case <-r.stopCh:
+	default:
+		close(r.stopCh)
+	}
+}
+
+func (r *Reconciler) reconcile() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	sessions, err := r.repo.ListByStatus(ctx, []session.SessionStatus{
+		session.StatusReady,
+		session.StatusRunning,
+	})
+	if err != nil {
+		r.logger.Error("Failed to list active sessions", "error", err)
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, sess := range sessions {
+		if sess.ContainerID == "" {
+			continue
+		}
+		for _, d := range r.check(ctx, sess) {
+			key := sess.ID + "/" + string(d.Field)
+			if !d.Repaired {
+				seen[key] = true
+				if prev, ok := r.unrepaired[key]; ok && prev == d.Actual {
+					continue
+				}
+				r.unrepaired[key] = d.Actual
+			}
+			r.report(ctx, sess.ID, d)
+		}
+	}
+
+	for key := range r.unrepaired {
+		if !seen[key] {
+			delete(r.unrepaired, key)
+		}
+	}
+}
+
+// dockerFor 返回 session 容器所在节点的客户端，无法确定时返回 nil。
+// 在错误的节点上检查会得到 NotFound，进而把正常的 session 标记为 error
+func (r *Reconciler) dockerFor(sess *session.Session) Docker {
+	if r.NodeDocker == nil {
+		if sess.NodeID == "" || sess.NodeID == nodes.LocalNode {
+			return r.docker
+		}
+		return nil
+	}
+	docker, err := r.NodeDocker(sess.NodeID)
+	if err != nil {
+		r.logger.Warn("Skipping session on unknown node", "session_id", sess.ID, "node_id", sess.NodeID, "error", err)
+		return nil
+	}
+	return docker
+}
+
+// check 对比单个 session 并按配置修复，返回发现的漂移
+func (r *Reconciler) check(ctx context.Context, sess *session.Session) []Drift {
+	docker := r.dockerFor(sess)
+	if docker == nil {
+		return nil
+	}
+	inspect, err := docker.ContainerInspect(ctx, sess.ContainerID)
+	if err != nil {
+		if !errdefs.IsNotFound(err) {
+			r.logger.Warn("Failed to inspect session container", "session_id", sess.ID, "error", err)
+			return nil
+		}
+		// 容器已不存在，session 无法继续使用
+		d := Drift{ContainerID: sess.ContainerID, Field: FieldMissing, Recorded: sess.ContainerID}
+		if r.config.Repair {
+			d.Repaired, d.Error = r.apply(r.repo.UpdateSessionStatus(ctx, sess.ID, session.StatusError))
+		}
+		return []Drift{d}
+	}
+
+	if inspect.State == nil || !inspect.State.Running {
+		// 停止的容器没有 IP，资源限制也无从比较；由清理器或重启流程处理
+		status := "unknown"
+		if inspect.State != nil {
+			status = inspect.State.Status
+		}
+		return []Drift{{ContainerID: sess.ContainerID, Field: FieldStopped, Recorded: string(sess.Status), Actual: status}}
+	}
+
+	var drifts []Drift
+
+	if ip := r.containerIP(sess.ID, inspect); ip != "" && ip != sess.NodeIP {
+		d := Drift{ContainerID: sess.ContainerID, Field: FieldIP, Recorded: sess.NodeIP, Actual: ip}
+		if r.config.Repair {
+			d.Repaired, d.Error = r.apply(r.repo.UpdateSessionContainerInfo(ctx, sess.ID, sess.ContainerID, ip))
+			if d.Repaired && r.OnIPChanged != nil {
+				r.OnIPChanged(sess.ID)
+			}
+		}
+		drifts = append(drifts, d)
+	}
+
+	var update container.Resources
+	var limitDrifts []Drift
+	if r.config.MemoryLimit > 0 && inspect.HostConfig != nil && inspect.HostConfig.Memory != r.config.MemoryLimit {
+		limitDrifts = append(limitDrifts, Drift{
+			ContainerID: sess.ContainerID,
+			Field:       FieldMemory,
+			Recorded:    strconv.FormatInt(r.config.MemoryLimit, 10),
+			Actual:      strconv.FormatInt(inspect.HostConfig.Memory, 10),
+		})
+		update.Memory = r.config.MemoryLimit
+		// 只调低 memory 而 swap 仍高于新值时 daemon 会拒绝更新
+		update.MemorySwap = r.config.MemoryLimit
+	}
+	nanoCPUs := int64(r.config.CPULimit * 1e9)
+	if nanoCPUs > 0 && inspect.HostConfig != nil && inspect.HostConfig.NanoCPUs != nanoCPUs {
+		limitDrifts = append(limitDrifts, Drift{
+			ContainerID: sess.ContainerID,
+			Field:       FieldCPU,
+			Recorded:    strconv.FormatInt(nanoCPUs, 10),
+			Actual:      strconv.FormatInt(inspect.HostConfig.NanoCPUs, 10),
+		})
+		update.NanoCPUs = nanoCPUs
+	}
+	if len(limitDrifts) > 0 && r.config.Repair {
+		_, err := docker.ContainerUpdate(ctx, sess.ContainerID, container.UpdateConfig{Resources: update})
+		repaired, errMsg := r.apply(err)
+		for i := range limitDrifts {
+			limitDrifts[i].Repaired, limitDrifts[i].Error = repaired, errMsg
+		}
+	}
+
+	return append(drifts, limitDrifts...)
+}
+
+// containerIP 与 Container.Start 选取 IP 的顺序一致：共享网络、session 专用网络、任意网络
+func (r *Reconciler) containerIP(sessionID string, inspect container.InspectResponse) string {
+	if inspect.NetworkSettings == nil {
+		return ""
+	}
+	networks := inspect.NetworkSettings.Networks
+	for _, name := range []string{r.config.NetworkName, sandbox.SessionNetworkName(sessionID)} {
+		if ep, ok := networks[name]; ok && ep.IPAddress != "" {
+			return ep.IPAddress
+		}
+	}
+	for _, ep := range networks {
+		if ep.IPAddress != "" {
+			return ep.IPAddress
+		}
+	}
+	return ""
+}
+
+func (r *Reconciler) apply(err error) (bool, string) {
+	if err != nil {
+		return false, err.Error()
+	}
+	return true, ""
+}
+
+func (r *Reconciler) report(ctx context.Context, sessionID string, d Drift) {
+	monitor.SessionDrift.WithLabelValues(string(d.Field), strconv.FormatBool(d.Repaired)).Inc()
+	r.logger.Warn("Session container drift detected",
+		"session_id", sessionID,
+		"container_id", d.ContainerID,
+		"field", d.Field,
+		"recorded", d.Recorded,
+		"actual", d.Actual,
+		"repaired", d.Repaired,
+		"error", d.Error,
+	)
+
+	if err := r.bus.Publish(ctx, sessionID, eventbus.Event{
+		Type:      eventbus.EventSessionDrift,
+		SessionID: sessionID,
+		Payload:   d,
+		Timestamp: time.Now(),
+	}); err != nil {
+		r.logger.Warn("Failed to publish drift event", "session_id", sessionID, "error", err)
+	}
+}
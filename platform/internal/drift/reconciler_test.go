@@ -0,0 +1,212 @@
+package drift
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+
+	"platform/internal/eventbus"
+	"platform/internal/session"
+)
+
+type fakeRepo struct {
+	session.SessionRepository
+	sessions []*session.Session
+	ips      map[string]string
+	statuses map[string]session.SessionStatus
+}
+
+func (r *fakeRepo) ListByStatus(ctx context.Context, statuses []session.SessionStatus) ([]*session.Session, error) {
+	return r.sessions, nil
+}
+
+func (r *fakeRepo) UpdateSessionContainerInfo(ctx context.Context, id, containerID, nodeIP string) error {
+	r.ips[id] = nodeIP
+	return nil
+}
+
+func (r *fakeRepo) UpdateSessionStatus(ctx context.Context, id string, status session.SessionStatus) error {
+	r.statuses[id] = status
+	return nil
+}
+
+type fakeBus struct {
+	eventbus.EventBus
+	events []eventbus.Event
+}
+
+func (b *fakeBus) Publish(ctx context.Context, sessionID string, event eventbus.Event) error {
+	b.events = append(b.events, event)
+	return nil
+}
+
+type fakeDocker struct {
+	containers map[string]container.InspectResponse
+	updates    map[string]container.Resources
+}
+
+func (d *fakeDocker) ContainerInspect(ctx context.Context, id string) (container.InspectResponse, error) {
+	inspect, ok := d.containers[id]
+	if !ok {
+		return container.InspectResponse{}, errdefs.ErrNotFound
+	}
+	return inspect, nil
+}
+
+func (d *fakeDocker) ContainerUpdate(ctx context.Context, id string, cfg container.UpdateConfig) (container.UpdateResponse, error) {
+	d.updates[id] = cfg.Resources
+	return container.UpdateResponse{}, nil
+}
+
+func running(ip string, memory, nanoCPUs int64) container.InspectResponse {
+	return container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			State:      &container.State{Running: true, Status: "running"},
+			HostConfig: &container.HostConfig{Resources: container.Resources{Memory: memory, NanoCPUs: nanoCPUs}},
+		},
+		NetworkSettings: &container.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{"agent-net": {IPAddress: ip}},
+		},
+	}
+}
+
+func TestReconcilerRepairsDrift(t *testing.T) {
+	const mem = 512 * 1024 * 1024
+	repo := &fakeRepo{
+		sessions: []*session.Session{
+			{ID: "s-ok", ContainerID: "c-ok", NodeIP: "10.0.0.2", Status: session.StatusReady},
+			{ID: "s-ip", ContainerID: "c-ip", NodeIP: "10.0.0.3", Status: session.StatusReady},
+			{ID: "s-mem", ContainerID: "c-mem", NodeIP: "10.0.0.4", Status: session.StatusRunning},
+			{ID: "s-gone", ContainerID: "c-gone", NodeIP: "10.0.0.5", Status: session.StatusReady},
+		},
+		ips:      map[string]string{},
+		statuses: map[string]session.SessionStatus{},
+	}
+	docker := &fakeDocker{
+		containers: map[string]container.InspectResponse{
+			"c-ok":  running("10.0.0.2", mem, 5e8),
+			"c-ip":  running("10.0.0.9", mem, 5e8),
+			"c-mem": running("10.0.0.4", 2*mem, 5e8),
+		},
+		updates: map[string]container.Resources{},
+	}
+	bus := &fakeBus{}
+	var forgotten []string
+
+	r := NewReconciler(repo, docker, bus, Config{
+		NetworkName: "agent-net",
+		MemoryLimit: mem,
+		CPULimit:    0.5,
+		Repair:      true,
+	}, slog.Default())
+	r.OnIPChanged = func(id string) { forgotten = append(forgotten, id) }
+
+	r.reconcile()
+
+	if repo.ips["s-ip"] != "10.0.0.9" || len(repo.ips) != 1 {
+		t.Fatalf("ip updates = %v", repo.ips)
+	}
+	if len(forgotten) != 1 || forgotten[0] != "s-ip" {
+		t.Fatalf("OnIPChanged calls = %v", forgotten)
+	}
+	if got := docker.updates["c-mem"]; got.Memory != mem || len(docker.updates) != 1 {
+		t.Fatalf("resource updates = %v", docker.updates)
+	}
+	if repo.statuses["s-gone"] != session.StatusError {
+		t.Fatalf("missing container: status = %q, want error", repo.statuses["s-gone"])
+	}
+
+	fields := map[string]Field{}
+	for _, ev := range bus.events {
+		d := ev.Payload.(Drift)
+		if !d.Repaired {
+			t.Fatalf("drift not repaired: %+v", d)
+		}
+		fields[ev.SessionID] = d.Field
+	}
+	want := map[string]Field{"s-ip": FieldIP, "s-mem": FieldMemory, "s-gone": FieldMissing}
+	if len(fields) != len(want) {
+		t.Fatalf("events = %v, want %v", fields, want)
+	}
+	for id, f := range want {
+		if fields[id] != f {
+			t.Fatalf("events = %v, want %v", fields, want)
+		}
+	}
+}
+
+func TestReconcilerReportOnlyDeduplicates(t *testing.T) {
+	repo := &fakeRepo{
+		sessions: []*session.Session{{ID: "s-ip", ContainerID: "c-ip", NodeIP: "10.0.0.3", Status: session.StatusReady}},
+		ips:      map[string]string{},
+	}
+	docker := &fakeDocker{
+		containers: map[string]container.InspectResponse{"c-ip": running("10.0.0.9", 0, 0)},
+		updates:    map[string]container.Resources{},
+	}
+	bus := &fakeBus{}
+
+	r := NewReconciler(repo, docker, bus, Config{NetworkName: "agent-net"}, slog.Default())
+	r.reconcile()
+	r.reconcile()
+
+	if len(repo.ips) != 0 {
+		t.Fatalf("report-only mode updated the DB: %v", repo.ips)
+	}
+	if len(bus.events) != 1 {
+		t.Fatalf("Expected one drift event, got %d", len(bus.events))
+	}
+}
+
+func TestReconcilerChecksSessionNode(t *testing.T) {
+	newRepo := func() *fakeRepo {
+		return &fakeRepo{
+			sessions: []*session.Session{
+				{ID: "s-local", ContainerID: "c-local", NodeIP: "10.0.0.2", Status: session.StatusReady},
+				{ID: "s-remote", ContainerID: "c-remote", NodeIP: "10.1.0.2", NodeID: "node-b", Status: session.StatusReady},
+				{ID: "s-removed", ContainerID: "c-removed", NodeIP: "10.2.0.2", NodeID: "node-c", Status: session.StatusReady},
+			},
+			ips:      map[string]string{},
+			statuses: map[string]session.SessionStatus{},
+		}
+	}
+	local := &fakeDocker{
+		containers: map[string]container.InspectResponse{"c-local": running("10.0.0.2", 0, 0)},
+		updates:    map[string]container.Resources{},
+	}
+	remote := &fakeDocker{
+		containers: map[string]container.InspectResponse{"c-remote": running("10.1.0.9", 0, 0)},
+		updates:    map[string]container.Resources{},
+	}
+
+	// 未配置节点时只检查本机，其他节点上的容器在本机查不到，不能据此标记 error
+	repo := newRepo()
+	r := NewReconciler(repo, local, &fakeBus{}, Config{NetworkName: "agent-net", Repair: true}, slog.Default())
+	r.reconcile()
+	if len(repo.statuses) != 0 || len(repo.ips) != 0 {
+		t.Fatalf("without nodes: statuses = %v, ips = %v, want no changes", repo.statuses, repo.ips)
+	}
+
+	repo = newRepo()
+	r = NewReconciler(repo, local, &fakeBus{}, Config{NetworkName: "agent-net", Repair: true}, slog.Default())
+	r.NodeDocker = func(nodeID string) (Docker, error) {
+		switch nodeID {
+		case "", "local":
+			return local, nil
+		case "node-b":
+			return remote, nil
+		}
+		return nil, errdefs.ErrNotFound
+	}
+	r.reconcile()
+	if len(repo.statuses) != 0 {
+		t.Fatalf("with nodes: statuses = %v, want no changes", repo.statuses)
+	}
+	if len(repo.ips) != 1 || repo.ips["s-remote"] != "10.1.0.9" {
+		t.Fatalf("with nodes: ip updates = %v, want s-remote repaired on its node", repo.ips)
+	}
+}
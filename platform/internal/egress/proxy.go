@@ -0,0 +1,240 @@
+// Package egress 受限网络策略下沙箱的出网代理：支持 HTTP CONNECT 隧道和绝对 URI 的普通 HTTP 请求，
+// 只放行目标域名或解析后 IP 命中白名单的连接。以 platform-server egress-proxy 运行在 session 的 sidecar 中。
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var ErrDenied = errors.New("destination not allowed by network policy")
+
+// Policy 放行规则
+type Policy struct {
+	cidrs   []*net.IPNet
+	domains []string
+}
+
+// ParsePolicy domains 中 "*.example.com" 匹配 example.com 及其子域名
+func ParsePolicy(cidrs, domains []string) (*Policy, error) {
+	p := &Policy{}
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+		}
+		p.cidrs = append(p.cidrs, ipNet)
+	}
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(d), "."))
+		if d != "" {
+			p.domains = append(p.domains, d)
+		}
+	}
+	return p, nil
+}
+
+// AllowDomain 域名是否在白名单中
+func (p *Policy) AllowDomain(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, d := range p.domains {
+		if suffix, ok := strings.CutPrefix(d, "*."); ok {
+			if host == suffix || strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == d {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowIP IP 是否在放行网段中
+func (p *Policy) AllowIP(ip net.IP) bool {
+	for _, n := range p.cidrs {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Proxy 出网代理。目标只解析一次并按解析出的 IP 拨号，避免检查后 DNS 结果变化绕过白名单
+type Proxy struct {
+	policy    *Policy
+	logger    *slog.Logger
+	resolver  *net.Resolver
+	dialer    *net.Dialer
+	transport *http.Transport
+}
+
+func NewProxy(policy *Policy, logger *slog.Logger) *Proxy {
+	p := &Proxy{
+		policy:   policy,
+		logger:   logger.With("component", "egress-proxy"),
+		resolver: net.DefaultResolver,
+		dialer:   &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second},
+	}
+	p.transport = &http.Transport{
+		Proxy:                 nil,
+		DialContext:           p.dial,
+		MaxIdleConns:          50,
+		IdleConnTimeout:       90 * time.Second,
+		ResponseHeaderTimeout: 60 * time.Second,
+	}
+	return p
+}
+
+// dial 检查目标后按 IP 拨号
+func (p *Proxy) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		addrs, err := p.resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range addrs {
+			ips = append(ips, a.IP)
+		}
+	}
+
+	domainOK := net.ParseIP(host) == nil && p.policy.AllowDomain(host)
+	for _, ip := range ips {
+		if !domainOK && !p.policy.AllowIP(ip) {
+			continue
+		}
+		return p.dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+	}
+	return nil, fmt.Errorf("%w: %s", ErrDenied, addr)
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.handleConnect(w, r)
+		return
+	}
+	if !r.URL.IsAbs() {
+		http.Error(w, "egress proxy only accepts absolute-URI or CONNECT requests", http.StatusBadRequest)
+		return
+	}
+	p.handleForward(w, r)
+}
+
+func (p *Proxy) handleConnect(w http.ResponseWriter, r *http.Request) {
+	upstream, err := p.dial(r.Context(), "tcp", r.Host)
+	if err != nil {
+		p.deny(w, r, err)
+		return
+	}
+	defer upstream.Close()
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buf, err := hijacker.Hijack()
+	if err != nil {
+		p.logger.Warn("Failed to hijack connection", "error", err)
+		return
+	}
+	defer client.Close()
+
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		// 客户端可能在 CONNECT 后立即发送数据，先转发已缓冲的部分
+		if n := buf.Reader.Buffered(); n > 0 {
+			pending, _ := buf.Reader.Peek(n)
+			upstream.Write(pending)
+		}
+		io.Copy(upstream, client)
+		closeWrite(upstream)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(client, upstream)
+		closeWrite(client)
+		done <- struct{}{}
+	}()
+	<-done
+	<-done
+}
+
+func (p *Proxy) handleForward(w http.ResponseWriter, r *http.Request) {
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	removeHopHeaders(out.Header)
+
+	resp, err := p.transport.RoundTrip(out)
+	if err != nil {
+		if errors.Is(err, ErrDenied) {
+			p.deny(w, r, err)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	removeHopHeaders(resp.Header)
+	for k, vs := range resp.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+func (p *Proxy) deny(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrDenied) {
+		p.logger.Info("Blocked egress request", "method", r.Method, "host", r.Host)
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadGateway)
+}
+
+// hopHeaders 逐跳头部，代理转发时不能透传
+var hopHeaders = []string{
+	"Connection", "Proxy-Connection", "Keep-Alive", "Proxy-Authenticate",
+	"Proxy-Authorization", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+func removeHopHeaders(h http.Header) {
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+}
+
+func closeWrite(conn net.Conn) {
+	if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+		cw.CloseWrite()
+		return
+	}
+	conn.Close()
+}
@@ -0,0 +1,130 @@
+package egress
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestPolicyAllowDomain(t *testing.T) {
+	p, err := ParsePolicy(nil, []string{"pypi.org", "*.github.com", "Example.COM."})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]bool{
+		"pypi.org":            true,
+		"files.pypi.org":      false,
+		"github.com":          true,
+		"api.github.com":      true,
+		"evilgithub.com":      false,
+		"example.com":         true,
+		"EXAMPLE.com.":        true,
+		"other.org":           false,
+		"github.com.evil.org": false,
+	}
+	for host, want := range cases {
+		if got := p.AllowDomain(host); got != want {
+			t.Errorf("AllowDomain(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestParsePolicyRejectsBadCIDR(t *testing.T) {
+	if _, err := ParsePolicy([]string{"10.0.0.0/33"}, nil); err == nil {
+		t.Fatal("expected error for invalid CIDR")
+	}
+}
+
+func newTestProxy(t *testing.T, cidrs []string) *url.URL {
+	t.Helper()
+	policy, err := ParsePolicy(cidrs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(NewProxy(policy, slog.New(slog.NewTextHandler(io.Discard, nil))))
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	return u
+}
+
+func TestProxyForward(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	}))
+	defer upstream.Close()
+
+	for _, tc := range []struct {
+		name   string
+		cidrs  []string
+		status int
+	}{
+		{"allowed", []string{"127.0.0.0/8"}, http.StatusOK},
+		{"denied", []string{"10.0.0.0/8"}, http.StatusForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(newTestProxy(t, tc.cidrs))}}
+			resp, err := client.Get(upstream.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tc.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tc.status)
+			}
+		})
+	}
+}
+
+func TestProxyConnect(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "tunneled")
+	}))
+	defer upstream.Close()
+	target := upstream.Listener.Addr().String()
+
+	connect := func(t *testing.T, proxy *url.URL) (net.Conn, *bufio.Reader, int) {
+		conn, err := net.Dial("tcp", proxy.Host)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(conn, "CONNECT %s HTTP/1.1\r\nHost: %s\r\n\r\n", target, target)
+		br := bufio.NewReader(conn)
+		resp, err := http.ReadResponse(br, &http.Request{Method: http.MethodConnect})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conn, br, resp.StatusCode
+	}
+
+	t.Run("allowed", func(t *testing.T) {
+		conn, br, status := connect(t, newTestProxy(t, []string{"127.0.0.1/32"}))
+		defer conn.Close()
+		if status != http.StatusOK {
+			t.Fatalf("CONNECT status = %d", status)
+		}
+		fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: %s\r\nConnection: close\r\n\r\n", target)
+		resp, err := http.ReadResponse(br, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != "tunneled" {
+			t.Fatalf("body = %q", body)
+		}
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		conn, _, status := connect(t, newTestProxy(t, nil))
+		defer conn.Close()
+		if status != http.StatusForbidden {
+			t.Fatalf("CONNECT status = %d, want 403", status)
+		}
+	})
+}
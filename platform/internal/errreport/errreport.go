@@ -0,0 +1,88 @@
+// Package errreport 将 panic 和严重错误上报到 Sentry（或兼容 Sentry 协议的服务）。
+// 未配置 DSN 时所有上报调用都是空操作，只保留本地日志。
+package errreport
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"platform/internal/reqid"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Config 错误上报配置
+type Config struct {
+	DSN         string
+	Environment string
+	Release     string
+	// SampleRate 错误事件采样率（0-1），0 时使用 1
+	SampleRate float64
+}
+
+// Init 初始化上报客户端，返回的函数在进程退出前调用以发送缓冲中的事件
+func Init(cfg Config) (func(), error) {
+	if cfg.DSN == "" {
+		return func() {}, nil
+	}
+
+	if err := sentry.Init(sentry.ClientOptions{
+		Dsn:              cfg.DSN,
+		Environment:      cfg.Environment,
+		Release:          cfg.Release,
+		SampleRate:       cfg.SampleRate,
+		AttachStacktrace: true,
+	}); err != nil {
+		return nil, fmt.Errorf("init error reporting: %w", err)
+	}
+	return func() { sentry.Flush(2 * time.Second) }, nil
+}
+
+// Tags 附加到事件上的上下文，如 session_id、task_type
+type Tags map[string]string
+
+// Recover 作为 defer 调用，捕获当前 goroutine 的 panic 并上报，不再向上传播。
+// 用于后台 goroutine，避免单个任务的 panic 导致整个进程退出。
+func Recover(ctx context.Context, logger *slog.Logger, component string, tags Tags) {
+	if r := recover(); r != nil {
+		ReportPanic(ctx, logger, component, r, tags)
+	}
+}
+
+// ReportPanic 上报已 recover 的 panic 值
+func ReportPanic(ctx context.Context, logger *slog.Logger, component string, value any, tags Tags) {
+	logger.Error("Recovered from panic",
+		"panic", fmt.Sprint(value),
+		"stack", string(debug.Stack()),
+	)
+
+	hub := hubFor(ctx, component, tags)
+	hub.RecoverWithContext(ctx, value)
+}
+
+// CaptureError 上报非 panic 的严重错误
+func CaptureError(ctx context.Context, component string, err error, tags Tags) {
+	if err == nil {
+		return
+	}
+	hubFor(ctx, component, tags).CaptureException(err)
+}
+
+func hubFor(ctx context.Context, component string, tags Tags) *sentry.Hub {
+	hub := sentry.CurrentHub().Clone()
+	hub.ConfigureScope(func(scope *sentry.Scope) {
+		scope.SetTag("component", component)
+		if id := reqid.FromContext(ctx); id != "" {
+			scope.SetTag("request_id", id)
+		}
+		for k, v := range tags {
+			if v != "" {
+				scope.SetTag(k, v)
+			}
+		}
+	})
+	return hub
+}
@@ -5,29 +5,69 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"sync"
+	"time"
+
+	"platform/internal/monitor"
+	"platform/internal/reqid"
 
 	"github.com/redis/go-redis/v9"
 )
 
 var _ EventBus = (*RedisBus)(nil)
 
+// 投递延迟的统计阶段，见 monitor.EventBusDeliveryLag
+const (
+	StageReceived = "received"
+	StageEmitted  = "emitted"
+)
+
 type RedisBus struct {
 	client redis.Cmdable
 	logger *slog.Logger
+	// Overflow 不为 nil 时超限的负载转存后以 PayloadRef 替代
+	Overflow *OverflowStore
+
+	mu          sync.Mutex
+	subscribers map[string]int
 }
 
 func NewRedisBus(client redis.Cmdable, logger *slog.Logger) *RedisBus {
-	return &RedisBus{client: client, logger: logger}
+	return &RedisBus{client: client, logger: logger, subscribers: make(map[string]int)}
+}
+
+// ObserveDeliveryLag 记录事件从产生到 stage 阶段的延迟，没有时间戳的事件不统计
+func ObserveDeliveryLag(stage string, event Event) {
+	if event.Timestamp.IsZero() {
+		return
+	}
+	monitor.EventBusDeliveryLag.WithLabelValues(stage).Observe(time.Since(event.Timestamp).Seconds())
 }
 
 func (b *RedisBus) Publish(ctx context.Context, sessionID string, event Event) error {
 	channelKey := SessionChannelKey(sessionID)
+	if event.RequestID == "" {
+		event.RequestID = reqid.FromContext(ctx)
+	}
+	if ref, err := b.Overflow.offload(ctx, sessionID, event.Payload); err != nil {
+		// 软上限：转存失败时仍按原负载发布
+		b.logger.Warn("Failed to offload event payload", "session_id", sessionID, "type", event.Type, "error", err)
+	} else if ref != nil {
+		monitor.EventBusPayloadOverflows.WithLabelValues(string(event.Type)).Inc()
+		event.Payload = *ref
+	}
 	data, err := json.Marshal(event)
 	if err != nil {
+		monitor.EventBusPublishErrors.WithLabelValues("marshal").Inc()
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
 
-	return b.client.Publish(ctx, channelKey, data).Err()
+	if err := b.client.Publish(ctx, channelKey, data).Err(); err != nil {
+		monitor.EventBusPublishErrors.WithLabelValues("redis").Inc()
+		return err
+	}
+	monitor.EventBusPublished.WithLabelValues(string(event.Type)).Inc()
+	return nil
 }
 
 func (b *RedisBus) Subscribe(ctx context.Context, sessionID string) (<-chan Event, error) {
@@ -38,11 +78,19 @@ func (b *RedisBus) Subscribe(ctx context.Context, sessionID string) (<-chan Even
 	}
 
 	pubSub := client.Subscribe(ctx, channelKey)
+	// Subscribe 不等待 Redis 确认，订阅生效前发布的事件会丢失；
+	// 等到确认再返回，调用方随后发起的对话产生的事件都能收到
+	if _, err := pubSub.Receive(ctx); err != nil {
+		pubSub.Close()
+		return nil, fmt.Errorf("failed to subscribe: %w", err)
+	}
 
 	ch := make(chan Event)
+	b.addSubscriber(sessionID, 1)
 
 	go func() {
 		defer close(ch)
+		defer b.addSubscriber(sessionID, -1)
 		defer func(pubSub *redis.PubSub) {
 			err := pubSub.Close()
 			if err != nil {
@@ -50,15 +98,48 @@ func (b *RedisBus) Subscribe(ctx context.Context, sessionID string) (<-chan Even
 			}
 		}(pubSub)
 
-		for msg := range pubSub.Channel() {
-			var event Event
-			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
-				b.logger.Error("failed to unmarshal event", "error", err)
-				continue
+		msgs := pubSub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				var event Event
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					monitor.EventBusUnmarshalErrors.Inc()
+					b.logger.Error("failed to unmarshal event", "error", err)
+					continue
+				}
+				monitor.EventBusConsumed.WithLabelValues(string(event.Type)).Inc()
+				ObserveDeliveryLag(StageReceived, event)
+
+				// 消费方阻塞时 Redis 端的缓冲会堆积，最终由 go-redis 丢弃消息
+				select {
+				case ch <- event:
+				case <-ctx.Done():
+					return
+				}
 			}
-			ch <- event
 		}
 	}()
 
 	return ch, nil
 }
+
+// addSubscriber 维护每个 session 的订阅者数量，归零时删除对应的指标序列以免 session 标签无限增长
+func (b *RedisBus) addSubscriber(sessionID string, delta int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := b.subscribers[sessionID] + delta
+	if n <= 0 {
+		delete(b.subscribers, sessionID)
+		monitor.EventBusSubscribers.DeleteLabelValues(sessionID)
+		return
+	}
+	b.subscribers[sessionID] = n
+	monitor.EventBusSubscribers.WithLabelValues(sessionID).Set(float64(n))
+}
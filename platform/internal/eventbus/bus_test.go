@@ -0,0 +1,26 @@
+package eventbus
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"platform/internal/monitor"
+)
+
+func TestSubscriberGaugeRemovedAtZero(t *testing.T) {
+	b := NewRedisBus(nil, slog.Default())
+
+	b.addSubscriber("s1", 1)
+	b.addSubscriber("s1", 1)
+	if got := testutil.ToFloat64(monitor.EventBusSubscribers.WithLabelValues("s1")); got != 2 {
+		t.Fatalf("subscribers = %v, want 2", got)
+	}
+
+	b.addSubscriber("s1", -1)
+	b.addSubscriber("s1", -1)
+	if n := testutil.CollectAndCount(monitor.EventBusSubscribers); n != 0 {
+		t.Fatalf("Expected series to be deleted, %d remain", n)
+	}
+}
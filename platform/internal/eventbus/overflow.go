@@ -0,0 +1,105 @@
+package eventbus
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrPayloadNotFound 引用的负载不存在或已过期
+var ErrPayloadNotFound = errors.New("event payload not found")
+
+const (
+	DefaultOverflowTTL  = time.Hour
+	DefaultPreviewBytes = 1024
+)
+
+// PayloadRef 超出大小上限的负载被转存后，事件中替代原负载的引用
+type PayloadRef struct {
+	// Ref 通过 GET /sessions/:id/events/payloads/:ref 获取完整负载
+	Ref string `json:"ref"`
+	// Size 原负载 JSON 的字节数
+	Size int `json:"size"`
+	// Preview 原负载 JSON 的前若干字节，用于展示
+	Preview   string `json:"preview"`
+	Truncated bool   `json:"truncated"`
+}
+
+// OverflowStore 将超出 MaxBytes 的事件负载转存到 Redis，事件中只保留引用和预览
+type OverflowStore struct {
+	client redis.Cmdable
+	// MaxBytes 负载 JSON 的大小上限，<=0 表示不限制
+	MaxBytes int
+	// TTL 转存负载的保留时长
+	TTL time.Duration
+	// PreviewBytes 引用中预览的字节数
+	PreviewBytes int
+}
+
+func NewOverflowStore(client redis.Cmdable, maxBytes int, ttl time.Duration) *OverflowStore {
+	if ttl <= 0 {
+		ttl = DefaultOverflowTTL
+	}
+	return &OverflowStore{client: client, MaxBytes: maxBytes, TTL: ttl, PreviewBytes: DefaultPreviewBytes}
+}
+
+func payloadKey(sessionID, ref string) string {
+	return "session:" + sessionID + ":payload:" + ref
+}
+
+// offload 负载超限时转存并返回替代它的引用，未超限时返回 nil
+func (s *OverflowStore) offload(ctx context.Context, sessionID string, payload any) (*PayloadRef, error) {
+	if s == nil || s.MaxBytes <= 0 || payload == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	if len(data) <= s.MaxBytes {
+		return nil, nil
+	}
+
+	ref := newPayloadRef()
+	if err := s.client.Set(ctx, payloadKey(sessionID, ref), data, s.TTL).Err(); err != nil {
+		return nil, fmt.Errorf("failed to store payload: %w", err)
+	}
+	preview, truncated := previewOf(data, s.PreviewBytes)
+	return &PayloadRef{Ref: ref, Size: len(data), Preview: preview, Truncated: truncated}, nil
+}
+
+// Load 读取转存的负载 JSON
+func (s *OverflowStore) Load(ctx context.Context, sessionID, ref string) ([]byte, error) {
+	data, err := s.client.Get(ctx, payloadKey(sessionID, ref)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrPayloadNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load payload: %w", err)
+	}
+	return data, nil
+}
+
+// previewOf 截取 data 的前 n 字节，不拆开多字节字符
+func previewOf(data []byte, n int) (string, bool) {
+	if len(data) <= n {
+		return string(data), false
+	}
+	for n > 0 && !utf8.RuneStart(data[n]) {
+		n--
+	}
+	return string(data[:n]), true
+}
+
+func newPayloadRef() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
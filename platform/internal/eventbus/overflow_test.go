@@ -0,0 +1,34 @@
+package eventbus
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPreviewOf(t *testing.T) {
+	if got, truncated := previewOf([]byte(`"short"`), 16); got != `"short"` || truncated {
+		t.Fatalf("previewOf short = %q, %v", got, truncated)
+	}
+	// 截断位置落在多字节字符中间时回退到字符起点
+	got, truncated := previewOf([]byte(`"你好"`), 3)
+	if got != `"` || !truncated {
+		t.Fatalf("previewOf multibyte = %q, %v", got, truncated)
+	}
+}
+
+func TestOffloadUnderLimit(t *testing.T) {
+	var nilStore *OverflowStore
+	if ref, err := nilStore.offload(context.Background(), "s1", strings.Repeat("x", 1<<20)); ref != nil || err != nil {
+		t.Fatalf("nil store offload = %v, %v", ref, err)
+	}
+
+	// 未超限时不访问 Redis
+	s := NewOverflowStore(nil, 64, 0)
+	if ref, err := s.offload(context.Background(), "s1", map[string]any{"output": "ok"}); ref != nil || err != nil {
+		t.Fatalf("offload under limit = %v, %v", ref, err)
+	}
+	if s.TTL != DefaultOverflowTTL {
+		t.Fatalf("TTL = %v, want default", s.TTL)
+	}
+}
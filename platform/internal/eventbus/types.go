@@ -9,6 +9,37 @@ const (
 	EventSessionReady  EventType = "session.ready"
 	EventSessionClosed EventType = "session.closed"
 	EventSessionError  EventType = "session.error"
+	// EventSessionQueued 排队中的 session 的排队位置和预计就绪时间发生变化
+	EventSessionQueued EventType = "session.queue_position"
+	// EventDiskQuotaExceeded 工作区磁盘占用超过配额
+	EventDiskQuotaExceeded EventType = "session.disk_quota_exceeded"
+	// EventSecurityViolation 平台发起的 exec 违反命令策略被拒绝
+	EventSecurityViolation EventType = "session.security_violation"
+	// EventSessionDrift 容器实际状态与 session 记录不一致（IP、资源限制等），负载说明是否已修复
+	EventSessionDrift EventType = "session.drift"
+	// EventSessionSuspended session 长时间无活动，容器已停止，负载包含最近一次活动的时间
+	EventSessionSuspended EventType = "session.suspended"
+	// EventSessionRestarting session 开始重启，负载包含重启方式（原地启动或重新分配容器）和原因，完成后发布 EventSessionReady
+	EventSessionRestarting EventType = "session.restarting"
+	// EventSessionContainerDied session 租用的容器意外停止或被删除，负载包含容器状态和处理方式（重新分配容器或标记为 error）
+	EventSessionContainerDied EventType = "session.container_died"
+
+	// EventImagePullProgress 冷启动拉取镜像的进度，负载为 sandbox.PullProgress
+	EventImagePullProgress EventType = "image.pull_progress"
+	// EventPlatformExecOutput 平台发起的 exec（如启动 agent、同步文件）的实时输出，负载为 ExecOutput
+	EventPlatformExecOutput EventType = "platform.exec_output"
+
+	// EventPoolReady 实例启动后预热池达到目标空闲数或等待超时，发布在 PlatformChannel 上
+	EventPoolReady EventType = "pool.ready"
+	// EventPoolDegraded 预热池补充连续失败进入冷却，EventPoolRecovered 冷却后成功创建了预热容器，都发布在 PlatformChannel 上
+	EventPoolDegraded  EventType = "pool.degraded"
+	EventPoolRecovered EventType = "pool.recovered"
+
+	// Compose Events
+	EventComposeServiceRestarted EventType = "compose.service_restarted"
+	EventComposeServiceScaled    EventType = "compose.service_scaled"
+	// EventServicesUpdated 服务连接信息（compose / companion）发生变化
+	EventServicesUpdated EventType = "services.updated"
 
 	// Agent Events (映射自 Proto)
 	EventAgentThought    EventType = "agent.thought"
@@ -30,8 +61,22 @@ type Event struct {
 	SessionID string    `json:"session_id"`
 	Payload   any       `json:"payload"`
 	Timestamp time.Time `json:"timestamp"`
+	// RequestID 触发该事件的请求 ID，由 Publish 从 context 中补全
+	RequestID string `json:"request_id,omitempty"`
 }
 
+// ExecOutput EventPlatformExecOutput 的负载
+type ExecOutput struct {
+	// Step 产生输出的平台步骤，与任务阶段相同，如 syncing_files、starting_agent
+	Step string `json:"step"`
+	// Stream stdout 或 stderr
+	Stream string `json:"stream"`
+	Data   string `json:"data"`
+}
+
+// PlatformChannel 不属于任何 session 的平台事件使用的通道 ID，订阅 SessionChannelKey(PlatformChannel)
+const PlatformChannel = "_platform"
+
 func SessionChannelKey(sessionID string) string {
 	return "session:" + sessionID + ":events"
 }
@@ -0,0 +1,172 @@
+// Package execpolicy 终端 exec 的启动命令策略：拒绝黑名单中的程序、限制工作目录、清理危险的环境变量。
+// 策略只检查启动命令本身（包括 sh -c 脚本中出现的命令名），进入 shell 后输入的命令不经过平台；
+// 它是纵深防御的一层，不能替代容器隔离——沙箱内的进程仍然可以自行启动任何程序。
+package execpolicy
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// DefaultDeniedBinaries 默认拒绝的程序：容器运行时客户端、命名空间和挂载操作、内核模块加载
+var DefaultDeniedBinaries = []string{
+	"docker", "podman", "nerdctl", "ctr", "kubectl",
+	"nsenter", "unshare", "chroot",
+	"mount", "umount",
+	"insmod", "modprobe", "rmmod",
+}
+
+// DefaultAllowedWorkDirs 默认允许的工作目录（含子目录）
+var DefaultAllowedWorkDirs = []string{"/app/workspace", "/tmp"}
+
+// DefaultScrubEnv 默认从调用方传入的环境变量中移除的名称，以 * 结尾表示前缀匹配
+var DefaultScrubEnv = []string{"LD_PRELOAD", "LD_LIBRARY_PATH", "LD_AUDIT", "DOCKER_*", "KUBECONFIG"}
+
+// shells 以 -c 执行脚本时需要检查脚本内容的 shell
+var shells = []string{"sh", "bash", "ash", "dash", "zsh"}
+
+type Policy struct {
+	DeniedBinaries  []string
+	AllowedWorkDirs []string
+	ScrubEnvVars    []string
+}
+
+func Default() *Policy {
+	return &Policy{
+		DeniedBinaries:  DefaultDeniedBinaries,
+		AllowedWorkDirs: DefaultAllowedWorkDirs,
+		ScrubEnvVars:    DefaultScrubEnv,
+	}
+}
+
+// Violation 命令违反策略
+type Violation struct {
+	// Rule denied_binary 或 workdir
+	Rule   string
+	Detail string
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("command denied by policy (%s): %s", v.Rule, v.Detail)
+}
+
+// Check 检查命令和工作目录，违反策略时返回 *Violation
+func (p *Policy) Check(cmd []string, workDir string) error {
+	if workDir != "" && len(p.AllowedWorkDirs) > 0 && !p.workDirAllowed(workDir) {
+		return &Violation{Rule: "workdir", Detail: workDir}
+	}
+	for _, name := range commandNames(cmd) {
+		if slices.Contains(p.DeniedBinaries, name) {
+			return &Violation{Rule: "denied_binary", Detail: name}
+		}
+	}
+	return nil
+}
+
+func (p *Policy) workDirAllowed(workDir string) bool {
+	dir := path.Clean(workDir)
+	for _, allowed := range p.AllowedWorkDirs {
+		if dir == allowed || strings.HasPrefix(dir, strings.TrimSuffix(allowed, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ScrubEnv 移除命中 ScrubEnvVars 的环境变量，返回保留的和被移除的变量名
+func (p *Policy) ScrubEnv(env []string) (kept []string, removed []string) {
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if p.scrubbed(name) {
+			removed = append(removed, name)
+			continue
+		}
+		kept = append(kept, kv)
+	}
+	return kept, removed
+}
+
+func (p *Policy) scrubbed(name string) bool {
+	for _, pattern := range p.ScrubEnvVars {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// commandNames 提取命令中会被执行的程序名：argv[0]，env / exec 等包装命令之后的程序，
+// 以及 sh -c 脚本中每个简单命令的首个单词
+func commandNames(cmd []string) []string {
+	if len(cmd) == 0 {
+		return nil
+	}
+	name := path.Base(cmd[0])
+	names := []string{name}
+
+	switch {
+	case slices.Contains(shells, name):
+		for i := 1; i < len(cmd)-1; i++ {
+			if cmd[i] == "-c" {
+				names = append(names, scriptCommands(cmd[i+1])...)
+				break
+			}
+		}
+	case name == "env" || name == "exec" || name == "nohup" || name == "sudo" || name == "timeout" || name == "nice":
+		rest := cmd[1:]
+		for len(rest) > 0 && (strings.HasPrefix(rest[0], "-") || strings.Contains(rest[0], "=") ||
+			(name == "timeout" && isDuration(rest[0]))) {
+			rest = rest[1:]
+		}
+		names = append(names, commandNames(rest)...)
+	}
+	return names
+}
+
+// scriptCommands 粗略切分 shell 脚本，取每个简单命令的程序名（不处理引号内的分隔符）
+func scriptCommands(script string) []string {
+	replacer := strings.NewReplacer(
+		"&&", "\n", "||", "\n", ";", "\n", "|", "\n", "&", "\n",
+		"$(", "\n", "`", "\n", "(", "\n", ")", "\n", "{", "\n", "}", "\n",
+	)
+	var names []string
+	for _, stmt := range strings.Split(replacer.Replace(script), "\n") {
+		fields := strings.Fields(stmt)
+		// 跳过 if/then 等关键字和前置的变量赋值
+		for len(fields) > 0 && (isShellKeyword(fields[0]) || strings.Contains(fields[0], "=")) {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		names = append(names, commandNames(fields)...)
+	}
+	return names
+}
+
+func isShellKeyword(word string) bool {
+	switch word {
+	case "if", "then", "else", "elif", "fi", "do", "done", "while", "until", "!", "command", "builtin":
+		return true
+	}
+	return false
+}
+
+func isDuration(s string) bool {
+	s = strings.TrimRight(s, "smhd")
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && r != '.' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package execpolicy
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	p := Default()
+
+	cases := []struct {
+		name    string
+		cmd     []string
+		workDir string
+		rule    string
+	}{
+		{"plain", []string{"ls", "-la"}, "/app/workspace", ""},
+		{"subdir", []string{"python", "main.py"}, "/app/workspace/src", ""},
+		{"terminal shell", []string{"/bin/sh", "-c", "if command -v bash >/dev/null 2>&1; then exec bash; else exec sh; fi"}, "/app/workspace", ""},
+		{"denied binary", []string{"/usr/bin/docker", "ps"}, "", "denied_binary"},
+		{"wrapped", []string{"env", "FOO=1", "nsenter", "-t", "1"}, "", "denied_binary"},
+		{"timeout wrapper", []string{"timeout", "10s", "mount", "/dev/sda1", "/mnt"}, "", "denied_binary"},
+		{"shell script", []string{"bash", "-c", "echo hi && docker run alpine"}, "", "denied_binary"},
+		{"command substitution", []string{"sh", "-c", "x=$(mount | grep foo)"}, "", "denied_binary"},
+		{"workdir escape", []string{"ls"}, "/app/workspace/../../etc", "workdir"},
+		{"workdir prefix", []string{"ls"}, "/app/workspace2", "workdir"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := p.Check(tc.cmd, tc.workDir)
+			if tc.rule == "" {
+				if err != nil {
+					t.Fatalf("unexpected violation: %v", err)
+				}
+				return
+			}
+			var v *Violation
+			if !errors.As(err, &v) || v.Rule != tc.rule {
+				t.Fatalf("err = %v, want %s violation", err, tc.rule)
+			}
+		})
+	}
+}
+
+func TestScrubEnv(t *testing.T) {
+	kept, removed := Default().ScrubEnv([]string{"TERM=xterm", "LD_PRELOAD=/tmp/x.so", "DOCKER_HOST=tcp://1.2.3.4", "PATH=/bin"})
+	if !slices.Equal(kept, []string{"TERM=xterm", "PATH=/bin"}) {
+		t.Fatalf("kept = %v", kept)
+	}
+	if !slices.Equal(removed, []string{"LD_PRELOAD", "DOCKER_HOST"}) {
+		t.Fatalf("removed = %v", removed)
+	}
+}
@@ -0,0 +1,226 @@
+package featureflag
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Flags 开关的默认值和运行时覆盖，覆盖定期从 Store 刷新。
+// Flags 为 nil 时所有开关取 Known 中的内置默认值
+type Flags struct {
+	store    Store
+	defaults map[Flag]bool
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu        sync.RWMutex
+	overrides map[string]Override
+	stopCh    chan struct{}
+}
+
+// New defaults 覆盖内置默认值，interval 为从 Store 刷新覆盖的周期
+func New(store Store, defaults map[Flag]bool, interval time.Duration, logger *slog.Logger) *Flags {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	merged := make(map[Flag]bool, len(Known))
+	for flag, def := range Known {
+		merged[flag] = def.Default
+	}
+	for flag, enabled := range defaults {
+		merged[flag] = enabled
+	}
+	return &Flags{
+		store:     store,
+		defaults:  merged,
+		interval:  interval,
+		logger:    logger.With("component", "feature-flags"),
+		overrides: make(map[string]Override),
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// Enabled 返回开关在 scope 下是否开启
+func (f *Flags) Enabled(flag Flag, scope Scope) bool {
+	if f == nil {
+		return Known[flag].Default
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, s := range candidates(scope) {
+		if o, ok := f.overrides[field(flag, s)]; ok {
+			return o.Enabled
+		}
+	}
+	return f.defaults[flag]
+}
+
+// candidates 按优先级从高到低列出 scope 匹配的覆盖范围
+func candidates(scope Scope) []Scope {
+	var out []Scope
+	if scope.Tenant != "" && scope.Project != "" {
+		out = append(out, scope)
+	}
+	if scope.Project != "" {
+		out = append(out, Scope{Project: scope.Project})
+	}
+	if scope.Tenant != "" {
+		out = append(out, Scope{Tenant: scope.Tenant})
+	}
+	return append(out, Scope{})
+}
+
+// EnabledCtx 使用 ctx 中由 WithScope 设置的范围判断开关
+func (f *Flags) EnabledCtx(ctx context.Context, flag Flag) bool {
+	return f.Enabled(flag, ScopeFrom(ctx))
+}
+
+// Set 保存一个覆盖并立即在本实例生效
+func (f *Flags) Set(ctx context.Context, o Override) (*Override, error) {
+	if _, ok := Known[o.Flag]; !ok {
+		return nil, fmt.Errorf("invalid feature flag %q", o.Flag)
+	}
+	o.UpdatedAt = time.Now()
+	if err := f.store.Save(ctx, &o); err != nil {
+		return nil, fmt.Errorf("failed to save feature flag: %w", err)
+	}
+
+	f.mu.Lock()
+	f.overrides[field(o.Flag, o.Scope)] = o
+	f.mu.Unlock()
+	f.logger.Info("Feature flag overridden", "flag", o.Flag, "tenant", o.Tenant, "project", o.Project, "enabled", o.Enabled)
+	return &o, nil
+}
+
+// Delete 移除一个覆盖，恢复使用更宽范围的覆盖或默认值
+func (f *Flags) Delete(ctx context.Context, flag Flag, scope Scope) error {
+	key := field(flag, scope)
+	f.mu.RLock()
+	_, ok := f.overrides[key]
+	f.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("feature flag override not found")
+	}
+	if err := f.store.Delete(ctx, flag, scope); err != nil {
+		return fmt.Errorf("failed to delete feature flag: %w", err)
+	}
+
+	f.mu.Lock()
+	delete(f.overrides, key)
+	f.mu.Unlock()
+	f.logger.Info("Feature flag override removed", "flag", flag, "tenant", scope.Tenant, "project", scope.Project)
+	return nil
+}
+
+// States 返回所有已知开关的默认值和覆盖，按名称排序
+func (f *Flags) States() []State {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	states := make([]State, 0, len(Known))
+	for flag, def := range Known {
+		st := State{Flag: flag, Description: def.Description, Default: f.defaults[flag], Overrides: []Override{}}
+		for _, o := range f.overrides {
+			if o.Flag == flag {
+				st.Overrides = append(st.Overrides, o)
+			}
+		}
+		sort.Slice(st.Overrides, func(i, j int) bool {
+			return field(flag, st.Overrides[i].Scope) < field(flag, st.Overrides[j].Scope)
+		})
+		states = append(states, st)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Flag < states[j].Flag })
+	return states
+}
+
+// Refresh 从 Store 重新加载全部覆盖，其他实例的修改由此生效
+func (f *Flags) Refresh(ctx context.Context) error {
+	list, err := f.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	overrides := make(map[string]Override, len(list))
+	for _, o := range list {
+		overrides[field(o.Flag, o.Scope)] = o
+	}
+
+	f.mu.Lock()
+	f.overrides = overrides
+	f.mu.Unlock()
+	return nil
+}
+
+// Start 立即加载覆盖并定期刷新，直到 Stop
+func (f *Flags) Start() {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := f.Refresh(ctx); err != nil {
+			f.logger.Warn("Failed to refresh feature flags", "error", err)
+		}
+		cancel()
+
+		select {
+		case <-f.stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop 停止刷新循环
+func (f *Flags) Stop() {
+	select {
+	case <-f.stopCh:
+	default:
+		close(f.stopCh)
+	}
+}
+
+// ParseDefaults 解析 "pool_fifo_acquire=true,coalesced_streaming=false" 形式的默认值
+func ParseDefaults(s string) (map[Flag]bool, error) {
+	out := make(map[Flag]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		flag := Flag(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("invalid feature flag default %q (expected flag=true|false)", part)
+		}
+		if _, known := Known[flag]; !known {
+			return nil, fmt.Errorf("invalid feature flag %q", flag)
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid feature flag default %q (expected flag=true|false)", part)
+		}
+		out[flag] = enabled
+	}
+	return out, nil
+}
+
+type scopeKey struct{}
+
+// WithScope 在 ctx 中记录判断开关使用的租户和项目
+func WithScope(ctx context.Context, scope Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// ScopeFrom 返回 WithScope 设置的范围，未设置时为全局范围
+func ScopeFrom(ctx context.Context) Scope {
+	scope, _ := ctx.Value(scopeKey{}).(Scope)
+	return scope
+}
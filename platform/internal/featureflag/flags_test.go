@@ -0,0 +1,116 @@
+package featureflag
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+)
+
+type memStore struct {
+	mu        sync.Mutex
+	overrides map[string]Override
+}
+
+func (s *memStore) List(ctx context.Context) ([]Override, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Override
+	for _, o := range s.overrides {
+		out = append(out, o)
+	}
+	return out, nil
+}
+
+func (s *memStore) Save(ctx context.Context, o *Override) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overrides[field(o.Flag, o.Scope)] = *o
+	return nil
+}
+
+func (s *memStore) Delete(ctx context.Context, flag Flag, scope Scope) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.overrides, field(flag, scope))
+	return nil
+}
+
+func TestEnabledPrecedence(t *testing.T) {
+	ctx := context.Background()
+	store := &memStore{overrides: map[string]Override{}}
+	f := New(store, nil, 0, slog.New(slog.NewTextHandler(os.Stderr, nil)))
+
+	if f.Enabled(PoolFIFOAcquire, Scope{Tenant: "alice", Project: "p1"}) {
+		t.Fatal("expected built-in default false")
+	}
+
+	set := func(scope Scope, enabled bool) {
+		t.Helper()
+		if _, err := f.Set(ctx, Override{Flag: PoolFIFOAcquire, Scope: scope, Enabled: enabled}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	set(Scope{}, true)
+	set(Scope{Tenant: "alice"}, false)
+	set(Scope{Project: "p1"}, true)
+
+	tests := []struct {
+		scope Scope
+		want  bool
+	}{
+		{Scope{}, true},
+		{Scope{Tenant: "bob"}, true},
+		{Scope{Tenant: "alice"}, false},
+		{Scope{Tenant: "alice", Project: "p2"}, false},
+		{Scope{Tenant: "alice", Project: "p1"}, true},
+	}
+	for _, tt := range tests {
+		if got := f.Enabled(PoolFIFOAcquire, tt.scope); got != tt.want {
+			t.Errorf("Enabled(%+v) = %v, want %v", tt.scope, got, tt.want)
+		}
+	}
+
+	// 其他实例通过 Refresh 看到同一份覆盖
+	other := New(store, nil, 0, slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	if err := other.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !other.EnabledCtx(WithScope(ctx, Scope{Tenant: "alice", Project: "p1"}), PoolFIFOAcquire) {
+		t.Error("expected refreshed override to apply")
+	}
+
+	if err := f.Delete(ctx, PoolFIFOAcquire, Scope{Project: "p1"}); err != nil {
+		t.Fatal(err)
+	}
+	if f.Enabled(PoolFIFOAcquire, Scope{Tenant: "alice", Project: "p1"}) {
+		t.Error("expected tenant override after deleting project override")
+	}
+	if err := f.Delete(ctx, PoolFIFOAcquire, Scope{Project: "p1"}); err == nil {
+		t.Error("expected error deleting missing override")
+	}
+	if _, err := f.Set(ctx, Override{Flag: "nope"}); err == nil {
+		t.Error("expected error for unknown flag")
+	}
+}
+
+func TestParseDefaults(t *testing.T) {
+	got, err := ParseDefaults("pool_fifo_acquire=true, structured_file_list=false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got[PoolFIFOAcquire] || got[StructuredFileList] {
+		t.Fatalf("ParseDefaults = %v", got)
+	}
+	for _, bad := range []string{"pool_fifo_acquire", "nope=true", "pool_fifo_acquire=maybe"} {
+		if _, err := ParseDefaults(bad); err == nil {
+			t.Errorf("ParseDefaults(%q) expected error", bad)
+		}
+	}
+
+	var nilFlags *Flags
+	if !nilFlags.Enabled(StructuredFileList, Scope{}) {
+		t.Error("nil Flags should use built-in defaults")
+	}
+}
@@ -0,0 +1,9 @@
+package featureflag
+
+import "context"
+
+type Store interface {
+	List(ctx context.Context) ([]Override, error)
+	Save(ctx context.Context, o *Override) error
+	Delete(ctx context.Context, flag Flag, scope Scope) error
+}
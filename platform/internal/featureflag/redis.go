@@ -0,0 +1,54 @@
+package featureflag
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// flagsKey 所有覆盖存放在同一个 hash 中，field 为 flag|tenant|project
+const flagsKey = "feature:flags"
+
+var _ Store = (*RedisStore)(nil)
+
+type RedisStore struct {
+	redis redis.Cmdable
+}
+
+func NewRedisStore(redis redis.Cmdable) *RedisStore {
+	return &RedisStore{redis: redis}
+}
+
+func field(flag Flag, scope Scope) string {
+	return string(flag) + "|" + scope.Tenant + "|" + scope.Project
+}
+
+func (s *RedisStore) List(ctx context.Context) ([]Override, error) {
+	vals, err := s.redis.HGetAll(ctx, flagsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Override, 0, len(vals))
+	for _, v := range vals {
+		var o Override
+		if err := json.Unmarshal([]byte(v), &o); err != nil {
+			continue
+		}
+		out = append(out, o)
+	}
+	return out, nil
+}
+
+func (s *RedisStore) Save(ctx context.Context, o *Override) error {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	return s.redis.HSet(ctx, flagsKey, field(o.Flag, o.Scope), b).Err()
+}
+
+func (s *RedisStore) Delete(ctx context.Context, flag Flag, scope Scope) error {
+	return s.redis.HDel(ctx, flagsKey, field(flag, scope)).Err()
+}
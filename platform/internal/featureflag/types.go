@@ -0,0 +1,55 @@
+// Package featureflag 按租户、项目开关实验性行为。
+// 默认值来自配置，运行时覆盖保存在 Redis 中，通过管理接口修改后各实例在刷新周期内生效。
+package featureflag
+
+import "time"
+
+// Flag 功能开关名称
+type Flag string
+
+const (
+	// PoolFIFOAcquire 预热池取出最早放入的空闲容器，默认取出最近放回的容器
+	PoolFIFOAcquire Flag = "pool_fifo_acquire"
+	// StructuredFileList 文件列表支持递归和 glob 过滤
+	StructuredFileList Flag = "structured_file_list"
+	// CoalescedStreaming SSE 每次写出所有已到达的事件后再 flush
+	CoalescedStreaming Flag = "coalesced_streaming"
+	// PlatformExecOutput 平台发起的 exec 输出作为 platform.exec_output 事件发布到 session 通道
+	PlatformExecOutput Flag = "platform_exec_output"
+)
+
+// Definition 已知开关的说明和内置默认值
+type Definition struct {
+	Description string
+	Default     bool
+}
+
+// Known 所有已知开关，未列出的开关不能覆盖
+var Known = map[Flag]Definition{
+	PoolFIFOAcquire:    {Description: "Acquire the oldest idle warm container instead of the most recently released one"},
+	StructuredFileList: {Description: "Allow recursive and glob-filtered file listings", Default: true},
+	CoalescedStreaming: {Description: "Write all pending SSE events before flushing"},
+	PlatformExecOutput: {Description: "Stream output of platform-initiated execs (agent start, file sync) as platform.exec_output session events"},
+}
+
+// Scope 判断开关时的租户和项目，字段为空表示不区分
+type Scope struct {
+	Tenant  string `json:"tenant,omitempty"`
+	Project string `json:"project,omitempty"`
+}
+
+// Override 运行时覆盖，Scope 越具体优先级越高：租户+项目 > 项目 > 租户 > 全局
+type Override struct {
+	Flag Flag `json:"flag"`
+	Scope
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// State 一个开关的默认值和所有覆盖
+type State struct {
+	Flag        Flag       `json:"flag"`
+	Description string     `json:"description"`
+	Default     bool       `json:"default"`
+	Overrides   []Override `json:"overrides"`
+}
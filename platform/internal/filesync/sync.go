@@ -0,0 +1,287 @@
+// Package filesync 以类似 rsync 的方式把宿主机目录增量同步到容器工作区：
+// 比较两端文件的 SHA-256 清单，只传输新增或内容变化的文件
+package filesync
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"platform/internal/sandbox"
+)
+
+// deleteBatch 每次 rm 调用删除的最大文件数，避免超出参数长度限制
+const deleteBatch = 500
+
+// Target 同步的目标容器
+type Target interface {
+	Exec(ctx context.Context, cmd []string, env []string, workDir string) (*sandbox.ExecResult, error)
+	UploadArchive(ctx context.Context, destPath string, tarStream io.Reader) error
+}
+
+// Options 同步参数
+type Options struct {
+	// Dest 容器内的目标目录（绝对路径）
+	Dest string
+	// Delete 删除容器中本地不存在的文件
+	Delete bool
+}
+
+// Result 同步结果
+type Result struct {
+	Uploaded  int   `json:"uploaded"`
+	Unchanged int   `json:"unchanged"`
+	Deleted   int   `json:"deleted"`
+	Bytes     int64 `json:"bytes"`
+}
+
+// File 本地清单中的一个文件
+type File struct {
+	Hash string
+	Size int64
+	Mode fs.FileMode
+	// Link 符号链接的目标，非空时 Hash 为空
+	Link string
+}
+
+// Tree 本地目录的清单，路径使用 / 分隔并相对于根目录
+type Tree struct {
+	Root  string
+	Files map[string]File
+	Dirs  []string
+}
+
+// Scan 遍历 root 并计算每个普通文件的 SHA-256
+func Scan(root string) (*Tree, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	tree := &Tree{Root: absRoot, Files: make(map[string]File)}
+
+	err = filepath.WalkDir(absRoot, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(absRoot, file)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			tree.Dirs = append(tree.Dirs, rel)
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(file)
+			if err != nil {
+				return fmt.Errorf("failed to read symlink: %w", err)
+			}
+			tree.Files[rel] = File{Link: link, Mode: info.Mode()}
+		case info.Mode().IsRegular():
+			hash, err := hashFile(file)
+			if err != nil {
+				return err
+			}
+			tree.Files[rel] = File{Hash: hash, Size: info.Size(), Mode: info.Mode()}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	return tree, nil
+}
+
+func hashFile(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// manifestScript 在容器内输出目标目录下所有普通文件的 sha256sum，目录不存在时先创建
+const manifestScript = `mkdir -p "$1" && cd "$1" && find . -type f -print0 | xargs -0 -r sha256sum`
+
+// ParseManifest 解析 sha256sum 的输出为 路径 → 哈希。
+// 文件名含换行或反斜杠时 sha256sum 会转义整行，这类文件视为远端不存在，总会重新上传
+func ParseManifest(r io.Reader) (map[string]string, error) {
+	manifest := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, `\`) {
+			continue
+		}
+		hash, name, ok := strings.Cut(line, "  ")
+		if !ok || len(hash) != sha256.Size*2 {
+			return nil, fmt.Errorf("invalid manifest line %q", line)
+		}
+		manifest[strings.TrimPrefix(name, "./")] = hash
+	}
+	return manifest, scanner.Err()
+}
+
+// Plan 需要上传和删除的文件
+type Plan struct {
+	Upload    []string
+	Delete    []string
+	Unchanged int
+}
+
+// Diff 比较本地清单与远端清单。符号链接不在远端清单中，总会上传
+func Diff(local *Tree, remote map[string]string, deleteExtra bool) Plan {
+	var plan Plan
+	for name, f := range local.Files {
+		if f.Link == "" && remote[name] == f.Hash {
+			plan.Unchanged++
+			continue
+		}
+		plan.Upload = append(plan.Upload, name)
+	}
+	if deleteExtra {
+		for name := range remote {
+			if _, ok := local.Files[name]; !ok {
+				plan.Delete = append(plan.Delete, name)
+			}
+		}
+	}
+	sort.Strings(plan.Upload)
+	sort.Strings(plan.Delete)
+	return plan
+}
+
+// Sync 将 root 增量同步到容器的 opts.Dest
+func Sync(ctx context.Context, target Target, root string, opts Options) (*Result, error) {
+	local, err := Scan(root)
+	if err != nil {
+		return nil, err
+	}
+
+	// 清单只供比对使用，不转发到 exec 输出
+	out, err := target.Exec(sandbox.WithExecOutput(ctx, nil), []string{"sh", "-c", manifestScript, "sh", opts.Dest}, nil, "/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote manifest: %w", err)
+	}
+	if out.ExitCode != 0 {
+		return nil, fmt.Errorf("failed to read remote manifest: exit code %d: %s", out.ExitCode, strings.TrimSpace(out.Stderr))
+	}
+	remote, err := ParseManifest(strings.NewReader(out.Stdout))
+	if err != nil {
+		return nil, err
+	}
+
+	plan := Diff(local, remote, opts.Delete)
+	result := &Result{Unchanged: plan.Unchanged}
+
+	if len(plan.Upload) > 0 {
+		for _, name := range plan.Upload {
+			result.Bytes += local.Files[name].Size
+		}
+		if err := target.UploadArchive(ctx, opts.Dest, WriteTar(local, plan.Upload)); err != nil {
+			return nil, fmt.Errorf("failed to upload changed files: %w", err)
+		}
+		result.Uploaded = len(plan.Upload)
+	}
+
+	for start := 0; start < len(plan.Delete); start += deleteBatch {
+		batch := plan.Delete[start:min(start+deleteBatch, len(plan.Delete))]
+		cmd := append([]string{"rm", "-f", "--"}, batch...)
+		out, err := target.Exec(ctx, cmd, nil, opts.Dest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete files: %w", err)
+		}
+		if out.ExitCode != 0 {
+			return nil, fmt.Errorf("failed to delete files: %s", strings.TrimSpace(out.Stderr))
+		}
+		result.Deleted += len(batch)
+	}
+	return result, nil
+}
+
+// WriteTar 以流的方式打包 tree 中的所有目录和 names 指定的文件，不会把整个项目读入内存。
+// 目录条目很小，总是包含在内，以保留空目录
+func WriteTar(tree *Tree, names []string) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := writeEntries(tw, tree, names)
+		if cerr := tw.Close(); err == nil {
+			err = cerr
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+func writeEntries(tw *tar.Writer, tree *Tree, names []string) error {
+	for _, dir := range tree.Dirs {
+		info, err := os.Lstat(filepath.Join(tree.Root, filepath.FromSlash(dir)))
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = dir + "/"
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names {
+		file := filepath.Join(tree.Root, filepath.FromSlash(name))
+		info, err := os.Lstat(file)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, tree.Files[name].Link)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		if err := copyFile(tw, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(w io.Writer, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
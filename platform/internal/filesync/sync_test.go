@@ -0,0 +1,153 @@
+package filesync
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"platform/internal/sandbox"
+)
+
+// fakeTarget 以内存中的 路径 → 内容 模拟容器工作区
+type fakeTarget struct {
+	files map[string]string
+}
+
+func (f *fakeTarget) Exec(_ context.Context, cmd []string, _ []string, _ string) (*sandbox.ExecResult, error) {
+	var out strings.Builder
+	switch cmd[0] {
+	case "sh":
+		for name, content := range f.files {
+			sum := sha256.Sum256([]byte(content))
+			fmt.Fprintf(&out, "%s  ./%s\n", hex.EncodeToString(sum[:]), name)
+		}
+	case "rm":
+		for _, name := range cmd[3:] {
+			delete(f.files, name)
+		}
+	}
+	return &sandbox.ExecResult{Stdout: out.String()}, nil
+}
+
+func (f *fakeTarget) UploadArchive(_ context.Context, _ string, r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		f.files[h.Name] = string(data)
+	}
+}
+
+func writeFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	p := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncTransfersOnlyChangedFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a.txt", "same")
+	writeFile(t, root, "src/b.go", "new content")
+	writeFile(t, root, "src/c.go", "added")
+
+	target := &fakeTarget{files: map[string]string{
+		"a.txt":    "same",
+		"src/b.go": "old content",
+		"stale":    "gone locally",
+	}}
+
+	result, err := Sync(context.Background(), target, root, Options{Dest: "/app/workspace"})
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if result.Uploaded != 2 || result.Unchanged != 1 || result.Deleted != 0 {
+		t.Fatalf("result = %+v", result)
+	}
+	if target.files["src/b.go"] != "new content" || target.files["src/c.go"] != "added" {
+		t.Fatalf("files = %v", target.files)
+	}
+	if _, ok := target.files["stale"]; !ok {
+		t.Fatal("stale file deleted without Delete option")
+	}
+
+	result, err = Sync(context.Background(), target, root, Options{Dest: "/app/workspace", Delete: true})
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if result.Uploaded != 0 || result.Unchanged != 3 || result.Deleted != 1 {
+		t.Fatalf("second result = %+v", result)
+	}
+	if _, ok := target.files["stale"]; ok {
+		t.Fatal("stale file not deleted")
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	hash := strings.Repeat("a", 64)
+	input := hash + "  ./dir/file name.txt\n" + `\` + hash + `  ./odd\nname` + "\n"
+	manifest, err := ParseManifest(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseManifest: %v", err)
+	}
+	if len(manifest) != 1 || manifest["dir/file name.txt"] != hash {
+		t.Fatalf("manifest = %v", manifest)
+	}
+
+	if _, err := ParseManifest(strings.NewReader("garbage\n")); err == nil {
+		t.Fatal("expected error for malformed line")
+	}
+}
+
+func TestWriteTarIncludesDirectories(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "keep/x.txt", "x")
+	if err := os.Mkdir(filepath.Join(root, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := Scan(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	tr := tar.NewReader(WriteTar(tree, nil))
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, h.Name)
+	}
+	slices.Sort(names)
+	if !slices.Equal(names, []string{"empty/", "keep/"}) {
+		t.Fatalf("entries = %v", names)
+	}
+}
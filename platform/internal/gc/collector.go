@@ -0,0 +1,351 @@
+package gc
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"platform/internal/diskusage"
+	"platform/internal/monitor"
+	"platform/internal/sandbox"
+	"platform/internal/session"
+)
+
+// Config 宿主机目录 GC 配置
+type Config struct {
+	Interval  time.Duration // GC 循环间隔
+	Retention time.Duration // 会话进入终态后目录的保留时长
+	// SizeBudget 所有受管目录的总大小上限（字节），0 表示不限制。
+	// 超出后按终止时间从旧到新删除未过保留期的目录，直到回到预算内。
+	SizeBudget int64
+	DryRun     bool // 只记录将要删除的目录，不实际删除
+
+	// ProjectRoots 下的子目录以 projectID 命名（冷容器挂载目录、Worker 项目目录）
+	ProjectRoots []string
+	// SessionRoots 下的子目录以 sessionID 命名（exec 日志、compose stack 目录）
+	SessionRoots []string
+}
+
+// EntryKind 受管目录的类别
+type EntryKind string
+
+const (
+	KindProject EntryKind = "project"
+	KindSession EntryKind = "session"
+)
+
+// Entry 一个 GC 候选目录
+type Entry struct {
+	Path      string    `json:"path"`
+	Kind      EntryKind `json:"kind"`
+	Owner     string    `json:"owner"` // projectID 或 sessionID
+	Size      int64     `json:"size"`
+	RefTime   time.Time `json:"ref_time"`  // 会话终止时间，未知时为目录修改时间
+	Protected bool      `json:"protected"` // 仍有活跃会话在使用
+}
+
+// Report 单次 GC 的结果
+type Report struct {
+	Scanned    int     `json:"scanned"`
+	TotalBytes int64   `json:"total_bytes"`
+	FreedBytes int64   `json:"freed_bytes"`
+	Removed    []Entry `json:"removed"`
+	DryRun     bool    `json:"dry_run"`
+}
+
+// Collector 定期清理已终止会话遗留在宿主机上的项目目录、compose 目录和 exec 日志目录
+type Collector struct {
+	repo   session.SessionRepository
+	config Config
+	logger *slog.Logger
+	stopCh chan struct{}
+
+	// Containers 非 nil 时，仍有运行中平台容器的 session / 项目目录也受保护（如预热池容器的 exec 日志）
+	Containers sandbox.ContainerLister
+}
+
+func NewCollector(repo session.SessionRepository, config Config, logger *slog.Logger) *Collector {
+	config.ProjectRoots = dedupRoots(config.ProjectRoots)
+	config.SessionRoots = dedupRoots(config.SessionRoots)
+
+	return &Collector{
+		repo:   repo,
+		config: config,
+		logger: logger.With("component", "host-gc"),
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Start 启动 GC 循环（阻塞，应在 goroutine 中调用）
+func (c *Collector) Start() {
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+
+	c.logger.Info("Host GC started",
+		"interval", c.config.Interval,
+		"retention", c.config.Retention,
+		"size_budget", c.config.SizeBudget,
+		"dry_run", c.config.DryRun,
+	)
+
+	for {
+		select {
+		case <-c.stopCh:
+			c.logger.Info("Host GC stopped")
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			if _, err := c.RunOnce(ctx); err != nil {
+				c.logger.Error("Host GC run failed", "error", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// Stop 停止 GC 循环
+func (c *Collector) Stop() {
+	select {
+	case <-c.stopCh:
+	default:
+		close(c.stopCh)
+	}
+}
+
+// RunOnce 扫描所有受管目录并执行一次回收
+func (c *Collector) RunOnce(ctx context.Context) (*Report, error) {
+	entries, err := c.scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Scanned: len(entries), DryRun: c.config.DryRun}
+	for _, e := range entries {
+		report.TotalBytes += e.Size
+	}
+
+	// 先按终止时间从旧到新排序，保留期和预算两轮都按这个顺序回收
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].RefTime.Before(entries[j].RefTime)
+	})
+
+	cutoff := time.Now().Add(-c.config.Retention)
+	remaining := report.TotalBytes
+	kept := make([]Entry, 0, len(entries))
+
+	for _, e := range entries {
+		if !e.Protected && e.RefTime.Before(cutoff) {
+			if c.remove(e) {
+				report.Removed = append(report.Removed, e)
+				report.FreedBytes += e.Size
+				remaining -= e.Size
+			}
+			continue
+		}
+		kept = append(kept, e)
+	}
+
+	if c.config.SizeBudget > 0 && remaining > c.config.SizeBudget {
+		c.logger.Warn("Host directories exceed size budget",
+			"total_bytes", remaining,
+			"size_budget", c.config.SizeBudget,
+		)
+		for _, e := range kept {
+			if remaining <= c.config.SizeBudget {
+				break
+			}
+			if e.Protected {
+				continue
+			}
+			if c.remove(e) {
+				report.Removed = append(report.Removed, e)
+				report.FreedBytes += e.Size
+				remaining -= e.Size
+			}
+		}
+	}
+
+	if len(report.Removed) > 0 {
+		c.logger.Info("Host GC completed",
+			"removed", len(report.Removed),
+			"freed_bytes", report.FreedBytes,
+			"dry_run", c.config.DryRun,
+		)
+	}
+
+	return report, nil
+}
+
+func (c *Collector) remove(e Entry) bool {
+	if c.config.DryRun {
+		c.logger.Info("Would remove host directory (dry run)",
+			"path", e.Path,
+			"kind", e.Kind,
+			"owner", e.Owner,
+			"size", e.Size,
+			"ref_time", e.RefTime,
+		)
+		return true
+	}
+
+	if err := os.RemoveAll(e.Path); err != nil {
+		c.logger.Error("Failed to remove host directory", "path", e.Path, "error", err)
+		return false
+	}
+
+	c.logger.Info("Removed host directory",
+		"path", e.Path,
+		"kind", e.Kind,
+		"owner", e.Owner,
+		"size", e.Size,
+	)
+	monitor.GCRemovedDirs.WithLabelValues(string(e.Kind)).Inc()
+	monitor.GCFreedBytes.Add(float64(e.Size))
+	return true
+}
+
+func (c *Collector) scan(ctx context.Context) ([]Entry, error) {
+	active, err := c.repo.ListByStatus(ctx, session.LiveStatuses())
+	if err != nil {
+		return nil, err
+	}
+
+	activeSessions := make(map[string]bool, len(active))
+	activeProjects := make(map[string]bool, len(active))
+	for _, sess := range active {
+		activeSessions[sess.ID] = true
+		activeProjects[sess.ProjectID] = true
+	}
+	if c.Containers != nil {
+		running, err := sandbox.ListManaged(ctx, c.Containers, nil, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, ct := range running {
+			if id := ct.Labels[sandbox.LabelSessionID]; id != "" {
+				activeSessions[id] = true
+			}
+			if id := ct.Labels[sandbox.LabelProjectID]; id != "" {
+				activeProjects[id] = true
+			}
+		}
+	}
+
+	var entries []Entry
+
+	for _, root := range c.config.SessionRoots {
+		for _, dir := range listSubdirs(root, c.logger) {
+			e := Entry{
+				Path:    filepath.Join(root, dir.Name()),
+				Kind:    KindSession,
+				Owner:   dir.Name(),
+				RefTime: modTime(dir),
+			}
+			if activeSessions[e.Owner] {
+				e.Protected = true
+			} else if sess, err := c.repo.GetByID(ctx, e.Owner); err == nil {
+				if !sess.Status.IsTerminal() {
+					e.Protected = true
+				} else if !sess.TerminatedAt.IsZero() {
+					e.RefTime = sess.TerminatedAt
+				}
+			} else if !errors.Is(err, session.ErrNotFound) {
+				// 查询失败时无法判断 session 是否仍在使用，本轮不回收
+				c.logger.Warn("Failed to look up session for directory", "path", e.Path, "error", err)
+				e.Protected = true
+			}
+			// 不存在的 session 的目录（如 warmup-* 日志）视为孤儿，以修改时间为准
+			e.Size, _ = diskusage.DirSize(e.Path)
+			entries = append(entries, e)
+		}
+	}
+
+	for _, root := range c.config.ProjectRoots {
+		for _, dir := range listSubdirs(root, c.logger) {
+			e := Entry{
+				Path:      filepath.Join(root, dir.Name()),
+				Kind:      KindProject,
+				Owner:     dir.Name(),
+				RefTime:   modTime(dir),
+				Protected: activeProjects[dir.Name()],
+			}
+			if !e.Protected {
+				last, err := c.lastTerminated(ctx, e.Owner)
+				if err != nil {
+					c.logger.Warn("Failed to list project sessions for directory", "path", e.Path, "error", err)
+					e.Protected = true
+				} else if last.After(e.RefTime) {
+					e.RefTime = last
+				}
+			}
+			e.Size, _ = diskusage.DirSize(e.Path)
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, nil
+}
+
+// lastTerminated 返回项目最近一次会话终止的时间
+func (c *Collector) lastTerminated(ctx context.Context, projectID string) (time.Time, error) {
+	sessions, err := c.repo.ListByProject(ctx, projectID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var last time.Time
+	for _, sess := range sessions {
+		if sess.TerminatedAt.After(last) {
+			last = sess.TerminatedAt
+		}
+	}
+	return last, nil
+}
+
+func listSubdirs(root string, logger *slog.Logger) []fs.DirEntry {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("Failed to read GC root", "root", root, "error", err)
+		}
+		return nil
+	}
+
+	dirs := make([]fs.DirEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs = append(dirs, e)
+		}
+	}
+	return dirs
+}
+
+func modTime(e fs.DirEntry) time.Time {
+	info, err := e.Info()
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func dedupRoots(roots []string) []string {
+	seen := make(map[string]bool, len(roots))
+	out := make([]string, 0, len(roots))
+	for _, r := range roots {
+		if r == "" {
+			continue
+		}
+		clean := filepath.Clean(r)
+		if seen[clean] {
+			continue
+		}
+		seen[clean] = true
+		out = append(out, clean)
+	}
+	return out
+}
@@ -0,0 +1,192 @@
+package gc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"platform/internal/session"
+)
+
+type fakeRepo struct {
+	session.SessionRepository
+	sessions map[string]*session.Session
+	getErr   error // 非 nil 时 GetByID 模拟数据库故障
+}
+
+func (r *fakeRepo) GetByID(ctx context.Context, id string) (*session.Session, error) {
+	if r.getErr != nil {
+		return nil, r.getErr
+	}
+	if s, ok := r.sessions[id]; ok {
+		return s, nil
+	}
+	return nil, session.ErrNotFound
+}
+
+func (r *fakeRepo) ListByStatus(ctx context.Context, statuses []session.SessionStatus) ([]*session.Session, error) {
+	var out []*session.Session
+	for _, s := range r.sessions {
+		for _, st := range statuses {
+			if s.Status == st {
+				out = append(out, s)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeRepo) ListByProject(ctx context.Context, projectID string) ([]*session.Session, error) {
+	var out []*session.Session
+	for _, s := range r.sessions {
+		if s.ProjectID == projectID {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func writeDir(t *testing.T, path string, size int) {
+	t.Helper()
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "data"), make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectorRetention(t *testing.T) {
+	root := t.TempDir()
+	logs := filepath.Join(root, "logs")
+	projects := filepath.Join(root, "projects")
+
+	old := time.Now().Add(-48 * time.Hour)
+	repo := &fakeRepo{sessions: map[string]*session.Session{
+		"s-old":   {ID: "s-old", ProjectID: "p-old", Status: session.StatusTerminated, TerminatedAt: old},
+		"s-stale": {ID: "s-stale", ProjectID: "p-old", Status: session.StatusTerminated, TerminatedAt: old},
+		"s-live":  {ID: "s-live", ProjectID: "p-live", Status: session.StatusRunning},
+		// 挂起的 session 仍会恢复，工作区目录不能回收
+		"s-suspended": {ID: "s-suspended", ProjectID: "p-suspended", Status: session.StatusSuspended},
+	}}
+
+	writeDir(t, filepath.Join(logs, "s-old"), 10)
+	writeDir(t, filepath.Join(logs, "s-live"), 10)
+	writeDir(t, filepath.Join(projects, "p-old"), 10)
+	writeDir(t, filepath.Join(projects, "p-live"), 10)
+	writeDir(t, filepath.Join(projects, "p-suspended"), 10)
+	// 项目目录取修改时间和会话终止时间中较新的一个
+	for _, p := range []string{"p-old", "p-suspended"} {
+		if err := os.Chtimes(filepath.Join(projects, p), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := NewCollector(repo, Config{
+		Retention:    24 * time.Hour,
+		ProjectRoots: []string{projects},
+		SessionRoots: []string{logs},
+	}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+
+	report, err := c.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce failed: %v", err)
+	}
+
+	if len(report.Removed) != 2 {
+		t.Fatalf("Expected 2 removed dirs, got %d: %+v", len(report.Removed), report.Removed)
+	}
+	for _, p := range []string{filepath.Join(logs, "s-old"), filepath.Join(projects, "p-old")} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", p)
+		}
+	}
+	for _, p := range []string{filepath.Join(logs, "s-live"), filepath.Join(projects, "p-live"), filepath.Join(projects, "p-suspended")} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("Expected %s to be kept: %v", p, err)
+		}
+	}
+}
+
+func TestCollectorSizeBudgetAndDryRun(t *testing.T) {
+	root := t.TempDir()
+	logs := filepath.Join(root, "logs")
+
+	now := time.Now()
+	repo := &fakeRepo{sessions: map[string]*session.Session{
+		"s-1": {ID: "s-1", Status: session.StatusTerminated, TerminatedAt: now.Add(-3 * time.Minute)},
+		"s-2": {ID: "s-2", Status: session.StatusTerminated, TerminatedAt: now.Add(-2 * time.Minute)},
+		"s-3": {ID: "s-3", Status: session.StatusError, TerminatedAt: now.Add(-1 * time.Minute)},
+	}}
+	for id := range repo.sessions {
+		writeDir(t, filepath.Join(logs, id), 100)
+	}
+
+	cfg := Config{
+		Retention:    24 * time.Hour,
+		SizeBudget:   150,
+		DryRun:       true,
+		SessionRoots: []string{logs},
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	report, err := NewCollector(repo, cfg, logger).RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce failed: %v", err)
+	}
+	if len(report.Removed) != 2 || report.Removed[0].Owner != "s-1" || report.Removed[1].Owner != "s-2" {
+		t.Fatalf("Expected oldest two dirs selected, got %+v", report.Removed)
+	}
+	if _, err := os.Stat(filepath.Join(logs, "s-1")); err != nil {
+		t.Errorf("Dry run should not delete directories: %v", err)
+	}
+
+	cfg.DryRun = false
+	if _, err := NewCollector(repo, cfg, logger).RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(logs, "s-3")); err != nil {
+		t.Errorf("Newest dir should be kept within budget: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(logs, "s-1")); !os.IsNotExist(err) {
+		t.Errorf("Oldest dir should be removed")
+	}
+}
+
+func TestCollectorKeepsDirsWhenLookupFails(t *testing.T) {
+	logs := filepath.Join(t.TempDir(), "logs")
+	dir := filepath.Join(logs, "s-unknown")
+	writeDir(t, dir, 10)
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(dir, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &fakeRepo{getErr: errors.New("connection refused")}
+	c := NewCollector(repo, Config{
+		Retention:    24 * time.Hour,
+		SessionRoots: []string{logs},
+	}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+
+	report, err := c.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce failed: %v", err)
+	}
+	if len(report.Removed) != 0 {
+		t.Fatalf("Expected no dirs removed while lookups fail, got %+v", report.Removed)
+	}
+
+	// 确认 session 不存在后按孤儿目录回收
+	repo.getErr = nil
+	report, err = c.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce failed: %v", err)
+	}
+	if len(report.Removed) != 1 || report.Removed[0].Owner != "s-unknown" {
+		t.Fatalf("Expected orphan dir removed, got %+v", report.Removed)
+	}
+}
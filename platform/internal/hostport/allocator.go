@@ -0,0 +1,188 @@
+package hostport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrPortTaken 端口已被其他分配记录占用（可能来自另一个平台实例）
+	ErrPortTaken = errors.New("host port already allocated")
+	// ErrExhausted 配置范围内没有可用端口
+	ErrExhausted = errors.New("no free host port in range")
+)
+
+// Config 宿主机端口分配范围（闭区间）
+type Config struct {
+	Min int
+	Max int
+}
+
+// Allocator 从固定范围内为需要从 Docker 外部访问的服务分配宿主机端口。
+// 分配记录持久化在数据库中，port 唯一约束保证多实例之间不会冲突；
+// 同一 (session, owner) 重复分配返回已有端口，便于 compose 更新时保持映射稳定。
+type Allocator struct {
+	store  Store
+	config Config
+	logger *slog.Logger
+	// probe 检查端口在宿主机上是否空闲，跳过被非平台进程占用的端口
+	probe func(port int) bool
+
+	mu   sync.Mutex
+	next int // 下一次扫描的起点，避免刚释放的端口被立即复用
+}
+
+func NewAllocator(store Store, config Config, logger *slog.Logger) *Allocator {
+	if config.Min <= 0 {
+		config.Min = 20000
+	}
+	if config.Max < config.Min {
+		config.Max = config.Min + 999
+	}
+	return &Allocator{
+		store:  store,
+		config: config,
+		logger: logger.With("component", "hostport"),
+		probe:  portFree,
+		next:   config.Min,
+	}
+}
+
+// Owner 构造 companion / compose 服务端口的 owner 标识
+func Owner(parts ...string) string {
+	return strings.Join(parts, ":")
+}
+
+// Allocate 为 owner 的 containerPort 分配宿主机端口，已分配时直接返回
+func (a *Allocator) Allocate(ctx context.Context, sessionID, owner string, containerPort int) (*Allocation, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	existing, err := a.store.ListBySession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list host ports: %w", err)
+	}
+	for _, alloc := range existing {
+		if alloc.Owner == owner && alloc.ContainerPort == containerPort {
+			return alloc, nil
+		}
+	}
+
+	all, err := a.store.ListAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list host ports: %w", err)
+	}
+	used := make(map[int]bool, len(all))
+	for _, alloc := range all {
+		used[alloc.Port] = true
+	}
+
+	size := a.config.Max - a.config.Min + 1
+	for i := 0; i < size; i++ {
+		port := a.config.Min + (a.next-a.config.Min+i)%size
+		if used[port] || !a.probe(port) {
+			continue
+		}
+
+		alloc := &Allocation{
+			Port:          port,
+			ContainerPort: containerPort,
+			SessionID:     sessionID,
+			Owner:         owner,
+			CreatedAt:     time.Now(),
+		}
+		if err := a.store.Insert(ctx, alloc); err != nil {
+			if errors.Is(err, ErrPortTaken) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to record host port: %w", err)
+		}
+
+		a.next = port + 1
+		if a.next > a.config.Max {
+			a.next = a.config.Min
+		}
+		a.logger.Info("Allocated host port",
+			"session_id", sessionID,
+			"owner", owner,
+			"host_port", port,
+			"container_port", containerPort,
+		)
+		return alloc, nil
+	}
+	return nil, fmt.Errorf("%w [%d-%d]", ErrExhausted, a.config.Min, a.config.Max)
+}
+
+// List 返回 session 的全部端口映射
+func (a *Allocator) List(ctx context.Context, sessionID string) ([]*Allocation, error) {
+	return a.store.ListBySession(ctx, sessionID)
+}
+
+// Release 释放 owner 以 prefix 开头的端口，keep 中的 owner 保留；返回释放数量
+func (a *Allocator) Release(ctx context.Context, sessionID, prefix string, keep ...string) (int, error) {
+	allocs, err := a.store.ListBySession(ctx, sessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	kept := make(map[string]bool, len(keep))
+	for _, k := range keep {
+		kept[k] = true
+	}
+
+	released := 0
+	for _, alloc := range allocs {
+		if !strings.HasPrefix(alloc.Owner, prefix) || kept[alloc.Owner] {
+			continue
+		}
+		if err := a.store.Delete(ctx, alloc.Port); err != nil {
+			return released, fmt.Errorf("failed to release host port %d: %w", alloc.Port, err)
+		}
+		released++
+	}
+	if released > 0 {
+		a.logger.Info("Released host ports", "session_id", sessionID, "owner", prefix, "count", released)
+	}
+	return released, nil
+}
+
+// ReleaseSession 释放 session 的全部端口
+func (a *Allocator) ReleaseSession(ctx context.Context, sessionID string) error {
+	_, err := a.Release(ctx, sessionID, "")
+	return err
+}
+
+// Prune 释放不再活跃的 session 遗留的端口（平台异常退出后启动时调用）
+func (a *Allocator) Prune(ctx context.Context, active func(sessionID string) bool) (int, error) {
+	allocs, err := a.store.ListAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+	pruned := 0
+	for _, alloc := range allocs {
+		if active(alloc.SessionID) {
+			continue
+		}
+		if err := a.store.Delete(ctx, alloc.Port); err != nil {
+			return pruned, err
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+func portFree(port int) bool {
+	ln, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		return false
+	}
+	ln.Close()
+	return true
+}
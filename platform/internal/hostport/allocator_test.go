@@ -0,0 +1,122 @@
+package hostport
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sort"
+	"testing"
+)
+
+type memStore struct {
+	allocs map[int]*Allocation
+}
+
+func (s *memStore) Insert(ctx context.Context, a *Allocation) error {
+	if _, ok := s.allocs[a.Port]; ok {
+		return ErrPortTaken
+	}
+	s.allocs[a.Port] = a
+	return nil
+}
+
+func (s *memStore) ListBySession(ctx context.Context, sessionID string) ([]*Allocation, error) {
+	var out []*Allocation
+	for _, a := range s.allocs {
+		if a.SessionID == sessionID {
+			out = append(out, a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Port < out[j].Port })
+	return out, nil
+}
+
+func (s *memStore) ListAll(ctx context.Context) ([]*Allocation, error) {
+	var out []*Allocation
+	for _, a := range s.allocs {
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+func (s *memStore) Delete(ctx context.Context, port int) error {
+	delete(s.allocs, port)
+	return nil
+}
+
+func newTestAllocator(min, max int, busy ...int) (*Allocator, *memStore) {
+	store := &memStore{allocs: make(map[int]*Allocation)}
+	a := NewAllocator(store, Config{Min: min, Max: max}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	blocked := make(map[int]bool)
+	for _, p := range busy {
+		blocked[p] = true
+	}
+	a.probe = func(port int) bool { return !blocked[port] }
+	return a, store
+}
+
+func TestAllocate(t *testing.T) {
+	ctx := context.Background()
+	a, _ := newTestAllocator(30000, 30003, 30001) // 30001 被宿主机其他进程占用
+
+	first, err := a.Allocate(ctx, "s1", "companion:a:", 5432)
+	if err != nil {
+		t.Fatalf("Allocate failed: %v", err)
+	}
+	if first.Port != 30000 {
+		t.Errorf("Expected port 30000, got %d", first.Port)
+	}
+
+	// 同一 owner 和容器端口重复分配返回已有映射
+	again, err := a.Allocate(ctx, "s1", "companion:a:", 5432)
+	if err != nil || again.Port != first.Port {
+		t.Errorf("Expected idempotent allocation, got %+v, %v", again, err)
+	}
+
+	second, err := a.Allocate(ctx, "s2", "compose:default:web:80", 80)
+	if err != nil {
+		t.Fatalf("Allocate failed: %v", err)
+	}
+	if second.Port != 30002 {
+		t.Errorf("Expected busy port to be skipped, got %d", second.Port)
+	}
+
+	if _, err := a.Allocate(ctx, "s2", "compose:default:api:8080", 8080); err != nil {
+		t.Fatalf("Allocate failed: %v", err)
+	}
+	if _, err := a.Allocate(ctx, "s3", "companion:b:", 6379); !errors.Is(err, ErrExhausted) {
+		t.Errorf("Expected ErrExhausted, got %v", err)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	ctx := context.Background()
+	a, store := newTestAllocator(30000, 30009)
+
+	for _, owner := range []string{"compose:default:web:80", "compose:default:api:8080", "compose:db:pg:5432", "companion:x:"} {
+		if _, err := a.Allocate(ctx, "s1", owner, 1); err != nil {
+			t.Fatalf("Allocate failed: %v", err)
+		}
+	}
+	if _, err := a.Allocate(ctx, "s2", "companion:y:", 1); err != nil {
+		t.Fatalf("Allocate failed: %v", err)
+	}
+
+	n, err := a.Release(ctx, "s1", "compose:default:", "compose:default:web:80")
+	if err != nil || n != 1 {
+		t.Fatalf("Expected 1 port released, got %d, %v", n, err)
+	}
+
+	if err := a.ReleaseSession(ctx, "s1"); err != nil {
+		t.Fatalf("ReleaseSession failed: %v", err)
+	}
+	if len(store.allocs) != 1 {
+		t.Errorf("Expected only s2 allocation to remain, got %d", len(store.allocs))
+	}
+
+	pruned, err := a.Prune(ctx, func(sessionID string) bool { return false })
+	if err != nil || pruned != 1 || len(store.allocs) != 0 {
+		t.Errorf("Expected inactive allocation pruned, got %d, %v", pruned, err)
+	}
+}
@@ -0,0 +1,11 @@
+package hostport
+
+import "context"
+
+type Store interface {
+	// Insert 写入分配记录，端口已被占用时返回 ErrPortTaken
+	Insert(ctx context.Context, a *Allocation) error
+	ListBySession(ctx context.Context, sessionID string) ([]*Allocation, error)
+	ListAll(ctx context.Context) ([]*Allocation, error)
+	Delete(ctx context.Context, port int) error
+}
@@ -0,0 +1,80 @@
+package hostport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+var _ Store = (*PGStore)(nil)
+
+type PGStore struct {
+	db *pg.DB
+}
+
+func NewPGStore(db *pg.DB) *PGStore {
+	return &PGStore{db: db}
+}
+
+// Migrate 创建 host_port_allocations 表
+func Migrate(db *pg.DB) error {
+	if err := db.Model(&AllocationModel{}).CreateTable(&orm.CreateTableOptions{
+		IfNotExists: true,
+	}); err != nil {
+		return fmt.Errorf("create host_port_allocations table: %w", err)
+	}
+	return nil
+}
+
+func (s *PGStore) Insert(ctx context.Context, a *Allocation) error {
+	model := &AllocationModel{
+		Port:          a.Port,
+		ContainerPort: a.ContainerPort,
+		SessionID:     a.SessionID,
+		Owner:         a.Owner,
+		CreatedAt:     a.CreatedAt,
+	}
+	if _, err := s.db.ModelContext(ctx, model).Insert(); err != nil {
+		var pgErr pg.Error
+		if errors.As(err, &pgErr) && pgErr.IntegrityViolation() {
+			return ErrPortTaken
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *PGStore) ListBySession(ctx context.Context, sessionID string) ([]*Allocation, error) {
+	var models []AllocationModel
+	if err := s.db.ModelContext(ctx, &models).
+		Where("session_id = ?", sessionID).
+		Order("port ASC").
+		Select(); err != nil {
+		return nil, err
+	}
+	return toAllocations(models), nil
+}
+
+func (s *PGStore) ListAll(ctx context.Context) ([]*Allocation, error) {
+	var models []AllocationModel
+	if err := s.db.ModelContext(ctx, &models).Order("port ASC").Select(); err != nil {
+		return nil, err
+	}
+	return toAllocations(models), nil
+}
+
+func (s *PGStore) Delete(ctx context.Context, port int) error {
+	_, err := s.db.ModelContext(ctx, &AllocationModel{}).Where("port = ?", port).Delete()
+	return err
+}
+
+func toAllocations(models []AllocationModel) []*Allocation {
+	out := make([]*Allocation, 0, len(models))
+	for i := range models {
+		out = append(out, models[i].toAllocation())
+	}
+	return out
+}
@@ -0,0 +1,33 @@
+package hostport
+
+import "time"
+
+// Allocation 一个已分配给服务的宿主机端口
+type Allocation struct {
+	Port          int       `json:"host_port"`
+	ContainerPort int       `json:"container_port"`
+	SessionID     string    `json:"session_id"`
+	Owner         string    `json:"owner"` // 如 companion:<service_id>:<port>、compose:<stack>:<service>:<port>
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AllocationModel 对应 host_port_allocations 表，port 主键保证多实例间不会重复分配
+type AllocationModel struct {
+	tableName struct{} `pg:"host_port_allocations"`
+
+	Port          int       `pg:"port,pk"`
+	ContainerPort int       `pg:"container_port,notnull"`
+	SessionID     string    `pg:"session_id,notnull"`
+	Owner         string    `pg:"owner,notnull"`
+	CreatedAt     time.Time `pg:"created_at,notnull"`
+}
+
+func (m *AllocationModel) toAllocation() *Allocation {
+	return &Allocation{
+		Port:          m.Port,
+		ContainerPort: m.ContainerPort,
+		SessionID:     m.SessionID,
+		Owner:         m.Owner,
+		CreatedAt:     m.CreatedAt,
+	}
+}
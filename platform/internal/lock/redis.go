@@ -0,0 +1,136 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNotAcquired 等待超时仍未拿到锁
+var ErrNotAcquired = errors.New("lock already held")
+
+// 只有持有者（value 匹配）才能续期或释放，避免误删他人重新获取的锁
+var (
+	refreshScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// Locker 基于 Redis SET NX 的分布式互斥锁。
+// 持有期间后台按 TTL/3 续期，进程崩溃后锁在 TTL 到期时自动释放。
+type Locker struct {
+	redis  redis.Cmdable
+	ttl    time.Duration
+	retry  time.Duration
+	logger *slog.Logger
+}
+
+func NewRedisLocker(redis redis.Cmdable, ttl time.Duration, logger *slog.Logger) *Locker {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	return &Locker{
+		redis:  redis,
+		ttl:    ttl,
+		retry:  100 * time.Millisecond,
+		logger: logger.With("component", "lock"),
+	}
+}
+
+// Lock 已获取的锁，使用完毕后必须调用 Release
+type Lock struct {
+	locker *Locker
+	key    string
+	value  string
+	stopCh chan struct{}
+	once   sync.Once
+}
+
+// Acquire 获取 key 上的锁，最多等待 wait；owner 记录持有者（如操作名），便于冲突时提示。
+// 超时返回包装了 ErrNotAcquired 的错误，其中包含当前持有者。
+func (l *Locker) Acquire(ctx context.Context, key, owner string, wait time.Duration) (*Lock, error) {
+	value := owner + ":" + uuid.New().String()
+	deadline := time.Now().Add(wait)
+
+	for {
+		ok, err := l.redis.SetNX(ctx, key, value, l.ttl).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			lk := &Lock{locker: l, key: key, value: value, stopCh: make(chan struct{})}
+			go lk.keepAlive()
+			return lk, nil
+		}
+
+		if !time.Now().Before(deadline) {
+			return nil, fmt.Errorf("%w by %s", ErrNotAcquired, l.holder(ctx, key))
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(l.retry):
+		}
+	}
+}
+
+// holder 返回当前持有者的 owner 部分
+func (l *Locker) holder(ctx context.Context, key string) string {
+	v, err := l.redis.Get(ctx, key).Result()
+	if err != nil {
+		return "unknown"
+	}
+	if i := strings.LastIndex(v, ":"); i > 0 {
+		return v[:i]
+	}
+	return v
+}
+
+// Release 释放锁，重复调用是安全的
+func (lk *Lock) Release(ctx context.Context) error {
+	var err error
+	lk.once.Do(func() {
+		close(lk.stopCh)
+		err = releaseScript.Run(ctx, lk.locker.redis, []string{lk.key}, lk.value).Err()
+	})
+	return err
+}
+
+func (lk *Lock) keepAlive() {
+	ticker := time.NewTicker(lk.locker.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lk.stopCh:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), lk.locker.ttl/3)
+			n, err := refreshScript.Run(ctx, lk.locker.redis, []string{lk.key}, lk.value, lk.locker.ttl.Milliseconds()).Int()
+			cancel()
+			if err != nil {
+				lk.locker.logger.Warn("Failed to refresh lock", "key", lk.key, "error", err)
+				continue
+			}
+			if n == 0 {
+				lk.locker.logger.Warn("Lock lost before release", "key", lk.key)
+				return
+			}
+		}
+	}
+}
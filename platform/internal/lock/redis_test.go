@@ -0,0 +1,64 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const redisAddr = "localhost:6383"
+
+func TestRedisLocker(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	rdb := redis.NewClient(&redis.Options{Addr: redisAddr})
+	defer rdb.Close()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		t.Fatalf("Failed to connect to Redis at %s: %v. Make sure docker-compose.test.yml is running.", redisAddr, err)
+	}
+
+	locker := NewRedisLocker(rdb, 300*time.Millisecond, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	key := "test:lock:" + uuid.New().String()
+
+	first, err := locker.Acquire(ctx, key, "restart", 0)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	// 超过 TTL 仍由续期保持
+	time.Sleep(500 * time.Millisecond)
+	_, err = locker.Acquire(ctx, key, "terminate", 100*time.Millisecond)
+	if !errors.Is(err, ErrNotAcquired) || !strings.Contains(err.Error(), "restart") {
+		t.Fatalf("Expected ErrNotAcquired held by restart, got %v", err)
+	}
+
+	// 等待者在持有者释放后获得锁
+	done := make(chan error, 1)
+	go func() {
+		lk, err := locker.Acquire(ctx, key, "terminate", 2*time.Second)
+		if err == nil {
+			err = lk.Release(ctx)
+		}
+		done <- err
+	}()
+	time.Sleep(200 * time.Millisecond)
+	if err := first.Release(ctx); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Waiter failed to acquire lock: %v", err)
+	}
+	if err := first.Release(ctx); err != nil {
+		t.Errorf("Second release should be a no-op: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+// Handler 包装底层 Handler，按 logger 所属组件判断级别并对高频日志采样
+type Handler struct {
+	inner     slog.Handler
+	levels    *Levels
+	sampler   *sampler
+	component string
+}
+
+// NewHandler inner 应配置为最低级别（Debug），级别过滤由 levels 负责
+func NewHandler(inner slog.Handler, levels *Levels, sampling SamplingConfig) *Handler {
+	return &Handler{
+		inner:   inner,
+		levels:  levels,
+		sampler: newSampler(sampling),
+	}
+}
+
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.levels.Level(h.component)
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if h.sampler != nil && !h.sampler.allow(h.component, r) {
+		return nil
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.inner = h.inner.WithAttrs(attrs)
+	for _, a := range attrs {
+		if a.Key == ComponentKey {
+			clone.component = a.Value.String()
+		}
+	}
+	return &clone
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.inner = h.inner.WithGroup(name)
+	return &clone
+}
+
+// Setup 创建输出 JSON 的根 logger。level 为默认级别，overrides 为 "component=level" 列表
+func Setup(w io.Writer, level, overrides string, sampling SamplingConfig) (*slog.Logger, *Levels, error) {
+	def, err := ParseLevel(level)
+	if err != nil {
+		return nil, nil, err
+	}
+	perComponent, err := ParseOverrides(overrides)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	levels := NewLevels(def, perComponent)
+	inner := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return slog.New(NewHandler(inner, levels, sampling)), levels, nil
+}
@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLogger(t *testing.T, overrides string, sampling SamplingConfig) (*slog.Logger, *Levels, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	logger, levels, err := Setup(&buf, "info", overrides, sampling)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return logger, levels, &buf
+}
+
+func TestComponentLevels(t *testing.T) {
+	logger, levels, buf := newTestLogger(t, "pool=debug,api=warn", SamplingConfig{})
+
+	pool := logger.With(ComponentKey, "pool")
+	api := logger.With(ComponentKey, "api")
+
+	pool.Debug("pool debug")
+	api.Info("api info")
+	logger.Debug("root debug")
+	logger.Info("root info")
+
+	out := buf.String()
+	for _, want := range []string{"pool debug", "root info"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"api info", "root debug"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("Did not expect %q in output:\n%s", unwanted, out)
+		}
+	}
+
+	// 运行时修改对已创建的 logger 立即生效
+	buf.Reset()
+	levels.Set("api", slog.LevelDebug)
+	levels.Reset("pool")
+	api.Debug("api debug")
+	pool.Debug("pool debug again")
+	if !strings.Contains(buf.String(), "api debug") || strings.Contains(buf.String(), "pool debug again") {
+		t.Errorf("Dynamic level change not applied:\n%s", buf.String())
+	}
+}
+
+func TestSampling(t *testing.T) {
+	logger, _, buf := newTestLogger(t, "", SamplingConfig{Initial: 2, Thereafter: 5, Interval: time.Hour})
+
+	for i := 0; i < 12; i++ {
+		logger.Info("Request", "path", "/health")
+	}
+	logger.Info("Request", "path", "/api/v1/sessions")
+	logger.Warn("Request", "path", "/health")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	health, other, warn := 0, 0, 0
+	for _, line := range lines {
+		switch {
+		case strings.Contains(line, `"level":"WARN"`):
+			warn++
+		case strings.Contains(line, "/health"):
+			health++
+		default:
+			other++
+		}
+	}
+	// 前 2 条全部输出，之后第 5、10 条输出
+	if health != 4 {
+		t.Errorf("Expected 4 sampled health logs, got %d", health)
+	}
+	if other != 1 || warn != 1 {
+		t.Errorf("Other routes and warnings must not be sampled (other=%d warn=%d)", other, warn)
+	}
+}
+
+func TestParseOverrides(t *testing.T) {
+	got, err := ParseOverrides(" pool=debug , api=INFO ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["pool"] != slog.LevelDebug || got["api"] != slog.LevelInfo {
+		t.Errorf("Unexpected overrides %v", got)
+	}
+	if _, err := ParseOverrides("pool"); err == nil {
+		t.Error("Expected error for missing level")
+	}
+	if _, err := ParseOverrides("pool=loud"); err == nil {
+		t.Error("Expected error for unknown level")
+	}
+}
@@ -0,0 +1,112 @@
+// Package logging 提供按组件控制级别和采样的 slog Handler。
+// 组件由 logger.With("component", name) 标识，级别可在运行时通过管理接口调整。
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// ComponentKey 标识组件的日志属性名
+const ComponentKey = "component"
+
+// Levels 默认日志级别和各组件的覆盖级别，可并发读写
+type Levels struct {
+	def slog.LevelVar
+
+	mu        sync.RWMutex
+	overrides map[string]slog.Level
+}
+
+func NewLevels(def slog.Level, overrides map[string]slog.Level) *Levels {
+	l := &Levels{overrides: make(map[string]slog.Level, len(overrides))}
+	l.def.Set(def)
+	for component, level := range overrides {
+		l.overrides[component] = level
+	}
+	return l
+}
+
+// Level 返回组件生效的级别，未覆盖时为默认级别
+func (l *Levels) Level(component string) slog.Level {
+	if component != "" {
+		l.mu.RLock()
+		level, ok := l.overrides[component]
+		l.mu.RUnlock()
+		if ok {
+			return level
+		}
+	}
+	return l.def.Level()
+}
+
+// SetDefault 修改默认级别
+func (l *Levels) SetDefault(level slog.Level) {
+	l.def.Set(level)
+}
+
+// Set 覆盖组件级别
+func (l *Levels) Set(component string, level slog.Level) {
+	l.mu.Lock()
+	l.overrides[component] = level
+	l.mu.Unlock()
+}
+
+// Reset 移除组件覆盖，恢复使用默认级别
+func (l *Levels) Reset(component string) {
+	l.mu.Lock()
+	delete(l.overrides, component)
+	l.mu.Unlock()
+}
+
+// Snapshot 返回默认级别和覆盖表的副本
+func (l *Levels) Snapshot() (slog.Level, map[string]slog.Level) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := make(map[string]slog.Level, len(l.overrides))
+	for component, level := range l.overrides {
+		out[component] = level
+	}
+	return l.def.Level(), out
+}
+
+// ParseLevel 解析 debug / info / warn / error（不区分大小写）
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", s)
+	}
+	return level, nil
+}
+
+// ParseOverrides 解析 "pool=debug,api=info" 形式的组件级别
+func ParseOverrides(s string) (map[string]slog.Level, error) {
+	out := make(map[string]slog.Level)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		component, levelStr, ok := strings.Cut(part, "=")
+		if !ok || strings.TrimSpace(component) == "" {
+			return nil, fmt.Errorf("invalid log level override %q (expected component=level)", part)
+		}
+		level, err := ParseLevel(levelStr)
+		if err != nil {
+			return nil, err
+		}
+		out[strings.TrimSpace(component)] = level
+	}
+	return out, nil
+}
+
+// FormatOverrides 将覆盖表转换为小写级别名，用于日志和接口响应
+func FormatOverrides(overrides map[string]slog.Level) map[string]string {
+	out := make(map[string]string, len(overrides))
+	for name, level := range overrides {
+		out[name] = strings.ToLower(level.String())
+	}
+	return out
+}
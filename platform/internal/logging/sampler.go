@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// SamplingConfig 每个采样周期内，同一条日志前 Initial 次全部输出，之后每 Thereafter 次输出一次。
+// 只对 Info 及以下级别采样，Warn / Error 总是输出。Initial 为 0 时不采样。
+type SamplingConfig struct {
+	Initial    int
+	Thereafter int
+	Interval   time.Duration
+}
+
+// sampler 按 (组件, 消息, path) 计数。path 属性区分不同路由的请求日志，
+// 健康检查等高频请求被采样时不影响其他接口的请求日志。
+type sampler struct {
+	cfg SamplingConfig
+
+	mu     sync.Mutex
+	window time.Time
+	counts map[string]int
+}
+
+func newSampler(cfg SamplingConfig) *sampler {
+	if cfg.Initial <= 0 {
+		return nil
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	return &sampler{cfg: cfg, counts: make(map[string]int)}
+}
+
+func (s *sampler) allow(component string, r slog.Record) bool {
+	if r.Level >= slog.LevelWarn {
+		return true
+	}
+
+	key := component + "\x00" + r.Message
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "path" {
+			key += "\x00" + a.Value.String()
+			return false
+		}
+		return true
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := r.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if now.Sub(s.window) >= s.cfg.Interval {
+		s.window = now
+		clear(s.counts)
+	}
+
+	s.counts[key]++
+	n := s.counts[key]
+	if n <= s.cfg.Initial {
+		return true
+	}
+	return s.cfg.Thereafter > 0 && (n-s.cfg.Initial)%s.cfg.Thereafter == 0
+}
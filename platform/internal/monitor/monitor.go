@@ -7,12 +7,12 @@ import (
 
 // Pool Metrics
 var (
-	PoolIdleCount = promauto.NewGauge(prometheus.GaugeOpts{
+	PoolIdleCount = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: "agent_platform",
 		Subsystem: "pool",
 		Name:      "idle_count",
-		Help:      "Current number of idle containers in the pool",
-	})
+		Help:      "Current number of idle containers, by warm pool",
+	}, []string{"pool"})
 
 	PoolAcquisitionLatency = promauto.NewHistogram(prometheus.HistogramOpts{
 		Namespace: "agent_platform",
@@ -35,6 +35,55 @@ var (
 		Name:      "managed_count",
 		Help:      "Total number of containers managed by the pool (idle + leased)",
 	})
+
+	PoolTargetIdle = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "agent_platform",
+		Subsystem: "pool",
+		Name:      "target_idle",
+		Help:      "Current target number of idle containers adjusted by the autoscaler, by warm pool",
+	}, []string{"pool"})
+
+	PoolAcquireQueued = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "agent_platform",
+		Subsystem: "pool",
+		Name:      "acquire_queued",
+		Help:      "Number of acquisitions waiting for pool capacity, by warm pool",
+	}, []string{"pool"})
+
+	PoolScaleDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "agent_platform",
+		Subsystem: "pool",
+		Name:      "scale_decisions_total",
+		Help:      "Total number of autoscaler changes to the target idle count, by warm pool and direction",
+	}, []string{"pool", "direction"})
+
+	PoolDegraded = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "agent_platform",
+		Subsystem: "pool",
+		Name:      "degraded",
+		Help:      "1 from the time a warm pool enters replenish cooldown until it creates a container successfully, by warm pool",
+	}, []string{"pool"})
+
+	PoolRetirements = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "agent_platform",
+		Subsystem: "pool",
+		Name:      "retirements_total",
+		Help:      "Total number of warm containers removed for exceeding their max age or idle TTL, by warm pool and reason",
+	}, []string{"pool", "reason"})
+
+	PoolRecycles = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "agent_platform",
+		Subsystem: "pool",
+		Name:      "recycles_total",
+		Help:      "Total number of released containers wiped and returned to the idle list, by warm pool and result",
+	}, []string{"pool", "result"})
+
+	ManagedContainers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "agent_platform",
+		Subsystem: "pool",
+		Name:      "sandbox_containers",
+		Help:      "Sandbox containers found by label query, by strategy, state and platform version",
+	}, []string{"strategy", "state", "platform_version"})
 )
 
 // Dispatcher Metrics
@@ -61,6 +110,64 @@ var (
 	})
 )
 
+// Event Bus Metrics
+var (
+	EventBusPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "agent_platform",
+		Subsystem: "eventbus",
+		Name:      "published_total",
+		Help:      "Total number of events published to the bus",
+	}, []string{"type"})
+
+	// EventBusPublishErrors reason 为 marshal 或 redis
+	EventBusPublishErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "agent_platform",
+		Subsystem: "eventbus",
+		Name:      "publish_errors_total",
+		Help:      "Total number of events that failed to publish",
+	}, []string{"reason"})
+
+	// EventBusPayloadOverflows 负载超出大小上限而转存的事件数
+	EventBusPayloadOverflows = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "agent_platform",
+		Subsystem: "eventbus",
+		Name:      "payload_overflows_total",
+		Help:      "Total number of event payloads stored out of band for exceeding the size limit",
+	}, []string{"type"})
+
+	EventBusConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "agent_platform",
+		Subsystem: "eventbus",
+		Name:      "consumed_total",
+		Help:      "Total number of events received by subscribers",
+	}, []string{"type"})
+
+	EventBusUnmarshalErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "agent_platform",
+		Subsystem: "eventbus",
+		Name:      "unmarshal_errors_total",
+		Help:      "Total number of received events that could not be decoded",
+	})
+
+	// EventBusSubscribers 每个 session 当前的订阅者数量，归零时删除该 session 的序列
+	EventBusSubscribers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "agent_platform",
+		Subsystem: "eventbus",
+		Name:      "subscribers",
+		Help:      "Current number of event subscribers per session",
+	}, []string{"session_id"})
+
+	// EventBusDeliveryLag 事件时间戳到各阶段的延迟：received 为订阅者从 Redis 收到，emitted 为写入 SSE 连接。
+	// 两者差距变大说明客户端消费慢，received 本身变大说明 Redis 或发布端拥塞
+	EventBusDeliveryLag = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "agent_platform",
+		Subsystem: "eventbus",
+		Name:      "delivery_lag_seconds",
+		Help:      "Delay between event timestamp and delivery stage",
+		Buckets:   []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"stage"})
+)
+
 // Session Metrics
 var (
 	SessionActiveCount = promauto.NewGauge(prometheus.GaugeOpts{
@@ -78,3 +185,121 @@ var (
 		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30},
 	})
 )
+
+// Host GC Metrics
+var (
+	GCRemovedDirs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "agent_platform",
+		Subsystem: "gc",
+		Name:      "removed_dirs_total",
+		Help:      "Total number of host directories removed by GC",
+	}, []string{"kind"})
+
+	GCFreedBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "agent_platform",
+		Subsystem: "gc",
+		Name:      "freed_bytes_total",
+		Help:      "Total bytes freed by host directory GC",
+	})
+)
+
+// Storage Metrics
+var (
+	StorageBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "agent_platform",
+		Subsystem: "storage",
+		Name:      "bytes",
+		Help:      "Disk usage attributable to the platform by category",
+	}, []string{"category"})
+
+	DiskQuotaExceeded = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "agent_platform",
+		Subsystem: "storage",
+		Name:      "quota_exceeded_total",
+		Help:      "Number of times a session workspace was found over its disk quota",
+	})
+
+	ExecPolicyViolations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "agent_platform",
+		Subsystem: "security",
+		Name:      "exec_policy_violations_total",
+		Help:      "Number of platform-initiated execs rejected by the command policy",
+	}, []string{"rule"})
+
+	// SessionDrift 记录容器实际状态与 session 记录不一致的次数，field 为 ip / memory / cpu / missing / stopped
+	SessionDrift = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "agent_platform",
+		Subsystem: "session",
+		Name:      "drift_total",
+		Help:      "Number of mismatches found between session records and live container state",
+	}, []string{"field", "repaired"})
+)
+
+// API Metrics
+var (
+	APIDeprecatedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "agent_platform",
+		Subsystem: "api",
+		Name:      "deprecated_requests_total",
+		Help:      "Total number of requests to deprecated API routes",
+	}, []string{"method", "route"})
+)
+
+// Outbox Metrics
+var (
+	OutboxRelayed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "agent_platform",
+		Subsystem: "outbox",
+		Name:      "relayed_total",
+		Help:      "Total number of outbox tasks relayed to the queue by result",
+	}, []string{"result"})
+)
+
+// Cache Metrics
+var (
+	CacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "agent_platform",
+		Subsystem: "cache",
+		Name:      "lookups_total",
+		Help:      "Total number of cache lookups by result (hit, miss, error)",
+	}, []string{"cache", "result"})
+
+	CacheErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "agent_platform",
+		Subsystem: "cache",
+		Name:      "errors_total",
+		Help:      "Total number of failed cache operations",
+	}, []string{"cache", "op"})
+
+	CacheLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "agent_platform",
+		Subsystem: "cache",
+		Name:      "operation_latency_seconds",
+		Help:      "Latency of cache operations",
+		Buckets:   []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25},
+	}, []string{"cache", "op"})
+)
+
+// SLO Metrics
+var (
+	SLOBurnRate = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "agent_platform",
+		Subsystem: "slo",
+		Name:      "burn_rate",
+		Help:      "Error budget burn rate (bad event ratio divided by 1 - target) over a trailing window, by objective and window",
+	}, []string{"objective", "window"})
+
+	SLOErrorBudgetRemaining = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "agent_platform",
+		Subsystem: "slo",
+		Name:      "error_budget_remaining",
+		Help:      "Fraction of the error budget left over a trailing window (negative once exhausted), by objective and window",
+	}, []string{"objective", "window"})
+
+	SLOEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "agent_platform",
+		Subsystem: "slo",
+		Name:      "events_total",
+		Help:      "Total number of events recorded by this instance against an objective, by objective and result (good, failed, slow)",
+	}, []string{"objective", "result"})
+)
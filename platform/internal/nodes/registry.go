@@ -0,0 +1,233 @@
+// Package nodes 多节点部署时可以放置沙箱容器的 Docker 主机。
+// 冷启动容器按各节点的剩余容量放置，预热池仍只在本机维护；
+// 容器网络需要在节点之间可路由（overlay 或路由的 bridge），dispatcher 直接连接容器 IP
+package nodes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/client"
+
+	"platform/internal/sandbox"
+)
+
+// LocalNode 控制面所在主机的节点 ID
+const LocalNode = "local"
+
+// ErrNoCapacity 所有节点都已达到容器上限
+var ErrNoCapacity = errors.New("no node has free capacity")
+
+// Config 一个远程 Docker 主机
+type Config struct {
+	ID string
+	// Address Docker API 地址，如 tcp://10.0.0.2:2376
+	Address string
+	// TLSDir 包含 ca.pem、cert.pem、key.pem 的目录，为空时不使用 TLS
+	TLSDir string
+	// MaxContainers 节点上平台容器的上限，0 表示不限制
+	MaxContainers int
+}
+
+// ParseConfigs 解析 "id=tcp://host:2376?max=20" 形式的节点列表。
+// tlsRoot 不为空时每个节点使用 <tlsRoot>/<id> 下的证书
+func ParseConfigs(entries []string, tlsRoot string) ([]Config, error) {
+	var configs []Config
+	seen := map[string]bool{LocalNode: true}
+	for _, entry := range entries {
+		id, addr, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || id == "" || addr == "" {
+			return nil, fmt.Errorf("invalid node %q (expected id=tcp://host:port)", entry)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("duplicate node id %q", id)
+		}
+		seen[id] = true
+
+		u, err := url.Parse(addr)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid address for node %s: %q", id, addr)
+		}
+		cfg := Config{ID: id}
+		if max := u.Query().Get("max"); max != "" {
+			if cfg.MaxContainers, err = strconv.Atoi(max); err != nil || cfg.MaxContainers < 0 {
+				return nil, fmt.Errorf("invalid max for node %s: %q", id, max)
+			}
+		}
+		u.RawQuery = ""
+		cfg.Address = u.String()
+		if tlsRoot != "" {
+			cfg.TLSDir = filepath.Join(tlsRoot, id)
+		}
+		configs = append(configs, cfg)
+	}
+	return configs, nil
+}
+
+// Node 一个可以放置容器的 Docker 主机
+type Node struct {
+	ID            string
+	Address       string
+	Client        *client.Client
+	MaxContainers int
+
+	lister sandbox.ContainerLister
+}
+
+// Registry 本机和远程节点的 Docker 客户端，负责放置新容器和按容器 ID 定位节点
+type Registry struct {
+	nodes  []*Node
+	logger *slog.Logger
+
+	mu sync.Mutex
+	// pending 已选定节点但尚未创建完成的容器数，避免并发放置时都选中同一个快满的节点
+	pending map[string]int
+	// located 容器 ID 到节点 ID 的缓存
+	located map[string]string
+}
+
+// NewRegistry local 为控制面所在主机的客户端，作为 LocalNode 排在第一位
+func NewRegistry(local *client.Client, localMax int, configs []Config, logger *slog.Logger) (*Registry, error) {
+	nodes := []*Node{{ID: LocalNode, Client: local, MaxContainers: localMax, lister: local}}
+	for _, cfg := range configs {
+		opts := []client.Opt{client.WithHost(cfg.Address), client.WithAPIVersionNegotiation()}
+		if cfg.TLSDir != "" {
+			ca, cert, key := filepath.Join(cfg.TLSDir, "ca.pem"), filepath.Join(cfg.TLSDir, "cert.pem"), filepath.Join(cfg.TLSDir, "key.pem")
+			for _, f := range []string{ca, cert, key} {
+				if _, err := os.Stat(f); err != nil {
+					return nil, fmt.Errorf("node %s: %w", cfg.ID, err)
+				}
+			}
+			opts = append(opts, client.WithTLSClientConfig(ca, cert, key))
+		}
+		cli, err := client.NewClientWithOpts(opts...)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: failed to create docker client: %w", cfg.ID, err)
+		}
+		nodes = append(nodes, &Node{ID: cfg.ID, Address: cfg.Address, Client: cli, MaxContainers: cfg.MaxContainers, lister: cli})
+	}
+	return newRegistry(nodes, logger), nil
+}
+
+func newRegistry(nodes []*Node, logger *slog.Logger) *Registry {
+	return &Registry{
+		nodes:   nodes,
+		logger:  logger.With("component", "nodes"),
+		pending: make(map[string]int),
+		located: make(map[string]string),
+	}
+}
+
+// Nodes 返回所有节点，本机在第一位
+func (r *Registry) Nodes() []*Node {
+	return r.nodes
+}
+
+// Node 按 ID 返回节点，空 ID 为本机
+func (r *Registry) Node(id string) (*Node, error) {
+	if id == "" {
+		id = LocalNode
+	}
+	for _, n := range r.nodes {
+		if n.ID == id {
+			return n, nil
+		}
+	}
+	return nil, fmt.Errorf("node %q is not configured", id)
+}
+
+// Place 选择剩余容量最多的节点放置一个新容器，返回的 done 必须在容器创建完成（无论成败）后调用。
+// 无法访问的节点跳过；所有节点都已满时返回 ErrNoCapacity
+func (r *Registry) Place(ctx context.Context) (*Node, func(), error) {
+	var best *Node
+	bestFree := math.MinInt
+	for _, n := range r.nodes {
+		running, err := sandbox.ListManaged(ctx, n.lister, nil, false)
+		if err != nil {
+			r.logger.Warn("Skipping unreachable node", "node", n.ID, "error", err)
+			continue
+		}
+		r.mu.Lock()
+		used := len(running) + r.pending[n.ID]
+		r.mu.Unlock()
+
+		// 不限制容量的节点按已用数量比较，已用越少越优先
+		free := math.MaxInt32 - used
+		if n.MaxContainers > 0 {
+			if free = n.MaxContainers - used; free <= 0 {
+				continue
+			}
+		}
+		if free > bestFree {
+			best, bestFree = n, free
+		}
+	}
+	if best == nil {
+		return nil, nil, ErrNoCapacity
+	}
+
+	r.mu.Lock()
+	r.pending[best.ID]++
+	r.mu.Unlock()
+	var once sync.Once
+	done := func() {
+		once.Do(func() {
+			r.mu.Lock()
+			r.pending[best.ID]--
+			r.mu.Unlock()
+		})
+	}
+	return best, done, nil
+}
+
+// Remember 记录容器所在节点，之后 Locate 不需要逐个节点查询
+func (r *Registry) Remember(containerID, nodeID string) {
+	if r == nil || containerID == "" || nodeID == "" {
+		return
+	}
+	r.mu.Lock()
+	r.located[containerID] = nodeID
+	r.mu.Unlock()
+}
+
+// Locate 返回容器所在的节点：先查缓存，再依次查询各节点，都找不到时返回本机
+func (r *Registry) Locate(ctx context.Context, containerID string) *Node {
+	r.mu.Lock()
+	id, ok := r.located[containerID]
+	r.mu.Unlock()
+	if ok {
+		if n, err := r.Node(id); err == nil {
+			return n
+		}
+	}
+
+	for _, n := range r.nodes {
+		if n.Client == nil {
+			continue
+		}
+		if _, err := n.Client.ContainerInspect(ctx, containerID); err == nil {
+			r.Remember(containerID, n.ID)
+			return n
+		}
+	}
+	return r.nodes[0]
+}
+
+// Forget 容器删除后清除缓存
+func (r *Registry) Forget(containerID string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	delete(r.located, containerID)
+	r.mu.Unlock()
+}
@@ -0,0 +1,136 @@
+package nodes
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+type fakeLister struct {
+	running int
+	err     error
+}
+
+func (f *fakeLister) ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return make([]container.Summary, f.running), nil
+}
+
+func testRegistry(nodes ...*Node) *Registry {
+	return newRegistry(nodes, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestParseConfigs(t *testing.T) {
+	configs, err := ParseConfigs([]string{"gpu-1=tcp://10.0.0.2:2376?max=20", " cpu-1=tcp://10.0.0.3:2375"}, "/etc/platform/nodes")
+	if err != nil {
+		t.Fatalf("ParseConfigs: %v", err)
+	}
+	if len(configs) != 2 {
+		t.Fatalf("got %d configs, want 2", len(configs))
+	}
+	want := Config{ID: "gpu-1", Address: "tcp://10.0.0.2:2376", TLSDir: "/etc/platform/nodes/gpu-1", MaxContainers: 20}
+	if configs[0] != want {
+		t.Errorf("configs[0] = %+v, want %+v", configs[0], want)
+	}
+	if configs[1].ID != "cpu-1" || configs[1].MaxContainers != 0 {
+		t.Errorf("configs[1] = %+v", configs[1])
+	}
+
+	for _, bad := range [][]string{
+		{"tcp://10.0.0.2:2376"},
+		{"a=tcp://h:1", "a=tcp://h:2"},
+		{"local=tcp://h:1"},
+		{"a=tcp://h:1?max=x"},
+		{"a=not-a-url"},
+	} {
+		if _, err := ParseConfigs(bad, ""); err == nil {
+			t.Errorf("ParseConfigs(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestPlacePrefersFreeCapacity(t *testing.T) {
+	r := testRegistry(
+		&Node{ID: LocalNode, MaxContainers: 4, lister: &fakeLister{running: 3}},
+		&Node{ID: "a", MaxContainers: 10, lister: &fakeLister{running: 5}},
+		&Node{ID: "b", MaxContainers: 2, lister: &fakeLister{running: 2}},
+	)
+	node, done, err := r.Place(context.Background())
+	if err != nil {
+		t.Fatalf("Place: %v", err)
+	}
+	defer done()
+	if node.ID != "a" {
+		t.Errorf("placed on %s, want a", node.ID)
+	}
+}
+
+func TestPlaceCountsPending(t *testing.T) {
+	r := testRegistry(
+		&Node{ID: LocalNode, MaxContainers: 2, lister: &fakeLister{running: 0}},
+		&Node{ID: "a", MaxContainers: 2, lister: &fakeLister{running: 1}},
+	)
+	ctx := context.Background()
+
+	first, done1, err := r.Place(ctx)
+	if err != nil || first.ID != LocalNode {
+		t.Fatalf("first Place = %v, %v; want local", first, err)
+	}
+	// 本机有一个待创建的容器，与 a 剩余容量相同，按顺序仍选本机
+	second, done2, err := r.Place(ctx)
+	if err != nil || second.ID != LocalNode {
+		t.Fatalf("second Place = %v, %v; want local", second, err)
+	}
+	third, done3, err := r.Place(ctx)
+	if err != nil || third.ID != "a" {
+		t.Fatalf("third Place = %v, %v; want a", third, err)
+	}
+	if _, _, err := r.Place(ctx); !errors.Is(err, ErrNoCapacity) {
+		t.Fatalf("fourth Place err = %v, want ErrNoCapacity", err)
+	}
+
+	done1()
+	done1()
+	done2()
+	done3()
+	if node, done, err := r.Place(ctx); err != nil || node.ID != LocalNode {
+		t.Fatalf("Place after done = %v, %v; want local", node, err)
+	} else {
+		done()
+	}
+}
+
+func TestPlaceSkipsUnreachableNodes(t *testing.T) {
+	r := testRegistry(
+		&Node{ID: LocalNode, lister: &fakeLister{err: errors.New("connection refused")}},
+		&Node{ID: "a", MaxContainers: 1, lister: &fakeLister{}},
+	)
+	node, done, err := r.Place(context.Background())
+	if err != nil {
+		t.Fatalf("Place: %v", err)
+	}
+	done()
+	if node.ID != "a" {
+		t.Errorf("placed on %s, want a", node.ID)
+	}
+}
+
+func TestLocateUsesRememberedNode(t *testing.T) {
+	r := testRegistry(&Node{ID: LocalNode}, &Node{ID: "a"})
+	ctx := context.Background()
+
+	r.Remember("c1", "a")
+	if n := r.Locate(ctx, "c1"); n.ID != "a" {
+		t.Errorf("Locate(c1) = %s, want a", n.ID)
+	}
+	r.Forget("c1")
+	if n := r.Locate(ctx, "c1"); n.ID != LocalNode {
+		t.Errorf("Locate(c1) after Forget = %s, want local", n.ID)
+	}
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+var _ Sender = (*EmailSender)(nil)
+
+type EmailConfig struct {
+	// Addr SMTP 服务器地址（host:port）
+	Addr string
+	From string
+	To   []string
+	// Username / Password 为空时不认证
+	Username string
+	Password string
+}
+
+// EmailSender 通过 SMTP 发送告警邮件
+type EmailSender struct {
+	config EmailConfig
+}
+
+func NewEmailSender(config EmailConfig) *EmailSender {
+	return &EmailSender{config: config}
+}
+
+func (s *EmailSender) Name() string { return "email" }
+
+func (s *EmailSender) Send(ctx context.Context, a Alert) error {
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		host, _, err := net.SplitHostPort(s.config.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid smtp address %q: %w", s.config.Addr, err)
+		}
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, host)
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", s.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.config.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject(a))
+	fmt.Fprintf(&msg, "Date: %s\r\n", a.Time.Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(text(a), "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	// smtp.SendMail 不支持 context，放到 goroutine 中以便超时返回
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- smtp.SendMail(s.config.Addr, auth, s.config.From, s.config.To, []byte(msg.String()))
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
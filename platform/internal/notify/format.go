@@ -0,0 +1,26 @@
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// text 将告警格式化为纯文本正文，字段按名称排序
+func text(a Alert) string {
+	var b strings.Builder
+	b.WriteString(a.Message)
+	keys := make([]string, 0, len(a.Fields))
+	for k := range a.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "\n%s: %s", k, a.Fields[k])
+	}
+	return b.String()
+}
+
+func subject(a Alert) string {
+	return fmt.Sprintf("[%s] %s", strings.ToUpper(string(a.Severity)), a.Title)
+}
@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	// Throttle 同一告警（Kind + Key）的最小发送间隔
+	Throttle time.Duration
+	// Kinds 启用的告警类型，为空时全部启用
+	Kinds []Kind
+	// QueueSize 待发送告警的缓冲，满时丢弃新告警
+	QueueSize int
+}
+
+// Notifier 异步发送告警并按 Kind + Key 节流。Notifier 为 nil 时 Notify 为空操作
+type Notifier struct {
+	senders []Sender
+	config  Config
+	logger  *slog.Logger
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time
+	queue    chan Alert
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+func New(senders []Sender, config Config, logger *slog.Logger) *Notifier {
+	if config.Throttle <= 0 {
+		config.Throttle = 15 * time.Minute
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 64
+	}
+	return &Notifier{
+		senders:  senders,
+		config:   config,
+		logger:   logger.With("component", "notifier"),
+		lastSent: make(map[string]time.Time),
+		queue:    make(chan Alert, config.QueueSize),
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+}
+
+// Notify 提交一条告警，不阻塞调用方；被节流或未启用的告警直接丢弃
+func (n *Notifier) Notify(a Alert) {
+	if n == nil {
+		return
+	}
+	if len(n.config.Kinds) > 0 && !slices.Contains(n.config.Kinds, a.Kind) {
+		return
+	}
+	if a.Time.IsZero() {
+		a.Time = time.Now()
+	}
+	if a.Severity == "" {
+		a.Severity = SeverityWarning
+	}
+
+	key := string(a.Kind) + "|" + a.Key
+	n.mu.Lock()
+	if last, ok := n.lastSent[key]; ok && a.Time.Sub(last) < n.config.Throttle {
+		n.mu.Unlock()
+		return
+	}
+	n.lastSent[key] = a.Time
+	n.mu.Unlock()
+
+	select {
+	case n.queue <- a:
+	default:
+		n.logger.Warn("Alert queue full, dropping alert", "kind", a.Kind, "key", a.Key)
+	}
+}
+
+// Start 发送队列中的告警（阻塞，应在 goroutine 中调用），Stop 后发送完剩余告警再返回
+func (n *Notifier) Start() {
+	defer close(n.doneCh)
+	for {
+		select {
+		case a := <-n.queue:
+			n.send(a)
+		case <-n.stopCh:
+			for {
+				select {
+				case a := <-n.queue:
+					n.send(a)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Stop 停止发送循环并等待剩余告警发送完成
+func (n *Notifier) Stop() {
+	select {
+	case <-n.stopCh:
+		return
+	default:
+		close(n.stopCh)
+	}
+	<-n.doneCh
+}
+
+func (n *Notifier) send(a Alert) {
+	for _, s := range n.senders {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := s.Send(ctx, a)
+		cancel()
+		if err != nil {
+			n.logger.Warn("Failed to send alert", "sender", s.Name(), "kind", a.Kind, "error", err)
+			continue
+		}
+		n.logger.Info("Alert sent", "sender", s.Name(), "kind", a.Kind, "key", a.Key)
+	}
+}
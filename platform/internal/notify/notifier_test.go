@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordSender struct {
+	mu     sync.Mutex
+	alerts []Alert
+}
+
+func (s *recordSender) Name() string { return "record" }
+
+func (s *recordSender) Send(ctx context.Context, a Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alerts = append(s.alerts, a)
+	return nil
+}
+
+func TestNotifierThrottle(t *testing.T) {
+	rec := &recordSender{}
+	n := New([]Sender{rec}, Config{
+		Throttle: time.Minute,
+		Kinds:    []Kind{KindPoolCooldown, KindDiskThreshold},
+	}, slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	go n.Start()
+
+	now := time.Now()
+	n.Notify(Alert{Kind: KindPoolCooldown, Title: "cooldown", Time: now})
+	n.Notify(Alert{Kind: KindPoolCooldown, Title: "cooldown again", Time: now.Add(time.Second)})
+	n.Notify(Alert{Kind: KindPoolCooldown, Title: "after throttle", Time: now.Add(2 * time.Minute)})
+	n.Notify(Alert{Kind: KindDiskThreshold, Key: "/data", Title: "disk", Time: now})
+	n.Notify(Alert{Kind: KindDiskThreshold, Key: "/var", Title: "disk", Time: now})
+	n.Notify(Alert{Kind: KindSessionErrorRate, Title: "disabled kind", Time: now})
+	n.Stop()
+
+	var titles []string
+	for _, a := range rec.alerts {
+		titles = append(titles, a.Title)
+	}
+	if got := strings.Join(titles, ","); got != "cooldown,after throttle,disk,disk" {
+		t.Fatalf("sent alerts = %s", got)
+	}
+	if rec.alerts[0].Severity != SeverityWarning {
+		t.Errorf("default severity = %q", rec.alerts[0].Severity)
+	}
+
+	var nilNotifier *Notifier
+	nilNotifier.Notify(Alert{Kind: KindPoolCooldown})
+}
+
+func TestRateTracker(t *testing.T) {
+	r := NewRateTracker(RateConfig{Window: time.Minute, Threshold: 0.5, MinSamples: 4})
+	now := time.Now()
+
+	r.Record("s1", true, now)
+	r.Record("s2", true, now)
+	if _, _, exceeded := r.Record("s3", false, now); exceeded {
+		t.Fatal("should not alert below MinSamples")
+	}
+	// 同一 session 重试后以最后一次结果为准
+	if rate, samples, exceeded := r.Record("s2", false, now); exceeded || samples != 3 || rate > 0.34 {
+		t.Fatalf("after retry: rate=%v samples=%d exceeded=%v", rate, samples, exceeded)
+	}
+	if _, _, exceeded := r.Record("s4", true, now); !exceeded {
+		t.Fatal("expected alert at 50% failures")
+	}
+	// 窗口外的结果被移除
+	if rate, samples, _ := r.Record("s5", false, now.Add(2*time.Minute)); samples != 1 || rate != 0 {
+		t.Fatalf("after window: rate=%v samples=%d", rate, samples)
+	}
+}
+
+func TestSlackSender(t *testing.T) {
+	var body map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer srv.Close()
+
+	err := NewSlackSender(srv.URL).Send(context.Background(), Alert{
+		Severity: SeverityCritical,
+		Title:    "Pool entered cooldown",
+		Message:  "3 consecutive failures",
+		Fields:   map[string]string{"image": "agent:latest"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "*[CRITICAL] Pool entered cooldown*\n3 consecutive failures\nimage: agent:latest"
+	if body["text"] != want {
+		t.Fatalf("text = %q", body["text"])
+	}
+}
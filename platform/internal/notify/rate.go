@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"sync"
+	"time"
+)
+
+// RateConfig 失败率告警的阈值
+type RateConfig struct {
+	// Window 统计窗口
+	Window time.Duration
+	// Threshold 窗口内失败比例达到此值时告警（0-1）
+	Threshold float64
+	// MinSamples 窗口内样本少于此数时不告警，避免个别失败触发
+	MinSamples int
+}
+
+type outcome struct {
+	failed bool
+	at     time.Time
+}
+
+// RateTracker 统计窗口内各对象（如 session）的最终结果，同一 key 多次记录时以最后一次为准
+type RateTracker struct {
+	config RateConfig
+
+	mu       sync.Mutex
+	outcomes map[string]outcome
+}
+
+func NewRateTracker(config RateConfig) *RateTracker {
+	if config.Window <= 0 {
+		config.Window = 10 * time.Minute
+	}
+	if config.MinSamples <= 0 {
+		config.MinSamples = 1
+	}
+	return &RateTracker{config: config, outcomes: make(map[string]outcome)}
+}
+
+// Record 记录 key 的结果，返回窗口内的失败比例、样本数以及是否超过阈值
+func (r *RateTracker) Record(key string, failed bool, now time.Time) (rate float64, samples int, exceeded bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.outcomes[key] = outcome{failed: failed, at: now}
+	failures := 0
+	for k, o := range r.outcomes {
+		if now.Sub(o.at) > r.config.Window {
+			delete(r.outcomes, k)
+			continue
+		}
+		if o.failed {
+			failures++
+		}
+	}
+
+	samples = len(r.outcomes)
+	rate = float64(failures) / float64(samples)
+	return rate, samples, samples >= r.config.MinSamples && rate >= r.config.Threshold
+}
@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var _ Sender = (*SlackSender)(nil)
+
+// SlackSender 通过 Slack incoming webhook 发送告警
+type SlackSender struct {
+	webhookURL string
+	client     *http.Client
+}
+
+func NewSlackSender(webhookURL string) *SlackSender {
+	return &SlackSender{webhookURL: webhookURL, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (s *SlackSender) Name() string { return "slack" }
+
+func (s *SlackSender) Send(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(map[string]string{
+		"text": fmt.Sprintf("*%s*\n%s", subject(a), text(a)),
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("slack webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+// Package notify 将运维告警发送到 Slack webhook 或邮件，作为 Prometheus 指标之外的主动通知。
+// 同一告警在节流周期内只发送一次。
+package notify
+
+import (
+	"context"
+	"time"
+)
+
+// Kind 告警类型
+type Kind string
+
+const (
+	// KindPoolCooldown 预热池连续补充失败，进入冷却
+	KindPoolCooldown Kind = "pool_cooldown"
+	// KindContainerCreateFailures 容器连续创建失败
+	KindContainerCreateFailures Kind = "container_create_failures"
+	// KindSessionErrorRate 近期创建的 session 失败比例过高
+	KindSessionErrorRate Kind = "session_error_rate"
+	// KindDiskThreshold 宿主机磁盘使用率超过阈值
+	KindDiskThreshold Kind = "disk_threshold"
+)
+
+type Severity string
+
+const (
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// Alert 一条告警，Kind + Key 用于去重，Key 区分同类告警的不同对象（如磁盘路径）
+type Alert struct {
+	Kind     Kind
+	Key      string
+	Severity Severity
+	Title    string
+	Message  string
+	Fields   map[string]string
+	Time     time.Time
+}
+
+// Sender 告警发送渠道
+type Sender interface {
+	Name() string
+	Send(ctx context.Context, a Alert) error
+}
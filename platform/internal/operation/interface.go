@@ -0,0 +1,9 @@
+package operation
+
+import "context"
+
+type Store interface {
+	Save(ctx context.Context, op *Operation) error
+	// Get 返回操作，不存在或已过期时返回 ErrNotFound
+	Get(ctx context.Context, id string) (*Operation, error)
+}
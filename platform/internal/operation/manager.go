@@ -0,0 +1,107 @@
+package operation
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Func 操作的执行体，返回值序列化后写入 Operation.Result
+type Func func(ctx context.Context) (any, error)
+
+// Manager 在后台执行异步操作并持久化其状态
+type Manager struct {
+	store  Store
+	logger *slog.Logger
+	wg     sync.WaitGroup
+}
+
+func NewManager(store Store, logger *slog.Logger) *Manager {
+	return &Manager{
+		store:  store,
+		logger: logger.With("component", "operation"),
+	}
+}
+
+// Start 创建操作记录并在后台执行 fn，立即返回 pending 状态的操作
+func (m *Manager) Start(ctx context.Context, kind Kind, sessionID string, timeout time.Duration, fn Func) (*Operation, error) {
+	now := time.Now()
+	op := &Operation{
+		ID:        uuid.New().String(),
+		Kind:      kind,
+		SessionID: sessionID,
+		Status:    StatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := m.store.Save(ctx, op); err != nil {
+		return nil, err
+	}
+
+	snapshot := *op
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.run(op, timeout, fn)
+	}()
+
+	return &snapshot, nil
+}
+
+func (m *Manager) run(op *Operation, timeout time.Duration, fn Func) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	op.Status = StatusRunning
+	op.UpdatedAt = time.Now()
+	m.save(op)
+
+	result, err := fn(ctx)
+
+	op.UpdatedAt = time.Now()
+	op.DoneAt = op.UpdatedAt
+	if err != nil {
+		op.Status = StatusFailed
+		op.Error = err.Error()
+		m.logger.Error("Operation failed", "id", op.ID, "kind", op.Kind, "session_id", op.SessionID, "error", err)
+	} else {
+		op.Status = StatusSucceeded
+		if result != nil {
+			if b, err := json.Marshal(result); err == nil {
+				op.Result = b
+			}
+		}
+	}
+	m.save(op)
+}
+
+func (m *Manager) save(op *Operation) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.store.Save(ctx, op); err != nil {
+		m.logger.Warn("Failed to persist operation", "id", op.ID, "status", op.Status, "error", err)
+	}
+}
+
+func (m *Manager) Get(ctx context.Context, id string) (*Operation, error) {
+	return m.store.Get(ctx, id)
+}
+
+// Wait 等待所有进行中的操作结束，ctx 到期时提前返回
+func (m *Manager) Wait(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		m.logger.Warn("Timed out waiting for in-flight operations")
+	}
+}
@@ -0,0 +1,67 @@
+package operation
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memStore struct {
+	mu  sync.Mutex
+	ops map[string]Operation
+}
+
+func (s *memStore) Save(ctx context.Context, op *Operation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ops[op.ID] = *op
+	return nil
+}
+
+func (s *memStore) Get(ctx context.Context, id string) (*Operation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	op, ok := s.ops[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &op, nil
+}
+
+func TestManagerRecordsResult(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(&memStore{ops: make(map[string]Operation)}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+
+	ok, err := m.Start(ctx, KindSync, "s1", time.Second, func(ctx context.Context) (any, error) {
+		return map[string]int{"files": 3}, nil
+	})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if ok.Status != StatusPending {
+		t.Errorf("Expected pending operation, got %s", ok.Status)
+	}
+
+	failed, _ := m.Start(ctx, KindTerminate, "s1", time.Second, func(ctx context.Context) (any, error) {
+		return nil, errors.New("container not found")
+	})
+
+	m.Wait(ctx)
+
+	got, err := m.Get(ctx, ok.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != StatusSucceeded || string(got.Result) != `{"files":3}` || got.DoneAt.IsZero() {
+		t.Errorf("Unexpected succeeded operation: %+v", got)
+	}
+
+	got, _ = m.Get(ctx, failed.ID)
+	if got.Status != StatusFailed || got.Error != "container not found" {
+		t.Errorf("Unexpected failed operation: %+v", got)
+	}
+}
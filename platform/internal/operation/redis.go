@@ -0,0 +1,53 @@
+package operation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrNotFound = errors.New("operation not found")
+
+// operationTTL 操作记录在 Redis 中的保留时长
+const operationTTL = 24 * time.Hour
+
+var _ Store = (*RedisStore)(nil)
+
+type RedisStore struct {
+	redis redis.Cmdable
+}
+
+func NewRedisStore(redis redis.Cmdable) *RedisStore {
+	return &RedisStore{redis: redis}
+}
+
+func (s *RedisStore) Save(ctx context.Context, op *Operation) error {
+	b, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+	return s.redis.Set(ctx, operationKey(op.ID), b, operationTTL).Err()
+}
+
+func (s *RedisStore) Get(ctx context.Context, id string) (*Operation, error) {
+	b, err := s.redis.Get(ctx, operationKey(id)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	var op Operation
+	if err := json.Unmarshal(b, &op); err != nil {
+		return nil, err
+	}
+	return &op, nil
+}
+
+func operationKey(id string) string {
+	return "operation:" + id
+}
@@ -0,0 +1,43 @@
+package operation
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Done 操作是否已结束
+func (s Status) Done() bool {
+	return s == StatusSucceeded || s == StatusFailed
+}
+
+// Kind 异步操作类型
+type Kind string
+
+const (
+	KindTerminate Kind = "terminate"
+	KindStopAgent Kind = "stop_agent"
+	KindRestart   Kind = "restart"
+	KindSync      Kind = "sync"
+)
+
+// Operation 一个异步执行的长时操作，客户端通过 GET /api/v1/operations/:id 轮询结果
+type Operation struct {
+	ID        string          `json:"id"`
+	Kind      Kind            `json:"kind"`
+	SessionID string          `json:"session_id,omitempty"`
+	Status    Status          `json:"status"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	DoneAt    time.Time       `json:"done_at,omitempty"`
+}
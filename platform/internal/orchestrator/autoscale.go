@@ -0,0 +1,122 @@
+package orchestrator
+
+import (
+	"sync"
+	"time"
+)
+
+// AutoscaleConfig 按获取压力在 [MinIdle, MaxIdle] 之间调整预热池的目标空闲数。
+// 未启用时目标空闲数固定为 PoolConfig.MinIdle
+type AutoscaleConfig struct {
+	Enabled bool
+	// MinIdle / MaxIdle 目标空闲数的上下限，MaxIdle 为 0 或超过 MaxBurst 时取 MaxBurst
+	MinIdle int
+	MaxIdle int
+	// Interval 统计窗口，每个窗口结束时决定一次扩缩容，默认 1 分钟
+	Interval time.Duration
+	// WaitThreshold 窗口内平均获取耗时超过该值时扩容，默认 500ms
+	WaitThreshold time.Duration
+	// ScaleDownDelay 距上次扩容至少经过该时间、且窗口内没有压力时才缩容，默认 10 分钟
+	ScaleDownDelay time.Duration
+}
+
+// 扩缩容决策的方向，作为指标标签
+const (
+	scaleUp   = "up"
+	scaleDown = "down"
+)
+
+// autoscaler 统计一个窗口内的获取情况并决定目标空闲数
+type autoscaler struct {
+	cfg AutoscaleConfig
+
+	mu       sync.Mutex
+	target   int
+	acquires int
+	// misses 没有空闲容器、需要现场创建的获取次数
+	misses    int
+	waitTotal time.Duration
+	// maxQueued 窗口内同时排队等待名额的最大请求数
+	maxQueued   int
+	lastScaleUp time.Time
+}
+
+func newAutoscaler(cfg AutoscaleConfig, maxBurst, initial int) *autoscaler {
+	if cfg.MaxIdle <= 0 || cfg.MaxIdle > maxBurst {
+		cfg.MaxIdle = maxBurst
+	}
+	if cfg.MinIdle < 0 {
+		cfg.MinIdle = 0
+	}
+	if cfg.MinIdle > cfg.MaxIdle {
+		cfg.MinIdle = cfg.MaxIdle
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	if cfg.WaitThreshold <= 0 {
+		cfg.WaitThreshold = 500 * time.Millisecond
+	}
+	if cfg.ScaleDownDelay <= 0 {
+		cfg.ScaleDownDelay = 10 * time.Minute
+	}
+	return &autoscaler{cfg: cfg, target: min(max(initial, cfg.MinIdle), cfg.MaxIdle)}
+}
+
+// observe 记录一次获取的耗时以及是否命中空闲容器，a 为 nil 时忽略
+func (a *autoscaler) observe(wait time.Duration, hit bool) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.acquires++
+	a.waitTotal += wait
+	if !hit {
+		a.misses++
+	}
+}
+
+// queued 记录当前排队等待名额的请求数，a 为 nil 时忽略
+func (a *autoscaler) queued(n int) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.maxQueued = max(a.maxQueued, n)
+}
+
+// decide 结束当前窗口并返回新的目标空闲数，direction 为空表示不调整。
+// 出现未命中、排队或平均耗时超过阈值时按缺口扩容；
+// 窗口内没有压力、获取次数少于目标值且距上次扩容足够久时每次缩容 1 个
+func (a *autoscaler) decide(now time.Time) (target int, direction string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	acquires, misses, queued := a.acquires, a.misses, a.maxQueued
+	var avgWait time.Duration
+	if acquires > 0 {
+		avgWait = a.waitTotal / time.Duration(acquires)
+	}
+	a.acquires, a.misses, a.waitTotal, a.maxQueued = 0, 0, 0, 0
+
+	pressured := misses > 0 || queued > 0 || avgWait > a.cfg.WaitThreshold
+	switch {
+	case pressured && a.target < a.cfg.MaxIdle:
+		a.target = min(a.target+max(misses, queued, 1), a.cfg.MaxIdle)
+		a.lastScaleUp = now
+		return a.target, scaleUp
+	case !pressured && acquires < a.target && a.target > a.cfg.MinIdle &&
+		now.Sub(a.lastScaleUp) >= a.cfg.ScaleDownDelay:
+		a.target--
+		return a.target, scaleDown
+	}
+	return a.target, ""
+}
+
+func (a *autoscaler) current() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.target
+}
@@ -0,0 +1,68 @@
+package orchestrator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAutoscalerScalesUpUnderPressure(t *testing.T) {
+	a := newAutoscaler(AutoscaleConfig{MinIdle: 1, MaxIdle: 6}, 10, 2)
+	now := time.Now()
+
+	a.observe(50*time.Millisecond, true)
+	a.observe(3*time.Second, false)
+	a.observe(3*time.Second, false)
+	if target, dir := a.decide(now); target != 4 || dir != scaleUp {
+		t.Fatalf("decide after misses = %d, %q; want 4, up", target, dir)
+	}
+
+	a.queued(5)
+	if target, dir := a.decide(now); target != 6 || dir != scaleUp {
+		t.Fatalf("decide with queue = %d, %q; want capped at 6, up", target, dir)
+	}
+
+	a.queued(1)
+	if target, dir := a.decide(now); target != 6 || dir != "" {
+		t.Fatalf("decide at ceiling = %d, %q; want 6, no change", target, dir)
+	}
+}
+
+func TestAutoscalerScalesDownAfterDelay(t *testing.T) {
+	a := newAutoscaler(AutoscaleConfig{MinIdle: 1, MaxIdle: 4, ScaleDownDelay: 10 * time.Minute}, 10, 1)
+	now := time.Now()
+
+	a.observe(2*time.Second, false)
+	if target, _ := a.decide(now); target != 2 {
+		t.Fatalf("target = %d, want 2", target)
+	}
+
+	// 刚扩容过，即使空闲也不缩容
+	if target, dir := a.decide(now.Add(time.Minute)); target != 2 || dir != "" {
+		t.Fatalf("decide before delay = %d, %q; want 2, no change", target, dir)
+	}
+
+	// 获取次数仍达到目标值时不缩容
+	a.observe(10*time.Millisecond, true)
+	a.observe(10*time.Millisecond, true)
+	if target, dir := a.decide(now.Add(11 * time.Minute)); target != 2 || dir != "" {
+		t.Fatalf("decide with demand = %d, %q; want 2, no change", target, dir)
+	}
+
+	if target, dir := a.decide(now.Add(12 * time.Minute)); target != 1 || dir != scaleDown {
+		t.Fatalf("decide idle = %d, %q; want 1, down", target, dir)
+	}
+	if target, dir := a.decide(now.Add(13 * time.Minute)); target != 1 || dir != "" {
+		t.Fatalf("decide at floor = %d, %q; want 1, no change", target, dir)
+	}
+}
+
+func TestAutoscalerSlowAcquireScalesUp(t *testing.T) {
+	a := newAutoscaler(AutoscaleConfig{MaxIdle: 20, WaitThreshold: time.Second}, 5, 0)
+	if a.cfg.MaxIdle != 5 {
+		t.Fatalf("MaxIdle = %d, want clamped to MaxBurst 5", a.cfg.MaxIdle)
+	}
+	a.observe(2*time.Second, true)
+	if target, dir := a.decide(time.Now()); target != 1 || dir != scaleUp {
+		t.Fatalf("decide slow = %d, %q; want 1, up", target, dir)
+	}
+}
@@ -0,0 +1,106 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"platform/internal/eventbus"
+	"platform/internal/monitor"
+	"platform/internal/notify"
+)
+
+const (
+	// DefaultCooldownBase 第一次进入冷却的时长，之后每次连续进入冷却翻倍
+	DefaultCooldownBase = time.Minute
+	// DefaultCooldownMax 冷却时长的上限
+	DefaultCooldownMax = 30 * time.Minute
+	// cooldownFailures 一轮补充中失败多少次后进入冷却
+	cooldownFailures = 3
+)
+
+// cooldownDuration 第 n 次（从 1 开始）连续进入冷却的时长
+func cooldownDuration(n int, base, limit time.Duration) time.Duration {
+	if base <= 0 {
+		base = DefaultCooldownBase
+	}
+	if limit <= 0 {
+		limit = DefaultCooldownMax
+	}
+	d := base
+	for i := 1; i < n && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+// enterCooldown 补充连续失败后暂停补充，时长按连续进入冷却的次数指数增长，
+// 直到成功创建预热容器。进入冷却时告警并在 PlatformChannel 上发布 pool.degraded
+func (p *Pool) enterCooldown(err error) {
+	p.mu.Lock()
+	p.cooldowns++
+	n := p.cooldowns
+	d := cooldownDuration(n, p.config.CooldownBase, p.config.CooldownMax)
+	p.cooldownUntil = time.Now().Add(d)
+	until := p.cooldownUntil
+	p.mu.Unlock()
+	p.degraded.Store(true)
+	monitor.PoolDegraded.WithLabelValues(p.name).Set(1)
+
+	p.logger.Warn("Warm pool entered cooldown", "cooldown", d, "cooldowns", n, "error", err)
+	p.config.Notifier.Notify(notify.Alert{
+		Kind:     notify.KindPoolCooldown,
+		Severity: notify.SeverityCritical,
+		Title:    "Warm pool entered cooldown",
+		Message:  fmt.Sprintf("Replenishing the warm pool failed repeatedly; new warm containers are paused for %s.", d),
+		Fields: map[string]string{
+			"image":      p.warmImage(),
+			"cooldowns":  fmt.Sprint(n),
+			"last_error": err.Error(),
+		},
+	})
+	p.publish(eventbus.EventPoolDegraded, map[string]any{
+		"pool":             p.name,
+		"image":            p.warmImage(),
+		"cooldowns":        n,
+		"cooldown_seconds": int(d.Seconds()),
+		"cooldown_until":   until,
+		"error":            err.Error(),
+	})
+}
+
+// recoverCooldown 冷却后成功创建了预热容器，清除冷却次数并发布 pool.recovered
+func (p *Pool) recoverCooldown() {
+	if !p.degraded.Swap(false) {
+		return
+	}
+	p.mu.Lock()
+	n := p.cooldowns
+	p.cooldowns = 0
+	p.mu.Unlock()
+	monitor.PoolDegraded.WithLabelValues(p.name).Set(0)
+
+	p.logger.Info("Warm pool recovered from cooldown", "cooldowns", n)
+	p.publish(eventbus.EventPoolRecovered, map[string]any{
+		"pool":      p.name,
+		"image":     p.warmImage(),
+		"cooldowns": n,
+	})
+}
+
+// publish 在 PlatformChannel 上发布预热池事件，未配置 Events 时不发布
+func (p *Pool) publish(typ eventbus.EventType, payload any) {
+	if p.config.Events == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.config.Events.Publish(ctx, eventbus.PlatformChannel, eventbus.Event{
+		Type:      typ,
+		SessionID: eventbus.PlatformChannel,
+		Payload:   payload,
+		Timestamp: time.Now(),
+	}); err != nil {
+		p.logger.Warn("Failed to publish pool event", "type", typ, "error", err)
+	}
+}
@@ -0,0 +1,78 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"platform/internal/eventbus"
+)
+
+type recordingBus struct {
+	events []eventbus.Event
+}
+
+func (b *recordingBus) Publish(ctx context.Context, sessionID string, event eventbus.Event) error {
+	b.events = append(b.events, event)
+	return nil
+}
+
+func (b *recordingBus) Subscribe(ctx context.Context, sessionID string) (<-chan eventbus.Event, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestCooldownDuration(t *testing.T) {
+	cases := []struct {
+		n    int
+		want time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{6, 30 * time.Minute},
+		{40, 30 * time.Minute},
+	}
+	for _, tc := range cases {
+		if got := cooldownDuration(tc.n, 0, 0); got != tc.want {
+			t.Errorf("cooldownDuration(%d) = %s, want %s", tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestCooldownDegradedAndRecovered(t *testing.T) {
+	bus := &recordingBus{}
+	p := drainTestPool()
+	p.config.CooldownBase = time.Second
+	p.config.Events = bus
+
+	p.enterCooldown(errors.New("pull failed"))
+	first := time.Until(p.cooldownUntil)
+	p.enterCooldown(errors.New("pull failed"))
+	if second := time.Until(p.cooldownUntil); second <= first {
+		t.Errorf("second cooldown %s not longer than first %s", second, first)
+	}
+	if !p.degraded.Load() || p.cooldowns != 2 {
+		t.Fatalf("degraded = %v, cooldowns = %d", p.degraded.Load(), p.cooldowns)
+	}
+
+	p.recoverCooldown()
+	p.recoverCooldown()
+	if p.degraded.Load() || p.cooldowns != 0 {
+		t.Errorf("after recovery degraded = %v, cooldowns = %d", p.degraded.Load(), p.cooldowns)
+	}
+
+	var types []eventbus.EventType
+	for _, e := range bus.events {
+		types = append(types, e.Type)
+	}
+	want := []eventbus.EventType{eventbus.EventPoolDegraded, eventbus.EventPoolDegraded, eventbus.EventPoolRecovered}
+	if len(types) != len(want) {
+		t.Fatalf("events = %v, want %v", types, want)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Errorf("events = %v, want %v", types, want)
+		}
+	}
+}
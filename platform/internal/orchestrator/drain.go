@@ -0,0 +1,169 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"platform/internal/errreport"
+	"platform/internal/sandbox"
+)
+
+// ErrPoolDraining 预热池正在关闭，不再分配容器
+var ErrPoolDraining = errors.New("pool is shutting down")
+
+const (
+	// drainPollInterval 关闭时检查租出容器是否已全部归还的间隔
+	drainPollInterval = 200 * time.Millisecond
+	// drainForceTimeout 等待超时后强制终止剩余 session 并删除其容器的时限
+	drainForceTimeout = 30 * time.Second
+)
+
+// DrainTimeoutFunc 关闭预热池时等待超时仍未归还的容器，leased 的 key 为容器 ID，value 为租用它的 session ID。
+// 实现应终止这些 session，终止流程会把容器归还预热池
+type DrainTimeoutFunc func(ctx context.Context, leased map[string]string)
+
+// OnDrainTimeout 设置默认池和各预热池关闭等待超时后的处理，未设置时剩余的容器保留在 Docker 中
+func (p *Pool) OnDrainTimeout(fn DrainTimeoutFunc) {
+	p.onDrainTimeout = fn
+	for _, wp := range p.profiles {
+		wp.onDrainTimeout = fn
+	}
+}
+
+// trackLease 记录租出的容器，关闭时等待它们归还
+func (p *Pool) trackLease(c *sandbox.Container) {
+	p.mu.Lock()
+	p.leased[c.ID] = c
+	p.mu.Unlock()
+}
+
+func (p *Pool) untrackLease(id string) {
+	p.mu.Lock()
+	delete(p.leased, id)
+	delete(p.deadLeases, id)
+	p.mu.Unlock()
+}
+
+// leasedContainer 按 ID 查找默认池和各预热池中租出的容器，没有时返回 nil
+func (p *Pool) leasedContainer(id string) *sandbox.Container {
+	if c := p.ownLeased(id); c != nil {
+		return c
+	}
+	for _, wp := range p.profiles {
+		if c := wp.ownLeased(id); c != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+func (p *Pool) ownLeased(id string) *sandbox.Container {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.leased[id]
+}
+
+// Leased 返回当前租出的容器及租用它们的 session，包括各预热池
+func (p *Pool) Leased() map[string]string {
+	leased := p.leasedSessions()
+	for _, wp := range p.profiles {
+		for id, sessionID := range wp.leasedSessions() {
+			leased[id] = sessionID
+		}
+	}
+	return leased
+}
+
+func (p *Pool) leasedSessions() map[string]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	leased := make(map[string]string, len(p.leased))
+	for id, c := range p.leased {
+		leased[id] = c.Config.SessionID
+	}
+	return leased
+}
+
+// goCleanup 异步执行容器清理，关闭时等待其完成，避免进程退出时遗留容器
+func (p *Pool) goCleanup(tags errreport.Tags, fn func()) {
+	p.cleanup.Go(func() {
+		defer errreport.Recover(context.Background(), p.logger, "pool", tags)
+		fn()
+	})
+}
+
+// Shutdown 关闭默认池和各预热池：拒绝新的 Acquire，删除空闲容器，并在 ctx 结束前等待租出的容器归还。
+// 等待超时后把剩余的容器交给 OnDrainTimeout 设置的处理函数强制终止
+func (p *Pool) Shutdown(ctx context.Context, _ *sandbox.Container) {
+	var wg sync.WaitGroup
+	for _, wp := range p.profiles {
+		wg.Go(func() { wp.drain(ctx) })
+	}
+	p.drain(ctx)
+	wg.Wait()
+}
+
+func (p *Pool) drain(ctx context.Context) {
+	p.mu.Lock()
+	p.draining.Store(true)
+	// 关闭 stopCh 以停止 worker，并让排队的 Acquire 返回
+	select {
+	case <-p.stopCh:
+	default:
+		close(p.stopCh)
+	}
+	idle := p.idleContainers
+	p.idleContainers = nil
+	p.mu.Unlock()
+
+	for _, c := range idle {
+		p.goCleanup(errreport.Tags{"container_id": c.ID}, func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			c.Stop(ctx, sandbox.CurrentRemovalPolicy().StopTimeoutSeconds())
+			c.Remove(ctx)
+		})
+	}
+
+	if leased := p.waitLeased(ctx); len(leased) > 0 {
+		p.logger.Warn("Leased containers not released before shutdown deadline", "count", len(leased))
+		if p.onDrainTimeout != nil {
+			forceCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), drainForceTimeout)
+			defer cancel()
+			p.onDrainTimeout(forceCtx, leased)
+			ctx = forceCtx
+		}
+	}
+
+	// 等待归还后的异步删除完成
+	done := make(chan struct{})
+	go func() {
+		p.cleanup.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		p.logger.Info("Pool drained")
+	case <-ctx.Done():
+		p.logger.Warn("Pool shutdown deadline exceeded, some containers may be left behind", "leased", len(p.leasedSessions()))
+	}
+}
+
+// waitLeased 等待租出的容器全部归还，ctx 结束时返回仍未归还的容器
+func (p *Pool) waitLeased(ctx context.Context) map[string]string {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		leased := p.leasedSessions()
+		if len(leased) == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return leased
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"platform/internal/sandbox"
+)
+
+func drainTestPool() *Pool {
+	return &Pool{
+		name:    "test",
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		stopCh:  make(chan struct{}),
+		waiters: newCapacityQueue(&DefaultScheduler{BatchMaxWait: time.Minute}),
+		leased:  make(map[string]*sandbox.Container),
+	}
+}
+
+func leasedContainer(id, sessionID string) *sandbox.Container {
+	return &sandbox.Container{ID: id, Config: sandbox.ContainerConfig{SessionID: sessionID}}
+}
+
+func TestShutdownWaitsForLeasedContainers(t *testing.T) {
+	p := drainTestPool()
+	p.trackLease(leasedContainer("c1", "s1"))
+	p.OnDrainTimeout(func(ctx context.Context, leased map[string]string) {
+		t.Errorf("unexpected drain timeout with %v", leased)
+	})
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		p.untrackLease("c1")
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	p.Shutdown(ctx, nil)
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("Shutdown returned after %s, want it to wait for the release", elapsed)
+	}
+
+	if _, err := p.AcquireWithPriority(context.Background(), "", PriorityInteractive); !errors.Is(err, ErrPoolDraining) {
+		t.Errorf("Acquire after Shutdown err = %v, want ErrPoolDraining", err)
+	}
+}
+
+func TestShutdownHandsOverLeasedOnTimeout(t *testing.T) {
+	p := drainTestPool()
+	p.trackLease(leasedContainer("c1", "s1"))
+	p.trackLease(leasedContainer("c2", "s2"))
+
+	var got map[string]string
+	p.OnDrainTimeout(func(ctx context.Context, leased map[string]string) {
+		if ctx.Err() != nil {
+			t.Error("drain timeout handler got an expired context")
+		}
+		got = leased
+		for id := range leased {
+			p.untrackLease(id)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	p.Shutdown(ctx, nil)
+
+	if len(got) != 2 || got["c1"] != "s1" || got["c2"] != "s2" {
+		t.Errorf("handler got %v, want c1=s1 c2=s2", got)
+	}
+	if leased := p.Leased(); len(leased) != 0 {
+		t.Errorf("Leased() = %v after forced termination", leased)
+	}
+}
@@ -0,0 +1,139 @@
+package orchestrator
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 调度策略名称，见 NewScheduler
+const (
+	SchedulerDefault   = "default"
+	SchedulerFairShare = "fair_share"
+)
+
+// NewScheduler 按名称创建调度策略，空串为 SchedulerDefault；base 为默认策略的参数，其他策略沿用它未覆盖的决策
+func NewScheduler(name string, base DefaultScheduler) (Scheduler, error) {
+	switch name {
+	case "", SchedulerDefault:
+		return &base, nil
+	case SchedulerFairShare:
+		return NewFairShareScheduler(base), nil
+	}
+	return nil, fmt.Errorf("invalid scheduler %q (expected %s or %s)", name, SchedulerDefault, SchedulerFairShare)
+}
+
+// LeaseObserver 可选接口，Scheduler 实现时预热池在容器租出和归还时通知它。
+// 只覆盖通过 Acquire 租出的容器，转移给其他 session 的容器仍记在原请求名下
+type LeaseObserver interface {
+	Leased(req Request, containerID string)
+	Released(containerID string)
+}
+
+// DefaultGrantTTL 分到名额后多久仍未租出容器（创建失败、请求放弃等）时不再计入租户的占用
+const DefaultGrantTTL = 2 * time.Minute
+
+var (
+	_ Scheduler     = (*FairShareScheduler)(nil)
+	_ LeaseObserver = (*FairShareScheduler)(nil)
+)
+
+// FairShareScheduler 按租户公平分配名额：名额用尽排队时，归还的名额分给当前占用最少的租户，
+// 占用为已租出的容器数加上已分到名额、正在取容器的请求数，占用相同时先到的租户优先；
+// 同一租户的请求之间沿用 DefaultScheduler 的优先级规则。
+// 一个租户突发大量创建时，只有排队前已取得的名额归它，之后其他租户的请求与它交替获得名额。其余决策同 DefaultScheduler
+type FairShareScheduler struct {
+	DefaultScheduler
+	// GrantTTL 0 时使用 DefaultGrantTTL
+	GrantTTL time.Duration
+
+	mu sync.Mutex
+	// leases 容器 ID 到租户；granted 按 Request.Ticket 记录经排队分到名额、尚未租出容器的请求
+	leases  map[string]string
+	granted map[uint64]grant
+}
+
+// grant 一个经排队分到的名额
+type grant struct {
+	tenant string
+	at     time.Time
+}
+
+func NewFairShareScheduler(base DefaultScheduler) *FairShareScheduler {
+	return &FairShareScheduler{
+		DefaultScheduler: base,
+		leases:           make(map[string]string),
+		granted:          make(map[uint64]grant),
+	}
+}
+
+func (s *FairShareScheduler) Next(waiting []*Waiter, now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireGrants(now)
+
+	// waiting 按到达顺序排列，占用相同时取最早到达的租户
+	counts := s.outstanding()
+	tenant, least := "", -1
+	for _, w := range waiting {
+		if n := counts[w.Request.Tenant]; least < 0 || n < least {
+			tenant, least = w.Request.Tenant, n
+		}
+	}
+
+	var idx []int
+	var mine []*Waiter
+	for i, w := range waiting {
+		if w.Request.Tenant == tenant {
+			idx = append(idx, i)
+			mine = append(mine, w)
+		}
+	}
+	j := s.DefaultScheduler.Next(mine, now)
+	if j < 0 || j >= len(idx) {
+		j = 0
+	}
+	s.granted[mine[j].Request.Ticket] = grant{tenant: tenant, at: now}
+	return idx[j]
+}
+
+func (s *FairShareScheduler) Leased(req Request, containerID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leases[containerID] = req.Tenant
+	// 经排队分到的名额已转为租出的容器，不再单独计数；未排队的请求没有名额记录
+	if req.Ticket != 0 {
+		delete(s.granted, req.Ticket)
+	}
+}
+
+func (s *FairShareScheduler) Released(containerID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.leases, containerID)
+}
+
+// outstanding 返回各租户当前的占用，调用时持有 s.mu
+func (s *FairShareScheduler) outstanding() map[string]int {
+	out := make(map[string]int, len(s.granted))
+	for _, tenant := range s.leases {
+		out[tenant]++
+	}
+	for _, g := range s.granted {
+		out[g.tenant]++
+	}
+	return out
+}
+
+// expireGrants 丢弃超过 GrantTTL 仍未租出容器的名额记录，调用时持有 s.mu
+func (s *FairShareScheduler) expireGrants(now time.Time) {
+	ttl := s.GrantTTL
+	if ttl <= 0 {
+		ttl = DefaultGrantTTL
+	}
+	for ticket, g := range s.granted {
+		if now.Sub(g.at) > ttl {
+			delete(s.granted, ticket)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package orchestrator
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFairShareInterleavesTenants(t *testing.T) {
+	s := NewFairShareScheduler(DefaultScheduler{})
+	// a 的突发请求在排队前已租出 1 个容器，其余 5 个排在 b 之前
+	s.Leased(Request{Tenant: "a"}, "a0")
+
+	now := time.Now()
+	var waiting []*Waiter
+	for range 5 {
+		waiting = append(waiting, &Waiter{Request: Request{Tenant: "a", Ticket: uint64(len(waiting) + 1)}, Enqueued: now})
+	}
+	for range 3 {
+		waiting = append(waiting, &Waiter{Request: Request{Tenant: "b", Ticket: uint64(len(waiting) + 1)}, Enqueued: now.Add(time.Second)})
+	}
+
+	var order []string
+	for len(waiting) > 0 {
+		i := s.Next(waiting, now)
+		w := waiting[i]
+		waiting = slices.Delete(waiting, i, i+1)
+		order = append(order, w.Request.Tenant)
+		s.Leased(w.Request, fmt.Sprintf("c%d", len(order)))
+	}
+	// 占用相同时先到的 a 优先，b 没有排队请求后剩余名额都给 a
+	want := []string{"b", "a", "b", "a", "b", "a", "a", "a"}
+	if !slices.Equal(order, want) {
+		t.Fatalf("grant order = %v, want %v", order, want)
+	}
+
+	s.Released("a0")
+	if got := s.outstanding(); got["a"] != 5 || got["b"] != 3 {
+		t.Errorf("outstanding = %v, want a=5 b=3", got)
+	}
+}
+
+func TestFairShareGrantExpires(t *testing.T) {
+	s := NewFairShareScheduler(DefaultScheduler{})
+	s.GrantTTL = time.Minute
+	now := time.Now()
+	waiting := []*Waiter{
+		{Request: Request{Tenant: "a", Ticket: 1}, Enqueued: now},
+		{Request: Request{Tenant: "b", Ticket: 2}, Enqueued: now},
+	}
+
+	if i := s.Next(waiting, now); i != 0 {
+		t.Fatalf("first grant = %d, want a", i)
+	}
+	// a 分到的名额尚未租出容器，仍计入占用
+	if i := s.Next(waiting, now.Add(30*time.Second)); i != 1 {
+		t.Fatalf("grant before expiry = %d, want b", i)
+	}
+	// a 的名额过期后不再计入，b 的名额仍在有效期内
+	if i := s.Next(waiting, now.Add(90*time.Second)); i != 0 {
+		t.Fatalf("grant after expiry = %d, want a", i)
+	}
+}
+
+func TestFairShareUnqueuedLeaseKeepsGrant(t *testing.T) {
+	s := NewFairShareScheduler(DefaultScheduler{})
+	now := time.Now()
+	queued := &Waiter{Request: Request{Tenant: "a", Ticket: 7}, Enqueued: now}
+	s.Next([]*Waiter{queued}, now)
+
+	// 未排队直接取得名额的请求不能抵消仍在取容器的排队名额
+	s.Leased(Request{Tenant: "a"}, "fast")
+	if got := s.outstanding()["a"]; got != 2 {
+		t.Fatalf("outstanding after unqueued lease = %d, want 2", got)
+	}
+	s.Leased(queued.Request, "queued")
+	if got := s.outstanding()["a"]; got != 2 {
+		t.Errorf("outstanding after queued lease = %d, want 2", got)
+	}
+}
+
+func TestNewScheduler(t *testing.T) {
+	for name, want := range map[string]string{
+		"":                 "*orchestrator.DefaultScheduler",
+		SchedulerDefault:   "*orchestrator.DefaultScheduler",
+		SchedulerFairShare: "*orchestrator.FairShareScheduler",
+	} {
+		s, err := NewScheduler(name, DefaultScheduler{})
+		if err != nil {
+			t.Fatalf("NewScheduler(%q): %v", name, err)
+		}
+		if got := fmt.Sprintf("%T", s); got != want {
+			t.Errorf("NewScheduler(%q) = %s, want %s", name, got, want)
+		}
+	}
+	if _, err := NewScheduler("binpack", DefaultScheduler{}); err == nil {
+		t.Error("expected error for unknown scheduler")
+	}
+}
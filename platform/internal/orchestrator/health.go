@@ -0,0 +1,126 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"platform/internal/errreport"
+	"platform/internal/monitor"
+	"platform/internal/sandbox"
+)
+
+const (
+	// DefaultAgentHealthTimeout 单次 agent 健康检查的默认超时
+	DefaultAgentHealthTimeout = time.Second
+	// DefaultAgentHealthFailures agent 连续健康检查失败多少次后移出预热池
+	DefaultAgentHealthFailures = 3
+	// agentHealthService agent 在 grpc.health.v1 中登记的服务名
+	agentHealthService = "agent.AgentService"
+	agentPort          = "50051"
+)
+
+// checkAgentHealth 通过 grpc.health.v1 检查 target 上的 agent 能否处理请求，只接受 TCP 连接而无法处理 RPC 的 agent 视为不健康。
+// 没有注册健康服务的旧版 agent 能返回 Unimplemented，说明 gRPC 服务可用，同样视为健康
+func (p *Pool) checkAgentHealth(ctx context.Context, target string) error {
+	timeout := p.config.AgentHealthTimeout
+	if timeout <= 0 {
+		timeout = DefaultAgentHealthTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: agentHealthService})
+	if status.Code(err) == codes.Unimplemented {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("agent status %s", resp.GetStatus())
+	}
+	return nil
+}
+
+// agentInWarmContainer 预热容器是否在启动时就运行 agent；默认的保活命令不启动 agent，不做健康检查
+func (p *Pool) agentInWarmContainer() bool {
+	return len(p.config.WarmCmd) > 0 || len(p.config.WarmEntrypoint) > 0
+}
+
+// probeAgents 检查空闲容器中 agent 的 gRPC 健康状态，连续失败 AgentHealthFailures 次的容器移出预热池并删除。
+// 检查期间不持有锁，检查时已被取走的容器不受影响
+func (p *Pool) probeAgents() {
+	if p.config.DisableHealthCheck || !p.agentInWarmContainer() {
+		return
+	}
+	threshold := p.config.AgentHealthFailures
+	if threshold <= 0 {
+		threshold = DefaultAgentHealthFailures
+	}
+
+	p.mu.Lock()
+	idle := append([]*sandbox.Container(nil), p.idleContainers...)
+	p.mu.Unlock()
+
+	failed := make(map[string]error)
+	for _, c := range idle {
+		if c.IP == "" {
+			failed[c.ID] = fmt.Errorf("container has no IP")
+		} else if err := p.checkAgentHealth(context.Background(), net.JoinHostPort(c.IP, agentPort)); err != nil {
+			failed[c.ID] = err
+		}
+	}
+
+	p.mu.Lock()
+	if p.healthFailures == nil {
+		p.healthFailures = make(map[string]int)
+	}
+	var evicted []*sandbox.Container
+	alive := p.idleContainers[:0]
+	for _, c := range p.idleContainers {
+		err, ok := failed[c.ID]
+		if !ok {
+			delete(p.healthFailures, c.ID)
+			alive = append(alive, c)
+			continue
+		}
+		p.healthFailures[c.ID]++
+		n := p.healthFailures[c.ID]
+		if n < threshold {
+			p.logger.Warn("Agent health check failed", "id", c.ID, "failures", n, "error", err)
+			alive = append(alive, c)
+			continue
+		}
+		p.logger.Warn("Removing container with unhealthy agent from pool", "id", c.ID, "failures", n, "error", err)
+		delete(p.healthFailures, c.ID)
+		evicted = append(evicted, c)
+	}
+	p.idleContainers = alive
+	p.managedCount -= len(evicted)
+	monitor.PoolIdleCount.WithLabelValues(p.name).Set(float64(len(p.idleContainers)))
+	p.mu.Unlock()
+
+	// 空闲容器不占用名额，移除后不归还
+	for _, c := range evicted {
+		p.goCleanup(errreport.Tags{"container_id": c.ID}, func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			c.Stop(ctx, sandbox.CurrentRemovalPolicy().StopTimeoutSeconds())
+			c.Remove(ctx)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package orchestrator
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// startHealthServer 启动只注册了健康服务的 gRPC server，返回其地址
+func startHealthServer(t *testing.T, register bool) (string, *health.Server) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	if register {
+		healthpb.RegisterHealthServer(srv, hs)
+	}
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String(), hs
+}
+
+func TestCheckAgentHealth(t *testing.T) {
+	p := drainTestPool()
+	ctx := context.Background()
+
+	addr, hs := startHealthServer(t, true)
+	hs.SetServingStatus(agentHealthService, healthpb.HealthCheckResponse_SERVING)
+	if err := p.checkAgentHealth(ctx, addr); err != nil {
+		t.Errorf("serving agent reported unhealthy: %v", err)
+	}
+	hs.SetServingStatus(agentHealthService, healthpb.HealthCheckResponse_NOT_SERVING)
+	if err := p.checkAgentHealth(ctx, addr); err == nil {
+		t.Error("not serving agent reported healthy")
+	}
+
+	// 没有注册健康服务的 agent 返回 Unimplemented
+	legacy, _ := startHealthServer(t, false)
+	if err := p.checkAgentHealth(ctx, legacy); err != nil {
+		t.Errorf("agent without health service reported unhealthy: %v", err)
+	}
+
+	// 只接受 TCP 连接、不说 gRPC 的服务
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	if err := p.checkAgentHealth(ctx, lis.Addr().String()); err == nil {
+		t.Error("plain TCP listener reported healthy")
+	}
+}
@@ -6,10 +6,21 @@ import (
 )
 
 type IPool interface {
-	Acquire(ctx context.Context) (*sandbox.Container, error)
+	// Acquire 从 selector（预热池名称或镜像，为空时为默认池）对应的预热池获取容器
+	Acquire(ctx context.Context, selector string) (*sandbox.Container, error)
+	// AcquireWithPriority 同 Acquire，名额用尽时交互式请求先于批处理请求分到名额
+	AcquireWithPriority(ctx context.Context, selector string, priority Priority) (*sandbox.Container, error)
+	// HasWarmPool selector 是否对应一个预热池
+	HasWarmPool(selector string) bool
+	// WarmPoolImage 返回 selector 对应预热池的镜像，用于改用冷启动时创建相同镜像的容器
+	WarmPoolImage(selector string) (string, bool)
 	Release(ctx context.Context, c *sandbox.Container)
+	// ReleaseContainer 按容器 ID 归还已租出的预热容器
+	ReleaseContainer(ctx context.Context, containerID string) error
 	Shutdown(ctx context.Context, c *sandbox.Container)
 	CreateColdContainer(ctx context.Context, opts ContainerOptions) (*sandbox.Container, error)
+	// Transfer 将已租出的容器转给另一个 session，不归还名额也不重建容器
+	Transfer(ctx context.Context, c *sandbox.Container, sessionID string) error
 }
 
 type ContainerStrategy interface {
@@ -0,0 +1,76 @@
+package orchestrator
+
+import (
+	"context"
+	"time"
+
+	"github.com/containerd/errdefs"
+
+	"platform/internal/errreport"
+)
+
+// LeaseDeadFunc 租出的容器已停止或被删除时调用，state 为容器状态，删除时为 "missing"。
+// 挂起、休眠等流程也会停止租出的容器，实现需要按 session 状态判断是否为意外退出
+type LeaseDeadFunc func(ctx context.Context, sessionID, containerID, state string)
+
+// OnLeaseDead 设置默认池和各预热池发现租出的容器不再运行时的处理，未设置时不检查租出的容器
+func (p *Pool) OnLeaseDead(fn LeaseDeadFunc) {
+	p.onLeaseDead = fn
+	for _, wp := range p.profiles {
+		wp.onLeaseDead = fn
+	}
+}
+
+// checkLeases 检查租出的容器是否仍在运行，对每个不再运行的容器只通知一次，直到它被归还或重新运行。
+// 查询 Docker 失败时不视为容器已停止
+func (p *Pool) checkLeases() {
+	if p.onLeaseDead == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	p.mu.Lock()
+	leased := make(map[string]string, len(p.leased))
+	for id, c := range p.leased {
+		leased[id] = c.Config.SessionID
+	}
+	p.mu.Unlock()
+
+	for id, sessionID := range leased {
+		state := ""
+		inspect, err := p.client.ContainerInspect(ctx, id)
+		switch {
+		case errdefs.IsNotFound(err):
+			state = "missing"
+		case err != nil:
+			p.logger.Warn("Failed to inspect leased container", "id", id, "error", err)
+			continue
+		case inspect.State != nil && !inspect.State.Running:
+			state = inspect.State.Status
+		}
+
+		p.mu.Lock()
+		_, stillLeased := p.leased[id]
+		notify := state != "" && stillLeased && !p.deadLeases[id]
+		if state == "" {
+			// 容器重新运行（如恢复挂起的 session）后再次停止时需要重新通知
+			delete(p.deadLeases, id)
+		} else if notify {
+			if p.deadLeases == nil {
+				p.deadLeases = make(map[string]bool)
+			}
+			p.deadLeases[id] = true
+		}
+		p.mu.Unlock()
+		if !notify {
+			continue
+		}
+
+		p.logger.Warn("Leased container is no longer running", "id", id, "session_id", sessionID, "state", state)
+		go func() {
+			defer errreport.Recover(context.Background(), p.logger, "pool", errreport.Tags{"container_id": id, "session_id": sessionID})
+			p.onLeaseDead(context.Background(), sessionID, id, state)
+		}()
+	}
+}
@@ -0,0 +1,97 @@
+package orchestrator
+
+import (
+	"context"
+	"time"
+
+	"platform/internal/errreport"
+	"platform/internal/monitor"
+	"platform/internal/sandbox"
+)
+
+// 预热容器被移除的原因
+const (
+	retireMaxAge  = "max_age"
+	retireIdleTTL = "idle_ttl"
+)
+
+// markIdle 记录容器放入空闲列表的时间，调用时持有 p.mu
+func (p *Pool) markIdle(c *sandbox.Container) {
+	if p.idleSince == nil {
+		p.idleSince = make(map[string]time.Time)
+	}
+	p.idleSince[c.ID] = time.Now()
+}
+
+// pastMaxAge 容器是否已超过 MaxContainerAge，创建时间未知的容器不算超过
+func (p *Pool) pastMaxAge(c *sandbox.Container, now time.Time) bool {
+	return p.config.MaxContainerAge > 0 && !c.CreatedAt.IsZero() && now.Sub(c.CreatedAt) >= p.config.MaxContainerAge
+}
+
+// retireExpired 移除超过 MaxContainerAge 和空闲超过 IdleTTL 的空闲容器。
+// 前者由 maintainPool 重建；后者说明目标空闲数高于实际需求，每移除一个就把目标空闲数下调一个
+func (p *Pool) retireExpired() {
+	if p.config.MaxContainerAge <= 0 && p.config.IdleTTL <= 0 {
+		return
+	}
+	now := time.Now()
+
+	p.mu.Lock()
+	if p.idleSince == nil {
+		p.idleSince = make(map[string]time.Time)
+	}
+	since := make(map[string]time.Time, len(p.idleContainers))
+	retired := make(map[*sandbox.Container]string)
+	alive := p.idleContainers[:0]
+	for _, c := range p.idleContainers {
+		idleAt, ok := p.idleSince[c.ID]
+		if !ok {
+			idleAt = now
+		}
+		switch {
+		case p.pastMaxAge(c, now):
+			retired[c] = retireMaxAge
+		case p.config.IdleTTL > 0 && now.Sub(idleAt) >= p.config.IdleTTL:
+			retired[c] = retireIdleTTL
+			p.idleShrink.Add(1)
+		default:
+			since[c.ID] = idleAt
+			alive = append(alive, c)
+		}
+	}
+	// 只保留仍在空闲列表中的容器，已被取走或移除的记录在这里清理
+	p.idleSince = since
+	p.idleContainers = alive
+	p.managedCount -= len(retired)
+	monitor.PoolIdleCount.WithLabelValues(p.name).Set(float64(len(p.idleContainers)))
+	p.mu.Unlock()
+
+	if len(retired) == 0 {
+		return
+	}
+	monitor.PoolTargetIdle.WithLabelValues(p.name).Set(float64(p.targetIdle()))
+	for c, reason := range retired {
+		p.logger.Info("Retiring idle container", "id", c.ID, "reason", reason, "age", now.Sub(c.CreatedAt).Round(time.Second))
+		monitor.PoolRetirements.WithLabelValues(p.name, reason).Inc()
+		// 空闲容器不占用名额，移除后不归还
+		p.goCleanup(errreport.Tags{"container_id": c.ID}, func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			c.Stop(ctx, sandbox.CurrentRemovalPolicy().StopTimeoutSeconds())
+			c.Remove(ctx)
+		})
+	}
+}
+
+// retireLeased 删除归还时已超过 MaxContainerAge 的容器而不回收，并归还它占用的名额
+func (p *Pool) retireLeased(ctx context.Context, c *sandbox.Container) {
+	p.mu.Lock()
+	p.managedCount--
+	p.mu.Unlock()
+	p.availableCh <- struct{}{}
+
+	p.logger.Info("Removing released container past max age", "id", c.ID, "age", time.Since(c.CreatedAt).Round(time.Second))
+	monitor.PoolRetirements.WithLabelValues(p.name, retireMaxAge).Inc()
+	c.Stop(ctx, 2)
+	c.Remove(ctx)
+}
@@ -0,0 +1,61 @@
+package orchestrator
+
+import (
+	"testing"
+	"time"
+
+	"platform/internal/sandbox"
+)
+
+func TestRetireExpired(t *testing.T) {
+	p := drainTestPool()
+	p.config.MinIdle = 3
+	p.config.MaxContainerAge = time.Hour
+	p.config.IdleTTL = 10 * time.Minute
+	p.scheduledMin.Store(-1)
+
+	now := time.Now()
+	old := &sandbox.Container{ID: "old", CreatedAt: now.Add(-2 * time.Hour)}
+	stale := &sandbox.Container{ID: "stale", CreatedAt: now.Add(-30 * time.Minute)}
+	fresh := &sandbox.Container{ID: "fresh", CreatedAt: now.Add(-30 * time.Minute)}
+	p.idleContainers = []*sandbox.Container{old, stale, fresh}
+	p.managedCount = 3
+	p.idleSince = map[string]time.Time{
+		"old":   now.Add(-time.Minute),
+		"stale": now.Add(-20 * time.Minute),
+		"fresh": now.Add(-time.Minute),
+	}
+
+	p.retireExpired()
+	p.cleanup.Wait()
+
+	if len(p.idleContainers) != 1 || p.idleContainers[0].ID != "fresh" {
+		t.Fatalf("idle containers = %v, want only fresh", p.idleContainers)
+	}
+	if p.managedCount != 1 {
+		t.Errorf("managedCount = %d, want 1", p.managedCount)
+	}
+	// 只有空闲超时的容器下调目标空闲数，超龄的容器会被重建
+	if got := p.targetIdle(); got != 2 {
+		t.Errorf("targetIdle = %d, want 2", got)
+	}
+}
+
+func TestRecycleRemovesContainerPastMaxAge(t *testing.T) {
+	p := drainTestPool()
+	p.config.MaxContainerAge = time.Hour
+	p.availableCh = make(chan struct{}, 1)
+	p.managedCount = 1
+	c := &sandbox.Container{ID: "c1", CreatedAt: time.Now().Add(-2 * time.Hour)}
+
+	p.recycle(c)
+
+	if len(p.idleContainers) != 0 || p.managedCount != 0 {
+		t.Errorf("idle = %d, managed = %d, want container removed", len(p.idleContainers), p.managedCount)
+	}
+	select {
+	case <-p.availableCh:
+	default:
+		t.Error("capacity token not returned")
+	}
+}
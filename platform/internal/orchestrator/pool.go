@@ -4,15 +4,18 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"net"
+	"platform/internal/errreport"
 	"platform/internal/monitor"
+	"platform/internal/notify"
+	"platform/internal/quota"
 	"platform/internal/sandbox"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/containerd/errdefs"
 	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
 )
 
@@ -28,9 +31,67 @@ type Pool struct {
 	managedCount   int // Pool 当前所有管理的容器数量（包括空闲和正在使用的）
 	cooldownUntil  time.Time
 	stopCh         chan struct{}
+	// bakedImage 烘焙成功后预热容器使用的镜像，为空时使用 config.WarmupImage
+	bakedImage string
+	// createFailures 连续创建失败的次数，成功后清零
+	createFailures atomic.Int32
+	// cooldowns 连续进入冷却的次数，决定下一次冷却的时长，成功创建预热容器后清零
+	cooldowns int
+	// degraded 进入冷却后为 true，直到成功创建预热容器
+	degraded atomic.Bool
+	// scaler 目标空闲数控制器，未启用自动扩缩容时为 nil
+	scaler *autoscaler
+	// queued 正在等待名额的 Acquire 数量
+	queued atomic.Int32
+	// name 预热池名称，默认池为 DefaultWarmPool
+	name string
+	// profiles 默认池之外的预热池，只在默认池上设置
+	profiles map[string]*Pool
+	// scheduledMin 当前预热窗口的最少空闲数，不在任何窗口内时为 -1
+	scheduledMin atomic.Int32
+	// waiters 名额用尽时排队的 Acquire，由 dispatchCapacity 按优先级分配名额
+	waiters *capacityQueue
+	// leased 租出且尚未归还的容器，关闭时等待它们归还
+	leased map[string]*sandbox.Container
+	// draining Shutdown 开始后为 true，不再分配容器
+	draining atomic.Bool
+	// drained 管理员 Drain 后为 true，不再分配和补充容器，Replenish 后恢复
+	drained atomic.Bool
+	// healthFailures 空闲容器中 agent 连续健康检查失败的次数
+	healthFailures map[string]int
+	// idleSince 空闲容器放入空闲列表的时间
+	idleSince map[string]time.Time
+	// idleShrink 因空闲超过 IdleTTL 而下调的目标空闲数，Acquire 成功后清零
+	idleShrink atomic.Int32
+	// cleanup 进行中的异步删除和回收
+	cleanup        sync.WaitGroup
+	onDrainTimeout DrainTimeoutFunc
+	onLeaseDead    LeaseDeadFunc
+	// deadLeases 已通知过不再运行的租出容器，归还后清除
+	deadLeases map[string]bool
 }
 
+// NewPool 创建默认预热池以及 cfg.WarmPools 中的各个预热池，返回的默认池按镜像把请求路由到对应的池
 func NewPool(client *client.Client, logger *slog.Logger, cfg PoolConfig) *Pool {
+	// 默认池和各预热池共用同一个 Scheduler，策略可以看到所有池的请求
+	if cfg.Scheduler == nil {
+		cfg.Scheduler = &DefaultScheduler{BatchMaxWait: cfg.BatchMaxWait, Flags: cfg.Flags}
+	}
+	profiles := make(map[string]*Pool, len(cfg.WarmPools))
+	for _, wp := range cfg.WarmPools {
+		sub := cfg
+		sub.WarmupImage = wp.Image
+		sub.MinIdle = wp.MinIdle
+		sub.MaxBurst = wp.MaxBurst
+		sub.WarmPools = nil
+		sub.BakeWarmImage = false
+		sub.Prewarm = nil
+		profiles[wp.Name] = newPool(client, logger, sub, wp.Name, nil)
+	}
+	return newPool(client, logger, cfg, DefaultWarmPool, profiles)
+}
+
+func newPool(client *client.Client, logger *slog.Logger, cfg PoolConfig, name string, profiles map[string]*Pool) *Pool {
 	if cfg.HealthCheckInterval == 0 {
 		cfg.HealthCheckInterval = 2 * time.Second
 	}
@@ -45,32 +106,49 @@ func NewPool(client *client.Client, logger *slog.Logger, cfg PoolConfig) *Pool {
 
 	p := &Pool{
 		client:         client,
-		logger:         logger,
+		logger:         logger.With("component", "pool", "warm_pool", name),
 		config:         cfg,
 		idleContainers: make([]*sandbox.Container, 0),
 		availableCh:    make(chan struct{}, cfg.MaxBurst),
 		stopCh:         make(chan struct{}),
+		name:           name,
+		profiles:       profiles,
+		waiters:        newCapacityQueue(cfg.Scheduler),
+		leased:         make(map[string]*sandbox.Container),
+		healthFailures: make(map[string]int),
+		idleSince:      make(map[string]time.Time),
 	}
 
+	if cfg.Autoscale.Enabled {
+		p.scaler = newAutoscaler(cfg.Autoscale, cfg.MaxBurst, cfg.MinIdle)
+	}
+	p.scheduledMin.Store(-1)
+	p.applySchedule(time.Now())
+	monitor.PoolTargetIdle.WithLabelValues(p.name).Set(float64(p.targetIdle()))
+
 	// 初始化 availableCh，装 cfg.MaxBurst 个空闲容器
 	for i := 0; i < cfg.MaxBurst; i++ {
 		p.availableCh <- struct{}{}
 	}
 
 	// 清理孤儿容器
-	// 扫描所有带有 managed_by=agent-platform 和 project_id=pool 标签的容器
-	opts := container.ListOptions{
-		All:     true,
-		Filters: filters.NewArgs(),
-	}
-	opts.Filters.Add("label", "managed_by=agent-platform")
-	opts.Filters.Add("label", "project_id=pool")
-
-	containers, err := client.ContainerList(context.Background(), opts)
+	// 扫描上一次运行遗留的预热池容器（project_id=pool），空闲的重新纳入池中
+	containers, err := sandbox.ListManaged(context.Background(), client, sandbox.LabelQuery{
+		sandbox.LabelProjectID: sandbox.PoolProjectID,
+	}, true)
 	if err != nil {
 		logger.Error("Failed to list orphaned containers", "error", err)
 	} else {
 		for _, c := range containers {
+			if owner := c.Labels[sandbox.LabelPoolProfile]; owner != p.profileLabel() {
+				// 其他预热池的容器由对应的池接管，已不再配置的预热池遗留的容器由默认池移除
+				if p.name != DefaultWarmPool || profiles[owner] != nil {
+					continue
+				}
+				logger.Info("Removing container of unconfigured warm pool", "id", c.ID, "warm_pool", owner)
+				client.ContainerRemove(context.Background(), c.ID, container.RemoveOptions{Force: true})
+				continue
+			}
 			if c.State == "running" {
 				logger.Info("Adopting orphaned container", "id", c.ID)
 				inspect, err := client.ContainerInspect(context.Background(), c.ID)
@@ -79,24 +157,10 @@ func NewPool(client *client.Client, logger *slog.Logger, cfg PoolConfig) *Pool {
 					continue
 				}
 
-				// 重建 Container
-				sc := sandbox.NewContainer(client, sandbox.ContainerConfig{
-					Image:           c.Image,
-					SessionID:       c.Labels["session_id"],
-					ProjectID:       c.Labels["project_id"],
-					NetworkName:     cfg.NetworkName,
-					MemoryLimit:     inspect.HostConfig.Memory,
-					CPULimit:        float64(inspect.HostConfig.NanoCPUs) / 1e9,
-					UseAnonymousVol: true, // Pool 容器是匿名卷
-				}, "", logger)
-				sc.ID = c.ID
-
-				// 获取 IP
-				if net, ok := c.NetworkSettings.Networks[cfg.NetworkName]; ok {
-					sc.IP = net.IPAddress
-				}
+				sc := p.rebuildContainer(inspect)
 
 				p.idleContainers = append(p.idleContainers, sc)
+				p.markIdle(sc)
 				p.managedCount++
 				// 消耗一个 availableCh token
 				select {
@@ -111,45 +175,156 @@ func NewPool(client *client.Client, logger *slog.Logger, cfg PoolConfig) *Pool {
 		}
 	}
 
-	monitor.PoolIdleCount.Set(float64(len(p.idleContainers)))
+	monitor.PoolIdleCount.WithLabelValues(p.name).Set(float64(len(p.idleContainers)))
 
 	go p.worker()
+	go p.dispatchCapacity()
 
 	return p
 }
 
-func (p *Pool) Acquire(ctx context.Context) (*sandbox.Container, error) {
+// rebuildContainer 由 inspect 结果重建预热容器
+func (p *Pool) rebuildContainer(inspect container.InspectResponse) *sandbox.Container {
+	labels := sandbox.OwnedLabels(inspect.Name, inspect.Config.Labels)
+	sc := sandbox.NewContainer(p.client, sandbox.ContainerConfig{
+		Image:           inspect.Config.Image,
+		SessionID:       labels[sandbox.LabelSessionID],
+		ProjectID:       labels[sandbox.LabelProjectID],
+		Tenant:          labels[sandbox.LabelTenant],
+		Strategy:        labels[sandbox.LabelStrategy],
+		PoolProfile:     labels[sandbox.LabelPoolProfile],
+		NetworkName:     p.config.NetworkName,
+		MemoryLimit:     inspect.HostConfig.Memory,
+		CPULimit:        float64(inspect.HostConfig.NanoCPUs) / 1e9,
+		Runtime:         inspect.HostConfig.Runtime,
+		ReadOnlyRootFS:  inspect.HostConfig.ReadonlyRootfs,
+		UseAnonymousVol: true, // Pool 容器是匿名卷
+	}, "", p.logger)
+	sc.ID = inspect.ID
+	if created, err := time.Parse(time.RFC3339Nano, inspect.Created); err == nil {
+		sc.CreatedAt = created
+	}
+
+	// 获取 IP
+	if net, ok := inspect.NetworkSettings.Networks[p.config.NetworkName]; ok {
+		sc.IP = net.IPAddress
+	}
+	return sc
+}
+
+// Acquire 从 selector 选中的预热池获取容器，selector 为预热池名称或镜像，为空时使用默认池
+func (p *Pool) Acquire(ctx context.Context, selector string) (*sandbox.Container, error) {
+	return p.AcquireWithPriority(ctx, selector, PriorityInteractive)
+}
+
+// AcquireWithPriority 同 Acquire，名额用尽时按 priority 排队：交互式请求先于批处理请求分到名额
+func (p *Pool) AcquireWithPriority(ctx context.Context, selector string, priority Priority) (*sandbox.Container, error) {
+	req := requestFrom(ctx, selector, priority)
+	wp, err := p.selectPool(req)
+	if err != nil {
+		return nil, err
+	}
+	if wp.draining.Load() {
+		return nil, ErrPoolDraining
+	}
+	if wp.drained.Load() {
+		return nil, ErrPoolDrained
+	}
+	// 重试或重启时 session 的配额可能已被释放，取容器前再次登记；已登记时直接通过
+	if lease, ok := quota.LeaseFrom(ctx); ok {
+		if err := p.config.Sessions.Acquire(ctx, lease); err != nil {
+			return nil, err
+		}
+	}
+	return wp.acquire(ctx, req)
+}
+
+// HasWarmPool selector 是否对应一个预热池
+func (p *Pool) HasWarmPool(selector string) bool {
+	_, err := p.warmPool(selector)
+	return err == nil
+}
+
+// WarmPoolImage 返回 selector 对应预热池配置的镜像（不是烘焙后的镜像）
+func (p *Pool) WarmPoolImage(selector string) (string, bool) {
+	wp, err := p.warmPool(selector)
+	if err != nil {
+		return "", false
+	}
+	return wp.config.WarmupImage, true
+}
+
+// warmPool 按名称或镜像选择预热池，selector 为空或与默认镜像相同时使用默认池
+func (p *Pool) warmPool(selector string) (*Pool, error) {
+	return p.selectPool(Request{Selector: selector})
+}
+
+// selectPool 由 Scheduler 从默认池和各预热池中为 req 选择预热池
+func (p *Pool) selectPool(req Request) (*Pool, error) {
+	pools := []*Pool{p}
+	for _, name := range p.profileNames() {
+		pools = append(pools, p.profiles[name])
+	}
+	infos := make([]PoolInfo, len(pools))
+	for i, wp := range pools {
+		wp.mu.Lock()
+		infos[i] = wp.info()
+		wp.mu.Unlock()
+	}
+	i, err := p.scheduler().SelectPool(req, infos)
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 || i >= len(pools) {
+		return nil, fmt.Errorf("scheduler selected unknown warm pool %d", i)
+	}
+	return pools[i], nil
+}
+
+// profileLabel 写入容器 pool_profile 标签的值，默认池为空
+func (p *Pool) profileLabel() string {
+	if p.name == DefaultWarmPool {
+		return ""
+	}
+	return p.name
+}
+
+func (p *Pool) acquire(ctx context.Context, req Request) (*sandbox.Container, error) {
 	start := time.Now()
+	sched := p.scheduler()
 	for {
 		// 等待有空闲容器
-		select {
-		case <-p.availableCh:
-			// 有空闲容器，继续
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case <-p.stopCh:
-			return nil, fmt.Errorf("pool is shutting down")
+		if err := p.waitCapacity(ctx, &req); err != nil {
+			return nil, err
 		}
 
 		p.mu.Lock()
 
 		if len(p.idleContainers) > 0 {
-			idx := len(p.idleContainers) - 1
+			idx := sched.PickIdle(ctx, req, p.idleView())
+			if idx < 0 || idx >= len(p.idleContainers) {
+				idx = len(p.idleContainers) - 1
+			}
 			c := p.idleContainers[idx]
-			p.idleContainers = p.idleContainers[:idx]
+			p.idleContainers = slices.Delete(p.idleContainers, idx, idx+1)
 			p.mu.Unlock()
 
 			// 检验容器状态
 			if c.IsRunning(ctx) {
 				p.logger.Info("Acquired warm container", "id", c.ID)
-				monitor.PoolIdleCount.Dec()
+				p.trackLease(c)
+				p.observeLease(req, c.ID)
+				p.idleShrink.Store(0)
+				monitor.PoolIdleCount.WithLabelValues(p.name).Dec()
 				monitor.PoolAcquisitionLatency.Observe(time.Since(start).Seconds())
+				p.scaler.observe(time.Since(start), true)
 				return c, nil
 			}
 
 			// 清理
 			p.logger.Warn("Pooled container is dead, discarding", "id", c.ID)
 			go func() {
+				defer errreport.Recover(context.Background(), p.logger, "pool", errreport.Tags{"container_id": c.ID})
 				c.Remove(context.Background())
 				// 加锁更新容器总数
 				p.mu.Lock()
@@ -162,7 +337,12 @@ func (p *Pool) Acquire(ctx context.Context) (*sandbox.Container, error) {
 			continue
 		}
 
-		// 没有空闲容器，创建一个新容器
+		// 没有空闲容器，由调度策略决定是否创建一个新容器
+		if !sched.Burst(req, p.info()) {
+			p.mu.Unlock()
+			p.availableCh <- struct{}{}
+			return nil, ErrNoIdleContainer
+		}
 		// 已经消耗了一个使用+创建名额，可以创建
 		p.managedCount++
 		p.mu.Unlock()
@@ -180,12 +360,147 @@ func (p *Pool) Acquire(ctx context.Context) (*sandbox.Container, error) {
 		}
 
 		p.logger.Info("Created burst container", "id", c.ID)
+		p.trackLease(c)
+		p.observeLease(req, c.ID)
+		p.idleShrink.Store(0)
 		monitor.PoolAcquisitionLatency.Observe(time.Since(start).Seconds())
+		p.scaler.observe(time.Since(start), false)
 		return c, nil
 	}
 }
 
+// waitCapacity 取得一个使用+创建名额，名额用尽时按 priority 排队等待。
+// 排队分到名额时 req.Ticket 为本次排队的编号，否则为 0
+func (p *Pool) waitCapacity(ctx context.Context, req *Request) error {
+	req.Ticket = 0
+	// 已有请求排队时不能插队，直接排到队尾
+	if p.waiters.len() == 0 {
+		select {
+		case <-p.availableCh:
+			return nil
+		default:
+		}
+	}
+
+	n := p.queued.Add(1)
+	monitor.PoolAcquireQueued.WithLabelValues(p.name).Set(float64(n))
+	p.scaler.queued(int(n))
+	defer func() {
+		monitor.PoolAcquireQueued.WithLabelValues(p.name).Set(float64(p.queued.Add(-1)))
+	}()
+
+	w := p.waiters.enqueue(*req, time.Now())
+	var err error
+	select {
+	case <-w.granted:
+		req.Ticket = w.Request.Ticket
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-p.stopCh:
+		err = ErrPoolDraining
+	}
+	if !p.waiters.cancel(w) {
+		// 放弃等待时名额已分配给本请求，归还
+		p.availableCh <- struct{}{}
+	}
+	return err
+}
+
+// dispatchCapacity 有请求排队时取得归还的名额，按优先级分给队列中的下一个请求
+func (p *Pool) dispatchCapacity() {
+	for {
+		select {
+		case <-p.waiters.notify:
+		case <-p.stopCh:
+			return
+		}
+		for p.waiters.len() > 0 {
+			select {
+			case <-p.availableCh:
+			case <-p.stopCh:
+				return
+			}
+			w := p.waiters.next(time.Now())
+			if w == nil {
+				// 排队的请求都已放弃
+				p.availableCh <- struct{}{}
+				break
+			}
+			close(w.granted)
+		}
+	}
+}
+
+// targetIdle 当前的目标空闲数
+func (p *Pool) targetIdle() int {
+	scheduled := int(p.scheduledMin.Load())
+	target := p.config.MinIdle
+	if p.scaler != nil {
+		target = max(p.scaler.current(), scheduled)
+	} else if scheduled >= 0 {
+		target = scheduled
+	}
+	return max(target-int(p.idleShrink.Load()), 0)
+}
+
+// Warm 默认池以及各预热池的空闲容器数是否都已达到目标空闲数
+func (p *Pool) Warm() bool {
+	for _, wp := range p.profiles {
+		if !wp.Warm() {
+			return false
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.idleContainers) >= p.targetIdle()
+}
+
+// applySchedule 按 now 所在的预热窗口更新最少空闲数，多出或不足的空闲容器由 maintainPool 调整
+func (p *Pool) applySchedule(now time.Time) {
+	if len(p.config.Prewarm) == 0 {
+		return
+	}
+	loc := p.config.PrewarmLocation
+	if loc == nil {
+		loc = time.Local
+	}
+	scheduled := -1
+	if n, ok := prewarmMinIdle(p.config.Prewarm, now.In(loc)); ok {
+		scheduled = min(n, p.config.MaxBurst)
+	}
+	if int(p.scheduledMin.Swap(int32(scheduled))) == scheduled {
+		return
+	}
+	// 进入新的预热窗口时按窗口的最少空闲数重新补充
+	p.idleShrink.Store(0)
+	target := p.targetIdle()
+	monitor.PoolTargetIdle.WithLabelValues(p.name).Set(float64(target))
+	p.logger.Info("Prewarm schedule changed minimum idle", "scheduled_min_idle", scheduled, "target_idle", target)
+}
+
+// autoscale 结束一个统计窗口并按决策调整目标空闲数，多出的空闲容器由 maintainPool 移除
+func (p *Pool) autoscale() {
+	target, direction := p.scaler.decide(time.Now())
+	monitor.PoolTargetIdle.WithLabelValues(p.name).Set(float64(target))
+	if direction == "" {
+		return
+	}
+	monitor.PoolScaleDecisions.WithLabelValues(p.name, direction).Inc()
+	p.logger.Info("Pool target idle adjusted", "direction", direction, "target_idle", target)
+}
+
+// Release 把容器的名额归还给它所属的预热池并异步删除容器，启用 RecycleOnRelease 时改为清理后放回空闲列表
 func (p *Pool) Release(ctx context.Context, c *sandbox.Container) {
+	if wp, ok := p.profiles[c.Config.PoolProfile]; ok {
+		p = wp
+	}
+	p.untrackLease(c.ID)
+	p.observeRelease(c.ID)
+	if p.config.RecycleOnRelease {
+		p.cleanup.Go(func() { p.recycle(c) })
+		return
+	}
 	// 直接更新
 	// API 行为保持同步，清理流程异步
 	p.mu.Lock()
@@ -200,7 +515,10 @@ func (p *Pool) Release(ctx context.Context, c *sandbox.Container) {
 	}
 
 	// 异步清理
-	go func() {
+	p.goCleanup(errreport.Tags{
+		"container_id": c.ID,
+		"session_id":   c.Config.SessionID,
+	}, func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
@@ -213,47 +531,158 @@ func (p *Pool) Release(ctx context.Context, c *sandbox.Container) {
 		}
 
 		p.logger.Info("Released and removed container", "id", c.ID)
-	}()
+	})
 }
 
-func (p *Pool) Shutdown(ctx context.Context, c *sandbox.Container) {
+// ReleaseContainer 按容器 ID 归还已租出的预热容器，所属的预热池由容器标签确定
+func (p *Pool) ReleaseContainer(ctx context.Context, containerID string) error {
+	inspect, err := p.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			// 容器已被外部删除，仍需归还它占用的租约和名额
+			if c := p.leasedContainer(containerID); c != nil {
+				p.Release(ctx, c)
+				return nil
+			}
+			return sandbox.ErrContainerNotFound
+		}
+		return fmt.Errorf("failed to inspect container: %w", err)
+	}
+	if inspect.Config.Labels[sandbox.LabelStrategy] != string(WarmStrategyType) {
+		return fmt.Errorf("container %s is not a warm pool container", containerID)
+	}
+	p.Release(ctx, p.rebuildContainer(inspect))
+	return nil
+}
+
+// recycle 清理归还的容器并改回预热名称后放回空闲列表，失败时删除容器。
+// 清理期间容器继续占用名额，完成后才归还
+func (p *Pool) recycle(c *sandbox.Container) {
+	defer errreport.Recover(context.Background(), p.logger, "pool", errreport.Tags{
+		"container_id": c.ID,
+		"session_id":   c.Config.SessionID,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if p.pastMaxAge(c, time.Now()) {
+		p.retireLeased(ctx, c)
+		return
+	}
+	err := c.Wipe(ctx)
+	if err == nil {
+		err = c.Reassign(ctx, newWarmupID())
+	}
+
 	p.mu.Lock()
-	defer p.mu.Unlock()
+	if err == nil {
+		select {
+		case <-p.stopCh:
+			err = fmt.Errorf("pool is shutting down")
+		default:
+			if p.drained.Load() {
+				err = ErrPoolDrained
+				break
+			}
+			p.idleContainers = append(p.idleContainers, c)
+			p.markIdle(c)
+			monitor.PoolIdleCount.WithLabelValues(p.name).Inc()
+		}
+	}
+	if err != nil {
+		p.managedCount--
+	}
+	p.mu.Unlock()
+	p.availableCh <- struct{}{}
 
-	// 关闭 stopCh 以停止 worker
-	select {
-	case <-p.stopCh:
-	default:
-		close(p.stopCh)
+	if err != nil {
+		p.logger.Warn("Failed to recycle container, removing it", "id", c.ID, "error", err)
+		monitor.PoolRecycles.WithLabelValues(p.name, "failed").Inc()
+		c.Stop(ctx, 2)
+		c.Remove(ctx)
+		return
 	}
+	monitor.PoolRecycles.WithLabelValues(p.name, "recycled").Inc()
+	p.logger.Info("Recycled released container", "id", c.ID)
+}
 
-	// 异步移除容器
-	for _, c := range p.idleContainers {
-		go func(c *sandbox.Container) {
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-			defer cancel()
-			c.Stop(ctx, 10)
-			c.Remove(ctx)
-		}(c)
+func (p *Pool) Transfer(ctx context.Context, c *sandbox.Container, sessionID string) error {
+	from := c.Config.SessionID
+	if err := c.Reassign(ctx, sessionID); err != nil {
+		return err
+	}
+	// 容器仍处于租出状态，managedCount 和名额都不变
+	if wp, ok := p.profiles[c.Config.PoolProfile]; ok {
+		p = wp
 	}
-	p.idleContainers = nil
+	p.trackLease(c)
+	p.logger.Info("Transferred leased container", "id", c.ID, "from_session", from, "to_session", sessionID)
+	return nil
 }
 
 func (p *Pool) worker() {
 	ticker := time.NewTicker(p.config.HealthCheckInterval)
 	defer ticker.Stop()
+	metricsTicker := time.NewTicker(containerMetricsInterval)
+	defer metricsTicker.Stop()
+	// 未启用自动扩缩容时 scaleC 为 nil，对应的 case 永远不会触发
+	var scaleC <-chan time.Time
+	if p.scaler != nil {
+		scaleTicker := time.NewTicker(p.scaler.cfg.Interval)
+		defer scaleTicker.Stop()
+		scaleC = scaleTicker.C
+	}
 	for {
 		select {
 		case <-p.stopCh:
 			return
 
 		case <-ticker.C:
-			p.healthCheck()
-			p.maintainPool()
+			p.tick()
+
+		case <-metricsTicker.C:
+			// 统计的是所有平台容器，只由默认池执行
+			if p.name == DefaultWarmPool {
+				p.reportContainers()
+			}
+
+		case <-scaleC:
+			p.autoscale()
 		}
 	}
 }
 
+// containerMetricsInterval 按标签统计平台容器数量的间隔
+const containerMetricsInterval = 30 * time.Second
+
+// reportContainers 按策略、状态和平台版本统计所有平台沙箱容器（包括非本池创建的冷容器）
+func (p *Pool) reportContainers() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	containers, err := sandbox.ListManaged(ctx, p.client, sandbox.LabelQuery{sandbox.LabelStrategy: ""}, true)
+	if err != nil {
+		p.logger.Warn("Failed to count platform containers", "error", err)
+		return
+	}
+
+	monitor.ManagedContainers.Reset()
+	for _, c := range containers {
+		monitor.ManagedContainers.WithLabelValues(c.Labels[sandbox.LabelStrategy], c.State, c.Labels[sandbox.LabelVersion]).Inc()
+	}
+}
+
+// tick 执行一轮健康检查和补充，panic 被捕获上报，不会终止 worker 循环
+func (p *Pool) tick() {
+	defer errreport.Recover(context.Background(), p.logger, "pool", nil)
+	p.healthCheck()
+	p.checkLeases()
+	p.probeAgents()
+	p.retireExpired()
+	p.applySchedule(time.Now())
+	p.maintainPool()
+}
+
 func (p *Pool) healthCheck() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -271,6 +700,7 @@ func (p *Pool) healthCheck() {
 		} else {
 			p.logger.Warn("Removing dead container from pool", "id", c.ID)
 			go func(c *sandbox.Container) {
+				defer errreport.Recover(context.Background(), p.logger, "pool", errreport.Tags{"container_id": c.ID})
 				c.Remove(context.Background())
 				p.mu.Lock()
 				p.managedCount--
@@ -282,34 +712,26 @@ func (p *Pool) healthCheck() {
 	}
 
 	p.idleContainers = alive
-	monitor.PoolIdleCount.Set(float64(len(p.idleContainers)))
-}
-
-// 通过 TCP 探活 agent
-func (p *Pool) checkAgentHealth(ip string) bool {
-	if p.config.DisableHealthCheck {
-		return true
-	}
-	if ip == "" {
-		return false
-	}
-	conn, err := net.DialTimeout("tcp", ip+":50051", 1*time.Second)
-	if err != nil {
-		return false
-	}
-	conn.Close()
-	return true
+	monitor.PoolIdleCount.WithLabelValues(p.name).Set(float64(len(p.idleContainers)))
 }
 
 func (p *Pool) maintainPool() {
+	if p.drained.Load() {
+		return
+	}
 	p.mu.Lock()
+	target := p.targetIdle()
+	if surplus := len(p.idleContainers) - target; surplus > 0 {
+		p.trimIdle(surplus)
+		return
+	}
 	if time.Now().Before(p.cooldownUntil) {
 		p.mu.Unlock()
 		return
 	}
 
 	currentIdle := len(p.idleContainers)
-	needed := p.config.MinIdle - currentIdle
+	needed := target - currentIdle
 
 	// 限制最大创建数量
 	maxAllowed := p.config.MaxBurst - p.managedCount
@@ -357,6 +779,7 @@ TokenLoop:
 		go func() {
 			defer wg.Done()
 			defer func() { <-sem }()
+			defer errreport.Recover(context.Background(), p.logger, "pool", nil)
 
 			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 			defer cancel()
@@ -365,11 +788,8 @@ TokenLoop:
 			if err != nil {
 				p.logger.Error("Failed to replenish pool", "error", err)
 				monitor.ContainerCreationErrors.Inc()
-				newCount := atomic.AddInt32(&failureCount, 1)
-				if newCount >= 3 {
-					p.mu.Lock()
-					p.cooldownUntil = time.Now().Add(1 * time.Minute)
-					p.mu.Unlock()
+				if atomic.AddInt32(&failureCount, 1) == cooldownFailures {
+					p.enterCooldown(err)
 				}
 
 				// 创建失败，回滚
@@ -383,10 +803,11 @@ TokenLoop:
 			// 加入空闲池
 			p.mu.Lock()
 			// 二次检查
-			if len(p.idleContainers) < p.config.MinIdle &&
+			if len(p.idleContainers) < p.targetIdle() &&
 				p.managedCount <= p.config.MaxBurst {
 				p.idleContainers = append(p.idleContainers, container)
-				monitor.PoolIdleCount.Inc()
+				p.markIdle(container)
+				monitor.PoolIdleCount.WithLabelValues(p.name).Inc()
 				p.mu.Unlock()
 				// 返回一个使用+创建名额
 				p.availableCh <- struct{}{}
@@ -395,7 +816,7 @@ TokenLoop:
 				p.managedCount--
 				p.mu.Unlock()
 				go func() {
-					container.Stop(ctx, 10)
+					container.Stop(ctx, sandbox.CurrentRemovalPolicy().StopTimeoutSeconds())
 					container.Remove(ctx)
 				}()
 				// 返回一个使用+创建名额
@@ -407,45 +828,194 @@ TokenLoop:
 	wg.Wait()
 }
 
+// trimIdle 目标空闲数下调后按 Scheduler.Evict 移除 n 个空闲容器，n 不小于空闲数时全部移除。调用时持有 p.mu，返回前释放
+func (p *Pool) trimIdle(n int) {
+	evict := make(map[int]bool, n)
+	if n >= len(p.idleContainers) {
+		for i := range p.idleContainers {
+			evict[i] = true
+		}
+	} else {
+		for _, i := range p.scheduler().Evict(p.idleView(), n) {
+			if i >= 0 && i < len(p.idleContainers) {
+				evict[i] = true
+			}
+		}
+	}
+	trimmed := make([]*sandbox.Container, 0, len(evict))
+	kept := p.idleContainers[:0]
+	for i, c := range p.idleContainers {
+		if evict[i] {
+			trimmed = append(trimmed, c)
+		} else {
+			kept = append(kept, c)
+		}
+	}
+	p.idleContainers = kept
+	p.managedCount -= len(trimmed)
+	monitor.PoolIdleCount.WithLabelValues(p.name).Set(float64(len(p.idleContainers)))
+	p.mu.Unlock()
+
+	for _, c := range trimmed {
+		p.logger.Info("Removing surplus idle container", "id", c.ID)
+		go func(c *sandbox.Container) {
+			defer errreport.Recover(context.Background(), p.logger, "pool", errreport.Tags{"container_id": c.ID})
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			c.Stop(ctx, sandbox.CurrentRemovalPolicy().StopTimeoutSeconds())
+			c.Remove(ctx)
+		}(c)
+	}
+}
+
+// newWarmupID 生成空闲预热容器使用的唯一 session ID
+func newWarmupID() string {
+	return fmt.Sprintf("warmup-%d", time.Now().UnixNano())
+}
+
 func (p *Pool) createWarmContainer(ctx context.Context) (*sandbox.Container, error) {
-	// 生成唯一 session ID
-	sessionID := fmt.Sprintf("warmup-%d", time.Now().UnixNano())
+	sessionID := newWarmupID()
+
+	// 未配置启动命令时保持容器存活，gRPC server 由 worker 稍后启动
+	cmd := p.config.WarmCmd
+	if len(cmd) == 0 && len(p.config.WarmEntrypoint) == 0 {
+		cmd = sandbox.KeepAliveCmd
+	}
 
 	cfg := sandbox.ContainerConfig{
-		Image:           p.config.WarmupImage,
-		Cmd:             []string{"tail", "-f", "/dev/null"}, // Keep alive; gRPC server started later by worker
+		Image:           p.warmImage(),
+		Cmd:             cmd,
+		Entrypoint:      p.config.WarmEntrypoint,
 		MemoryLimit:     p.config.ContainerMem * 1024 * 1024,
 		CPULimit:        p.config.ContainerCPU,
+		DiskLimit:       p.config.ContainerDisk * 1024 * 1024,
 		UseAnonymousVol: true,
 		NetworkName:     p.config.NetworkName,
+		Runtime:         p.config.Runtime,
+		CgroupParent:    p.config.CgroupParent,
+		ReadOnlyRootFS:  p.config.ReadOnlyRootFS,
+		Security:        p.config.Security,
 		SessionID:       sessionID,
-		ProjectID:       "pool",
+		ProjectID:       sandbox.PoolProjectID,
+		Strategy:        string(WarmStrategyType),
+		PoolProfile:     p.profileLabel(),
 	}
 
 	c := sandbox.NewContainer(p.client, cfg, "", p.logger)
 	if err := c.Start(ctx); err != nil {
+		p.recordCreate(cfg.Image, err)
 		return nil, fmt.Errorf("failed to start warm container: %w", err)
 	}
+	p.recordCreate(cfg.Image, nil)
+	p.recoverCooldown()
 
 	return c, nil
 }
 
+// recordCreate 统计连续的容器创建失败，达到 CreateFailureAlert 时告警
+func (p *Pool) recordCreate(image string, err error) {
+	if err == nil {
+		p.createFailures.Store(0)
+		return
+	}
+	n := int(p.createFailures.Add(1))
+	if p.config.CreateFailureAlert <= 0 || n < p.config.CreateFailureAlert {
+		return
+	}
+	p.config.Notifier.Notify(notify.Alert{
+		Kind:    notify.KindContainerCreateFailures,
+		Title:   "Repeated container creation failures",
+		Message: fmt.Sprintf("%d consecutive sandbox containers failed to start.", n),
+		Fields: map[string]string{
+			"image":      image,
+			"last_error": err.Error(),
+		},
+	})
+}
+
+func (p *Pool) warmImage() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.bakedImage != "" {
+		return p.bakedImage
+	}
+	return p.config.WarmupImage
+}
+
+// BakeWarmImage 烘焙 WarmupImage 的预热镜像，之后新建的预热容器使用该镜像，已有的空闲容器不受影响
+func (p *Pool) BakeWarmImage(ctx context.Context) error {
+	tag, err := sandbox.BakeImage(ctx, p.client, sandbox.BakeOptions{
+		BaseImage: p.config.WarmupImage,
+		Command:   p.config.BakeCommand,
+		Timeout:   10 * time.Minute,
+	}, p.logger)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.bakedImage = tag
+	p.mu.Unlock()
+	p.logger.Info("Warm containers now use baked image", "image", tag)
+	return nil
+}
+
 func (p *Pool) CreateColdContainer(ctx context.Context, opts ContainerOptions) (*sandbox.Container, error) {
 	cfg := sandbox.ContainerConfig{
 		Image:           opts.Image,
 		EnvVars:         opts.EnvVars,
 		MemoryLimit:     p.config.ContainerMem * 1024 * 1024,
 		CPULimit:        p.config.ContainerCPU,
+		DiskLimit:       p.config.ContainerDisk * 1024 * 1024,
 		UseAnonymousVol: false,
 		NetworkName:     p.config.NetworkName,
+		Runtime:         p.config.Runtime,
+		CgroupParent:    p.config.CgroupParent,
+		ReadOnlyRootFS:  p.config.ReadOnlyRootFS,
+		Security:        p.securityFor(opts.ProjectID),
 		SessionID:       opts.SessionID,
 		ProjectID:       opts.ProjectID,
+		Tenant:          opts.Tenant,
+		Strategy:        string(ColdStrategyType),
+
+		NetworkPolicy:    opts.NetworkPolicy,
+		EgressProxyImage: p.config.EgressProxyImage,
+		GPUCount:         opts.GPUCount,
+		GPUDeviceIDs:     opts.GPUDeviceIDs,
+		Mounts:           opts.Mounts,
+		WorkspaceVolume:  p.config.WorkspaceVolume,
+		OnPullProgress:   opts.OnPullProgress,
 	}
 
-	c := sandbox.NewContainer(p.client, cfg, p.config.HostRoot, p.logger)
+	cli, nodeID := p.client, ""
+	req := Request{Selector: opts.Image, Priority: opts.Priority, Tenant: opts.Tenant, SessionID: opts.SessionID}
+	node, done, err := p.scheduler().Place(ctx, req, p.config.Nodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to place cold container: %w", err)
+	}
+	if done != nil {
+		defer done()
+	}
+	if node != nil {
+		cli, nodeID = node.Client, node.ID
+	}
+
+	c := sandbox.NewContainer(cli, cfg, p.config.HostRoot, p.logger)
+	c.NodeID = nodeID
 	if err := c.Start(ctx); err != nil {
+		p.recordCreate(cfg.Image, err)
 		return nil, fmt.Errorf("failed to start cold container: %w", err)
 	}
+	p.recordCreate(cfg.Image, nil)
+	p.config.Nodes.Remember(c.ID, nodeID)
 
 	return c, nil
 }
+
+// securityFor 豁免项目使用 Docker 默认的安全配置。预热容器创建时还不知道项目，始终使用加固配置
+func (p *Pool) securityFor(projectID string) sandbox.SecurityProfile {
+	if slices.Contains(p.config.SecurityExemptProjects, projectID) {
+		return sandbox.SecurityProfile{}
+	}
+	return p.config.Security
+}
@@ -185,7 +185,7 @@ func TestAcquireRelease(t *testing.T) {
 
 	// Acquire
 	start := time.Now()
-	c, err := p.Acquire(ctx)
+	c, err := p.Acquire(ctx, "")
 	if err != nil {
 		t.Fatalf("Failed to acquire: %v", err)
 	}
@@ -312,7 +312,7 @@ func TestConcurrency(t *testing.T) {
 
 			t.Logf("Req %d: Acquiring...", id)
 			start := time.Now()
-			c, err := p.Acquire(ctx)
+			c, err := p.Acquire(ctx, "")
 			if err != nil {
 				t.Logf("Req %d: Failed: %v", id, err)
 				atomic.AddInt32(&timeoutCount, 1)
@@ -0,0 +1,127 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PrewarmWindow 一个预热时间窗口，窗口内默认池的最少空闲数为 MinIdle
+type PrewarmWindow struct {
+	// Days 窗口开始的星期，按 time.Weekday 索引
+	Days [7]bool
+	// Start / End 距当天 0 点的时间，End <= Start 表示窗口跨过午夜，结束于次日 End
+	Start   time.Duration
+	End     time.Duration
+	MinIdle int
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParsePrewarmSchedule 解析 "mon-fri 09:00-18:00=10; * 22:00-06:00=2" 形式的预热计划，
+// 窗口之间用分号分隔，每项为 days HH:MM-HH:MM=min_idle。days 为 *、星期缩写、范围（mon-fri）
+// 或逗号分隔的列表（sat,sun）。多个窗口重叠时使用先列出的窗口
+func ParsePrewarmSchedule(s string) ([]PrewarmWindow, error) {
+	var windows []PrewarmWindow
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.Fields(part)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid prewarm window %q (expected days HH:MM-HH:MM=min_idle)", part)
+		}
+		span, count, ok := strings.Cut(fields[1], "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid prewarm window %q (expected days HH:MM-HH:MM=min_idle)", part)
+		}
+
+		var w PrewarmWindow
+		var err error
+		if w.Days, err = parseDays(fields[0]); err != nil {
+			return nil, fmt.Errorf("invalid prewarm window %q: %w", part, err)
+		}
+		start, end, ok := strings.Cut(span, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid prewarm window %q: time range must be HH:MM-HH:MM", part)
+		}
+		if w.Start, err = parseClock(start); err != nil {
+			return nil, fmt.Errorf("invalid prewarm window %q: %w", part, err)
+		}
+		if w.End, err = parseClock(end); err != nil {
+			return nil, fmt.Errorf("invalid prewarm window %q: %w", part, err)
+		}
+		if w.MinIdle, err = strconv.Atoi(count); err != nil || w.MinIdle < 0 {
+			return nil, fmt.Errorf("invalid prewarm window %q: min_idle must be a non-negative integer", part)
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+func parseDays(s string) ([7]bool, error) {
+	var days [7]bool
+	if s == "*" {
+		for i := range days {
+			days[i] = true
+		}
+		return days, nil
+	}
+	for _, item := range strings.Split(strings.ToLower(s), ",") {
+		from, to, isRange := strings.Cut(item, "-")
+		first, ok := weekdays[from]
+		if !ok {
+			return days, fmt.Errorf("unknown weekday %q", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdays[to]; !ok {
+				return days, fmt.Errorf("unknown weekday %q", to)
+			}
+		}
+		// 范围可以跨过周日，如 fri-mon
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (expected HH:MM)", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// contains 判断 now 是否落在窗口内，now 应已转换到计划使用的时区
+func (w PrewarmWindow) contains(now time.Time) bool {
+	clock := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute +
+		time.Duration(now.Second())*time.Second
+	today := now.Weekday()
+	if w.Start < w.End {
+		return w.Days[today] && clock >= w.Start && clock < w.End
+	}
+	// 跨午夜：当天开始的前半段，或前一天开始的后半段
+	yesterday := (today + 6) % 7
+	return (w.Days[today] && clock >= w.Start) || (w.Days[yesterday] && clock < w.End)
+}
+
+// prewarmMinIdle 返回 now 所在窗口的最少空闲数，不在任何窗口内时 ok 为 false
+func prewarmMinIdle(windows []PrewarmWindow, now time.Time) (minIdle int, ok bool) {
+	for _, w := range windows {
+		if w.contains(now) {
+			return w.MinIdle, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,85 @@
+package orchestrator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePrewarmSchedule(t *testing.T) {
+	windows, err := ParsePrewarmSchedule("mon-fri 09:00-18:00=10; sat,sun 10:00-16:00=4; * 22:00-06:00=2")
+	if err != nil {
+		t.Fatalf("ParsePrewarmSchedule: %v", err)
+	}
+	if len(windows) != 3 {
+		t.Fatalf("got %d windows, want 3", len(windows))
+	}
+	if w := windows[0]; !w.Days[time.Monday] || !w.Days[time.Friday] || w.Days[time.Saturday] ||
+		w.Start != 9*time.Hour || w.End != 18*time.Hour || w.MinIdle != 10 {
+		t.Errorf("unexpected business-hours window: %+v", w)
+	}
+	if w := windows[1]; !w.Days[time.Sunday] || !w.Days[time.Saturday] || w.Days[time.Monday] {
+		t.Errorf("unexpected weekend window: %+v", w)
+	}
+
+	for _, bad := range []string{"mon 09:00=3", "xyz 09:00-10:00=1", "mon 9-10=1", "mon 09:00-10:00=-1", "mon 09:00-10:00"} {
+		if _, err := ParsePrewarmSchedule(bad); err == nil {
+			t.Errorf("ParsePrewarmSchedule(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestPrewarmMinIdle(t *testing.T) {
+	windows, err := ParsePrewarmSchedule("mon-fri 09:00-18:00=10; fri 22:00-06:00=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day, clock string) time.Time {
+		// 2024-01-01 为周一
+		ts, err := time.Parse("2006-01-02 15:04", day+" "+clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+	cases := []struct {
+		now  time.Time
+		want int
+		ok   bool
+	}{
+		{at("2024-01-01", "09:00"), 10, true},
+		{at("2024-01-01", "17:59"), 10, true},
+		{at("2024-01-01", "18:00"), 0, false},
+		{at("2024-01-05", "23:00"), 2, true}, // 周五晚上
+		{at("2024-01-06", "05:30"), 2, true}, // 跨午夜到周六凌晨
+		{at("2024-01-06", "06:00"), 0, false},
+		{at("2024-01-02", "01:00"), 0, false}, // 周一晚上不在窗口内
+	}
+	for _, tc := range cases {
+		got, ok := prewarmMinIdle(windows, tc.now)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("prewarmMinIdle(%s) = %d, %v; want %d, %v", tc.now.Format(time.RFC1123), got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestTargetIdleFollowsSchedule(t *testing.T) {
+	p := &Pool{config: PoolConfig{MinIdle: 2, MaxBurst: 8}}
+	p.scheduledMin.Store(-1)
+	if got := p.targetIdle(); got != 2 {
+		t.Fatalf("targetIdle without window = %d, want 2", got)
+	}
+	p.scheduledMin.Store(6)
+	if got := p.targetIdle(); got != 6 {
+		t.Fatalf("targetIdle in window = %d, want 6", got)
+	}
+
+	// 自动扩缩容时计划值作为下限
+	p.scaler = newAutoscaler(AutoscaleConfig{Enabled: true, MinIdle: 1, MaxIdle: 8}, 8, 3)
+	if got := p.targetIdle(); got != 6 {
+		t.Fatalf("autoscaled targetIdle = %d, want 6", got)
+	}
+	p.scheduledMin.Store(-1)
+	if got := p.targetIdle(); got != 3 {
+		t.Fatalf("autoscaled targetIdle without window = %d, want 3", got)
+	}
+}
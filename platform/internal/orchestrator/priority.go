@@ -0,0 +1,104 @@
+package orchestrator
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Priority Acquire 排队等待名额时的优先级
+type Priority int
+
+const (
+	// PriorityInteractive 交互式 session，默认优先级
+	PriorityInteractive Priority = iota
+	// PriorityBatch 批处理 session，名额紧张时让位于交互式 session
+	PriorityBatch
+)
+
+// DefaultBatchMaxWait 批处理请求排队超过该时间后与交互式请求按到达顺序竞争，避免饿死
+const DefaultBatchMaxWait = 30 * time.Second
+
+// ParsePriority 解析 API 中的优先级名称，空串为 PriorityInteractive
+func ParsePriority(s string) (Priority, error) {
+	switch s {
+	case "", "interactive":
+		return PriorityInteractive, nil
+	case "batch":
+		return PriorityBatch, nil
+	}
+	return 0, fmt.Errorf("invalid priority %q (expected interactive or batch)", s)
+}
+
+func (p Priority) String() string {
+	if p == PriorityBatch {
+		return "batch"
+	}
+	return "interactive"
+}
+
+// capacityQueue 名额用尽时的等待队列，按到达顺序保存排队的 Acquire，有名额归还时由 Scheduler.Next 选择分给谁
+type capacityQueue struct {
+	sched Scheduler
+
+	mu      sync.Mutex
+	waiting []*Waiter
+	// notify 有新的等待者时唤醒分发协程
+	notify chan struct{}
+}
+
+// tickets 排队请求的编号，各预热池共用 Scheduler，编号需要全局唯一
+var tickets atomic.Uint64
+
+func newCapacityQueue(sched Scheduler) *capacityQueue {
+	return &capacityQueue{sched: sched, notify: make(chan struct{}, 1)}
+}
+
+// enqueue 为 req 分配 Ticket 后排到队尾
+func (q *capacityQueue) enqueue(req Request, now time.Time) *Waiter {
+	req.Ticket = tickets.Add(1)
+	w := &Waiter{Request: req, Enqueued: now, granted: make(chan struct{})}
+	q.mu.Lock()
+	q.waiting = append(q.waiting, w)
+	q.mu.Unlock()
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return w
+}
+
+// cancel 移除放弃等待的 w，w 已分到名额时返回 false，调用方需要归还该名额
+func (q *capacityQueue) cancel(w *Waiter) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if i := slices.Index(q.waiting, w); i >= 0 {
+		q.waiting = slices.Delete(q.waiting, i, i+1)
+		return true
+	}
+	return false
+}
+
+func (q *capacityQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.waiting)
+}
+
+// next 取出下一个应分到名额的等待者，队列为空时返回 nil
+func (q *capacityQueue) next(now time.Time) *Waiter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.waiting) == 0 {
+		return nil
+	}
+	i := q.sched.Next(q.waiting, now)
+	if i < 0 || i >= len(q.waiting) {
+		i = 0
+	}
+	w := q.waiting[i]
+	q.waiting = slices.Delete(q.waiting, i, i+1)
+	return w
+}
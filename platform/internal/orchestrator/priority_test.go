@@ -0,0 +1,104 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCapacityQueueOrder(t *testing.T) {
+	q := newCapacityQueue(&DefaultScheduler{BatchMaxWait: time.Minute})
+	start := time.Now()
+	b1 := q.enqueue(Request{Priority: PriorityBatch}, start)
+	i1 := q.enqueue(Request{Priority: PriorityInteractive}, start.Add(time.Second))
+	i2 := q.enqueue(Request{Priority: PriorityInteractive}, start.Add(2*time.Second))
+
+	now := start.Add(10 * time.Second)
+	if got := q.next(now); got != i1 {
+		t.Fatal("expected first interactive waiter ahead of batch")
+	}
+	if got := q.next(now); got != i2 {
+		t.Fatal("expected second interactive waiter")
+	}
+	if got := q.next(now); got != b1 {
+		t.Fatal("expected batch waiter once interactive queue is empty")
+	}
+	if q.next(now) != nil {
+		t.Fatal("expected empty queue")
+	}
+}
+
+func TestCapacityQueueStarvation(t *testing.T) {
+	q := newCapacityQueue(&DefaultScheduler{BatchMaxWait: 30 * time.Second})
+	start := time.Now()
+	b := q.enqueue(Request{Priority: PriorityBatch}, start)
+	i := q.enqueue(Request{Priority: PriorityInteractive}, start.Add(40*time.Second))
+
+	// 批处理请求已等待超过 batchMaxWait，且早于交互式请求到达
+	if got := q.next(start.Add(45 * time.Second)); got != b {
+		t.Fatal("expected starved batch waiter to be served first")
+	}
+	if got := q.next(start.Add(45 * time.Second)); got != i {
+		t.Fatal("expected interactive waiter next")
+	}
+}
+
+func TestWaitCapacityPriority(t *testing.T) {
+	p := &Pool{
+		name:        "test",
+		availableCh: make(chan struct{}, 1),
+		stopCh:      make(chan struct{}),
+		waiters:     newCapacityQueue(&DefaultScheduler{BatchMaxWait: time.Minute}),
+	}
+	defer close(p.stopCh)
+	go p.dispatchCapacity()
+
+	ctx := context.Background()
+	order := make(chan Priority, 2)
+	wait := func(priority Priority) {
+		req := &Request{Priority: priority}
+		if err := p.waitCapacity(ctx, req); err != nil {
+			t.Error(err)
+			return
+		}
+		if req.Ticket == 0 {
+			t.Error("queued request granted without a ticket")
+		}
+		order <- priority
+	}
+	go wait(PriorityBatch)
+	waitQueued(t, p, 1)
+	go wait(PriorityInteractive)
+	waitQueued(t, p, 2)
+
+	p.availableCh <- struct{}{}
+	if got := <-order; got != PriorityInteractive {
+		t.Fatalf("first grant went to %s, want interactive", got)
+	}
+	p.availableCh <- struct{}{}
+	if got := <-order; got != PriorityBatch {
+		t.Fatalf("second grant went to %s, want batch", got)
+	}
+
+	// 放弃等待的请求不占用名额
+	cctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := p.waitCapacity(cctx, &Request{Priority: PriorityBatch}); err == nil {
+		t.Fatal("expected timeout without capacity")
+	}
+	p.availableCh <- struct{}{}
+	if err := p.waitCapacity(ctx, &Request{Priority: PriorityInteractive}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitQueued(t *testing.T, p *Pool, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for p.waiters.len() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d queued acquires", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
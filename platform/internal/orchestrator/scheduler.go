@@ -0,0 +1,205 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"platform/internal/featureflag"
+	"platform/internal/nodes"
+	"platform/internal/quota"
+	"platform/internal/sandbox"
+)
+
+// ErrNoIdleContainer 调度策略拒绝为请求新建容器，调用方可以改用冷启动或稍后重试
+var ErrNoIdleContainer = errors.New("no idle warm container available")
+
+// Request 一次取容器请求中与调度相关的信息
+type Request struct {
+	// Selector 预热池名称或镜像，冷启动时为镜像
+	Selector string
+	Priority Priority
+	// Tenant 来自 context 中的 featureflag.Scope，SessionID 来自 quota.Lease，没有时为空
+	Tenant    string
+	SessionID string
+	// Ticket 排队等待名额时分配的编号，在所有预热池间唯一；未排队直接取得名额时为 0
+	Ticket uint64
+}
+
+// requestFrom 由 Acquire 的参数和 context 构造调度请求
+func requestFrom(ctx context.Context, selector string, priority Priority) Request {
+	req := Request{Selector: selector, Priority: priority, Tenant: featureflag.ScopeFrom(ctx).Tenant}
+	if lease, ok := quota.LeaseFrom(ctx); ok {
+		req.SessionID = lease.SessionID
+		if req.Tenant == "" {
+			req.Tenant = lease.User
+		}
+	}
+	return req
+}
+
+// PoolInfo 调度时一个预热池的状态快照
+type PoolInfo struct {
+	Name     string
+	Image    string
+	Idle     int
+	Leased   int
+	Managed  int
+	MaxBurst int
+}
+
+// Waiter 一个名额用尽时排队中的 Acquire，granted 被关闭表示已分到名额
+type Waiter struct {
+	Request  Request
+	Enqueued time.Time
+	granted  chan struct{}
+}
+
+// IdleContainer 一个空闲容器及其放入空闲列表的时间
+type IdleContainer struct {
+	Container *sandbox.Container
+	IdleSince time.Time
+}
+
+// Scheduler 预热池取容器时的各项决策。默认实现 DefaultScheduler 即原有行为，
+// 其他策略（装箱、按租户公平分配等）通过 PoolConfig.Scheduler 替换，默认池和各预热池共用同一个 Scheduler。
+// 除 Place 外的方法可能在持有预热池锁时调用，不能回调 Pool
+type Scheduler interface {
+	// SelectPool 为 req 选择预热池，返回其在 pools 中的下标；pools[0] 为默认池，其余按名称排序
+	SelectPool(req Request, pools []PoolInfo) (int, error)
+	// Next 有名额归还时选择分到名额的排队请求，返回其在 waiting 中的下标；waiting 按到达顺序排列且不为空
+	Next(waiting []*Waiter, now time.Time) int
+	// PickIdle 选择分配给 req 的空闲容器，返回其在 idle 中的下标；idle 按放入顺序排列且不为空
+	PickIdle(ctx context.Context, req Request, idle []IdleContainer) int
+	// Burst 取得名额但没有空闲容器时是否立即为 req 新建容器，返回 false 时 Acquire 归还名额并返回 ErrNoIdleContainer
+	Burst(req Request, pool PoolInfo) bool
+	// Evict 目标空闲数下调时选择要移除的 n 个空闲容器，返回它们在 idle 中的下标
+	Evict(idle []IdleContainer, n int) []int
+	// Place 选择冷启动容器所在的节点，返回 nil 节点表示本机；done 必须在容器创建完成（无论成败）后调用
+	Place(ctx context.Context, req Request, registry *nodes.Registry) (*nodes.Node, func(), error)
+}
+
+var _ Scheduler = (*DefaultScheduler)(nil)
+
+// DefaultScheduler 默认的调度策略：按名称或镜像选择预热池；排队时交互式请求优先，
+// 批处理请求等待超过 BatchMaxWait 后按到达顺序竞争；后进先出地取空闲容器（PoolFIFOAcquire 开启时先进先出）；
+// 没有空闲容器时总是新建；缩容时移除最早放入的空闲容器；冷启动容器放到剩余容量最多的节点
+type DefaultScheduler struct {
+	// BatchMaxWait 0 时使用 DefaultBatchMaxWait
+	BatchMaxWait time.Duration
+	// Flags 功能开关，nil 时使用内置默认值
+	Flags *featureflag.Flags
+}
+
+func (s *DefaultScheduler) SelectPool(req Request, pools []PoolInfo) (int, error) {
+	def := pools[0]
+	if req.Selector == "" || req.Selector == def.Name || req.Selector == def.Image {
+		return 0, nil
+	}
+	for i, p := range pools[1:] {
+		if p.Name == req.Selector {
+			return i + 1, nil
+		}
+	}
+	for i, p := range pools[1:] {
+		if p.Image == req.Selector {
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid image %q: no warm pool is configured for it, use %s or configure a warm pool", req.Selector, ColdStrategyType)
+}
+
+func (s *DefaultScheduler) Next(waiting []*Waiter, now time.Time) int {
+	maxWait := s.BatchMaxWait
+	if maxWait <= 0 {
+		maxWait = DefaultBatchMaxWait
+	}
+	interactive, batch := -1, -1
+	for i, w := range waiting {
+		if w.Request.Priority == PriorityBatch {
+			if batch < 0 {
+				batch = i
+			}
+		} else if interactive < 0 {
+			interactive = i
+		}
+	}
+	if interactive < 0 {
+		return batch
+	}
+	// waiting 按到达顺序排列，batch < interactive 即最早的批处理请求先于交互式请求到达
+	if batch >= 0 && batch < interactive && now.Sub(waiting[batch].Enqueued) >= maxWait {
+		return batch
+	}
+	return interactive
+}
+
+func (s *DefaultScheduler) PickIdle(ctx context.Context, req Request, idle []IdleContainer) int {
+	if s.Flags.EnabledCtx(ctx, featureflag.PoolFIFOAcquire) {
+		// 先取最早放入的容器，空闲容器的存活时间更均匀
+		return 0
+	}
+	return len(idle) - 1
+}
+
+func (s *DefaultScheduler) Burst(req Request, pool PoolInfo) bool {
+	return true
+}
+
+func (s *DefaultScheduler) Evict(idle []IdleContainer, n int) []int {
+	out := make([]int, 0, n)
+	for i := range min(n, len(idle)) {
+		out = append(out, i)
+	}
+	return out
+}
+
+func (s *DefaultScheduler) Place(ctx context.Context, req Request, registry *nodes.Registry) (*nodes.Node, func(), error) {
+	if registry == nil {
+		return nil, func() {}, nil
+	}
+	return registry.Place(ctx)
+}
+
+// scheduler 返回预热池使用的调度策略，未配置时为 DefaultScheduler
+func (p *Pool) scheduler() Scheduler {
+	if p.config.Scheduler != nil {
+		return p.config.Scheduler
+	}
+	return &DefaultScheduler{BatchMaxWait: p.config.BatchMaxWait, Flags: p.config.Flags}
+}
+
+// observeLease / observeRelease 调度策略实现 LeaseObserver 时通知容器租出和归还
+func (p *Pool) observeLease(req Request, containerID string) {
+	if o, ok := p.scheduler().(LeaseObserver); ok {
+		o.Leased(req, containerID)
+	}
+}
+
+func (p *Pool) observeRelease(containerID string) {
+	if o, ok := p.scheduler().(LeaseObserver); ok {
+		o.Released(containerID)
+	}
+}
+
+// info 返回预热池的状态快照，调用时持有 p.mu
+func (p *Pool) info() PoolInfo {
+	return PoolInfo{
+		Name:     p.name,
+		Image:    p.config.WarmupImage,
+		Idle:     len(p.idleContainers),
+		Leased:   len(p.leased),
+		Managed:  p.managedCount,
+		MaxBurst: p.config.MaxBurst,
+	}
+}
+
+// idleView 返回空闲列表的调度视图，调用时持有 p.mu
+func (p *Pool) idleView() []IdleContainer {
+	out := make([]IdleContainer, len(p.idleContainers))
+	for i, c := range p.idleContainers {
+		out[i] = IdleContainer{Container: c, IdleSince: p.idleSince[c.ID]}
+	}
+	return out
+}
@@ -0,0 +1,91 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"platform/internal/sandbox"
+)
+
+// testScheduler 不新建容器、取最早放入的空闲容器并从最新的开始移除
+type testScheduler struct {
+	DefaultScheduler
+}
+
+func (s *testScheduler) PickIdle(ctx context.Context, req Request, idle []IdleContainer) int {
+	return 0
+}
+
+func (s *testScheduler) Burst(req Request, pool PoolInfo) bool {
+	return false
+}
+
+func (s *testScheduler) Evict(idle []IdleContainer, n int) []int {
+	out := make([]int, 0, n)
+	for i := len(idle) - 1; i >= 0 && len(out) < n; i-- {
+		out = append(out, i)
+	}
+	return out
+}
+
+func schedulerTestPool(sched Scheduler) *Pool {
+	p := drainTestPool()
+	p.config.Scheduler = sched
+	p.waiters = newCapacityQueue(sched)
+	p.availableCh = make(chan struct{}, 1)
+	p.availableCh <- struct{}{}
+	return p
+}
+
+func TestAcquireWithoutBurst(t *testing.T) {
+	p := schedulerTestPool(&testScheduler{})
+
+	if _, err := p.acquire(context.Background(), Request{}); !errors.Is(err, ErrNoIdleContainer) {
+		t.Fatalf("acquire error = %v, want ErrNoIdleContainer", err)
+	}
+	// 拒绝新建时归还名额
+	select {
+	case <-p.availableCh:
+	default:
+		t.Fatal("capacity token was not returned")
+	}
+	if p.managedCount != 0 {
+		t.Errorf("managedCount = %d, want 0", p.managedCount)
+	}
+}
+
+func TestTrimIdleUsesSchedulerEviction(t *testing.T) {
+	p := schedulerTestPool(&testScheduler{})
+	p.idleContainers = []*sandbox.Container{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	p.managedCount = 3
+
+	p.mu.Lock()
+	p.trimIdle(2)
+
+	if len(p.idleContainers) != 1 || p.idleContainers[0].ID != "a" {
+		t.Fatalf("idle containers = %v, want only a", p.idleContainers)
+	}
+	if p.managedCount != 1 {
+		t.Errorf("managedCount = %d, want 1", p.managedCount)
+	}
+}
+
+func TestDefaultSchedulerNext(t *testing.T) {
+	s := &DefaultScheduler{BatchMaxWait: 30 * time.Second}
+	start := time.Now()
+	waiting := []*Waiter{
+		{Request: Request{Priority: PriorityBatch}, Enqueued: start},
+		{Request: Request{Priority: PriorityInteractive}, Enqueued: start.Add(10 * time.Second)},
+	}
+	if got := s.Next(waiting, start.Add(20*time.Second)); got != 1 {
+		t.Errorf("Next before batch max wait = %d, want interactive waiter", got)
+	}
+	if got := s.Next(waiting, start.Add(40*time.Second)); got != 0 {
+		t.Errorf("Next after batch max wait = %d, want starved batch waiter", got)
+	}
+	if got := s.Next(waiting[:1], start); got != 0 {
+		t.Errorf("Next with only batch waiters = %d, want 0", got)
+	}
+}
@@ -0,0 +1,218 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"platform/internal/errreport"
+	"platform/internal/sandbox"
+)
+
+// ErrPoolDrained 预热池已被管理员排空，调用 Replenish 之前不分配容器也不补充空闲容器
+var ErrPoolDrained = errors.New("warm pool is drained")
+
+// 预热容器的健康状态
+const (
+	HealthRunning   = "running"
+	HealthStopped   = "stopped"
+	HealthUnchecked = "unchecked"
+)
+
+// ContainerStatus 预热池中一个容器的状态
+type ContainerStatus struct {
+	ID        string `json:"id"`
+	SessionID string `json:"session_id,omitempty"`
+	// State idle 或 leased
+	State      string    `json:"state"`
+	Image      string    `json:"image,omitempty"`
+	CreatedAt  time.Time `json:"created_at,omitzero"`
+	AgeSeconds int64     `json:"age_seconds,omitempty"`
+	Health     string    `json:"health"`
+	// HealthFailures 空闲容器中 agent 连续 gRPC 健康检查失败的次数
+	HealthFailures int `json:"health_failures,omitempty"`
+	// IdleSeconds 空闲容器已连续空闲的秒数
+	IdleSeconds int64 `json:"idle_seconds,omitempty"`
+}
+
+// PoolStatus 一个预热池的状态，供运维接口展示
+type PoolStatus struct {
+	Name       string `json:"name"`
+	Image      string `json:"image"`
+	Idle       int    `json:"idle"`
+	Leased     int    `json:"leased"`
+	Managed    int    `json:"managed"`
+	TargetIdle int    `json:"target_idle"`
+	MaxBurst   int    `json:"max_burst"`
+	Queued     int    `json:"queued"`
+	// CooldownUntil 连续创建失败后暂停补充的截止时间，不在冷却中时为零值
+	CooldownUntil  time.Time `json:"cooldown_until,omitzero"`
+	Cooldown       bool      `json:"cooldown"`
+	CreateFailures int       `json:"create_failures"`
+	// Degraded 进入冷却后尚未成功创建预热容器，Cooldowns 为连续进入冷却的次数
+	Degraded     bool              `json:"degraded"`
+	Cooldowns    int               `json:"cooldowns,omitempty"`
+	Drained      bool              `json:"drained"`
+	ShuttingDown bool              `json:"shutting_down"`
+	Containers   []ContainerStatus `json:"containers"`
+}
+
+// Status 返回默认池及各预热池的状态，默认池在前，其余按名称排序。
+// probe 为 true 时逐个检查容器是否仍在运行，否则健康状态为 unchecked
+func (p *Pool) Status(ctx context.Context, probe bool) []PoolStatus {
+	out := []PoolStatus{p.status(ctx, probe)}
+	for _, name := range p.profileNames() {
+		out = append(out, p.profiles[name].status(ctx, probe))
+	}
+	return out
+}
+
+func (p *Pool) profileNames() []string {
+	names := make([]string, 0, len(p.profiles))
+	for name := range p.profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (p *Pool) status(ctx context.Context, probe bool) PoolStatus {
+	now := time.Now()
+	p.mu.Lock()
+	st := PoolStatus{
+		Name:           p.name,
+		Image:          p.config.WarmupImage,
+		Idle:           len(p.idleContainers),
+		Leased:         len(p.leased),
+		Managed:        p.managedCount,
+		TargetIdle:     p.targetIdle(),
+		MaxBurst:       p.config.MaxBurst,
+		Queued:         int(p.queued.Load()),
+		CreateFailures: int(p.createFailures.Load()),
+		Drained:        p.drained.Load(),
+		Degraded:       p.degraded.Load(),
+		Cooldowns:      p.cooldowns,
+		ShuttingDown:   p.draining.Load(),
+	}
+	if p.bakedImage != "" {
+		st.Image = p.bakedImage
+	}
+	if now.Before(p.cooldownUntil) {
+		st.Cooldown = true
+		st.CooldownUntil = p.cooldownUntil
+	}
+	containers := make([]*sandbox.Container, 0, len(p.idleContainers)+len(p.leased))
+	states := make([]string, 0, cap(containers))
+	failures := make(map[string]int)
+	idleSince := make(map[string]time.Time)
+	for _, c := range p.idleContainers {
+		containers = append(containers, c)
+		states = append(states, "idle")
+		if n := p.healthFailures[c.ID]; n > 0 {
+			failures[c.ID] = n
+		}
+		if t, ok := p.idleSince[c.ID]; ok {
+			idleSince[c.ID] = t
+		}
+	}
+	for _, c := range p.leased {
+		containers = append(containers, c)
+		states = append(states, "leased")
+	}
+	p.mu.Unlock()
+
+	// 检查容器状态需要请求 Docker，不持有锁
+	st.Containers = make([]ContainerStatus, 0, len(containers))
+	for i, c := range containers {
+		cs := ContainerStatus{
+			ID:        c.ID,
+			State:     states[i],
+			Image:     c.Config.Image,
+			CreatedAt: c.CreatedAt,
+			Health:    HealthUnchecked,
+
+			HealthFailures: failures[c.ID],
+		}
+		if states[i] == "leased" {
+			cs.SessionID = c.Config.SessionID
+		}
+		if t, ok := idleSince[c.ID]; ok {
+			cs.IdleSeconds = int64(now.Sub(t).Seconds())
+		}
+		if !c.CreatedAt.IsZero() {
+			cs.AgeSeconds = int64(now.Sub(c.CreatedAt).Seconds())
+		}
+		if probe {
+			cs.Health = HealthStopped
+			if c.IsRunning(ctx) {
+				cs.Health = HealthRunning
+			}
+		}
+		st.Containers = append(st.Containers, cs)
+	}
+	return st
+}
+
+// targets 返回 selector 对应的预热池，selector 为空时返回所有预热池
+func (p *Pool) targets(selector string) ([]*Pool, error) {
+	if selector != "" {
+		wp, err := p.warmPool(selector)
+		if err != nil {
+			return nil, err
+		}
+		return []*Pool{wp}, nil
+	}
+	pools := []*Pool{p}
+	for _, name := range p.profileNames() {
+		pools = append(pools, p.profiles[name])
+	}
+	return pools, nil
+}
+
+// Replenish 立即补充空闲容器到目标空闲数：结束冷却、解除 Drain，补充在后台进行。selector 为空时作用于所有预热池
+func (p *Pool) Replenish(selector string) error {
+	pools, err := p.targets(selector)
+	if err != nil {
+		return err
+	}
+	for _, wp := range pools {
+		if wp.draining.Load() {
+			return ErrPoolDraining
+		}
+	}
+	for _, wp := range pools {
+		wp.mu.Lock()
+		wp.cooldownUntil = time.Time{}
+		wp.mu.Unlock()
+		wp.createFailures.Store(0)
+		if wp.drained.Swap(false) {
+			wp.logger.Info("Warm pool resumed")
+		}
+		go func() {
+			defer errreport.Recover(context.Background(), wp.logger, "pool", nil)
+			wp.maintainPool()
+		}()
+	}
+	return nil
+}
+
+// Drain 删除空闲容器并停止补充，之后的 Acquire 返回 ErrPoolDrained，直到调用 Replenish。
+// 租出的容器不受影响，归还时直接删除。排空状态只保存在本实例内存中，重启后恢复。selector 为空时作用于所有预热池
+func (p *Pool) Drain(selector string) error {
+	pools, err := p.targets(selector)
+	if err != nil {
+		return err
+	}
+	for _, wp := range pools {
+		wp.drained.Store(true)
+		wp.mu.Lock()
+		if n := len(wp.idleContainers); n > 0 {
+			wp.trimIdle(n)
+		} else {
+			wp.mu.Unlock()
+		}
+		wp.logger.Info("Warm pool drained")
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"platform/internal/sandbox"
+)
+
+func TestPoolStatus(t *testing.T) {
+	p := drainTestPool()
+	p.config.WarmupImage = "sandbox:latest"
+	p.config.MaxBurst = 5
+	p.config.MinIdle = 2
+	p.scheduledMin.Store(-1)
+	p.cooldownUntil = time.Now().Add(time.Minute)
+	p.idleContainers = []*sandbox.Container{{ID: "idle-1", CreatedAt: time.Now().Add(-time.Hour)}}
+	p.managedCount = 2
+	p.trackLease(leasedContainer("leased-1", "s1"))
+
+	pools := p.Status(context.Background(), false)
+	if len(pools) != 1 {
+		t.Fatalf("got %d pools, want 1", len(pools))
+	}
+	st := pools[0]
+	if st.Idle != 1 || st.Leased != 1 || st.Managed != 2 || st.TargetIdle != 2 || !st.Cooldown {
+		t.Errorf("unexpected status %+v", st)
+	}
+	if len(st.Containers) != 2 {
+		t.Fatalf("got %d containers, want 2", len(st.Containers))
+	}
+	idle, leased := st.Containers[0], st.Containers[1]
+	if idle.State != "idle" || idle.AgeSeconds < 3599 || idle.Health != HealthUnchecked {
+		t.Errorf("unexpected idle container %+v", idle)
+	}
+	if leased.State != "leased" || leased.SessionID != "s1" {
+		t.Errorf("unexpected leased container %+v", leased)
+	}
+}
+
+func TestDrainAndReplenish(t *testing.T) {
+	p := drainTestPool()
+	p.scheduledMin.Store(-1)
+	p.cooldownUntil = time.Now().Add(time.Minute)
+
+	if err := p.Drain("missing"); err == nil {
+		t.Error("Drain of unknown pool succeeded")
+	}
+	if err := p.Drain(""); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	if _, err := p.AcquireWithPriority(context.Background(), "", PriorityInteractive); !errors.Is(err, ErrPoolDrained) {
+		t.Errorf("Acquire after Drain err = %v, want ErrPoolDrained", err)
+	}
+
+	// MinIdle 为 0，补充不会创建容器
+	if err := p.Replenish(""); err != nil {
+		t.Fatalf("Replenish: %v", err)
+	}
+	st := p.Status(context.Background(), false)[0]
+	if st.Drained || st.Cooldown {
+		t.Errorf("status after Replenish = %+v, want resumed without cooldown", st)
+	}
+}
@@ -2,6 +2,7 @@ package orchestrator
 
 import (
 	"context"
+	"platform/internal/featureflag"
 	"platform/internal/sandbox"
 )
 
@@ -18,7 +19,8 @@ func (w *WarmStrategy) Name() StrategyType {
 }
 
 func (w *WarmStrategy) Get(ctx context.Context, pool IPool, opts ContainerOptions) (*sandbox.Container, error) {
-	container, err := pool.Acquire(ctx)
+	ctx = featureflag.WithScope(ctx, featureflag.Scope{Tenant: opts.Tenant, Project: opts.ProjectID})
+	container, err := pool.AcquireWithPriority(ctx, opts.Image, opts.Priority)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,15 @@
 package orchestrator
 
-import "time"
+import (
+	"time"
+
+	"platform/internal/eventbus"
+	"platform/internal/featureflag"
+	"platform/internal/nodes"
+	"platform/internal/notify"
+	"platform/internal/quota"
+	"platform/internal/sandbox"
+)
 
 // TODO: 冷容器和热容器的配置在哪些地方需要区分？
 type ContainerOptions struct {
@@ -8,6 +17,19 @@ type ContainerOptions struct {
 	EnvVars   []string
 	SessionID string
 	ProjectID string
+	// Tenant 容器所属租户（session 的用户），写入容器标签
+	Tenant string
+	// NetworkPolicy 网络隔离策略，仅 Cold 策略支持（预热容器已接入共享网络）
+	NetworkPolicy sandbox.NetworkPolicy
+	// GPUCount / GPUDeviceIDs GPU 请求，仅 Cold 策略支持
+	GPUCount     int
+	GPUDeviceIDs []string
+	// Mounts 工作区之外的额外挂载，仅 Cold 策略支持
+	Mounts []sandbox.MountSpec
+	// OnPullProgress 冷启动拉取镜像时的进度回调，可为 nil
+	OnPullProgress func(sandbox.PullProgress)
+	// Priority 预热池名额用尽时的排队优先级，仅 Warm 策略使用
+	Priority Priority
 }
 
 type StrategyType string
@@ -26,5 +48,79 @@ type PoolConfig struct {
 	HostRoot            string  // 冷容器挂载目录
 	ContainerMem        int64   // MB
 	ContainerCPU        float64 // CPU 核心数
+	ContainerDisk       int64   // 工作区磁盘上限（MB），0 表示不限制
+	Runtime             string  // 容器 OCI 运行时（如 runsc），为空时使用 daemon 默认
+	CgroupParent        string  // 所有沙箱容器共享的 cgroup parent，为空时使用 daemon 默认
 	DisableHealthCheck  bool    // 是否禁用应用层健康检查（用于测试）
+	EgressProxyImage    string  // 受限网络策略的出网代理镜像
+	// Security 沙箱的 seccomp 与 capability 配置
+	Security sandbox.SecurityProfile
+	// SecurityExemptProjects 冷启动时不应用 Security 的项目，使用 Docker 默认行为
+	SecurityExemptProjects []string
+	// ReadOnlyRootFS 沙箱根文件系统只读
+	ReadOnlyRootFS bool
+	// WarmCmd / WarmEntrypoint 预热容器的启动命令，都为空时运行 sandbox.KeepAliveCmd。
+	// 镜像自行启动 agent server 时，worker 检测到已在监听就不再重复启动
+	WarmCmd        []string
+	WarmEntrypoint []string
+	// BakeWarmImage 启动时从 WarmupImage 烘焙预热镜像，成功后预热容器改用该镜像
+	BakeWarmImage bool
+	// BakeCommand 烘焙时执行的预热命令，为空时使用 sandbox.DefaultBakeCommand
+	BakeCommand []string
+	// WorkspaceVolume 冷容器工作区所在的共享卷，nil 时绑定挂载 HostRoot 下的项目目录
+	WorkspaceVolume *sandbox.WorkspaceVolume
+	// Flags 功能开关，nil 时使用内置默认值
+	Flags *featureflag.Flags
+	// Notifier 运维告警，nil 时不发送
+	Notifier *notify.Notifier
+	// CreateFailureAlert 容器连续创建失败达到此次数时告警，0 表示不告警
+	CreateFailureAlert int
+	// CooldownBase / CooldownMax 补充连续失败后暂停补充的初始时长和上限，连续进入冷却时时长翻倍；
+	// 0 时使用 DefaultCooldownBase / DefaultCooldownMax
+	CooldownBase time.Duration
+	CooldownMax  time.Duration
+	// Events 预热池进入冷却和恢复时在 eventbus.PlatformChannel 上发布事件，nil 时不发布
+	Events eventbus.EventBus
+	// Autoscale 按获取压力动态调整目标空闲数，启用后 MinIdle 只作为初始值
+	Autoscale AutoscaleConfig
+	// WarmPools 默认池（WarmupImage）之外按镜像划分的预热池，各自维护空闲容器和名额，
+	// 其余配置与默认池相同
+	WarmPools []WarmPoolConfig
+	// RecycleOnRelease 归还的预热容器清理后放回空闲列表，而不是删除后重新创建。
+	// 清理只覆盖进程、工作区、/tmp 和 /app/.platform，根文件系统的修改会保留给下一个会话，
+	// 多租户环境应同时启用 ReadOnlyRootFS
+	RecycleOnRelease bool
+	// Prewarm 按时间窗口调整默认池的最少空闲数，不在任何窗口内时使用 MinIdle。
+	// 启用自动扩缩容时作为目标空闲数的下限
+	Prewarm []PrewarmWindow
+	// PrewarmLocation 解释 Prewarm 时间窗口使用的时区，nil 时使用本地时区
+	PrewarmLocation *time.Location
+	// Sessions 用户和项目的并发 session 配额，context 中带有 quota.Lease 时 Acquire 前再次检查；nil 时不限制
+	Sessions *quota.ConcurrencyLimiter
+	// BatchMaxWait 批处理 Acquire 排队超过该时间后不再让位于交互式请求，0 时使用 DefaultBatchMaxWait
+	BatchMaxWait time.Duration
+	// Scheduler 选择预热池、排队顺序、空闲容器和冷启动节点的调度策略，nil 时使用 DefaultScheduler
+	Scheduler Scheduler
+	// Nodes 冷启动容器按剩余容量放置到的 Docker 节点，nil 时只使用本机；预热池始终在本机
+	Nodes *nodes.Registry
+	// AgentHealthTimeout 单次 agent gRPC 健康检查的超时，0 时使用 DefaultAgentHealthTimeout
+	AgentHealthTimeout time.Duration
+	// AgentHealthFailures agent 连续健康检查失败达到此次数时移出预热池，0 时使用 DefaultAgentHealthFailures
+	AgentHealthFailures int
+	// MaxContainerAge 预热容器从创建起的最长存活时间，超过后空闲的容器被移除并重建，
+	// 租出的容器归还时直接删除而不回收；0 表示不限制
+	MaxContainerAge time.Duration
+	// IdleTTL 容器连续空闲超过该时长后移除，并把目标空闲数下调一个，直到该池再次被 Acquire；0 表示不移除
+	IdleTTL time.Duration
+}
+
+// DefaultWarmPool 使用 WarmupImage 的默认预热池名称
+const DefaultWarmPool = "default"
+
+// WarmPoolConfig 一个按镜像划分的预热池，Acquire 可以用 Name 或 Image 选择
+type WarmPoolConfig struct {
+	Name     string
+	Image    string
+	MinIdle  int
+	MaxBurst int
 }
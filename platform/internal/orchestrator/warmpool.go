@@ -0,0 +1,47 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseWarmPools 解析 "python=python-agent:3.12|2|5,node=node-agent:latest" 形式的预热池配置，
+// 每项为 name=image|min_idle|max_burst，省略的数量使用默认池的 minIdle / maxBurst
+func ParseWarmPools(s string, minIdle, maxBurst int) ([]WarmPoolConfig, error) {
+	var pools []WarmPoolConfig
+	seen := map[string]bool{DefaultWarmPool: true}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid warm pool %q (expected name=image|min_idle|max_burst)", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("invalid warm pool %q: duplicate or reserved name", name)
+		}
+		seen[name] = true
+
+		fields := strings.Split(spec, "|")
+		if len(fields) > 3 || strings.TrimSpace(fields[0]) == "" {
+			return nil, fmt.Errorf("invalid warm pool %q (expected name=image|min_idle|max_burst)", part)
+		}
+		wp := WarmPoolConfig{Name: name, Image: strings.TrimSpace(fields[0]), MinIdle: minIdle, MaxBurst: maxBurst}
+		for i, dst := range []*int{&wp.MinIdle, &wp.MaxBurst} {
+			if len(fields) <= i+1 {
+				break
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(fields[i+1]))
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid warm pool %q: counts must be non-negative integers", part)
+			}
+			*dst = n
+		}
+		pools = append(pools, wp)
+	}
+	return pools, nil
+}
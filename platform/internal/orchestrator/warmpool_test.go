@@ -0,0 +1,49 @@
+package orchestrator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseWarmPools(t *testing.T) {
+	got, err := ParseWarmPools("python=python-agent:3.12|1|4, node=node-agent:latest", 2, 10)
+	if err != nil {
+		t.Fatalf("ParseWarmPools: %v", err)
+	}
+	want := []WarmPoolConfig{
+		{Name: "python", Image: "python-agent:3.12", MinIdle: 1, MaxBurst: 4},
+		{Name: "node", Image: "node-agent:latest", MinIdle: 2, MaxBurst: 10},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseWarmPools = %+v, want %+v", got, want)
+	}
+
+	for _, bad := range []string{"python", "=img", "default=img", "a=img,a=img2", "a=img|x", "a=img|1|2|3", "a=|1"} {
+		if _, err := ParseWarmPools(bad, 2, 10); err == nil {
+			t.Errorf("ParseWarmPools(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestWarmPoolSelection(t *testing.T) {
+	python := &Pool{name: "python", config: PoolConfig{WarmupImage: "python-agent:3.12"}}
+	root := &Pool{
+		name:     DefaultWarmPool,
+		config:   PoolConfig{WarmupImage: "agent-runtime:latest"},
+		profiles: map[string]*Pool{"python": python},
+	}
+
+	for selector, want := range map[string]*Pool{
+		"":                     root,
+		"agent-runtime:latest": root,
+		"python":               python,
+		"python-agent:3.12":    python,
+	} {
+		if got, err := root.warmPool(selector); err != nil || got != want {
+			t.Errorf("warmPool(%q) = %v, %v", selector, got, err)
+		}
+	}
+	if root.HasWarmPool("node-agent:latest") {
+		t.Error("HasWarmPool accepted an unconfigured image")
+	}
+}
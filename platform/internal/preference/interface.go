@@ -0,0 +1,10 @@
+package preference
+
+import "context"
+
+type Store interface {
+	// Get 返回用户偏好，用户未设置时返回 ErrNotFound
+	Get(ctx context.Context, userID string) (*Preferences, error)
+	Upsert(ctx context.Context, prefs *Preferences) error
+	Delete(ctx context.Context, userID string) error
+}
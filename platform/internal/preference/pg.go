@@ -0,0 +1,77 @@
+package preference
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+var ErrNotFound = errors.New("preferences not found")
+
+var _ Store = (*PGStore)(nil)
+
+type PGStore struct {
+	db *pg.DB
+}
+
+func NewPGStore(db *pg.DB) *PGStore {
+	return &PGStore{db: db}
+}
+
+// Migrate 创建 user_preferences 表
+func Migrate(db *pg.DB) error {
+	if err := db.Model(&PreferenceModel{}).CreateTable(&orm.CreateTableOptions{
+		IfNotExists: true,
+	}); err != nil {
+		return fmt.Errorf("create user_preferences table: %w", err)
+	}
+	return nil
+}
+
+func (s *PGStore) Get(ctx context.Context, userID string) (*Preferences, error) {
+	model := &PreferenceModel{UserID: userID}
+	if err := s.db.ModelContext(ctx, model).WherePK().Select(); err != nil {
+		if errors.Is(err, pg.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return model.toPreferences(), nil
+}
+
+func (s *PGStore) Upsert(ctx context.Context, prefs *Preferences) error {
+	prefs.UpdatedAt = time.Now()
+	model := &PreferenceModel{
+		UserID:          prefs.UserID,
+		DefaultStrategy: prefs.DefaultStrategy,
+		DefaultImage:    prefs.DefaultImage,
+		DefaultEnvVars:  prefs.DefaultEnvVars,
+		Notifications:   prefs.Notifications,
+		UpdatedAt:       prefs.UpdatedAt,
+	}
+
+	_, err := s.db.ModelContext(ctx, model).
+		OnConflict("(user_id) DO UPDATE").
+		Set("default_strategy = EXCLUDED.default_strategy").
+		Set("default_image = EXCLUDED.default_image").
+		Set("default_env_vars = EXCLUDED.default_env_vars").
+		Set("notifications = EXCLUDED.notifications").
+		Set("updated_at = EXCLUDED.updated_at").
+		Insert()
+	return err
+}
+
+func (s *PGStore) Delete(ctx context.Context, userID string) error {
+	res, err := s.db.ModelContext(ctx, &PreferenceModel{UserID: userID}).WherePK().Delete()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package preference
+
+import (
+	"platform/internal/orchestrator"
+	"time"
+)
+
+// NotificationSettings 用户的通知偏好
+type NotificationSettings struct {
+	WebhookURL     string `json:"webhook_url,omitempty"`
+	Email          string `json:"email,omitempty"`
+	OnSessionReady bool   `json:"on_session_ready"`
+	OnSessionError bool   `json:"on_session_error"`
+}
+
+// Preferences 用户级默认设置，在创建 Session 时填充请求中省略的字段
+type Preferences struct {
+	UserID          string                    `json:"user_id"`
+	DefaultStrategy orchestrator.StrategyType `json:"default_strategy,omitempty"`
+	DefaultImage    string                    `json:"default_image,omitempty"`
+	DefaultEnvVars  []string                  `json:"default_env_vars,omitempty"`
+	Notifications   NotificationSettings      `json:"notifications"`
+	UpdatedAt       time.Time                 `json:"updated_at"`
+}
+
+// PreferenceModel 对应 user_preferences 表
+type PreferenceModel struct {
+	tableName struct{} `pg:"user_preferences"`
+
+	UserID          string                    `pg:"user_id,pk"`
+	DefaultStrategy orchestrator.StrategyType `pg:"default_strategy"`
+	DefaultImage    string                    `pg:"default_image"`
+	DefaultEnvVars  []string                  `pg:"default_env_vars,array"`
+	Notifications   NotificationSettings      `pg:"notifications,type:jsonb"`
+	UpdatedAt       time.Time                 `pg:"updated_at,notnull"`
+}
+
+func (m *PreferenceModel) toPreferences() *Preferences {
+	return &Preferences{
+		UserID:          m.UserID,
+		DefaultStrategy: m.DefaultStrategy,
+		DefaultImage:    m.DefaultImage,
+		DefaultEnvVars:  m.DefaultEnvVars,
+		Notifications:   m.Notifications,
+		UpdatedAt:       m.UpdatedAt,
+	}
+}
+
+// MergeEnvVars 以 KEY=VALUE 形式合并环境变量，overrides 中的同名变量优先
+func MergeEnvVars(defaults, overrides []string) []string {
+	if len(defaults) == 0 {
+		return overrides
+	}
+
+	keys := make(map[string]bool, len(overrides))
+	for _, kv := range overrides {
+		keys[envKey(kv)] = true
+	}
+
+	merged := make([]string, 0, len(defaults)+len(overrides))
+	for _, kv := range defaults {
+		if !keys[envKey(kv)] {
+			merged = append(merged, kv)
+		}
+	}
+	return append(merged, overrides...)
+}
+
+func envKey(kv string) string {
+	for i := 0; i < len(kv); i++ {
+		if kv[i] == '=' {
+			return kv[:i]
+		}
+	}
+	return kv
+}
@@ -0,0 +1,10 @@
+package projectstack
+
+import "context"
+
+type Store interface {
+	// Get 返回项目的默认服务，项目未声明时返回 ErrNotFound
+	Get(ctx context.Context, projectID string) (*Stack, error)
+	Upsert(ctx context.Context, stack *Stack) error
+	Delete(ctx context.Context, projectID string) error
+}
@@ -0,0 +1,73 @@
+package projectstack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+var ErrNotFound = errors.New("project stack not found")
+
+var _ Store = (*PGStore)(nil)
+
+type PGStore struct {
+	db *pg.DB
+}
+
+func NewPGStore(db *pg.DB) *PGStore {
+	return &PGStore{db: db}
+}
+
+// Migrate 创建 project_stacks 表
+func Migrate(db *pg.DB) error {
+	if err := db.Model(&StackModel{}).CreateTable(&orm.CreateTableOptions{
+		IfNotExists: true,
+	}); err != nil {
+		return fmt.Errorf("create project_stacks table: %w", err)
+	}
+	return nil
+}
+
+func (s *PGStore) Get(ctx context.Context, projectID string) (*Stack, error) {
+	model := &StackModel{ProjectID: projectID}
+	if err := s.db.ModelContext(ctx, model).WherePK().Select(); err != nil {
+		if errors.Is(err, pg.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return model.toStack(), nil
+}
+
+func (s *PGStore) Upsert(ctx context.Context, stack *Stack) error {
+	stack.UpdatedAt = time.Now()
+	model := &StackModel{
+		ProjectID: stack.ProjectID,
+		Services:  stack.Services,
+		Compose:   stack.Compose,
+		UpdatedAt: stack.UpdatedAt,
+	}
+
+	_, err := s.db.ModelContext(ctx, model).
+		OnConflict("(project_id) DO UPDATE").
+		Set("services = EXCLUDED.services").
+		Set("compose = EXCLUDED.compose").
+		Set("updated_at = EXCLUDED.updated_at").
+		Insert()
+	return err
+}
+
+func (s *PGStore) Delete(ctx context.Context, projectID string) error {
+	res, err := s.db.ModelContext(ctx, &StackModel{ProjectID: projectID}).WherePK().Delete()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// Package projectstack 保存项目声明的默认伴随服务和 compose stack，
+// 创建该项目的 session 时自动启动，随 session 终止删除
+package projectstack
+
+import "time"
+
+// Service 项目声明的伴随服务，字段含义与 session 伴随服务请求相同
+type Service struct {
+	Name        string   `json:"name"`
+	Image       string   `json:"image"`
+	EnvVars     []string `json:"env_vars,omitempty"`
+	Cmd         []string `json:"cmd,omitempty"`
+	ExposePorts []int    `json:"expose_ports,omitempty"`
+	// Scope session（默认）或 project
+	Scope    string  `json:"scope,omitempty"`
+	MemoryMB int     `json:"memory_mb,omitempty"`
+	CPUs     float64 `json:"cpus,omitempty"`
+}
+
+// Compose 项目声明的 compose stack
+type Compose struct {
+	Name        string           `json:"name"`
+	Content     string           `json:"content"`
+	ExposePorts map[string][]int `json:"expose_ports,omitempty"`
+}
+
+// Stack 项目的默认服务，Services 按声明顺序启动，之后启动 Compose
+type Stack struct {
+	ProjectID string    `json:"project_id"`
+	Services  []Service `json:"services"`
+	Compose   *Compose  `json:"compose,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Empty 没有声明任何服务
+func (s *Stack) Empty() bool {
+	return len(s.Services) == 0 && s.Compose == nil
+}
+
+// StackModel 对应 project_stacks 表
+type StackModel struct {
+	tableName struct{} `pg:"project_stacks"`
+
+	ProjectID string    `pg:"project_id,pk"`
+	Services  []Service `pg:"services,type:jsonb"`
+	Compose   *Compose  `pg:"compose,type:jsonb"`
+	UpdatedAt time.Time `pg:"updated_at,notnull"`
+}
+
+func (m *StackModel) toStack() *Stack {
+	return &Stack{
+		ProjectID: m.ProjectID,
+		Services:  m.Services,
+		Compose:   m.Compose,
+		UpdatedAt: m.UpdatedAt,
+	}
+}
@@ -0,0 +1,245 @@
+// Package queuepos 计算排队中的会话创建任务的排队位置和预计就绪时间（ETA），
+// ETA 按最近成功创建的耗时分位数和 Worker 并发数粗略估算，供客户端决定继续等待还是取消
+package queuepos
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	"platform/internal/eventbus"
+	"platform/internal/session"
+	"platform/internal/taskstatus"
+)
+
+const (
+	// DefaultQueue 会话创建任务所在的 asynq 队列
+	DefaultQueue = "default"
+	// maxScan 查找排队位置时最多扫描的排队任务数，排在更后面的 session 不计算位置
+	maxScan  = 1000
+	pageSize = 100
+)
+
+// 会话创建任务的排队状态
+const (
+	StatePending = "pending"
+	StateActive  = "active"
+)
+
+// Inspector 估算所需的 asynq Inspector 子集，*asynq.Inspector 满足该接口
+type Inspector interface {
+	ListPendingTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error)
+	Servers() ([]*asynq.ServerInfo, error)
+}
+
+// Position 一个会话创建任务的排队位置和预计就绪时间，作为 EventSessionQueued 的负载
+type Position struct {
+	SessionID string `json:"session_id"`
+	// State pending 表示排队中，active 表示 Worker 正在处理
+	State string `json:"state"`
+	// Position 排队中时为 1 起的位置（前面的任务包括其他类型），处理中时为 0
+	Position int `json:"position"`
+	// Phase 处理中时任务所处的阶段
+	Phase taskstatus.Phase `json:"phase,omitempty"`
+	// ETASeconds / ETAP90Seconds 按最近创建耗时的 p50 / p90 估算的剩余秒数，Samples 为 0 时不可用
+	ETASeconds    int `json:"eta_seconds"`
+	ETAP90Seconds int `json:"eta_p90_seconds"`
+	// Samples 估算所依据的最近成功创建数
+	Samples int `json:"samples"`
+	// Workers 处理该队列的 Worker 总并发数
+	Workers int `json:"workers"`
+}
+
+type Config struct {
+	// Queue 会话创建任务所在的队列，为空时使用 DefaultQueue
+	Queue string
+	// Interval 发布排队位置事件的间隔
+	Interval time.Duration
+}
+
+// Estimator 查询会话创建任务的排队位置，并定期向排队中的 session 发布位置事件
+type Estimator struct {
+	inspector Inspector
+	tracker   *taskstatus.Tracker
+	bus       eventbus.EventBus
+	config    Config
+	logger    *slog.Logger
+	stopCh    chan struct{}
+
+	// last 上次发布的位置，位置不变时不重复发布
+	last map[string]int
+}
+
+func NewEstimator(inspector Inspector, tracker *taskstatus.Tracker, bus eventbus.EventBus, config Config, logger *slog.Logger) *Estimator {
+	if config.Queue == "" {
+		config.Queue = DefaultQueue
+	}
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Second
+	}
+	return &Estimator{
+		inspector: inspector,
+		tracker:   tracker,
+		bus:       bus,
+		config:    config,
+		logger:    logger.With("component", "queue-position"),
+		stopCh:    make(chan struct{}),
+		last:      make(map[string]int),
+	}
+}
+
+// snapshot 一次查询得到的队列状态
+type snapshot struct {
+	// pending 排队中的会话创建任务的位置，key 为 session ID
+	pending map[string]int
+	active  map[string]taskstatus.TaskView
+	workers int
+	busy    int
+	latency taskstatus.Latency
+}
+
+func (e *Estimator) snapshot(ctx context.Context) (*snapshot, error) {
+	snap := &snapshot{pending: make(map[string]int), active: make(map[string]taskstatus.TaskView)}
+
+	servers, err := e.inspector.Servers()
+	if err != nil {
+		return nil, err
+	}
+	for _, srv := range servers {
+		if len(srv.Queues) > 0 {
+			if _, ok := srv.Queues[e.config.Queue]; !ok {
+				continue
+			}
+		}
+		snap.workers += srv.Concurrency
+		snap.busy += len(srv.ActiveWorkers)
+	}
+
+	for page := 1; (page-1)*pageSize < maxScan; page++ {
+		tasks, err := e.inspector.ListPendingTasks(e.config.Queue, asynq.PageSize(pageSize), asynq.Page(page))
+		if err != nil {
+			return nil, err
+		}
+		for i, t := range tasks {
+			if t.Type == session.SessionCreateTask {
+				snap.pending[t.ID] = (page-1)*pageSize + i + 1
+			}
+		}
+		if len(tasks) < pageSize {
+			break
+		}
+	}
+
+	// 没有 Tracker 时无法得知处理中的任务和创建耗时，只返回排队位置
+	if e.tracker == nil {
+		return snap, nil
+	}
+	if views, err := e.tracker.List(ctx); err == nil {
+		for _, v := range views {
+			snap.active[v.SessionID] = v
+		}
+	}
+	if snap.latency, err = e.tracker.Latency(ctx, session.SessionCreateTask); err != nil {
+		e.logger.Warn("Failed to load session creation latency", "error", err)
+	}
+	return snap, nil
+}
+
+// position 估算 sessionID 的排队位置，不在队列中也未在处理时返回 false
+func (s *snapshot) position(sessionID string, now time.Time) (*Position, bool) {
+	pos := &Position{SessionID: sessionID, Samples: s.latency.Samples, Workers: s.workers}
+	if n, ok := s.pending[sessionID]; ok {
+		pos.State = StatePending
+		pos.Position = n
+		pos.ETASeconds = seconds(pendingETA(n, s.workers, s.busy, s.latency.P50))
+		pos.ETAP90Seconds = seconds(pendingETA(n, s.workers, s.busy, s.latency.P90))
+		return pos, true
+	}
+	if v, ok := s.active[sessionID]; ok {
+		elapsed := now.Sub(v.StartedAt)
+		pos.State = StateActive
+		pos.Phase = v.Phase
+		pos.ETASeconds = seconds(max(s.latency.P50-elapsed, 0))
+		pos.ETAP90Seconds = seconds(max(s.latency.P90-elapsed, 0))
+		return pos, true
+	}
+	return nil, false
+}
+
+// pendingETA 排在第 n 位的任务预计多久后完成：空闲 Worker 足够时立即开始，
+// 否则每轮可开始 workers 个任务，每轮按一次创建耗时计算
+func pendingETA(n, workers, busy int, latency time.Duration) time.Duration {
+	workers = max(workers, 1)
+	free := max(workers-busy, 0)
+	if n <= free {
+		return latency
+	}
+	rounds := (n-free-1)/workers + 1
+	return time.Duration(rounds+1) * latency
+}
+
+func seconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// Estimate 返回 sessionID 的排队位置，session 不在排队也未在处理（已就绪、等待重试等）时返回 nil
+func (e *Estimator) Estimate(ctx context.Context, sessionID string) (*Position, error) {
+	snap, err := e.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pos, _ := snap.position(sessionID, time.Now())
+	return pos, nil
+}
+
+// Start 启动发布循环（阻塞，应在 goroutine 中调用）。每个实例都会发布，多实例部署时客户端可能收到重复的位置事件
+func (e *Estimator) Start() {
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.stopCh:
+			return
+		case <-ticker.C:
+			e.publish()
+		}
+	}
+}
+
+// Stop 停止发布循环
+func (e *Estimator) Stop() {
+	select {
+	case <-e.stopCh:
+	default:
+		close(e.stopCh)
+	}
+}
+
+// publish 向排队中的 session 发布位置变化
+func (e *Estimator) publish() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	snap, err := e.snapshot(ctx)
+	if err != nil {
+		e.logger.Warn("Failed to inspect session create queue", "error", err)
+		return
+	}
+	now := time.Now()
+	for id, n := range snap.pending {
+		if last, ok := e.last[id]; ok && last == n {
+			continue
+		}
+		pos, _ := snap.position(id, now)
+		e.bus.Publish(ctx, id, eventbus.Event{
+			Type:      eventbus.EventSessionQueued,
+			SessionID: id,
+			Payload:   pos,
+			Timestamp: now,
+		})
+	}
+	e.last = snap.pending
+}
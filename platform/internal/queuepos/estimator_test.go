@@ -0,0 +1,70 @@
+package queuepos
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	"platform/internal/session"
+)
+
+type fakeInspector struct {
+	pending []*asynq.TaskInfo
+	servers []*asynq.ServerInfo
+}
+
+func (f *fakeInspector) ListPendingTasks(queue string, opts ...asynq.ListOption) ([]*asynq.TaskInfo, error) {
+	return f.pending, nil
+}
+
+func (f *fakeInspector) Servers() ([]*asynq.ServerInfo, error) {
+	return f.servers, nil
+}
+
+func TestEstimatePosition(t *testing.T) {
+	insp := &fakeInspector{
+		pending: []*asynq.TaskInfo{
+			{ID: "s1", Type: session.SessionCreateTask},
+			{ID: "gc", Type: "gc:sweep"},
+			{ID: "s2", Type: session.SessionCreateTask},
+		},
+		servers: []*asynq.ServerInfo{
+			{Concurrency: 2, Queues: map[string]int{DefaultQueue: 1}, ActiveWorkers: make([]*asynq.WorkerInfo, 2)},
+			{Concurrency: 8, Queues: map[string]int{"other": 1}},
+		},
+	}
+	e := NewEstimator(insp, nil, nil, Config{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	pos, err := e.Estimate(context.Background(), "s2")
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if pos == nil || pos.State != StatePending || pos.Position != 3 || pos.Workers != 2 {
+		t.Errorf("Estimate(s2) = %+v, want pending at position 3 with 2 workers", pos)
+	}
+	if pos, _ := e.Estimate(context.Background(), "missing"); pos != nil {
+		t.Errorf("Estimate(missing) = %+v, want nil", pos)
+	}
+}
+
+func TestPendingETA(t *testing.T) {
+	tests := []struct {
+		n, workers, busy int
+		want             time.Duration
+	}{
+		{1, 4, 0, 10 * time.Second},
+		{4, 4, 0, 10 * time.Second},
+		{1, 4, 4, 20 * time.Second},
+		{5, 4, 4, 30 * time.Second},
+		{3, 0, 0, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := pendingETA(tt.n, tt.workers, tt.busy, 10*time.Second); got != tt.want {
+			t.Errorf("pendingETA(%d, %d, %d) = %s, want %s", tt.n, tt.workers, tt.busy, got, tt.want)
+		}
+	}
+}
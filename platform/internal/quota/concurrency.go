@@ -0,0 +1,213 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrTooManySessions 创建 session 会超出用户或项目的并发配额，API 返回 429
+var ErrTooManySessions = errors.New("too many concurrent sessions")
+
+// SessionLimits 用户或项目同时运行的 session 上限，字段为 0 表示不限制
+type SessionLimits struct {
+	Sessions    int
+	MemoryBytes int64
+	CPUs        float64
+}
+
+func (l SessionLimits) IsZero() bool {
+	return l.Sessions == 0 && l.MemoryBytes == 0 && l.CPUs == 0
+}
+
+// Lease 一个 session 对并发配额的占用
+type Lease struct {
+	SessionID   string
+	User        string
+	Project     string
+	MemoryBytes int64
+	NanoCPUs    int64
+}
+
+// acquireScript 检查所有 scope 加上本 session 后是否超限，全部通过才登记。
+// 每个 scope 是一个 hash，field 为 session ID，value 为 "<memory>,<nano_cpus>"；
+// 已登记的 session 重复获取直接成功。超限时返回 {scope 序号, 资源, 已用, 上限}
+var acquireScript = redis.NewScript(`
+local mem, cpu = tonumber(ARGV[3]), tonumber(ARGV[4])
+for i, key in ipairs(KEYS) do
+	if redis.call("HEXISTS", key, ARGV[1]) == 0 then
+		local base = 4 + (i - 1) * 3
+		local maxSessions, maxMem, maxCPU = tonumber(ARGV[base + 1]), tonumber(ARGV[base + 2]), tonumber(ARGV[base + 3])
+		local vals = redis.call("HVALS", key)
+		local usedMem, usedCPU = 0, 0
+		for _, v in ipairs(vals) do
+			local sep = string.find(v, ",", 1, true)
+			usedMem = usedMem + tonumber(string.sub(v, 1, sep - 1))
+			usedCPU = usedCPU + tonumber(string.sub(v, sep + 1))
+		end
+		if maxSessions > 0 and #vals + 1 > maxSessions then
+			return {i, "sessions", tostring(#vals), tostring(maxSessions)}
+		end
+		if maxMem > 0 and usedMem + mem > maxMem then
+			return {i, "memory", tostring(usedMem), tostring(maxMem)}
+		end
+		if maxCPU > 0 and usedCPU + cpu > maxCPU then
+			return {i, "cpu", tostring(usedCPU), tostring(maxCPU)}
+		end
+	end
+end
+for _, key in ipairs(KEYS) do
+	redis.call("HSET", key, ARGV[1], ARGV[2])
+end
+return 0`)
+
+// ConcurrencyLimiter 用 Redis 计数限制每个用户和每个项目同时运行的 session 数量与资源总量。
+// 检查与登记在一个 Lua 脚本中完成，多个控制面实例之间也不会超出上限
+type ConcurrencyLimiter struct {
+	client  redis.Cmdable
+	user    SessionLimits
+	project SessionLimits
+	// 每个 session 计入的内存和 CPU，即沙箱容器的资源上限
+	memoryBytes int64
+	nanoCPUs    int64
+}
+
+func NewConcurrencyLimiter(client redis.Cmdable, user, project SessionLimits, memoryBytes int64, cpus float64) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{client: client, user: user, project: project, memoryBytes: memoryBytes, nanoCPUs: int64(cpus * 1e9)}
+}
+
+// Lease 返回 session 的占用，按每个 session 的资源计入；l 为 nil 时资源为 0
+func (l *ConcurrencyLimiter) Lease(sessionID, user, project string) Lease {
+	lease := Lease{SessionID: sessionID, User: user, Project: project}
+	if l != nil {
+		lease.MemoryBytes, lease.NanoCPUs = l.memoryBytes, l.nanoCPUs
+	}
+	return lease
+}
+
+func sessionsKey(scope, id string) string {
+	return "quota:sessions:" + scope + ":" + id
+}
+
+type leaseScope struct {
+	name   string
+	id     string
+	limits SessionLimits
+}
+
+func (l *ConcurrencyLimiter) scopes(lease Lease) []leaseScope {
+	var scopes []leaseScope
+	if lease.User != "" && !l.user.IsZero() {
+		scopes = append(scopes, leaseScope{"user", lease.User, l.user})
+	}
+	if lease.Project != "" && !l.project.IsZero() {
+		scopes = append(scopes, leaseScope{"project", lease.Project, l.project})
+	}
+	return scopes
+}
+
+// Acquire 登记 lease，加上它后用户或项目超出上限时返回包装了 ErrTooManySessions 的错误。
+// 同一 session 重复登记直接成功
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context, lease Lease) error {
+	if l == nil {
+		return nil
+	}
+	scopes := l.scopes(lease)
+	if len(scopes) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(scopes))
+	args := []any{lease.SessionID, fmt.Sprintf("%d,%d", lease.MemoryBytes, lease.NanoCPUs), lease.MemoryBytes, lease.NanoCPUs}
+	for i, s := range scopes {
+		keys[i] = sessionsKey(s.name, s.id)
+		args = append(args, s.limits.Sessions, s.limits.MemoryBytes, int64(s.limits.CPUs*1e9))
+	}
+	res, err := acquireScript.Run(ctx, l.client, keys, args...).Result()
+	if err != nil {
+		return fmt.Errorf("failed to acquire session quota: %w", err)
+	}
+	denied, ok := res.([]any)
+	if !ok || len(denied) != 4 {
+		return nil
+	}
+	idx, _ := denied[0].(int64)
+	if idx < 1 || int(idx) > len(scopes) {
+		return fmt.Errorf("failed to acquire session quota: unexpected script result %v", res)
+	}
+	resource, _ := denied[1].(string)
+	used, _ := denied[2].(string)
+	limit, _ := denied[3].(string)
+	return exceeded(scopes[idx-1], resource, used, limit)
+}
+
+func exceeded(s leaseScope, resource, used, limit string) error {
+	switch resource {
+	case "memory":
+		// Lua 以浮点数计算，数值按 %.14g 格式化
+		u, _ := strconv.ParseFloat(used, 64)
+		m, _ := strconv.ParseFloat(limit, 64)
+		return fmt.Errorf("%w: %s %s already uses %dMB memory (limit %dMB)", ErrTooManySessions, s.name, s.id, int64(u)>>20, int64(m)>>20)
+	case "cpu":
+		u, _ := strconv.ParseFloat(used, 64)
+		m, _ := strconv.ParseFloat(limit, 64)
+		return fmt.Errorf("%w: %s %s already uses %.2f CPUs (limit %.2f)", ErrTooManySessions, s.name, s.id, u/1e9, m/1e9)
+	}
+	return fmt.Errorf("%w: %s %s already runs %s sessions (limit %s)", ErrTooManySessions, s.name, s.id, used, limit)
+}
+
+// Release 释放 session 的占用，重复释放无副作用
+func (l *ConcurrencyLimiter) Release(ctx context.Context, lease Lease) error {
+	if l == nil {
+		return nil
+	}
+	pipe := l.client.TxPipeline()
+	if lease.User != "" {
+		pipe.HDel(ctx, sessionsKey("user", lease.User), lease.SessionID)
+	}
+	if lease.Project != "" {
+		pipe.HDel(ctx, sessionsKey("project", lease.Project), lease.SessionID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to release session quota: %w", err)
+	}
+	return nil
+}
+
+// Holders 返回占用 lease 所属用户和项目配额的 session ID，用于清理已结束但未释放的占用
+func (l *ConcurrencyLimiter) Holders(ctx context.Context, lease Lease) ([]string, error) {
+	if l == nil {
+		return nil, nil
+	}
+	seen := make(map[string]bool)
+	var ids []string
+	for _, s := range l.scopes(lease) {
+		fields, err := l.client.HKeys(ctx, sessionsKey(s.name, s.id)).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range fields {
+			if !seen[id] && id != lease.SessionID {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+type leaseKey struct{}
+
+// WithLease 将 session 的配额占用放入 context，预热池 Acquire 时据此再次检查
+func WithLease(ctx context.Context, lease Lease) context.Context {
+	return context.WithValue(ctx, leaseKey{}, lease)
+}
+
+// LeaseFrom 返回 WithLease 设置的占用
+func LeaseFrom(ctx context.Context) (Lease, bool) {
+	lease, ok := ctx.Value(leaseKey{}).(Lease)
+	return lease, ok
+}
@@ -0,0 +1,70 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConcurrencyLimiterScopes(t *testing.T) {
+	l := NewConcurrencyLimiter(nil, SessionLimits{Sessions: 2}, SessionLimits{}, 512<<20, 1)
+	lease := l.Lease("s1", "alice", "proj")
+	if lease.MemoryBytes != 512<<20 || lease.NanoCPUs != 1e9 {
+		t.Fatalf("unexpected lease cost: %+v", lease)
+	}
+	// 项目未设置上限，只检查用户
+	scopes := l.scopes(lease)
+	if len(scopes) != 1 || scopes[0].name != "user" || scopes[0].id != "alice" {
+		t.Fatalf("scopes = %+v", scopes)
+	}
+	if scopes := l.scopes(Lease{SessionID: "s2"}); len(scopes) != 0 {
+		t.Fatalf("expected no scopes without user or project, got %+v", scopes)
+	}
+	// 没有需要检查的范围时不访问 Redis
+	if err := l.Acquire(context.Background(), Lease{SessionID: "s2"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConcurrencyLimiterNil(t *testing.T) {
+	var l *ConcurrencyLimiter
+	ctx := context.Background()
+	if err := l.Acquire(ctx, Lease{SessionID: "s1", User: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Release(ctx, Lease{SessionID: "s1", User: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if lease := l.Lease("s1", "alice", "proj"); lease.MemoryBytes != 0 || lease.User != "alice" {
+		t.Fatalf("unexpected lease from nil limiter: %+v", lease)
+	}
+}
+
+func TestExceededMessage(t *testing.T) {
+	s := leaseScope{name: "project", id: "proj"}
+	cases := map[string]string{
+		exceeded(s, "sessions", "3", "3").Error():                   "project proj already runs 3 sessions (limit 3)",
+		exceeded(s, "memory", "2147483648", "2147483648").Error():   "project proj already uses 2048MB memory (limit 2048MB)",
+		exceeded(s, "cpu", "2000000000", "2500000000").Error():      "project proj already uses 2.00 CPUs (limit 2.50)",
+		exceeded(s, "memory", "1.073741824e+09", "2.1e+09").Error(): "project proj already uses 1024MB memory",
+	}
+	for got, want := range cases {
+		if !strings.Contains(got, want) {
+			t.Errorf("message %q does not contain %q", got, want)
+		}
+	}
+	if err := exceeded(s, "sessions", "1", "1"); !errors.Is(err, ErrTooManySessions) {
+		t.Fatal("expected ErrTooManySessions")
+	}
+}
+
+func TestLeaseContext(t *testing.T) {
+	if _, ok := LeaseFrom(context.Background()); ok {
+		t.Fatal("expected no lease")
+	}
+	ctx := WithLease(context.Background(), Lease{SessionID: "s1"})
+	if lease, ok := LeaseFrom(ctx); !ok || lease.SessionID != "s1" {
+		t.Fatalf("LeaseFrom = %+v, %v", lease, ok)
+	}
+}
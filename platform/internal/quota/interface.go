@@ -0,0 +1,14 @@
+package quota
+
+import "context"
+
+type Store interface {
+	// Get 返回 ID 对应的占用，不存在时返回 nil
+	Get(ctx context.Context, id string) (*Allocation, error)
+	// Put 登记占用，ID 已存在时覆盖
+	Put(ctx context.Context, alloc *Allocation) error
+	Delete(ctx context.Context, id string) error
+	DeleteSession(ctx context.Context, sessionID string) error
+	SessionUsage(ctx context.Context, sessionID string) (Usage, error)
+	TenantUsage(ctx context.Context, tenantID string) (Usage, error)
+}
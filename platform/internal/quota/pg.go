@@ -0,0 +1,99 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+var _ Store = (*PGStore)(nil)
+
+type PGStore struct {
+	db *pg.DB
+}
+
+func NewPGStore(db *pg.DB) *PGStore {
+	return &PGStore{db: db}
+}
+
+// Migrate 创建 quota_allocations 表
+func Migrate(db *pg.DB) error {
+	if err := db.Model(&AllocationModel{}).CreateTable(&orm.CreateTableOptions{
+		IfNotExists: true,
+	}); err != nil {
+		return fmt.Errorf("create quota_allocations table: %w", err)
+	}
+	for _, stmt := range []string{
+		`CREATE INDEX IF NOT EXISTS quota_allocations_session_idx ON quota_allocations (session_id)`,
+		`CREATE INDEX IF NOT EXISTS quota_allocations_tenant_idx ON quota_allocations (tenant_id)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("create quota_allocations index: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *PGStore) Get(ctx context.Context, id string) (*Allocation, error) {
+	model := &AllocationModel{ID: id}
+	if err := s.db.ModelContext(ctx, model).WherePK().Select(); err != nil {
+		if errors.Is(err, pg.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &Allocation{
+		ID:        model.ID,
+		SessionID: model.SessionID,
+		TenantID:  model.TenantID,
+		Usage:     Usage{Services: model.Services, MemoryBytes: model.MemoryBytes, NanoCPUs: model.NanoCPUs},
+		CreatedAt: model.CreatedAt,
+	}, nil
+}
+
+func (s *PGStore) Put(ctx context.Context, alloc *Allocation) error {
+	_, err := s.db.ModelContext(ctx, &AllocationModel{
+		ID:          alloc.ID,
+		SessionID:   alloc.SessionID,
+		TenantID:    alloc.TenantID,
+		Services:    alloc.Usage.Services,
+		MemoryBytes: alloc.Usage.MemoryBytes,
+		NanoCPUs:    alloc.Usage.NanoCPUs,
+		CreatedAt:   alloc.CreatedAt,
+	}).
+		OnConflict("(id) DO UPDATE").
+		Set("services = EXCLUDED.services").
+		Set("memory_bytes = EXCLUDED.memory_bytes").
+		Set("nano_cpus = EXCLUDED.nano_cpus").
+		Insert()
+	return err
+}
+
+func (s *PGStore) Delete(ctx context.Context, id string) error {
+	_, err := s.db.ModelContext(ctx, &AllocationModel{ID: id}).WherePK().Delete()
+	return err
+}
+
+func (s *PGStore) DeleteSession(ctx context.Context, sessionID string) error {
+	_, err := s.db.ModelContext(ctx, (*AllocationModel)(nil)).Where("session_id = ?", sessionID).Delete()
+	return err
+}
+
+func (s *PGStore) SessionUsage(ctx context.Context, sessionID string) (Usage, error) {
+	return s.sum(ctx, "session_id", sessionID)
+}
+
+func (s *PGStore) TenantUsage(ctx context.Context, tenantID string) (Usage, error) {
+	return s.sum(ctx, "tenant_id", tenantID)
+}
+
+func (s *PGStore) sum(ctx context.Context, column, value string) (Usage, error) {
+	var u Usage
+	_, err := s.db.QueryOneContext(ctx, pg.Scan(&u.Services, &u.MemoryBytes, &u.NanoCPUs),
+		`SELECT COALESCE(SUM(services), 0), COALESCE(SUM(memory_bytes), 0), COALESCE(SUM(nano_cpus), 0)
+		FROM quota_allocations WHERE ?0 = ?1`, pg.Ident(column), value)
+	return u, err
+}
@@ -0,0 +1,112 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Tracker 在创建资源前检查配额并登记占用。
+// 检查与登记在进程内串行，多个控制面实例并发创建时可能短暂超出租户配额
+type Tracker struct {
+	mu      sync.Mutex
+	store   Store
+	session Limits
+	tenant  Limits
+	logger  *slog.Logger
+}
+
+func NewTracker(store Store, session, tenant Limits, logger *slog.Logger) *Tracker {
+	return &Tracker{store: store, session: session, tenant: tenant, logger: logger}
+}
+
+// Reserve 检查登记 alloc 后 session 和租户的总占用是否超出上限，未超出时登记。
+// 同一 ID 已有占用时按差值计算，用于 compose 堆栈更新和扩缩容
+func (t *Tracker) Reserve(ctx context.Context, alloc *Allocation) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var prev Usage
+	existing, err := t.store.Get(ctx, alloc.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load quota allocation: %w", err)
+	}
+	if existing != nil {
+		prev = existing.Usage
+		alloc.CreatedAt = existing.CreatedAt
+		// 扩缩容等调用方不知道归属时沿用原有记录
+		if alloc.SessionID == "" {
+			alloc.SessionID = existing.SessionID
+		}
+		if alloc.TenantID == "" {
+			alloc.TenantID = existing.TenantID
+		}
+	}
+
+	if alloc.SessionID != "" && !t.session.IsZero() {
+		used, err := t.store.SessionUsage(ctx, alloc.SessionID)
+		if err != nil {
+			return fmt.Errorf("failed to load session quota usage: %w", err)
+		}
+		if err := used.Sub(prev).Add(alloc.Usage).check(t.session, "session"); err != nil {
+			return err
+		}
+	}
+	if alloc.TenantID != "" && !t.tenant.IsZero() {
+		used, err := t.store.TenantUsage(ctx, alloc.TenantID)
+		if err != nil {
+			return fmt.Errorf("failed to load tenant quota usage: %w", err)
+		}
+		if err := used.Sub(prev).Add(alloc.Usage).check(t.tenant, "tenant"); err != nil {
+			return err
+		}
+	}
+
+	if alloc.CreatedAt.IsZero() {
+		alloc.CreatedAt = time.Now()
+	}
+	return t.store.Put(ctx, alloc)
+}
+
+// Release 释放一项占用，失败只记录日志
+func (t *Tracker) Release(ctx context.Context, id string) {
+	if t == nil {
+		return
+	}
+	if err := t.store.Delete(ctx, id); err != nil {
+		t.logger.Warn("Failed to release quota allocation", "id", id, "error", err)
+	}
+}
+
+// ReleaseSession 释放 session 的所有占用（session 终止时调用）
+func (t *Tracker) ReleaseSession(ctx context.Context, sessionID string) {
+	if t == nil {
+		return
+	}
+	if err := t.store.DeleteSession(ctx, sessionID); err != nil {
+		t.logger.Warn("Failed to release session quota", "session_id", sessionID, "error", err)
+	}
+}
+
+// Status 配额上限与当前占用
+type Status struct {
+	Limits Limits `json:"limits"`
+	Used   Usage  `json:"used"`
+}
+
+// SessionStatus 返回 session 与其租户的配额状态
+func (t *Tracker) SessionStatus(ctx context.Context, sessionID, tenantID string) (session, tenant Status, err error) {
+	session.Limits, tenant.Limits = t.session, t.tenant
+	if session.Used, err = t.store.SessionUsage(ctx, sessionID); err != nil {
+		return
+	}
+	if tenantID != "" {
+		tenant.Used, err = t.store.TenantUsage(ctx, tenantID)
+	}
+	return
+}
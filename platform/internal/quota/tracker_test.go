@@ -0,0 +1,123 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+// memStore 内存实现，仅用于测试
+type memStore struct {
+	allocs map[string]Allocation
+}
+
+func newMemStore() *memStore {
+	return &memStore{allocs: make(map[string]Allocation)}
+}
+
+func (m *memStore) Get(_ context.Context, id string) (*Allocation, error) {
+	a, ok := m.allocs[id]
+	if !ok {
+		return nil, nil
+	}
+	return &a, nil
+}
+
+func (m *memStore) Put(_ context.Context, alloc *Allocation) error {
+	m.allocs[alloc.ID] = *alloc
+	return nil
+}
+
+func (m *memStore) Delete(_ context.Context, id string) error {
+	delete(m.allocs, id)
+	return nil
+}
+
+func (m *memStore) DeleteSession(_ context.Context, sessionID string) error {
+	for id, a := range m.allocs {
+		if a.SessionID == sessionID {
+			delete(m.allocs, id)
+		}
+	}
+	return nil
+}
+
+func (m *memStore) SessionUsage(_ context.Context, sessionID string) (Usage, error) {
+	var u Usage
+	for _, a := range m.allocs {
+		if a.SessionID == sessionID {
+			u = u.Add(a.Usage)
+		}
+	}
+	return u, nil
+}
+
+func (m *memStore) TenantUsage(_ context.Context, tenantID string) (Usage, error) {
+	var u Usage
+	for _, a := range m.allocs {
+		if a.TenantID == tenantID {
+			u = u.Add(a.Usage)
+		}
+	}
+	return u, nil
+}
+
+const mb = 1024 * 1024
+
+func svcUsage(memMB int64) Usage {
+	return Usage{Services: 1, MemoryBytes: memMB * mb, NanoCPUs: 5e8}
+}
+
+func TestTrackerSessionAndTenantLimits(t *testing.T) {
+	ctx := context.Background()
+	tr := NewTracker(newMemStore(),
+		Limits{Services: 2, MemoryBytes: 1024 * mb},
+		Limits{MemoryBytes: 1536 * mb},
+		slog.Default())
+
+	if err := tr.Reserve(ctx, &Allocation{ID: "a", SessionID: "s1", TenantID: "u1", Usage: svcUsage(512)}); err != nil {
+		t.Fatalf("first reserve: %v", err)
+	}
+	if err := tr.Reserve(ctx, &Allocation{ID: "b", SessionID: "s1", TenantID: "u1", Usage: svcUsage(512)}); err != nil {
+		t.Fatalf("second reserve: %v", err)
+	}
+	// session 服务数达到上限
+	err := tr.Reserve(ctx, &Allocation{ID: "c", SessionID: "s1", TenantID: "u1", Usage: svcUsage(1)})
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("third reserve = %v, want ErrQuotaExceeded", err)
+	}
+
+	// 另一个 session 不受 s1 的限制，但受租户内存上限约束
+	if err := tr.Reserve(ctx, &Allocation{ID: "d", SessionID: "s2", TenantID: "u1", Usage: svcUsage(512)}); err != nil {
+		t.Fatalf("other session reserve: %v", err)
+	}
+	if err := tr.Reserve(ctx, &Allocation{ID: "e", SessionID: "s2", TenantID: "u1", Usage: svcUsage(512)}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("tenant memory reserve = %v, want ErrQuotaExceeded", err)
+	}
+
+	tr.ReleaseSession(ctx, "s1")
+	if err := tr.Reserve(ctx, &Allocation{ID: "e", SessionID: "s2", TenantID: "u1", Usage: svcUsage(512)}); err != nil {
+		t.Fatalf("reserve after release: %v", err)
+	}
+}
+
+func TestTrackerReplacesExistingAllocation(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	tr := NewTracker(store, Limits{Services: 3}, Limits{}, slog.Default())
+
+	if err := tr.Reserve(ctx, &Allocation{ID: "stack", SessionID: "s1", TenantID: "u1", Usage: Usage{Services: 3}}); err != nil {
+		t.Fatal(err)
+	}
+	// 同一 ID 按差值计算：3 → 2 不超限，并沿用原有的归属
+	if err := tr.Reserve(ctx, &Allocation{ID: "stack", Usage: Usage{Services: 2}}); err != nil {
+		t.Fatalf("scale down: %v", err)
+	}
+	if a := store.allocs["stack"]; a.Usage.Services != 2 || a.SessionID != "s1" || a.TenantID != "u1" {
+		t.Fatalf("allocation = %+v", a)
+	}
+	if err := tr.Reserve(ctx, &Allocation{ID: "stack", Usage: Usage{Services: 4}}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("scale up = %v, want ErrQuotaExceeded", err)
+	}
+}
@@ -0,0 +1,84 @@
+// Package quota 限制伴随服务与 compose 服务占用的数量、内存和 CPU，
+// 按 session 和租户（session 所属用户）两级统计，占用记录持久化在数据库中；
+// 以及每个用户和项目同时运行的 session，计数保存在 Redis 中，见 ConcurrencyLimiter
+package quota
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrQuotaExceeded 创建资源会超出配额
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Limits 资源上限，字段为 0 表示不限制
+type Limits struct {
+	Services    int     `json:"services"`
+	MemoryBytes int64   `json:"memory_bytes"`
+	CPUs        float64 `json:"cpus"`
+}
+
+func (l Limits) IsZero() bool {
+	return l.Services == 0 && l.MemoryBytes == 0 && l.CPUs == 0
+}
+
+// Usage 资源占用
+type Usage struct {
+	Services    int   `json:"services"`
+	MemoryBytes int64 `json:"memory_bytes"`
+	NanoCPUs    int64 `json:"nano_cpus"`
+}
+
+func (u Usage) Add(o Usage) Usage {
+	return Usage{
+		Services:    u.Services + o.Services,
+		MemoryBytes: u.MemoryBytes + o.MemoryBytes,
+		NanoCPUs:    u.NanoCPUs + o.NanoCPUs,
+	}
+}
+
+func (u Usage) Sub(o Usage) Usage {
+	return Usage{
+		Services:    u.Services - o.Services,
+		MemoryBytes: u.MemoryBytes - o.MemoryBytes,
+		NanoCPUs:    u.NanoCPUs - o.NanoCPUs,
+	}
+}
+
+// check 返回 u 超出 l 的第一项，scope 用于错误信息
+func (u Usage) check(l Limits, scope string) error {
+	switch {
+	case l.Services > 0 && u.Services > l.Services:
+		return fmt.Errorf("%w: %s would run %d services (limit %d)", ErrQuotaExceeded, scope, u.Services, l.Services)
+	case l.MemoryBytes > 0 && u.MemoryBytes > l.MemoryBytes:
+		return fmt.Errorf("%w: %s would use %dMB memory (limit %dMB)", ErrQuotaExceeded, scope, u.MemoryBytes>>20, l.MemoryBytes>>20)
+	case l.CPUs > 0 && float64(u.NanoCPUs) > l.CPUs*1e9:
+		return fmt.Errorf("%w: %s would use %.2f CPUs (limit %.2f)", ErrQuotaExceeded, scope, float64(u.NanoCPUs)/1e9, l.CPUs)
+	}
+	return nil
+}
+
+// Allocation 一个伴随服务或 compose 堆栈的资源占用
+type Allocation struct {
+	// ID 资源标识，同一 ID 再次登记时替换原有占用
+	ID string `json:"id"`
+	// SessionID 项目级服务不归属单个 session，为空
+	SessionID string    `json:"session_id,omitempty"`
+	TenantID  string    `json:"tenant_id,omitempty"`
+	Usage     Usage     `json:"usage"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AllocationModel 对应 quota_allocations 表
+type AllocationModel struct {
+	tableName struct{} `pg:"quota_allocations"`
+
+	ID          string    `pg:"id,pk"`
+	SessionID   string    `pg:"session_id"`
+	TenantID    string    `pg:"tenant_id"`
+	Services    int       `pg:"services,use_zero"`
+	MemoryBytes int64     `pg:"memory_bytes,use_zero"`
+	NanoCPUs    int64     `pg:"nano_cpus,use_zero"`
+	CreatedAt   time.Time `pg:"created_at,notnull"`
+}
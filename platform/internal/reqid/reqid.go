@@ -0,0 +1,27 @@
+// Package reqid 在 context 中传递请求关联 ID，使一次用户操作可以在
+// API、任务队列、dispatcher、事件总线和 exec 日志之间串联追踪。
+package reqid
+
+import "context"
+
+// Header 请求 ID 的 HTTP 头
+const Header = "X-Request-ID"
+
+// MetadataKey 向 Agent 发起 gRPC 调用时携带请求 ID 的 metadata 键
+const MetadataKey = "x-request-id"
+
+type ctxKey struct{}
+
+// WithContext 返回携带请求 ID 的 context，id 为空时原样返回
+func WithContext(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext 返回 context 中的请求 ID，不存在时为空
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
@@ -0,0 +1,19 @@
+package reqid
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContextRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	if got := FromContext(ctx); got != "" {
+		t.Fatalf("expected empty id, got %q", got)
+	}
+	if WithContext(ctx, "") != ctx {
+		t.Fatal("empty id should not wrap the context")
+	}
+	if got := FromContext(WithContext(ctx, "req-1")); got != "req-1" {
+		t.Fatalf("expected req-1, got %q", got)
+	}
+}
@@ -0,0 +1,8 @@
+package sandbox
+
+// 沙箱后端（SANDBOX_BACKEND）
+const (
+	BackendDocker = "docker"
+	// BackendPodman 通过 Podman 的 Docker 兼容 REST API 运行容器，支持 rootless 部署
+	BackendPodman = "podman"
+)
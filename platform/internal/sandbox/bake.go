@@ -0,0 +1,135 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// BakedFromLabel 预烘焙镜像上记录基础镜像 ID 的标签，基础镜像更新后需要重新烘焙
+const BakedFromLabel = "agent-platform.baked-from"
+
+// BakedImageRepo 预烘焙镜像的仓库名，tag 为基础镜像 ID 前 12 位
+const BakedImageRepo = "agent-platform-baked"
+
+// DefaultBakeCommand 预编译 agent 源码的字节码并导入一次入口模块，
+// 使 Python 依赖的 .pyc 缓存和首次导入时生成的文件进入镜像
+var DefaultBakeCommand = []string{
+	"sh", "-c",
+	"python -m compileall -q /app/src > /dev/null 2>&1; cd /app && PYTHONPATH=/app python -c 'import src.main'",
+}
+
+// BakeOptions 预烘焙参数
+type BakeOptions struct {
+	BaseImage string
+	// Command 在临时容器中执行的预热命令，为空时使用 DefaultBakeCommand
+	Command []string
+	Timeout time.Duration
+}
+
+// BakedImageTag 基础镜像 ID 对应的预烘焙镜像名
+func BakedImageTag(baseImageID string) string {
+	id := strings.TrimPrefix(baseImageID, "sha256:")
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	return BakedImageRepo + ":" + id
+}
+
+// BakeImage 从基础镜像启动临时容器执行预热命令，再通过 docker commit 保存为新镜像并返回镜像名。
+// commit 只保存文件系统，不保存进程，agent server 仍需在容器启动后拉起，
+// 但依赖导入和字节码编译的开销已在镜像中。已存在对应当前基础镜像的预烘焙镜像时直接返回
+func BakeImage(ctx context.Context, cli *client.Client, opts BakeOptions, logger *slog.Logger) (string, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	command := opts.Command
+	if len(command) == 0 {
+		command = DefaultBakeCommand
+	}
+
+	base, err := cli.ImageInspect(ctx, opts.BaseImage)
+	if err == nil {
+		if tag, ok := bakedImageUpToDate(ctx, cli, base.ID); ok {
+			logger.Info("Baked image is up to date", "image", tag, "base", opts.BaseImage)
+			return tag, nil
+		}
+	} else if !errdefs.IsNotFound(err) {
+		return "", fmt.Errorf("failed to inspect base image: %w", err)
+	}
+
+	// 基础镜像不存在时由 Start 拉取
+	c := NewContainer(cli, ContainerConfig{
+		Image:           opts.BaseImage,
+		Cmd:             KeepAliveCmd,
+		UseAnonymousVol: true,
+		SessionID:       fmt.Sprintf("bake-%d", time.Now().UnixNano()),
+		ProjectID:       "bake",
+	}, "", logger)
+	if err := c.Start(ctx); err != nil {
+		return "", fmt.Errorf("failed to start bake container: %w", err)
+	}
+	defer func() {
+		rmCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := c.Remove(rmCtx); err != nil {
+			logger.Warn("Failed to remove bake container", "container_id", c.ID, "error", err)
+		}
+	}()
+
+	if base.ID == "" {
+		if base, err = cli.ImageInspect(ctx, opts.BaseImage); err != nil {
+			return "", fmt.Errorf("failed to inspect base image: %w", err)
+		}
+	}
+	tag := BakedImageTag(base.ID)
+
+	logger.Info("Baking warm image", "base", opts.BaseImage, "image", tag)
+	result, err := c.Exec(ctx, command, nil, "/app")
+	if err != nil {
+		return "", fmt.Errorf("failed to run bake command: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return "", fmt.Errorf("bake command exited with code %d: %s", result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+
+	// commit 会把临时容器的 Cmd（tail）和标签合并进镜像，显式保留基础镜像的启动命令
+	labels := make(map[string]string)
+	cfg := &container.Config{Labels: labels}
+	if base.Config != nil {
+		for k, v := range base.Config.Labels {
+			labels[k] = v
+		}
+		cfg.Cmd = base.Config.Cmd
+		cfg.Entrypoint = base.Config.Entrypoint
+	}
+	labels[BakedFromLabel] = base.ID
+	if _, err := cli.ContainerCommit(ctx, c.ID, container.CommitOptions{
+		Reference: tag,
+		Comment:   "agent-platform warm image baked from " + opts.BaseImage,
+		Config:    cfg,
+		Pause:     true,
+	}); err != nil {
+		return "", fmt.Errorf("failed to commit baked image: %w", err)
+	}
+
+	logger.Info("Baked warm image", "base", opts.BaseImage, "image", tag, "duration", result.Duration)
+	return tag, nil
+}
+
+func bakedImageUpToDate(ctx context.Context, cli *client.Client, baseID string) (string, bool) {
+	tag := BakedImageTag(baseID)
+	img, err := cli.ImageInspect(ctx, tag)
+	if err != nil || img.Config == nil {
+		return tag, false
+	}
+	return tag, img.Config.Labels[BakedFromLabel] == baseID
+}
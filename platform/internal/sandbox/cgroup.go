@@ -0,0 +1,122 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// cgroupRoot cgroup v2 统一层级的挂载点，测试中替换为临时目录
+var cgroupRoot = "/sys/fs/cgroup"
+
+// cgroupCPUPeriod cpu.max 的调度周期（微秒）
+const cgroupCPUPeriod = 100000
+
+// CgroupBudget 平台容器共享的 cgroup parent 的总资源上限，字段为 0 表示不限制
+type CgroupBudget struct {
+	MemoryBytes int64
+	CPUs        float64
+	PidsMax     int64
+}
+
+func (b CgroupBudget) IsZero() bool {
+	return b.MemoryBytes == 0 && b.CPUs == 0 && b.PidsMax == 0
+}
+
+// EnsureCgroupParent 创建平台容器共享的 cgroup v2 parent 并写入总资源上限，
+// 容器通过 HostConfig.CgroupParent 放入其下，整体不会挤占控制面和宿主机其他负载。
+// parent 以 .slice 结尾时按 systemd 的命名规则展开路径（a-b.slice → a.slice/a-b.slice），
+// 此时 systemd 重新加载可能覆盖这里写入的值，生产环境建议用 systemctl set-property 固化
+func EnsureCgroupParent(parent string, budget CgroupBudget) error {
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		return fmt.Errorf("cgroup v2 is not available at %s: %w", cgroupRoot, err)
+	}
+	rel, err := cgroupParentPath(parent)
+	if err != nil {
+		return err
+	}
+
+	dir := cgroupRoot
+	for _, part := range strings.Split(rel, "/") {
+		// 子 cgroup 要使用 cpu / memory / pids 控制器，必须在每一级父节点的 subtree_control 中启用
+		if err := enableControllers(dir, "cpu", "memory", "pids"); err != nil {
+			return err
+		}
+		dir = filepath.Join(dir, part)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create cgroup %s: %w", dir, err)
+		}
+	}
+
+	limits := map[string]string{
+		"memory.max": "max",
+		"cpu.max":    "max " + strconv.Itoa(cgroupCPUPeriod),
+		"pids.max":   "max",
+	}
+	if budget.MemoryBytes > 0 {
+		limits["memory.max"] = strconv.FormatInt(budget.MemoryBytes, 10)
+	}
+	if budget.CPUs > 0 {
+		limits["cpu.max"] = fmt.Sprintf("%d %d", int64(budget.CPUs*cgroupCPUPeriod), cgroupCPUPeriod)
+	}
+	if budget.PidsMax > 0 {
+		limits["pids.max"] = strconv.FormatInt(budget.PidsMax, 10)
+	}
+	for file, value := range limits {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(value), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// cgroupParentPath 将 CgroupParent 转为相对 cgroupRoot 的路径
+func cgroupParentPath(parent string) (string, error) {
+	parent = strings.Trim(parent, "/")
+	if parent == "" || strings.Contains(parent, "..") {
+		return "", fmt.Errorf("invalid cgroup parent %q", parent)
+	}
+	if !strings.HasSuffix(parent, ".slice") || strings.Contains(parent, "/") {
+		return parent, nil
+	}
+
+	name := strings.TrimSuffix(parent, ".slice")
+	if name == "" || strings.HasPrefix(name, "-") || strings.HasSuffix(name, "-") || strings.Contains(name, "--") {
+		return "", fmt.Errorf("invalid systemd slice name %q", parent)
+	}
+	var parts []string
+	prefix := ""
+	for _, seg := range strings.Split(name, "-") {
+		prefix += seg
+		parts = append(parts, prefix+".slice")
+		prefix += "-"
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+func enableControllers(dir string, controllers ...string) error {
+	available, err := os.ReadFile(filepath.Join(dir, "cgroup.controllers"))
+	if err != nil {
+		return fmt.Errorf("failed to read controllers of %s: %w", dir, err)
+	}
+	have := strings.Fields(string(available))
+
+	var enable []string
+	for _, c := range controllers {
+		for _, h := range have {
+			if h == c {
+				enable = append(enable, "+"+c)
+			}
+		}
+	}
+	if len(enable) == 0 {
+		return errors.New("cgroup controllers cpu/memory/pids are not available in " + dir)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.subtree_control"), []byte(strings.Join(enable, " ")), 0644); err != nil {
+		return fmt.Errorf("failed to enable controllers in %s: %w", dir, err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCgroupParentPath(t *testing.T) {
+	cases := map[string]string{
+		"agent.slice":               "agent.slice",
+		"agent-sandbox.slice":       "agent.slice/agent-sandbox.slice",
+		"/agent-platform/sandboxes": "agent-platform/sandboxes",
+	}
+	for in, want := range cases {
+		got, err := cgroupParentPath(in)
+		if err != nil || got != want {
+			t.Errorf("cgroupParentPath(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "-a.slice", "a--b.slice", "../escape"} {
+		if _, err := cgroupParentPath(bad); err == nil {
+			t.Errorf("cgroupParentPath(%q) should fail", bad)
+		}
+	}
+}
+
+func TestEnsureCgroupParent(t *testing.T) {
+	root := t.TempDir()
+	old := cgroupRoot
+	cgroupRoot = root
+	t.Cleanup(func() { cgroupRoot = old })
+
+	// 模拟 cgroupfs：每一级都有 cgroup.controllers
+	for _, dir := range []string{root, filepath.Join(root, "agent.slice")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "cgroup.controllers"), []byte("cpuset cpu io memory pids"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := EnsureCgroupParent("agent-sandbox.slice", CgroupBudget{MemoryBytes: 8 << 30, CPUs: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(rel string) string {
+		data, err := os.ReadFile(filepath.Join(root, rel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	if got := read("cgroup.subtree_control"); got != "+cpu +memory +pids" {
+		t.Fatalf("root subtree_control = %q", got)
+	}
+	dir := "agent.slice/agent-sandbox.slice/"
+	if got := read(dir + "memory.max"); got != "8589934592" {
+		t.Fatalf("memory.max = %q", got)
+	}
+	if got := read(dir + "cpu.max"); got != "400000 100000" {
+		t.Fatalf("cpu.max = %q", got)
+	}
+	if got := read(dir + "pids.max"); got != "max" {
+		t.Fatalf("pids.max = %q", got)
+	}
+}
@@ -0,0 +1,83 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/checkpoint"
+	"github.com/docker/docker/api/types/container"
+)
+
+// Checkpoint 通过 CRIU 将容器进程状态保存为名为 name 的检查点并停止容器，释放其占用的内存。
+// 需要 Docker daemon 开启 experimental 且宿主机安装 CRIU；容器不能持有已建立的 TCP 连接，
+// 调用方应先关闭到 Agent 的 gRPC 连接。Config.CheckpointDir 为空时使用 Docker 默认目录。
+func (c *Container) Checkpoint(ctx context.Context, name string) error {
+	if err := c.checkCheckpointSupport(ctx); err != nil {
+		return err
+	}
+
+	c.logger.Info("Checkpointing container", "container_id", c.ID, "checkpoint", name)
+	err := c.client.CheckpointCreate(ctx, c.ID, checkpoint.CreateOptions{
+		CheckpointID:  name,
+		CheckpointDir: c.Config.CheckpointDir,
+		Exit:          true,
+	})
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return ErrContainerNotFound
+		}
+		return Wrap(ErrCheckpointFailed, err)
+	}
+	return nil
+}
+
+// Restore 从检查点恢复容器，成功后删除检查点并刷新容器 IP
+func (c *Container) Restore(ctx context.Context, name string) error {
+	if err := c.checkCheckpointSupport(ctx); err != nil {
+		return err
+	}
+
+	c.logger.Info("Restoring container from checkpoint", "container_id", c.ID, "checkpoint", name)
+	err := c.client.ContainerStart(ctx, c.ID, container.StartOptions{
+		CheckpointID:  name,
+		CheckpointDir: c.Config.CheckpointDir,
+	})
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return ErrContainerNotFound
+		}
+		return Wrap(ErrCheckpointFailed, err)
+	}
+
+	if err := c.client.CheckpointDelete(ctx, c.ID, checkpoint.DeleteOptions{
+		CheckpointID:  name,
+		CheckpointDir: c.Config.CheckpointDir,
+	}); err != nil {
+		c.logger.Warn("Failed to delete checkpoint after restore", "checkpoint", name, "error", err)
+	}
+
+	inspect, err := c.client.ContainerInspect(ctx, c.ID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container: %w", err)
+	}
+	for _, net := range inspect.NetworkSettings.Networks {
+		if net.IPAddress != "" {
+			c.IP = net.IPAddress
+			break
+		}
+	}
+	return c.refreshStatus(ctx)
+}
+
+// checkCheckpointSupport checkpoint API 只在 experimental 模式下可用，否则返回的 404 难以理解
+func (c *Container) checkCheckpointSupport(ctx context.Context) error {
+	info, err := c.client.Info(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to query docker info: %w", err)
+	}
+	if !info.ExperimentalBuild {
+		return fmt.Errorf("%w: docker daemon experimental features are disabled", ErrCheckpointUnsupported)
+	}
+	return nil
+}
@@ -2,23 +2,27 @@ package sandbox
 
 import (
 	"archive/tar"
-	"bufio"
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"io"
 
+	"platform/internal/reqid"
+	"platform/internal/sandbox/pathsafe"
+
 	"github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/stdcopy"
@@ -28,8 +32,12 @@ import (
 var _ Sandbox = (*Container)(nil)
 
 type Container struct {
-	ID        string
-	IP        string
+	ID string
+	IP string
+	// NodeID 容器所在的节点，见 nodes 包；单机部署时为空
+	NodeID string
+	// CreatedAt 容器的创建时间
+	CreatedAt time.Time
 	Config    ContainerConfig
 	client    *client.Client
 	status    container.ContainerState
@@ -53,7 +61,7 @@ func NewContainer(client *client.Client, cfg ContainerConfig, hostRoot string,
 	}
 
 	if c.Config.LogDir == "" {
-		c.Config.LogDir = ".dockerlogs"
+		c.Config.LogDir = DefaultLogDir
 	}
 
 	// Ensure log directory exists
@@ -70,26 +78,20 @@ func NewContainer(client *client.Client, cfg ContainerConfig, hostRoot string,
 }
 
 func (c *Container) resolveHostPath(userPath string) (string, error) {
-	// join会清理路径、去掉相对路径
-	target := filepath.Join(c.HostPath, userPath)
-	if !strings.HasPrefix(target, filepath.Clean(c.HostPath)) {
+	target, err := pathsafe.HostJoin(c.HostPath, userPath)
+	if err != nil {
 		return "", fmt.Errorf("%w: path escapes workspace: %s", ErrInvalidPath, userPath)
 	}
 	return target, nil
 }
 
 func (c *Container) resolveContainerPath(userPath string) (string, error) {
-	basePath := c.MountPath
-
-	// 这里用 path，因为 filepath 在 windows 上会把 / 换成 \，导致容器路径错误
-	target := path.Join(basePath, userPath)
-	cleanedTarget := path.Clean(target)
-
-	// 确保路径没有逃逸
-	if !strings.HasPrefix(cleanedTarget, basePath) {
-		return "", fmt.Errorf("Path escapes workspace: %s", userPath)
+	// 容器路径使用 path，filepath 在 windows 上会把 / 换成 \
+	target, err := pathsafe.Join(c.MountPath, userPath)
+	if err != nil {
+		return "", fmt.Errorf("%w: path escapes workspace: %s", ErrInvalidPath, userPath)
 	}
-	return cleanedTarget, nil
+	return target, nil
 }
 
 func (c *Container) Start(ctx context.Context) error {
@@ -102,31 +104,35 @@ func (c *Container) Start(ctx context.Context) error {
 		reader, err := c.client.ImagePull(ctx, c.Config.Image, image.PullOptions{})
 		if err != nil {
 			c.logger.Error("Failed to pull image", "error", err)
-			return fmt.Errorf("%w: %v", ErrImagePullFailed, err)
+			return Wrap(ErrImagePullFailed, err)
 		}
 		defer reader.Close()
 
-		// 异步读取 pull 输出
-		done := make(chan struct{})
+		// 异步读取 pull 输出，流中的错误消息表示拉取失败
+		done := make(chan error, 1)
 		go func() {
-			_, err := io.Copy(io.Discard, reader)
-			if err != nil {
-				c.logger.Error("Failed to read pull output", "error", err)
-			}
-			close(done)
+			done <- readPullStream(reader, c.Config.Image, PullProgressInterval, c.Config.OnPullProgress)
 		}()
 
 		select {
-		case <-done:
+		case err := <-done:
+			if err != nil {
+				c.logger.Error("Failed to pull image", "image", c.Config.Image, "error", err)
+				return Wrap(ErrImagePullFailed, err)
+			}
 			c.logger.Info("Image pull completed")
 		case <-ctx.Done():
 			c.logger.Info("Image pull cancelled")
-			return fmt.Errorf("%w: %v", ErrImagePullFailed, ctx.Err())
+			return Wrap(ErrImagePullFailed, ctx.Err())
 		}
 	} else if err != nil {
 		return fmt.Errorf("failed to inspect image: %w", err)
 	}
 
+	if err := c.checkRuntime(ctx); err != nil {
+		return err
+	}
+
 	// 确保工作目录存在
 	if c.HostPath != "" {
 		if err := os.MkdirAll(c.HostPath, 0755); err != nil {
@@ -134,6 +140,13 @@ func (c *Container) Start(ctx context.Context) error {
 		}
 	}
 
+	if err := c.Config.NetworkPolicy.Validate(); err != nil {
+		return Wrap(ErrContainerStartFailed, err)
+	}
+	if err := ValidateMounts(c.Config.Mounts, c.MountPath); err != nil {
+		return Wrap(ErrContainerStartFailed, err)
+	}
+
 	name := ContainerName(c.Config.SessionID)
 
 	// 使用可配置 Cmd 命令
@@ -144,18 +157,20 @@ func (c *Container) Start(ctx context.Context) error {
 
 	config := &container.Config{
 		Image:      c.Config.Image,
+		Entrypoint: c.Config.Entrypoint,
 		Cmd:        cmd,
 		Env:        c.Config.EnvVars,
 		WorkingDir: c.MountPath,
-		Labels: map[string]string{
-			"managed_by": "agent-platform",
-			"project_id": c.Config.ProjectID,
-			"session_id": c.Config.SessionID,
-		},
+		Labels:     c.Config.Labels(),
 	}
 
 	// 启用 host.docker.internal 支持，让容器内 Agent 可以回调到宿主机上的 Go Platform 服务
-	extraHosts := []string{"host.docker.internal:host-gateway"}
+	// Podman 会自动写入 host.containers.internal / host.docker.internal，
+	// 且较旧版本不支持 host-gateway，此时不再显式添加
+	var extraHosts []string
+	if !isPodman(ctx, c.client) {
+		extraHosts = []string{"host.docker.internal:host-gateway"}
+	}
 
 	var hostConfig *container.HostConfig
 	if c.Config.UseAnonymousVol {
@@ -171,15 +186,44 @@ func (c *Container) Start(ctx context.Context) error {
 			AutoRemove: false,
 			ExtraHosts: extraHosts,
 		}
-		// 在 Config 中声明匿名卷，Docker 会自动创建
-		config.Volumes = map[string]struct{}{
-			c.MountPath: {},
+		if c.Config.DiskLimit > 0 {
+			// 有磁盘上限时使用 tmpfs 后端的匿名卷，由内核按 size 强制上限。
+			// 它仍是 Docker Volume，CopyToContainer 可以正常写入；注意占用的是宿主机内存
+			hostConfig.Mounts = []mount.Mount{{
+				Type:   mount.TypeVolume,
+				Target: c.MountPath,
+				VolumeOptions: &mount.VolumeOptions{
+					DriverConfig: &mount.Driver{
+						Name: "local",
+						Options: map[string]string{
+							"type":   "tmpfs",
+							"device": "tmpfs",
+							"o":      fmt.Sprintf("size=%d", c.Config.DiskLimit),
+						},
+					},
+				},
+			}}
+		} else {
+			// 在 Config 中声明匿名卷，Docker 会自动创建
+			config.Volumes = map[string]struct{}{
+				c.MountPath: {},
+			}
 		}
 	} else {
+		workspace := mount.Mount{
+			Type:   mount.TypeBind,
+			Source: c.HostPath,
+			Target: c.MountPath,
+		}
+		if wv := c.Config.WorkspaceVolume; wv != nil {
+			if err := c.ensureSubpath(ctx, wv, c.Config.ProjectID); err != nil {
+				c.logger.Error("Workspace volume check failed", "volume", wv.Name, "error", err)
+				return Wrap(ErrContainerStartFailed, err)
+			}
+			workspace = wv.workspaceMount(c.Config.ProjectID, c.MountPath)
+		}
 		hostConfig = &container.HostConfig{
-			Binds: []string{
-				fmt.Sprintf("%s:%s:rw", c.HostPath, c.MountPath),
-			},
+			Mounts: []mount.Mount{workspace},
 			Resources: container.Resources{
 				Memory:   c.Config.MemoryLimit,
 				NanoCPUs: int64(c.Config.CPULimit * 1e9),
@@ -189,35 +233,95 @@ func (c *Container) Start(ctx context.Context) error {
 		}
 	}
 
+	for _, m := range c.Config.Mounts {
+		hostConfig.Mounts = append(hostConfig.Mounts, m.dockerMount())
+	}
+	hostConfig.Runtime = c.Config.Runtime
+	hostConfig.CgroupParent = c.Config.CgroupParent
+	if req, ok := gpuDeviceRequest(c.Config); ok {
+		hostConfig.DeviceRequests = []container.DeviceRequest{req}
+	}
+	if c.Config.ReadOnlyRootFS {
+		// 工作区已经是卷或绑定挂载，只读根文件系统下仍可写，不能换成 tmpfs（CopyToContainer 写入会被遮挡）
+		hostConfig.ReadonlyRootfs = true
+		hostConfig.Tmpfs = ReadOnlyTmpfs
+		if config.Volumes == nil {
+			config.Volumes = map[string]struct{}{}
+		}
+		for _, p := range ReadOnlyVolumePaths {
+			config.Volumes[p] = struct{}{}
+		}
+	}
+	hostConfig.CapDrop = c.Config.Security.CapDrop
+	hostConfig.CapAdd = c.Config.Security.CapAdd
+	securityOpts, err := c.Config.Security.securityOpts()
+	if err != nil {
+		return Wrap(ErrContainerStartFailed, err)
+	}
+	hostConfig.SecurityOpt = securityOpts
+
+	networkName := c.Config.NetworkName
+	if c.Config.NetworkPolicy.Restricted() {
+		sn, err := c.setupSessionNetwork(ctx)
+		if err != nil {
+			c.logger.Error("Failed to set up session network", "error", err)
+			return err
+		}
+		networkName = sn.name
+		config.Env = append(append([]string{}, config.Env...), sn.env...)
+		// internal 网络中 host-gateway 指向的默认网桥不可达，改用 session 网络的网关访问宿主机
+		if sn.gateway != "" {
+			hostConfig.ExtraHosts = []string{"host.docker.internal:" + sn.gateway}
+		}
+	}
+
 	netConfig := &network.NetworkingConfig{
 		EndpointsConfig: map[string]*network.EndpointSettings{
-			c.Config.NetworkName: {},
+			networkName: {},
 		},
 	}
 
+	// cleanup 启动失败时清理容器以及 session 网络；KeepFailed 时容器只停止不删除，保留现场
+	cleanup := func() {
+		if c.ID != "" {
+			if policy := CurrentRemovalPolicy(); policy.KeepFailed {
+				timeout := policy.StopTimeoutSeconds()
+				_ = c.client.ContainerStop(context.Background(), c.ID, container.StopOptions{Timeout: &timeout})
+				c.logger.Warn("Keeping failed container for debugging", "container_id", c.ID, "name", name)
+			} else {
+				_ = RemoveContainer(context.Background(), c.client, c.ID)
+			}
+		}
+		if c.Config.NetworkPolicy.Restricted() {
+			_ = RemoveSessionNetwork(context.Background(), c.client, c.Config.SessionID)
+		}
+	}
+
 	resp, err := c.client.ContainerCreate(ctx, config, hostConfig, netConfig, nil, name)
 	if err != nil {
 		c.logger.Error("Failed to create container", "error", err)
-		return fmt.Errorf("%w: %v", ErrContainerStartFailed, err)
+		cleanup()
+		return Wrap(ErrContainerStartFailed, err)
 	}
 
 	c.ID = resp.ID
+	c.CreatedAt = time.Now()
 	if err := c.client.ContainerStart(ctx, c.ID, container.StartOptions{}); err != nil {
 		c.logger.Error("Failed to start container", "error", err)
 		// 如果启动失败，清理容器
-		_ = c.client.ContainerRemove(context.Background(), c.ID, container.RemoveOptions{Force: true})
-		return fmt.Errorf("%w: %v", ErrContainerStartFailed, err)
+		cleanup()
+		return Wrap(ErrContainerStartFailed, err)
 	}
 
 	// 获取容器IP
 	inspect, err := c.client.ContainerInspect(ctx, c.ID)
 	if err != nil {
 		c.logger.Error("Failed to inspect container", "error", err)
-		_ = c.client.ContainerRemove(context.Background(), c.ID, container.RemoveOptions{Force: true})
+		cleanup()
 		return fmt.Errorf("failed to inspect container: %w", err)
 	}
 
-	if net, ok := inspect.NetworkSettings.Networks[c.Config.NetworkName]; ok {
+	if net, ok := inspect.NetworkSettings.Networks[networkName]; ok {
 		c.IP = net.IPAddress
 	} else {
 		for _, v := range inspect.NetworkSettings.Networks {
@@ -226,6 +330,13 @@ func (c *Container) Start(ctx context.Context) error {
 		}
 	}
 
+	// 网络存储可能挂载成功但权限或导出配置不对，启动后确认容器内可用
+	if err := c.checkMounts(ctx); err != nil {
+		c.logger.Error("Mount health check failed", "error", err)
+		cleanup()
+		return Wrap(ErrContainerStartFailed, err)
+	}
+
 	// 初始状态更新
 	if err := c.refreshStatus(ctx); err != nil {
 		c.logger.Warn("Failed to refresh status after start", "error", err)
@@ -235,6 +346,30 @@ func (c *Container) Start(ctx context.Context) error {
 	return nil
 }
 
+// checkRuntime 确认 Config.Runtime 已在 Docker daemon 中注册，
+// 避免未安装 runsc 等运行时时 ContainerCreate 返回难以理解的错误
+func (c *Container) checkRuntime(ctx context.Context) error {
+	if c.Config.Runtime == "" {
+		return nil
+	}
+
+	info, err := c.client.Info(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to query docker runtimes: %w", err)
+	}
+	if _, ok := info.Runtimes[c.Config.Runtime]; ok {
+		return nil
+	}
+
+	available := make([]string, 0, len(info.Runtimes))
+	for name := range info.Runtimes {
+		available = append(available, name)
+	}
+	sort.Strings(available)
+	return fmt.Errorf("%w: %q is not registered with the docker daemon (available: %s)",
+		ErrRuntimeUnavailable, c.Config.Runtime, strings.Join(available, ", "))
+}
+
 func (c *Container) Stop(ctx context.Context, timeoutSeconds int) error {
 	c.logger.Info("Stopping container", "container_id", c.ID)
 	opts := container.StopOptions{
@@ -252,23 +387,59 @@ func (c *Container) Stop(ctx context.Context, timeoutSeconds int) error {
 	return nil
 }
 
-func (c *Container) Remove(ctx context.Context) error {
-	c.logger.Info("Removing container", "container_id", c.ID)
-	opts := container.RemoveOptions{
-		Force: true,
+// Pause 通过 cgroup freezer 冻结容器内所有进程，内存和文件系统保持不变
+func (c *Container) Pause(ctx context.Context) error {
+	c.logger.Info("Pausing container", "container_id", c.ID)
+	if err := c.client.ContainerPause(ctx, c.ID); err != nil {
+		if errdefs.IsNotFound(err) {
+			return ErrContainerNotFound
+		}
+		return fmt.Errorf("failed to pause container: %w", err)
 	}
+	return nil
+}
 
-	if err := c.client.ContainerRemove(ctx, c.ID, opts); err != nil {
+// Unpause 解冻 Pause 冻结的容器
+func (c *Container) Unpause(ctx context.Context) error {
+	c.logger.Info("Unpausing container", "container_id", c.ID)
+	if err := c.client.ContainerUnpause(ctx, c.ID); err != nil {
 		if errdefs.IsNotFound(err) {
 			return ErrContainerNotFound
 		}
-		return fmt.Errorf("failed to remove container: %w", err)
+		return fmt.Errorf("failed to unpause container: %w", err)
+	}
+	return nil
+}
+
+func (c *Container) Remove(ctx context.Context) error {
+	c.logger.Info("Removing container", "container_id", c.ID)
+	if err := RemoveContainer(ctx, c.client, c.ID); err != nil {
+		return err
+	}
+
+	if c.Config.NetworkPolicy.Restricted() {
+		if err := RemoveSessionNetwork(ctx, c.client, c.Config.SessionID); err != nil {
+			c.logger.Warn("Failed to clean up session network", "error", err)
+		}
 	}
 
 	c.logger.Info("Container removed successfully", "container_id", c.ID)
 	return nil
 }
 
+// Reassign 将容器改归 sessionID 所有，容器名随之更新。Docker 不支持修改已有容器的标签，
+// 创建时的 session_id 和 tenant 标签保持不变，读取时经 OwnedLabels 以容器名为准
+func (c *Container) Reassign(ctx context.Context, sessionID string) error {
+	if err := c.client.ContainerRename(ctx, c.ID, ContainerName(sessionID)); err != nil {
+		if errdefs.IsNotFound(err) {
+			return ErrContainerNotFound
+		}
+		return fmt.Errorf("failed to rename container: %w", err)
+	}
+	c.Config.SessionID = sessionID
+	return nil
+}
+
 func (c *Container) refreshStatus(ctx context.Context) error {
 	inspect, err := c.client.ContainerInspect(ctx, c.ID)
 	if err != nil {
@@ -289,12 +460,29 @@ func (c *Container) GetStatus(ctx context.Context) (container.ContainerState, er
 }
 
 func (c *Container) Exec(ctx context.Context, cmd []string, env []string, workDir string) (*ExecResult, error) {
+	return c.ExecWithOptions(ctx, cmd, env, workDir, ExecOptions{})
+}
+
+func (c *Container) ExecWithOptions(ctx context.Context, cmd []string, env []string, workDir string, opts ExecOptions) (*ExecResult, error) {
 	if workDir == "" {
 		workDir = c.MountPath
 	}
 
+	release, err := AcquireExec(ctx, c.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	execCmd := cmd
+	var pidFile string
+	if opts.Timeout > 0 {
+		pidFile = newExecPIDFile()
+		execCmd = withPIDFile(pidFile, cmd)
+	}
+
 	createOpts := container.ExecOptions{
-		Cmd:          cmd,
+		Cmd:          execCmd,
 		Env:          env,
 		WorkingDir:   workDir,
 		Tty:          false,
@@ -304,7 +492,7 @@ func (c *Container) Exec(ctx context.Context, cmd []string, env []string, workDi
 
 	createdResp, err := c.client.ContainerExecCreate(ctx, c.ID, createOpts)
 	if err != nil {
-		return nil, fmt.Errorf("%w: failed to create exec: %v", ErrExecFailed, err)
+		return nil, Wrap(ErrExecFailed, fmt.Errorf("failed to create exec: %w", err))
 	}
 
 	c.logger.Info("Exec created successfully")
@@ -316,24 +504,43 @@ func (c *Container) Exec(ctx context.Context, cmd []string, env []string, workDi
 
 	attachResp, err := c.client.ContainerExecAttach(ctx, createdResp.ID, attachOpts)
 	if err != nil {
-		return nil, fmt.Errorf("%w: failed to attach to exec: %v", ErrExecFailed, err)
+		return nil, Wrap(ErrExecFailed, fmt.Errorf("failed to attach to exec: %w", err))
 	}
 	defer attachResp.Close()
 
-	var stdoutBuf, stderrBuf bytes.Buffer
+	stdoutBuf := &limitedBuffer{limit: opts.MaxOutputBytes}
+	stderrBuf := &limitedBuffer{limit: opts.MaxOutputBytes}
 	start := time.Now()
 
 	// 异步读取输出
 	done := make(chan struct{})
 	go func() {
 		// TTY=false, Docker 使用多路复用格式，stdcopy 可以解析
-		_, _ = stdcopy.StdCopy(&stdoutBuf, &stderrBuf, attachResp.Reader)
+		_, _ = stdcopy.StdCopy(teeExecOutput(ctx, "stdout", stdoutBuf), teeExecOutput(ctx, "stderr", stderrBuf), attachResp.Reader)
 		close(done)
 	}()
 
+	var timeout <-chan time.Time
+	if opts.Timeout > 0 {
+		timer := time.NewTimer(opts.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	timedOut := false
 	select {
 	case <-done:
 		// 正常完成
+	case <-timeout:
+		timedOut = true
+		c.logger.Warn("Exec timed out, killing process", "cmd", cmd, "timeout", opts.Timeout)
+		c.killExec(pidFile)
+		select {
+		case <-done:
+		case <-time.After(execKillGrace):
+			attachResp.Close()
+			<-done
+		}
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	}
@@ -342,7 +549,7 @@ func (c *Container) Exec(ctx context.Context, cmd []string, env []string, workDi
 
 	inspectResp, err := c.client.ContainerExecInspect(ctx, createdResp.ID)
 	if err != nil {
-		return nil, fmt.Errorf("%w: failed to inspect exec: %v", ErrExecFailed, err)
+		return nil, Wrap(ErrExecFailed, fmt.Errorf("failed to inspect exec: %w", err))
 	}
 
 	// 持久化 Exec Log 存储
@@ -353,28 +560,127 @@ func (c *Container) Exec(ctx context.Context, cmd []string, env []string, workDi
 		Output:     stdoutBuf.String() + stderrBuf.String(),
 		ExitCode:   inspectResp.ExitCode,
 		DurationMs: duration.Milliseconds(),
+		RequestID:  reqid.FromContext(ctx),
 	}
 
-	logFile := filepath.Join(c.Config.LogDir, c.Config.SessionID, "events.jsonl")
-	if f, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err == nil {
-		if data, err := json.Marshal(entry); err == nil {
-			_, _ = f.Write(append(data, '\n'))
-		} else {
-			c.logger.Error("Failed to marshal log entry", "error", err)
-		}
-		f.Close()
-	} else {
-		c.logger.Error("Failed to open log file", "error", err)
-	}
+	appendExecLog(c.logger, c.Config, entry)
 
 	return &ExecResult{
-		ExitCode: inspectResp.ExitCode,
-		Stdout:   stdoutBuf.String(),
-		Stderr:   stderrBuf.String(),
-		Duration: duration,
+		ExitCode:  inspectResp.ExitCode,
+		Stdout:    stdoutBuf.String(),
+		Stderr:    stderrBuf.String(),
+		Duration:  duration,
+		TimedOut:  timedOut,
+		Truncated: stdoutBuf.truncated || stderrBuf.truncated,
 	}, nil
 }
 
+// killExec 通过另一个 exec 杀掉超时命令，调用方的 ctx 可能已接近截止，使用独立超时
+func (c *Container) killExec(pidFile string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	created, err := c.client.ContainerExecCreate(ctx, c.ID, container.ExecOptions{Cmd: killPIDFileCommand(pidFile)})
+	if err == nil {
+		err = c.client.ContainerExecStart(ctx, created.ID, container.ExecStartOptions{Detach: true})
+	}
+	if err != nil {
+		c.logger.Warn("Failed to kill timed out exec", "error", err)
+	}
+}
+
+// ExecStream 流式执行命令，exec 日志只记录命令、退出码和耗时，不保存输出
+func (c *Container) ExecStream(ctx context.Context, cmd []string, env []string, workDir string) (io.WriteCloser, io.ReadCloser, io.ReadCloser, error) {
+	if workDir == "" {
+		workDir = c.MountPath
+	}
+
+	// 名额在命令结束（输出读完）后释放
+	release, err := AcquireExec(ctx, c.ID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	createdResp, err := c.client.ContainerExecCreate(ctx, c.ID, container.ExecOptions{
+		Cmd:          cmd,
+		Env:          env,
+		WorkingDir:   workDir,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		release()
+		return nil, nil, nil, Wrap(ErrExecFailed, fmt.Errorf("failed to create exec: %w", err))
+	}
+
+	attachResp, err := c.client.ContainerExecAttach(ctx, createdResp.ID, container.ExecAttachOptions{})
+	if err != nil {
+		release()
+		return nil, nil, nil, Wrap(ErrExecFailed, fmt.Errorf("failed to attach to exec: %w", err))
+	}
+
+	stdoutR, stdoutW := io.Pipe()
+	stderrR, stderrW := io.Pipe()
+	start := time.Now()
+
+	// ctx 取消时关闭连接，解除 StdCopy 的阻塞
+	stop := context.AfterFunc(ctx, attachResp.Close)
+	go func() {
+		defer release()
+		defer attachResp.Close()
+		defer stop()
+
+		_, copyErr := stdcopy.StdCopy(stdoutW, stderrW, attachResp.Reader)
+
+		exitCode := -1
+		var streamErr error
+		switch {
+		case ctx.Err() != nil:
+			streamErr = ctx.Err()
+		case copyErr != nil:
+			streamErr = Wrap(ErrExecFailed, copyErr)
+		default:
+			inspectResp, err := c.client.ContainerExecInspect(context.Background(), createdResp.ID)
+			if err != nil {
+				streamErr = Wrap(ErrExecFailed, fmt.Errorf("failed to inspect exec: %w", err))
+				break
+			}
+			exitCode = inspectResp.ExitCode
+			if exitCode != 0 {
+				streamErr = &ExitError{Code: exitCode}
+			}
+		}
+
+		appendExecLog(c.logger, c.Config, ExecLogEntry{
+			ID:         uuid.New().String(),
+			Timestamp:  start,
+			Command:    cmd,
+			ExitCode:   exitCode,
+			DurationMs: time.Since(start).Milliseconds(),
+			RequestID:  reqid.FromContext(ctx),
+		})
+
+		stderrW.Close()
+		stdoutW.CloseWithError(streamErr)
+	}()
+
+	return &execStdin{resp: &attachResp}, stdoutR, stderrR, nil
+}
+
+// execStdin 向 exec 连接写入 stdin，Close 只关闭写方向，输出仍可继续读取
+type execStdin struct {
+	resp *types.HijackedResponse
+}
+
+func (s *execStdin) Write(p []byte) (int, error) {
+	return s.resp.Conn.Write(p)
+}
+
+func (s *execStdin) Close() error {
+	return s.resp.CloseWrite()
+}
+
 func (c *Container) WriteFile(ctx context.Context, path string, reader io.Reader, perm os.FileMode) error {
 	hostTarget, err := c.resolveHostPath(path)
 	if err != nil {
@@ -432,6 +738,7 @@ func (c *Container) ListFiles(ctx context.Context, path string) ([]FileInfo, err
 			Size:    fileInfo.Size(),
 			IsDir:   entry.IsDir(),
 			ModTime: fileInfo.ModTime(),
+			Mode:    fileInfo.Mode().String(),
 		})
 	}
 
@@ -586,30 +893,5 @@ func (c *Container) IsRunning(ctx context.Context) bool {
 }
 
 func (c *Container) GetExecLogs(ctx context.Context) ([]ExecLogEntry, error) {
-	logFile := filepath.Join(c.Config.LogDir, c.Config.SessionID, "events.jsonl")
-	f, err := os.Open(logFile)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return []ExecLogEntry{}, nil
-		}
-		return nil, fmt.Errorf("failed to open log file: %w", err)
-	}
-	defer f.Close()
-
-	var entries []ExecLogEntry
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		var entry ExecLogEntry
-		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
-			c.logger.Warn("Failed to unmarshal log entry", "error", err)
-			continue
-		}
-		entries = append(entries, entry)
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to scan log file: %w", err)
-	}
-
-	return entries, nil
+	return readExecLogs(c.logger, c.Config)
 }
@@ -3,7 +3,9 @@ package sandbox_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -656,6 +658,170 @@ func TestExecCommand(t *testing.T) {
 	})
 }
 
+func TestExecWithOptions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	h := NewTestHarness(t)
+	defer h.Cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	sessionID := fmt.Sprintf("test-exec-opts-%d", time.Now().UnixNano())
+	c := h.NewContainer(sessionID, "test-project")
+
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start container: %v", err)
+	}
+	h.TrackContainer(c.ID)
+
+	t.Run("TimeoutKillsProcess", func(t *testing.T) {
+		start := time.Now()
+		result, err := c.ExecWithOptions(ctx, []string{"sleep", "300"}, nil, "", sandbox.ExecOptions{
+			Timeout: 2 * time.Second,
+		})
+		if err != nil {
+			t.Fatalf("ExecWithOptions failed: %v", err)
+		}
+		if !result.TimedOut {
+			t.Error("Expected TimedOut to be set")
+		}
+		if result.ExitCode == 0 {
+			t.Error("Expected non-zero exit code for killed process")
+		}
+		if time.Since(start) > 30*time.Second {
+			t.Errorf("Exec took too long after timeout: %v", time.Since(start))
+		}
+
+		check, err := c.Exec(ctx, []string{"sh", "-c", "pgrep -x sleep || true"}, nil, "")
+		if err != nil {
+			t.Fatalf("Exec failed: %v", err)
+		}
+		if strings.TrimSpace(check.Stdout) != "" {
+			t.Errorf("Expected sleep to be killed, still running: %q", check.Stdout)
+		}
+	})
+
+	t.Run("TruncatesOutput", func(t *testing.T) {
+		result, err := c.ExecWithOptions(ctx, []string{"sh", "-c", "head -c 10000 /dev/zero | tr '\\0' a"}, nil, "", sandbox.ExecOptions{
+			MaxOutputBytes: 100,
+		})
+		if err != nil {
+			t.Fatalf("ExecWithOptions failed: %v", err)
+		}
+		if len(result.Stdout) != 100 {
+			t.Errorf("Expected 100 bytes of stdout, got %d", len(result.Stdout))
+		}
+		if !result.Truncated {
+			t.Error("Expected Truncated to be set")
+		}
+		if result.ExitCode != 0 {
+			t.Errorf("Expected exit code 0, got %d", result.ExitCode)
+		}
+	})
+
+	t.Run("FastCommandUnaffected", func(t *testing.T) {
+		result, err := c.ExecWithOptions(ctx, []string{"sh", "-c", "echo ok; exit 3"}, nil, "", sandbox.ExecOptions{
+			Timeout: 10 * time.Second,
+		})
+		if err != nil {
+			t.Fatalf("ExecWithOptions failed: %v", err)
+		}
+		if result.TimedOut || result.ExitCode != 3 || strings.TrimSpace(result.Stdout) != "ok" {
+			t.Errorf("Unexpected result: %+v", result)
+		}
+	})
+}
+
+func TestExecStream(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	h := NewTestHarness(t)
+	defer h.Cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	sessionID := fmt.Sprintf("test-exec-stream-%d", time.Now().UnixNano())
+	c := h.NewContainer(sessionID, "test-project")
+
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Failed to start container: %v", err)
+	}
+	h.TrackContainer(c.ID)
+
+	t.Run("Stdin", func(t *testing.T) {
+		stdin, stdout, stderr, err := c.ExecStream(ctx, []string{"cat"}, nil, "")
+		if err != nil {
+			t.Fatalf("Failed to start exec stream: %v", err)
+		}
+		go io.Copy(io.Discard, stderr)
+
+		if _, err := io.WriteString(stdin, "hello stream\n"); err != nil {
+			t.Fatalf("Failed to write stdin: %v", err)
+		}
+		stdin.Close()
+
+		out, err := io.ReadAll(stdout)
+		if err != nil {
+			t.Fatalf("Unexpected stream error: %v", err)
+		}
+		if strings.TrimSpace(string(out)) != "hello stream" {
+			t.Errorf("Expected echoed stdin, got %q", out)
+		}
+	})
+
+	t.Run("IncrementalOutput", func(t *testing.T) {
+		stdin, stdout, stderr, err := c.ExecStream(ctx, []string{"sh", "-c", "echo first; sleep 2; echo second"}, nil, "")
+		if err != nil {
+			t.Fatalf("Failed to start exec stream: %v", err)
+		}
+		stdin.Close()
+		go io.Copy(io.Discard, stderr)
+
+		start := time.Now()
+		buf := make([]byte, 64)
+		n, err := stdout.Read(buf)
+		if err != nil || !strings.Contains(string(buf[:n]), "first") {
+			t.Fatalf("Expected first line, got %q (%v)", buf[:n], err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("First line should arrive before the command finishes, took %v", elapsed)
+		}
+		rest, _ := io.ReadAll(stdout)
+		if !strings.Contains(string(rest), "second") {
+			t.Errorf("Expected second line, got %q", rest)
+		}
+	})
+
+	t.Run("ExitCode", func(t *testing.T) {
+		stdin, stdout, stderr, err := c.ExecStream(ctx, []string{"sh", "-c", "echo oops >&2; exit 3"}, nil, "")
+		if err != nil {
+			t.Fatalf("Failed to start exec stream: %v", err)
+		}
+		stdin.Close()
+
+		errOut := make(chan []byte)
+		go func() {
+			b, _ := io.ReadAll(stderr)
+			errOut <- b
+		}()
+
+		_, err = io.ReadAll(stdout)
+		var exitErr *sandbox.ExitError
+		if !errors.As(err, &exitErr) || exitErr.Code != 3 {
+			t.Fatalf("Expected ExitError with code 3, got %v", err)
+		}
+		if got := strings.TrimSpace(string(<-errOut)); got != "oops" {
+			t.Errorf("Expected stderr 'oops', got %q", got)
+		}
+	})
+}
+
 func TestContainerStateTransitions(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
@@ -884,3 +1050,58 @@ func TestExecLogs(t *testing.T) {
 		}
 	}
 }
+
+func TestRuntimeValidation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	h := NewTestHarness(t)
+	defer h.Cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	t.Run("UnknownRuntime", func(t *testing.T) {
+		c := h.NewContainerWithConfig(sandbox.ContainerConfig{
+			SessionID:   fmt.Sprintf("test-runtime-%d", time.Now().UnixNano()),
+			ProjectID:   "test-project",
+			Image:       testImage,
+			NetworkName: testNetworkName,
+			Runtime:     "no-such-runtime",
+		})
+
+		err := c.Start(ctx)
+		if !errors.Is(err, sandbox.ErrRuntimeUnavailable) {
+			t.Fatalf("Expected ErrRuntimeUnavailable, got %v", err)
+		}
+		if c.ID != "" {
+			h.TrackContainer(c.ID)
+			t.Error("Container should not be created with an unknown runtime")
+		}
+	})
+
+	t.Run("DefaultRuntime", func(t *testing.T) {
+		c := h.NewContainerWithConfig(sandbox.ContainerConfig{
+			SessionID:   fmt.Sprintf("test-runtime-runc-%d", time.Now().UnixNano()),
+			ProjectID:   "test-project",
+			Image:       testImage,
+			Cmd:         []string{"sleep", "30"},
+			NetworkName: testNetworkName,
+			Runtime:     "runc",
+		})
+
+		if err := c.Start(ctx); err != nil {
+			t.Fatalf("Failed to start with runc runtime: %v", err)
+		}
+		h.TrackContainer(c.ID)
+
+		inspect, err := h.dockerClient.ContainerInspect(ctx, c.ID)
+		if err != nil {
+			t.Fatalf("Failed to inspect: %v", err)
+		}
+		if inspect.HostConfig.Runtime != "runc" {
+			t.Errorf("Expected runtime runc, got %q", inspect.HostConfig.Runtime)
+		}
+	})
+}
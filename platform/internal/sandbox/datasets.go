@@ -0,0 +1,148 @@
+package sandbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DatasetPrefix session 在 mounts 中以 "dataset:<name>" 按名称引用目录中的数据挂载
+const DatasetPrefix = "dataset:"
+
+// DefaultDatasetRoot 数据集未指定 container_path 时挂载到 /datasets/<name>
+const DefaultDatasetRoot = "/datasets"
+
+// Dataset 平台目录中可复用的只读数据挂载（数据集、模型权重等）。
+// 所有 session 挂载同一个宿主机目录或命名卷，不为每个 session 复制数据
+type Dataset struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Type bind 或 volume，为空时为 bind
+	Type MountType `json:"type,omitempty"`
+	// Source bind 时为宿主机绝对路径，volume 时为卷名
+	Source string `json:"source"`
+	// Driver volume 数据集的卷驱动配置，可直接挂载 NFS / CIFS 上的共享数据
+	Driver *VolumeDriver `json:"driver,omitempty"`
+	// ContainerPath 默认挂载点，session 可在引用时覆盖
+	ContainerPath string `json:"container_path,omitempty"`
+}
+
+// Target 默认挂载点
+func (d Dataset) Target() string {
+	if d.ContainerPath != "" {
+		return d.ContainerPath
+	}
+	return path.Join(DefaultDatasetRoot, d.Name)
+}
+
+func (d Dataset) mountSpec() MountSpec {
+	return MountSpec{
+		HostPath:      d.Source,
+		ContainerPath: d.Target(),
+		ReadOnly:      true,
+		Type:          d.Type,
+		Driver:        d.Driver,
+		Dataset:       d.Name,
+	}
+}
+
+// DatasetCatalog 按名称索引的数据集目录，创建后只读，可并发使用
+type DatasetCatalog struct {
+	byName map[string]Dataset
+}
+
+// NewDatasetCatalog 校验并建立目录，名称不能重复，挂载只能是 bind 或 volume
+func NewDatasetCatalog(datasets []Dataset) (*DatasetCatalog, error) {
+	c := &DatasetCatalog{byName: make(map[string]Dataset, len(datasets))}
+	for _, d := range datasets {
+		if d.Name == "" || strings.ContainsAny(d.Name, "/\\: ") {
+			return nil, fmt.Errorf("invalid dataset name %q", d.Name)
+		}
+		if _, ok := c.byName[d.Name]; ok {
+			return nil, fmt.Errorf("invalid dataset catalog: duplicate dataset %q", d.Name)
+		}
+		spec := d.mountSpec()
+		if spec.mountType() == MountTmpfs {
+			return nil, fmt.Errorf("invalid dataset %q: tmpfs cannot back a dataset", d.Name)
+		}
+		if err := spec.Validate(); err != nil {
+			return nil, fmt.Errorf("dataset %q: %w", d.Name, err)
+		}
+		c.byName[d.Name] = d
+	}
+	return c, nil
+}
+
+// LoadDatasetCatalog 从 JSON 文件（Dataset 数组）加载目录
+func LoadDatasetCatalog(file string) (*DatasetCatalog, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dataset catalog: %w", err)
+	}
+	var datasets []Dataset
+	if err := json.Unmarshal(data, &datasets); err != nil {
+		return nil, fmt.Errorf("invalid dataset catalog %s: %w", file, err)
+	}
+	return NewDatasetCatalog(datasets)
+}
+
+// List 按名称排序返回所有数据集
+func (c *DatasetCatalog) List() []Dataset {
+	if c == nil {
+		return nil
+	}
+	out := make([]Dataset, 0, len(c.byName))
+	for _, d := range c.byName {
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Resolve 将引用数据集的挂载替换为目录中的定义，强制只读；请求只能覆盖挂载点。
+// 其余挂载原样返回，目录为 nil 时任何数据集引用都无效
+func (c *DatasetCatalog) Resolve(mounts []MountSpec) ([]MountSpec, error) {
+	out := make([]MountSpec, 0, len(mounts))
+	for _, m := range mounts {
+		if m.Dataset == "" {
+			out = append(out, m)
+			continue
+		}
+		var d Dataset
+		ok := false
+		if c != nil {
+			d, ok = c.byName[m.Dataset]
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid mount: unknown dataset %q", m.Dataset)
+		}
+		spec := d.mountSpec()
+		if m.ContainerPath != "" {
+			spec.ContainerPath = m.ContainerPath
+		}
+		out = append(out, spec)
+	}
+	return out, nil
+}
+
+// UnmarshalJSON 除对象外还接受 "dataset:<name>" 形式的字符串
+func (m *MountSpec) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '"' {
+		var ref string
+		if err := json.Unmarshal(trimmed, &ref); err != nil {
+			return err
+		}
+		name, ok := strings.CutPrefix(ref, DatasetPrefix)
+		if !ok || name == "" {
+			return fmt.Errorf("invalid mount %q: string mounts must be %s<name>", ref, DatasetPrefix)
+		}
+		*m = MountSpec{Dataset: name}
+		return nil
+	}
+	type plain MountSpec
+	return json.Unmarshal(data, (*plain)(m))
+}
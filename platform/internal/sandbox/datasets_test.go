@@ -0,0 +1,65 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDatasetResolve(t *testing.T) {
+	catalog, err := NewDatasetCatalog([]Dataset{
+		{Name: "imagenet-mini", Source: "/srv/datasets/imagenet-mini"},
+		{Name: "llama-7b", Type: MountVolume, Source: "llama-weights", ContainerPath: "/models/llama"},
+	})
+	if err != nil {
+		t.Fatalf("NewDatasetCatalog: %v", err)
+	}
+
+	var mounts []MountSpec
+	body := `["dataset:imagenet-mini", {"dataset": "llama-7b", "container_path": "/weights"}, {"container_path": "/scratch", "type": "tmpfs"}]`
+	if err := json.Unmarshal([]byte(body), &mounts); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	resolved, err := catalog.Resolve(mounts)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if got := resolved[0]; got.HostPath != "/srv/datasets/imagenet-mini" || got.ContainerPath != "/datasets/imagenet-mini" || !got.ReadOnly {
+		t.Fatalf("imagenet mount = %+v", got)
+	}
+	if got := resolved[1]; got.HostPath != "llama-weights" || got.ContainerPath != "/weights" || !got.ReadOnly || got.Type != MountVolume {
+		t.Fatalf("llama mount = %+v", got)
+	}
+	if resolved[2].Type != MountTmpfs {
+		t.Fatalf("plain mount changed: %+v", resolved[2])
+	}
+	// 数据集由平台配置，不受 bind 根目录限制
+	if err := ValidateMountSources(resolved, nil, nil); err != nil {
+		t.Fatalf("ValidateMountSources: %v", err)
+	}
+
+	if _, err := catalog.Resolve([]MountSpec{{Dataset: "missing"}}); err == nil || !strings.Contains(err.Error(), "invalid") {
+		t.Fatalf("unknown dataset err = %v, want invalid", err)
+	}
+	var nilCatalog *DatasetCatalog
+	if _, err := nilCatalog.Resolve([]MountSpec{{Dataset: "imagenet-mini"}}); err == nil {
+		t.Fatal("nil catalog resolved a dataset")
+	}
+	if err := json.Unmarshal([]byte(`["imagenet-mini"]`), &mounts); err == nil {
+		t.Fatal("string mount without dataset: prefix accepted")
+	}
+}
+
+func TestNewDatasetCatalogRejectsInvalid(t *testing.T) {
+	bad := map[string][]Dataset{
+		"empty name": {{Source: "/srv/a"}},
+		"duplicate":  {{Name: "a", Source: "/srv/a"}, {Name: "a", Source: "/srv/b"}},
+		"tmpfs":      {{Name: "a", Type: MountTmpfs}},
+		"relative":   {{Name: "a", Source: "srv/a"}},
+	}
+	for name, datasets := range bad {
+		if _, err := NewDatasetCatalog(datasets); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
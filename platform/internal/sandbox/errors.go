@@ -1,6 +1,13 @@
 package sandbox
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/containerd/errdefs"
+)
 
 var (
 	ErrContainerNotFound = errors.New("container not found")
@@ -12,4 +19,125 @@ var (
 	ErrInvalidPath = errors.New("invalid path")
 
 	ErrImagePullFailed = errors.New("failed to pull image")
+
+	ErrRuntimeUnavailable = errors.New("container runtime not available")
+
+	ErrTooManyExecs = errors.New("too many concurrent commands")
+
+	ErrCheckpointUnsupported = errors.New("checkpoint not supported")
+
+	ErrCheckpointFailed = errors.New("checkpoint operation failed")
 )
+
+// Category 沙箱错误的分类，决定重试和 HTTP 状态码
+type Category string
+
+const (
+	// CategoryNotFound 容器、镜像等资源不存在
+	CategoryNotFound Category = "not_found"
+	// CategoryConflict 资源状态冲突，如名称已被占用、容器未运行
+	CategoryConflict Category = "conflict"
+	// CategoryInvalid 请求参数有误，重试不会成功
+	CategoryInvalid Category = "invalid"
+	// CategoryTransient Docker 暂时不可用、超时或资源暂时耗尽，可以重试
+	CategoryTransient Category = "transient"
+	// CategoryFatal 其他无法通过重试恢复的错误
+	CategoryFatal Category = "fatal"
+)
+
+// Error 沙箱操作的结构化错误。Kind 为上面的哨兵错误，Err 为底层（通常是 Docker）错误，
+// errors.Is 对两者都能匹配，错误信息与 fmt.Errorf("%w: %v", Kind, Err) 相同
+type Error struct {
+	Kind      error
+	Category  Category
+	Retryable bool
+	Err       error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Kind.Error()
+	}
+	return fmt.Sprintf("%v: %v", e.Kind, e.Err)
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// Wrap 用 kind 包装底层错误，分类优先按底层错误判断，无法判断时使用 kind 的默认分类
+func Wrap(kind, err error) *Error {
+	category := classify(err)
+	if category == "" {
+		category = kindCategory(kind)
+	}
+	return &Error{Kind: kind, Category: category, Retryable: category == CategoryTransient, Err: err}
+}
+
+// kindCategory 哨兵错误本身的默认分类
+func kindCategory(kind error) Category {
+	switch kind {
+	case ErrContainerNotFound:
+		return CategoryNotFound
+	case ErrInvalidPath:
+		return CategoryInvalid
+	case ErrTooManyExecs:
+		return CategoryTransient
+	default:
+		return CategoryFatal
+	}
+}
+
+// classify 按 Docker / containerd 错误类型和网络错误分类，无法判断时返回空
+func classify(err error) Category {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return ""
+	case errdefs.IsNotFound(err):
+		return CategoryNotFound
+	case errdefs.IsConflict(err), errdefs.IsAlreadyExists(err), errdefs.IsFailedPrecondition(err):
+		return CategoryConflict
+	case errdefs.IsInvalidArgument(err):
+		return CategoryInvalid
+	case errdefs.IsUnavailable(err), errdefs.IsResourceExhausted(err), errdefs.IsDeadlineExceeded(err),
+		errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr):
+		return CategoryTransient
+	}
+	return ""
+}
+
+// CategoryOf 返回错误链中沙箱错误的分类；没有 *Error 时按哨兵错误和底层错误判断，都无法判断时返回空
+func CategoryOf(err error) Category {
+	var se *Error
+	if errors.As(err, &se) {
+		return se.Category
+	}
+	for _, kind := range []error{ErrContainerNotFound, ErrInvalidPath, ErrTooManyExecs} {
+		if errors.Is(err, kind) {
+			return kindCategory(kind)
+		}
+	}
+	return classify(err)
+}
+
+// IsRetryable 错误是否可以通过重试恢复
+func IsRetryable(err error) bool {
+	var se *Error
+	if errors.As(err, &se) {
+		return se.Retryable
+	}
+	return CategoryOf(err) == CategoryTransient
+}
+
+// ExitError ExecStream 的命令以非零状态退出时，stdout 读到末尾返回该错误
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("command exited with code %d", e.Code)
+}
@@ -0,0 +1,55 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	errdefs "github.com/containerd/errdefs"
+)
+
+func TestWrapClassifiesUnderlyingError(t *testing.T) {
+	cases := []struct {
+		name      string
+		kind      error
+		err       error
+		category  Category
+		retryable bool
+	}{
+		{"docker unavailable", ErrContainerStartFailed, errdefs.ErrUnavailable, CategoryTransient, true},
+		{"deadline", ErrImagePullFailed, context.DeadlineExceeded, CategoryTransient, true},
+		{"name conflict", ErrContainerStartFailed, errdefs.ErrConflict, CategoryConflict, false},
+		{"image missing", ErrImagePullFailed, errdefs.ErrNotFound, CategoryNotFound, false},
+		{"bad config", ErrContainerStartFailed, errdefs.ErrInvalidArgument, CategoryInvalid, false},
+		{"unknown", ErrExecFailed, errors.New("boom"), CategoryFatal, false},
+	}
+	for _, tc := range cases {
+		err := Wrap(tc.kind, tc.err)
+		if err.Category != tc.category || err.Retryable != tc.retryable {
+			t.Errorf("%s: category=%s retryable=%v, want %s %v", tc.name, err.Category, err.Retryable, tc.category, tc.retryable)
+		}
+		if !errors.Is(err, tc.kind) || !errors.Is(err, tc.err) {
+			t.Errorf("%s: errors.Is lost kind or cause", tc.name)
+		}
+		if want := fmt.Sprintf("%v: %v", tc.kind, tc.err); err.Error() != want {
+			t.Errorf("%s: message %q, want %q", tc.name, err.Error(), want)
+		}
+	}
+}
+
+func TestCategoryOfWrappedChain(t *testing.T) {
+	err := fmt.Errorf("failed to start warm container: %w", Wrap(ErrContainerStartFailed, errdefs.ErrUnavailable))
+	if CategoryOf(err) != CategoryTransient || !IsRetryable(err) {
+		t.Fatalf("CategoryOf = %s, retryable = %v", CategoryOf(err), IsRetryable(err))
+	}
+	if got := CategoryOf(fmt.Errorf("%w: waited 1s", ErrTooManyExecs)); got != CategoryTransient {
+		t.Fatalf("too many execs category = %s", got)
+	}
+	if got := CategoryOf(ErrContainerNotFound); got != CategoryNotFound {
+		t.Fatalf("not found category = %s", got)
+	}
+	if got := CategoryOf(errors.New("plain")); got != "" || IsRetryable(errors.New("plain")) {
+		t.Fatalf("plain error category = %q", got)
+	}
+}
@@ -0,0 +1,63 @@
+package sandbox
+
+import (
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// execKillGrace 超时杀进程后等待输出流结束的时间，超过后直接断开连接
+const execKillGrace = 5 * time.Second
+
+// execPIDDir 记录带超时命令 PID 的目录，位于容器内
+const execPIDDir = "/tmp"
+
+// newExecPIDFile 为一次带超时的 exec 生成容器内 PID 文件路径
+func newExecPIDFile() string {
+	return path.Join(execPIDDir, ".exec-"+uuid.New().String()+".pid")
+}
+
+// withPIDFile Docker 没有终止单个 exec 的接口，
+// 由 shell 在后台启动命令并记录 PID，超时后再通过另一个 exec 杀掉它。
+// shell 等待命令结束后删除 PID 文件并透传退出码。
+func withPIDFile(pidFile string, cmd []string) []string {
+	script := `"$@" & pid=$!; echo "$pid" > "$0"; wait "$pid"; rc=$?; rm -f "$0"; exit "$rc"`
+	return append([]string{"/bin/sh", "-c", script, pidFile}, cmd...)
+}
+
+// killPIDFileCommand 杀掉 PID 文件中记录的进程及其直接子进程
+func killPIDFileCommand(pidFile string) []string {
+	script := `pid=$(cat "$0" 2>/dev/null) || exit 0; pkill -KILL -P "$pid" 2>/dev/null; kill -KILL "$pid" 2>/dev/null; rm -f "$0"; exit 0`
+	return []string{"/bin/sh", "-c", script, pidFile}
+}
+
+// limitedBuffer 最多保留 limit 字节，超出部分丢弃但仍报告写入成功，
+// 以便继续消费输出流；limit <= 0 表示不限制
+type limitedBuffer struct {
+	buf       []byte
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.limit <= 0 {
+		b.buf = append(b.buf, p...)
+		return len(p), nil
+	}
+	if room := b.limit - len(b.buf); room > 0 {
+		if len(p) > room {
+			b.buf = append(b.buf, p[:room]...)
+			b.truncated = true
+		} else {
+			b.buf = append(b.buf, p...)
+		}
+	} else if len(p) > 0 {
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return string(b.buf)
+}
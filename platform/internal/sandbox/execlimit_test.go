@@ -0,0 +1,24 @@
+package sandbox
+
+import "testing"
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{limit: 5}
+	for _, chunk := range []string{"abc", "def", "gh"} {
+		if n, err := b.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	if b.String() != "abcde" {
+		t.Errorf("Expected %q, got %q", "abcde", b.String())
+	}
+	if !b.truncated {
+		t.Error("Expected truncated to be set")
+	}
+
+	unlimited := &limitedBuffer{}
+	unlimited.Write([]byte("abcdef"))
+	if unlimited.String() != "abcdef" || unlimited.truncated {
+		t.Errorf("Unexpected unlimited buffer state: %q truncated=%v", unlimited.String(), unlimited.truncated)
+	}
+}
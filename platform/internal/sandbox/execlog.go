@@ -0,0 +1,60 @@
+package sandbox
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// execLogFile exec 日志在宿主机上的位置：<LogDir>/<SessionID>/events.jsonl
+func execLogFile(cfg ContainerConfig) string {
+	return filepath.Join(cfg.LogDir, cfg.SessionID, "events.jsonl")
+}
+
+// appendExecLog 追加一条 exec 记录，失败只记录日志
+func appendExecLog(logger *slog.Logger, cfg ContainerConfig, entry ExecLogEntry) {
+	f, err := os.OpenFile(execLogFile(cfg), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logger.Error("Failed to open log file", "error", err)
+		return
+	}
+	defer f.Close()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		logger.Error("Failed to marshal log entry", "error", err)
+		return
+	}
+	_, _ = f.Write(append(data, '\n'))
+}
+
+func readExecLogs(logger *slog.Logger, cfg ContainerConfig) ([]ExecLogEntry, error) {
+	f, err := os.Open(execLogFile(cfg))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []ExecLogEntry{}, nil
+		}
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+
+	var entries []ExecLogEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry ExecLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			logger.Warn("Failed to unmarshal log entry", "error", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan log file: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,43 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// ExecOutputFunc 接收 exec 的实时输出片段，stream 为 "stdout" 或 "stderr"
+type ExecOutputFunc func(stream string, data []byte)
+
+type execOutputKey struct{}
+
+// WithExecOutput 在 ctx 上执行的 Exec / ExecWithOptions 会在缓冲输出的同时把输出片段交给 fn，
+// fn 为 nil 时关闭继承自上层 ctx 的输出转发（如输出无意义的探测命令）
+func WithExecOutput(ctx context.Context, fn ExecOutputFunc) context.Context {
+	return context.WithValue(ctx, execOutputKey{}, fn)
+}
+
+// ExecOutputFrom 返回 ctx 上设置的输出回调，未设置时返回 nil
+func ExecOutputFrom(ctx context.Context) ExecOutputFunc {
+	fn, _ := ctx.Value(execOutputKey{}).(ExecOutputFunc)
+	return fn
+}
+
+// teeExecOutput 在 ctx 设置了输出回调时把 w 的写入同时转发给回调
+func teeExecOutput(ctx context.Context, stream string, w io.Writer) io.Writer {
+	fn := ExecOutputFrom(ctx)
+	if fn == nil {
+		return w
+	}
+	return io.MultiWriter(w, execOutputWriter{stream: stream, fn: fn})
+}
+
+type execOutputWriter struct {
+	stream string
+	fn     ExecOutputFunc
+}
+
+func (w execOutputWriter) Write(p []byte) (int, error) {
+	w.fn(w.stream, bytes.Clone(p))
+	return len(p), nil
+}
@@ -0,0 +1,29 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestTeeExecOutput(t *testing.T) {
+	var buf bytes.Buffer
+	if w := teeExecOutput(context.Background(), "stdout", &buf); w != &buf {
+		t.Fatal("expected the buffer itself without an output callback")
+	}
+
+	var got []string
+	ctx := WithExecOutput(context.Background(), func(stream string, data []byte) {
+		got = append(got, stream+":"+string(data))
+	})
+	w := teeExecOutput(ctx, "stderr", &buf)
+	w.Write([]byte("boom"))
+	if buf.String() != "boom" || len(got) != 1 || got[0] != "stderr:boom" {
+		t.Fatalf("buf=%q got=%v", buf.String(), got)
+	}
+
+	// 显式关闭后不再转发
+	if w := teeExecOutput(WithExecOutput(ctx, nil), "stdout", &buf); w != &buf {
+		t.Fatal("expected nil callback to disable forwarding")
+	}
+}
@@ -0,0 +1,105 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ExecConcurrency 单个容器的并发 exec 限制，MaxConcurrent 为 0 表示不限制
+type ExecConcurrency struct {
+	// MaxConcurrent 同时运行的 exec 数量上限
+	MaxConcurrent int
+	// MaxQueued 等待空闲名额的 exec 数量上限，超出后直接拒绝
+	MaxQueued int
+	// QueueTimeout 排队等待的最长时间，0 表示一直等到 ctx 结束
+	QueueTimeout time.Duration
+}
+
+// execLimiter 按容器 ID 记录正在运行和排队的 exec。
+// 同一容器会被多处各自 NewContainer，名额必须放在包级别共享
+type execLimiter struct {
+	mu     sync.Mutex
+	config ExecConcurrency
+	slots  map[string]*execSlot
+}
+
+type execSlot struct {
+	sem chan struct{}
+	// refs 正在运行和排队的 exec 总数，归零时删除
+	refs int
+}
+
+var execLimits = &execLimiter{slots: make(map[string]*execSlot)}
+
+// SetExecConcurrency 设置所有容器的并发 exec 限制，应在启动时调用
+func SetExecConcurrency(cfg ExecConcurrency) {
+	execLimits.mu.Lock()
+	defer execLimits.mu.Unlock()
+	execLimits.config = cfg
+}
+
+// AcquireExec 为容器占用一个 exec 名额，名额已满时排队；排队超时或队列已满时返回 ErrTooManyExecs。
+// 返回的 release 必须调用。直接调用 Docker exec API 的路径也应通过它限流
+func AcquireExec(ctx context.Context, containerID string) (release func(), err error) {
+	return execLimits.acquire(ctx, containerID)
+}
+
+func (l *execLimiter) acquire(ctx context.Context, key string) (func(), error) {
+	l.mu.Lock()
+	cfg := l.config
+	if cfg.MaxConcurrent <= 0 {
+		l.mu.Unlock()
+		return func() {}, nil
+	}
+	slot, ok := l.slots[key]
+	if !ok {
+		slot = &execSlot{sem: make(chan struct{}, cfg.MaxConcurrent)}
+		l.slots[key] = slot
+	}
+	if slot.refs >= cap(slot.sem)+cfg.MaxQueued {
+		l.mu.Unlock()
+		return nil, fmt.Errorf("%w: %d running, %d queued", ErrTooManyExecs, cap(slot.sem), cfg.MaxQueued)
+	}
+	slot.refs++
+	l.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if cfg.QueueTimeout > 0 {
+		timer := time.NewTimer(cfg.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case slot.sem <- struct{}{}:
+	default:
+		select {
+		case slot.sem <- struct{}{}:
+		case <-timeout:
+			l.unref(key, slot)
+			return nil, fmt.Errorf("%w: waited %s for a free slot", ErrTooManyExecs, cfg.QueueTimeout)
+		case <-ctx.Done():
+			l.unref(key, slot)
+			return nil, ctx.Err()
+		}
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-slot.sem
+			l.unref(key, slot)
+		})
+	}, nil
+}
+
+func (l *execLimiter) unref(key string, slot *execSlot) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slot.refs--
+	if slot.refs == 0 && l.slots[key] == slot {
+		delete(l.slots, key)
+	}
+}
@@ -0,0 +1,94 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(cfg ExecConcurrency) *execLimiter {
+	return &execLimiter{config: cfg, slots: make(map[string]*execSlot)}
+}
+
+func TestExecLimiterQueuesUntilRelease(t *testing.T) {
+	l := newTestLimiter(ExecConcurrency{MaxConcurrent: 1, MaxQueued: 1})
+
+	release, err := l.acquire(context.Background(), "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan func(), 1)
+	go func() {
+		r, err := l.acquire(context.Background(), "c1")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		acquired <- r
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second exec should wait for the first to finish")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// 其他容器不受影响
+	other, err := l.acquire(context.Background(), "c2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other()
+
+	release()
+	select {
+	case r := <-acquired:
+		r()
+	case <-time.After(time.Second):
+		t.Fatal("queued exec was not admitted after release")
+	}
+
+	if len(l.slots) != 0 {
+		t.Fatalf("slots not cleaned up: %d", len(l.slots))
+	}
+}
+
+func TestExecLimiterRejects(t *testing.T) {
+	l := newTestLimiter(ExecConcurrency{MaxConcurrent: 1, MaxQueued: 0})
+	release, err := l.acquire(context.Background(), "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	if _, err := l.acquire(context.Background(), "c1"); !errors.Is(err, ErrTooManyExecs) {
+		t.Fatalf("queue full: err = %v, want ErrTooManyExecs", err)
+	}
+
+	l.config.MaxQueued = 1
+	l.config.QueueTimeout = 20 * time.Millisecond
+	if _, err := l.acquire(context.Background(), "c1"); !errors.Is(err, ErrTooManyExecs) {
+		t.Fatalf("queue timeout: err = %v, want ErrTooManyExecs", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l.config.QueueTimeout = 0
+	if _, err := l.acquire(ctx, "c1"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled: err = %v, want context.Canceled", err)
+	}
+	if got := l.slots["c1"].refs; got != 1 {
+		t.Fatalf("refs = %d, want 1", got)
+	}
+}
+
+func TestExecLimiterUnlimited(t *testing.T) {
+	l := newTestLimiter(ExecConcurrency{})
+	for range 100 {
+		if _, err := l.acquire(context.Background(), "c1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
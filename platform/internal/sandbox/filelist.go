@@ -0,0 +1,73 @@
+package sandbox
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"time"
+)
+
+// findFormat 每个条目输出类型、权限、大小、修改时间、链接目标和相对路径，字段以 NUL 分隔，
+// 文件名中的换行、制表符等字符不会破坏解析
+const findFormat = `%y\0%m\0%s\0%T@\0%l\0%P\0`
+
+const findFields = 6
+
+// FindCommand 列出 dir 下条目的 find 命令（需要 GNU find），recursive 为 false 时只列出直接子项
+func FindCommand(dir string, recursive bool) []string {
+	cmd := []string{"find", dir, "-mindepth", "1"}
+	if !recursive {
+		cmd = append(cmd, "-maxdepth", "1")
+	}
+	return append(cmd, "-printf", findFormat)
+}
+
+// ParseFindOutput 解析 FindCommand 的输出，Path 为相对 dir 的路径
+func ParseFindOutput(out []byte) ([]FileInfo, error) {
+	fields := bytes.Split(out, []byte{0})
+	// 输出以 NUL 结尾，最后一个元素为空
+	if n := len(fields); n > 0 && len(fields[n-1]) == 0 {
+		fields = fields[:n-1]
+	}
+	if len(fields)%findFields != 0 {
+		return nil, fmt.Errorf("malformed file listing: %d fields", len(fields))
+	}
+
+	files := make([]FileInfo, 0, len(fields)/findFields)
+	for i := 0; i < len(fields); i += findFields {
+		typ, permStr, sizeStr, mtimeStr := string(fields[i]), string(fields[i+1]), string(fields[i+2]), string(fields[i+3])
+		perm, err := strconv.ParseUint(permStr, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("malformed file mode %q", permStr)
+		}
+		size, _ := strconv.ParseInt(sizeStr, 10, 64)
+		mtime, _ := strconv.ParseFloat(mtimeStr, 64)
+
+		mode := fs.FileMode(perm) & fs.ModePerm
+		switch typ {
+		case "d":
+			mode |= fs.ModeDir
+		case "l":
+			mode |= fs.ModeSymlink
+		case "p":
+			mode |= fs.ModeNamedPipe
+		case "s":
+			mode |= fs.ModeSocket
+		case "c":
+			mode |= fs.ModeDevice | fs.ModeCharDevice
+		case "b":
+			mode |= fs.ModeDevice
+		}
+
+		files = append(files, FileInfo{
+			Path:    string(fields[i+5]),
+			Size:    size,
+			IsDir:   typ == "d",
+			ModTime: time.Unix(0, int64(mtime*float64(time.Second))),
+			Mode:    mode.String(),
+			Link:    string(fields[i+4]),
+		})
+	}
+	return files, nil
+}
@@ -0,0 +1,44 @@
+package sandbox
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseFindOutput(t *testing.T) {
+	out := "d\x00755\x004096\x001700000000.5\x00\x00src\x00" +
+		"f\x00644\x0012\x001700000001.0\x00\x00src/we ird\nname.txt\x00" +
+		"l\x00777\x007\x001700000002.0\x00main.go\x00link\x00"
+	files, err := ParseFindOutput([]byte(out))
+	if err != nil {
+		t.Fatalf("ParseFindOutput: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("got %d files, want 3", len(files))
+	}
+	if f := files[0]; f.Path != "src" || !f.IsDir || f.Mode != "drwxr-xr-x" {
+		t.Fatalf("dir = %+v", f)
+	}
+	if f := files[1]; f.Path != "src/we ird\nname.txt" || f.Size != 12 || f.Mode != "-rw-r--r--" || f.ModTime.Unix() != 1700000001 {
+		t.Fatalf("file = %+v", f)
+	}
+	if f := files[2]; f.Link != "main.go" || f.Mode != "Lrwxrwxrwx" {
+		t.Fatalf("symlink = %+v", f)
+	}
+
+	if files, err := ParseFindOutput(nil); err != nil || len(files) != 0 {
+		t.Fatalf("empty output = %v, %v", files, err)
+	}
+	if _, err := ParseFindOutput([]byte("f\x00644\x00")); err == nil {
+		t.Fatal("expected error for truncated output")
+	}
+}
+
+func TestFindCommand(t *testing.T) {
+	if cmd := FindCommand("/app/workspace", false); !slices.Contains(cmd, "-maxdepth") {
+		t.Fatalf("non-recursive command = %v", cmd)
+	}
+	if cmd := FindCommand("/app/workspace", true); slices.Contains(cmd, "-maxdepth") {
+		t.Fatalf("recursive command = %v", cmd)
+	}
+}
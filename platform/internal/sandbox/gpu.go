@@ -0,0 +1,35 @@
+package sandbox
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// gpuDeviceRequest 对应 docker run --gpus，未请求 GPU 时返回 false
+func gpuDeviceRequest(cfg ContainerConfig) (container.DeviceRequest, bool) {
+	if cfg.GPUCount == 0 && len(cfg.GPUDeviceIDs) == 0 {
+		return container.DeviceRequest{}, false
+	}
+	req := container.DeviceRequest{
+		Driver:       "nvidia",
+		Capabilities: [][]string{{"gpu"}},
+	}
+	if len(cfg.GPUDeviceIDs) > 0 {
+		req.DeviceIDs = cfg.GPUDeviceIDs
+	} else {
+		req.Count = cfg.GPUCount
+	}
+	return req, true
+}
+
+// ValidateGPURequest 检查 GPU 请求参数
+func ValidateGPURequest(count int, deviceIDs []string) error {
+	if count < -1 {
+		return fmt.Errorf("invalid gpu_count %d: must be -1 (all) or a non-negative number", count)
+	}
+	if count != 0 && len(deviceIDs) > 0 {
+		return fmt.Errorf("invalid gpu request: gpu_count and gpu_device_ids are mutually exclusive")
+	}
+	return nil
+}
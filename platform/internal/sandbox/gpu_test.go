@@ -0,0 +1,34 @@
+package sandbox
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGPUDeviceRequest(t *testing.T) {
+	if _, ok := gpuDeviceRequest(ContainerConfig{}); ok {
+		t.Fatal("no GPU requested, expected no device request")
+	}
+
+	req, ok := gpuDeviceRequest(ContainerConfig{GPUCount: -1})
+	if !ok || req.Count != -1 || req.Driver != "nvidia" || !slices.Equal(req.Capabilities[0], []string{"gpu"}) {
+		t.Fatalf("all GPUs: %+v", req)
+	}
+
+	req, ok = gpuDeviceRequest(ContainerConfig{GPUDeviceIDs: []string{"0", "2"}})
+	if !ok || req.Count != 0 || !slices.Equal(req.DeviceIDs, []string{"0", "2"}) {
+		t.Fatalf("device ids: %+v", req)
+	}
+}
+
+func TestValidateGPURequest(t *testing.T) {
+	if err := ValidateGPURequest(2, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateGPURequest(-2, nil); err == nil {
+		t.Fatal("expected error for negative count")
+	}
+	if err := ValidateGPURequest(1, []string{"0"}); err == nil {
+		t.Fatal("expected error for count with device ids")
+	}
+}
@@ -11,6 +11,16 @@ type Sandbox interface {
 	Stop(ctx context.Context, timeoutSeconds int) error
 	Remove(ctx context.Context) error
 	Exec(ctx context.Context, cmd []string, env []string, workDir string) (*ExecResult, error)
+
+	// ExecWithOptions 带超时和输出上限的 Exec。超时后命令进程被杀掉，
+	// 返回已收集的输出并设置 TimedOut；ctx 取消仍返回 ctx.Err()。
+	ExecWithOptions(ctx context.Context, cmd []string, env []string, workDir string, opts ExecOptions) (*ExecResult, error)
+
+	// ExecStream 启动命令并返回 stdin、stdout、stderr 流，输出随命令执行增量到达。
+	// 调用方需要并发读取 stdout 和 stderr 直到 EOF，并在输入结束后关闭 stdin；
+	// 命令以非零状态退出时，stdout 在输出结束后返回 *ExitError。ctx 取消会终止连接。
+	ExecStream(ctx context.Context, cmd []string, env []string, workDir string) (io.WriteCloser, io.ReadCloser, io.ReadCloser, error)
+
 	GetStatus(ctx context.Context) (string, error)
 	GetLogs(ctx context.Context, tail int) (*LogResult, error)
 	GetExecLogs(ctx context.Context) ([]ExecLogEntry, error)
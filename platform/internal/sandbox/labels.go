@@ -0,0 +1,150 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/google/uuid"
+
+	"platform/internal/buildinfo"
+)
+
+// 平台创建的容器、网络和卷上的标签
+const (
+	LabelManagedBy = "managed_by"
+	LabelProjectID = "project_id"
+	LabelSessionID = "session_id"
+	// LabelTenant 容器所属租户（session 的用户），预热容器在创建时尚无租户
+	LabelTenant = "tenant"
+	// LabelTemplate 创建容器所用的沙箱模板（镜像）
+	LabelTemplate = "template"
+	// LabelRunID 创建容器的平台进程实例，重启后不同，可据此区分上一次运行遗留的容器
+	LabelRunID = "run_id"
+	// LabelStrategy 容器的获取策略（Warm-Strategy / Cold-Strategy）
+	LabelStrategy = "strategy"
+	// LabelVersion 创建容器的平台版本
+	LabelVersion = "platform_version"
+	// LabelPoolProfile 预热容器所属的预热池，默认池的容器没有该标签
+	LabelPoolProfile = "pool_profile"
+)
+
+// ManagedByValue 平台资源的 managed_by 标签值
+const ManagedByValue = "agent-platform"
+
+// PoolProjectID 预热池容器的 project_id 标签值
+const PoolProjectID = "pool"
+
+// RunID 当前平台进程的实例 ID
+var RunID = uuid.NewString()
+
+// Labels 容器的标准标签，空值不写入（预热容器没有租户）
+func (cfg ContainerConfig) Labels() map[string]string {
+	labels := map[string]string{
+		LabelManagedBy: ManagedByValue,
+		LabelProjectID: cfg.ProjectID,
+		LabelSessionID: cfg.SessionID,
+		LabelRunID:     RunID,
+		LabelVersion:   buildinfo.Version,
+	}
+	for k, v := range map[string]string{
+		LabelTenant:      cfg.Tenant,
+		LabelTemplate:    cfg.Image,
+		LabelStrategy:    cfg.Strategy,
+		LabelPoolProfile: cfg.PoolProfile,
+	} {
+		if v != "" {
+			labels[k] = v
+		}
+	}
+	return labels
+}
+
+// IsManaged 标签是否表明资源由平台创建
+func IsManaged(labels map[string]string) bool {
+	return labels[LabelManagedBy] == ManagedByValue
+}
+
+// LabelQuery 按标签查询平台容器，值为空表示只要求存在该标签；managed_by 条件总是附加
+type LabelQuery map[string]string
+
+// Filters 转换为 Docker 的 label 过滤条件
+func (q LabelQuery) Filters() filters.Args {
+	args := filters.NewArgs(filters.Arg("label", LabelManagedBy+"="+ManagedByValue))
+	for k, v := range q {
+		if k == LabelManagedBy {
+			continue
+		}
+		if v == "" {
+			args.Add("label", k)
+		} else {
+			args.Add("label", k+"="+v)
+		}
+	}
+	return args
+}
+
+// ContainerLister 标签查询所需的 Docker API 子集，*client.Client 满足该接口
+type ContainerLister interface {
+	ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error)
+}
+
+// ListManaged 按标签列出平台容器，all 为 true 时包含已停止的容器。
+// 返回的标签已由 OwnedLabels 修正，session_id 和 tenant 条件按修正后的标签过滤
+func ListManaged(ctx context.Context, docker ContainerLister, q LabelQuery, all bool) ([]container.Summary, error) {
+	remote, owned := LabelQuery{}, LabelQuery{}
+	for k, v := range q {
+		if k == LabelSessionID || k == LabelTenant {
+			owned[k] = v
+		} else {
+			remote[k] = v
+		}
+	}
+	containers, err := docker.ContainerList(ctx, container.ListOptions{All: all, Filters: remote.Filters()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list platform containers: %w", err)
+	}
+
+	out := containers[:0]
+	for _, c := range containers {
+		name := ""
+		if len(c.Names) > 0 {
+			name = c.Names[0]
+		}
+		c.Labels = OwnedLabels(name, c.Labels)
+		if owned.matches(c.Labels) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (q LabelQuery) matches(labels map[string]string) bool {
+	for k, v := range q {
+		got, ok := labels[k]
+		if !ok || (v != "" && got != v) {
+			return false
+		}
+	}
+	return true
+}
+
+// OwnedLabels 返回反映沙箱容器当前归属的标签。预热容器回收或转移时只改名为 agent-<session>，
+// Docker 不能修改已有容器的标签，session_id 和 tenant 可能仍指向上一个 session：
+// 两者不一致时以容器名为准并去掉 tenant。没有 strategy 标签的容器（出网代理、伴随服务等）不会改名，原样返回
+func OwnedLabels(name string, labels map[string]string) map[string]string {
+	if labels[LabelStrategy] == "" {
+		return labels
+	}
+	owner, ok := strings.CutPrefix(strings.TrimPrefix(name, "/"), ContainerName(""))
+	if !ok || owner == "" || labels[LabelSessionID] == owner {
+		return labels
+	}
+	out := maps.Clone(labels)
+	out[LabelSessionID] = owner
+	delete(out, LabelTenant)
+	return out
+}
@@ -0,0 +1,107 @@
+package sandbox
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestContainerLabels(t *testing.T) {
+	labels := ContainerConfig{SessionID: "s1", ProjectID: "p1", Image: "python:3.12", Strategy: "Cold-Strategy"}.Labels()
+	if !IsManaged(labels) || labels[LabelSessionID] != "s1" || labels[LabelTemplate] != "python:3.12" || labels[LabelRunID] != RunID {
+		t.Fatalf("labels = %v", labels)
+	}
+	if _, ok := labels[LabelTenant]; ok {
+		t.Fatalf("empty tenant should be omitted: %v", labels)
+	}
+}
+
+func TestLabelQuery(t *testing.T) {
+	q := LabelQuery{LabelTenant: "alice", LabelStrategy: ""}
+	got := q.Filters().Get("label")
+	slices.Sort(got)
+	want := []string{"managed_by=agent-platform", "strategy", "tenant=alice"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("filters = %v, want %v", got, want)
+	}
+
+}
+
+// fakeLister 返回固定的容器列表，并记录 Docker 侧的过滤条件
+type fakeLister struct {
+	containers []container.Summary
+	filters    []string
+}
+
+func (f *fakeLister) ContainerList(_ context.Context, opts container.ListOptions) ([]container.Summary, error) {
+	f.filters = opts.Filters.Get("label")
+	return slices.Clone(f.containers), nil
+}
+
+func TestListManagedUsesCurrentOwner(t *testing.T) {
+	stale := map[string]string{
+		LabelManagedBy: ManagedByValue,
+		LabelSessionID: "old-session",
+		LabelTenant:    "alice",
+		LabelStrategy:  "Warm-Strategy",
+	}
+	lister := &fakeLister{containers: []container.Summary{
+		// 回收后改回预热名称
+		{ID: "recycled", Names: []string{"/agent-warmup-1"}, Labels: stale},
+		// 回收后又分配给 new-session
+		{ID: "transferred", Names: []string{"/agent-new-session"}, Labels: stale},
+		{ID: "fresh", Names: []string{"/agent-s2"}, Labels: map[string]string{
+			LabelManagedBy: ManagedByValue, LabelSessionID: "s2", LabelTenant: "bob", LabelStrategy: "Cold-Strategy",
+		}},
+		// 出网代理没有 strategy 标签，名称不是 agent-<session>
+		{ID: "proxy", Names: []string{"/agent-proxy-old-session"}, Labels: map[string]string{
+			LabelManagedBy: ManagedByValue, LabelSessionID: "old-session",
+		}},
+	}}
+
+	tests := []struct {
+		name  string
+		query LabelQuery
+		want  []string
+	}{
+		{"last session", LabelQuery{LabelSessionID: "old-session"}, []string{"proxy"}},
+		{"new owner", LabelQuery{LabelSessionID: "new-session"}, []string{"transferred"}},
+		{"stale tenant", LabelQuery{LabelTenant: "alice"}, nil},
+		{"current tenant", LabelQuery{LabelTenant: "bob"}, []string{"fresh"}},
+		{"all", nil, []string{"recycled", "transferred", "fresh", "proxy"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ListManaged(context.Background(), lister, tt.query, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, c := range got {
+				ids = append(ids, c.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Fatalf("containers = %v, want %v", ids, tt.want)
+			}
+			if slices.ContainsFunc(lister.filters, func(f string) bool {
+				return strings.HasPrefix(f, LabelSessionID) || strings.HasPrefix(f, LabelTenant)
+			}) {
+				t.Errorf("ownership labels should be filtered locally, docker filters = %v", lister.filters)
+			}
+		})
+	}
+
+	got, _ := ListManaged(context.Background(), lister, nil, true)
+	if owner := got[0].Labels[LabelSessionID]; owner != "warmup-1" {
+		t.Errorf("recycled container session_id = %q, want warmup-1", owner)
+	}
+	if _, ok := got[0].Labels[LabelTenant]; ok {
+		t.Errorf("recycled container should not keep the last tenant: %v", got[0].Labels)
+	}
+	if stale[LabelSessionID] != "old-session" {
+		t.Error("OwnedLabels must not modify the original labels")
+	}
+}
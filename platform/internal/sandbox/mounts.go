@@ -0,0 +1,176 @@
+package sandbox
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+)
+
+// MountType 额外挂载的类型
+type MountType string
+
+const (
+	// MountBind 绑定宿主机目录或文件，HostPath 为宿主机绝对路径
+	MountBind MountType = "bind"
+	// MountVolume 挂载已有的 Docker 命名卷，HostPath 为卷名
+	MountVolume MountType = "volume"
+	// MountTmpfs 内存文件系统，不需要 HostPath
+	MountTmpfs MountType = "tmpfs"
+)
+
+// MountSpec 工作区之外的额外挂载，如只读数据集、pip / npm 缓存、密钥文件
+type MountSpec struct {
+	HostPath      string    `json:"host_path,omitempty"`
+	ContainerPath string    `json:"container_path"`
+	ReadOnly      bool      `json:"read_only,omitempty"`
+	Type          MountType `json:"type,omitempty"` // 为空时为 bind
+	// Driver volume 挂载的卷驱动配置（NFS / CIFS / 本机设备），卷不存在时按此创建
+	Driver *VolumeDriver `json:"driver,omitempty"`
+	// Dataset 引用的数据集名称，由 DatasetCatalog.Resolve 填充其余字段
+	Dataset string `json:"dataset,omitempty"`
+}
+
+func (m MountSpec) mountType() MountType {
+	if m.Type == "" {
+		return MountBind
+	}
+	return m.Type
+}
+
+// Validate 检查单个挂载，错误信息包含 invalid
+func (m MountSpec) Validate() error {
+	if !path.IsAbs(m.ContainerPath) || path.Clean(m.ContainerPath) == "/" {
+		return fmt.Errorf("invalid mount: container_path %q must be an absolute path other than /", m.ContainerPath)
+	}
+	switch m.mountType() {
+	case MountBind:
+		if !filepath.IsAbs(m.HostPath) {
+			return fmt.Errorf("invalid mount: host_path %q must be an absolute path", m.HostPath)
+		}
+	case MountVolume:
+		if m.HostPath == "" || strings.ContainsAny(m.HostPath, "/\\") {
+			return fmt.Errorf("invalid mount: volume mounts require a volume name in host_path")
+		}
+	case MountTmpfs:
+		if m.HostPath != "" {
+			return fmt.Errorf("invalid mount: tmpfs mounts do not take a host_path")
+		}
+	default:
+		return fmt.Errorf("invalid mount type %q (expected bind, volume or tmpfs)", m.Type)
+	}
+	if m.Dataset != "" && !m.ReadOnly {
+		return fmt.Errorf("invalid mount: dataset %q must be mounted read-only", m.Dataset)
+	}
+	if m.Driver != nil {
+		if m.mountType() != MountVolume {
+			return fmt.Errorf("invalid mount: driver options require a volume mount")
+		}
+		if err := m.Driver.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateMounts 检查所有挂载，挂载点不能重复，也不能与工作区重叠
+func ValidateMounts(mounts []MountSpec, workspace string) error {
+	seen := make(map[string]bool, len(mounts))
+	for _, m := range mounts {
+		if err := m.Validate(); err != nil {
+			return err
+		}
+		target := path.Clean(m.ContainerPath)
+		if seen[target] {
+			return fmt.Errorf("invalid mount: duplicate container_path %q", target)
+		}
+		seen[target] = true
+		if workspace != "" && (target == workspace || strings.HasPrefix(workspace, target+"/") || strings.HasPrefix(target, workspace+"/")) {
+			return fmt.Errorf("invalid mount: container_path %q overlaps the workspace %s", target, workspace)
+		}
+	}
+	return nil
+}
+
+// ValidateMountSources 检查 session 请求的挂载来源：bind 挂载的宿主机路径解析符号链接后必须位于 roots 之一下，
+// roots 为空时不允许 bind 挂载；命名卷必须在 volumes 中（以 * 结尾时按前缀匹配），volumes 为空时不允许挂载命名卷，
+// 以免挂载其他 session 的工作区卷或平台自身的卷；卷驱动配置只能由平台通过数据集提供。数据集由平台配置，不受此限制
+func ValidateMountSources(mounts []MountSpec, roots, volumes []string) error {
+	for _, m := range mounts {
+		if m.Dataset != "" {
+			continue
+		}
+		if m.Driver != nil {
+			return fmt.Errorf("invalid mount: volume driver options for %s cannot be set per session, use a dataset instead", m.ContainerPath)
+		}
+		switch m.mountType() {
+		case MountBind:
+			if err := checkMountRoot(m.HostPath, roots); err != nil {
+				return err
+			}
+		case MountVolume:
+			if !volumeAllowed(m.HostPath, volumes) {
+				return fmt.Errorf("invalid mount: volume %q is not an allowed mount volume", m.HostPath)
+			}
+		}
+	}
+	return nil
+}
+
+// checkMountRoot 宿主机路径和 roots 都先解析符号链接再比较，防止借 roots 下的符号链接指向其外的目录
+func checkMountRoot(hostPath string, roots []string) error {
+	host, err := filepath.EvalSymlinks(hostPath)
+	if err != nil {
+		return fmt.Errorf("invalid mount: host_path %q cannot be resolved: %w", hostPath, err)
+	}
+	for _, root := range roots {
+		if resolved, err := filepath.EvalSymlinks(root); err == nil {
+			root = resolved
+		}
+		root = filepath.Clean(root)
+		if host == root || strings.HasPrefix(host, root+string(filepath.Separator)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid mount: host_path %q is not under an allowed mount root", hostPath)
+}
+
+func volumeAllowed(name string, volumes []string) bool {
+	for _, v := range volumes {
+		if prefix, ok := strings.CutSuffix(v, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (m MountSpec) dockerMount() mount.Mount {
+	dm := mount.Mount{
+		Type:     mount.Type(m.mountType()),
+		Source:   m.HostPath,
+		Target:   m.ContainerPath,
+		ReadOnly: m.ReadOnly,
+	}
+	if m.mountType() == MountTmpfs {
+		dm.Source = ""
+	}
+	if m.Driver != nil {
+		dm.VolumeOptions = &mount.VolumeOptions{NoCopy: true, DriverConfig: m.Driver.driverConfig()}
+	}
+	return dm
+}
+
+// DockerMounts 转换为 Docker 挂载，供沙箱之外的容器（如伴随服务）使用，调用前应先校验
+func DockerMounts(mounts []MountSpec) []mount.Mount {
+	out := make([]mount.Mount, 0, len(mounts))
+	for _, m := range mounts {
+		out = append(out, m.dockerMount())
+	}
+	return out
+}
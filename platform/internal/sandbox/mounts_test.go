@@ -0,0 +1,91 @@
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateMounts(t *testing.T) {
+	workspace := "/app/workspace"
+	ok := []MountSpec{
+		{HostPath: "/data/imagenet", ContainerPath: "/data", ReadOnly: true},
+		{HostPath: "pip-cache", ContainerPath: "/root/.cache/pip", Type: MountVolume},
+		{ContainerPath: "/scratch", Type: MountTmpfs},
+	}
+	if err := ValidateMounts(ok, workspace); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	bad := map[string][]MountSpec{
+		"relative host path": {{HostPath: "data", ContainerPath: "/data"}},
+		"relative target":    {{HostPath: "/data", ContainerPath: "data"}},
+		"root target":        {{HostPath: "/data", ContainerPath: "/"}},
+		"volume with path":   {{HostPath: "/var/lib/x", ContainerPath: "/x", Type: MountVolume}},
+		"tmpfs with source":  {{HostPath: "/x", ContainerPath: "/x", Type: MountTmpfs}},
+		"unknown type":       {{HostPath: "/x", ContainerPath: "/x", Type: "npipe"}},
+		"duplicate":          {{HostPath: "/a", ContainerPath: "/x"}, {HostPath: "/b", ContainerPath: "/x/"}},
+		"workspace":          {{HostPath: "/a", ContainerPath: "/app/workspace"}},
+		"inside workspace":   {{HostPath: "/a", ContainerPath: "/app/workspace/data"}},
+		"shadows workspace":  {{HostPath: "/a", ContainerPath: "/app"}},
+	}
+	for name, mounts := range bad {
+		if err := ValidateMounts(mounts, workspace); err == nil || !strings.Contains(err.Error(), "invalid") {
+			t.Errorf("%s: err = %v, want invalid", name, err)
+		}
+	}
+}
+
+func TestValidateMountSources(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "datasets")
+	outside := filepath.Join(dir, "private")
+	for _, d := range []string{filepath.Join(root, "imagenet"), outside} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// roots 下指向其外目录的符号链接
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	roots := []string{root}
+	volumes := []string{"pip-cache", "shared-*"}
+
+	if err := ValidateMountSources([]MountSpec{
+		{HostPath: filepath.Join(root, "imagenet"), ContainerPath: "/data"},
+		{HostPath: "pip-cache", ContainerPath: "/cache", Type: MountVolume},
+		{HostPath: "shared-models", ContainerPath: "/models", Type: MountVolume},
+		{ContainerPath: "/scratch", Type: MountTmpfs},
+	}, roots, volumes); err != nil {
+		t.Fatal(err)
+	}
+
+	nfs := &VolumeDriver{Type: VolumeNFS, Server: "10.0.0.5", Path: "/data"}
+	bad := map[string]MountSpec{
+		"sibling dir":       {HostPath: root + "-private", ContainerPath: "/data"},
+		"dot dot":           {HostPath: filepath.Join(root, "..", "private"), ContainerPath: "/data"},
+		"symlink escape":    {HostPath: filepath.Join(root, "escape"), ContainerPath: "/data"},
+		"missing host path": {HostPath: filepath.Join(root, "missing"), ContainerPath: "/data"},
+		"workspace volume":  {HostPath: "workspace-s-123", ContainerPath: "/data", Type: MountVolume},
+		"volume prefix":     {HostPath: "pip-cache-other", ContainerPath: "/data", Type: MountVolume},
+		"session driver":    {HostPath: "pip-cache", ContainerPath: "/x", Type: MountVolume, Driver: nfs},
+	}
+	for name, m := range bad {
+		if err := ValidateMountSources([]MountSpec{m}, roots, volumes); err == nil || !strings.Contains(err.Error(), "invalid") {
+			t.Errorf("%s: err = %v, want invalid", name, err)
+		}
+	}
+
+	if err := ValidateMountSources([]MountSpec{{HostPath: root, ContainerPath: "/data"}}, nil, volumes); err == nil {
+		t.Error("bind mounts should be rejected without roots")
+	}
+	if err := ValidateMountSources([]MountSpec{{HostPath: "pip-cache", ContainerPath: "/cache", Type: MountVolume}}, roots, nil); err == nil {
+		t.Error("named volumes should be rejected without an allowlist")
+	}
+	// 卷驱动只能来自数据集目录
+	if err := ValidateMountSources([]MountSpec{{HostPath: "x", ContainerPath: "/x", Type: MountVolume, Driver: nfs, Dataset: "shared", ReadOnly: true}}, nil, nil); err != nil {
+		t.Errorf("dataset volume driver: %v", err)
+	}
+}
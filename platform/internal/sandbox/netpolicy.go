@@ -0,0 +1,236 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+)
+
+type NetworkPolicyMode string
+
+const (
+	// NetworkOpen 默认：沙箱直接接入共享网络，出网不受限制
+	NetworkOpen NetworkPolicyMode = ""
+	// NetworkNone 只接入 session 专属的 internal 网络，除宿主机上的 Platform 外无法访问任何地址
+	NetworkNone NetworkPolicyMode = "none"
+	// NetworkInternalOnly 经出网代理只能访问私有网段（RFC 1918），即平台内部的服务
+	NetworkInternalOnly NetworkPolicyMode = "internal-only"
+	// NetworkAllowlist 经出网代理只能访问 AllowCIDRs / AllowDomains 中的地址
+	NetworkAllowlist NetworkPolicyMode = "allowlist"
+)
+
+// EgressProxyAlias 代理 sidecar 在 session 网络中的别名
+const EgressProxyAlias = "egress-proxy"
+
+// EgressProxyPort 代理 sidecar 的监听端口
+const EgressProxyPort = 3128
+
+// 代理 sidecar 通过环境变量接收放行规则，见 platform-server egress-proxy
+const (
+	EgressEnvListen       = "EGRESS_LISTEN"
+	EgressEnvAllowCIDRs   = "EGRESS_ALLOW_CIDRS"
+	EgressEnvAllowDomains = "EGRESS_ALLOW_DOMAINS"
+)
+
+// PrivateCIDRs internal-only 模式放行的网段
+var PrivateCIDRs = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"}
+
+// NetworkPolicy 沙箱的网络隔离策略，零值表示不限制
+type NetworkPolicy struct {
+	Mode NetworkPolicyMode `json:"mode,omitempty"`
+	// AllowCIDRs 允许访问的网段，仅 allowlist 模式使用
+	AllowCIDRs []string `json:"allow_cidrs,omitempty"`
+	// AllowDomains 允许访问的域名，"*.example.com" 同时匹配子域名，仅 allowlist 模式使用
+	AllowDomains []string `json:"allow_domains,omitempty"`
+}
+
+// Restricted 是否需要为 session 创建专属网络
+func (p NetworkPolicy) Restricted() bool {
+	return p.Mode != NetworkOpen
+}
+
+// NeedsProxy 是否需要出网代理 sidecar
+func (p NetworkPolicy) NeedsProxy() bool {
+	return p.Mode == NetworkInternalOnly || p.Mode == NetworkAllowlist
+}
+
+// ProxyRules 代理 sidecar 实际使用的放行网段和域名
+func (p NetworkPolicy) ProxyRules() (cidrs, domains []string) {
+	switch p.Mode {
+	case NetworkInternalOnly:
+		return PrivateCIDRs, nil
+	case NetworkAllowlist:
+		return p.AllowCIDRs, p.AllowDomains
+	default:
+		return nil, nil
+	}
+}
+
+func (p NetworkPolicy) Validate() error {
+	switch p.Mode {
+	case NetworkOpen, NetworkNone, NetworkInternalOnly:
+		if len(p.AllowCIDRs) > 0 || len(p.AllowDomains) > 0 {
+			return fmt.Errorf("invalid network policy: allow lists require mode %q", NetworkAllowlist)
+		}
+		return nil
+	case NetworkAllowlist:
+	default:
+		return fmt.Errorf("invalid network policy mode %q", p.Mode)
+	}
+
+	if len(p.AllowCIDRs) == 0 && len(p.AllowDomains) == 0 {
+		return fmt.Errorf("invalid network policy: allowlist mode needs allow_cidrs or allow_domains")
+	}
+	for _, cidr := range p.AllowCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("invalid network policy CIDR %q", cidr)
+		}
+	}
+	for _, domain := range p.AllowDomains {
+		d := strings.TrimPrefix(domain, "*.")
+		if d == "" || strings.ContainsAny(d, "/:* ,") {
+			return fmt.Errorf("invalid network policy domain %q", domain)
+		}
+	}
+	return nil
+}
+
+func SessionNetworkName(sessionID string) string {
+	return "agent-session-" + sessionID
+}
+
+func EgressProxyName(sessionID string) string {
+	return "agent-proxy-" + sessionID
+}
+
+// sessionNetwork Start 为受限策略准备好的网络环境
+type sessionNetwork struct {
+	name    string
+	gateway string
+	// env 注入沙箱的代理环境变量
+	env []string
+}
+
+// setupSessionNetwork 创建 session 专属的 internal 网络，按需启动出网代理 sidecar。
+// internal 网络没有默认路由，沙箱只能访问同网络的代理和宿主机（网关地址）。
+func (c *Container) setupSessionNetwork(ctx context.Context) (*sessionNetwork, error) {
+	policy := c.Config.NetworkPolicy
+	sid := c.Config.SessionID
+	name := SessionNetworkName(sid)
+
+	labels := map[string]string{
+		LabelManagedBy: ManagedByValue,
+		LabelProjectID: c.Config.ProjectID,
+		LabelSessionID: sid,
+	}
+
+	if _, err := c.client.NetworkCreate(ctx, name, network.CreateOptions{
+		Driver:   "bridge",
+		Internal: true,
+		Labels:   labels,
+	}); err != nil && !errdefs.IsConflict(err) {
+		return nil, Wrap(ErrContainerStartFailed, fmt.Errorf("create session network: %w", err))
+	}
+
+	inspect, err := c.client.NetworkInspect(ctx, name, network.InspectOptions{})
+	if err != nil {
+		RemoveSessionNetwork(context.Background(), c.client, sid)
+		return nil, Wrap(ErrContainerStartFailed, fmt.Errorf("inspect session network: %w", err))
+	}
+	sn := &sessionNetwork{name: name}
+	if len(inspect.IPAM.Config) > 0 {
+		sn.gateway = inspect.IPAM.Config[0].Gateway
+	}
+
+	if !policy.NeedsProxy() {
+		return sn, nil
+	}
+
+	if err := c.startEgressProxy(ctx, name, labels); err != nil {
+		RemoveSessionNetwork(context.Background(), c.client, sid)
+		return nil, err
+	}
+
+	proxyURL := fmt.Sprintf("http://%s:%d", EgressProxyAlias, EgressProxyPort)
+	noProxy := "localhost,127.0.0.1,host.docker.internal"
+	sn.env = []string{
+		"HTTP_PROXY=" + proxyURL, "http_proxy=" + proxyURL,
+		"HTTPS_PROXY=" + proxyURL, "https_proxy=" + proxyURL,
+		"NO_PROXY=" + noProxy, "no_proxy=" + noProxy,
+	}
+	return sn, nil
+}
+
+// startEgressProxy 代理 sidecar 同时接入共享网络（出网）和 session 网络（服务沙箱）
+func (c *Container) startEgressProxy(ctx context.Context, sessionNet string, labels map[string]string) error {
+	if c.Config.EgressProxyImage == "" {
+		return fmt.Errorf("%w: network policy %q requires an egress proxy image", ErrContainerStartFailed, c.Config.NetworkPolicy.Mode)
+	}
+
+	cidrs, domains := c.Config.NetworkPolicy.ProxyRules()
+	proxyLabels := map[string]string{"role": "egress-proxy"}
+	for k, v := range labels {
+		proxyLabels[k] = v
+	}
+
+	config := &container.Config{
+		Image: c.Config.EgressProxyImage,
+		Cmd:   []string{"egress-proxy"},
+		Env: []string{
+			fmt.Sprintf("%s=:%d", EgressEnvListen, EgressProxyPort),
+			EgressEnvAllowCIDRs + "=" + strings.Join(cidrs, ","),
+			EgressEnvAllowDomains + "=" + strings.Join(domains, ","),
+		},
+		Labels: proxyLabels,
+	}
+	hostConfig := &container.HostConfig{
+		Resources: container.Resources{
+			Memory:       64 * 1024 * 1024,
+			NanoCPUs:     int64(0.2 * 1e9),
+			CgroupParent: c.Config.CgroupParent,
+		},
+	}
+	netConfig := &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			c.Config.NetworkName: {},
+		},
+	}
+
+	name := EgressProxyName(c.Config.SessionID)
+	resp, err := c.client.ContainerCreate(ctx, config, hostConfig, netConfig, nil, name)
+	if err != nil {
+		return Wrap(ErrContainerStartFailed, fmt.Errorf("create egress proxy: %w", err))
+	}
+	// 较旧的 API 创建时只支持一个网络，第二个网络在启动前单独接入
+	if err := c.client.NetworkConnect(ctx, sessionNet, resp.ID, &network.EndpointSettings{
+		Aliases: []string{EgressProxyAlias},
+	}); err != nil {
+		return Wrap(ErrContainerStartFailed, fmt.Errorf("attach egress proxy: %w", err))
+	}
+	if err := c.client.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return Wrap(ErrContainerStartFailed, fmt.Errorf("start egress proxy: %w", err))
+	}
+
+	c.logger.Info("Egress proxy started", "proxy_id", resp.ID, "mode", c.Config.NetworkPolicy.Mode)
+	return nil
+}
+
+// RemoveSessionNetwork 删除 session 的出网代理和专属网络，不存在时忽略。
+// 终止 session 时只知道容器 ID，因此按命名约定查找。
+func RemoveSessionNetwork(ctx context.Context, cli *client.Client, sessionID string) error {
+	err := cli.ContainerRemove(ctx, EgressProxyName(sessionID), container.RemoveOptions{Force: true})
+	if err != nil && !errdefs.IsNotFound(err) {
+		return fmt.Errorf("failed to remove egress proxy: %w", err)
+	}
+	err = cli.NetworkRemove(ctx, SessionNetworkName(sessionID))
+	if err != nil && !errdefs.IsNotFound(err) {
+		return fmt.Errorf("failed to remove session network: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package sandbox
+
+import "testing"
+
+func TestNetworkPolicyValidate(t *testing.T) {
+	cases := []struct {
+		name    string
+		policy  NetworkPolicy
+		wantErr bool
+	}{
+		{"open", NetworkPolicy{}, false},
+		{"none", NetworkPolicy{Mode: NetworkNone}, false},
+		{"internal-only", NetworkPolicy{Mode: NetworkInternalOnly}, false},
+		{"allowlist", NetworkPolicy{Mode: NetworkAllowlist, AllowCIDRs: []string{"10.1.0.0/16"}, AllowDomains: []string{"*.pypi.org"}}, false},
+		{"unknown mode", NetworkPolicy{Mode: "public"}, true},
+		{"empty allowlist", NetworkPolicy{Mode: NetworkAllowlist}, true},
+		{"bad cidr", NetworkPolicy{Mode: NetworkAllowlist, AllowCIDRs: []string{"10.1.0.0"}}, true},
+		{"bad domain", NetworkPolicy{Mode: NetworkAllowlist, AllowDomains: []string{"https://pypi.org"}}, true},
+		{"allow list without allowlist mode", NetworkPolicy{Mode: NetworkNone, AllowDomains: []string{"pypi.org"}}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.policy.Validate()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestNetworkPolicyProxyRules(t *testing.T) {
+	cidrs, domains := NetworkPolicy{Mode: NetworkInternalOnly}.ProxyRules()
+	if len(cidrs) != len(PrivateCIDRs) || domains != nil {
+		t.Fatalf("internal-only rules = %v %v", cidrs, domains)
+	}
+	if (NetworkPolicy{Mode: NetworkNone}).NeedsProxy() {
+		t.Fatal("none mode should not start a proxy")
+	}
+}
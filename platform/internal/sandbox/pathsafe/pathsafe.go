@@ -0,0 +1,83 @@
+// Package pathsafe 将用户提供的路径解析到工作区内。
+// 所有文件接口都经由这里处理用户路径：包含 .. 的路径一律拒绝，而不是依赖 path.Join 的截断，
+// 前缀比较按路径分段进行，避免 /app/workspace2 被当作 /app/workspace 的子路径
+package pathsafe
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrEscape 路径指向工作区之外
+var ErrEscape = errors.New("path escapes the workspace")
+
+// Rel 将相对工作区根目录的用户路径规范化为不以 / 开头的相对路径，工作区根目录返回空串。
+// 开头的 / 视为工作区根目录
+func Rel(p string) (string, error) {
+	if strings.Contains(p, "\x00") {
+		return "", fmt.Errorf("invalid path %q", p)
+	}
+	for _, part := range strings.Split(filepath.ToSlash(p), "/") {
+		if part == ".." {
+			return "", fmt.Errorf("invalid path %q: %w", p, ErrEscape)
+		}
+	}
+	return strings.TrimPrefix(path.Clean("/"+p), "/"), nil
+}
+
+// File 同 Rel，但路径必须指向工作区中的某个条目而不是根目录
+func File(p string) (string, error) {
+	rel, err := Rel(p)
+	if err != nil {
+		return "", err
+	}
+	if rel == "" {
+		return "", fmt.Errorf("invalid path %q: must name a file", p)
+	}
+	return rel, nil
+}
+
+// Join 返回 userPath 在容器内工作区 base 下的绝对路径
+func Join(base, userPath string) (string, error) {
+	rel, err := Rel(userPath)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(base, rel), nil
+}
+
+// HostJoin 同 Join，用于宿主机路径
+func HostJoin(root, userPath string) (string, error) {
+	rel, err := Rel(userPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, filepath.FromSlash(rel)), nil
+}
+
+// Within 判断容器内的绝对路径 p 是否为 base 或位于 base 之下
+func Within(base, p string) bool {
+	base, p = path.Clean(base), path.Clean(p)
+	return p == base || strings.HasPrefix(p, strings.TrimSuffix(base, "/")+"/")
+}
+
+// ResolveCommand 在容器内解析 p 中所有符号链接的命令，输出规范化后的绝对路径。
+// 优先使用 GNU realpath -m（允许最后几级不存在），busybox 镜像退化为 readlink -f
+func ResolveCommand(p string) []string {
+	return []string{"sh", "-c", `realpath -m -- "$1" 2>/dev/null || readlink -f -- "$1"`, "sh", p}
+}
+
+// CheckResolved 校验 ResolveCommand 的输出仍位于工作区 base 内，返回解析后的路径
+func CheckResolved(base, userPath string, output []byte) (string, error) {
+	resolved := strings.TrimSpace(string(output))
+	if resolved == "" || !path.IsAbs(resolved) {
+		return "", fmt.Errorf("failed to resolve path %q", userPath)
+	}
+	if !Within(base, resolved) {
+		return "", fmt.Errorf("invalid path %q: symlink %w", userPath, ErrEscape)
+	}
+	return path.Clean(resolved), nil
+}
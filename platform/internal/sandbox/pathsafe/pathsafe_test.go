@@ -0,0 +1,80 @@
+package pathsafe
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRelAndFile(t *testing.T) {
+	cases := []struct {
+		in     string
+		want   string
+		ok     bool
+		fileOK bool
+	}{
+		{"main.go", "main.go", true, true},
+		{"/src/./pkg/a.go", "src/pkg/a.go", true, true},
+		{"src//b.txt", "src/b.txt", true, true},
+		{"", "", true, false},
+		{"/", "", true, false},
+		{"../etc/passwd", "", false, false},
+		{"src/../../x", "", false, false},
+		{"src/../x", "", false, false},
+		{"a\x00b", "", false, false},
+	}
+	for _, tc := range cases {
+		got, err := Rel(tc.in)
+		if tc.ok != (err == nil) || got != tc.want {
+			t.Errorf("Rel(%q) = %q, %v; want %q, ok=%v", tc.in, got, err, tc.want, tc.ok)
+		}
+		if _, err := File(tc.in); tc.fileOK != (err == nil) {
+			t.Errorf("File(%q) err = %v, want ok=%v", tc.in, err, tc.fileOK)
+		}
+	}
+}
+
+func TestJoin(t *testing.T) {
+	got, err := Join("/app/workspace", "/src/main.go")
+	if err != nil || got != "/app/workspace/src/main.go" {
+		t.Fatalf("Join = %q, %v", got, err)
+	}
+	if _, err := Join("/app/workspace", "../workspace2/x"); !errors.Is(err, ErrEscape) {
+		t.Fatalf("Join escape err = %v, want ErrEscape", err)
+	}
+	got, err = HostJoin("/data/p1", "out/a.txt")
+	if err != nil || got != "/data/p1/out/a.txt" {
+		t.Fatalf("HostJoin = %q, %v", got, err)
+	}
+}
+
+func TestWithin(t *testing.T) {
+	cases := []struct {
+		p    string
+		want bool
+	}{
+		{"/app/workspace", true},
+		{"/app/workspace/", true},
+		{"/app/workspace/a/b", true},
+		{"/app/workspace2", false},
+		{"/app", false},
+		{"/etc/passwd", false},
+	}
+	for _, tc := range cases {
+		if got := Within("/app/workspace", tc.p); got != tc.want {
+			t.Errorf("Within(%q) = %v, want %v", tc.p, got, tc.want)
+		}
+	}
+}
+
+func TestCheckResolved(t *testing.T) {
+	got, err := CheckResolved("/app/workspace", "link", []byte("/app/workspace/real/file\n"))
+	if err != nil || got != "/app/workspace/real/file" {
+		t.Fatalf("CheckResolved = %q, %v", got, err)
+	}
+	if _, err := CheckResolved("/app/workspace", "link", []byte("/etc/shadow\n")); !errors.Is(err, ErrEscape) {
+		t.Fatalf("CheckResolved outside err = %v, want ErrEscape", err)
+	}
+	if _, err := CheckResolved("/app/workspace", "link", nil); err == nil {
+		t.Fatal("CheckResolved accepted empty output")
+	}
+}
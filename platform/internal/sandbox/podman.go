@@ -0,0 +1,62 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/client"
+)
+
+// DefaultPodmanSocket 返回 rootless Podman API 的默认地址（podman system service 启动的 socket）
+func DefaultPodmanSocket() string {
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		runtimeDir = fmt.Sprintf("/run/user/%d", os.Getuid())
+	}
+	return "unix://" + runtimeDir + "/podman/podman.sock"
+}
+
+// NewPodmanClient 创建连接 Podman 的客户端。Podman 提供 Docker 兼容的 REST API，
+// Container、Pool 以及伴随服务无需修改即可在 Podman 上运行；socket 为空时使用 DefaultPodmanSocket。
+func NewPodmanClient(ctx context.Context, socket string) (*client.Client, error) {
+	if socket == "" {
+		socket = DefaultPodmanSocket()
+	}
+
+	cli, err := client.NewClientWithOpts(client.WithHost(socket), client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("podman client: %w", err)
+	}
+	if !isPodman(ctx, cli) {
+		cli.Close()
+		return nil, fmt.Errorf("podman client: %s is not a podman API socket (hint: run `podman system service --time=0`)", socket)
+	}
+	return cli, nil
+}
+
+// podmanClients 缓存每个客户端是否连接的是 Podman，避免每次创建容器都查询版本
+var podmanClients sync.Map // *client.Client -> bool
+
+// isPodman 通过版本信息中的组件名判断 API 是否由 Podman 提供
+func isPodman(ctx context.Context, cli *client.Client) bool {
+	if v, ok := podmanClients.Load(cli); ok {
+		return v.(bool)
+	}
+
+	version, err := cli.ServerVersion(ctx)
+	if err != nil {
+		return false
+	}
+	podman := false
+	for _, component := range version.Components {
+		if strings.HasPrefix(component.Name, "Podman") {
+			podman = true
+			break
+		}
+	}
+	podmanClients.Store(cli, podman)
+	return podman
+}
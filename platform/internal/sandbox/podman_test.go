@@ -0,0 +1,46 @@
+package sandbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"platform/internal/sandbox"
+)
+
+func fakeEngine(t *testing.T, component string) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			w.Header().Set("API-Version", "1.41")
+			w.Write([]byte("OK"))
+		case strings.HasSuffix(r.URL.Path, "/version"):
+			json.NewEncoder(w).Encode(map[string]any{
+				"ApiVersion": "1.41",
+				"Components": []map[string]any{{"Name": component, "Version": "5.0.0"}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return "tcp://" + strings.TrimPrefix(srv.URL, "http://")
+}
+
+func TestNewPodmanClient(t *testing.T) {
+	ctx := context.Background()
+
+	cli, err := sandbox.NewPodmanClient(ctx, fakeEngine(t, "Podman Engine"))
+	if err != nil {
+		t.Fatalf("expected podman socket to be accepted: %v", err)
+	}
+	cli.Close()
+
+	if _, err := sandbox.NewPodmanClient(ctx, fakeEngine(t, "Engine")); err == nil {
+		t.Fatal("expected docker engine to be rejected")
+	}
+}
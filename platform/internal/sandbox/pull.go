@@ -0,0 +1,119 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// PullProgressInterval 两次拉取进度回调之间的最小间隔
+const PullProgressInterval = 500 * time.Millisecond
+
+// PullProgress 镜像拉取进度。Current / Total 为已知大小的层的下载字节数合计，
+// 层的大小在开始下载后才可知，因此 Total 会随拉取推进而增长
+type PullProgress struct {
+	Image      string `json:"image"`
+	Status     string `json:"status"`
+	Layers     int    `json:"layers"`
+	LayersDone int    `json:"layers_done"`
+	Current    int64  `json:"current"`
+	Total      int64  `json:"total"`
+	Done       bool   `json:"done"`
+}
+
+// pullMessage Docker pull 输出流中的一行
+type pullMessage struct {
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+	Error string `json:"error"`
+}
+
+type layerProgress struct {
+	current int64
+	total   int64
+	done    bool
+}
+
+// readPullStream 读取并解析 pull 输出直到结束，按 interval 节流调用 onProgress（可为 nil），
+// 结束时总会回调一次 Done 的进度。流中的错误消息（如镜像不存在、认证失败）作为 error 返回
+func readPullStream(r io.Reader, image string, interval time.Duration, onProgress func(PullProgress)) error {
+	layers := make(map[string]*layerProgress)
+	var order []string
+	var status string
+	var last time.Time
+
+	snapshot := func(done bool) PullProgress {
+		p := PullProgress{Image: image, Status: status, Layers: len(order), Done: done}
+		for _, id := range order {
+			l := layers[id]
+			if l.done {
+				p.LayersDone++
+			}
+			p.Current += l.current
+			p.Total += l.total
+		}
+		return p
+	}
+
+	dec := json.NewDecoder(r)
+	for {
+		var msg pullMessage
+		if err := dec.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("failed to read pull output: %w", err)
+		}
+		if msg.Error != "" {
+			return errors.New(msg.Error)
+		}
+		status = msg.Status
+
+		// 没有 ID 或 ID 为 tag 的消息是镜像级别的状态（Pulling from ...、Digest、Status），只统计层的消息
+		if msg.ID != "" && isLayerStatus(msg.Status) {
+			l, ok := layers[msg.ID]
+			if !ok {
+				l = &layerProgress{}
+				layers[msg.ID] = l
+				order = append(order, msg.ID)
+			}
+			switch msg.Status {
+			case "Downloading":
+				l.current = msg.ProgressDetail.Current
+				if msg.ProgressDetail.Total > 0 {
+					l.total = msg.ProgressDetail.Total
+				}
+			case "Download complete", "Verifying Checksum":
+				l.current = l.total
+			case "Pull complete", "Already exists":
+				l.current = l.total
+				l.done = true
+			}
+		}
+
+		if onProgress != nil && time.Since(last) >= interval {
+			last = time.Now()
+			onProgress(snapshot(false))
+		}
+	}
+
+	if onProgress != nil {
+		onProgress(snapshot(true))
+	}
+	return nil
+}
+
+func isLayerStatus(status string) bool {
+	switch status {
+	case "Pulling fs layer", "Waiting", "Downloading", "Verifying Checksum", "Download complete",
+		"Extracting", "Pull complete", "Already exists":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package sandbox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadPullStream(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"status":"Pulling from library/python","id":"3.12"}`,
+		`{"status":"Pulling fs layer","id":"a1"}`,
+		`{"status":"Already exists","id":"b2"}`,
+		`{"status":"Downloading","progressDetail":{"current":512,"total":2048},"id":"a1"}`,
+		`{"status":"Download complete","id":"a1"}`,
+		`{"status":"Pull complete","id":"a1"}`,
+		`{"status":"Digest: sha256:abc"}`,
+		`{"status":"Status: Downloaded newer image for python:3.12"}`,
+	}, "\n")
+
+	var updates []PullProgress
+	if err := readPullStream(strings.NewReader(stream), "python:3.12", 0, func(p PullProgress) {
+		updates = append(updates, p)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := updates[3]; got.Current != 512 || got.Total != 2048 || got.Done {
+		t.Fatalf("downloading progress = %+v", got)
+	}
+	last := updates[len(updates)-1]
+	if !last.Done || last.Layers != 2 || last.LayersDone != 2 || last.Current != 2048 || last.Total != 2048 {
+		t.Fatalf("final progress = %+v", last)
+	}
+}
+
+func TestReadPullStreamError(t *testing.T) {
+	stream := `{"status":"Pulling from library/nope"}
+{"errorDetail":{"message":"manifest unknown"},"error":"manifest unknown"}`
+
+	err := readPullStream(strings.NewReader(stream), "nope:latest", PullProgressInterval, nil)
+	if err == nil || !strings.Contains(err.Error(), "manifest unknown") {
+		t.Fatalf("err = %v, want manifest unknown", err)
+	}
+}
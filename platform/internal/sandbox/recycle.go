@@ -0,0 +1,57 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// recycleDirs 回收时除工作区外需要清空的目录：临时文件和 Platform 写入的服务端点等文件
+var recycleDirs = []string{"/tmp", "/app/.platform"}
+
+// wipeCommand 杀掉 PID 1 以外的所有进程（agent server、后台任务以及它们继承的会话环境变量），
+// 再清空 dirs 下的内容。第一个目录（工作区）清理后必须为空，否则返回非零退出码
+func wipeCommand(dirs []string) []string {
+	script := `kill -KILL -1 2>/dev/null; sleep 0.2
+for d in "$@"; do [ -d "$d" ] && find "$d" -mindepth 1 -delete 2>/dev/null; done
+[ -z "$(ls -A "$1" 2>/dev/null)" ] || { echo "workspace $1 is not empty after wipe" >&2; exit 1; }`
+	return append([]string{"/bin/sh", "-c", script, "sh"}, dirs...)
+}
+
+// Wipe 清除上一个会话留在容器内的状态，使容器可以回到预热池：
+// 结束所有会话进程，清空工作区、/tmp 和 /app/.platform。根文件系统的其他修改不会还原，
+// 需要严格隔离时应同时启用只读根文件系统
+func (c *Container) Wipe(ctx context.Context) error {
+	dirs := append([]string{c.MountPath}, recycleDirs...)
+	created, err := c.client.ContainerExecCreate(ctx, c.ID, container.ExecOptions{
+		Cmd:          wipeCommand(dirs),
+		User:         "0",
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return Wrap(ErrExecFailed, fmt.Errorf("failed to create wipe exec: %w", err))
+	}
+	attach, err := c.client.ContainerExecAttach(ctx, created.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return Wrap(ErrExecFailed, fmt.Errorf("failed to attach to wipe exec: %w", err))
+	}
+	defer attach.Close()
+
+	var stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&bytes.Buffer{}, &stderr, attach.Reader); err != nil {
+		return Wrap(ErrExecFailed, fmt.Errorf("failed to read wipe output: %w", err))
+	}
+	inspect, err := c.client.ContainerExecInspect(ctx, created.ID)
+	if err != nil {
+		return Wrap(ErrExecFailed, fmt.Errorf("failed to inspect wipe exec: %w", err))
+	}
+	if inspect.ExitCode != 0 {
+		return fmt.Errorf("%w: wipe exited with %d: %s", ErrExecFailed, inspect.ExitCode, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
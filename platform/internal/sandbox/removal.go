@@ -0,0 +1,66 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// RemovalPolicy 平台停止和删除沙箱容器的方式
+type RemovalPolicy struct {
+	// StopTimeout 停止容器时发送 SIGTERM 后等待退出的时间，超时后 SIGKILL
+	StopTimeout time.Duration
+	// Force 删除仍在运行的容器时直接强制删除；为 false 时先按 StopTimeout 停止再删除
+	Force bool
+	// KeepFailed 启动失败的容器停止后保留而不删除，供排查 agent 启动失败的原因，需要手动清理
+	KeepFailed bool
+}
+
+// DefaultRemovalPolicy 未调用 SetRemovalPolicy 时的策略
+var DefaultRemovalPolicy = RemovalPolicy{StopTimeout: 10 * time.Second, Force: true}
+
+var removalPolicy atomic.Pointer[RemovalPolicy]
+
+// SetRemovalPolicy 设置所有沙箱容器的停止与删除策略，应在启动时调用
+func SetRemovalPolicy(p RemovalPolicy) {
+	removalPolicy.Store(&p)
+}
+
+// CurrentRemovalPolicy 返回当前的停止与删除策略
+func CurrentRemovalPolicy() RemovalPolicy {
+	if p := removalPolicy.Load(); p != nil {
+		return *p
+	}
+	return DefaultRemovalPolicy
+}
+
+// StopTimeoutSeconds 以秒为单位的 StopTimeout，供 Container.Stop 和 Docker StopOptions 使用
+func (p RemovalPolicy) StopTimeoutSeconds() int {
+	return int(p.StopTimeout / time.Second)
+}
+
+// RemoveContainer 按策略停止并删除容器，容器不存在时返回 ErrContainerNotFound
+func RemoveContainer(ctx context.Context, cli *client.Client, id string) error {
+	p := CurrentRemovalPolicy()
+	if !p.Force {
+		timeout := p.StopTimeoutSeconds()
+		if err := cli.ContainerStop(ctx, id, container.StopOptions{Timeout: &timeout}); err != nil {
+			if errdefs.IsNotFound(err) {
+				return ErrContainerNotFound
+			}
+			return fmt.Errorf("failed to stop container: %w", err)
+		}
+	}
+	if err := cli.ContainerRemove(ctx, id, container.RemoveOptions{Force: p.Force}); err != nil {
+		if errdefs.IsNotFound(err) {
+			return ErrContainerNotFound
+		}
+		return fmt.Errorf("failed to remove container: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package sandbox
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRemovalPolicy(t *testing.T) {
+	t.Cleanup(func() { SetRemovalPolicy(DefaultRemovalPolicy) })
+
+	if got := CurrentRemovalPolicy(); got != DefaultRemovalPolicy {
+		t.Fatalf("default policy = %+v", got)
+	}
+	SetRemovalPolicy(RemovalPolicy{StopTimeout: 90 * time.Second, KeepFailed: true})
+	got := CurrentRemovalPolicy()
+	if got.Force || !got.KeepFailed || got.StopTimeoutSeconds() != 90 {
+		t.Fatalf("policy after SetRemovalPolicy = %+v", got)
+	}
+}
@@ -0,0 +1,46 @@
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSecurityOpts(t *testing.T) {
+	profileFile := filepath.Join(t.TempDir(), "seccomp.json")
+	if err := os.WriteFile(profileFile, []byte("{\n  \"defaultAction\": \"SCMP_ACT_ALLOW\"\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		profile SecurityProfile
+		want    []string
+	}{
+		{"zero value", SecurityProfile{}, nil},
+		{"default", DefaultSecurityProfile(), []string{"no-new-privileges:true"}},
+		{"unconfined", SecurityProfile{Seccomp: SeccompUnconfined}, []string{"seccomp=unconfined"}},
+		{"inline json", SecurityProfile{Seccomp: `{ "defaultAction": "SCMP_ACT_ERRNO" }`}, []string{`seccomp={"defaultAction":"SCMP_ACT_ERRNO"}`}},
+		{"file", SecurityProfile{Seccomp: profileFile, NoNewPrivileges: true}, []string{"no-new-privileges:true", `seccomp={"defaultAction":"SCMP_ACT_ALLOW"}`}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.profile.securityOpts()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("securityOpts() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSecurityOptsInvalidProfile(t *testing.T) {
+	for _, spec := range []string{"{not json", filepath.Join(t.TempDir(), "missing.json")} {
+		if _, err := (SecurityProfile{Seccomp: spec}).securityOpts(); err == nil {
+			t.Errorf("securityOpts(%q) expected error", spec)
+		}
+	}
+}
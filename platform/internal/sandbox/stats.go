@@ -0,0 +1,110 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// ResourceStats 容器某一时刻的资源使用情况
+type ResourceStats struct {
+	Timestamp     time.Time `json:"timestamp"`
+	CPUPercent    float64   `json:"cpu_percent"` // 相对单核，多核满载可超过 100
+	MemoryUsage   uint64    `json:"memory_usage_bytes"`
+	MemoryLimit   uint64    `json:"memory_limit_bytes"`
+	MemoryPercent float64   `json:"memory_percent"`
+	NetworkRx     uint64    `json:"network_rx_bytes"`
+	NetworkTx     uint64    `json:"network_tx_bytes"`
+	BlockRead     uint64    `json:"block_read_bytes"`
+	BlockWrite    uint64    `json:"block_write_bytes"`
+	PIDs          uint64    `json:"pids"`
+}
+
+// Stats 订阅 Docker 的实时统计流（约每秒一次）。
+// ctx 取消或容器停止时通道关闭。
+func (c *Container) Stats(ctx context.Context) (<-chan ResourceStats, error) {
+	resp, err := c.client.ContainerStats(ctx, c.ID, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container stats: %w", err)
+	}
+
+	ch := make(chan ResourceStats)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var raw container.StatsResponse
+			if err := dec.Decode(&raw); err != nil {
+				if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+					c.logger.Warn("Container stats stream ended", "error", err)
+				}
+				return
+			}
+
+			select {
+			case ch <- newResourceStats(&raw):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// newResourceStats 按 docker stats 的口径换算：CPU 取两次采样的差值，
+// 内存扣除可回收的 page cache
+func newResourceStats(s *container.StatsResponse) ResourceStats {
+	stats := ResourceStats{
+		Timestamp:   s.Read,
+		MemoryLimit: s.MemoryStats.Limit,
+		PIDs:        s.PidsStats.Current,
+	}
+
+	cpuDelta := float64(s.CPUStats.CPUUsage.TotalUsage) - float64(s.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(s.CPUStats.SystemUsage) - float64(s.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(s.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(s.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		stats.CPUPercent = cpuDelta / systemDelta * onlineCPUs * 100
+	}
+
+	stats.MemoryUsage = s.MemoryStats.Usage
+	// cgroup v1 为 total_inactive_file，v2 为 inactive_file
+	cache := s.MemoryStats.Stats["total_inactive_file"]
+	if v, ok := s.MemoryStats.Stats["inactive_file"]; ok && cache == 0 {
+		cache = v
+	}
+	if cache < stats.MemoryUsage {
+		stats.MemoryUsage -= cache
+	}
+	if stats.MemoryLimit > 0 {
+		stats.MemoryPercent = float64(stats.MemoryUsage) / float64(stats.MemoryLimit) * 100
+	}
+
+	for _, n := range s.Networks {
+		stats.NetworkRx += n.RxBytes
+		stats.NetworkTx += n.TxBytes
+	}
+
+	for _, entry := range s.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			stats.BlockRead += entry.Value
+		case "write":
+			stats.BlockWrite += entry.Value
+		}
+	}
+
+	return stats
+}
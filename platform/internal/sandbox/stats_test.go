@@ -0,0 +1,57 @@
+package sandbox
+
+import (
+	"math"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestNewResourceStats(t *testing.T) {
+	raw := &container.StatsResponse{
+		CPUStats: container.CPUStats{
+			CPUUsage:    container.CPUUsage{TotalUsage: 300},
+			SystemUsage: 2000,
+			OnlineCPUs:  2,
+		},
+		PreCPUStats: container.CPUStats{
+			CPUUsage:    container.CPUUsage{TotalUsage: 100},
+			SystemUsage: 1000,
+		},
+		MemoryStats: container.MemoryStats{
+			Usage: 600,
+			Limit: 1000,
+			Stats: map[string]uint64{"inactive_file": 100},
+		},
+		Networks: map[string]container.NetworkStats{
+			"eth0": {RxBytes: 10, TxBytes: 20},
+			"eth1": {RxBytes: 1, TxBytes: 2},
+		},
+		BlkioStats: container.BlkioStats{
+			IoServiceBytesRecursive: []container.BlkioStatEntry{
+				{Op: "Read", Value: 5},
+				{Op: "Write", Value: 7},
+				{Op: "read", Value: 1},
+			},
+		},
+		PidsStats: container.PidsStats{Current: 3},
+	}
+
+	stats := newResourceStats(raw)
+
+	if math.Abs(stats.CPUPercent-40) > 1e-9 {
+		t.Errorf("Expected CPU 40%%, got %v", stats.CPUPercent)
+	}
+	if stats.MemoryUsage != 500 || math.Abs(stats.MemoryPercent-50) > 1e-9 {
+		t.Errorf("Expected memory 500 (50%%), got %d (%v%%)", stats.MemoryUsage, stats.MemoryPercent)
+	}
+	if stats.NetworkRx != 11 || stats.NetworkTx != 22 {
+		t.Errorf("Unexpected network totals: rx=%d tx=%d", stats.NetworkRx, stats.NetworkTx)
+	}
+	if stats.BlockRead != 6 || stats.BlockWrite != 7 {
+		t.Errorf("Unexpected block IO: read=%d write=%d", stats.BlockRead, stats.BlockWrite)
+	}
+	if stats.PIDs != 3 {
+		t.Errorf("Expected 3 pids, got %d", stats.PIDs)
+	}
+}
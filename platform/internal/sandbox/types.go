@@ -10,12 +10,45 @@ type ContainerConfig struct {
 	ProjectID       string
 	SessionID       string
 	Image           string
-	Cmd             []string // 要在容器中运行的命令
+	Cmd             []string // 要在容器中运行的命令，为空时使用镜像的 CMD
+	Entrypoint      []string // 覆盖镜像的 ENTRYPOINT，为空时使用镜像的 ENTRYPOINT
 	EnvVars         []string
 	MemoryLimit     int64   // 内存限制（字节）
 	CPULimit        float64 // CPU 核心数（如 0.5, 1, 2）
 	NetworkName     string
 	LogDir          string // 宿主机日志存储路径
+	// Tenant / Strategy / PoolProfile 只用于容器标签，见 Labels
+	Tenant   string
+	Strategy string
+	// PoolProfile 预热容器所属的预热池，默认池为空
+	PoolProfile string
+	// Runtime OCI 运行时名称（如 gVisor 的 runsc），为空时使用 daemon 默认运行时
+	Runtime string
+	// Mounts 工作区之外的额外挂载，见 ValidateMounts
+	Mounts []MountSpec
+	// WorkspaceVolume 非匿名卷容器的工作区改为挂载共享卷中的项目子目录（如 NFS），nil 时绑定挂载 HostPath
+	WorkspaceVolume *WorkspaceVolume
+	// CgroupParent 容器所在的 cgroup parent（含出网代理 sidecar），总资源上限见 EnsureCgroupParent
+	CgroupParent string
+	// DiskLimit 工作区磁盘上限（字节），0 表示不限制。匿名卷容器通过 tmpfs 卷的 size 强制，
+	// 绑定挂载的工作区由 diskusage.QuotaWatcher 定期检查
+	DiskLimit int64
+	// NetworkPolicy 网络隔离策略，受限模式下沙箱只接入 session 专属的 internal 网络
+	NetworkPolicy NetworkPolicy
+	// EgressProxyImage 出网代理 sidecar 的镜像（需包含 platform-server），internal-only / allowlist 模式必填
+	EgressProxyImage string
+	// Security seccomp 与 capability 配置，零值使用 Docker 默认行为，见 DefaultSecurityProfile
+	Security SecurityProfile
+	// ReadOnlyRootFS 根文件系统只读，/tmp、/run 挂载 tmpfs，工作区和 ReadOnlyVolumePaths 保持可写
+	ReadOnlyRootFS bool
+	// GPUCount 分配的 GPU 数量，-1 表示全部；GPUDeviceIDs 指定具体设备（与 GPUCount 二选一）。
+	// 通过 Docker device request 交给 nvidia 运行时，宿主机需安装 NVIDIA Container Toolkit
+	GPUCount     int
+	GPUDeviceIDs []string
+	// CheckpointDir Checkpoint / Restore 使用的检查点目录，为空时使用 Docker 默认目录
+	CheckpointDir string
+	// OnPullProgress Start 拉取缺失镜像时的进度回调，可为 nil
+	OnPullProgress func(PullProgress)
 }
 
 type FileInfo struct {
@@ -23,6 +56,10 @@ type FileInfo struct {
 	Size    int64     `json:"size"`
 	IsDir   bool      `json:"is_dir"`
 	ModTime time.Time `json:"mod_time"`
+	// Mode 类型和权限，格式同 ls，如 drwxr-xr-x、Lrwxrwxrwx
+	Mode string `json:"mode,omitempty"`
+	// Link 符号链接的目标
+	Link string `json:"link,omitempty"`
 }
 
 type ExecResult struct {
@@ -30,6 +67,18 @@ type ExecResult struct {
 	Stdout   string
 	Stderr   string
 	Duration time.Duration
+	// TimedOut 命令超过 ExecOptions.Timeout 被强制终止
+	TimedOut bool
+	// Truncated 输出超过 ExecOptions.MaxOutputBytes，超出部分已丢弃
+	Truncated bool
+}
+
+// ExecOptions Exec 的执行限制，零值表示不限制
+type ExecOptions struct {
+	// Timeout 超时后杀掉命令进程（及其子进程），容器保持运行
+	Timeout time.Duration
+	// MaxOutputBytes stdout 和 stderr 各自保留的最大字节数
+	MaxOutputBytes int
 }
 
 type LogResult struct {
@@ -44,8 +93,25 @@ type ExecLogEntry struct {
 	Output     string    `json:"output"`
 	ExitCode   int       `json:"exit_code"`
 	DurationMs int64     `json:"duration_ms"`
+	RequestID  string    `json:"request_id,omitempty"` // 触发该命令的请求 ID
 }
 
+// KeepAliveCmd 不运行任何服务、只保持容器存活的命令，预热容器默认使用
+var KeepAliveCmd = []string{"tail", "-f", "/dev/null"}
+
+// ReadOnlyTmpfs 只读根文件系统模式下挂载的 tmpfs
+var ReadOnlyTmpfs = map[string]string{
+	"/tmp": "rw,nosuid,nodev,size=256m",
+	"/run": "rw,nosuid,nodev,size=16m",
+}
+
+// ReadOnlyVolumePaths 只读根文件系统模式下使用匿名卷保持可写的目录。
+// Platform 通过 CopyToContainer 写入这些目录（如服务端点文件），tmpfs 会遮挡写入的内容，只能用卷
+var ReadOnlyVolumePaths = []string{"/app/.platform"}
+
+// DefaultLogDir 未配置 LogDir 时 exec 日志的存放目录（相对于进程工作目录）
+const DefaultLogDir = ".dockerlogs"
+
 func ContainerName(sessionID string) string {
 	return "agent-" + sessionID
 }
@@ -0,0 +1,193 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+)
+
+// VolumeType 网络 / 外部存储的类型，均通过 Docker local 卷驱动的 mount 选项实现
+type VolumeType string
+
+const (
+	// VolumeNFS NFS 导出目录，Server 为服务器地址，Path 为导出路径
+	VolumeNFS VolumeType = "nfs"
+	// VolumeCIFS SMB / CIFS 共享，Server 为服务器地址，Path 为共享名（可含子目录）
+	VolumeCIFS VolumeType = "cifs"
+	// VolumeLocal 本机块设备或其他文件系统，Path 为设备，FSType 为文件系统类型
+	VolumeLocal VolumeType = "local"
+)
+
+// VolumeDriver 卷的驱动配置。同名卷不存在时 Docker 按此配置创建，已存在时沿用原有配置
+type VolumeDriver struct {
+	Type   VolumeType `json:"type"`
+	Server string     `json:"server,omitempty"`
+	Path   string     `json:"path"`
+	// FSType VolumeLocal 的文件系统类型，如 ext4、xfs
+	FSType string `json:"fs_type,omitempty"`
+	// Options 追加到 mount 的选项，如 nfsvers=4,soft 或 vers=3.0
+	Options string `json:"options,omitempty"`
+	// Username / Password CIFS 认证
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// Validate 检查驱动配置，错误信息包含 invalid
+func (v *VolumeDriver) Validate() error {
+	switch v.Type {
+	case VolumeNFS, VolumeCIFS:
+		if v.Server == "" || v.Path == "" {
+			return fmt.Errorf("invalid volume driver: %s requires server and path", v.Type)
+		}
+	case VolumeLocal:
+		if v.Path == "" || v.FSType == "" {
+			return fmt.Errorf("invalid volume driver: local requires path (device) and fs_type")
+		}
+	default:
+		return fmt.Errorf("invalid volume driver type %q (expected nfs, cifs or local)", v.Type)
+	}
+	// 选项以逗号拼接，不允许借此注入额外的 mount 选项
+	for _, s := range []string{v.Server, v.Username, v.Password} {
+		if strings.ContainsAny(s, ",") {
+			return fmt.Errorf("invalid volume driver: server and credentials must not contain commas")
+		}
+	}
+	// bind 选项会把 Path 当作宿主机目录直接绑定进容器，绕过挂载目录白名单
+	for _, opt := range strings.Split(v.Options, ",") {
+		switch strings.TrimSpace(opt) {
+		case "bind", "rbind":
+			return fmt.Errorf("invalid volume driver: option %q is not allowed", strings.TrimSpace(opt))
+		}
+	}
+	return nil
+}
+
+// driverConfig 转换为 local 卷驱动的选项
+func (v *VolumeDriver) driverConfig() *mount.Driver {
+	var device, fsType string
+	var opts []string
+	switch v.Type {
+	case VolumeNFS:
+		fsType = "nfs"
+		device = ":" + path.Clean("/"+v.Path)
+		opts = append(opts, "addr="+v.Server)
+	case VolumeCIFS:
+		fsType = "cifs"
+		device = "//" + v.Server + "/" + strings.TrimPrefix(v.Path, "/")
+		opts = append(opts, "addr="+v.Server)
+		if v.Username != "" {
+			opts = append(opts, "username="+v.Username, "password="+v.Password)
+		}
+	case VolumeLocal:
+		fsType = v.FSType
+		device = v.Path
+	}
+	if v.Options != "" {
+		opts = append(opts, v.Options)
+	}
+
+	options := map[string]string{"type": fsType, "device": device}
+	if len(opts) > 0 {
+		options["o"] = strings.Join(opts, ",")
+	}
+	return &mount.Driver{Name: "local", Options: options}
+}
+
+// WorkspaceVolume 冷容器工作区所在的共享卷：所有项目共用名为 Name 的卷，
+// 每个项目挂载其中以项目 ID 命名的子目录，多台主机通过同一网络存储访问相同的工作区
+type WorkspaceVolume struct {
+	Name   string
+	Driver VolumeDriver
+}
+
+// workspaceMount 项目工作区的挂载，需要先调用 ensureSubpath 创建子目录
+func (w *WorkspaceVolume) workspaceMount(projectID, target string) mount.Mount {
+	return mount.Mount{
+		Type:   mount.TypeVolume,
+		Source: w.Name,
+		Target: target,
+		VolumeOptions: &mount.VolumeOptions{
+			NoCopy:       true,
+			Subpath:      projectID,
+			DriverConfig: w.Driver.driverConfig(),
+		},
+	}
+}
+
+// ensureSubpath 用一次性容器挂载共享卷并创建项目子目录（Docker 要求 Subpath 已存在）。
+// 这同时是启动前的挂载检查：存储不可达或认证失败时在这里返回错误，而不是创建出半可用的沙箱
+func (c *Container) ensureSubpath(ctx context.Context, w *WorkspaceVolume, subpath string) error {
+	const root = "/volume"
+	resp, err := c.client.ContainerCreate(ctx, &container.Config{
+		Image:      c.Config.Image,
+		Entrypoint: []string{"mkdir"},
+		Cmd:        []string{"-p", path.Join(root, subpath)},
+		User:       "0",
+		Labels:     map[string]string{LabelManagedBy: ManagedByValue},
+	}, &container.HostConfig{
+		NetworkMode: "none",
+		Mounts: []mount.Mount{{
+			Type:          mount.TypeVolume,
+			Source:        w.Name,
+			Target:        root,
+			VolumeOptions: &mount.VolumeOptions{NoCopy: true, DriverConfig: w.Driver.driverConfig()},
+		}},
+	}, nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("workspace volume %s unavailable: %w", w.Name, err)
+	}
+	defer c.client.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
+
+	if err := c.client.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return fmt.Errorf("workspace volume %s failed to mount: %w", w.Name, err)
+	}
+	statusCh, errCh := c.client.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("workspace volume %s: %w", w.Name, err)
+	case status := <-statusCh:
+		if status.StatusCode != 0 {
+			return fmt.Errorf("workspace volume %s: failed to create project directory (exit code %d)", w.Name, status.StatusCode)
+		}
+	}
+	return nil
+}
+
+// checkMounts 启动后确认工作区和使用卷驱动的额外挂载在容器内可访问，可写挂载还需可写
+func (c *Container) checkMounts(ctx context.Context) error {
+	type target struct {
+		path     string
+		readOnly bool
+	}
+	var targets []target
+	if c.Config.WorkspaceVolume != nil && !c.Config.UseAnonymousVol {
+		targets = append(targets, target{path: c.MountPath})
+	}
+	for _, m := range c.Config.Mounts {
+		if m.Driver != nil {
+			targets = append(targets, target{path: m.ContainerPath, readOnly: m.ReadOnly})
+		}
+	}
+
+	for _, t := range targets {
+		script := `test -d "$1" && ls "$1" > /dev/null`
+		if !t.readOnly {
+			script = `test -d "$1" && test -w "$1"`
+		}
+		result, err := c.Exec(ctx, []string{"sh", "-c", script, "sh", t.path}, nil, "/")
+		if err != nil {
+			return fmt.Errorf("mount health check for %s failed: %w", t.path, err)
+		}
+		if result.ExitCode != 0 {
+			if t.readOnly {
+				return fmt.Errorf("mount health check for %s failed: not accessible", t.path)
+			}
+			return fmt.Errorf("mount health check for %s failed: not accessible or not writable", t.path)
+		}
+	}
+	return nil
+}
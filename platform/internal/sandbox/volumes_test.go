@@ -0,0 +1,69 @@
+package sandbox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVolumeDriverConfig(t *testing.T) {
+	cases := []struct {
+		name   string
+		driver VolumeDriver
+		want   map[string]string
+	}{
+		{
+			name:   "nfs",
+			driver: VolumeDriver{Type: VolumeNFS, Server: "10.0.0.5", Path: "exports/ws", Options: "nfsvers=4,soft"},
+			want:   map[string]string{"type": "nfs", "device": ":/exports/ws", "o": "addr=10.0.0.5,nfsvers=4,soft"},
+		},
+		{
+			name:   "cifs",
+			driver: VolumeDriver{Type: VolumeCIFS, Server: "files.local", Path: "/share/data", Username: "svc", Password: "pw"},
+			want:   map[string]string{"type": "cifs", "device": "//files.local/share/data", "o": "addr=files.local,username=svc,password=pw"},
+		},
+		{
+			name:   "local device",
+			driver: VolumeDriver{Type: VolumeLocal, Path: "/dev/sdb1", FSType: "xfs"},
+			want:   map[string]string{"type": "xfs", "device": "/dev/sdb1"},
+		},
+	}
+	for _, tc := range cases {
+		if err := tc.driver.Validate(); err != nil {
+			t.Fatalf("%s: Validate: %v", tc.name, err)
+		}
+		cfg := tc.driver.driverConfig()
+		if cfg.Name != "local" || len(cfg.Options) != len(tc.want) {
+			t.Fatalf("%s: config = %+v", tc.name, cfg)
+		}
+		for k, v := range tc.want {
+			if cfg.Options[k] != v {
+				t.Errorf("%s: option %s = %q, want %q", tc.name, k, cfg.Options[k], v)
+			}
+		}
+	}
+}
+
+func TestVolumeDriverValidate(t *testing.T) {
+	bad := map[string]VolumeDriver{
+		"unknown type":     {Type: "ceph", Server: "x", Path: "/x"},
+		"nfs no server":    {Type: VolumeNFS, Path: "/x"},
+		"local no fs type": {Type: VolumeLocal, Path: "/dev/sdb1"},
+		"option injection": {Type: VolumeCIFS, Server: "x", Path: "s", Username: "u,uid=0"},
+		"bind option":      {Type: VolumeLocal, Path: "/", FSType: "none", Options: "bind"},
+		"rbind option":     {Type: VolumeNFS, Server: "x", Path: "/x", Options: "soft, rbind"},
+	}
+	for name, d := range bad {
+		if err := d.Validate(); err == nil || !strings.Contains(err.Error(), "invalid") {
+			t.Errorf("%s: err = %v, want invalid", name, err)
+		}
+	}
+
+	// 卷驱动只能用于 volume 挂载
+	nfs := &VolumeDriver{Type: VolumeNFS, Server: "10.0.0.5", Path: "/data"}
+	if err := (MountSpec{HostPath: "datasets", ContainerPath: "/data", Type: MountVolume, Driver: nfs}).Validate(); err != nil {
+		t.Fatalf("volume with driver: %v", err)
+	}
+	if err := (MountSpec{HostPath: "/srv/data", ContainerPath: "/data", Driver: nfs}).Validate(); err == nil {
+		t.Fatal("bind mount with driver should be invalid")
+	}
+}
@@ -0,0 +1,216 @@
+package sandbox
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// FileOp 工作区文件变更类型
+type FileOp string
+
+const (
+	FileCreated  FileOp = "create"
+	FileModified FileOp = "modify"
+	FileDeleted  FileOp = "delete"
+)
+
+// FileEvent 文件变更事件，Path 为相对被监听目录的路径
+type FileEvent struct {
+	Op    FileOp    `json:"op"`
+	Path  string    `json:"path"`
+	IsDir bool      `json:"is_dir"`
+	Time  time.Time `json:"time"`
+}
+
+// WatchHostDir 使用 fsnotify 递归监听宿主机目录，ctx 结束时关闭返回的通道。
+// 新建的子目录自动加入监听，其中已有的条目补发 create 事件
+func WatchHostDir(ctx context.Context, root string) (<-chan FileEvent, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Add(root); err != nil {
+		w.Close()
+		return nil, err
+	}
+
+	dirs := map[string]bool{root: true}
+	// addTree 监听 dir 下的所有子目录并返回其中的条目
+	addTree := func(dir string) []string {
+		var found []string
+		_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || p == dir {
+				return nil
+			}
+			found = append(found, p)
+			if d.IsDir() {
+				if err := w.Add(p); err != nil {
+					slog.Warn("Failed to watch directory", "path", p, "error", err)
+					return fs.SkipDir
+				}
+				dirs[p] = true
+			}
+			return nil
+		})
+		return found
+	}
+	addTree(root)
+
+	ch := make(chan FileEvent, 64)
+	go func() {
+		defer close(ch)
+		defer w.Close()
+
+		emit := func(op FileOp, name string, isDir bool) bool {
+			rel, err := filepath.Rel(root, name)
+			if err != nil || rel == "." {
+				return true
+			}
+			select {
+			case ch <- FileEvent{Op: op, Path: filepath.ToSlash(rel), IsDir: isDir, Time: time.Now()}:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				switch {
+				case ev.Op&fsnotify.Create != 0:
+					info, err := os.Lstat(ev.Name)
+					if err != nil {
+						continue
+					}
+					if !emit(FileCreated, ev.Name, info.IsDir()) {
+						return
+					}
+					if info.IsDir() {
+						if err := w.Add(ev.Name); err == nil {
+							dirs[ev.Name] = true
+						}
+						// 监听建立前目录中可能已经写入了文件
+						for _, p := range addTree(ev.Name) {
+							info, err := os.Lstat(p)
+							if err == nil && !emit(FileCreated, p, info.IsDir()) {
+								return
+							}
+						}
+					}
+				case ev.Op&fsnotify.Write != 0:
+					if !emit(FileModified, ev.Name, false) {
+						return
+					}
+				case ev.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+					// 重命名的新名称以 Create 事件报告
+					isDir := dirs[ev.Name]
+					delete(dirs, ev.Name)
+					if !emit(FileDeleted, ev.Name, isDir) {
+						return
+					}
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				slog.Warn("File watcher error", "root", root, "error", err)
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// maxPollFailures 轮询连续失败的次数上限，超过后认为容器已不可用并结束监听
+const maxPollFailures = 3
+
+// PollFiles 以 interval 周期调用 list 获取文件列表并与上一次结果比较，用于无法在宿主机上监听的匿名卷。
+// 首次列出失败时直接返回错误
+func PollFiles(ctx context.Context, interval time.Duration, list func(context.Context) ([]FileInfo, error)) (<-chan FileEvent, error) {
+	prev, err := list(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan FileEvent, 64)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		failures := 0
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			cur, err := list(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				if failures++; failures >= maxPollFailures {
+					slog.Warn("File polling stopped", "error", err)
+					return
+				}
+				continue
+			}
+			failures = 0
+
+			for _, ev := range DiffFileLists(prev, cur, time.Now()) {
+				select {
+				case ch <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+			prev = cur
+		}
+	}()
+	return ch, nil
+}
+
+// DiffFileLists 比较两次文件列表，按 cur 的顺序报告新增和修改，随后报告删除。
+// 目录只报告新增和删除，其修改时间随子项变化，不单独报告
+func DiffFileLists(prev, cur []FileInfo, now time.Time) []FileEvent {
+	old := make(map[string]FileInfo, len(prev))
+	for _, f := range prev {
+		old[f.Path] = f
+	}
+
+	var events []FileEvent
+	for _, f := range cur {
+		o, ok := old[f.Path]
+		delete(old, f.Path)
+		switch {
+		case !ok:
+			events = append(events, FileEvent{Op: FileCreated, Path: f.Path, IsDir: f.IsDir, Time: now})
+		case o.IsDir != f.IsDir:
+			// 类型改变视为删除后重建
+			events = append(events,
+				FileEvent{Op: FileDeleted, Path: f.Path, IsDir: o.IsDir, Time: now},
+				FileEvent{Op: FileCreated, Path: f.Path, IsDir: f.IsDir, Time: now})
+		case !f.IsDir && (o.Size != f.Size || !o.ModTime.Equal(f.ModTime) || o.Mode != f.Mode):
+			events = append(events, FileEvent{Op: FileModified, Path: f.Path, Time: now})
+		}
+	}
+	for _, f := range prev {
+		if _, ok := old[f.Path]; ok {
+			events = append(events, FileEvent{Op: FileDeleted, Path: f.Path, IsDir: f.IsDir, Time: now})
+		}
+	}
+	return events
+}
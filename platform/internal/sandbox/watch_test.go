@@ -0,0 +1,89 @@
+package sandbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiffFileLists(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	prev := []FileInfo{
+		{Path: "src", IsDir: true, ModTime: t0},
+		{Path: "src/a.go", Size: 10, ModTime: t0},
+		{Path: "old.txt", Size: 1, ModTime: t0},
+		{Path: "same.txt", Size: 1, ModTime: t0},
+	}
+	cur := []FileInfo{
+		{Path: "src", IsDir: true, ModTime: t0.Add(time.Second)},
+		{Path: "src/a.go", Size: 12, ModTime: t0.Add(time.Second)},
+		{Path: "src/b.go", Size: 3, ModTime: t0},
+		{Path: "same.txt", Size: 1, ModTime: t0},
+	}
+
+	got := DiffFileLists(prev, cur, t0)
+	want := []FileEvent{
+		{Op: FileModified, Path: "src/a.go", Time: t0},
+		{Op: FileCreated, Path: "src/b.go", Time: t0},
+		{Op: FileDeleted, Path: "old.txt", Time: t0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("events = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("event %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWatchHostDir(t *testing.T) {
+	root := t.TempDir()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ch, err := WatchHostDir(ctx, root)
+	if err != nil {
+		t.Skipf("fsnotify unavailable: %v", err)
+	}
+
+	next := func() FileEvent {
+		t.Helper()
+		select {
+		case ev := <-ch:
+			return ev
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for event")
+			return FileEvent{}
+		}
+	}
+
+	if err := os.Mkdir(filepath.Join(root, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if ev := next(); ev.Op != FileCreated || ev.Path != "src" || !ev.IsDir {
+		t.Fatalf("mkdir event = %+v", ev)
+	}
+
+	file := filepath.Join(root, "src", "main.go")
+	if err := os.WriteFile(file, []byte("package main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ev := next(); ev.Op != FileCreated || ev.Path != "src/main.go" {
+		t.Fatalf("create event = %+v", ev)
+	}
+
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	// 子目录监听建立前后的写入可能缺少 modify 事件或重复报告 create
+	ev := next()
+	for ev.Op != FileDeleted {
+		ev = next()
+	}
+	if ev.Path != "src/main.go" {
+		t.Fatalf("delete event = %+v", ev)
+	}
+}
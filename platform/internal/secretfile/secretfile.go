@@ -0,0 +1,187 @@
+// Package secretfile 识别工作区中由平台生成的 .env 等凭据文件，并负责它们在宿主机一侧的处理：
+// 同步回宿主机和打包归档时默认排除；显式要求同步时按租户加密后落盘，替换下来的明文安全删除
+package secretfile
+
+import (
+	"archive/tar"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// DefaultPatterns 默认视为凭据的文件名（按文件名匹配，支持 path.Match 通配符）
+var DefaultPatterns = []string{".env", ".env.*", ".netrc", ".git-credentials"}
+
+// SealedSuffix 加密后写到宿主机的凭据文件的后缀
+const SealedSuffix = ".enc"
+
+// Matcher 按文件名匹配凭据文件，nil 使用 DefaultPatterns
+type Matcher struct {
+	patterns []string
+}
+
+func NewMatcher(patterns []string) (*Matcher, error) {
+	if len(patterns) == 0 {
+		patterns = DefaultPatterns
+	}
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid secret file pattern %q: %w", p, err)
+		}
+	}
+	return &Matcher{patterns: patterns}, nil
+}
+
+// Match 判断 name（工作区相对路径或 tar 条目名）是否为凭据文件
+func (m *Matcher) Match(name string) bool {
+	patterns := DefaultPatterns
+	if m != nil {
+		patterns = m.patterns
+	}
+	base := path.Base(strings.TrimSuffix(name, "/"))
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterTar 返回去掉凭据文件条目的 tar 流，关闭返回值时同时关闭 r
+func FilterTar(r io.ReadCloser, m *Matcher) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		defer r.Close()
+		pw.CloseWithError(copyTar(tar.NewWriter(pw), tar.NewReader(r), m))
+	}()
+	return pr
+}
+
+func copyTar(tw *tar.Writer, tr *tar.Reader, m *Matcher) error {
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return tw.Close()
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeDir && m.Match(header.Name) {
+			continue
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// Sealer 以 AES-GCM 加密凭据文件，每个租户使用由主密钥派生的独立密钥
+type Sealer struct {
+	key []byte
+}
+
+// NewSealer key 为 base64 编码的 32 字节主密钥
+func NewSealer(key string) (*Sealer, error) {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret encryption key: %w", err)
+	}
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("invalid secret encryption key: got %d bytes, want 32", len(raw))
+	}
+	return &Sealer{key: raw}, nil
+}
+
+func (s *Sealer) aead(tenant string) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte("secretfile:" + tenant))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seal 加密 plaintext，输出为 nonce 加密文
+func (s *Sealer) Seal(tenant string, plaintext []byte) ([]byte, error) {
+	aead, err := s.aead(tenant)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, []byte(tenant)), nil
+}
+
+// Open 解密 Seal 的输出，租户不匹配或内容被篡改时返回错误
+func (s *Sealer) Open(tenant string, sealed []byte) ([]byte, error) {
+	aead, err := s.aead(tenant)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("sealed secret is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(tenant))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return plaintext, nil
+}
+
+// SecureDelete 用零覆盖文件内容并落盘后删除，文件不存在时返回 nil。
+// 写时复制或日志型文件系统上不能保证旧数据块被覆盖，只是尽力而为
+func SecureDelete(name string) error {
+	return secureDelete(name, os.OpenFile, os.Remove)
+}
+
+// SecureDeleteIn 同 SecureDelete，name 相对 root 解析，不会跟随符号链接到 root 之外
+func SecureDeleteIn(root *os.Root, name string) error {
+	return secureDelete(name, root.OpenFile, root.Remove)
+}
+
+func secureDelete(name string, open func(string, int, os.FileMode) (*os.File, error), remove func(string) error) error {
+	f, err := open(name, os.O_WRONLY, 0)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err == nil && info.Mode().IsRegular() {
+		_, err = io.CopyN(f, zeroReader{}, info.Size())
+		if err == nil {
+			err = f.Sync()
+		}
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to overwrite %s: %w", name, err)
+	}
+	return remove(name)
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
@@ -0,0 +1,114 @@
+package secretfile
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	var m *Matcher
+	for name, want := range map[string]bool{
+		".env":               true,
+		"app/.env.local":     true,
+		"./.git-credentials": true,
+		"env.go":             false,
+		".envrc":             false,
+	} {
+		if got := m.Match(name); got != want {
+			t.Errorf("Match(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	custom, err := NewMatcher([]string{"*.pem"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !custom.Match("certs/server.pem") || custom.Match(".env") {
+		t.Error("custom patterns should replace the defaults")
+	}
+	if _, err := NewMatcher([]string{"["}); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+}
+
+func TestFilterTar(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range []string{"./", "./main.go", "./.env", "./sub/.env.local"} {
+		h := &tar.Header{Name: name, Typeflag: tar.TypeReg, Size: 1, Mode: 0644}
+		if name == "./" {
+			h = &tar.Header{Name: name, Typeflag: tar.TypeDir, Mode: 0755}
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if h.Size > 0 {
+			tw.Write([]byte("x"))
+		}
+	}
+	tw.Close()
+
+	filtered := FilterTar(io.NopCloser(&buf), nil)
+	defer filtered.Close()
+	tr := tar.NewReader(filtered)
+	var names []string
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, h.Name)
+	}
+	if len(names) != 2 || names[0] != "./" || names[1] != "./main.go" {
+		t.Fatalf("filtered entries = %v", names)
+	}
+}
+
+func TestSealTenantIsolation(t *testing.T) {
+	s, err := NewSealer(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := s.Seal("alice", []byte("TOKEN=secret\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, []byte("secret")) {
+		t.Fatal("sealed output contains plaintext")
+	}
+	plain, err := s.Open("alice", sealed)
+	if err != nil || string(plain) != "TOKEN=secret\n" {
+		t.Fatalf("Open = %q, %v", plain, err)
+	}
+	if _, err := s.Open("bob", sealed); err == nil {
+		t.Fatal("expected another tenant to fail decryption")
+	}
+
+	if _, err := NewSealer("c2hvcnQ="); err == nil {
+		t.Fatal("expected error for short key")
+	}
+}
+
+func TestSecureDelete(t *testing.T) {
+	name := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(name, []byte("TOKEN=secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SecureDelete(name); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Fatalf("file still exists: %v", err)
+	}
+	if err := SecureDelete(name); err != nil {
+		t.Fatalf("SecureDelete on missing file: %v", err)
+	}
+}
@@ -4,13 +4,20 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"os"
 
 	"platform/internal/config"
+	"platform/internal/hostport"
+	"platform/internal/logging"
+	"platform/internal/preference"
+	"platform/internal/projectstack"
+	"platform/internal/quota"
+	"platform/internal/sandbox"
+	"platform/internal/serviceaccount"
 	"platform/internal/session/repo"
 
 	"github.com/docker/docker/client"
 	"github.com/go-pg/pg/v10"
-	"github.com/go-pg/pg/v10/orm"
 	"github.com/hibiken/asynq"
 	"github.com/redis/go-redis/v9"
 )
@@ -23,10 +30,25 @@ type Dependency struct {
 	AsynqClient *asynq.Client
 	AsynqRedis  asynq.RedisClientOpt
 	Logger      *slog.Logger
+	// LogLevels 运行时可调整的日志级别，为 nil 时管理接口不支持修改级别
+	LogLevels *logging.Levels
 }
 
 func InitDeps(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*Dependency, error) {
-	dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	var dockerClient *client.Client
+	var err error
+	switch cfg.Sandbox.Backend {
+	case sandbox.BackendDocker:
+		dockerClient, err = client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	case sandbox.BackendPodman:
+		dockerClient, err = sandbox.NewPodmanClient(ctx, cfg.Sandbox.PodmanSocket)
+		if err == nil {
+			// compose 通过 docker CLI 执行，指向同一个 Podman socket
+			os.Setenv("DOCKER_HOST", dockerClient.DaemonHost())
+		}
+	default:
+		return nil, fmt.Errorf("invalid SANDBOX_BACKEND %q (expected docker or podman)", cfg.Sandbox.Backend)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("docker client: %w", err)
 	}
@@ -58,9 +80,7 @@ func InitDeps(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*De
 	}
 
 	// 迁移数据库 schema
-	if err := pgDB.Model(&repo.SessionModel{}).CreateTable(&orm.CreateTableOptions{
-		IfNotExists: true,
-	}); err != nil {
+	if err := migrate(pgDB); err != nil {
 		pgDB.Close()
 		redisClient.Close()
 		dockerClient.Close()
@@ -83,6 +103,29 @@ func InitDeps(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*De
 	}, nil
 }
 
+// migrate 执行所有表的 schema 迁移
+func migrate(db *pg.DB) error {
+	if err := repo.Migrate(db); err != nil {
+		return err
+	}
+	if err := preference.Migrate(db); err != nil {
+		return err
+	}
+	if err := serviceaccount.Migrate(db); err != nil {
+		return err
+	}
+	if err := hostport.Migrate(db); err != nil {
+		return err
+	}
+	if err := quota.Migrate(db); err != nil {
+		return err
+	}
+	if err := projectstack.Migrate(db); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (d *Dependency) Close() {
 	if d.AsynqClient != nil {
 		d.AsynqClient.Close()
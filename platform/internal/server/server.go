@@ -2,23 +2,53 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 	"time"
 
 	"platform/internal/api"
+	"platform/internal/auth"
 	"platform/internal/config"
+	"platform/internal/debugbundle"
+	"platform/internal/diskusage"
 	"platform/internal/dispatcher"
+	"platform/internal/drift"
+	"platform/internal/errreport"
 	"platform/internal/eventbus"
+	"platform/internal/execpolicy"
+	"platform/internal/featureflag"
+	"platform/internal/gc"
+	"platform/internal/hostport"
+	"platform/internal/lock"
 	"platform/internal/monitor"
+	"platform/internal/nodes"
+	"platform/internal/notify"
+	"platform/internal/operation"
 	"platform/internal/orchestrator"
+	"platform/internal/preference"
+	"platform/internal/projectstack"
+	"platform/internal/queuepos"
+	"platform/internal/quota"
+	"platform/internal/reqid"
+	"platform/internal/sandbox"
+	"platform/internal/secretfile"
 	"platform/internal/service"
+	"platform/internal/serviceaccount"
 	"platform/internal/session"
 	"platform/internal/session/repo"
 	"platform/internal/session/worker"
+	"platform/internal/slo"
+	"platform/internal/snapshot"
+	"platform/internal/storage"
+	"platform/internal/taskstatus"
 
+	"github.com/docker/docker/client"
 	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
 )
 
 type Server struct {
@@ -30,6 +60,15 @@ type Server struct {
 	pool        *orchestrator.Pool
 	svc         *service.Service
 	cleaner     *session.SessionCleaner
+	idleReaper  *session.IdleReaper
+	relay       *session.OutboxRelay
+	collector   *gc.Collector
+	reconciler  *drift.Reconciler
+	queuePos    *queuepos.Estimator
+	diskUsage   *diskusage.Inspector
+	quota       *diskusage.QuotaWatcher
+	flags       *featureflag.Flags
+	notifier    *notify.Notifier
 	logger      *slog.Logger
 }
 
@@ -37,7 +76,58 @@ func NewServer(cfg *config.Config, deps *Dependency) *Server {
 	logger := deps.Logger
 
 	bus := eventbus.NewRedisBus(deps.Redis, logger)
+	if cfg.Events.MaxPayloadBytes > 0 {
+		bus.Overflow = eventbus.NewOverflowStore(deps.Redis, cfg.Events.MaxPayloadBytes, cfg.Events.OverflowTTL)
+	}
+
+	sandbox.SetExecConcurrency(sandbox.ExecConcurrency{
+		MaxConcurrent: cfg.Sandbox.ExecMaxConcurrent,
+		MaxQueued:     cfg.Sandbox.ExecMaxQueued,
+		QueueTimeout:  cfg.Sandbox.ExecQueueTimeout,
+	})
+	sandbox.SetRemovalPolicy(sandbox.RemovalPolicy{
+		StopTimeout: cfg.Sandbox.StopTimeout,
+		Force:       cfg.Sandbox.ForceRemove,
+		KeepFailed:  cfg.Sandbox.KeepFailedContainers,
+	})
+
+	// 平台容器共享的 cgroup parent 需在创建容器前写入总资源上限；失败时容器仍放入该 parent，只是没有整体上限
+	if budget := cgroupBudget(cfg.Pool); cfg.Pool.CgroupParent != "" && !budget.IsZero() {
+		if err := sandbox.EnsureCgroupParent(cfg.Pool.CgroupParent, budget); err != nil {
+			logger.Warn("Failed to apply cgroup parent budget", "cgroup_parent", cfg.Pool.CgroupParent, "error", err)
+		}
+	}
+
+	flagDefaults, err := featureflag.ParseDefaults(cfg.Features.Defaults)
+	if err != nil {
+		logger.Warn("Ignoring feature flag defaults", "value", cfg.Features.Defaults, "error", err)
+	}
+	flags := featureflag.New(featureflag.NewRedisStore(deps.Redis), flagDefaults, cfg.Features.RefreshInterval, logger)
+
+	notifier := newNotifier(cfg.Notify, logger)
 
+	warmPools, err := orchestrator.ParseWarmPools(cfg.Pool.WarmPools, cfg.Pool.MinIdle, cfg.Pool.MaxBurst)
+	if err != nil {
+		logger.Warn("Ignoring POOL_WARM_POOLS, only the default warm pool is used", "error", err)
+		warmPools = nil
+	}
+	prewarm, prewarmLoc := prewarmSchedule(cfg.Pool, logger)
+	// 用户和项目的并发 session 配额，Redis 计数在多个控制面实例间共享
+	var sessionQuota *quota.ConcurrencyLimiter
+	userLimits := quota.SessionLimits{
+		Sessions:    cfg.Quota.UserSessions,
+		MemoryBytes: cfg.Quota.UserMemoryMB * 1024 * 1024,
+		CPUs:        cfg.Quota.UserCPUs,
+	}
+	projectLimits := quota.SessionLimits{
+		Sessions:    cfg.Quota.ProjectSessions,
+		MemoryBytes: cfg.Quota.ProjectMemoryMB * 1024 * 1024,
+		CPUs:        cfg.Quota.ProjectCPUs,
+	}
+	if cfg.Quota.Enabled && (!userLimits.IsZero() || !projectLimits.IsZero()) {
+		sessionQuota = quota.NewConcurrencyLimiter(deps.Redis, userLimits, projectLimits, cfg.Pool.ContainerMem*1024*1024, cfg.Pool.ContainerCPU)
+	}
+	nodeRegistry := newNodeRegistry(deps.Docker, cfg.Nodes, logger)
 	pool := orchestrator.NewPool(deps.Docker, logger, orchestrator.PoolConfig{
 		MinIdle:             cfg.Pool.MinIdle,
 		MaxBurst:            cfg.Pool.MaxBurst,
@@ -47,15 +137,179 @@ func NewServer(cfg *config.Config, deps *Dependency) *Server {
 		HostRoot:            cfg.Pool.HostRoot,
 		ContainerMem:        cfg.Pool.ContainerMem,
 		ContainerCPU:        cfg.Pool.ContainerCPU,
+		ContainerDisk:       cfg.Pool.ContainerDisk,
+		Runtime:             cfg.Pool.Runtime,
+		CgroupParent:        cfg.Pool.CgroupParent,
+		EgressProxyImage:    cfg.Pool.EgressProxyImage,
+
+		Security:               securityProfile(cfg.Pool),
+		SecurityExemptProjects: cfg.Pool.SecurityExemptProjects,
+		ReadOnlyRootFS:         cfg.Pool.ReadOnlyRootFS,
+		WarmCmd:                cfg.Pool.WarmCmd,
+		WarmEntrypoint:         cfg.Pool.WarmEntrypoint,
+		BakeWarmImage:          cfg.Pool.BakeWarmImage,
+		BakeCommand:            bakeCommand(cfg.Pool.BakeCommand),
+		WorkspaceVolume:        workspaceVolume(cfg.Pool, logger),
+		Flags:                  flags,
+		Notifier:               notifier,
+		CreateFailureAlert:     cfg.Notify.CreateFailures,
+		Autoscale: orchestrator.AutoscaleConfig{
+			Enabled:        cfg.Pool.Autoscale,
+			MinIdle:        cfg.Pool.AutoscaleMinIdle,
+			MaxIdle:        cfg.Pool.AutoscaleMaxIdle,
+			Interval:       cfg.Pool.AutoscaleInterval,
+			WaitThreshold:  cfg.Pool.AutoscaleWaitThreshold,
+			ScaleDownDelay: cfg.Pool.AutoscaleScaleDownDelay,
+		},
+		WarmPools:        warmPools,
+		RecycleOnRelease: cfg.Pool.RecycleOnRelease,
+		Prewarm:          prewarm,
+		PrewarmLocation:  prewarmLoc,
+		BatchMaxWait:     cfg.Pool.BatchMaxWait,
+		Scheduler:        poolScheduler(cfg.Pool, flags, logger),
+		Sessions:         sessionQuota,
+		Nodes:            nodeRegistry,
+
+		AgentHealthTimeout:  cfg.Pool.AgentHealthTimeout,
+		AgentHealthFailures: cfg.Pool.AgentHealthFailures,
+		MaxContainerAge:     cfg.Pool.MaxContainerAge,
+		IdleTTL:             cfg.Pool.IdleTTL,
+		CooldownBase:        cfg.Pool.CooldownBase,
+		CooldownMax:         cfg.Pool.CooldownMax,
+		Events:              bus,
 	})
 
-	sessionRepo := repo.NewRepository(deps.PG, deps.Redis)
+	// 显式声明接口类型，关闭缓存时传入的是 nil 接口而不是 nil *redis.Client
+	var sessionCache redis.Cmdable
+	if cfg.Cache.SessionEnabled {
+		sessionCache = deps.Redis
+	} else {
+		logger.Info("Session cache disabled")
+	}
+	sessionRepo := repo.NewRepository(deps.PG, sessionCache)
 	sessionMgr := session.NewSessionManager(pool, sessionRepo, deps.Redis, deps.AsynqClient, logger)
+	sessionMgr.Quota = sessionQuota
+	sessionMgr.GitTokens = session.NewGitTokenStore(deps.Redis)
 	disp := dispatcher.NewDispatcher(bus, logger)
 	companions := service.NewCompanionManager(deps.Docker, cfg.Pool.NetworkName, logger)
 	compose := service.NewComposeManager(deps.Docker, cfg.Pool.NetworkName, cfg.Log.ContainerLogDir, logger)
+	ports := hostport.NewAllocator(hostport.NewPGStore(deps.PG), hostport.Config{
+		Min: cfg.HostPorts.Min,
+		Max: cfg.HostPorts.Max,
+	}, logger)
+	companions.Ports = ports
+	companions.CgroupParent = cfg.Pool.CgroupParent
+	companions.Refs = service.NewRedisProjectRefs(deps.Redis)
+	compose.Ports = ports
+	// 伴随服务与 compose 服务的数量、内存和 CPU 配额，按 session 和租户（session 所属用户）统计
+	var quotas *quota.Tracker
+	if cfg.Quota.Enabled {
+		quotas = quota.NewTracker(quota.NewPGStore(deps.PG), quota.Limits{
+			Services:    cfg.Quota.SessionServices,
+			MemoryBytes: cfg.Quota.SessionMemoryMB * 1024 * 1024,
+			CPUs:        cfg.Quota.SessionCPUs,
+		}, quota.Limits{
+			Services:    cfg.Quota.TenantServices,
+			MemoryBytes: cfg.Quota.TenantMemoryMB * 1024 * 1024,
+			CPUs:        cfg.Quota.TenantCPUs,
+		}, logger)
+	}
+	companions.Quota = quotas
+	compose.Quota = quotas
 	svc := service.NewService(sessionMgr, sessionRepo, disp, bus, deps.Docker, logger, cfg.Pool.HostRoot, companions, compose)
 
+	// 存储占用统计
+	diskUsage := diskusage.NewInspector(deps.Docker, diskusage.Config{
+		Dirs: []diskusage.DirGroup{
+			{Category: "projects", Roots: []string{cfg.Pool.HostRoot, cfg.Worker.ProjectDir}},
+			{Category: "compose", Roots: []string{cfg.Log.ContainerLogDir}},
+			{Category: "exec_logs", Roots: []string{sandbox.DefaultLogDir}},
+			{Category: "logs", Roots: []string{cfg.Log.Dir}},
+			{Category: "storage", Roots: []string{cfg.Storage.LocalDir}},
+		},
+		Images:       warmImages(cfg.Pool.WarmupImage, warmPools),
+		Interval:     cfg.DiskUsage.Interval,
+		AlertPercent: cfg.Notify.DiskPercent,
+	}, logger)
+	diskUsage.Notifier = notifier
+	svc.DiskUsage = diskUsage
+	svc.Preferences = preference.NewPGStore(deps.PG)
+	svc.ProjectStacks = projectstack.NewPGStore(deps.PG)
+	svc.ServiceAccounts = serviceaccount.NewManager(serviceaccount.NewPGStore(deps.PG), logger)
+	svc.Operations = operation.NewManager(operation.NewRedisStore(deps.Redis), logger)
+	svc.Locks = lock.NewRedisLocker(deps.Redis, 30*time.Second, logger)
+	svc.Ports = ports
+	svc.LogLevels = deps.LogLevels
+	svc.Queues = asynq.NewInspector(deps.AsynqRedis)
+	svc.Cancels = session.NewCancelStore(deps.Redis)
+	svc.CheckpointDir = cfg.Session.CheckpointDir
+	svc.Quota = quotas
+	svc.Flags = flags
+	svc.Payloads = bus.Overflow
+	svc.Nodes = nodeRegistry
+	svc.Pool = pool
+	svc.StrategyFallback = cfg.Worker.StrategyFallback
+	svc.HealDeadContainers = cfg.Session.HealDeadContainers
+	// 租出的预热容器意外退出时重新分配容器或将 session 标记为 error
+	pool.OnLeaseDead(svc.HandleDeadContainer)
+	if cfg.Pool.WarmupTimeout > 0 {
+		svc.SetWarming()
+	}
+	// 持久化 Agent 回答，容器被替换后随对话历史重放
+	disp.OnEvent = svc.RecordAgentEvent
+	svc.MountRoots = cfg.Sandbox.MountRoots
+	svc.MountVolumes = cfg.Sandbox.MountVolumes
+	svc.MaxArchiveSize = cfg.Sandbox.ArchiveMaxMB << 20
+	if matcher, err := secretfile.NewMatcher(cfg.Sandbox.SecretFilePatterns); err != nil {
+		logger.Warn("Ignoring secret file patterns, using defaults", "error", err)
+	} else {
+		svc.SecretFiles = matcher
+	}
+	if cfg.Sandbox.SecretKey != "" {
+		sealer, err := secretfile.NewSealer(cfg.Sandbox.SecretKey)
+		if err != nil {
+			logger.Warn("Invalid secret key, secret files will not be synced to the host", "error", err)
+		} else {
+			svc.Secrets = sealer
+		}
+	}
+	if cfg.Sandbox.DatasetCatalog != "" {
+		datasets, err := sandbox.LoadDatasetCatalog(cfg.Sandbox.DatasetCatalog)
+		if err != nil {
+			logger.Warn("Ignoring dataset catalog, dataset mounts are disabled", "file", cfg.Sandbox.DatasetCatalog, "error", err)
+		} else {
+			svc.Datasets = datasets
+		}
+	}
+	blob := newBlob(cfg.Storage, logger)
+	svc.Snapshots = snapshot.NewStore(blob)
+	svc.DebugBundles = newDebugCollector(cfg.Log, blob, deps.Docker, logger)
+	if svc.DebugBundles != nil {
+		svc.DebugBundles.Nodes = nodeRegistry
+	}
+	if cfg.Storage.Projects {
+		svc.Projects = storage.NewProjectStore(blob)
+	}
+	if cfg.Sandbox.ExecPolicyEnabled {
+		svc.ExecPolicy = execPolicy(cfg.Sandbox)
+	}
+	svc.Tasks = taskstatus.NewTracker(taskstatus.NewRedisStore(deps.Redis), taskstatus.Config{
+		Interval:   cfg.Worker.HeartbeatInterval,
+		StaleAfter: cfg.Worker.StaleAfter,
+	}, logger)
+	sloConfig := slo.Config{
+		Objectives: []slo.Objective{
+			{Name: slo.ObjectiveSessionCreate, Target: cfg.SLO.SessionCreateTarget, LatencyTarget: cfg.SLO.SessionCreateLatency},
+			{Name: slo.ObjectiveChatDispatch, Target: cfg.SLO.ChatTarget, LatencyTarget: cfg.SLO.ChatLatency},
+		},
+		Windows:  cfg.SLO.Windows,
+		Interval: cfg.SLO.Interval,
+	}
+	svc.SLO = slo.NewTracker(slo.NewRedisStore(deps.Redis, sloConfig.MaxWindow()), sloConfig, logger)
+	svc.QueuePositions = queuepos.NewEstimator(svc.Queues, svc.Tasks, bus, queuepos.Config{
+		Interval: cfg.Worker.QueueEventInterval,
+	}, logger)
+
 	// 会话清理器
 	var cleaner *session.SessionCleaner
 	if cfg.Session.Enabled {
@@ -68,13 +322,89 @@ func NewServer(cfg *config.Config, deps *Dependency) *Server {
 			},
 			logger,
 		)
+		if cfg.Pool.DrainForceTerminate {
+			pool.OnDrainTimeout(cleaner.TerminateLeased)
+		}
+	}
+
+	// 空闲 session 挂起
+	svc.Activity = session.NewActivityTracker(sessionRepo, session.DefaultActivityInterval, logger)
+	var idleReaper *session.IdleReaper
+	if cfg.Session.IdleTimeout > 0 {
+		idleReaper = session.NewIdleReaper(sessionRepo, svc.SuspendSession, session.IdleConfig{
+			Interval: cfg.Session.IdleCheckInterval,
+			Timeout:  cfg.Session.IdleTimeout,
+		}, logger)
+	}
+
+	// outbox relay：补投事务提交后未能入队的会话创建任务
+	relay := session.NewOutboxRelay(sessionRepo, deps.AsynqClient, session.OutboxConfig{
+		Interval:  cfg.Outbox.Interval,
+		Grace:     cfg.Outbox.Grace,
+		Retention: cfg.Outbox.Retention,
+	}, logger)
+
+	// 宿主机目录 GC
+	var collector *gc.Collector
+	if cfg.GC.Enabled {
+		collector = gc.NewCollector(sessionRepo, gc.Config{
+			Interval:     cfg.GC.Interval,
+			Retention:    cfg.GC.Retention,
+			SizeBudget:   cfg.GC.SizeBudgetMB * 1024 * 1024,
+			DryRun:       cfg.GC.DryRun,
+			ProjectRoots: []string{cfg.Pool.HostRoot, cfg.Worker.ProjectDir},
+			SessionRoots: []string{cfg.Log.ContainerLogDir, sandbox.DefaultLogDir},
+		}, logger)
+		collector.Containers = deps.Docker
+	}
+
+	// session 容器状态对账：修复 Docker 重启后的 IP 变化和被外部修改的资源限制
+	var reconciler *drift.Reconciler
+	if cfg.Drift.Enabled {
+		reconciler = drift.NewReconciler(sessionRepo, deps.Docker, bus, drift.Config{
+			Interval:    cfg.Drift.Interval,
+			NetworkName: cfg.Pool.NetworkName,
+			MemoryLimit: cfg.Pool.ContainerMem * 1024 * 1024,
+			CPULimit:    cfg.Pool.ContainerCPU,
+			Repair:      cfg.Drift.Repair,
+		}, logger)
+		reconciler.OnIPChanged = svc.ResetAgent
+		if nodeRegistry != nil {
+			reconciler.NodeDocker = func(nodeID string) (drift.Docker, error) {
+				node, err := nodeRegistry.Node(nodeID)
+				if err != nil {
+					return nil, err
+				}
+				return node.Client, nil
+			}
+		}
+	}
+
+	// 绑定挂载工作区的磁盘配额检查（匿名卷容器由 tmpfs 大小限制）
+	var quota *diskusage.QuotaWatcher
+	if cfg.Pool.ContainerDisk > 0 {
+		quota = diskusage.NewQuotaWatcher(sessionRepo, bus, svc.TerminateSession, diskusage.QuotaConfig{
+			Limit:     cfg.Pool.ContainerDisk * 1024 * 1024,
+			Interval:  cfg.DiskUsage.QuotaInterval,
+			HostRoot:  cfg.Pool.HostRoot,
+			Terminate: cfg.DiskUsage.QuotaTerminate,
+		}, logger)
 	}
 
 	sessionWorker := worker.NewSessionTaskWorker(pool, sessionRepo, bus, worker.WorkerConfig{
 		ProjectDir:      cfg.Worker.ProjectDir,
 		PlatformAPIURL:  "http://host.docker.internal" + cfg.Server.Addr,
 		ContainerLogDir: cfg.Log.ContainerLogDir,
+		FallbackWait:    cfg.Worker.FallbackWait,
 	}, logger)
+	sessionWorker.Tracker = svc.Tasks
+	sessionWorker.Snapshots = svc.Snapshots
+	sessionWorker.Projects = svc.Projects
+	sessionWorker.Flags = flags
+	sessionWorker.Quota = sessionQuota
+	sessionWorker.Debug = svc.DebugBundles
+	sessionWorker.Cancels = svc.Cancels
+	sessionWorker.GitTokens = sessionMgr.GitTokens
 
 	asynqServer := asynq.NewServer(deps.AsynqRedis, asynq.Config{
 		Concurrency: cfg.Worker.Concurrency,
@@ -82,9 +412,45 @@ func NewServer(cfg *config.Config, deps *Dependency) *Server {
 	})
 
 	mux := asynq.NewServeMux()
+	mux.Use(reportTaskPanics(logger))
+	if notifier != nil && cfg.Notify.SessionErrorRate > 0 {
+		mux.Use(alertSessionErrors(sessionRepo, notifier, notify.NewRateTracker(notify.RateConfig{
+			Window:     cfg.Notify.SessionErrorWindow,
+			Threshold:  cfg.Notify.SessionErrorRate,
+			MinSamples: cfg.Notify.SessionErrorMinSamples,
+		})))
+	}
+	mux.Use(recordSessionSLO(sessionRepo, svc.SLO))
+	mux.Use(provisionProjectStacks(svc, logger))
 	mux.HandleFunc(session.SessionCreateTask, sessionWorker.HandleSessionCreate)
 
-	router := api.NewRouter(svc)
+	// OIDC 身份认证（未配置 issuer 时不启用）
+	var oidc *auth.OIDCProvider
+	if cfg.OIDC.IssuerURL != "" {
+		oidc = auth.NewOIDCProvider(auth.OIDCConfig{
+			IssuerURL:    cfg.OIDC.IssuerURL,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+			Audience:     cfg.OIDC.Audience,
+			UserClaim:    cfg.OIDC.UserClaim,
+			TenantClaim:  cfg.OIDC.TenantClaim,
+		}, logger)
+	}
+
+	if cfg.SignedURL.Secret == "" {
+		logger.Warn("SIGNED_URL_SECRET not set, signed URLs will not survive restarts")
+	}
+	signer := auth.NewURLSigner(cfg.SignedURL.Secret, cfg.SignedURL.MaxTTL)
+
+	router := api.NewRouter(svc, api.RouterConfig{
+		AdminToken:       cfg.Admin.Token,
+		OIDC:             oidc,
+		URLSigner:        signer,
+		SignedURLDefault: cfg.SignedURL.DefaultTTL,
+		Compression:      cfg.Server.Compression,
+	})
 	httpServer := &http.Server{
 		Addr:         cfg.Server.Addr,
 		Handler:      router,
@@ -101,6 +467,15 @@ func NewServer(cfg *config.Config, deps *Dependency) *Server {
 		pool:        pool,
 		svc:         svc,
 		cleaner:     cleaner,
+		idleReaper:  idleReaper,
+		relay:       relay,
+		collector:   collector,
+		reconciler:  reconciler,
+		queuePos:    svc.QueuePositions,
+		diskUsage:   diskUsage,
+		quota:       quota,
+		flags:       flags,
+		notifier:    notifier,
 		logger:      logger,
 	}
 
@@ -112,6 +487,56 @@ func (s *Server) Start(ctx context.Context) error {
 	if s.cleaner != nil {
 		go s.cleaner.Start()
 	}
+	if s.idleReaper != nil {
+		go s.idleReaper.Start()
+	}
+	go s.svc.SLO.Start()
+
+	go s.relay.Start()
+	go s.flags.Start()
+	if s.notifier != nil {
+		go s.notifier.Start()
+	}
+
+	if err := s.svc.Companions.RestoreProjectServices(ctx); err != nil {
+		s.logger.Warn("Failed to restore project companion services", "error", err)
+	}
+
+	if n, err := s.svc.PruneHostPorts(ctx); err != nil {
+		s.logger.Warn("Failed to prune host ports", "error", err)
+	} else if n > 0 {
+		s.logger.Info("Pruned host ports of terminated sessions", "count", n)
+	}
+
+	if s.cfg.Pool.BakeWarmImage {
+		go func() {
+			if err := s.pool.BakeWarmImage(ctx); err != nil {
+				s.logger.Error("Failed to bake warm image, keep using the base image", "image", s.cfg.Pool.WarmupImage, "error", err)
+			}
+		}()
+	}
+
+	if s.collector != nil {
+		go s.collector.Start()
+	}
+
+	if s.reconciler != nil {
+		go s.reconciler.Start()
+	}
+
+	if s.cfg.Worker.QueueEventInterval > 0 {
+		go s.queuePos.Start()
+	}
+
+	go s.diskUsage.Start()
+
+	if s.cfg.Pool.WarmupTimeout > 0 {
+		go s.svc.AwaitPoolWarm(ctx, s.pool.Warm, s.cfg.Pool.WarmupTimeout)
+	}
+
+	if s.quota != nil {
+		go s.quota.Start()
+	}
 
 	go func() {
 		s.logger.Info("Starting Asynq worker", "concurrency", s.cfg.Worker.Concurrency)
@@ -152,22 +577,340 @@ func (s *Server) Shutdown() error {
 	if s.cleaner != nil {
 		s.cleaner.Stop()
 	}
+	if s.idleReaper != nil {
+		s.idleReaper.Stop()
+	}
+	s.svc.SLO.Stop()
+
+	s.relay.Stop()
+	s.flags.Stop()
+
+	if s.collector != nil {
+		s.collector.Stop()
+	}
+
+	if s.reconciler != nil {
+		s.reconciler.Stop()
+	}
+
+	s.queuePos.Stop()
+
+	s.diskUsage.Stop()
+
+	if s.quota != nil {
+		s.quota.Stop()
+	}
 
 	if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
 		s.logger.Error("HTTP server shutdown error", "error", err)
 	}
 
 	s.asynqServer.Shutdown()
+	s.svc.Queues.Close()
+
+	// 等待进行中的异步操作（终止、同步等）完成
+	s.svc.Operations.Wait(shutdownCtx)
 
 	// 清理所有活跃 session 的容器和资源
 	session.CleanupAllActive(shutdownCtx, s.svc.SessionRepo, s.svc.TerminateSession, s.logger)
 
-	s.pool.Shutdown(shutdownCtx, nil)
+	if s.notifier != nil {
+		s.notifier.Stop()
+	}
+
+	// 预热池使用独立的时限等待租出的容器归还，不与前面的步骤共用
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), s.cfg.Pool.DrainTimeout)
+	defer cancelDrain()
+	s.pool.Shutdown(drainCtx, nil)
 
 	s.logger.Info("Server stopped gracefully")
 	return nil
 }
 
+// reportTaskPanics 捕获任务 handler 的 panic 并上报，转为错误交由 asynq 按重试策略处理
+// securityProfile 在加固默认配置上应用 POOL_* 覆盖项
+func securityProfile(cfg config.PoolConfig) sandbox.SecurityProfile {
+	profile := sandbox.DefaultSecurityProfile()
+	profile.Seccomp = cfg.SeccompProfile
+	profile.CapAdd = cfg.CapAdd
+	profile.NoNewPrivileges = cfg.NoNewPrivileges
+	switch {
+	case len(cfg.CapDrop) == 1 && cfg.CapDrop[0] == "none":
+		profile.CapDrop = nil
+	case cfg.CapDrop != nil:
+		profile.CapDrop = cfg.CapDrop
+	}
+	return profile
+}
+
+func cgroupBudget(cfg config.PoolConfig) sandbox.CgroupBudget {
+	return sandbox.CgroupBudget{
+		MemoryBytes: cfg.CgroupMemoryMB * 1024 * 1024,
+		CPUs:        cfg.CgroupCPUs,
+		PidsMax:     cfg.CgroupPids,
+	}
+}
+
+// workspaceVolume 未配置或配置无效时返回 nil，冷容器回退到绑定挂载
+func workspaceVolume(cfg config.PoolConfig, logger *slog.Logger) *sandbox.WorkspaceVolume {
+	if cfg.WorkspaceVolumeType == "" {
+		return nil
+	}
+	wv := &sandbox.WorkspaceVolume{
+		Name: cfg.WorkspaceVolumeName,
+		Driver: sandbox.VolumeDriver{
+			Type:     sandbox.VolumeType(cfg.WorkspaceVolumeType),
+			Server:   cfg.WorkspaceVolumeServer,
+			Path:     cfg.WorkspaceVolumePath,
+			FSType:   cfg.WorkspaceVolumeFSType,
+			Options:  cfg.WorkspaceVolumeOptions,
+			Username: cfg.WorkspaceVolumeUsername,
+			Password: cfg.WorkspaceVolumePassword,
+		},
+	}
+	if err := wv.Driver.Validate(); err != nil {
+		logger.Warn("Ignoring workspace volume config, cold workspaces use bind mounts", "error", err)
+		return nil
+	}
+	return wv
+}
+
+// newDebugCollector 按 DEBUG_BUNDLES 选择调试包的保存位置，off 时返回 nil
+func newDebugCollector(cfg config.LogConfig, blob storage.Blob, docker *client.Client, logger *slog.Logger) *debugbundle.Collector {
+	switch cfg.DebugBundles {
+	case "off":
+		return nil
+	case "storage":
+	default:
+		if cfg.DebugBundles != "log" {
+			logger.Warn("Unknown DEBUG_BUNDLES value, saving debug bundles to the log dir", "value", cfg.DebugBundles)
+		}
+		blob = storage.NewLocalBlob(cfg.Dir)
+	}
+	return debugbundle.NewCollector(blob, docker, logger)
+}
+
+// newNodeRegistry 未配置远程节点或配置无效时返回 nil，所有容器都在本机创建。
+// 远程节点上的容器以绑定挂载使用工作区时，HostRoot 需要是各节点共享的目录
+func newNodeRegistry(local *client.Client, cfg config.NodesConfig, logger *slog.Logger) *nodes.Registry {
+	if len(cfg.Remote) == 0 {
+		return nil
+	}
+	configs, err := nodes.ParseConfigs(cfg.Remote, cfg.TLSDir)
+	if err != nil {
+		logger.Warn("Ignoring NODES, containers are placed on the local node only", "error", err)
+		return nil
+	}
+	registry, err := nodes.NewRegistry(local, cfg.LocalMaxContainers, configs, logger)
+	if err != nil {
+		logger.Warn("Ignoring NODES, containers are placed on the local node only", "error", err)
+		return nil
+	}
+	logger.Info("Multi-node placement enabled", "nodes", len(registry.Nodes()))
+	return registry
+}
+
+func bakeCommand(script string) []string {
+	if script == "" {
+		return nil
+	}
+	return []string{"sh", "-c", script}
+}
+
+// execPolicy 在默认命令策略上应用 EXEC_POLICY_* 覆盖项
+func execPolicy(cfg config.SandboxConfig) *execpolicy.Policy {
+	policy := execpolicy.Default()
+	if cfg.ExecDeniedBinaries != nil {
+		policy.DeniedBinaries = cfg.ExecDeniedBinaries
+	}
+	if cfg.ExecAllowedWorkDirs != nil {
+		policy.AllowedWorkDirs = cfg.ExecAllowedWorkDirs
+	}
+	if cfg.ExecScrubEnv != nil {
+		policy.ScrubEnvVars = cfg.ExecScrubEnv
+	}
+	return policy
+}
+
+// newBlob 按 STORAGE_DRIVER 创建对象存储，S3 配置无效时回退到本地目录，避免平台无法启动
+func newBlob(cfg config.StorageConfig, logger *slog.Logger) storage.Blob {
+	if cfg.Driver == "s3" {
+		blob, err := storage.NewS3Blob(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+		})
+		if err == nil {
+			return blob
+		}
+		logger.Error("Invalid S3 storage config, falling back to local storage", "error", err, "dir", cfg.LocalDir)
+	} else if cfg.Driver != "local" {
+		logger.Warn("Unknown storage driver, using local storage", "driver", cfg.Driver, "dir", cfg.LocalDir)
+	}
+	return storage.NewLocalBlob(cfg.LocalDir)
+}
+
+// newNotifier 按配置创建告警发送渠道，Slack 和邮件都未配置时返回 nil
+func newNotifier(cfg config.NotifyConfig, logger *slog.Logger) *notify.Notifier {
+	var senders []notify.Sender
+	if cfg.SlackWebhook != "" {
+		senders = append(senders, notify.NewSlackSender(cfg.SlackWebhook))
+	}
+	if cfg.SMTPAddr != "" && len(cfg.SMTPTo) > 0 {
+		senders = append(senders, notify.NewEmailSender(notify.EmailConfig{
+			Addr:     cfg.SMTPAddr,
+			From:     cfg.SMTPFrom,
+			To:       cfg.SMTPTo,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+		}))
+	}
+	if len(senders) == 0 {
+		return nil
+	}
+
+	kinds := make([]notify.Kind, 0, len(cfg.Alerts))
+	for _, k := range cfg.Alerts {
+		kinds = append(kinds, notify.Kind(k))
+	}
+	return notify.New(senders, notify.Config{Throttle: cfg.Throttle, Kinds: kinds}, logger)
+}
+
+// warmImages 所有预热池使用的镜像
+func warmImages(defaultImage string, pools []orchestrator.WarmPoolConfig) []string {
+	images := []string{defaultImage}
+	for _, wp := range pools {
+		if !slices.Contains(images, wp.Image) {
+			images = append(images, wp.Image)
+		}
+	}
+	return images
+}
+
+// alertSessionErrors 统计 session 创建任务结束后的状态，近期失败比例超过阈值时告警。
+// 任务重试时同一 session 以最后一次结果为准
+func alertSessionErrors(repo session.SessionRepository, notifier *notify.Notifier, tracker *notify.RateTracker) asynq.MiddlewareFunc {
+	return func(next asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+			err := next.ProcessTask(ctx, task)
+
+			var payload session.SessionCreatePayload
+			if json.Unmarshal(task.Payload(), &payload) != nil || payload.SessionID == "" {
+				return err
+			}
+			sess, getErr := repo.GetByID(ctx, payload.SessionID)
+			if getErr != nil {
+				return err
+			}
+			var failed bool
+			switch sess.Status {
+			case session.StatusReady:
+			case session.StatusError:
+				failed = true
+			default:
+				// 仍在初始化或已被终止，不计入
+				return err
+			}
+
+			rate, samples, exceeded := tracker.Record(sess.ID, failed, time.Now())
+			if failed && exceeded {
+				notifier.Notify(notify.Alert{
+					Kind:     notify.KindSessionErrorRate,
+					Severity: notify.SeverityCritical,
+					Title:    "Session error rate spike",
+					Message:  fmt.Sprintf("%.0f%% of the last %d session creations failed.", rate*100, samples),
+					Fields: map[string]string{
+						"last_failed_session": payload.SessionID,
+					},
+				})
+			}
+			return err
+		})
+	}
+}
+
+// recordSessionSLO 在 session 创建任务结束后按 session 状态记录创建 SLO：就绪计为成功，耗时从 session 创建起算（包括排队）；
+// 出错计为失败；仍在初始化（将重试）或已被终止的不计入。重复投递给已不在初始化中的 session 的任务不计入
+func recordSessionSLO(repo session.SessionRepository, tracker *slo.Tracker) asynq.MiddlewareFunc {
+	return func(next asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+			var payload session.SessionCreatePayload
+			if json.Unmarshal(task.Payload(), &payload) != nil || payload.SessionID == "" {
+				return next.ProcessTask(ctx, task)
+			}
+			if sess, err := repo.GetByID(ctx, payload.SessionID); err != nil || sess.Status != session.StatusInitializing {
+				return next.ProcessTask(ctx, task)
+			}
+
+			err := next.ProcessTask(ctx, task)
+			sess, getErr := repo.GetByID(ctx, payload.SessionID)
+			if getErr != nil {
+				return err
+			}
+			latency := time.Since(sess.CreatedAt)
+			switch sess.Status {
+			case session.StatusReady:
+				monitor.SessionCreationLatency.Observe(latency.Seconds())
+				tracker.Record(ctx, slo.ObjectiveSessionCreate, false, latency)
+			case session.StatusError:
+				tracker.Record(ctx, slo.ObjectiveSessionCreate, true, latency)
+			}
+			return err
+		})
+	}
+}
+
+// provisionProjectStacks 在 session 创建任务成功后启动项目声明的默认服务。
+// 服务启动失败只记录日志，不让任务失败重试，session 本身已可用
+func provisionProjectStacks(svc *service.Service, logger *slog.Logger) asynq.MiddlewareFunc {
+	logger = logger.With("component", "project-stack")
+	return func(next asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+			if err := next.ProcessTask(ctx, task); err != nil {
+				return err
+			}
+
+			var payload session.SessionCreatePayload
+			if json.Unmarshal(task.Payload(), &payload) != nil || payload.SessionID == "" {
+				return nil
+			}
+			if err := svc.ProvisionProjectStack(ctx, payload.SessionID); err != nil {
+				logger.Warn("Failed to provision project services", "session_id", payload.SessionID, "error", err)
+			}
+			return nil
+		})
+	}
+}
+
+func reportTaskPanics(logger *slog.Logger) asynq.MiddlewareFunc {
+	logger = logger.With("component", "asynq")
+	return func(next asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) (err error) {
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+
+				var payload session.SessionCreatePayload
+				_ = json.Unmarshal(task.Payload(), &payload)
+				if payload.RequestID != "" {
+					ctx = reqid.WithContext(ctx, payload.RequestID)
+				}
+				errreport.ReportPanic(ctx, logger, "asynq", r, errreport.Tags{
+					"task_type":  task.Type(),
+					"session_id": payload.SessionID,
+				})
+				err = fmt.Errorf("panic in task %s: %v", task.Type(), r)
+			}()
+			return next.ProcessTask(ctx, task)
+		})
+	}
+}
+
 type asynqLogger struct {
 	l *slog.Logger
 }
@@ -181,3 +924,32 @@ func (a *asynqLogger) Info(args ...any)  { a.l.Info("", "msg", args) }
 func (a *asynqLogger) Warn(args ...any)  { a.l.Warn("", "msg", args) }
 func (a *asynqLogger) Error(args ...any) { a.l.Error("", "msg", args) }
 func (a *asynqLogger) Fatal(args ...any) { a.l.Error("FATAL", "msg", args) }
+
+// poolScheduler 按名称创建预热池调度策略，名称无效时使用默认策略
+func poolScheduler(cfg config.PoolConfig, flags *featureflag.Flags, logger *slog.Logger) orchestrator.Scheduler {
+	base := orchestrator.DefaultScheduler{BatchMaxWait: cfg.BatchMaxWait, Flags: flags}
+	sched, err := orchestrator.NewScheduler(cfg.Scheduler, base)
+	if err != nil {
+		logger.Warn("Ignoring POOL_SCHEDULER, using default scheduler", "error", err)
+		return &base
+	}
+	return sched
+}
+
+// prewarmSchedule 解析预热计划和时区，格式错误时忽略计划，时区无效时使用本地时区
+func prewarmSchedule(cfg config.PoolConfig, logger *slog.Logger) ([]orchestrator.PrewarmWindow, *time.Location) {
+	windows, err := orchestrator.ParsePrewarmSchedule(cfg.PrewarmSchedule)
+	if err != nil {
+		logger.Warn("Ignoring POOL_PREWARM_SCHEDULE", "error", err)
+		return nil, nil
+	}
+	if cfg.PrewarmTimezone == "" {
+		return windows, nil
+	}
+	loc, err := time.LoadLocation(cfg.PrewarmTimezone)
+	if err != nil {
+		logger.Warn("Ignoring POOL_PREWARM_TIMEZONE, using local time", "timezone", cfg.PrewarmTimezone, "error", err)
+		return windows, nil
+	}
+	return windows, loc
+}
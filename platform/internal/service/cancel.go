@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+
+	"platform/internal/queuepos"
+)
+
+// cancelPendingCreate 取消初始化中 session 的创建任务：排队中的任务直接从队列删除；
+// 已在处理的任务通过取消标记和 asynq 取消通知 worker，由 worker 归还已取得的容器
+func (s *Service) cancelPendingCreate(ctx context.Context, id string) {
+	if s.Cancels != nil {
+		if err := s.Cancels.Cancel(ctx, id); err != nil {
+			s.Logger.Warn("Failed to mark session creation cancelled", "session_id", id, "error", err)
+		}
+	}
+	if s.Queues == nil {
+		return
+	}
+	// 会话创建任务的 ID 即 session ID
+	if err := s.Queues.DeleteTask(queuepos.DefaultQueue, id); err == nil {
+		s.Logger.Info("Deleted pending session create task", "session_id", id)
+		return
+	}
+	if err := s.Queues.CancelProcessing(id); err != nil {
+		s.Logger.Warn("Failed to cancel session create task", "session_id", id, "error", err)
+		return
+	}
+	s.Logger.Info("Cancelling in-flight session create task", "session_id", id)
+}
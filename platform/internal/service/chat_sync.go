@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"platform/internal/eventbus"
+)
+
+// ErrChatTimeout 同步对话在超时前没有结束
+var ErrChatTimeout = errors.New("timed out waiting for agent answer")
+
+// maxToolResultLen 同步对话结果中每个工具返回值保留的最大长度
+const maxToolResultLen = 1024
+
+// ToolCallSummary 同步对话中的一次工具调用
+type ToolCallSummary struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments,omitempty"`
+	Result    string `json:"result,omitempty"`
+	// Truncated Result 超过 maxToolResultLen 被截断
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// ChatResult 同步对话的聚合结果。Agent 没有发出 answer 时 Answer 为文本片段的拼接
+type ChatResult struct {
+	SessionID string            `json:"session_id"`
+	Status    string            `json:"status"` // completed / error
+	Answer    string            `json:"answer"`
+	Error     string            `json:"error,omitempty"`
+	ToolCalls []ToolCallSummary `json:"tool_calls"`
+	Duration  time.Duration     `json:"duration_ns"`
+}
+
+// SendMessageSync 发送消息并在服务端聚合事件流，直到 Agent 的流结束后一次性返回结果。
+// 超时前已经收到 answer 时返回已有结果，否则返回 ErrChatTimeout
+func (s *Service) SendMessageSync(ctx context.Context, sessionID, message string, timeout time.Duration) (*ChatResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// 先订阅再发送，避免漏掉最早的事件
+	eventCh, err := s.StreamEvents(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.SendMessage(ctx, sessionID, message); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	result := &ChatResult{SessionID: sessionID, Status: "completed", ToolCalls: []ToolCallSummary{}}
+	var chunks strings.Builder
+	answered := false
+
+	finish := func() *ChatResult {
+		if !answered {
+			result.Answer = chunks.String()
+		}
+		if result.Error != "" {
+			result.Status = "error"
+		}
+		result.Duration = time.Since(start)
+		return result
+	}
+
+	for {
+		select {
+		case event, ok := <-eventCh:
+			if !ok {
+				if answered {
+					return finish(), nil
+				}
+				if ctx.Err() == context.DeadlineExceeded {
+					return nil, ErrChatTimeout
+				}
+				return nil, fmt.Errorf("event stream closed: %w", ctx.Err())
+			}
+
+			switch event.Type {
+			case eventbus.EventAgentAnswer:
+				result.Answer = payloadString(event.Payload, "text")
+				answered = true
+			case eventbus.EventAgentTextChunk:
+				chunks.WriteString(payloadString(event.Payload, "text"))
+			case eventbus.EventAgentToolCall:
+				result.ToolCalls = append(result.ToolCalls, ToolCallSummary{
+					ID:        payloadString(event.Payload, "tool_call_id"),
+					Name:      payloadString(event.Payload, "tool_name"),
+					Arguments: payloadString(event.Payload, "arguments"),
+				})
+			case eventbus.EventAgentToolResult:
+				if call := findToolCall(result.ToolCalls, payloadString(event.Payload, "tool_call_id")); call != nil {
+					call.Result = payloadString(event.Payload, "text")
+					if len(call.Result) > maxToolResultLen {
+						call.Result = call.Result[:maxToolResultLen]
+						call.Truncated = true
+					}
+				}
+			case eventbus.EventAgentError:
+				result.Error = payloadString(event.Payload, "text")
+			case eventbus.EventSessionError:
+				result.Error = payloadString(event.Payload, "error")
+			case eventbus.EventStreamDone:
+				return finish(), nil
+			}
+
+		case <-ctx.Done():
+			if answered {
+				return finish(), nil
+			}
+			if ctx.Err() == context.DeadlineExceeded {
+				return nil, ErrChatTimeout
+			}
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// findToolCall 按 ID 查找工具调用，ID 为空时匹配最后一个还没有结果的调用
+func findToolCall(calls []ToolCallSummary, id string) *ToolCallSummary {
+	for i := len(calls) - 1; i >= 0; i-- {
+		if id != "" && calls[i].ID == id {
+			return &calls[i]
+		}
+		if id == "" && calls[i].Result == "" {
+			return &calls[i]
+		}
+	}
+	return nil
+}
+
+// payloadString 事件经过 Redis 反序列化后负载为 map[string]any
+func payloadString(payload any, key string) string {
+	switch p := payload.(type) {
+	case map[string]any:
+		v, _ := p[key].(string)
+		return v
+	case map[string]string:
+		return p[key]
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+package service
+
+import "testing"
+
+func TestFindToolCall(t *testing.T) {
+	calls := []ToolCallSummary{
+		{ID: "a", Name: "bash", Result: "ok"},
+		{ID: "b", Name: "file_read"},
+		{Name: "list_files"},
+	}
+
+	if got := findToolCall(calls, "b"); got == nil || got.Name != "file_read" {
+		t.Fatalf("findToolCall(b) = %+v", got)
+	}
+	// 没有 ID 的结果归到最后一个尚无结果的调用
+	if got := findToolCall(calls, ""); got == nil || got.Name != "list_files" {
+		t.Fatalf("findToolCall(\"\") = %+v", got)
+	}
+	if got := findToolCall(calls, "missing"); got != nil {
+		t.Fatalf("findToolCall(missing) = %+v, want nil", got)
+	}
+}
+
+func TestPayloadString(t *testing.T) {
+	if got := payloadString(map[string]any{"text": "hi", "n": 1}, "text"); got != "hi" {
+		t.Fatalf("payloadString = %q", got)
+	}
+	if got := payloadString(map[string]any{"n": 1}, "n"); got != "" {
+		t.Fatalf("non-string value = %q, want empty", got)
+	}
+	if got := payloadString("plain", "text"); got != "" {
+		t.Fatalf("non-map payload = %q, want empty", got)
+	}
+}
@@ -7,22 +7,32 @@ import (
 	"sync"
 	"time"
 
+	"platform/internal/buildinfo"
+	"platform/internal/hostport"
+	"platform/internal/quota"
+	"platform/internal/sandbox"
+
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
 	"github.com/google/uuid"
 )
 
 // CompanionService Agent 创建时附加的伴随服务容器
 type CompanionService struct {
-	ID          string    `json:"service_id"`
-	Name        string    `json:"name"`
-	Image       string    `json:"image"`
-	ContainerID string    `json:"container_id"`
-	IP          string    `json:"ip"`
-	SessionID   string    `json:"session_id"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          string        `json:"service_id"`
+	Name        string        `json:"name"`
+	Image       string        `json:"image"`
+	ContainerID string        `json:"container_id"`
+	IP          string        `json:"ip"`
+	SessionID   string        `json:"session_id,omitempty"` // 项目级服务为空
+	ProjectID   string        `json:"project_id,omitempty"` // 仅项目级服务
+	Scope       string        `json:"scope"`
+	RefCount    int           `json:"ref_count,omitempty"` // 项目级服务当前被多少个 session 引用
+	Status      string        `json:"status"`
+	Ports       []PortMapping `json:"ports,omitempty"` // 发布到宿主机的端口
+	CreatedAt   time.Time     `json:"created_at"`
 }
 
 // CompanionManager 追踪和管理每个会话的伴随服务容器
@@ -32,11 +42,58 @@ type CompanionManager struct {
 	docker   *client.Client
 	logger   *slog.Logger
 	network  string // Docker network name for agent containers
+
+	// projectMu 串行化项目级服务的创建与引用变更，避免并发 session 重复创建同名服务
+	projectMu sync.Mutex
+	shared    map[string]*projectService // projectID/name -> service
+
+	// Ports 宿主机端口分配器，为 nil 时不支持 expose_ports
+	Ports *hostport.Allocator
+	// CgroupParent 伴随服务容器与沙箱共享的 cgroup parent，为空时使用 daemon 默认
+	CgroupParent string
+	// Quota 服务数量、内存和 CPU 配额，nil 时不限制
+	Quota *quota.Tracker
+	// Refs 项目级服务引用的持久化存储，nil 时引用只保存在内存中，重启后丢失
+	Refs ProjectRefStore
+}
+
+const (
+	defaultCompanionMemoryMB = 512
+	defaultCompanionCPUs     = 0.5
+)
+
+// companionAllocationID 伴随服务在配额记录中的 ID
+func companionAllocationID(serviceID string) string {
+	return "companion:" + serviceID
+}
+
+// resources 返回请求的内存（字节）和 CPU（NanoCPUs），未指定时使用默认值
+func (req CreateServiceRequest) resources() (int64, int64) {
+	memoryMB, cpus := req.MemoryMB, req.CPUs
+	if memoryMB <= 0 {
+		memoryMB = defaultCompanionMemoryMB
+	}
+	if cpus <= 0 {
+		cpus = defaultCompanionCPUs
+	}
+	return int64(memoryMB) * 1024 * 1024, int64(cpus * 1e9)
+}
+
+// reserve 登记伴随服务的配额占用，项目级服务的 sessionID 为空，只计入租户
+func (m *CompanionManager) reserve(ctx context.Context, serviceID, sessionID string, req CreateServiceRequest) error {
+	memory, nanoCPUs := req.resources()
+	return m.Quota.Reserve(ctx, &quota.Allocation{
+		ID:        companionAllocationID(serviceID),
+		SessionID: sessionID,
+		TenantID:  req.Tenant,
+		Usage:     quota.Usage{Services: 1, MemoryBytes: memory, NanoCPUs: nanoCPUs},
+	})
 }
 
 func NewCompanionManager(docker *client.Client, networkName string, logger *slog.Logger) *CompanionManager {
 	return &CompanionManager{
 		services: make(map[string][]*CompanionService),
+		shared:   make(map[string]*projectService),
 		docker:   docker,
 		logger:   logger,
 		network:  networkName,
@@ -54,27 +111,78 @@ func (m *CompanionManager) CreateService(ctx context.Context, sessionID string,
 		"container_name", containerName,
 	)
 
+	if err := m.reserve(ctx, serviceID, sessionID, req); err != nil {
+		return nil, err
+	}
+	labels := map[string]string{sandbox.LabelSessionID: sessionID}
+	if req.Tenant != "" {
+		labels[sandbox.LabelTenant] = req.Tenant
+	}
+	svc, err := m.startService(ctx, serviceID, containerName, sessionID, labels, req)
+	if err != nil {
+		m.Quota.Release(context.Background(), companionAllocationID(serviceID))
+		return nil, err
+	}
+	svc.SessionID = sessionID
+	svc.Scope = ScopeSession
+
+	m.mu.Lock()
+	m.services[sessionID] = append(m.services[sessionID], svc)
+	m.mu.Unlock()
+
+	return svc, nil
+}
+
+// startService 创建并启动伴随服务容器。portScope 为宿主机端口分配记录所属的范围
+// （session ID 或 project:<id>），释放端口时使用同一范围。
+func (m *CompanionManager) startService(ctx context.Context, serviceID, containerName, portScope string, labels map[string]string, req CreateServiceRequest) (*CompanionService, error) {
 	config := &container.Config{
 		Image: req.Image,
 		Env:   req.EnvVars,
 		Labels: map[string]string{
-			"managed_by":   "agent-platform",
-			"service_type": "companion",
-			"session_id":   sessionID,
-			"service_name": req.Name,
-			"service_id":   serviceID,
+			sandbox.LabelManagedBy: sandbox.ManagedByValue,
+			sandbox.LabelRunID:     sandbox.RunID,
+			sandbox.LabelVersion:   buildinfo.Version,
+			"service_type":         "companion",
+			"service_name":         req.Name,
+			"service_id":           serviceID,
 		},
 	}
+	for k, v := range labels {
+		config.Labels[k] = v
+	}
 	if len(req.Cmd) > 0 {
 		config.Cmd = req.Cmd
 	}
 
+	memory, nanoCPUs := req.resources()
 	hostConfig := &container.HostConfig{
 		Resources: container.Resources{
-			Memory:   512 * 1024 * 1024, // 512MB
-			NanoCPUs: int64(0.5 * 1e9),  // 0.5 CPU
+			Memory:       memory,
+			NanoCPUs:     nanoCPUs,
+			CgroupParent: m.CgroupParent,
 		},
 		AutoRemove: false,
+		// 使用卷驱动的网络存储挂载失败时 ContainerStart 返回错误
+		Mounts: sandbox.DockerMounts(req.Mounts),
+	}
+
+	owner := hostport.Owner("companion", serviceID, "")
+	mappings, err := allocatePorts(ctx, m.Ports, portScope, owner, req.ExposePorts)
+	if err != nil {
+		return nil, err
+	}
+	if len(mappings) > 0 {
+		config.ExposedPorts = nat.PortSet{}
+		hostConfig.PortBindings = nat.PortMap{}
+		for _, pm := range mappings {
+			port := nat.Port(fmt.Sprintf("%d/tcp", pm.ContainerPort))
+			config.ExposedPorts[port] = struct{}{}
+			hostConfig.PortBindings[port] = []nat.PortBinding{{HostPort: fmt.Sprint(pm.HostPort)}}
+		}
+	}
+	releasePorts := func() {
+		releasePortOwner(context.Background(), m.Ports, portScope, owner, m.logger)
 	}
 
 	netConfig := &network.NetworkingConfig{
@@ -85,11 +193,13 @@ func (m *CompanionManager) CreateService(ctx context.Context, sessionID string,
 
 	resp, err := m.docker.ContainerCreate(ctx, config, hostConfig, netConfig, nil, containerName)
 	if err != nil {
+		releasePorts()
 		return nil, fmt.Errorf("failed to create companion container: %w", err)
 	}
 
 	if err := m.docker.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
 		_ = m.docker.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
+		releasePorts()
 		return nil, fmt.Errorf("failed to start companion container: %w", err)
 	}
 
@@ -109,26 +219,39 @@ func (m *CompanionManager) CreateService(ctx context.Context, sessionID string,
 		Image:       req.Image,
 		ContainerID: resp.ID,
 		IP:          ip,
-		SessionID:   sessionID,
 		Status:      "running",
+		Ports:       mappings,
 		CreatedAt:   time.Now(),
 	}
 
-	m.mu.Lock()
-	m.services[sessionID] = append(m.services[sessionID], svc)
-	m.mu.Unlock()
-
 	m.logger.Info("Companion service created",
 		"service_id", serviceID,
 		"name", req.Name,
 		"container_id", resp.ID,
 		"ip", ip,
+		"ports", len(mappings),
 	)
 
 	return svc, nil
 }
 
+// removeContainer 停止并删除伴随服务容器
+func (m *CompanionManager) removeContainer(ctx context.Context, containerID string) {
+	timeout := 5
+	if err := m.docker.ContainerStop(ctx, containerID, container.StopOptions{Timeout: &timeout}); err != nil {
+		m.logger.Warn("Failed to stop companion container", "container_id", containerID, "error", err)
+	}
+	if err := m.docker.ContainerRemove(ctx, containerID, container.RemoveOptions{Force: true}); err != nil {
+		m.logger.Warn("Failed to remove companion container", "container_id", containerID, "error", err)
+	}
+}
+
 func (m *CompanionManager) RemoveService(ctx context.Context, sessionID, serviceID string) error {
+	// 项目级服务只解除当前 session 的引用，最后一个引用释放时才删除容器
+	if m.detachProjectService(ctx, sessionID, serviceID) {
+		return nil
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -144,6 +267,8 @@ func (m *CompanionManager) RemoveService(ctx context.Context, sessionID, service
 			_ = m.docker.ContainerRemove(ctx, svc.ContainerID, container.RemoveOptions{Force: true})
 
 			m.services[sessionID] = append(services[:i], services[i+1:]...)
+			releasePortOwner(ctx, m.Ports, sessionID, hostport.Owner("companion", serviceID, ""), m.logger)
+			m.Quota.Release(ctx, companionAllocationID(serviceID))
 
 			m.logger.Info("Companion service removed",
 				"service_id", serviceID,
@@ -158,6 +283,10 @@ func (m *CompanionManager) RemoveService(ctx context.Context, sessionID, service
 }
 
 func (m *CompanionManager) CleanupSession(ctx context.Context, sessionID string) {
+	for _, svc := range m.ListProjectServices(sessionID) {
+		m.detachProjectService(ctx, sessionID, svc.ID)
+	}
+
 	m.mu.Lock()
 	services, ok := m.services[sessionID]
 	if !ok {
@@ -168,30 +297,44 @@ func (m *CompanionManager) CleanupSession(ctx context.Context, sessionID string)
 	m.mu.Unlock()
 
 	for _, svc := range services {
-		timeout := 5
-		stopErr := m.docker.ContainerStop(ctx, svc.ContainerID, container.StopOptions{Timeout: &timeout})
-		if stopErr != nil {
-			m.logger.Warn("Failed to stop companion container", "container_id", svc.ContainerID, "error", stopErr)
-		}
-		rmErr := m.docker.ContainerRemove(ctx, svc.ContainerID, container.RemoveOptions{Force: true})
-		if rmErr != nil {
-			m.logger.Warn("Failed to remove companion container", "container_id", svc.ContainerID, "error", rmErr)
-		}
+		m.removeContainer(ctx, svc.ContainerID)
+		releasePortOwner(ctx, m.Ports, sessionID, hostport.Owner("companion", svc.ID, ""), m.logger)
+		m.Quota.Release(ctx, companionAllocationID(svc.ID))
 	}
 
 	m.logger.Info("Cleaned up all companion services for session", "session_id", sessionID, "count", len(services))
 }
 
+// ListServices 返回 session 自己的服务以及它引用的项目级服务
 func (m *CompanionManager) ListServices(sessionID string) []*CompanionService {
 	m.mu.RLock()
-	defer m.mu.RUnlock()
+	services := append([]*CompanionService(nil), m.services[sessionID]...)
+	m.mu.RUnlock()
 
-	return m.services[sessionID]
+	return append(services, m.ListProjectServices(sessionID)...)
 }
 
+const (
+	// ScopeSession 服务归属单个 session，随 session 终止删除（默认）
+	ScopeSession = "session"
+	// ScopeProject 服务归属项目，由同一项目的多个 session 共享，最后一个引用释放时删除
+	ScopeProject = "project"
+)
+
 type CreateServiceRequest struct {
 	Name    string   `json:"name"`
 	Image   string   `json:"image"`
 	EnvVars []string `json:"env_vars"`
 	Cmd     []string `json:"cmd"`
+	// ExposePorts 需要从 Docker 外部访问的容器端口（TCP），平台分配宿主机端口并发布
+	ExposePorts []int `json:"expose_ports"`
+	// Scope 服务范围：session（默认）或 project
+	Scope string `json:"scope"`
+	// MemoryMB / CPUs 容器资源上限，未指定时为 512MB / 0.5 CPU，计入配额
+	MemoryMB int     `json:"memory_mb"`
+	CPUs     float64 `json:"cpus"`
+	// Mounts 数据目录等额外挂载，可通过 driver 挂载 NFS / CIFS 等网络存储，与 session 的 mounts 规则相同
+	Mounts []sandbox.MountSpec `json:"mounts"`
+	// Tenant 配额统计的租户（session 所属用户），由 Service 填充
+	Tenant string `json:"-"`
 }
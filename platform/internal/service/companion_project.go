@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"platform/internal/hostport"
+	"platform/internal/sandbox"
+
+	"github.com/google/uuid"
+)
+
+// projectService 项目级伴随服务及引用它的 session
+type projectService struct {
+	svc      *CompanionService
+	sessions map[string]bool
+}
+
+func projectServiceKey(projectID, name string) string {
+	return projectID + "/" + name
+}
+
+// projectPortScope 项目级服务宿主机端口分配记录的范围，不随单个 session 终止释放
+func projectPortScope(projectID string) string {
+	return "project:" + projectID
+}
+
+// AttachProjectService 让 session 引用项目级服务，同名服务不存在时创建。
+// 同一项目的多个 session 因此可以连接同一个数据库协作。
+func (m *CompanionManager) AttachProjectService(ctx context.Context, projectID, sessionID string, req CreateServiceRequest) (*CompanionService, error) {
+	m.projectMu.Lock()
+	defer m.projectMu.Unlock()
+
+	key := projectServiceKey(projectID, req.Name)
+	m.mu.Lock()
+	if ps, ok := m.shared[key]; ok {
+		defer m.mu.Unlock()
+		if ps.svc.Image != req.Image {
+			return nil, fmt.Errorf("project service %q already exists with image %s", req.Name, ps.svc.Image)
+		}
+		ps.sessions[sessionID] = true
+		ps.svc.RefCount = len(ps.sessions)
+		m.saveRef(ctx, ps.svc.ID, sessionID)
+
+		m.logger.Info("Attached session to project service",
+			"project_id", projectID,
+			"session_id", sessionID,
+			"service_id", ps.svc.ID,
+			"refs", ps.svc.RefCount,
+		)
+		return ps.svc, nil
+	}
+	m.mu.Unlock()
+
+	serviceID := uuid.New().String()[:8]
+	containerName := fmt.Sprintf("svc-proj-%s-%s", req.Name, serviceID)
+
+	m.logger.Info("Creating project companion service",
+		"project_id", projectID,
+		"session_id", sessionID,
+		"name", req.Name,
+		"image", req.Image,
+		"container_name", containerName,
+	)
+
+	if err := m.reserve(ctx, serviceID, "", req); err != nil {
+		return nil, err
+	}
+	svc, err := m.startService(ctx, serviceID, containerName, projectPortScope(projectID), map[string]string{
+		sandbox.LabelProjectID: projectID,
+		"service_scope":        ScopeProject,
+	}, req)
+	if err != nil {
+		m.Quota.Release(context.Background(), companionAllocationID(serviceID))
+		return nil, err
+	}
+	svc.ProjectID = projectID
+	svc.Scope = ScopeProject
+	svc.RefCount = 1
+
+	m.mu.Lock()
+	m.shared[key] = &projectService{svc: svc, sessions: map[string]bool{sessionID: true}}
+	m.mu.Unlock()
+	m.saveRef(ctx, serviceID, sessionID)
+
+	return svc, nil
+}
+
+// detachProjectService 解除 session 对项目级服务的引用，最后一个引用解除时删除容器并释放端口。
+// serviceID 不是该 session 引用的项目级服务时返回 false。
+func (m *CompanionManager) detachProjectService(ctx context.Context, sessionID, serviceID string) bool {
+	m.projectMu.Lock()
+	defer m.projectMu.Unlock()
+
+	m.mu.Lock()
+	var key string
+	var ps *projectService
+	for k, candidate := range m.shared {
+		if candidate.svc.ID == serviceID && candidate.sessions[sessionID] {
+			key, ps = k, candidate
+			break
+		}
+	}
+	if ps == nil {
+		m.mu.Unlock()
+		return false
+	}
+	delete(ps.sessions, sessionID)
+	ps.svc.RefCount = len(ps.sessions)
+	last := len(ps.sessions) == 0
+	if last {
+		delete(m.shared, key)
+	}
+	m.mu.Unlock()
+	m.dropRef(ctx, serviceID, sessionID)
+
+	if !last {
+		m.logger.Info("Detached session from project service",
+			"project_id", ps.svc.ProjectID,
+			"session_id", sessionID,
+			"service_id", serviceID,
+			"refs", ps.svc.RefCount,
+		)
+		return true
+	}
+
+	m.removeContainer(ctx, ps.svc.ContainerID)
+	releasePortOwner(ctx, m.Ports, projectPortScope(ps.svc.ProjectID), hostport.Owner("companion", serviceID, ""), m.logger)
+	m.Quota.Release(ctx, companionAllocationID(serviceID))
+
+	m.logger.Info("Project companion service removed after last reference",
+		"project_id", ps.svc.ProjectID,
+		"service_id", serviceID,
+		"name", ps.svc.Name,
+	)
+	return true
+}
+
+// ListProjectServices 返回 session 引用的项目级服务
+func (m *CompanionManager) ListProjectServices(sessionID string) []*CompanionService {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var services []*CompanionService
+	for _, ps := range m.shared {
+		if ps.sessions[sessionID] {
+			services = append(services, ps.svc)
+		}
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+	return services
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"platform/internal/sandbox"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestProjectServiceReferences(t *testing.T) {
+	m := NewCompanionManager(nil, "test-net", slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	db := &CompanionService{ID: "db1", Name: "postgres", Image: "postgres:16", ProjectID: "proj", Scope: ScopeProject, RefCount: 2}
+	m.shared[projectServiceKey("proj", "postgres")] = &projectService{
+		svc:      db,
+		sessions: map[string]bool{"s1": true, "s2": true},
+	}
+
+	// 同名不同镜像的项目级服务视为冲突，不会创建第二个容器
+	_, err := m.AttachProjectService(context.Background(), "proj", "s3", CreateServiceRequest{Name: "postgres", Image: "postgres:15"})
+	if err == nil {
+		t.Fatal("Expected conflict for mismatched image")
+	}
+
+	svc, err := m.AttachProjectService(context.Background(), "proj", "s3", CreateServiceRequest{Name: "postgres", Image: "postgres:16"})
+	if err != nil || svc != db || db.RefCount != 3 {
+		t.Fatalf("Expected existing service to be shared, got %+v, %v", svc, err)
+	}
+
+	if got := m.ListServices("s1"); len(got) != 1 || got[0] != db {
+		t.Errorf("Expected session to list project service, got %v", got)
+	}
+	if got := m.ListServices("other"); len(got) != 0 {
+		t.Errorf("Unrelated session should not see project service, got %v", got)
+	}
+
+	if m.detachProjectService(context.Background(), "other", "db1") {
+		t.Error("Detaching an unreferenced session should be a no-op")
+	}
+	if !m.detachProjectService(context.Background(), "s1", "db1") || db.RefCount != 2 {
+		t.Errorf("Expected ref count 2 after detach, got %d", db.RefCount)
+	}
+	if _, ok := m.shared[projectServiceKey("proj", "postgres")]; !ok {
+		t.Error("Project service should remain while referenced")
+	}
+}
+
+// memRefs 内存中的 ProjectRefStore
+type memRefs map[string]map[string]bool
+
+func (r memRefs) Add(_ context.Context, serviceID, sessionID string) error {
+	if r[serviceID] == nil {
+		r[serviceID] = map[string]bool{}
+	}
+	r[serviceID][sessionID] = true
+	return nil
+}
+
+func (r memRefs) Remove(_ context.Context, serviceID, sessionID string) error {
+	delete(r[serviceID], sessionID)
+	if len(r[serviceID]) == 0 {
+		delete(r, serviceID)
+	}
+	return nil
+}
+
+func (r memRefs) Sessions(_ context.Context, serviceID string) ([]string, error) {
+	var ids []string
+	for id := range r[serviceID] {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func TestProjectServiceRefsSurviveRestart(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	refs := memRefs{}
+	m := NewCompanionManager(nil, "test-net", logger)
+	m.Refs = refs
+	m.shared[projectServiceKey("proj", "postgres")] = &projectService{
+		svc:      &CompanionService{ID: "db1", Name: "postgres", Image: "postgres:16", ProjectID: "proj", Scope: ScopeProject, RefCount: 1},
+		sessions: map[string]bool{"s1": true},
+	}
+	refs.Add(ctx, "db1", "s1")
+
+	if _, err := m.AttachProjectService(ctx, "proj", "s2", CreateServiceRequest{Name: "postgres", Image: "postgres:16"}); err != nil {
+		t.Fatal(err)
+	}
+	if !m.detachProjectService(ctx, "s1", "db1") {
+		t.Fatal("Expected s1 to be detached")
+	}
+
+	// 模拟 API 重启：新的 manager 从容器标签和持久化的引用恢复
+	c := container.Summary{
+		ID:      "cid",
+		Image:   "postgres:16",
+		State:   "running",
+		Created: 1700000000,
+		Labels: map[string]string{
+			sandbox.LabelManagedBy: sandbox.ManagedByValue,
+			sandbox.LabelProjectID: "proj",
+			"service_scope":        ScopeProject,
+			"service_name":         "postgres",
+			"service_id":           "db1",
+		},
+		Ports: []container.Port{
+			{PrivatePort: 5432, PublicPort: 40001, Type: "tcp", IP: "0.0.0.0"},
+			{PrivatePort: 5432, PublicPort: 40001, Type: "tcp", IP: "::"},
+		},
+	}
+	restored := NewCompanionManager(nil, "test-net", logger)
+	restored.Refs = refs
+	svc := projectServiceFromContainer(c, "test-net")
+	if svc == nil {
+		t.Fatal("Expected project service from container labels")
+	}
+	sessions, _ := refs.Sessions(ctx, svc.ID)
+	restored.restoreProjectService(svc, sessions)
+
+	got := restored.ListServices("s2")
+	if len(got) != 1 || got[0].ID != "db1" || got[0].RefCount != 1 || got[0].ContainerID != "cid" {
+		t.Fatalf("Expected restored service referenced by s2, got %+v", got)
+	}
+	if len(got[0].Ports) != 1 || got[0].Ports[0] != (PortMapping{ContainerPort: 5432, HostPort: 40001}) {
+		t.Errorf("Unexpected restored ports %+v", got[0].Ports)
+	}
+	if len(restored.ListServices("s1")) != 0 {
+		t.Error("Detached session should not reference the restored service")
+	}
+}
+
+func TestProjectServiceFromContainerRequiresLabels(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+	}{
+		{"missing id", map[string]string{"service_name": "postgres", sandbox.LabelProjectID: "proj"}},
+		{"missing name", map[string]string{"service_id": "db1", sandbox.LabelProjectID: "proj"}},
+		{"missing project", map[string]string{"service_id": "db1", "service_name": "postgres"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if svc := projectServiceFromContainer(container.Summary{Labels: tt.labels}, "net"); svc != nil {
+				t.Errorf("Expected nil, got %+v", svc)
+			}
+		})
+	}
+}
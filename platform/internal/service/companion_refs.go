@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"platform/internal/hostport"
+	"platform/internal/sandbox"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/redis/go-redis/v9"
+)
+
+// ProjectRefStore 持久化项目级伴随服务被哪些 session 引用。服务本身的信息在容器标签上，
+// API 重启后 RestoreProjectServices 据此恢复引用计数
+type ProjectRefStore interface {
+	Add(ctx context.Context, serviceID, sessionID string) error
+	Remove(ctx context.Context, serviceID, sessionID string) error
+	Sessions(ctx context.Context, serviceID string) ([]string, error)
+}
+
+const projectRefsKeyPrefix = "companion:project_refs:"
+
+// RedisProjectRefs 以 Redis 集合保存每个项目级服务的引用 session，最后一个成员移除后集合随之删除
+type RedisProjectRefs struct {
+	redis redis.Cmdable
+}
+
+func NewRedisProjectRefs(redis redis.Cmdable) *RedisProjectRefs {
+	return &RedisProjectRefs{redis: redis}
+}
+
+func (r *RedisProjectRefs) Add(ctx context.Context, serviceID, sessionID string) error {
+	return r.redis.SAdd(ctx, projectRefsKeyPrefix+serviceID, sessionID).Err()
+}
+
+func (r *RedisProjectRefs) Remove(ctx context.Context, serviceID, sessionID string) error {
+	return r.redis.SRem(ctx, projectRefsKeyPrefix+serviceID, sessionID).Err()
+}
+
+func (r *RedisProjectRefs) Sessions(ctx context.Context, serviceID string) ([]string, error) {
+	return r.redis.SMembers(ctx, projectRefsKeyPrefix+serviceID).Result()
+}
+
+// saveRef 记录引用，失败只记录日志：内存中的引用仍然有效，只是重启后无法恢复
+func (m *CompanionManager) saveRef(ctx context.Context, serviceID, sessionID string) {
+	if m.Refs == nil {
+		return
+	}
+	if err := m.Refs.Add(ctx, serviceID, sessionID); err != nil {
+		m.logger.Warn("Failed to persist project service reference", "service_id", serviceID, "session_id", sessionID, "error", err)
+	}
+}
+
+func (m *CompanionManager) dropRef(ctx context.Context, serviceID, sessionID string) {
+	if m.Refs == nil {
+		return
+	}
+	if err := m.Refs.Remove(ctx, serviceID, sessionID); err != nil {
+		m.logger.Warn("Failed to remove project service reference", "service_id", serviceID, "session_id", sessionID, "error", err)
+	}
+}
+
+// RestoreProjectServices 启动时按容器标签找回项目级服务，并从 Refs 恢复引用它们的 session。
+// 没有任何引用的容器是引用全部解除前 API 退出留下的，直接删除并释放端口和配额
+func (m *CompanionManager) RestoreProjectServices(ctx context.Context) error {
+	if m.Refs == nil {
+		return nil
+	}
+	containers, err := sandbox.ListManaged(ctx, m.docker, sandbox.LabelQuery{"service_scope": ScopeProject}, true)
+	if err != nil {
+		return err
+	}
+
+	m.projectMu.Lock()
+	defer m.projectMu.Unlock()
+
+	for _, c := range containers {
+		svc := projectServiceFromContainer(c, m.network)
+		if svc == nil {
+			continue
+		}
+		sessions, err := m.Refs.Sessions(ctx, svc.ID)
+		if err != nil {
+			m.logger.Warn("Failed to load project service references", "service_id", svc.ID, "error", err)
+			continue
+		}
+		if len(sessions) == 0 {
+			m.removeContainer(ctx, svc.ContainerID)
+			releasePortOwner(ctx, m.Ports, projectPortScope(svc.ProjectID), hostport.Owner("companion", svc.ID, ""), m.logger)
+			m.Quota.Release(ctx, companionAllocationID(svc.ID))
+			m.logger.Info("Removed unreferenced project companion service", "project_id", svc.ProjectID, "service_id", svc.ID)
+			continue
+		}
+		m.restoreProjectService(svc, sessions)
+	}
+	return nil
+}
+
+// restoreProjectService 登记恢复出的项目级服务及其引用，调用时持有 m.projectMu
+func (m *CompanionManager) restoreProjectService(svc *CompanionService, sessions []string) {
+	ps := &projectService{svc: svc, sessions: make(map[string]bool, len(sessions))}
+	for _, id := range sessions {
+		ps.sessions[id] = true
+	}
+	svc.RefCount = len(ps.sessions)
+
+	m.mu.Lock()
+	m.shared[projectServiceKey(svc.ProjectID, svc.Name)] = ps
+	m.mu.Unlock()
+
+	m.logger.Info("Restored project companion service",
+		"project_id", svc.ProjectID,
+		"service_id", svc.ID,
+		"name", svc.Name,
+		"refs", svc.RefCount,
+	)
+}
+
+// projectServiceFromContainer 由容器标签和列表信息还原项目级服务，标签不完整时返回 nil
+func projectServiceFromContainer(c container.Summary, network string) *CompanionService {
+	id, name, projectID := c.Labels["service_id"], c.Labels["service_name"], c.Labels[sandbox.LabelProjectID]
+	if id == "" || name == "" || projectID == "" {
+		return nil
+	}
+
+	ip := ""
+	if c.NetworkSettings != nil {
+		if netInfo, ok := c.NetworkSettings.Networks[network]; ok && netInfo != nil {
+			ip = netInfo.IPAddress
+		}
+	}
+	// 同一端口在 IPv4 和 IPv6 上各列出一次
+	var ports []PortMapping
+	seen := make(map[uint16]bool)
+	for _, p := range c.Ports {
+		if p.PublicPort != 0 && strings.EqualFold(p.Type, "tcp") && !seen[p.PrivatePort] {
+			seen[p.PrivatePort] = true
+			ports = append(ports, PortMapping{ContainerPort: int(p.PrivatePort), HostPort: int(p.PublicPort)})
+		}
+	}
+	status := "running"
+	if c.State != "running" {
+		status = c.State
+	}
+
+	return &CompanionService{
+		ID:          id,
+		Name:        name,
+		Image:       c.Image,
+		ContainerID: c.ID,
+		IP:          ip,
+		ProjectID:   projectID,
+		Scope:       ScopeProject,
+		Status:      status,
+		Ports:       ports,
+		CreatedAt:   time.Unix(c.Created, 0),
+	}
+}
@@ -3,15 +3,23 @@ package service
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"platform/internal/hostport"
+	"platform/internal/quota"
+
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
@@ -22,19 +30,32 @@ import (
 // ───────────────────────────────────────────────────────────────────────
 
 type ComposeStack struct {
-	SessionID   string           `json:"session_id"`
-	ProjectName string           `json:"project_name"` // docker compose -p <name>
-	ComposeFile string           `json:"compose_file"` // 宿主机上的 compose 文件路径
-	Services    []ComposeService `json:"services"`
-	Status      string           `json:"status"` // "running", "stopped", "error"
-	CreatedAt   time.Time        `json:"created_at"`
+	SessionID   string `json:"session_id"`
+	Name        string `json:"name"`
+	ProjectName string `json:"project_name"` // docker compose -p <name>
+	ComposeFile string `json:"compose_file"` // 宿主机上的 compose 文件路径
+	// PortsFile 发布宿主机端口的 override 文件，未请求 expose_ports 时为空
+	PortsFile string           `json:"ports_file,omitempty"`
+	Services  []ComposeService `json:"services"`
+	Status    string           `json:"status"` // "running", "stopped", "error"
+	CreatedAt time.Time        `json:"created_at"`
 }
 
 type ComposeService struct {
-	Name        string `json:"name"`
-	ContainerID string `json:"container_id"`
-	IP          string `json:"ip"`
-	Status      string `json:"status"`
+	Name        string        `json:"name"`
+	Replica     int           `json:"replica,omitempty"` // 同一服务扩容后的副本序号，从 1 开始
+	ContainerID string        `json:"container_id"`
+	IP          string        `json:"ip"`
+	Status      string        `json:"status"`
+	Ports       []PortMapping `json:"ports,omitempty"` // 发布到宿主机的端口
+}
+
+// files 返回 docker compose -f 使用的文件列表
+func (s *ComposeStack) files() []string {
+	if s.PortsFile == "" {
+		return []string{s.ComposeFile}
+	}
+	return []string{s.ComposeFile, s.PortsFile}
 }
 
 // ───────────────────────────────────────────────────────────────────────
@@ -43,13 +64,32 @@ type ComposeService struct {
 // docker socket 访问权限，可以直接调用 docker compose CLI。
 // ───────────────────────────────────────────────────────────────────────
 
+// DefaultStackName 未指定名称的 compose 接口（/sessions/:id/compose）操作的堆栈
+const DefaultStackName = "default"
+
+// stackNamePattern 堆栈名会拼入 compose project 名，需满足 compose 的命名规则
+var stackNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// ValidateStackName 校验堆栈名；"services" 与 /compose/services/... 路由冲突，保留不用
+func ValidateStackName(name string) error {
+	if !stackNamePattern.MatchString(name) || name == "services" {
+		return fmt.Errorf("invalid stack name %q: must match %s and not be \"services\"", name, stackNamePattern)
+	}
+	return nil
+}
+
 type ComposeManager struct {
 	mu      sync.RWMutex
-	stacks  map[string]*ComposeStack // sessionID -> stack
+	stacks  map[string]map[string]*ComposeStack // sessionID -> stack name -> stack
 	docker  *client.Client
 	network string // 共享 Docker 网络名称
 	dataDir string // 存放 compose 文件的根目录
 	logger  *slog.Logger
+
+	// Ports 宿主机端口分配器，为 nil 时不支持 expose_ports
+	Ports *hostport.Allocator
+	// Quota 服务数量、内存和 CPU 配额，nil 时不限制
+	Quota *quota.Tracker
 }
 
 func NewComposeManager(docker *client.Client, networkName string, dataDir string, logger *slog.Logger) *ComposeManager {
@@ -60,7 +100,7 @@ func NewComposeManager(docker *client.Client, networkName string, dataDir string
 	_ = os.MkdirAll(dataDir, 0755)
 
 	return &ComposeManager{
-		stacks:  make(map[string]*ComposeStack),
+		stacks:  make(map[string]map[string]*ComposeStack),
 		docker:  docker,
 		network: networkName,
 		dataDir: dataDir,
@@ -71,6 +111,7 @@ func NewComposeManager(docker *client.Client, networkName string, dataDir string
 // ───────────────────────────────────────────────────────────────────────
 // CreateStack 接收一个 docker-compose.yml 内容字符串，
 // 写入临时目录后通过 CLI 启动。所有服务会被连接到平台共享网络。
+// 同一 session 可以有多个命名堆栈，各自使用独立的 compose project。
 // ───────────────────────────────────────────────────────────────────────
 
 type CreateComposeRequest struct {
@@ -78,61 +119,58 @@ type CreateComposeRequest struct {
 	ComposeContent string `json:"compose_content"`
 	// ComposeFile 是宿主机上已存在的 compose 文件路径（与 ComposeContent 二选一）
 	ComposeFile string `json:"compose_file"`
+	// ExposePorts 服务名 -> 需要从 Docker 外部访问的容器端口（TCP），
+	// 平台分配宿主机端口并通过 override 文件发布
+	ExposePorts map[string][]int `json:"expose_ports"`
+	// Tenant 配额统计的租户（session 所属用户），由 Service 填充
+	Tenant string `json:"-"`
 }
 
-func (m *ComposeManager) CreateStack(ctx context.Context, sessionID string, req CreateComposeRequest) (*ComposeStack, error) {
-	m.mu.Lock()
-	if _, exists := m.stacks[sessionID]; exists {
-		m.mu.Unlock()
-		return nil, fmt.Errorf("session %s already has a compose stack; tear it down first", sessionID)
+func (m *ComposeManager) CreateStack(ctx context.Context, sessionID, name string, req CreateComposeRequest) (*ComposeStack, error) {
+	if err := ValidateStackName(name); err != nil {
+		return nil, err
+	}
+	if _, exists := m.getStack(sessionID, name); exists {
+		return nil, fmt.Errorf("session %s already has a compose stack named %q; update it with PUT or tear it down first", sessionID, name)
 	}
-	m.mu.Unlock()
 
-	projectName := fmt.Sprintf("agent-%s", sessionID[:8])
-	stackDir := filepath.Join(m.dataDir, sessionID)
+	projectName := composeProjectName(sessionID, name)
+	stackDir := m.stackDir(sessionID, name)
 	if err := os.MkdirAll(stackDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create stack directory: %w", err)
 	}
 
-	var composeFile string
-	if req.ComposeContent != "" {
-		// 注入/替换 network 配置，确保所有服务接入平台网络
-		content := m.injectNetwork(req.ComposeContent)
-		composeFile = filepath.Join(stackDir, "docker-compose.yml")
-		if err := os.WriteFile(composeFile, []byte(content), 0644); err != nil {
-			return nil, fmt.Errorf("failed to write compose file: %w", err)
-		}
-	} else if req.ComposeFile != "" {
-		// 使用用户指定的已有 compose 文件
-		absPath, err := filepath.Abs(req.ComposeFile)
-		if err != nil {
-			return nil, fmt.Errorf("invalid compose file path: %w", err)
-		}
-		if _, err := os.Stat(absPath); err != nil {
-			return nil, fmt.Errorf("compose file not found: %w", err)
-		}
-		// 复制到 stack 目录并注入网络
-		raw, err := os.ReadFile(absPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read compose file: %w", err)
-		}
-		content := m.injectNetwork(string(raw))
-		composeFile = filepath.Join(stackDir, "docker-compose.yml")
-		if err := os.WriteFile(composeFile, []byte(content), 0644); err != nil {
-			return nil, fmt.Errorf("failed to write compose file: %w", err)
-		}
-	} else {
-		return nil, fmt.Errorf("either compose_content or compose_file must be provided")
+	composeFile, err := m.writeComposeFile(stackDir, "docker-compose.yml", req)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{composeFile}
+	portsFile, _, err := m.writePortsFile(ctx, sessionID, name, "docker-compose.ports.yml", req.ExposePorts)
+	if err != nil {
+		releasePortOwner(context.Background(), m.Ports, sessionID, stackPortOwner(name), m.logger)
+		return nil, err
+	}
+	if portsFile != "" {
+		files = append(files, portsFile)
+	}
+
+	if err := m.reserveStack(ctx, sessionID, name, req.Tenant, files, nil); err != nil {
+		releasePortOwner(context.Background(), m.Ports, sessionID, stackPortOwner(name), m.logger)
+		return nil, err
 	}
 
 	m.logger.Info("Starting compose stack",
 		"session_id", sessionID,
+		"stack", name,
 		"project_name", projectName,
 		"compose_file", composeFile,
 	)
 
 	// docker compose -p <project> -f <file> up -d
-	if err := m.composeUp(ctx, projectName, composeFile); err != nil {
+	if err := m.composeUp(ctx, projectName, files...); err != nil {
+		releasePortOwner(context.Background(), m.Ports, sessionID, stackPortOwner(name), m.logger)
+		m.Quota.Release(context.Background(), composeAllocationID(sessionID, name))
 		return nil, fmt.Errorf("docker compose up failed: %w", err)
 	}
 
@@ -144,19 +182,25 @@ func (m *ComposeManager) CreateStack(ctx context.Context, sessionID string, req
 
 	stack := &ComposeStack{
 		SessionID:   sessionID,
+		Name:        name,
 		ProjectName: projectName,
 		ComposeFile: composeFile,
+		PortsFile:   portsFile,
 		Services:    services,
 		Status:      "running",
 		CreatedAt:   time.Now(),
 	}
 
 	m.mu.Lock()
-	m.stacks[sessionID] = stack
+	if m.stacks[sessionID] == nil {
+		m.stacks[sessionID] = make(map[string]*ComposeStack)
+	}
+	m.stacks[sessionID][name] = stack
 	m.mu.Unlock()
 
 	m.logger.Info("Compose stack created",
 		"session_id", sessionID,
+		"stack", name,
 		"project_name", projectName,
 		"services", len(services),
 	)
@@ -164,52 +208,399 @@ func (m *ComposeManager) CreateStack(ctx context.Context, sessionID string, req
 	return stack, nil
 }
 
+// ComposeUpdateResult 原地更新 compose 堆栈的结果
+type ComposeUpdateResult struct {
+	Stack     *ComposeStack `json:"stack"`
+	Added     []string      `json:"added"`
+	Removed   []string      `json:"removed"`
+	Changed   []string      `json:"changed"`
+	Unchanged []string      `json:"unchanged"`
+}
+
+// HasChanges 是否有服务被新增、删除或修改
+func (r *ComposeUpdateResult) HasChanges() bool {
+	return len(r.Added) > 0 || len(r.Removed) > 0 || len(r.Changed) > 0
+}
+
+// UpdateStack 将新的 compose 内容应用到已运行的堆栈：
+// 先用 docker compose config 规范化新旧文件并按服务对比，有变更时执行
+// up -d --remove-orphans，compose 只会重建配置变化的服务并移除已删除的服务。
+func (m *ComposeManager) UpdateStack(ctx context.Context, sessionID, name string, req CreateComposeRequest) (*ComposeUpdateResult, error) {
+	stack, ok := m.getStack(sessionID, name)
+	if !ok {
+		return nil, stackNotFound(sessionID, name)
+	}
+
+	nextFile, err := m.writeComposeFile(m.stackDir(sessionID, name), "docker-compose.next.yml", req)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(nextFile)
+
+	// 失败或无变化时回收本次新分配的端口，成功时回收不再使用的端口
+	keepOwners, err := m.stackPortOwners(ctx, sessionID, name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		releasePortOwner(context.Background(), m.Ports, sessionID, stackPortOwner(name), m.logger, keepOwners...)
+	}()
+
+	// 已有的端口映射按 (服务, 容器端口) 复用，只为新增的端口分配宿主机端口
+	nextPortsFile, owners, err := m.writePortsFile(ctx, sessionID, name, "docker-compose.ports.next.yml", req.ExposePorts)
+	if err != nil {
+		return nil, err
+	}
+	nextFiles := []string{nextFile}
+	if nextPortsFile != "" {
+		defer os.Remove(nextPortsFile)
+		nextFiles = append(nextFiles, nextPortsFile)
+	}
+
+	oldServices, err := m.composeConfig(ctx, stack.ProjectName, stack.files()...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load current compose config: %w", err)
+	}
+	newServices, err := m.composeConfig(ctx, stack.ProjectName, nextFiles...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid compose content: %w", err)
+	}
+
+	result := diffComposeServices(oldServices, newServices)
+	result.Stack = stack
+	if !result.HasChanges() {
+		m.logger.Info("Compose stack unchanged, skipping update", "session_id", sessionID, "stack", name)
+		return result, nil
+	}
+
+	if m.Quota != nil {
+		usage, err := composeUsage(newServices, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.Quota.Reserve(ctx, &quota.Allocation{
+			ID:        composeAllocationID(sessionID, name),
+			SessionID: sessionID,
+			TenantID:  req.Tenant,
+			Usage:     usage,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	m.logger.Info("Updating compose stack",
+		"session_id", sessionID,
+		"stack", name,
+		"project_name", stack.ProjectName,
+		"added", result.Added,
+		"removed", result.Removed,
+		"changed", result.Changed,
+	)
+
+	if err := m.composeApply(ctx, stack.ProjectName, nextFiles...); err != nil {
+		m.mu.Lock()
+		stack.Status = "error"
+		m.mu.Unlock()
+		// 部分服务可能已按新配置启动，配额记录保留新的占用，避免低估
+		return nil, fmt.Errorf("docker compose up failed: %w", err)
+	}
+
+	// 新文件成为堆栈的当前配置，后续 down 使用它
+	keepOwners = owners
+	if err := os.Rename(nextFile, stack.ComposeFile); err != nil {
+		return nil, fmt.Errorf("failed to replace compose file: %w", err)
+	}
+	portsFile := ""
+	if nextPortsFile != "" {
+		portsFile = filepath.Join(m.stackDir(sessionID, name), "docker-compose.ports.yml")
+		if err := os.Rename(nextPortsFile, portsFile); err != nil {
+			return nil, fmt.Errorf("failed to replace ports file: %w", err)
+		}
+	} else if stack.PortsFile != "" {
+		_ = os.Remove(stack.PortsFile)
+	}
+
+	services, err := m.inspectServices(ctx, stack.ProjectName)
+	if err != nil {
+		m.logger.Warn("Failed to inspect services after compose update", "error", err)
+	}
+
+	m.mu.Lock()
+	stack.PortsFile = portsFile
+	stack.Services = services
+	stack.Status = "running"
+	m.mu.Unlock()
+
+	return result, nil
+}
+
+// RestartService 重启堆栈中的单个服务（包括其所有副本）
+func (m *ComposeManager) RestartService(ctx context.Context, sessionID, stackName, name string) (*ComposeStack, error) {
+	stack, err := m.lookupService(ctx, sessionID, stackName, name)
+	if err != nil {
+		return nil, err
+	}
+
+	m.logger.Info("Restarting compose service", "session_id", sessionID, "stack", stackName, "service", name)
+	args := append([]string{"compose", "-p", stack.ProjectName}, composeFileArgs(stack.files())...)
+	if err := m.runDocker(ctx, append(args, "restart", name)); err != nil {
+		return nil, fmt.Errorf("docker compose restart failed: %w", err)
+	}
+
+	return m.RefreshServices(ctx, sessionID, stackName)
+}
+
+// ScaleService 将服务调整为 replicas 个副本，不影响其他服务。
+// 固定了 container_name 或宿主机端口的服务无法扩容到多个副本，compose 会返回错误。
+func (m *ComposeManager) ScaleService(ctx context.Context, sessionID, stackName, name string, replicas int) (*ComposeStack, error) {
+	stack, err := m.lookupService(ctx, sessionID, stackName, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.reserveStack(ctx, sessionID, stackName, "", stack.files(), map[string]int{name: replicas}); err != nil {
+		return nil, err
+	}
+
+	m.logger.Info("Scaling compose service", "session_id", sessionID, "stack", stackName, "service", name, "replicas", replicas)
+	args := append([]string{"compose", "-p", stack.ProjectName}, composeFileArgs(stack.files())...)
+	args = append(args,
+		"up", "-d", "--no-deps", "--no-recreate",
+		"--scale", fmt.Sprintf("%s=%d", name, replicas),
+	)
+	if replicas > 0 {
+		args = append(args, "--wait")
+	}
+	args = append(args, name)
+	if err := m.runDocker(ctx, args); err != nil {
+		return nil, fmt.Errorf("docker compose scale failed: %w", err)
+	}
+
+	return m.RefreshServices(ctx, sessionID, stackName)
+}
+
+// lookupService 返回 session 的指定堆栈，并确认 name 是 compose 文件中定义的服务
+func (m *ComposeManager) lookupService(ctx context.Context, sessionID, stackName, name string) (*ComposeStack, error) {
+	stack, ok := m.getStack(sessionID, stackName)
+	if !ok {
+		return nil, stackNotFound(sessionID, stackName)
+	}
+
+	services, err := m.composeConfig(ctx, stack.ProjectName, stack.files()...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load compose config: %w", err)
+	}
+	if _, ok := services[name]; !ok {
+		return nil, fmt.Errorf("compose service %q not found in stack", name)
+	}
+	return stack, nil
+}
+
+// reserveStack 按 files 的 compose 配置登记堆栈的配额占用，tenant 为空时沿用已有记录
+func (m *ComposeManager) reserveStack(ctx context.Context, sessionID, name, tenant string, files []string, replicas map[string]int) error {
+	if m.Quota == nil {
+		return nil
+	}
+	services, err := m.composeConfig(ctx, composeProjectName(sessionID, name), files...)
+	if err != nil {
+		return fmt.Errorf("invalid compose content: %w", err)
+	}
+	usage, err := composeUsage(services, replicas)
+	if err != nil {
+		return err
+	}
+	return m.Quota.Reserve(ctx, &quota.Allocation{
+		ID:        composeAllocationID(sessionID, name),
+		SessionID: sessionID,
+		TenantID:  tenant,
+		Usage:     usage,
+	})
+}
+
+// writeComposeFile 将请求中的 compose 内容（或已有文件）注入平台网络后写入 stackDir/name
+func (m *ComposeManager) writeComposeFile(stackDir, name string, req CreateComposeRequest) (string, error) {
+	var raw string
+	if req.ComposeContent != "" {
+		raw = req.ComposeContent
+	} else if req.ComposeFile != "" {
+		// 使用用户指定的已有 compose 文件
+		absPath, err := filepath.Abs(req.ComposeFile)
+		if err != nil {
+			return "", fmt.Errorf("invalid compose file path: %w", err)
+		}
+		if _, err := os.Stat(absPath); err != nil {
+			return "", fmt.Errorf("compose file not found: %w", err)
+		}
+		b, err := os.ReadFile(absPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read compose file: %w", err)
+		}
+		raw = string(b)
+	} else {
+		return "", fmt.Errorf("either compose_content or compose_file must be provided")
+	}
+
+	// 注入/替换 network 配置，确保所有服务接入平台网络
+	composeFile := filepath.Join(stackDir, name)
+	if err := os.WriteFile(composeFile, []byte(m.injectNetwork(raw)), 0644); err != nil {
+		return "", fmt.Errorf("failed to write compose file: %w", err)
+	}
+	return composeFile, nil
+}
+
+// writePortsFile 为 expose 中的每个服务端口分配宿主机端口，并写入发布这些端口的 override 文件。
+// 返回文件路径（无需发布时为空）和本次使用的端口 owner 列表。
+func (m *ComposeManager) writePortsFile(ctx context.Context, sessionID, stackName, fileName string, expose map[string][]int) (string, []string, error) {
+	if len(expose) == 0 {
+		return "", nil, nil
+	}
+	if m.Ports == nil {
+		return "", nil, fmt.Errorf("invalid request: host port publishing is not enabled")
+	}
+
+	names := make([]string, 0, len(expose))
+	for name := range expose {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var owners []string
+	var b strings.Builder
+	b.WriteString("services:\n")
+	for _, name := range names {
+		if len(expose[name]) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "  %s:\n    ports:\n", strconv.Quote(name))
+		for _, cp := range expose[name] {
+			owner := hostport.Owner("compose", stackName, name, strconv.Itoa(cp))
+			alloc, err := m.Ports.Allocate(ctx, sessionID, owner, cp)
+			if err != nil {
+				return "", nil, fmt.Errorf("failed to allocate host port for %s:%d: %w", name, cp, err)
+			}
+			owners = append(owners, owner)
+			fmt.Fprintf(&b, "      - \"%d:%d\"\n", alloc.Port, cp)
+		}
+	}
+	if len(owners) == 0 {
+		return "", nil, nil
+	}
+
+	path := filepath.Join(m.stackDir(sessionID, stackName), fileName)
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return "", nil, fmt.Errorf("failed to write ports file: %w", err)
+	}
+	return path, owners, nil
+}
+
+// stackPortOwners 返回堆栈当前占用宿主机端口的 owner 列表
+func (m *ComposeManager) stackPortOwners(ctx context.Context, sessionID, stackName string) ([]string, error) {
+	if m.Ports == nil {
+		return nil, nil
+	}
+	allocs, err := m.Ports.List(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list host ports: %w", err)
+	}
+	var owners []string
+	for _, a := range allocs {
+		if strings.HasPrefix(a.Owner, stackPortOwner(stackName)) {
+			owners = append(owners, a.Owner)
+		}
+	}
+	return owners, nil
+}
+
+// stackPortOwner 堆栈所有端口 owner 的公共前缀
+func stackPortOwner(stackName string) string {
+	return hostport.Owner("compose", stackName, "")
+}
+
+// publishedPorts 提取容器已发布到宿主机的 TCP 端口，IPv4/IPv6 重复绑定只保留一条
+func publishedPorts(ports []container.Port) []PortMapping {
+	var mappings []PortMapping
+	seen := make(map[PortMapping]bool)
+	for _, p := range ports {
+		if p.PublicPort == 0 || p.Type != "tcp" {
+			continue
+		}
+		pm := PortMapping{ContainerPort: int(p.PrivatePort), HostPort: int(p.PublicPort)}
+		if seen[pm] {
+			continue
+		}
+		seen[pm] = true
+		mappings = append(mappings, pm)
+	}
+	sort.Slice(mappings, func(i, j int) bool {
+		return mappings[i].ContainerPort < mappings[j].ContainerPort
+	})
+	return mappings
+}
+
 // TeardownStack 停止并移除 compose 堆栈的所有容器和卷
-func (m *ComposeManager) TeardownStack(ctx context.Context, sessionID string) error {
+func (m *ComposeManager) TeardownStack(ctx context.Context, sessionID, name string) error {
 	m.mu.Lock()
-	stack, ok := m.stacks[sessionID]
+	stack, ok := m.stacks[sessionID][name]
 	if !ok {
 		m.mu.Unlock()
 		return nil // 没有堆栈，静默返回
 	}
-	delete(m.stacks, sessionID)
+	delete(m.stacks[sessionID], name)
+	if len(m.stacks[sessionID]) == 0 {
+		delete(m.stacks, sessionID)
+	}
 	m.mu.Unlock()
 
 	m.logger.Info("Tearing down compose stack",
 		"session_id", sessionID,
+		"stack", name,
 		"project_name", stack.ProjectName,
 	)
 
-	if err := m.composeDown(ctx, stack.ProjectName, stack.ComposeFile); err != nil {
+	if err := m.composeDown(ctx, stack.ProjectName, stack.files()...); err != nil {
 		m.logger.Error("Failed to tear down compose stack",
 			"session_id", sessionID,
+			"stack", name,
 			"error", err,
 		)
 		return err
 	}
 
+	releasePortOwner(ctx, m.Ports, sessionID, stackPortOwner(name), m.logger)
+	m.Quota.Release(ctx, composeAllocationID(sessionID, name))
+
 	// 清理 stack 目录
-	stackDir := filepath.Join(m.dataDir, sessionID)
-	_ = os.RemoveAll(stackDir)
+	_ = os.RemoveAll(m.stackDir(sessionID, name))
 
 	return nil
 }
 
-// GetStack 返回 session 对应的 compose 堆栈信息
-func (m *ComposeManager) GetStack(sessionID string) *ComposeStack {
+// GetStack 返回 session 指定名称的 compose 堆栈信息
+func (m *ComposeManager) GetStack(sessionID, name string) *ComposeStack {
+	stack, _ := m.getStack(sessionID, name)
+	return stack
+}
+
+// ListStacks 返回 session 的所有 compose 堆栈，按名称排序
+func (m *ComposeManager) ListStacks(sessionID string) []*ComposeStack {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	return m.stacks[sessionID]
+
+	stacks := make([]*ComposeStack, 0, len(m.stacks[sessionID]))
+	for _, stack := range m.stacks[sessionID] {
+		stacks = append(stacks, stack)
+	}
+	sort.Slice(stacks, func(i, j int) bool {
+		return stacks[i].Name < stacks[j].Name
+	})
+	return stacks
 }
 
 // RefreshServices 重新检查堆栈中服务的状态和 IP
-func (m *ComposeManager) RefreshServices(ctx context.Context, sessionID string) (*ComposeStack, error) {
-	m.mu.RLock()
-	stack, ok := m.stacks[sessionID]
-	m.mu.RUnlock()
-
+func (m *ComposeManager) RefreshServices(ctx context.Context, sessionID, name string) (*ComposeStack, error) {
+	stack, ok := m.getStack(sessionID, name)
 	if !ok {
-		return nil, fmt.Errorf("no compose stack for session %s", sessionID)
+		return nil, stackNotFound(sessionID, name)
 	}
 
 	services, err := m.inspectServices(ctx, stack.ProjectName)
@@ -224,35 +615,128 @@ func (m *ComposeManager) RefreshServices(ctx context.Context, sessionID string)
 	return stack, nil
 }
 
-// CleanupSession 清理 session 的 compose 堆栈（TerminateSession 时调用）
+// CleanupSession 清理 session 的所有 compose 堆栈（TerminateSession 时调用）
 func (m *ComposeManager) CleanupSession(ctx context.Context, sessionID string) {
-	_ = m.TeardownStack(ctx, sessionID)
+	for _, stack := range m.ListStacks(sessionID) {
+		_ = m.TeardownStack(ctx, sessionID, stack.Name)
+	}
+	_ = os.RemoveAll(filepath.Join(m.dataDir, sessionID))
+}
+
+func (m *ComposeManager) getStack(sessionID, name string) (*ComposeStack, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	stack, ok := m.stacks[sessionID][name]
+	return stack, ok
+}
+
+// stackDir 堆栈 compose 文件所在目录：<dataDir>/<sessionID>/<name>
+func (m *ComposeManager) stackDir(sessionID, name string) string {
+	return filepath.Join(m.dataDir, sessionID, name)
+}
+
+// composeProjectName 默认堆栈沿用 agent-<session 前缀>，命名堆栈追加名称后缀
+func composeProjectName(sessionID, name string) string {
+	if name == DefaultStackName {
+		return fmt.Sprintf("agent-%s", sessionID[:8])
+	}
+	return fmt.Sprintf("agent-%s-%s", sessionID[:8], name)
+}
+
+func stackNotFound(sessionID, name string) error {
+	return fmt.Errorf("compose stack %q not found for session %s", name, sessionID)
 }
 
 // ───────────────────────────────────────────────────────────────────────
 // 内部方法
 // ───────────────────────────────────────────────────────────────────────
 
-func (m *ComposeManager) composeUp(ctx context.Context, projectName, composeFile string) error {
-	args := []string{
-		"compose",
-		"-p", projectName,
-		"-f", composeFile,
-		"up", "-d",
-		"--wait",
+// composeFileArgs 将文件列表展开为 -f <file> 参数，后面的文件覆盖前面的配置
+func composeFileArgs(files []string) []string {
+	args := make([]string, 0, 2*len(files))
+	for _, f := range files {
+		args = append(args, "-f", f)
 	}
+	return args
+}
+
+func (m *ComposeManager) composeUp(ctx context.Context, projectName string, files ...string) error {
+	args := append([]string{"compose", "-p", projectName}, composeFileArgs(files)...)
+	args = append(args, "up", "-d", "--wait")
+	return m.runDocker(ctx, args)
+}
+
+func (m *ComposeManager) composeApply(ctx context.Context, projectName string, files ...string) error {
+	args := append([]string{"compose", "-p", projectName}, composeFileArgs(files)...)
+	args = append(args, "up", "-d", "--wait", "--remove-orphans")
 	return m.runDocker(ctx, args)
 }
 
-func (m *ComposeManager) composeDown(ctx context.Context, projectName, composeFile string) error {
-	args := []string{
-		"compose",
-		"-p", projectName,
-		"-f", composeFile,
-		"down",
-		"--volumes",
-		"--remove-orphans",
+// composeConfig 返回规范化后的各服务配置（service name -> JSON）
+func (m *ComposeManager) composeConfig(ctx context.Context, projectName string, files ...string) (map[string]json.RawMessage, error) {
+	args := append([]string{"compose", "-p", projectName}, composeFileArgs(files)...)
+	args = append(args, "config", "--format", "json")
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %s", err, stderr.String())
+	}
+
+	var cfg struct {
+		Services map[string]json.RawMessage `json:"services"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse compose config: %w", err)
 	}
+	return cfg.Services, nil
+}
+
+// diffComposeServices 按服务名对比新旧配置，结果按名称排序
+func diffComposeServices(oldServices, newServices map[string]json.RawMessage) *ComposeUpdateResult {
+	result := &ComposeUpdateResult{
+		Added:     []string{},
+		Removed:   []string{},
+		Changed:   []string{},
+		Unchanged: []string{},
+	}
+	for name, newCfg := range newServices {
+		oldCfg, ok := oldServices[name]
+		switch {
+		case !ok:
+			result.Added = append(result.Added, name)
+		case !jsonEqual(oldCfg, newCfg):
+			result.Changed = append(result.Changed, name)
+		default:
+			result.Unchanged = append(result.Unchanged, name)
+		}
+	}
+	for name := range oldServices {
+		if _, ok := newServices[name]; !ok {
+			result.Removed = append(result.Removed, name)
+		}
+	}
+
+	sort.Strings(result.Added)
+	sort.Strings(result.Removed)
+	sort.Strings(result.Changed)
+	sort.Strings(result.Unchanged)
+	return result
+}
+
+// jsonEqual 忽略字段顺序比较两个 JSON 值
+func jsonEqual(a, b json.RawMessage) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func (m *ComposeManager) composeDown(ctx context.Context, projectName string, files ...string) error {
+	args := append([]string{"compose", "-p", projectName}, composeFileArgs(files)...)
+	args = append(args, "down", "--volumes", "--remove-orphans")
 	return m.runDocker(ctx, args)
 }
 
@@ -286,8 +770,10 @@ func (m *ComposeManager) inspectServices(ctx context.Context, projectName string
 	var services []ComposeService
 	for _, c := range containers {
 		name := ""
+		replica := 0
 		if labels := c.Labels; labels != nil {
 			name = labels["com.docker.compose.service"]
+			replica, _ = strconv.Atoi(labels["com.docker.compose.container-number"])
 		}
 		if name == "" && len(c.Names) > 0 {
 			name = strings.TrimPrefix(c.Names[0], "/")
@@ -314,9 +800,11 @@ func (m *ComposeManager) inspectServices(ctx context.Context, projectName string
 
 		services = append(services, ComposeService{
 			Name:        name,
+			Replica:     replica,
 			ContainerID: c.ID[:12],
 			IP:          ip,
 			Status:      status,
+			Ports:       publishedPorts(c.Ports),
 		})
 	}
 
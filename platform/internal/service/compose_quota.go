@@ -0,0 +1,136 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"platform/internal/quota"
+)
+
+// composeAllocationID compose 堆栈在配额记录中的 ID
+func composeAllocationID(sessionID, name string) string {
+	return "compose:" + sessionID + "/" + name
+}
+
+// composeServiceResources compose config 输出中与资源占用相关的字段
+type composeServiceResources struct {
+	MemLimit json.RawMessage `json:"mem_limit"`
+	CPUs     json.RawMessage `json:"cpus"`
+	Scale    *int            `json:"scale"`
+	Deploy   *struct {
+		Replicas  *int `json:"replicas"`
+		Resources struct {
+			Limits struct {
+				Memory json.RawMessage `json:"memory"`
+				CPUs   json.RawMessage `json:"cpus"`
+			} `json:"limits"`
+		} `json:"resources"`
+	} `json:"deploy"`
+}
+
+// composeUsage 按 compose 配置估算堆栈的资源占用：每个副本计一个服务，
+// 未声明内存或 CPU 上限的服务按伴随服务的默认值计算。replicas 覆盖指定服务的副本数
+func composeUsage(services map[string]json.RawMessage, replicas map[string]int) (quota.Usage, error) {
+	var usage quota.Usage
+	for name, raw := range services {
+		var svc composeServiceResources
+		if err := json.Unmarshal(raw, &svc); err != nil {
+			return usage, fmt.Errorf("invalid compose service %q: %w", name, err)
+		}
+
+		count := 1
+		memRaw, cpuRaw := svc.MemLimit, svc.CPUs
+		if svc.Scale != nil {
+			count = *svc.Scale
+		}
+		if svc.Deploy != nil {
+			if svc.Deploy.Replicas != nil {
+				count = *svc.Deploy.Replicas
+			}
+			if len(svc.Deploy.Resources.Limits.Memory) > 0 {
+				memRaw = svc.Deploy.Resources.Limits.Memory
+			}
+			if len(svc.Deploy.Resources.Limits.CPUs) > 0 {
+				cpuRaw = svc.Deploy.Resources.Limits.CPUs
+			}
+		}
+		if n, ok := replicas[name]; ok {
+			count = n
+		}
+
+		memory, err := parseComposeBytes(memRaw)
+		if err != nil {
+			return usage, fmt.Errorf("invalid memory limit for compose service %q: %w", name, err)
+		}
+		if memory <= 0 {
+			memory = defaultCompanionMemoryMB * 1024 * 1024
+		}
+		cpus, err := parseComposeFloat(cpuRaw)
+		if err != nil {
+			return usage, fmt.Errorf("invalid cpus for compose service %q: %w", name, err)
+		}
+		if cpus <= 0 {
+			cpus = defaultCompanionCPUs
+		}
+
+		usage = usage.Add(quota.Usage{
+			Services:    count,
+			MemoryBytes: int64(count) * memory,
+			NanoCPUs:    int64(count) * int64(cpus*1e9),
+		})
+	}
+	return usage, nil
+}
+
+// parseComposeBytes 解析数字或带单位（b/k/m/g，可带 b 后缀）的字符串，空值返回 0
+func parseComposeBytes(raw json.RawMessage) (int64, error) {
+	v, err := composeScalar(raw)
+	if err != nil || v == "" {
+		return 0, err
+	}
+	v = strings.TrimSuffix(strings.ToLower(v), "b")
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(v, "k"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(v, "m"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(v, "g"):
+		multiplier = 1 << 30
+	}
+	if multiplier > 1 {
+		v = v[:len(v)-1]
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// parseComposeFloat 解析数字或数字字符串，空值返回 0
+func parseComposeFloat(raw json.RawMessage) (float64, error) {
+	v, err := composeScalar(raw)
+	if err != nil || v == "" {
+		return 0, err
+	}
+	return strconv.ParseFloat(v, 64)
+}
+
+// composeScalar 将 JSON 数字或字符串统一为字符串
+func composeScalar(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.TrimSpace(s), nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return "", err
+	}
+	return n.String(), nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiffComposeServices(t *testing.T) {
+	oldServices := map[string]json.RawMessage{
+		"db":    json.RawMessage(`{"image":"postgres:15","environment":{"A":"1","B":"2"}}`),
+		"cache": json.RawMessage(`{"image":"redis:7"}`),
+		"web":   json.RawMessage(`{"image":"nginx:1.25"}`),
+	}
+	newServices := map[string]json.RawMessage{
+		// 字段顺序不同但内容相同
+		"db":     json.RawMessage(`{"environment":{"B":"2","A":"1"},"image":"postgres:15"}`),
+		"web":    json.RawMessage(`{"image":"nginx:1.27"}`),
+		"worker": json.RawMessage(`{"image":"python:3.12"}`),
+	}
+
+	result := diffComposeServices(oldServices, newServices)
+
+	want := &ComposeUpdateResult{
+		Added:     []string{"worker"},
+		Removed:   []string{"cache"},
+		Changed:   []string{"web"},
+		Unchanged: []string{"db"},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Fatalf("Unexpected diff: got %+v, want %+v", result, want)
+	}
+	if !result.HasChanges() {
+		t.Error("Expected HasChanges to be true")
+	}
+
+	if diffComposeServices(oldServices, oldServices).HasChanges() {
+		t.Error("Identical configs should have no changes")
+	}
+}
+
+func TestStackNames(t *testing.T) {
+	for _, name := range []string{"default", "app", "test-deps", "db_2"} {
+		if err := ValidateStackName(name); err != nil {
+			t.Errorf("Expected %q to be valid: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "App", "-app", "services", "a/b", "this-name-is-way-too-long-for-a-stack"} {
+		if err := ValidateStackName(name); err == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+
+	sid := "0123456789abcdef"
+	if got := composeProjectName(sid, DefaultStackName); got != "agent-01234567" {
+		t.Errorf("Default stack should keep legacy project name, got %s", got)
+	}
+	if got := composeProjectName(sid, "deps"); got != "agent-01234567-deps" {
+		t.Errorf("Unexpected project name %s", got)
+	}
+}
+
+func TestComposeUsage(t *testing.T) {
+	services := map[string]json.RawMessage{
+		"db":    json.RawMessage(`{"image":"postgres","deploy":{"resources":{"limits":{"memory":"268435456","cpus":"0.25"}}}}`),
+		"web":   json.RawMessage(`{"image":"nginx","mem_limit":"1g","cpus":1,"deploy":{"replicas":2}}`),
+		"cache": json.RawMessage(`{"image":"redis"}`),
+	}
+
+	usage, err := composeUsage(services, nil)
+	if err != nil {
+		t.Fatalf("composeUsage: %v", err)
+	}
+	wantMem := int64(256<<20) + 2*int64(1<<30) + int64(defaultCompanionMemoryMB<<20)
+	wantCPU := int64(0.25*1e9) + 2*int64(1e9) + int64(defaultCompanionCPUs*1e9)
+	if usage.Services != 4 || usage.MemoryBytes != wantMem || usage.NanoCPUs != wantCPU {
+		t.Fatalf("usage = %+v, want services=4 memory=%d cpus=%d", usage, wantMem, wantCPU)
+	}
+
+	scaled, err := composeUsage(services, map[string]int{"web": 0})
+	if err != nil {
+		t.Fatalf("composeUsage: %v", err)
+	}
+	if scaled.Services != 2 {
+		t.Fatalf("scaled services = %d, want 2", scaled.Services)
+	}
+
+	if _, err := composeUsage(map[string]json.RawMessage{"x": json.RawMessage(`{"mem_limit":"lots"}`)}, nil); err == nil {
+		t.Fatal("expected error for unparsable memory limit")
+	}
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+
+	"platform/internal/debugbundle"
+)
+
+// OpenDebugBundle 打开失败 session 的调试包，返回内容和下载文件名，调用方负责关闭
+func (s *Service) OpenDebugBundle(ctx context.Context, sessionID string) (io.ReadCloser, string, error) {
+	sess, err := s.SessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, "", fmt.Errorf("session not found: %w", err)
+	}
+	if sess.DebugBundle == "" || s.DebugBundles == nil {
+		return nil, "", debugbundle.ErrNotFound
+	}
+	r, err := s.DebugBundles.Open(ctx, sess.DebugBundle)
+	if err != nil {
+		return nil, "", err
+	}
+	return r, sessionID + "-" + path.Base(sess.DebugBundle), nil
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"platform/internal/eventbus"
+	"platform/internal/sandbox"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+const (
+	// EndpointsDir / EndpointsFile Agent 容器内服务连接信息文件的位置
+	EndpointsDir  = "/app/.platform"
+	EndpointsFile = EndpointsDir + "/services.json"
+)
+
+// ServiceEndpoint 一个可供 Agent 连接的 compose 或 companion 服务
+type ServiceEndpoint struct {
+	Kind        string            `json:"kind"`            // compose | companion
+	Stack       string            `json:"stack,omitempty"` // compose 堆栈名
+	Scope       string            `json:"scope,omitempty"` // 项目级共享服务为 project
+	Name        string            `json:"name"`
+	Replica     int               `json:"replica,omitempty"`
+	Host        string            `json:"host"` // 平台网络内的容器 IP
+	Ports       []int             `json:"ports"`
+	Env         map[string]string `json:"env,omitempty"` // 容器环境变量（用户名、密码、库名等）
+	Status      string            `json:"status"`
+	Health      string            `json:"health,omitempty"` // healthy | unhealthy | starting，未配置健康检查时为空
+	ContainerID string            `json:"container_id"`
+}
+
+// EndpointsDocument 写入 Agent 容器的 services.json 内容
+type EndpointsDocument struct {
+	SessionID string            `json:"session_id"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Services  []ServiceEndpoint `json:"services"`
+}
+
+// ListServiceEndpoints 返回 session 当前所有 compose / companion 服务的连接信息
+func (s *Service) ListServiceEndpoints(ctx context.Context, sessionID string) ([]ServiceEndpoint, error) {
+	if _, err := s.SessionMgr.GetSession(ctx, sessionID); err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	return s.endpoints.Collect(ctx, sessionID, s.projectServices(sessionID), s.ListComposeStacks(sessionID))
+}
+
+func (s *Service) projectServices(sessionID string) []*CompanionService {
+	if s.Companions == nil {
+		return nil
+	}
+	return s.Companions.ListProjectServices(sessionID)
+}
+
+// syncEndpoints 在服务变化后刷新 Agent 容器内的 services.json，内容变化时发布事件。
+// 写入失败不影响调用方的操作结果，只记录日志。
+func (s *Service) syncEndpoints(ctx context.Context, sessionID string) {
+	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+	if err != nil || sess.ContainerID == "" {
+		return
+	}
+
+	endpoints, err := s.endpoints.Collect(ctx, sessionID, s.projectServices(sessionID), s.ListComposeStacks(sessionID))
+	if err != nil {
+		s.Logger.Warn("Failed to collect service endpoints", "session_id", sessionID, "error", err)
+		return
+	}
+
+	changed, err := s.endpoints.Publish(ctx, sessionID, sess.ContainerID, endpoints)
+	if err != nil {
+		s.Logger.Warn("Failed to publish service endpoints", "session_id", sessionID, "error", err)
+		return
+	}
+	if changed {
+		s.Bus.Publish(ctx, sessionID, eventbus.Event{
+			Type:      eventbus.EventServicesUpdated,
+			SessionID: sessionID,
+			Payload: map[string]any{
+				"file":     EndpointsFile,
+				"services": endpoints,
+			},
+			Timestamp: time.Now(),
+		})
+	}
+}
+
+// endpointPublisher 收集 session 的 compose / companion 服务连接信息，
+// 写入 Agent 容器内的 EndpointsFile，Agent 无需猜测服务 IP。
+// 内容未变化时不重复写入。
+type endpointPublisher struct {
+	docker  *client.Client
+	network string
+	logger  *slog.Logger
+
+	mu   sync.Mutex
+	last map[string][32]byte // sessionID -> 上次写入内容的摘要
+}
+
+func newEndpointPublisher(docker *client.Client, network string, logger *slog.Logger) *endpointPublisher {
+	return &endpointPublisher{
+		docker:  docker,
+		network: network,
+		logger:  logger.With("component", "endpoints"),
+		last:    make(map[string][32]byte),
+	}
+}
+
+// Collect 通过容器标签查询 session 的所有服务容器，shared 为 session 引用的项目级服务
+func (p *endpointPublisher) Collect(ctx context.Context, sessionID string, shared []*CompanionService, stacks []*ComposeStack) ([]ServiceEndpoint, error) {
+	var endpoints []ServiceEndpoint
+
+	companions, err := sandbox.ListManaged(ctx, p.docker, sandbox.LabelQuery{
+		"service_type":         "companion",
+		sandbox.LabelSessionID: sessionID,
+	}, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range companions {
+		ep, err := p.inspect(ctx, c.ID)
+		if err != nil {
+			p.logger.Warn("Failed to inspect companion container", "container_id", c.ID, "error", err)
+			continue
+		}
+		ep.Kind = "companion"
+		ep.Name = c.Labels["service_name"]
+		endpoints = append(endpoints, ep)
+	}
+
+	for _, svc := range shared {
+		ep, err := p.inspect(ctx, svc.ContainerID)
+		if err != nil {
+			p.logger.Warn("Failed to inspect project companion container", "container_id", svc.ContainerID, "error", err)
+			continue
+		}
+		ep.Kind = "companion"
+		ep.Scope = ScopeProject
+		ep.Name = svc.Name
+		endpoints = append(endpoints, ep)
+	}
+
+	for _, stack := range stacks {
+		containers, err := p.docker.ContainerList(ctx, container.ListOptions{
+			Filters: filters.NewArgs(
+				filters.Arg("label", "com.docker.compose.project="+stack.ProjectName),
+			),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list compose containers: %w", err)
+		}
+		for _, c := range containers {
+			ep, err := p.inspect(ctx, c.ID)
+			if err != nil {
+				p.logger.Warn("Failed to inspect compose container", "container_id", c.ID, "error", err)
+				continue
+			}
+			ep.Kind = "compose"
+			ep.Stack = stack.Name
+			ep.Name = c.Labels["com.docker.compose.service"]
+			ep.Replica, _ = strconv.Atoi(c.Labels["com.docker.compose.container-number"])
+			endpoints = append(endpoints, ep)
+		}
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool {
+		a, b := endpoints[i], endpoints[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Stack != b.Stack {
+			return a.Stack < b.Stack
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Replica < b.Replica
+	})
+	return endpoints, nil
+}
+
+func (p *endpointPublisher) inspect(ctx context.Context, containerID string) (ServiceEndpoint, error) {
+	info, err := p.docker.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return ServiceEndpoint{}, err
+	}
+
+	ep := ServiceEndpoint{
+		ContainerID: containerID[:12],
+		Ports:       []int{},
+		Status:      info.State.Status,
+	}
+	if info.State.Health != nil {
+		ep.Health = info.State.Health.Status
+	}
+
+	if netInfo, ok := info.NetworkSettings.Networks[p.network]; ok {
+		ep.Host = netInfo.IPAddress
+	} else {
+		for _, n := range info.NetworkSettings.Networks {
+			if n.IPAddress != "" {
+				ep.Host = n.IPAddress
+				break
+			}
+		}
+	}
+
+	if info.Config != nil {
+		for port := range info.Config.ExposedPorts {
+			if port.Proto() == "tcp" {
+				ep.Ports = append(ep.Ports, port.Int())
+			}
+		}
+		sort.Ints(ep.Ports)
+		ep.Env = connectionEnv(info.Config.Env)
+	}
+	return ep, nil
+}
+
+// connectionEnv 保留与连接相关的环境变量，去掉镜像自带的 PATH、版本号等噪音
+func connectionEnv(env []string) map[string]string {
+	out := make(map[string]string)
+	for _, kv := range env {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || isNoiseEnv(k) {
+			continue
+		}
+		out[k] = v
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func isNoiseEnv(key string) bool {
+	switch key {
+	case "PATH", "HOME", "HOSTNAME", "TERM", "LANG", "LANGUAGE", "PWD", "SHLVL", "GOSU_VERSION", "PGDATA":
+		return true
+	}
+	if strings.HasPrefix(key, "LC_") {
+		return true
+	}
+	for _, suffix := range []string{"_VERSION", "_SHA256", "_SHA512", "_GPG_KEY", "_GPG_KEYS", "_MAJOR"} {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish 将服务列表写入 Agent 容器，内容与上次相同时跳过；返回是否实际写入
+func (p *endpointPublisher) Publish(ctx context.Context, sessionID, containerID string, endpoints []ServiceEndpoint) (bool, error) {
+	if endpoints == nil {
+		endpoints = []ServiceEndpoint{}
+	}
+	services, err := json.Marshal(endpoints)
+	if err != nil {
+		return false, err
+	}
+	sum := sha256.Sum256(append([]byte(containerID), services...))
+
+	p.mu.Lock()
+	unchanged := p.last[sessionID] == sum
+	p.mu.Unlock()
+	if unchanged {
+		return false, nil
+	}
+
+	doc, err := json.MarshalIndent(EndpointsDocument{
+		SessionID: sessionID,
+		UpdatedAt: time.Now(),
+		Services:  endpoints,
+	}, "", "  ")
+	if err != nil {
+		return false, err
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	dirName := strings.TrimPrefix(EndpointsDir, "/app/")
+	if err := tw.WriteHeader(&tar.Header{Name: dirName + "/", Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+		return false, err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: dirName + "/services.json", Mode: 0644, Size: int64(len(doc))}); err != nil {
+		return false, err
+	}
+	if _, err := tw.Write(doc); err != nil {
+		return false, err
+	}
+	if err := tw.Close(); err != nil {
+		return false, err
+	}
+
+	if err := p.docker.CopyToContainer(ctx, containerID, "/app", &buf, container.CopyToContainerOptions{}); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", EndpointsFile, err)
+	}
+
+	p.mu.Lock()
+	p.last[sessionID] = sum
+	p.mu.Unlock()
+
+	p.logger.Info("Published service endpoints", "session_id", sessionID, "services", len(endpoints))
+	return true, nil
+}
+
+// Forget 清理 session 的缓存摘要（session 终止时调用）
+func (p *endpointPublisher) Forget(sessionID string) {
+	p.mu.Lock()
+	delete(p.last, sessionID)
+	p.mu.Unlock()
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConnectionEnv(t *testing.T) {
+	env := []string{
+		"PATH=/usr/local/sbin:/usr/bin",
+		"POSTGRES_USER=app",
+		"POSTGRES_PASSWORD=secret",
+		"POSTGRES_DB=appdb",
+		"PG_MAJOR=15",
+		"PG_VERSION=15.4",
+		"LANG=en_US.utf8",
+		"GOSU_VERSION=1.16",
+		"MALFORMED",
+	}
+
+	want := map[string]string{
+		"POSTGRES_USER":     "app",
+		"POSTGRES_PASSWORD": "secret",
+		"POSTGRES_DB":       "appdb",
+	}
+	if got := connectionEnv(env); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected env: got %v, want %v", got, want)
+	}
+
+	if got := connectionEnv([]string{"PATH=/bin"}); got != nil {
+		t.Errorf("Expected nil for noise-only env, got %v", got)
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"platform/internal/eventbus"
+	"platform/internal/execpolicy"
+	"platform/internal/monitor"
+)
+
+// SecurityViolation 命令策略违规事件的负载
+type SecurityViolation struct {
+	Rule    string   `json:"rule"`
+	Detail  string   `json:"detail"`
+	Command []string `json:"command"`
+	WorkDir string   `json:"work_dir"`
+}
+
+// enforceExecPolicy 检查终端的启动命令和工作目录，违规时记录安全事件并返回错误；
+// 通过时返回清理后的环境变量。平台目前没有执行调用方命令的 exec 接口，
+// 文件、快照等内部 exec 使用固定命令，不经过策略；以后开放调用方命令时应在执行前调用这里
+func (s *Service) enforceExecPolicy(ctx context.Context, sessionID string, cmd []string, workDir string, env []string) ([]string, error) {
+	if s.ExecPolicy == nil {
+		return env, nil
+	}
+
+	err := s.ExecPolicy.Check(cmd, workDir)
+	var violation *execpolicy.Violation
+	if errors.As(err, &violation) {
+		monitor.ExecPolicyViolations.WithLabelValues(violation.Rule).Inc()
+		s.Logger.Warn("Exec denied by command policy",
+			"component", "security",
+			"session_id", sessionID,
+			"rule", violation.Rule,
+			"detail", violation.Detail,
+			"cmd", cmd,
+			"work_dir", workDir,
+		)
+		if s.Bus != nil {
+			if pubErr := s.Bus.Publish(ctx, sessionID, eventbus.Event{
+				Type:      eventbus.EventSecurityViolation,
+				SessionID: sessionID,
+				Payload: SecurityViolation{
+					Rule:    violation.Rule,
+					Detail:  violation.Detail,
+					Command: cmd,
+					WorkDir: workDir,
+				},
+				Timestamp: time.Now(),
+			}); pubErr != nil {
+				s.Logger.Warn("Failed to publish security event", "session_id", sessionID, "error", pubErr)
+			}
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	kept, removed := s.ExecPolicy.ScrubEnv(env)
+	if len(removed) > 0 {
+		s.Logger.Warn("Scrubbed environment variables from exec",
+			"component", "security",
+			"session_id", sessionID,
+			"removed", removed,
+		)
+	}
+	return kept, nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+
+	"platform/internal/eventbus"
+	"platform/internal/execpolicy"
+)
+
+type recordingBus struct {
+	eventbus.EventBus
+	events []eventbus.Event
+}
+
+func (b *recordingBus) Publish(ctx context.Context, sessionID string, event eventbus.Event) error {
+	b.events = append(b.events, event)
+	return nil
+}
+
+func TestEnforceExecPolicy(t *testing.T) {
+	bus := &recordingBus{}
+	s := &Service{
+		Bus:        bus,
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ExecPolicy: execpolicy.Default(),
+	}
+	ctx := context.Background()
+
+	env, err := s.enforceExecPolicy(ctx, "sess-1", []string{"ls"}, "/app/workspace", []string{"TERM=xterm", "LD_PRELOAD=/x.so"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(env, []string{"TERM=xterm"}) {
+		t.Fatalf("env = %v", env)
+	}
+	if len(bus.events) != 0 {
+		t.Fatalf("unexpected events: %v", bus.events)
+	}
+
+	if _, err := s.enforceExecPolicy(ctx, "sess-1", []string{"docker", "ps"}, "/app/workspace", nil); err == nil {
+		t.Fatal("expected docker to be denied")
+	}
+	if len(bus.events) != 1 || bus.events[0].Type != eventbus.EventSecurityViolation {
+		t.Fatalf("expected one security event, got %v", bus.events)
+	}
+	if v := bus.events[0].Payload.(SecurityViolation); v.Rule != "denied_binary" || v.Detail != "docker" {
+		t.Fatalf("payload = %+v", v)
+	}
+}
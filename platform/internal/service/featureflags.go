@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"platform/internal/featureflag"
+	"platform/internal/session"
+)
+
+// sessionScope session 判断功能开关使用的范围，租户为 session 所属用户
+func sessionScope(sess *session.Session) featureflag.Scope {
+	return featureflag.Scope{Tenant: sess.UserID, Project: sess.ProjectID}
+}
+
+// SessionFeatureEnabled 返回开关对 session 所属租户和项目是否开启，session 不存在时使用全局范围
+func (s *Service) SessionFeatureEnabled(ctx context.Context, sessionID string, flag featureflag.Flag) bool {
+	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+	if err != nil {
+		return s.Flags.Enabled(flag, featureflag.Scope{})
+	}
+	return s.Flags.Enabled(flag, sessionScope(sess))
+}
+
+func (s *Service) ListFeatureFlags() ([]featureflag.State, error) {
+	if s.Flags == nil {
+		return nil, fmt.Errorf("feature flags not initialized")
+	}
+	return s.Flags.States(), nil
+}
+
+// SetFeatureFlag 覆盖开关在租户 / 项目范围内的取值，各实例在刷新周期内生效
+func (s *Service) SetFeatureFlag(ctx context.Context, o featureflag.Override) (*featureflag.Override, error) {
+	if s.Flags == nil {
+		return nil, fmt.Errorf("feature flags not initialized")
+	}
+	return s.Flags.Set(ctx, o)
+}
+
+// DeleteFeatureFlag 移除覆盖，恢复使用更宽范围的覆盖或默认值
+func (s *Service) DeleteFeatureFlag(ctx context.Context, flag featureflag.Flag, scope featureflag.Scope) error {
+	if s.Flags == nil {
+		return fmt.Errorf("feature flags not initialized")
+	}
+	return s.Flags.Delete(ctx, flag, scope)
+}
@@ -0,0 +1,436 @@
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"platform/internal/featureflag"
+	"platform/internal/sandbox"
+	"platform/internal/sandbox/pathsafe"
+	"platform/internal/secretfile"
+	"platform/internal/session"
+)
+
+// resolveWorkspacePath 在容器内解析 rel 中的符号链接，拒绝解析后位于工作区之外的路径，返回解析后的相对路径。
+// 路径不存在时按字面返回，由后续操作报告不存在
+func (s *Service) resolveWorkspacePath(ctx context.Context, containerID, workspace, rel string) (string, error) {
+	target := path.Join(workspace, rel)
+	stdout, stderr, exitCode, err := s.execQuiet(ctx, containerID, pathsafe.ResolveCommand(target))
+	if err != nil {
+		return "", err
+	}
+	if exitCode != 0 {
+		return "", fmt.Errorf("failed to resolve path %q: %s", "/"+rel, strings.TrimSpace(stderr))
+	}
+	resolved, err := pathsafe.CheckResolved(workspace, "/"+rel, stdout)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(resolved, workspace), "/"), nil
+}
+
+// WriteContainerFile 将 r 的内容写入容器工作区中的 filePath 并返回写入的字节数，已存在时覆盖，缺失的父目录自动创建。
+// size 未知（-1）时先暂存到临时文件，tar 头部需要文件大小。写入期间持有 session 锁，
+// 避免与挂起、重启等更换容器的操作交错
+func (s *Service) WriteContainerFile(ctx context.Context, sessionID, filePath string, r io.Reader, size int64, perm os.FileMode) (int64, error) {
+	rel, err := pathsafe.File(filePath)
+	if err != nil {
+		return 0, err
+	}
+
+	// 在取锁前读完未知长度的请求体，慢速上传不占用 session 锁
+	if size < 0 {
+		tmp, err := os.CreateTemp("", "upload-*")
+		if err != nil {
+			return 0, fmt.Errorf("failed to buffer upload: %w", err)
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		if size, err = io.Copy(tmp, r); err != nil {
+			return 0, fmt.Errorf("failed to buffer upload: %w", err)
+		}
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return 0, err
+		}
+		r = tmp
+	}
+	if perm == 0 {
+		perm = 0644
+	}
+
+	err = s.withSessionLock(ctx, sessionID, "write_file", func() error {
+		return s.writeContainerFile(ctx, sessionID, filePath, rel, r, size, perm)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+func (s *Service) writeContainerFile(ctx context.Context, sessionID, filePath, rel string, r io.Reader, size int64, perm os.FileMode) error {
+	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("session not found: %w", err)
+	}
+	if err := ensureActive(sess); err != nil {
+		return err
+	}
+	if sess.ContainerID == "" {
+		return fmt.Errorf("session has no container")
+	}
+
+	workspace := s.sessionContainer(sess).MountPath
+	if rel, err = s.resolveWorkspacePath(ctx, sess.ContainerID, workspace, rel); err != nil {
+		return err
+	}
+	if rel == "" {
+		return fmt.Errorf("invalid path %q: must name a file", filePath)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := tw.WriteHeader(&tar.Header{
+			Name:    rel,
+			Mode:    int64(perm.Perm()),
+			Size:    size,
+			ModTime: time.Now(),
+		})
+		if err == nil {
+			_, err = io.CopyN(tw, r, size)
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	if err := s.dockerFor(sess).CopyToContainer(ctx, sess.ContainerID, workspace, pr, container.CopyToContainerOptions{}); err != nil {
+		pr.CloseWithError(err)
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	s.Logger.Info("File written to container", "session_id", sessionID, "path", rel, "size", size)
+	return nil
+}
+
+const (
+	defaultFileListLimit = 1000
+	maxFileListLimit     = 10000
+)
+
+// ListFilesOptions 列出工作区文件的选项
+type ListFilesOptions struct {
+	// Path 相对工作区的目录，为空时为工作区根目录
+	Path      string
+	Recursive bool
+	// Glob 按 path.Match 过滤：不含 / 时匹配文件名，否则匹配相对 Path 的路径
+	Glob string
+	// Offset / Limit 按路径排序后分页，Limit 为 0 时使用默认值
+	Offset int
+	Limit  int
+}
+
+// FileList 一页文件列表，Total 为过滤后的总数，NextOffset 为 0 表示没有下一页
+type FileList struct {
+	Path       string             `json:"path"`
+	Files      []sandbox.FileInfo `json:"files"`
+	Total      int                `json:"total"`
+	NextOffset int                `json:"next_offset,omitempty"`
+}
+
+// ListContainerFiles 列出容器工作区中的文件，返回结构化的文件信息
+func (s *Service) ListContainerFiles(ctx context.Context, sessionID string, opts ListFilesOptions) (*FileList, error) {
+	if opts.Offset < 0 || opts.Limit < 0 || opts.Limit > maxFileListLimit {
+		return nil, fmt.Errorf("invalid pagination: offset must be >= 0 and limit between 0 and %d", maxFileListLimit)
+	}
+	if opts.Limit == 0 {
+		opts.Limit = defaultFileListLimit
+	}
+	if opts.Glob != "" {
+		if _, err := path.Match(opts.Glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", opts.Glob, err)
+		}
+	}
+	rel, err := pathsafe.Rel(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	if sess.Status == session.StatusPaused {
+		return nil, ErrSessionPaused
+	}
+	if sess.ContainerID == "" {
+		return nil, fmt.Errorf("session has no container")
+	}
+	if (opts.Recursive || opts.Glob != "") && !s.Flags.Enabled(featureflag.StructuredFileList, sessionScope(sess)) {
+		return nil, fmt.Errorf("invalid listing options: recursive and glob listings are disabled for this project")
+	}
+
+	workspace := s.sessionContainer(sess).MountPath
+	resolved, err := s.resolveWorkspacePath(ctx, sess.ContainerID, workspace, rel)
+	if err != nil {
+		return nil, err
+	}
+	target := path.Join(workspace, resolved)
+	stdout, stderr, exitCode, err := s.execQuiet(ctx, sess.ContainerID, sandbox.FindCommand(target, opts.Recursive))
+	if err != nil {
+		return nil, err
+	}
+	if exitCode != 0 {
+		if strings.Contains(stderr, "No such file") && len(stdout) == 0 {
+			return nil, fmt.Errorf("path not found: %s", "/"+rel)
+		}
+		if len(stdout) == 0 {
+			return nil, fmt.Errorf("failed to list files: %s", strings.TrimSpace(stderr))
+		}
+		// 部分子目录不可读时 find 仍输出其余条目
+		s.Logger.Warn("File listing incomplete", "session_id", sessionID, "stderr", strings.TrimSpace(stderr))
+	}
+
+	all, err := sandbox.ParseFindOutput(stdout)
+	if err != nil {
+		return nil, err
+	}
+	files := all[:0]
+	for _, f := range all {
+		if opts.Glob == "" || globMatch(opts.Glob, f.Path) {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	list := &FileList{Path: "/" + rel, Files: []sandbox.FileInfo{}, Total: len(files)}
+	if opts.Offset < len(files) {
+		end := min(opts.Offset+opts.Limit, len(files))
+		list.Files = files[opts.Offset:end]
+		if end < len(files) {
+			list.NextOffset = end
+		}
+	}
+	return list, nil
+}
+
+func globMatch(pattern, rel string) bool {
+	name := rel
+	if !strings.Contains(pattern, "/") {
+		name = path.Base(rel)
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// execQuiet 在容器中执行平台内部的只读命令，不写入 exec 日志
+func (s *Service) execQuiet(ctx context.Context, containerID string, cmd []string) ([]byte, string, int, error) {
+	release, err := sandbox.AcquireExec(ctx, containerID)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	defer release()
+
+	docker := s.containerDocker(ctx, containerID)
+	resp, err := docker.ContainerExecCreate(ctx, containerID, container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("failed to create exec: %w", err)
+	}
+	attachResp, err := docker.ContainerExecAttach(ctx, resp.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("failed to attach exec: %w", err)
+	}
+	defer attachResp.Close()
+
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, attachResp.Reader); err != nil {
+		return nil, "", 0, fmt.Errorf("failed to read exec output: %w", err)
+	}
+	inspect, err := docker.ContainerExecInspect(ctx, resp.ID)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("failed to inspect exec: %w", err)
+	}
+	return stdout.Bytes(), stderr.String(), inspect.ExitCode, nil
+}
+
+// DefaultMaxArchiveSize 工作区归档下载未压缩内容的默认上限
+const DefaultMaxArchiveSize = 1 << 30
+
+// WorkspaceArchive 打包中的工作区目录，Name 为建议的下载文件名
+type WorkspaceArchive struct {
+	io.ReadCloser
+	Name string
+	// Size 打包前统计的未压缩文件总大小
+	Size int64
+}
+
+// ArchiveContainerFiles 将容器工作区中的 dir 打包为 tar.gz 流，条目以目录名为前缀。
+// 打包前统计文件大小，超过 MaxArchiveSize 时拒绝；统计之后增长的内容在传输中超限时以错误中断流
+func (s *Service) ArchiveContainerFiles(ctx context.Context, sessionID, dir string) (*WorkspaceArchive, error) {
+	rel, err := pathsafe.Rel(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	if sess.Status == session.StatusPaused {
+		return nil, ErrSessionPaused
+	}
+	if sess.ContainerID == "" {
+		return nil, fmt.Errorf("session has no container")
+	}
+
+	limit := s.MaxArchiveSize
+	if limit <= 0 {
+		limit = DefaultMaxArchiveSize
+	}
+
+	workspace := s.sessionContainer(sess).MountPath
+	resolved, err := s.resolveWorkspacePath(ctx, sess.ContainerID, workspace, rel)
+	if err != nil {
+		return nil, err
+	}
+	target := path.Join(workspace, resolved)
+	stdout, _, _, err := s.execQuiet(ctx, sess.ContainerID, sandbox.FindCommand(target, true))
+	if err != nil {
+		return nil, err
+	}
+	files, err := sandbox.ParseFindOutput(stdout)
+	if err != nil {
+		return nil, err
+	}
+	var size int64
+	for _, f := range files {
+		if !f.IsDir {
+			size += f.Size
+		}
+	}
+	if size > limit {
+		return nil, fmt.Errorf("%s is too large to archive: %d bytes exceeds the limit of %d bytes", "/"+rel, size, limit)
+	}
+
+	reader, stat, err := s.dockerFor(sess).CopyFromContainer(ctx, sess.ContainerID, target)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil, fmt.Errorf("path not found: %s", "/"+rel)
+		}
+		return nil, fmt.Errorf("failed to copy from container: %w", err)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		defer reader.Close()
+		gz := gzip.NewWriter(pw)
+		filtered := secretfile.FilterTar(reader, s.SecretFiles)
+		defer filtered.Close()
+		// 归档包含 tar 头部，按内容上限留出余量
+		_, err := io.Copy(gz, &limitedReader{r: filtered, n: limit + limit/10 + 1<<20})
+		if err == nil {
+			err = gz.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	s.Logger.Info("Streaming workspace archive", "session_id", sessionID, "path", "/"+rel, "size", size)
+	return &WorkspaceArchive{ReadCloser: pr, Name: stat.Name + ".tar.gz", Size: size}, nil
+}
+
+// limitedReader 与 io.LimitReader 不同，超过上限时返回错误而不是 EOF，避免生成被截断但格式完整的归档
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		return 0, fmt.Errorf("archive exceeds size limit")
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// ContainerFile 从容器中读取的单个文件，读取完毕后需要 Close
+type ContainerFile struct {
+	io.Reader
+	closer  io.Closer
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+func (f *ContainerFile) Close() error {
+	return f.closer.Close()
+}
+
+// OpenContainerFile 以流的方式读取容器工作区中的文件，内容不经过内存缓冲，适合二进制和大文件
+func (s *Service) OpenContainerFile(ctx context.Context, sessionID, filePath string) (*ContainerFile, error) {
+	rel, err := pathsafe.File(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	if sess.ContainerID == "" {
+		return nil, fmt.Errorf("session has no container")
+	}
+
+	workspace := s.sessionContainer(sess).MountPath
+	resolved, err := s.resolveWorkspacePath(ctx, sess.ContainerID, workspace, rel)
+	if err != nil {
+		return nil, err
+	}
+	reader, stat, err := s.dockerFor(sess).CopyFromContainer(ctx, sess.ContainerID, path.Join(workspace, resolved))
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil, fmt.Errorf("file not found: %s", rel)
+		}
+		return nil, fmt.Errorf("failed to copy from container: %w", err)
+	}
+	if !stat.Mode.IsRegular() {
+		reader.Close()
+		return nil, fmt.Errorf("invalid path %q: not a regular file", rel)
+	}
+
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			reader.Close()
+			if err == io.EOF {
+				return nil, fmt.Errorf("no file found in archive")
+			}
+			return nil, fmt.Errorf("tar read error: %w", err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			return &ContainerFile{Reader: tr, closer: reader, Name: path.Base(rel), Size: header.Size, ModTime: header.ModTime}, nil
+		}
+	}
+}
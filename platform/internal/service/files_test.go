@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"platform/internal/session"
+)
+
+func TestGlobMatch(t *testing.T) {
+	cases := []struct {
+		pattern, rel string
+		want         bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "pkg/util/x.go", true},
+		{"*.go", "README.md", false},
+		{"pkg/*.go", "pkg/a.go", true},
+		{"pkg/*.go", "pkg/util/x.go", false},
+	}
+	for _, tc := range cases {
+		if got := globMatch(tc.pattern, tc.rel); got != tc.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tc.pattern, tc.rel, got, tc.want)
+		}
+	}
+}
+
+func TestWriteContainerFileRequiresActiveSession(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tests := []struct {
+		status session.SessionStatus
+		want   error
+	}{
+		{session.StatusPaused, ErrSessionPaused},
+		{session.StatusSuspended, ErrSessionSuspended},
+		{session.StatusHibernated, nil},
+		{session.StatusTerminated, nil},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			repo := &statusRepo{sess: &session.Session{ID: "s1", ContainerID: "c1", Status: tt.status}}
+			s := &Service{SessionMgr: session.NewSessionManager(nil, repo, nil, nil, logger), Logger: logger}
+
+			// 未就绪的 session 在访问容器前被拒绝
+			_, err := s.WriteContainerFile(context.Background(), "s1", "a.txt", strings.NewReader("hi"), 2, 0)
+			if err == nil || (tt.want != nil && !errors.Is(err, tt.want)) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
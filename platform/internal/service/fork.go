@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"platform/internal/orchestrator"
+	"platform/internal/session"
+)
+
+// ForkSession 以 session 当前的工作区和 Agent 配置创建一个新 session：工作区保存为快照作为新 session 的种子，
+// 容器参数沿用源 session 的创建参数，最近一次 Configure 和对话记录复制到新 session，就绪后重放给它的 Agent。
+// 新 session 通过 ParentSessionID 关联源 session，之后两者互不影响
+func (s *Service) ForkSession(ctx context.Context, sourceID string) (*session.Session, error) {
+	src, err := s.SessionMgr.GetSession(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	if err := ensureActive(src); err != nil {
+		return nil, err
+	}
+	if src.Spec == nil {
+		return nil, fmt.Errorf("session %s was created without a recorded spec and cannot be forked", sourceID)
+	}
+
+	info, err := s.SnapshotWorkspace(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot workspace: %w", err)
+	}
+
+	spec := src.Spec
+	fallback := spec.Fallback
+	fork, err := s.CreateSession(ctx, session.SessionParams{
+		ProjectID: src.ProjectID,
+		UserID:    src.UserID,
+		Strategy:  src.Strategy,
+		EnvVars:   spec.EnvVars,
+		ContainerOpts: orchestrator.ContainerOptions{
+			Image:     spec.Image,
+			ProjectID: src.ProjectID,
+			EnvVars:   spec.EnvVars,
+
+			NetworkPolicy: spec.NetworkPolicy,
+			GPUCount:      spec.GPUCount,
+			GPUDeviceIDs:  spec.GPUDeviceIDs,
+			Mounts:        spec.Mounts,
+			Priority:      spec.Priority,
+		},
+		SnapshotID:      info.ID,
+		Fallback:        &fallback,
+		ParentSessionID: sourceID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.copyConversation(ctx, sourceID, fork.ID) {
+		go s.replayWhenReady(fork.ID)
+	}
+	s.Logger.Info("Session forked", "session_id", fork.ID, "parent_session_id", sourceID, "snapshot_id", info.ID)
+	return fork, nil
+}
+
+// copyConversation 将源 session 最近一次 Configure 和最近的对话记录复制到 to，返回是否复制了记录。失败只记录日志
+func (s *Service) copyConversation(ctx context.Context, from, to string) bool {
+	repo := s.conversations()
+	if repo == nil {
+		return false
+	}
+	configs, err := repo.ListMessages(ctx, from, []string{session.RoleConfigure}, 1)
+	if err != nil {
+		s.Logger.Warn("Failed to load configuration for fork", "session_id", from, "error", err)
+		return false
+	}
+	history, err := repo.ListMessages(ctx, from, []string{session.RoleUser, session.RoleAssistant}, replayHistoryLimit)
+	if err != nil {
+		s.Logger.Warn("Failed to load conversation for fork", "session_id", from, "error", err)
+		return false
+	}
+
+	copied := false
+	for _, msg := range append(configs, history...) {
+		msg.SessionID = to
+		if err := repo.AppendMessage(ctx, &msg); err != nil {
+			s.Logger.Warn("Failed to copy conversation message", "session_id", to, "error", err)
+			continue
+		}
+		copied = true
+	}
+	return copied
+}
+
+// replayWhenReady 等待新创建的 session 就绪后重放复制过来的配置和对话
+func (s *Service) replayWhenReady(sessionID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), restartReadyTimeout)
+	defer cancel()
+
+	if _, err := s.WaitForReady(ctx, sessionID, time.Second); err != nil {
+		s.Logger.Warn("Forked session did not become ready, skipping replay", "session_id", sessionID, "error", err)
+		return
+	}
+	s.replayAfterRestart(sessionID)
+}
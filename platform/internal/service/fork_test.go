@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"platform/internal/session"
+)
+
+type conversationStore struct {
+	session.SessionRepository
+	*memConversations
+}
+
+func TestCopyConversation(t *testing.T) {
+	ctx := context.Background()
+	repo := &memConversations{}
+	s := &Service{
+		SessionRepo: conversationStore{memConversations: repo},
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	if s.copyConversation(ctx, "src", "fork") {
+		t.Fatal("copied conversation from a session without records")
+	}
+
+	repo.AppendMessage(ctx, &session.Message{SessionID: "src", Role: session.RoleConfigure, Content: `{"systemPrompt":"old"}`})
+	repo.AppendMessage(ctx, &session.Message{SessionID: "src", Role: session.RoleUser, Content: "hi"})
+	repo.AppendMessage(ctx, &session.Message{SessionID: "src", Role: session.RoleConfigure, Content: `{"systemPrompt":"new"}`})
+	repo.AppendMessage(ctx, &session.Message{SessionID: "src", Role: session.RoleAssistant, Content: "hello"})
+
+	if !s.copyConversation(ctx, "src", "fork") {
+		t.Fatal("conversation not copied")
+	}
+	// 只复制最近一次配置，复制后的记录可以直接用于重放
+	req, err := s.replayRequest(ctx, repo, "fork")
+	if err != nil {
+		t.Fatalf("replayRequest: %v", err)
+	}
+	if req.SessionId != "fork" || req.SystemPrompt != "new" || req.AgentConfig[ConversationHistoryKey] == "" {
+		t.Fatalf("fork replay request = %+v", req)
+	}
+	if n := len(repo.msgs); n != 7 {
+		t.Fatalf("messages = %d, want 4 source + 3 copied", n)
+	}
+}
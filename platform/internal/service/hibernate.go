@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"platform/internal/sandbox"
+	"platform/internal/session"
+)
+
+// HibernateSession 将 session 容器保存为检查点并停止，释放内存；ResumeSession 从检查点恢复
+func (s *Service) HibernateSession(ctx context.Context, sessionID string) error {
+	return s.withSessionLock(ctx, sessionID, "hibernate", func() error {
+		return s.hibernateSession(ctx, sessionID)
+	})
+}
+
+func (s *Service) hibernateSession(ctx context.Context, sessionID string) error {
+	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("session not found: %w", err)
+	}
+	if sess.Status == session.StatusHibernated {
+		return fmt.Errorf("session already hibernated")
+	}
+	if sess.Status != session.StatusReady && sess.Status != session.StatusRunning {
+		return fmt.Errorf("session is not ready (status: %s)", sess.Status)
+	}
+	if sess.ContainerID == "" {
+		return fmt.Errorf("session is not ready: no container")
+	}
+
+	// CRIU 不能保存已建立的 TCP 连接，先断开到 Agent 的 gRPC 连接
+	s.Dispatcher.CleanUp(sessionID)
+
+	name := fmt.Sprintf("hibernate-%d", time.Now().Unix())
+	c := s.sessionContainer(sess)
+	if err := c.Checkpoint(ctx, name); err != nil {
+		return err
+	}
+
+	if err := s.SessionRepo.UpdateSessionCheckpoint(ctx, sessionID, name, s.CheckpointDir); err != nil {
+		return fmt.Errorf("failed to record checkpoint: %w", err)
+	}
+	if err := s.SessionRepo.UpdateSessionStatus(ctx, sessionID, session.StatusHibernated); err != nil {
+		return fmt.Errorf("failed to update session status: %w", err)
+	}
+
+	s.Logger.Info("Session hibernated", "session_id", sessionID, "container_id", sess.ContainerID, "checkpoint", name)
+	return nil
+}
+
+// ResumeSession 恢复已暂停、已休眠或因空闲被挂起的 session
+func (s *Service) ResumeSession(ctx context.Context, sessionID string) error {
+	return s.withSessionLock(ctx, sessionID, "resume", func() error {
+		sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+		if err != nil {
+			return fmt.Errorf("session not found: %w", err)
+		}
+		switch sess.Status {
+		case session.StatusPaused:
+			return s.unpauseSession(ctx, sess)
+		case session.StatusSuspended:
+			return s.resumeSuspended(ctx, sess)
+		}
+		return s.restoreSession(ctx, sess)
+	})
+}
+
+func (s *Service) restoreSession(ctx context.Context, sess *session.Session) error {
+	sessionID := sess.ID
+	if sess.Status != session.StatusHibernated || sess.Checkpoint == "" {
+		return fmt.Errorf("session is not paused or hibernated (status: %s)", sess.Status)
+	}
+
+	c := s.sessionContainer(sess)
+	c.Config.CheckpointDir = sess.CheckpointDir
+	if err := c.Restore(ctx, sess.Checkpoint); err != nil {
+		return err
+	}
+
+	if c.IP != "" && c.IP != sess.NodeIP {
+		if err := s.SessionRepo.UpdateSessionContainerInfo(ctx, sessionID, sess.ContainerID, c.IP); err != nil {
+			s.Logger.Warn("Failed to update container IP after resume", "session_id", sessionID, "error", err)
+		}
+	}
+	if err := s.SessionRepo.UpdateSessionCheckpoint(ctx, sessionID, "", ""); err != nil {
+		s.Logger.Warn("Failed to clear checkpoint", "session_id", sessionID, "error", err)
+	}
+	if err := s.SessionRepo.UpdateSessionStatus(ctx, sessionID, session.StatusReady); err != nil {
+		return fmt.Errorf("failed to update session status: %w", err)
+	}
+
+	s.Logger.Info("Session resumed", "session_id", sessionID, "container_id", sess.ContainerID, "ip", c.IP)
+	return nil
+}
+
+// sessionContainer 按 session 记录构造容器句柄，用于对已存在的容器执行操作
+func (s *Service) sessionContainer(sess *session.Session) *sandbox.Container {
+	c := sandbox.NewContainer(s.dockerFor(sess), sandbox.ContainerConfig{
+		SessionID:       sess.ID,
+		ProjectID:       sess.ProjectID,
+		UseAnonymousVol: true,
+		CheckpointDir:   s.CheckpointDir,
+	}, "", s.Logger)
+	c.ID = sess.ContainerID
+	c.IP = sess.NodeIP
+	return c
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"platform/internal/hostport"
+	"platform/internal/session"
+)
+
+// PortMapping 容器端口到宿主机端口的映射
+type PortMapping struct {
+	ContainerPort int `json:"container_port"`
+	HostPort      int `json:"host_port"`
+}
+
+// allocatePorts 为 owner 的每个容器端口分配宿主机端口，任一失败时释放本次已分配的端口
+func allocatePorts(ctx context.Context, ports *hostport.Allocator, sessionID, owner string, containerPorts []int) ([]PortMapping, error) {
+	if len(containerPorts) == 0 {
+		return nil, nil
+	}
+	if ports == nil {
+		return nil, fmt.Errorf("invalid request: host port publishing is not enabled")
+	}
+
+	mappings := make([]PortMapping, 0, len(containerPorts))
+	for _, cp := range containerPorts {
+		alloc, err := ports.Allocate(ctx, sessionID, owner, cp)
+		if err != nil {
+			_, _ = ports.Release(context.Background(), sessionID, owner)
+			return nil, fmt.Errorf("failed to allocate host port for %d: %w", cp, err)
+		}
+		mappings = append(mappings, PortMapping{ContainerPort: cp, HostPort: alloc.Port})
+	}
+	return mappings, nil
+}
+
+// releasePortOwner 释放 owner 前缀下的所有宿主机端口，失败只记录日志
+func releasePortOwner(ctx context.Context, ports *hostport.Allocator, sessionID, owner string, logger *slog.Logger, keep ...string) {
+	if ports == nil {
+		return
+	}
+	if _, err := ports.Release(ctx, sessionID, owner, keep...); err != nil {
+		logger.Warn("Failed to release host ports", "session_id", sessionID, "owner", owner, "error", err)
+	}
+}
+
+// ListHostPorts 返回 session 当前占用的全部宿主机端口
+func (s *Service) ListHostPorts(ctx context.Context, sessionID string) ([]*hostport.Allocation, error) {
+	if s.Ports == nil {
+		return []*hostport.Allocation{}, nil
+	}
+	if _, err := s.SessionMgr.GetSession(ctx, sessionID); err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	return s.Ports.List(ctx, sessionID)
+}
+
+// PruneHostPorts 回收已终止 session 遗留的宿主机端口（平台异常退出后未能正常释放）。
+// 非终态 session 的服务容器在平台重启后仍在运行，其端口保留。
+func (s *Service) PruneHostPorts(ctx context.Context) (int, error) {
+	if s.Ports == nil {
+		return 0, nil
+	}
+	sessions, err := s.SessionRepo.ListByStatus(ctx, session.LiveStatuses())
+	if err != nil {
+		return 0, err
+	}
+	// 项目级服务的端口以 project:<id> 记录，项目仍有活跃 session 时保留
+	active := make(map[string]bool, 2*len(sessions))
+	for _, sess := range sessions {
+		active[sess.ID] = true
+		active[projectPortScope(sess.ProjectID)] = true
+	}
+	return s.Ports.Prune(ctx, func(scope string) bool { return active[scope] })
+}
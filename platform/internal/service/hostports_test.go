@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+
+	"platform/internal/hostport"
+	"platform/internal/session"
+)
+
+type liveRepo struct {
+	session.SessionRepository
+	sessions []*session.Session
+}
+
+func (r *liveRepo) ListByStatus(_ context.Context, statuses []session.SessionStatus) ([]*session.Session, error) {
+	var out []*session.Session
+	for _, s := range r.sessions {
+		if slices.Contains(statuses, s.Status) {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+type memPortStore struct {
+	allocs []*hostport.Allocation
+}
+
+func (m *memPortStore) Insert(_ context.Context, a *hostport.Allocation) error {
+	m.allocs = append(m.allocs, a)
+	return nil
+}
+
+func (m *memPortStore) ListBySession(_ context.Context, sessionID string) ([]*hostport.Allocation, error) {
+	var out []*hostport.Allocation
+	for _, a := range m.allocs {
+		if a.SessionID == sessionID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (m *memPortStore) ListAll(context.Context) ([]*hostport.Allocation, error) {
+	return m.allocs, nil
+}
+
+func (m *memPortStore) Delete(_ context.Context, port int) error {
+	// Prune 遍历 ListAll 的结果时删除，不能原地修改
+	var out []*hostport.Allocation
+	for _, a := range m.allocs {
+		if a.Port != port {
+			out = append(out, a)
+		}
+	}
+	m.allocs = out
+	return nil
+}
+
+func liveSessions() *liveRepo {
+	return &liveRepo{sessions: []*session.Session{
+		{ID: "s-ready", ProjectID: "p1", Status: session.StatusReady},
+		{ID: "s-suspended", ProjectID: "p2", Status: session.StatusSuspended},
+		{ID: "s-terminated", ProjectID: "p3", Status: session.StatusTerminated},
+	}}
+}
+
+func TestPruneHostPortsKeepsSuspendedSessions(t *testing.T) {
+	store := &memPortStore{allocs: []*hostport.Allocation{
+		{Port: 20001, SessionID: "s-ready"},
+		{Port: 20002, SessionID: "s-suspended"},
+		{Port: 20003, SessionID: projectPortScope("p2")},
+		{Port: 20004, SessionID: "s-terminated"},
+		{Port: 20005, SessionID: projectPortScope("p3")},
+	}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := &Service{
+		SessionRepo: liveSessions(),
+		Ports:       hostport.NewAllocator(store, hostport.Config{}, logger),
+		Logger:      logger,
+	}
+
+	pruned, err := s.PruneHostPorts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kept []int
+	for _, a := range store.allocs {
+		kept = append(kept, a.Port)
+	}
+	if pruned != 2 || !slices.Equal(kept, []int{20001, 20002, 20003}) {
+		t.Fatalf("pruned = %d, kept = %v, want 2 pruned and [20001 20002 20003] kept", pruned, kept)
+	}
+}
+
+func TestListActiveSessionsIncludesSuspended(t *testing.T) {
+	s := &Service{SessionRepo: liveSessions()}
+	sessions, err := s.ListActiveSessions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, sess := range sessions {
+		ids = append(ids, sess.ID)
+	}
+	if !slices.Equal(ids, []string{"s-ready", "s-suspended"}) {
+		t.Fatalf("active sessions = %v", ids)
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"platform/internal/eventbus"
+	"platform/internal/session"
+)
+
+// 容器意外退出后的处理，记录在 session.container_died 事件中
+const (
+	deadContainerReprovision = "reprovision"
+	deadContainerError       = "error"
+)
+
+// HandleDeadContainer 作为 Pool.OnLeaseDead 处理租出后意外停止或被删除的 session 容器。
+// 只处理仍在使用该容器的 Ready/Running session，挂起、休眠或重启中的容器停止是预期的。
+// 启用 HealDeadContainers 时用原容器的工作区快照重新分配容器，否则归还容器并将 session 标记为 error
+func (s *Service) HandleDeadContainer(ctx context.Context, sessionID, containerID, state string) {
+	err := s.withSessionLock(ctx, sessionID, "heal", func() error {
+		sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+		if err != nil {
+			return fmt.Errorf("session not found: %w", err)
+		}
+		// 持锁后重新读取：通知发出后 session 可能已被挂起、重启或终止
+		if sess.ContainerID != containerID || (sess.Status != session.StatusReady && sess.Status != session.StatusRunning) {
+			return nil
+		}
+
+		action := deadContainerError
+		if s.HealDeadContainers && sess.Spec != nil {
+			action = deadContainerReprovision
+		}
+		s.Logger.Warn("Session container died",
+			"session_id", sessionID,
+			"container_id", containerID,
+			"state", state,
+			"action", action,
+		)
+		if s.Bus != nil {
+			s.Bus.Publish(ctx, sessionID, eventbus.Event{
+				Type:      eventbus.EventSessionContainerDied,
+				SessionID: sessionID,
+				Payload:   map[string]string{"container_id": containerID, "state": state, "action": action},
+				Timestamp: time.Now(),
+			})
+		}
+
+		if action == deadContainerReprovision {
+			return s.reprovisionSession(ctx, sess, "container "+state)
+		}
+
+		// 归还预热池，否则已退出的容器一直占用租约和名额
+		s.Dispatcher.CleanUp(sessionID)
+		s.endpoints.Forget(sessionID)
+		s.releaseContainer(ctx, sess)
+		if err := s.SessionRepo.UpdateSessionContainerInfo(ctx, sessionID, "", ""); err != nil {
+			s.Logger.Warn("Failed to clear container info", "session_id", sessionID, "error", err)
+		}
+		if err := s.SessionRepo.UpdateSessionStatus(ctx, sessionID, session.StatusError); err != nil {
+			return fmt.Errorf("failed to mark session error: %w", err)
+		}
+		if s.Bus != nil {
+			s.Bus.Publish(ctx, sessionID, eventbus.Event{
+				Type:      eventbus.EventSessionError,
+				SessionID: sessionID,
+				Payload:   fmt.Sprintf("session container %s is %s", containerID, state),
+				Timestamp: time.Now(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		s.Logger.Error("Failed to handle dead session container", "session_id", sessionID, "container_id", containerID, "error", err)
+	}
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"platform/internal/session"
+)
+
+type statusRepo struct {
+	session.SessionRepository
+	sess    *session.Session
+	updates []session.SessionStatus
+}
+
+func (r *statusRepo) GetByID(context.Context, string) (*session.Session, error) {
+	return r.sess, nil
+}
+
+func (r *statusRepo) UpdateSessionStatus(_ context.Context, _ string, status session.SessionStatus) error {
+	r.updates = append(r.updates, status)
+	return nil
+}
+
+func TestHandleDeadContainerIgnoresExpectedStops(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for name, sess := range map[string]*session.Session{
+		// 挂起和休眠会停止容器
+		"suspended":  {ID: "s1", ContainerID: "c1", Status: session.StatusSuspended},
+		"hibernated": {ID: "s1", ContainerID: "c1", Status: session.StatusHibernated},
+		// 重启已为 session 换了容器，旧容器的通知过时
+		"replaced":   {ID: "s1", ContainerID: "c2", Status: session.StatusReady},
+		"terminated": {ID: "s1", ContainerID: "c1", Status: session.StatusTerminated},
+	} {
+		repo := &statusRepo{sess: sess}
+		s := &Service{
+			SessionMgr:  session.NewSessionManager(nil, repo, nil, nil, logger),
+			SessionRepo: repo,
+			Logger:      logger,
+		}
+		s.HandleDeadContainer(context.Background(), "s1", "c1", "exited")
+		if len(repo.updates) != 0 {
+			t.Errorf("%s: status updates = %v, want none", name, repo.updates)
+		}
+	}
+}
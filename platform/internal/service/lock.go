@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"platform/internal/lock"
+)
+
+// sessionLockWait 同一 session 上的操作等待前一个操作结束的最长时间，超时返回 409
+const sessionLockWait = 10 * time.Second
+
+// withSessionLock 串行化同一 session 上会修改容器或会话状态的操作（终止、重启、同步、compose 等），
+// 避免并发请求交错执行后留下已拆除一半的容器和 Ready 的数据库状态。未配置 Locks 时直接执行。
+func (s *Service) withSessionLock(ctx context.Context, sessionID, op string, fn func() error) error {
+	if s.Locks == nil {
+		return fn()
+	}
+
+	lk, err := s.Locks.Acquire(ctx, sessionLockKey(sessionID), op, sessionLockWait)
+	if err != nil {
+		if errors.Is(err, lock.ErrNotAcquired) {
+			return fmt.Errorf("session %s is busy: %w", sessionID, err)
+		}
+		return fmt.Errorf("failed to acquire session lock: %w", err)
+	}
+	defer func() {
+		// 请求 ctx 可能已取消，释放锁使用独立的 ctx
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := lk.Release(releaseCtx); err != nil {
+			s.Logger.Warn("Failed to release session lock", "session_id", sessionID, "op", op, "error", err)
+		}
+	}()
+
+	return fn()
+}
+
+func sessionLockKey(sessionID string) string {
+	return "session:" + sessionID + ":lock"
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"platform/internal/logging"
+)
+
+// LogLevels 当前默认级别和组件覆盖级别
+type LogLevels struct {
+	Default    string            `json:"default"`
+	Components map[string]string `json:"components"`
+}
+
+func (s *Service) GetLogLevels() (*LogLevels, error) {
+	if s.LogLevels == nil {
+		return nil, fmt.Errorf("log level control not initialized")
+	}
+	def, overrides := s.LogLevels.Snapshot()
+	return &LogLevels{
+		Default:    strings.ToLower(def.String()),
+		Components: logging.FormatOverrides(overrides),
+	}, nil
+}
+
+// SetLogLevel 修改组件级别，component 为空时修改默认级别；立即对所有 logger 生效
+func (s *Service) SetLogLevel(component, level string) (*LogLevels, error) {
+	if s.LogLevels == nil {
+		return nil, fmt.Errorf("log level control not initialized")
+	}
+	parsed, err := logging.ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	if component == "" {
+		s.LogLevels.SetDefault(parsed)
+	} else {
+		s.LogLevels.Set(component, parsed)
+	}
+	s.Logger.Info("Log level changed", "target_component", component, "level", level)
+	return s.GetLogLevels()
+}
+
+// ResetLogLevel 移除组件覆盖，恢复默认级别
+func (s *Service) ResetLogLevel(component string) (*LogLevels, error) {
+	if s.LogLevels == nil {
+		return nil, fmt.Errorf("log level control not initialized")
+	}
+	s.LogLevels.Reset(component)
+	s.Logger.Info("Log level override removed", "target_component", component)
+	return s.GetLogLevels()
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+
+	"github.com/docker/docker/client"
+
+	"platform/internal/session"
+)
+
+// dockerFor 返回 session 容器所在节点的 Docker 客户端，未配置多节点或 session 在本机时为 s.Docker
+func (s *Service) dockerFor(sess *session.Session) *client.Client {
+	if s.Nodes == nil || sess.NodeID == "" {
+		return s.Docker
+	}
+	node, err := s.Nodes.Node(sess.NodeID)
+	if err != nil {
+		s.Logger.Warn("Session node is not configured, using local docker", "session_id", sess.ID, "node_id", sess.NodeID, "error", err)
+		return s.Docker
+	}
+	s.Nodes.Remember(sess.ContainerID, node.ID)
+	return node.Client
+}
+
+// containerDocker 按容器 ID 定位所在节点的 Docker 客户端，用于只有容器 ID 的调用方
+func (s *Service) containerDocker(ctx context.Context, containerID string) *client.Client {
+	if s.Nodes == nil {
+		return s.Docker
+	}
+	return s.Nodes.Locate(ctx, containerID).Client
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"platform/internal/session"
+)
+
+// ErrSessionPaused session 已暂停，恢复前拒绝对话和命令执行
+var ErrSessionPaused = errors.New("session is paused")
+
+// ErrSessionSuspended session 因空闲被挂起，容器已停止，需先通过 ResumeSession 恢复
+var ErrSessionSuspended = errors.New("session is suspended")
+
+// ensureActive 检查 session 能否接收对话和执行命令
+func ensureActive(sess *session.Session) error {
+	if sess.Status == session.StatusPaused {
+		return ErrSessionPaused
+	}
+	if sess.Status == session.StatusSuspended {
+		return ErrSessionSuspended
+	}
+	if sess.Status != session.StatusReady && sess.Status != session.StatusRunning {
+		return fmt.Errorf("session is not ready (status: %s)", sess.Status)
+	}
+	return nil
+}
+
+// PauseSession 冻结 session 容器内的所有进程。与休眠不同，容器内存不会释放，
+// 但恢复不需要 CRIU，也不会断开已建立的连接；暂停期间不计入用量
+func (s *Service) PauseSession(ctx context.Context, sessionID string) error {
+	return s.withSessionLock(ctx, sessionID, "pause", func() error {
+		return s.pauseSession(ctx, sessionID)
+	})
+}
+
+func (s *Service) pauseSession(ctx context.Context, sessionID string) error {
+	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("session not found: %w", err)
+	}
+	if sess.Status == session.StatusPaused {
+		return fmt.Errorf("session already paused")
+	}
+	if sess.Status != session.StatusReady && sess.Status != session.StatusRunning {
+		return fmt.Errorf("session is not ready (status: %s)", sess.Status)
+	}
+	if sess.ContainerID == "" {
+		return fmt.Errorf("session is not ready: no container")
+	}
+
+	if err := s.sessionContainer(sess).Pause(ctx); err != nil {
+		return err
+	}
+	if err := s.SessionRepo.UpdateSessionStatus(ctx, sessionID, session.StatusPaused); err != nil {
+		return fmt.Errorf("failed to update session status: %w", err)
+	}
+	if pauses, ok := s.SessionRepo.(session.PauseRepository); ok {
+		if err := pauses.OpenPauseWindow(ctx, sessionID, time.Now()); err != nil {
+			s.Logger.Warn("Failed to record pause window", "session_id", sessionID, "error", err)
+		}
+	}
+
+	s.Logger.Info("Session paused", "session_id", sessionID, "container_id", sess.ContainerID)
+	return nil
+}
+
+func (s *Service) unpauseSession(ctx context.Context, sess *session.Session) error {
+	if err := s.sessionContainer(sess).Unpause(ctx); err != nil {
+		return err
+	}
+	s.closePauseWindow(ctx, sess.ID)
+	if err := s.SessionRepo.UpdateSessionStatus(ctx, sess.ID, session.StatusReady); err != nil {
+		return fmt.Errorf("failed to update session status: %w", err)
+	}
+
+	s.Logger.Info("Session unpaused", "session_id", sess.ID, "container_id", sess.ContainerID)
+	return nil
+}
+
+func (s *Service) closePauseWindow(ctx context.Context, sessionID string) {
+	if pauses, ok := s.SessionRepo.(session.PauseRepository); ok {
+		if err := pauses.ClosePauseWindow(ctx, sessionID, time.Now()); err != nil {
+			s.Logger.Warn("Failed to close pause window", "session_id", sessionID, "error", err)
+		}
+	}
+}
+
+// PauseUsage session 的暂停记录，用量统计时从存活时长中扣除 TotalPaused
+type PauseUsage struct {
+	Windows     []session.PauseWindow `json:"windows"`
+	TotalPaused time.Duration         `json:"total_paused_ns"`
+}
+
+func (s *Service) GetPauseUsage(ctx context.Context, sessionID string) (*PauseUsage, error) {
+	if _, err := s.SessionMgr.GetSession(ctx, sessionID); err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	usage := &PauseUsage{Windows: []session.PauseWindow{}}
+	pauses, ok := s.SessionRepo.(session.PauseRepository)
+	if !ok {
+		return usage, nil
+	}
+	windows, err := pauses.ListPauseWindows(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pause windows: %w", err)
+	}
+	usage.Windows = windows
+	usage.TotalPaused = session.TotalPaused(windows, time.Now())
+	return usage, nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"platform/internal/orchestrator"
+)
+
+// errPoolUnavailable 未注入预热池
+var errPoolUnavailable = errors.New("warm pool not found")
+
+// PoolStatus 返回各预热池的空闲/租出数量、容器年龄与健康状态和冷却状态，probe 为 true 时检查容器是否仍在运行
+func (s *Service) PoolStatus(ctx context.Context, probe bool) ([]orchestrator.PoolStatus, error) {
+	if s.Pool == nil {
+		return nil, errPoolUnavailable
+	}
+	return s.Pool.Status(ctx, probe), nil
+}
+
+// ReplenishPool 结束冷却并立即补充 name 对应的预热池，name 为空时补充所有预热池
+func (s *Service) ReplenishPool(ctx context.Context, name string) ([]orchestrator.PoolStatus, error) {
+	if s.Pool == nil {
+		return nil, errPoolUnavailable
+	}
+	if err := s.Pool.Replenish(name); err != nil {
+		return nil, err
+	}
+	s.Logger.Info("Warm pool replenish requested", "warm_pool", name)
+	return s.Pool.Status(ctx, false), nil
+}
+
+// DrainPool 删除 name 对应预热池的空闲容器并暂停分配和补充，name 为空时排空所有预热池
+func (s *Service) DrainPool(ctx context.Context, name string) ([]orchestrator.PoolStatus, error) {
+	if s.Pool == nil {
+		return nil, errPoolUnavailable
+	}
+	if err := s.Pool.Drain(name); err != nil {
+		return nil, err
+	}
+	s.Logger.Warn("Warm pool drained by administrator", "warm_pool", name)
+	return s.Pool.Status(ctx, false), nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"platform/internal/projectstack"
+)
+
+func (s *Service) GetProjectStack(ctx context.Context, projectID string) (*projectstack.Stack, error) {
+	if s.ProjectStacks == nil {
+		return nil, fmt.Errorf("project stack store not initialized")
+	}
+	return s.ProjectStacks.Get(ctx, projectID)
+}
+
+// UpdateProjectStack 整体替换项目的默认服务，只影响之后就绪的 session
+func (s *Service) UpdateProjectStack(ctx context.Context, stack *projectstack.Stack) error {
+	if s.ProjectStacks == nil {
+		return fmt.Errorf("project stack store not initialized")
+	}
+	if stack.Compose != nil {
+		if err := ValidateStackName(stack.Compose.Name); err != nil {
+			return err
+		}
+	}
+	return s.ProjectStacks.Upsert(ctx, stack)
+}
+
+func (s *Service) DeleteProjectStack(ctx context.Context, projectID string) error {
+	if s.ProjectStacks == nil {
+		return fmt.Errorf("project stack store not initialized")
+	}
+	return s.ProjectStacks.Delete(ctx, projectID)
+}
+
+// ProvisionProjectStack 为就绪的 session 启动所属项目声明的伴随服务和 compose stack。
+// 已存在的同名服务和 stack 会跳过，创建任务重试时可以重复调用；
+// 启动的服务归属 session，随 session 终止由 releaseSessionResources 删除。
+// 单个服务失败不影响其余服务，全部尝试后返回合并的错误
+func (s *Service) ProvisionProjectStack(ctx context.Context, sessionID string) error {
+	if s.ProjectStacks == nil {
+		return nil
+	}
+	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("session not found: %w", err)
+	}
+	if sess.ProjectID == "" || ensureActive(sess) != nil {
+		return nil
+	}
+	stack, err := s.ProjectStacks.Get(ctx, sess.ProjectID)
+	if errors.Is(err, projectstack.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load project stack: %w", err)
+	}
+	if stack.Empty() {
+		return nil
+	}
+
+	existing := make(map[string]bool)
+	for _, svc := range s.ListCompanionServices(sessionID) {
+		existing[svc.Name] = true
+	}
+
+	var errs []error
+	for _, spec := range stack.Services {
+		if existing[spec.Name] {
+			continue
+		}
+		svc, err := s.CreateCompanionService(ctx, sessionID, CreateServiceRequest{
+			Name:        spec.Name,
+			Image:       spec.Image,
+			EnvVars:     spec.EnvVars,
+			Cmd:         spec.Cmd,
+			ExposePorts: spec.ExposePorts,
+			Scope:       spec.Scope,
+			MemoryMB:    spec.MemoryMB,
+			CPUs:        spec.CPUs,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("service %s: %w", spec.Name, err))
+			continue
+		}
+		s.Logger.Info("Provisioned project service",
+			"session_id", sessionID,
+			"project_id", sess.ProjectID,
+			"name", spec.Name,
+			"service_id", svc.ID,
+		)
+	}
+
+	if c := stack.Compose; c != nil && s.Compose != nil && s.Compose.GetStack(sessionID, c.Name) == nil {
+		if _, err := s.CreateComposeStack(ctx, sessionID, c.Name, CreateComposeRequest{
+			ComposeContent: c.Content,
+			ExposePorts:    c.ExposePorts,
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("compose stack %s: %w", c.Name, err))
+		} else {
+			s.Logger.Info("Provisioned project compose stack", "session_id", sessionID, "project_id", sess.ProjectID, "stack", c.Name)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+
+	"platform/internal/queuepos"
+	"platform/internal/session"
+)
+
+// SessionQueuePosition 返回初始化中的 session 的排队位置和预计就绪时间，其他状态或无法估算时返回 nil
+func (s *Service) SessionQueuePosition(ctx context.Context, sess *session.Session) *queuepos.Position {
+	if s.QueuePositions == nil || sess.Status != session.StatusInitializing {
+		return nil
+	}
+	pos, err := s.QueuePositions.Estimate(ctx, sess.ID)
+	if err != nil {
+		s.Logger.Warn("Failed to estimate queue position", "session_id", sess.ID, "error", err)
+		return nil
+	}
+	return pos
+}
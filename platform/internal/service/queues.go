@@ -0,0 +1,83 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hibiken/asynq"
+)
+
+// QueueTaskStates 可查询的任务状态
+var QueueTaskStates = []string{"pending", "active", "scheduled", "retry", "archived", "completed"}
+
+// ListQueues 返回所有 asynq 队列的统计信息，按队列名排序
+func (s *Service) ListQueues() ([]*asynq.QueueInfo, error) {
+	if s.Queues == nil {
+		return nil, fmt.Errorf("queue inspector not initialized")
+	}
+
+	names, err := s.Queues.Queues()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queues: %w", err)
+	}
+	sort.Strings(names)
+
+	infos := make([]*asynq.QueueInfo, 0, len(names))
+	for _, name := range names {
+		info, err := s.Queues.GetQueueInfo(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect queue %s: %w", name, err)
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// ListQueueTasks 分页列出队列中某个状态的任务，page 从 1 开始
+func (s *Service) ListQueueTasks(queue, state string, page, size int) ([]*asynq.TaskInfo, error) {
+	if s.Queues == nil {
+		return nil, fmt.Errorf("queue inspector not initialized")
+	}
+
+	opts := []asynq.ListOption{asynq.Page(page), asynq.PageSize(size)}
+	switch state {
+	case "pending":
+		return s.Queues.ListPendingTasks(queue, opts...)
+	case "active":
+		return s.Queues.ListActiveTasks(queue, opts...)
+	case "scheduled":
+		return s.Queues.ListScheduledTasks(queue, opts...)
+	case "retry":
+		return s.Queues.ListRetryTasks(queue, opts...)
+	case "archived":
+		return s.Queues.ListArchivedTasks(queue, opts...)
+	case "completed":
+		return s.Queues.ListCompletedTasks(queue, opts...)
+	default:
+		return nil, fmt.Errorf("invalid task state %q", state)
+	}
+}
+
+// RunQueueTask 立即执行 scheduled / retry / archived 状态的任务
+func (s *Service) RunQueueTask(queue, taskID string) error {
+	if s.Queues == nil {
+		return fmt.Errorf("queue inspector not initialized")
+	}
+	if err := s.Queues.RunTask(queue, taskID); err != nil {
+		return fmt.Errorf("failed to run task %s: %w", taskID, err)
+	}
+	s.Logger.Info("Queue task run manually", "queue", queue, "task_id", taskID)
+	return nil
+}
+
+// DeleteQueueTask 删除非 active 状态的任务
+func (s *Service) DeleteQueueTask(queue, taskID string) error {
+	if s.Queues == nil {
+		return fmt.Errorf("queue inspector not initialized")
+	}
+	if err := s.Queues.DeleteTask(queue, taskID); err != nil {
+		return fmt.Errorf("failed to delete task %s: %w", taskID, err)
+	}
+	s.Logger.Info("Queue task deleted", "queue", queue, "task_id", taskID)
+	return nil
+}
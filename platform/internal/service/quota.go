@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"platform/internal/quota"
+)
+
+// QuotaStatus session 及其租户的服务配额和当前占用
+type QuotaStatus struct {
+	Enabled bool         `json:"enabled"`
+	Session quota.Status `json:"session"`
+	Tenant  quota.Status `json:"tenant"`
+}
+
+func (s *Service) GetQuota(ctx context.Context, sessionID string) (*QuotaStatus, error) {
+	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	if s.Quota == nil {
+		return &QuotaStatus{}, nil
+	}
+	sessionStatus, tenantStatus, err := s.Quota.SessionStatus(ctx, sessionID, sess.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load quota usage: %w", err)
+	}
+	return &QuotaStatus{Enabled: true, Session: sessionStatus, Tenant: tenantStatus}, nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"platform/internal/eventbus"
+)
+
+// ReadinessState 实例是否可以接收流量
+type ReadinessState string
+
+const (
+	// ReadinessWarming 预热池尚未达到目标空闲数，此时创建的 session 大多需要冷启动
+	ReadinessWarming ReadinessState = "warming"
+	ReadinessReady   ReadinessState = "ready"
+)
+
+// 标记就绪的原因
+const (
+	ReadyReasonPoolWarm = "pool_warm"
+	ReadyReasonTimeout  = "timeout"
+)
+
+// Readiness 实例当前的就绪状态
+type Readiness struct {
+	State ReadinessState
+	// Reason 就绪的原因，预热中时为空
+	Reason string
+	Since  time.Time
+}
+
+// Readiness 返回实例的就绪状态，未启用预热门控时总是就绪
+func (s *Service) Readiness() Readiness {
+	if r := s.readiness.Load(); r != nil {
+		return *r
+	}
+	return Readiness{State: ReadinessReady}
+}
+
+// SetWarming 将实例标记为预热中，直到 AwaitPoolWarm 完成
+func (s *Service) SetWarming() {
+	s.readiness.Store(&Readiness{State: ReadinessWarming, Since: time.Now()})
+}
+
+// AwaitPoolWarm 每秒检查一次 warm，返回 true 或超过 timeout 后把实例标记为就绪，
+// 并在 eventbus.PlatformChannel 上发布 pool.ready 事件。ctx 取消时直接返回
+func (s *Service) AwaitPoolWarm(ctx context.Context, warm func() bool, timeout time.Duration) {
+	start := time.Now()
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	reason := ReadyReasonPoolWarm
+	for !warm() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			reason = ReadyReasonTimeout
+		case <-ticker.C:
+			continue
+		}
+		break
+	}
+
+	s.readiness.Store(&Readiness{State: ReadinessReady, Reason: reason, Since: time.Now()})
+	waited := time.Since(start)
+	s.Logger.Info("Instance ready", "reason", reason, "waited", waited)
+	if s.Bus == nil {
+		return
+	}
+	if err := s.Bus.Publish(ctx, eventbus.PlatformChannel, eventbus.Event{
+		Type:      eventbus.EventPoolReady,
+		SessionID: eventbus.PlatformChannel,
+		Payload:   map[string]any{"reason": reason, "waited_ms": waited.Milliseconds()},
+		Timestamp: time.Now(),
+	}); err != nil {
+		s.Logger.Warn("Failed to publish pool ready event", "error", err)
+	}
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"platform/internal/eventbus"
+)
+
+func TestAwaitPoolWarm(t *testing.T) {
+	bus := &recordingBus{}
+	s := &Service{Bus: bus, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	if got := s.Readiness().State; got != ReadinessReady {
+		t.Fatalf("readiness without gate = %s, want ready", got)
+	}
+
+	s.SetWarming()
+	if got := s.Readiness().State; got != ReadinessWarming {
+		t.Fatalf("readiness after SetWarming = %s", got)
+	}
+	s.AwaitPoolWarm(context.Background(), func() bool { return true }, time.Minute)
+	if r := s.Readiness(); r.State != ReadinessReady || r.Reason != ReadyReasonPoolWarm {
+		t.Fatalf("readiness = %+v", r)
+	}
+	if len(bus.events) != 1 || bus.events[0].Type != eventbus.EventPoolReady {
+		t.Fatalf("events = %+v", bus.events)
+	}
+
+	// 预热池一直未达标时超时后仍标记就绪
+	s.SetWarming()
+	s.AwaitPoolWarm(context.Background(), func() bool { return false }, 10*time.Millisecond)
+	if r := s.Readiness(); r.State != ReadinessReady || r.Reason != ReadyReasonTimeout {
+		t.Fatalf("readiness after timeout = %+v", r)
+	}
+}
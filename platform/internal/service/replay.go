@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"platform/internal/agentproto"
+	"platform/internal/eventbus"
+	"platform/internal/session"
+)
+
+const (
+	// replayHistoryLimit 重放给新 Agent 的最大消息条数，更早的消息丢弃
+	replayHistoryLimit = 100
+	// replayTimeout 容器替换后等待新 Agent 就绪并完成重放的最长时间
+	replayTimeout = 60 * time.Second
+	// replayRetryInterval 新 Agent 尚未监听时的重试间隔
+	replayRetryInterval = 2 * time.Second
+	// ConversationHistoryKey ConfigureRequest.AgentConfig 中携带重放历史的键，值为 JSON 数组
+	ConversationHistoryKey = "conversation_history"
+)
+
+// conversations 返回对话记录存储，SessionRepo 不支持时返回 nil
+func (s *Service) conversations() session.ConversationRepository {
+	repo, _ := s.SessionRepo.(session.ConversationRepository)
+	return repo
+}
+
+// recordMessage 追加一条对话记录，失败只记录日志，不影响对话本身
+func (s *Service) recordMessage(ctx context.Context, sessionID, role, content string) {
+	repo := s.conversations()
+	if repo == nil || content == "" {
+		return
+	}
+	if err := repo.AppendMessage(ctx, &session.Message{SessionID: sessionID, Role: role, Content: content}); err != nil {
+		s.Logger.Warn("Failed to record conversation message", "session_id", sessionID, "role", role, "error", err)
+	}
+}
+
+// RecordAgentEvent 作为 Dispatcher.OnEvent 持久化 Agent 的最终回答
+func (s *Service) RecordAgentEvent(ctx context.Context, event eventbus.Event) {
+	if event.Type != eventbus.EventAgentAnswer {
+		return
+	}
+	s.recordMessage(ctx, event.SessionID, session.RoleAssistant, payloadString(event.Payload, "text"))
+}
+
+// ReplayConversation 将持久化的对话记录通过 Configure 重新发送给 session 当前的 Agent，
+// 用于容器重启或迁移后恢复上下文。没有任何记录时不做处理
+func (s *Service) ReplayConversation(ctx context.Context, sessionID string) error {
+	repo := s.conversations()
+	if repo == nil {
+		return nil
+	}
+	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("session not found: %w", err)
+	}
+	if err := ensureActive(sess); err != nil {
+		return err
+	}
+
+	req, err := s.replayRequest(ctx, repo, sessionID)
+	if err != nil || req == nil {
+		return err
+	}
+	_, err = s.Dispatcher.Configure(ctx, s.sessionContainer(sess), req)
+	return err
+}
+
+// replayRequest 以最近一次 Configure 请求为基础，附带最近的对话历史
+func (s *Service) replayRequest(ctx context.Context, repo session.ConversationRepository, sessionID string) (*agentproto.ConfigureRequest, error) {
+	history, err := repo.ListMessages(ctx, sessionID, []string{session.RoleUser, session.RoleAssistant}, replayHistoryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation: %w", err)
+	}
+	configs, err := repo.ListMessages(ctx, sessionID, []string{session.RoleConfigure}, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if len(history) == 0 && len(configs) == 0 {
+		return nil, nil
+	}
+
+	req := &agentproto.ConfigureRequest{}
+	if len(configs) > 0 {
+		if err := protojson.Unmarshal([]byte(configs[0].Content), req); err != nil {
+			return nil, fmt.Errorf("invalid recorded configuration: %w", err)
+		}
+	}
+	req.SessionId = sessionID
+	if len(history) > 0 {
+		data, err := json.Marshal(history)
+		if err != nil {
+			return nil, err
+		}
+		if req.AgentConfig == nil {
+			req.AgentConfig = make(map[string]string)
+		}
+		req.AgentConfig[ConversationHistoryKey] = string(data)
+	}
+	return req, nil
+}
+
+// replayAfterRestart 在后台等待新 Agent 启动并重放对话，Agent 监听前 Configure 会失败，按间隔重试
+func (s *Service) replayAfterRestart(sessionID string) {
+	if s.conversations() == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), replayTimeout)
+		defer cancel()
+
+		for {
+			err := s.ReplayConversation(ctx, sessionID)
+			if err == nil {
+				s.Logger.Info("Conversation replayed", "session_id", sessionID)
+				return
+			}
+			select {
+			case <-ctx.Done():
+				s.Logger.Warn("Failed to replay conversation", "session_id", sessionID, "error", err)
+				return
+			case <-time.After(replayRetryInterval):
+			}
+		}
+	}()
+}
+
+// ResetAgent 在 session 容器被外部替换（如 Docker 重启后 IP 变化）时断开旧连接并重放对话
+func (s *Service) ResetAgent(sessionID string) {
+	s.Dispatcher.CleanUp(sessionID)
+	s.replayAfterRestart(sessionID)
+}
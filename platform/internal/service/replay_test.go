@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"platform/internal/session"
+)
+
+type memConversations struct {
+	msgs []session.Message
+}
+
+func (m *memConversations) AppendMessage(_ context.Context, msg *session.Message) error {
+	m.msgs = append(m.msgs, *msg)
+	return nil
+}
+
+func (m *memConversations) ListMessages(_ context.Context, sessionID string, roles []string, limit int) ([]session.Message, error) {
+	var out []session.Message
+	for _, msg := range m.msgs {
+		if msg.SessionID == sessionID && slices.Contains(roles, msg.Role) {
+			out = append(out, msg)
+		}
+	}
+	if len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out, nil
+}
+
+func TestReplayRequest(t *testing.T) {
+	s := &Service{}
+	repo := &memConversations{}
+	ctx := context.Background()
+
+	if req, err := s.replayRequest(ctx, repo, "s1"); err != nil || req != nil {
+		t.Fatalf("empty conversation = %v, %v; want nil", req, err)
+	}
+
+	repo.AppendMessage(ctx, &session.Message{SessionID: "s1", Role: session.RoleConfigure, Content: `{"systemPrompt":"old"}`})
+	repo.AppendMessage(ctx, &session.Message{SessionID: "s1", Role: session.RoleUser, Content: "hi"})
+	repo.AppendMessage(ctx, &session.Message{SessionID: "s1", Role: session.RoleConfigure, Content: `{"systemPrompt":"be brief","agentConfig":{"model":"m"}}`})
+	repo.AppendMessage(ctx, &session.Message{SessionID: "s1", Role: session.RoleAssistant, Content: "hello"})
+	repo.AppendMessage(ctx, &session.Message{SessionID: "s2", Role: session.RoleUser, Content: "other"})
+
+	req, err := s.replayRequest(ctx, repo, "s1")
+	if err != nil {
+		t.Fatalf("replayRequest: %v", err)
+	}
+	// 使用最近一次配置
+	if req.SessionId != "s1" || req.SystemPrompt != "be brief" || req.AgentConfig["model"] != "m" {
+		t.Fatalf("request = %+v", req)
+	}
+
+	var history []session.Message
+	if err := json.Unmarshal([]byte(req.AgentConfig[ConversationHistoryKey]), &history); err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(history) != 2 || history[0].Content != "hi" || history[1].Role != session.RoleAssistant {
+		t.Fatalf("history = %+v", history)
+	}
+}
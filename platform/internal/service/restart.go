@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"platform/internal/eventbus"
+	"platform/internal/orchestrator"
+	"platform/internal/session"
+
+	"github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types/container"
+)
+
+// restartReadyTimeout 重新分配容器后等待 session 就绪的最长时间
+const restartReadyTimeout = 5 * time.Minute
+
+// 重启方式，记录在 session.restarting 事件中
+const (
+	restartInPlace     = "in_place"
+	restartReprovision = "reprovision"
+)
+
+// RestartSession 重启 session 的容器，fresh 为 true 时不尝试原地启动，直接重新分配容器
+func (s *Service) RestartSession(ctx context.Context, sessionID string, fresh bool) error {
+	return s.withSessionLock(ctx, sessionID, "restart", func() error {
+		return s.restartSession(ctx, sessionID, fresh)
+	})
+}
+
+// restartSession 重启 session 的容器，期间 session 处于 Initializing，完成后回到 Ready 并重放对话。
+// 冷启动容器原地启动，启动命令会拉起 Agent；预热容器的 Agent 由 worker 启动，容器已不存在或无法启动时
+// 同样改为重新分配容器，由 worker 重新完成初始化
+func (s *Service) restartSession(ctx context.Context, sessionID string, fresh bool) error {
+	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("session not found: %w", err)
+	}
+
+	// 持锁后重新读取状态：排队期间 session 可能已被终止，不能再把它标记回 Ready
+	if sess.Status.IsTerminal() {
+		return fmt.Errorf("session already %s", sess.Status)
+	}
+	if sess.Status == session.StatusInitializing {
+		return fmt.Errorf("session is still initializing")
+	}
+
+	if sess.ContainerID == "" {
+		return s.reprovisionSession(ctx, sess, "no container")
+	}
+	if fresh {
+		return s.reprovisionSession(ctx, sess, "requested")
+	}
+
+	inspect, err := s.dockerFor(sess).ContainerInspect(ctx, sess.ContainerID)
+	if errdefs.IsNotFound(err) {
+		return s.reprovisionSession(ctx, sess, "container missing")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	// 只重启未运行的容器，避免中途重启导致状态不一致
+	if inspect.State.Running {
+		s.Logger.Info("Container already running", "session_id", sessionID, "container_id", sess.ContainerID)
+		return nil
+	}
+
+	if sess.ContainerStrategy() == orchestrator.WarmStrategyType {
+		return s.reprovisionSession(ctx, sess, "warm container")
+	}
+
+	if err := s.restartInPlace(ctx, sess); err != nil {
+		if sess.Spec == nil {
+			return err
+		}
+		s.Logger.Warn("Failed to restart container in place, provisioning a new one",
+			"session_id", sessionID, "container_id", sess.ContainerID, "error", err)
+		return s.reprovisionSession(ctx, sess, "start failed")
+	}
+	return nil
+}
+
+// restartInPlace 重新启动 session 已停止的冷启动容器
+func (s *Service) restartInPlace(ctx context.Context, sess *session.Session) error {
+	sessionID := sess.ID
+	s.publishRestarting(ctx, sessionID, restartInPlace, "stopped", "")
+	if err := s.SessionRepo.UpdateSessionStatus(ctx, sessionID, session.StatusInitializing); err != nil {
+		s.Logger.Warn("Failed to update session status before restart", "error", err)
+	}
+
+	// 清理 Dispatcher 中的旧状态
+	s.Dispatcher.CleanUp(sessionID)
+
+	if err := s.dockerFor(sess).ContainerStart(ctx, sess.ContainerID, container.StartOptions{}); err != nil {
+		// 恢复原状态，由调用方决定是否改为重新分配容器
+		if uerr := s.SessionRepo.UpdateSessionStatus(ctx, sessionID, sess.Status); uerr != nil {
+			s.Logger.Warn("Failed to restore session status", "error", uerr)
+		}
+		return fmt.Errorf("failed to restart container: %w", err)
+	}
+
+	s.Logger.Info("Container restarted", "session_id", sessionID, "container_id", sess.ContainerID)
+
+	// 重新获取新的容器 IP
+	nodeIP := sess.NodeIP
+	newInspect, err := s.dockerFor(sess).ContainerInspect(ctx, sess.ContainerID)
+	if err != nil {
+		s.Logger.Warn("Failed to inspect container after restart", "error", err)
+	} else {
+		newIP := ""
+		for _, net := range newInspect.NetworkSettings.Networks {
+			if net.IPAddress != "" {
+				newIP = net.IPAddress
+				break
+			}
+		}
+		if newIP != "" && newIP != sess.NodeIP {
+			s.Logger.Info("Container IP changed after restart",
+				"session_id", sessionID,
+				"old_ip", sess.NodeIP,
+				"new_ip", newIP,
+			)
+			if err := s.SessionRepo.UpdateSessionContainerInfo(ctx, sessionID, sess.ContainerID, newIP); err != nil {
+				s.Logger.Warn("Failed to update container IP after restart", "error", err)
+			}
+			nodeIP = newIP
+		}
+	}
+
+	if err := s.SessionRepo.UpdateSessionStatus(ctx, sessionID, session.StatusReady); err != nil {
+		s.Logger.Warn("Failed to update session status after restart", "error", err)
+	}
+	if s.Bus != nil {
+		s.Bus.Publish(ctx, sessionID, eventbus.Event{
+			Type:      eventbus.EventSessionReady,
+			SessionID: sessionID,
+			Payload:   map[string]string{"container_id": sess.ContainerID, "node_ip": nodeIP},
+			Timestamp: time.Now(),
+		})
+	}
+
+	// 重启后的 Agent 进程没有任何上下文，重放持久化的对话
+	s.replayAfterRestart(sessionID)
+	return nil
+}
+
+// reprovisionSession 为 session 重新分配容器：保存原容器的工作区快照（未配置快照存储或没有容器时跳过），
+// 释放原容器，再按创建参数重新投递创建任务，由 worker 取容器、用快照初始化工作区并启动 Agent。
+// 等待 session 就绪后重放对话；配额、伴随服务和 compose stack 保持不变
+func (s *Service) reprovisionSession(ctx context.Context, sess *session.Session, reason string) error {
+	if sess.Spec == nil {
+		return fmt.Errorf("session %s was created without a recorded spec and cannot be given a new container", sess.ID)
+	}
+
+	snapshotID := ""
+	if s.Snapshots != nil && sess.ContainerID != "" {
+		info, err := s.SnapshotWorkspace(ctx, sess.ID)
+		if err != nil {
+			// 容器已不存在等情况下拿不到工作区，新容器按创建参数重新初始化
+			s.Logger.Warn("Failed to snapshot workspace before reprovisioning", "session_id", sess.ID, "error", err)
+		} else {
+			snapshotID = info.ID
+		}
+	}
+
+	s.publishRestarting(ctx, sess.ID, restartReprovision, reason, snapshotID)
+
+	s.Dispatcher.CleanUp(sess.ID)
+	s.endpoints.Forget(sess.ID)
+	s.releaseContainer(ctx, sess)
+
+	if err := s.SessionMgr.Reprovision(ctx, sess, snapshotID); err != nil {
+		// 原容器已释放，session 无法再使用
+		if uerr := s.SessionRepo.UpdateSessionStatus(ctx, sess.ID, session.StatusError); uerr != nil {
+			s.Logger.Warn("Failed to mark session error", "session_id", sess.ID, "error", uerr)
+		}
+		return fmt.Errorf("failed to reprovision session: %w", err)
+	}
+	s.Logger.Info("Session reprovisioning",
+		"session_id", sess.ID,
+		"old_container_id", sess.ContainerID,
+		"reason", reason,
+		"snapshot_id", snapshotID,
+	)
+
+	waitCtx, cancel := context.WithTimeout(ctx, restartReadyTimeout)
+	defer cancel()
+	ready, err := s.WaitForReady(waitCtx, sess.ID, 500*time.Millisecond)
+	if err != nil {
+		return fmt.Errorf("session did not become ready after reprovisioning: %w", err)
+	}
+
+	s.Logger.Info("Session reprovisioned", "session_id", sess.ID, "container_id", ready.ContainerID)
+	s.replayAfterRestart(sess.ID)
+	return nil
+}
+
+func (s *Service) publishRestarting(ctx context.Context, sessionID, mode, reason, snapshotID string) {
+	if s.Bus == nil {
+		return
+	}
+	payload := map[string]string{"mode": mode, "reason": reason}
+	if snapshotID != "" {
+		payload["snapshot_id"] = snapshotID
+	}
+	s.Bus.Publish(ctx, sessionID, eventbus.Event{
+		Type:      eventbus.EventSessionRestarting,
+		SessionID: sessionID,
+		Payload:   payload,
+		Timestamp: time.Now(),
+	})
+}
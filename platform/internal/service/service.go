@@ -1,22 +1,48 @@
 package service
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
 	"platform/internal/agentproto"
+	"platform/internal/debugbundle"
+	"platform/internal/diskusage"
 	"platform/internal/dispatcher"
 	"platform/internal/eventbus"
+	"platform/internal/execpolicy"
+	"platform/internal/featureflag"
+	"platform/internal/hostport"
+	"platform/internal/lock"
+	"platform/internal/logging"
+	"platform/internal/nodes"
+	"platform/internal/operation"
+	"platform/internal/orchestrator"
+	"platform/internal/preference"
+	"platform/internal/projectstack"
+	"platform/internal/queuepos"
+	"platform/internal/quota"
 	"platform/internal/sandbox"
+	"platform/internal/sandbox/pathsafe"
+	"platform/internal/secretfile"
+	"platform/internal/serviceaccount"
 	"platform/internal/session"
+	"platform/internal/slo"
+	"platform/internal/snapshot"
+	"platform/internal/storage"
+	"platform/internal/taskstatus"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
+	"github.com/hibiken/asynq"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 type Service struct {
@@ -29,6 +55,67 @@ type Service struct {
 	HostRoot    string // 宿主机项目根目录，用于文件同步
 	Companions  *CompanionManager
 	Compose     *ComposeManager
+
+	endpoints *endpointPublisher
+	// readiness 实例的就绪状态，nil 表示未启用预热门控
+	readiness atomic.Pointer[Readiness]
+
+	// 以下为可选组件，由 server 按配置注入
+	DiskUsage       *diskusage.Inspector
+	Preferences     preference.Store
+	ServiceAccounts *serviceaccount.Manager
+	Operations      *operation.Manager
+	Tasks           *taskstatus.Tracker
+	Locks           *lock.Locker
+	Ports           *hostport.Allocator
+	LogLevels       *logging.Levels
+	Queues          *asynq.Inspector
+	// ExecPolicy 终端启动命令的策略，nil 时不检查
+	ExecPolicy *execpolicy.Policy
+	// CheckpointDir 休眠检查点的存放目录，为空时使用 Docker 默认目录
+	CheckpointDir string
+	// MountRoots 允许 session bind 挂载的宿主机目录，为空时不允许 bind 挂载
+	MountRoots []string
+	// MountVolumes 允许 session 挂载的命名卷，以 * 结尾时按前缀匹配，为空时不允许挂载命名卷
+	MountVolumes []string
+	// Datasets 可按名称挂载的只读数据集目录，为 nil 时不允许引用数据集
+	Datasets *sandbox.DatasetCatalog
+	// Snapshots 工作区快照存储，nil 时不支持快照
+	Snapshots *snapshot.Store
+	// Projects 项目文件的对象存储副本，nil 时项目文件只保存在本机 HostRoot 下
+	Projects *storage.ProjectStore
+	// ProjectStacks 项目声明的默认服务，nil 时 session 就绪后不自动启动伴随服务
+	ProjectStacks projectstack.Store
+	// Flags 功能开关，nil 时使用内置默认值
+	Flags *featureflag.Flags
+	// MaxArchiveSize 工作区归档下载的未压缩大小上限，0 时使用 DefaultMaxArchiveSize
+	MaxArchiveSize int64
+	// SecretFiles 视为凭据的文件（如生成的 .env），同步回宿主机和打包归档时排除；nil 使用 secretfile.DefaultPatterns
+	SecretFiles *secretfile.Matcher
+	// Secrets 不为 nil 时凭据文件按租户加密后同步回宿主机，否则不同步
+	Secrets *secretfile.Sealer
+	// Payloads 超限事件负载的转存，nil 时事件负载全部内联
+	Payloads *eventbus.OverflowStore
+	// Quota 伴随服务与 compose 服务的配额，与 Companions / Compose 共用，nil 时不限制
+	Quota *quota.Tracker
+	// Nodes 多节点部署时容器所在的 Docker 节点，nil 时所有容器都在本机
+	Nodes *nodes.Registry
+	// DebugBundles 失败 session 调试包的存储，nil 时不收集
+	DebugBundles *debugbundle.Collector
+	// StrategyFallback 请求未指定 fallback 时是否允许改用另一种策略
+	StrategyFallback bool
+	// HealDeadContainers 租出的容器意外退出时是否重新分配容器，false 时将 session 标记为 error
+	HealDeadContainers bool
+	// Pool 预热池，供运维接口查看状态、补充和排空，nil 时不支持
+	Pool *orchestrator.Pool
+	// QueuePositions 初始化中的 session 的排队位置和 ETA，nil 时不返回
+	QueuePositions *queuepos.Estimator
+	// Cancels 初始化期间被终止的 session 的取消标记，nil 时只删除排队中的创建任务
+	Cancels *session.CancelStore
+	// Activity 记录 session 最近一次活动的时间，nil 时不记录
+	Activity *session.ActivityTracker
+	// SLO 会话创建和对话分发的服务等级目标统计，nil 时不记录
+	SLO *slo.Tracker
 }
 
 func NewService(
@@ -42,6 +129,11 @@ func NewService(
 	companions *CompanionManager,
 	compose *ComposeManager,
 ) *Service {
+	network := ""
+	if companions != nil {
+		network = companions.network
+	}
+
 	return &Service{
 		SessionMgr:  sessionMgr,
 		SessionRepo: sessionRepo,
@@ -52,23 +144,282 @@ func NewService(
 		HostRoot:    hostRoot,
 		Companions:  companions,
 		Compose:     compose,
+		endpoints:   newEndpointPublisher(docker, network, logger),
 	}
 }
 
 func (s *Service) CreateSession(ctx context.Context, params session.SessionParams) (*session.Session, error) {
+	s.applyUserDefaults(ctx, &params)
+	if params.Strategy == "" {
+		params.Strategy = orchestrator.ColdStrategyType
+	}
+	if params.Fallback == nil {
+		fallback := s.StrategyFallback
+		params.Fallback = &fallback
+	}
+
+	policy := params.ContainerOpts.NetworkPolicy
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	// 预热容器创建时已接入共享网络，受限策略只能由冷启动容器实现
+	if policy.Restricted() && params.Strategy != orchestrator.ColdStrategyType {
+		return nil, fmt.Errorf("invalid strategy %s: network policy %q requires %s",
+			params.Strategy, policy.Mode, orchestrator.ColdStrategyType)
+	}
+
+	opts := params.ContainerOpts
+	if err := sandbox.ValidateGPURequest(opts.GPUCount, opts.GPUDeviceIDs); err != nil {
+		return nil, err
+	}
+	// 设备在创建容器时分配，预热容器没有 GPU
+	if (opts.GPUCount != 0 || len(opts.GPUDeviceIDs) > 0) && params.Strategy != orchestrator.ColdStrategyType {
+		return nil, fmt.Errorf("invalid strategy %s: GPU requests require %s", params.Strategy, orchestrator.ColdStrategyType)
+	}
+	if len(opts.Mounts) > 0 {
+		if params.Strategy != orchestrator.ColdStrategyType {
+			return nil, fmt.Errorf("invalid strategy %s: extra mounts require %s", params.Strategy, orchestrator.ColdStrategyType)
+		}
+		mounts, err := s.Datasets.Resolve(opts.Mounts)
+		if err != nil {
+			return nil, err
+		}
+		opts.Mounts = mounts
+		params.ContainerOpts.Mounts = mounts
+		if err := sandbox.ValidateMounts(opts.Mounts, sandbox.DefaultMountPath(opts.ProjectID)); err != nil {
+			return nil, err
+		}
+		if err := sandbox.ValidateMountSources(opts.Mounts, s.MountRoots, s.MountVolumes); err != nil {
+			return nil, err
+		}
+	}
+	if params.SnapshotID != "" {
+		if err := s.checkSnapshot(ctx, params.SnapshotID); err != nil {
+			return nil, err
+		}
+	}
+	if params.Git != nil {
+		if params.SnapshotID != "" {
+			return nil, fmt.Errorf("invalid request: git_url and snapshot_id are mutually exclusive")
+		}
+		if err := params.Git.Validate(); err != nil {
+			return nil, err
+		}
+	}
 	return s.SessionMgr.CreateSession(ctx, params)
 }
 
+// applyUserDefaults 用用户偏好填充请求中省略的策略、镜像和环境变量
+func (s *Service) applyUserDefaults(ctx context.Context, params *session.SessionParams) {
+	if s.Preferences == nil || params.UserID == "" {
+		return
+	}
+
+	prefs, err := s.Preferences.Get(ctx, params.UserID)
+	if err != nil {
+		if !errors.Is(err, preference.ErrNotFound) {
+			s.Logger.Warn("Failed to load user preferences", "user_id", params.UserID, "error", err)
+		}
+		return
+	}
+
+	if params.Strategy == "" {
+		params.Strategy = prefs.DefaultStrategy
+	}
+	if params.ContainerOpts.Image == "" {
+		params.ContainerOpts.Image = prefs.DefaultImage
+	}
+	params.EnvVars = preference.MergeEnvVars(prefs.DefaultEnvVars, params.EnvVars)
+	params.ContainerOpts.EnvVars = params.EnvVars
+}
+
+func (s *Service) GetUserPreferences(ctx context.Context, userID string) (*preference.Preferences, error) {
+	if s.Preferences == nil {
+		return nil, fmt.Errorf("preference store not initialized")
+	}
+	return s.Preferences.Get(ctx, userID)
+}
+
+func (s *Service) UpdateUserPreferences(ctx context.Context, prefs *preference.Preferences) error {
+	if s.Preferences == nil {
+		return fmt.Errorf("preference store not initialized")
+	}
+	return s.Preferences.Upsert(ctx, prefs)
+}
+
+func (s *Service) DeleteUserPreferences(ctx context.Context, userID string) error {
+	if s.Preferences == nil {
+		return fmt.Errorf("preference store not initialized")
+	}
+	return s.Preferences.Delete(ctx, userID)
+}
+
+func (s *Service) CreateServiceAccount(ctx context.Context, params serviceaccount.CreateParams) (*serviceaccount.Account, string, error) {
+	if s.ServiceAccounts == nil {
+		return nil, "", fmt.Errorf("service accounts not initialized")
+	}
+	return s.ServiceAccounts.Create(ctx, params)
+}
+
+func (s *Service) ListServiceAccounts(ctx context.Context) ([]*serviceaccount.Account, error) {
+	if s.ServiceAccounts == nil {
+		return nil, fmt.Errorf("service accounts not initialized")
+	}
+	return s.ServiceAccounts.List(ctx)
+}
+
+func (s *Service) GetServiceAccount(ctx context.Context, id string) (*serviceaccount.Account, error) {
+	if s.ServiceAccounts == nil {
+		return nil, fmt.Errorf("service accounts not initialized")
+	}
+	return s.ServiceAccounts.Get(ctx, id)
+}
+
+func (s *Service) RotateServiceAccountToken(ctx context.Context, id string, grace, ttl time.Duration) (*serviceaccount.Account, string, error) {
+	if s.ServiceAccounts == nil {
+		return nil, "", fmt.Errorf("service accounts not initialized")
+	}
+	return s.ServiceAccounts.Rotate(ctx, id, grace, ttl)
+}
+
+func (s *Service) DeleteServiceAccount(ctx context.Context, id string) error {
+	if s.ServiceAccounts == nil {
+		return fmt.Errorf("service accounts not initialized")
+	}
+	return s.ServiceAccounts.Delete(ctx, id)
+}
+
+// runAsync 在后台执行 fn 并返回可轮询的操作；未配置 Operations 时退化为无状态的 goroutine
+func (s *Service) runAsync(ctx context.Context, kind operation.Kind, sessionID string, timeout time.Duration, fn operation.Func) (*operation.Operation, error) {
+	if s.Operations != nil {
+		return s.Operations.Start(ctx, kind, sessionID, timeout, fn)
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if _, err := fn(ctx); err != nil {
+			s.Logger.Error("Background operation failed", "kind", kind, "session_id", sessionID, "error", err)
+		}
+	}()
+	return nil, nil
+}
+
+func (s *Service) GetOperation(ctx context.Context, id string) (*operation.Operation, error) {
+	if s.Operations == nil {
+		return nil, fmt.Errorf("operations not initialized")
+	}
+	return s.Operations.Get(ctx, id)
+}
+
+// TerminateSessionAsync 在后台终止 session 并清理容器
+func (s *Service) TerminateSessionAsync(ctx context.Context, id string) (*operation.Operation, error) {
+	return s.runAsync(ctx, operation.KindTerminate, id, 30*time.Second, func(ctx context.Context) (any, error) {
+		return nil, s.TerminateSession(ctx, id)
+	})
+}
+
+// StopAgentAsync 在后台通过 gRPC 停止 Agent
+func (s *Service) StopAgentAsync(ctx context.Context, id string) (*operation.Operation, error) {
+	return s.runAsync(ctx, operation.KindStopAgent, id, 15*time.Second, func(ctx context.Context) (any, error) {
+		return s.StopAgent(ctx, id)
+	})
+}
+
+// RestartSessionAsync 在后台重启 session 容器
+func (s *Service) RestartSessionAsync(ctx context.Context, id string, fresh bool) (*operation.Operation, error) {
+	return s.runAsync(ctx, operation.KindRestart, id, restartReadyTimeout, func(ctx context.Context) (any, error) {
+		return nil, s.RestartSession(ctx, id, fresh)
+	})
+}
+
+// SyncFilesToHostAsync 在后台将容器文件同步到宿主机
+func (s *Service) SyncFilesToHostAsync(ctx context.Context, id, srcPath, destPath string) (*operation.Operation, error) {
+	return s.runAsync(ctx, operation.KindSync, id, 5*time.Minute, func(ctx context.Context) (any, error) {
+		return nil, s.SyncFilesToHost(ctx, id, srcPath, destPath)
+	})
+}
+
+// ListContainers 按标签查询平台创建的容器
+func (s *Service) ListContainers(ctx context.Context, q sandbox.LabelQuery, all bool) ([]container.Summary, error) {
+	if s.Docker == nil {
+		return nil, fmt.Errorf("docker client not initialized")
+	}
+	return sandbox.ListManaged(ctx, s.Docker, q, all)
+}
+
 func (s *Service) GetSession(ctx context.Context, id string) (*session.Session, error) {
 	return s.SessionMgr.GetSession(ctx, id)
 }
 
 func (s *Service) TerminateSession(ctx context.Context, id string) error {
+	return s.withSessionLock(ctx, id, "terminate", func() error {
+		return s.terminateSession(ctx, id)
+	})
+}
+
+func (s *Service) terminateSession(ctx context.Context, id string) error {
 	sess, err := s.SessionMgr.GetSession(ctx, id)
 	if err != nil {
 		return err
 	}
 
+	if sess.Status == session.StatusInitializing {
+		s.cancelPendingCreate(ctx, id)
+	}
+
+	s.releaseSessionResources(ctx, id)
+
+	if sess.Status == session.StatusPaused {
+		// 冻结的进程收不到 SIGTERM，先解冻再停止，同时结束暂停记录
+		if err := s.sessionContainer(sess).Unpause(ctx); err != nil {
+			s.Logger.Warn("Failed to unpause container", "container_id", sess.ContainerID, "error", err)
+		}
+		s.closePauseWindow(ctx, id)
+	}
+
+	s.releaseContainer(ctx, sess)
+
+	if docker := s.dockerFor(sess); docker != nil {
+		// session 未记录网络策略，按命名约定清理可能存在的出网代理和专属网络
+		if err := sandbox.RemoveSessionNetwork(ctx, docker, id); err != nil {
+			s.Logger.Warn("Failed to clean up session network", "session_id", id, "error", err)
+		}
+	}
+
+	return s.SessionMgr.TerminateSession(ctx, id)
+}
+
+// releaseContainer 释放 session 的容器：预热容器归还预热池，由池删除或回收，同时归还名额；
+// 其他容器或归还失败时停止并删除
+func (s *Service) releaseContainer(ctx context.Context, sess *session.Session) {
+	if sess.ContainerID == "" {
+		return
+	}
+	if sess.ContainerStrategy() == orchestrator.WarmStrategyType {
+		err := s.SessionMgr.ReleaseContainer(ctx, sess)
+		if err == nil {
+			return
+		}
+		s.Logger.Warn("Failed to release warm container, removing it", "container_id", sess.ContainerID, "error", err)
+	}
+
+	policy := sandbox.CurrentRemovalPolicy()
+	timeout := policy.StopTimeoutSeconds()
+	docker := s.dockerFor(sess)
+	stopErr := docker.ContainerStop(ctx, sess.ContainerID, container.StopOptions{Timeout: &timeout})
+	if stopErr != nil {
+		s.Logger.Warn("Failed to stop container", "container_id", sess.ContainerID, "error", stopErr)
+	}
+	rmErr := docker.ContainerRemove(ctx, sess.ContainerID, container.RemoveOptions{Force: policy.Force})
+	if rmErr != nil {
+		s.Logger.Warn("Failed to remove container", "container_id", sess.ContainerID, "error", rmErr)
+	}
+	s.Nodes.Forget(sess.ContainerID)
+}
+
+// releaseSessionResources 清理 session 的伴随服务、compose stack、配额、端口和 Agent 连接，不处理容器本身
+func (s *Service) releaseSessionResources(ctx context.Context, id string) {
 	if s.Companions != nil {
 		s.Companions.CleanupSession(ctx, id)
 	}
@@ -77,21 +428,18 @@ func (s *Service) TerminateSession(ctx context.Context, id string) error {
 		s.Compose.CleanupSession(ctx, id)
 	}
 
-	s.Dispatcher.CleanUp(id)
+	// 兜底释放清理过程中未能逐项释放的配额占用
+	s.Quota.ReleaseSession(ctx, id)
 
-	if sess.ContainerID != "" {
-		timeout := 10
-		stopErr := s.Docker.ContainerStop(ctx, sess.ContainerID, container.StopOptions{Timeout: &timeout})
-		if stopErr != nil {
-			s.Logger.Warn("Failed to stop container", "container_id", sess.ContainerID, "error", stopErr)
-		}
-		rmErr := s.Docker.ContainerRemove(ctx, sess.ContainerID, container.RemoveOptions{Force: true})
-		if rmErr != nil {
-			s.Logger.Warn("Failed to remove container", "container_id", sess.ContainerID, "error", rmErr)
+	if s.Ports != nil {
+		// 兜底释放平台重启后已不在内存中追踪的服务所占端口
+		if err := s.Ports.ReleaseSession(ctx, id); err != nil {
+			s.Logger.Warn("Failed to release host ports", "session_id", id, "error", err)
 		}
 	}
 
-	return s.SessionMgr.TerminateSession(ctx, id)
+	s.Dispatcher.CleanUp(id)
+	s.endpoints.Forget(id)
 }
 
 // Agent RPC 调用
@@ -101,8 +449,8 @@ func (s *Service) ConfigureSession(ctx context.Context, sessionID string, req *a
 		return nil, fmt.Errorf("session not found: %w", err)
 	}
 
-	if sess.Status != session.StatusReady && sess.Status != session.StatusRunning {
-		return nil, fmt.Errorf("session is not ready (status: %s)", sess.Status)
+	if err := ensureActive(sess); err != nil {
+		return nil, err
 	}
 
 	if sess.NodeIP == "" {
@@ -119,10 +467,28 @@ func (s *Service) ConfigureSession(ctx context.Context, sessionID string, req *a
 	}
 
 	req.SessionId = sessionID
-	return s.Dispatcher.Configure(ctx, c, req)
+	resp, err := s.Dispatcher.Configure(ctx, c, req)
+	if err != nil {
+		return nil, err
+	}
+	// 记录配置，Agent 容器被替换后与对话历史一起重放
+	if data, err := protojson.Marshal(req); err == nil {
+		s.recordMessage(ctx, sessionID, session.RoleConfigure, string(data))
+	}
+	return resp, nil
 }
 
 func (s *Service) StopAgent(ctx context.Context, sessionID string) (*agentproto.StopResponse, error) {
+	var resp *agentproto.StopResponse
+	err := s.withSessionLock(ctx, sessionID, "stop_agent", func() error {
+		var err error
+		resp, err = s.stopAgent(ctx, sessionID)
+		return err
+	})
+	return resp, err
+}
+
+func (s *Service) stopAgent(ctx context.Context, sessionID string) (*agentproto.StopResponse, error) {
 	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("session not found: %w", err)
@@ -150,8 +516,8 @@ func (s *Service) SendMessage(ctx context.Context, sessionID string, message str
 		return fmt.Errorf("session not found: %w", err)
 	}
 
-	if sess.Status != session.StatusReady && sess.Status != session.StatusRunning {
-		return fmt.Errorf("session is not ready (status: %s)", sess.Status)
+	if err := ensureActive(sess); err != nil {
+		return err
 	}
 
 	if sess.NodeIP == "" {
@@ -173,7 +539,14 @@ func (s *Service) SendMessage(ctx context.Context, sessionID string, message str
 		}
 	}
 
-	return s.Dispatcher.Dispatch(ctx, c, message)
+	start := time.Now()
+	err = s.Dispatcher.Dispatch(ctx, c, message)
+	s.SLO.Record(ctx, slo.ObjectiveChatDispatch, err != nil, time.Since(start))
+	if err != nil {
+		return err
+	}
+	s.recordMessage(ctx, sessionID, session.RoleUser, message)
+	return nil
 }
 
 // 事件订阅
@@ -186,6 +559,52 @@ func (s *Service) StreamEvents(ctx context.Context, sessionID string) (<-chan ev
 	return s.Bus.Subscribe(ctx, sessionID)
 }
 
+// ErrEventWaitTimeout WaitForEvent 在超时前没有等到匹配的事件
+var ErrEventWaitTimeout = errors.New("timed out waiting for event")
+
+// WaitForEvent 订阅 session 事件并返回下一个类型属于 types 的事件，types 为空时匹配任意事件。
+// 只能收到订阅之后发布的事件，调用方应在发送消息前开始等待
+// GetEventPayload 读取因超出大小上限而转存的事件负载 JSON
+func (s *Service) GetEventPayload(ctx context.Context, sessionID, ref string) ([]byte, error) {
+	if s.Payloads == nil {
+		return nil, eventbus.ErrPayloadNotFound
+	}
+	return s.Payloads.Load(ctx, sessionID, ref)
+}
+
+func (s *Service) WaitForEvent(ctx context.Context, sessionID string, types []eventbus.EventType, timeout time.Duration) (*eventbus.Event, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	eventCh, err := s.StreamEvents(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		select {
+		case event, ok := <-eventCh:
+			if !ok {
+				if ctx.Err() == context.DeadlineExceeded {
+					return nil, ErrEventWaitTimeout
+				}
+				return nil, fmt.Errorf("event stream closed: %w", ctx.Err())
+			}
+			if event.Type == eventbus.EventStreamDone {
+				continue
+			}
+			if len(types) == 0 || slices.Contains(types, event.Type) {
+				return &event, nil
+			}
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return nil, ErrEventWaitTimeout
+			}
+			return nil, ctx.Err()
+		}
+	}
+}
+
 // Agent 辅助容器管理
 func (s *Service) CreateCompanionService(ctx context.Context, sessionID string, req CreateServiceRequest) (*CompanionService, error) {
 	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
@@ -193,15 +612,46 @@ func (s *Service) CreateCompanionService(ctx context.Context, sessionID string,
 		return nil, fmt.Errorf("session not found: %w", err)
 	}
 
-	if sess.Status != session.StatusReady && sess.Status != session.StatusRunning {
-		return nil, fmt.Errorf("session is not ready (status: %s)", sess.Status)
+	if err := ensureActive(sess); err != nil {
+		return nil, err
 	}
 
 	if s.Companions == nil {
 		return nil, fmt.Errorf("companion service manager not initialized")
 	}
 
-	return s.Companions.CreateService(ctx, sessionID, req)
+	mounts, err := s.Datasets.Resolve(req.Mounts)
+	if err != nil {
+		return nil, err
+	}
+	req.Mounts = mounts
+	if err := sandbox.ValidateMounts(req.Mounts, ""); err != nil {
+		return nil, err
+	}
+	if err := sandbox.ValidateMountSources(req.Mounts, s.MountRoots, s.MountVolumes); err != nil {
+		return nil, err
+	}
+
+	req.Tenant = sess.UserID
+	var svc *CompanionService
+	err = s.withSessionLock(ctx, sessionID, "create_service", func() error {
+		var err error
+		switch req.Scope {
+		case "", ScopeSession:
+			svc, err = s.Companions.CreateService(ctx, sessionID, req)
+		case ScopeProject:
+			svc, err = s.Companions.AttachProjectService(ctx, sess.ProjectID, sessionID, req)
+		default:
+			err = fmt.Errorf("invalid service scope %q", req.Scope)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.syncEndpoints(ctx, sessionID)
+	return svc, nil
 }
 
 func (s *Service) RemoveCompanionService(ctx context.Context, sessionID, serviceID string) error {
@@ -214,7 +664,15 @@ func (s *Service) RemoveCompanionService(ctx context.Context, sessionID, service
 		return fmt.Errorf("companion service manager not initialized")
 	}
 
-	return s.Companions.RemoveService(ctx, sessionID, serviceID)
+	err = s.withSessionLock(ctx, sessionID, "remove_service", func() error {
+		return s.Companions.RemoveService(ctx, sessionID, serviceID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.syncEndpoints(ctx, sessionID)
+	return nil
 }
 
 func (s *Service) ListCompanionServices(sessionID string) []*CompanionService {
@@ -225,6 +683,12 @@ func (s *Service) ListCompanionServices(sessionID string) []*CompanionService {
 }
 
 func (s *Service) SyncFilesToHost(ctx context.Context, sessionID string, srcPath string, destPath string) error {
+	return s.withSessionLock(ctx, sessionID, "sync", func() error {
+		return s.syncFilesToHost(ctx, sessionID, srcPath, destPath)
+	})
+}
+
+func (s *Service) syncFilesToHost(ctx context.Context, sessionID string, srcPath string, destPath string) error {
 	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
 	if err != nil {
 		return fmt.Errorf("session not found: %w", err)
@@ -234,30 +698,57 @@ func (s *Service) SyncFilesToHost(ctx context.Context, sessionID string, srcPath
 		return fmt.Errorf("session has no container")
 	}
 
-	hostDest := filepath.Join(s.HostRoot, sess.ProjectID)
-	if destPath != "" {
-		hostDest = filepath.Join(hostDest, destPath)
+	projectRoot := filepath.Join(s.HostRoot, sess.ProjectID)
+	destRel, err := pathsafe.Rel(destPath)
+	if err != nil {
+		return err
 	}
+	destDir := filepath.Join(".", filepath.FromSlash(destRel))
+	hostDest := filepath.Join(projectRoot, destDir)
 
-	if err := os.MkdirAll(hostDest, 0755); err != nil {
-		return fmt.Errorf("failed to create host directory: %w", err)
+	srcRel, err := pathsafe.Rel(srcPath)
+	if err != nil {
+		return err
+	}
+	workspace := s.sessionContainer(sess).MountPath
+	if srcRel, err = s.resolveWorkspacePath(ctx, sess.ContainerID, workspace, srcRel); err != nil {
+		return err
+	}
+	containerSrc := path.Join(workspace, srcRel)
+	if srcRel == "" {
+		containerSrc += "/"
 	}
 
-	containerSrc := srcPath
-	if containerSrc == "" {
-		containerSrc = "/app/workspace/"
+	// 冷启动容器的工作区挂载自项目目录，其中的符号链接由 Agent 控制，之后的写入都限制在项目目录内
+	if err := os.MkdirAll(projectRoot, 0755); err != nil {
+		return fmt.Errorf("failed to create host directory: %w", err)
+	}
+	root, err := os.OpenRoot(projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to open host directory: %w", err)
+	}
+	defer root.Close()
+	if err := root.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("failed to create host directory: %w", err)
 	}
 
-	reader, _, err := s.Docker.CopyFromContainer(ctx, sess.ContainerID, containerSrc)
+	reader, _, err := s.dockerFor(sess).CopyFromContainer(ctx, sess.ContainerID, containerSrc)
 	if err != nil {
 		return fmt.Errorf("failed to copy from container: %w", err)
 	}
 	defer reader.Close()
 
-	if err := extractTarToDir(reader, hostDest); err != nil {
+	secrets := &hostSecrets{match: s.SecretFiles, sealer: s.Secrets, tenant: sess.UserID}
+	if err := extractTarToDir(reader, root, destDir, secrets); err != nil {
 		return fmt.Errorf("failed to extract files: %w", err)
 	}
 
+	if s.Projects != nil {
+		if err := s.Projects.SaveDir(ctx, sess.ProjectID, projectRoot); err != nil {
+			return err
+		}
+	}
+
 	s.Logger.Info("Files synced from container to host",
 		"session_id", sessionID,
 		"container_id", sess.ContainerID,
@@ -268,68 +759,14 @@ func (s *Service) SyncFilesToHost(ctx context.Context, sessionID string, srcPath
 	return nil
 }
 
-func (s *Service) ListContainerFiles(ctx context.Context, sessionID string, path string) (string, error) {
-	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
-	if err != nil {
-		return "", fmt.Errorf("session not found: %w", err)
-	}
-
-	if sess.ContainerID == "" {
-		return "", fmt.Errorf("session has no container")
-	}
-
-	target := "/app/workspace"
-	if path != "" {
-		target = filepath.Join(target, path)
-	}
-
-	execCfg := container.ExecOptions{
-		Cmd:          []string{"ls", "-la", target},
-		AttachStdout: true,
-		AttachStderr: true,
-	}
-
-	resp, err := s.Docker.ContainerExecCreate(ctx, sess.ContainerID, execCfg)
-	if err != nil {
-		return "", fmt.Errorf("failed to create exec: %w", err)
-	}
-
-	attachResp, err := s.Docker.ContainerExecAttach(ctx, resp.ID, container.ExecAttachOptions{})
-	if err != nil {
-		return "", fmt.Errorf("failed to attach exec: %w", err)
-	}
-	defer attachResp.Close()
-
-	var buf bytes.Buffer
-	_, _ = io.Copy(&buf, attachResp.Reader)
-
-	return buf.String(), nil
-}
-
 func (s *Service) ReadContainerFile(ctx context.Context, sessionID string, path string) ([]byte, error) {
-	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
-	if err != nil {
-		return nil, fmt.Errorf("session not found: %w", err)
-	}
-
-	if sess.ContainerID == "" {
-		return nil, fmt.Errorf("session has no container")
-	}
-
-	containerPath := filepath.Join("/app/workspace", path)
-
-	reader, _, err := s.Docker.CopyFromContainer(ctx, sess.ContainerID, containerPath)
+	f, err := s.OpenContainerFile(ctx, sessionID, path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to copy from container: %w", err)
-	}
-	defer reader.Close()
-
-	var buf bytes.Buffer
-	if err := extractFirstFileFromTar(reader, &buf); err != nil {
 		return nil, err
 	}
+	defer f.Close()
 
-	return buf.Bytes(), nil
+	return io.ReadAll(f)
 }
 
 func (s *Service) HealthCheck(ctx context.Context, sessionID string) (bool, error) {
@@ -342,7 +779,7 @@ func (s *Service) HealthCheck(ctx context.Context, sessionID string) (bool, erro
 		return false, nil
 	}
 
-	inspect, inspectErr := s.Docker.ContainerInspect(ctx, sess.ContainerID)
+	inspect, inspectErr := s.dockerFor(sess).ContainerInspect(ctx, sess.ContainerID)
 	if inspectErr != nil {
 		return false, nil
 	}
@@ -360,7 +797,7 @@ func (s *Service) GetContainerState(ctx context.Context, sessionID string) (stri
 		return "no_container", nil
 	}
 
-	inspect, inspectErr := s.Docker.ContainerInspect(ctx, sess.ContainerID)
+	inspect, inspectErr := s.dockerFor(sess).ContainerInspect(ctx, sess.ContainerID)
 	if inspectErr != nil {
 		return "unreachable", nil
 	}
@@ -368,67 +805,6 @@ func (s *Service) GetContainerState(ctx context.Context, sessionID string) (stri
 	return string(inspect.State.Status), nil
 }
 
-func (s *Service) RestartSession(ctx context.Context, sessionID string) error {
-	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
-	if err != nil {
-		return fmt.Errorf("session not found: %w", err)
-	}
-
-	if sess.ContainerID == "" {
-		return fmt.Errorf("session has no container")
-	}
-
-	inspect, err := s.Docker.ContainerInspect(ctx, sess.ContainerID)
-	if err != nil {
-		return fmt.Errorf("failed to inspect container: %w", err)
-	}
-
-	// 只重启未运行的容器，避免中途重启导致状态不一致
-	if inspect.State.Running {
-		s.Logger.Info("Container already running", "session_id", sessionID, "container_id", sess.ContainerID)
-		return nil
-	}
-
-	// 清理 Dispatcher 中的旧状态
-	s.Dispatcher.CleanUp(sessionID)
-
-	if err := s.Docker.ContainerStart(ctx, sess.ContainerID, container.StartOptions{}); err != nil {
-		return fmt.Errorf("failed to restart container: %w", err)
-	}
-
-	s.Logger.Info("Container restarted", "session_id", sessionID, "container_id", sess.ContainerID)
-
-	// 重新获取新的容器 IP
-	newInspect, err := s.Docker.ContainerInspect(ctx, sess.ContainerID)
-	if err != nil {
-		s.Logger.Warn("Failed to inspect container after restart", "error", err)
-	} else {
-		newIP := ""
-		for _, net := range newInspect.NetworkSettings.Networks {
-			if net.IPAddress != "" {
-				newIP = net.IPAddress
-				break
-			}
-		}
-		if newIP != "" && newIP != sess.NodeIP {
-			s.Logger.Info("Container IP changed after restart",
-				"session_id", sessionID,
-				"old_ip", sess.NodeIP,
-				"new_ip", newIP,
-			)
-			if err := s.SessionRepo.UpdateSessionContainerInfo(ctx, sessionID, sess.ContainerID, newIP); err != nil {
-				s.Logger.Warn("Failed to update container IP after restart", "error", err)
-			}
-		}
-	}
-
-	if err := s.SessionRepo.UpdateSessionStatus(ctx, sessionID, session.StatusReady); err != nil {
-		s.Logger.Warn("Failed to update session status after restart", "error", err)
-	}
-
-	return nil
-}
-
 func (s *Service) WaitForReady(ctx context.Context, sessionID string, pollInterval time.Duration) (*session.Session, error) {
 	ticker := time.NewTicker(pollInterval)
 	defer ticker.Stop()
@@ -452,42 +828,159 @@ func (s *Service) WaitForReady(ctx context.Context, sessionID string, pollInterv
 	}
 }
 
-func (s *Service) CreateComposeStack(ctx context.Context, sessionID string, req CreateComposeRequest) (*ComposeStack, error) {
+func (s *Service) CreateComposeStack(ctx context.Context, sessionID, stackName string, req CreateComposeRequest) (*ComposeStack, error) {
+	sess, err := s.checkComposeReady(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	req.Tenant = sess.UserID
+
+	var stack *ComposeStack
+	err = s.withSessionLock(ctx, sessionID, "compose_up", func() error {
+		var err error
+		stack, err = s.Compose.CreateStack(ctx, sessionID, stackName, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.syncEndpoints(ctx, sessionID)
+	return stack, nil
+}
+
+// UpdateComposeStack 将新的 compose 内容原地应用到已有堆栈，返回变更的服务
+func (s *Service) UpdateComposeStack(ctx context.Context, sessionID, stackName string, req CreateComposeRequest) (*ComposeUpdateResult, error) {
+	sess, err := s.checkComposeReady(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	req.Tenant = sess.UserID
+
+	var result *ComposeUpdateResult
+	err = s.withSessionLock(ctx, sessionID, "compose_update", func() error {
+		var err error
+		result, err = s.Compose.UpdateStack(ctx, sessionID, stackName, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.syncEndpoints(ctx, sessionID)
+	return result, nil
+}
+
+// RestartComposeService 重启 compose 堆栈中的单个服务
+func (s *Service) RestartComposeService(ctx context.Context, sessionID, stackName, name string) (*ComposeStack, error) {
+	if _, err := s.checkComposeReady(ctx, sessionID); err != nil {
+		return nil, err
+	}
+
+	var stack *ComposeStack
+	err := s.withSessionLock(ctx, sessionID, "compose_restart", func() error {
+		var err error
+		stack, err = s.Compose.RestartService(ctx, sessionID, stackName, name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Bus.Publish(ctx, sessionID, eventbus.Event{
+		Type:      eventbus.EventComposeServiceRestarted,
+		SessionID: sessionID,
+		Payload:   map[string]any{"stack": stackName, "service": name},
+		Timestamp: time.Now(),
+	})
+	s.syncEndpoints(ctx, sessionID)
+	return stack, nil
+}
+
+// ScaleComposeService 将 compose 服务调整为指定副本数
+func (s *Service) ScaleComposeService(ctx context.Context, sessionID, stackName, name string, replicas int) (*ComposeStack, error) {
+	if _, err := s.checkComposeReady(ctx, sessionID); err != nil {
+		return nil, err
+	}
+
+	var stack *ComposeStack
+	err := s.withSessionLock(ctx, sessionID, "compose_scale", func() error {
+		var err error
+		stack, err = s.Compose.ScaleService(ctx, sessionID, stackName, name, replicas)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Bus.Publish(ctx, sessionID, eventbus.Event{
+		Type:      eventbus.EventComposeServiceScaled,
+		SessionID: sessionID,
+		Payload:   map[string]any{"stack": stackName, "service": name, "replicas": replicas},
+		Timestamp: time.Now(),
+	})
+	s.syncEndpoints(ctx, sessionID)
+	return stack, nil
+}
+
+func (s *Service) checkComposeReady(ctx context.Context, sessionID string) (*session.Session, error) {
 	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("session not found: %w", err)
 	}
 
-	if sess.Status != session.StatusReady && sess.Status != session.StatusRunning {
-		return nil, fmt.Errorf("session is not ready (status: %s)", sess.Status)
+	if err := ensureActive(sess); err != nil {
+		return nil, err
 	}
 
 	if s.Compose == nil {
 		return nil, fmt.Errorf("compose manager not initialized")
 	}
-
-	return s.Compose.CreateStack(ctx, sessionID, req)
+	return sess, nil
 }
 
-func (s *Service) TeardownComposeStack(ctx context.Context, sessionID string) error {
+func (s *Service) TeardownComposeStack(ctx context.Context, sessionID, stackName string) error {
 	if s.Compose == nil {
 		return fmt.Errorf("compose manager not initialized")
 	}
-	return s.Compose.TeardownStack(ctx, sessionID)
+	err := s.withSessionLock(ctx, sessionID, "compose_down", func() error {
+		return s.Compose.TeardownStack(ctx, sessionID, stackName)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.syncEndpoints(ctx, sessionID)
+	return nil
+}
+
+func (s *Service) GetComposeStack(sessionID, stackName string) *ComposeStack {
+	if s.Compose == nil {
+		return nil
+	}
+	return s.Compose.GetStack(sessionID, stackName)
 }
 
-func (s *Service) GetComposeStack(sessionID string) *ComposeStack {
+// ListComposeStacks 返回 session 的所有 compose 堆栈
+func (s *Service) ListComposeStacks(sessionID string) []*ComposeStack {
 	if s.Compose == nil {
 		return nil
 	}
-	return s.Compose.GetStack(sessionID)
+	return s.Compose.ListStacks(sessionID)
 }
 
-func (s *Service) RefreshComposeStack(ctx context.Context, sessionID string) (*ComposeStack, error) {
+func (s *Service) RefreshComposeStack(ctx context.Context, sessionID, stackName string) (*ComposeStack, error) {
 	if s.Compose == nil {
 		return nil, fmt.Errorf("compose manager not initialized")
 	}
-	return s.Compose.RefreshServices(ctx, sessionID)
+	stack, err := s.Compose.RefreshServices(ctx, sessionID, stackName)
+	if err != nil {
+		return nil, err
+	}
+
+	// 刷新时同步连接信息，IP 或健康状态变化会更新到 Agent 容器
+	s.syncEndpoints(ctx, sessionID)
+	return stack, nil
 }
 
 func (s *Service) ListSessionsByProject(ctx context.Context, projectID string) ([]*session.Session, error) {
@@ -495,9 +988,27 @@ func (s *Service) ListSessionsByProject(ctx context.Context, projectID string) (
 }
 
 func (s *Service) ListActiveSessions(ctx context.Context) ([]*session.Session, error) {
-	return s.SessionRepo.ListByStatus(ctx, []session.SessionStatus{
-		session.StatusInitializing,
-		session.StatusReady,
-		session.StatusRunning,
-	})
+	return s.SessionRepo.ListByStatus(ctx, session.LiveStatuses())
+}
+
+// ListWorkerTasks 返回 Worker 正在处理的会话创建任务及其心跳状态
+func (s *Service) ListWorkerTasks(ctx context.Context) ([]taskstatus.TaskView, error) {
+	if s.Tasks == nil {
+		return nil, fmt.Errorf("task tracker not initialized")
+	}
+	return s.Tasks.List(ctx)
+}
+
+// StorageReport 返回平台存储占用，refresh 为 true 或尚无缓存时重新统计
+func (s *Service) StorageReport(ctx context.Context, refresh bool) (*diskusage.Report, error) {
+	if s.DiskUsage == nil {
+		return nil, fmt.Errorf("disk usage inspector not initialized")
+	}
+
+	if !refresh {
+		if report := s.DiskUsage.Last(); report != nil {
+			return report, nil
+		}
+	}
+	return s.DiskUsage.Inspect(ctx)
 }
@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"platform/internal/slo"
+)
+
+// errSLOUnavailable 未启用 SLO 统计
+var errSLOUnavailable = errors.New("slo tracking not found")
+
+// SLOReport 返回会话创建和对话分发在各时间窗口内的成功率、耗时分位数和错误预算消耗
+func (s *Service) SLOReport(ctx context.Context) ([]slo.Report, error) {
+	if s.SLO == nil {
+		return nil, errSLOUnavailable
+	}
+	return s.SLO.Report(ctx)
+}
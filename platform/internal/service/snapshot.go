@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+
+	"platform/internal/sandbox"
+	"platform/internal/secretfile"
+	"platform/internal/snapshot"
+)
+
+// SnapshotWorkspace 将 session 工作区打包保存为快照，归档内的路径相对于工作区目录
+func (s *Service) SnapshotWorkspace(ctx context.Context, sessionID string) (*snapshot.Info, error) {
+	if s.Snapshots == nil {
+		return nil, fmt.Errorf("snapshot store not initialized")
+	}
+	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	if sess.ContainerID == "" {
+		return nil, fmt.Errorf("session has no container")
+	}
+
+	workspace := sandbox.DefaultMountPath(sess.ProjectID)
+	// 以 /. 结尾时 Docker 只打包目录内容，条目名为 ./<path>
+	reader, _, err := s.dockerFor(sess).CopyFromContainer(ctx, sess.ContainerID, workspace+"/.")
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy from container: %w", err)
+	}
+	// 快照会被其他 session 恢复，不包含凭据文件；新 session 的 .env 由 worker 重新生成
+	filtered := secretfile.FilterTar(reader, s.SecretFiles)
+	defer filtered.Close()
+
+	info, err := s.Snapshots.Put(ctx, filtered)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Info("Workspace snapshot saved", "session_id", sessionID, "snapshot_id", info.ID, "size", info.Size)
+	return info, nil
+}
+
+// RestoreWorkspace 清空 session 工作区后解压快照，用于从已知状态分叉 agent 运行
+func (s *Service) RestoreWorkspace(ctx context.Context, sessionID, snapshotID string) error {
+	return s.withSessionLock(ctx, sessionID, "restore_workspace", func() error {
+		return s.restoreWorkspace(ctx, sessionID, snapshotID)
+	})
+}
+
+func (s *Service) restoreWorkspace(ctx context.Context, sessionID, snapshotID string) error {
+	if s.Snapshots == nil {
+		return fmt.Errorf("snapshot store not initialized")
+	}
+	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("session not found: %w", err)
+	}
+	if err := ensureActive(sess); err != nil {
+		return err
+	}
+
+	archive, err := s.Snapshots.Open(ctx, snapshotID)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	workspace := sandbox.DefaultMountPath(sess.ProjectID)
+	c := s.sessionContainer(sess)
+	result, err := c.Exec(ctx, []string{"find", workspace, "-mindepth", "1", "-delete"}, nil, "/")
+	if err != nil {
+		return fmt.Errorf("failed to clear workspace: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("failed to clear workspace: %s", strings.TrimSpace(result.Stderr))
+	}
+
+	if err := s.dockerFor(sess).CopyToContainer(ctx, sess.ContainerID, workspace, archive, container.CopyToContainerOptions{
+		AllowOverwriteDirWithFile: true,
+	}); err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+
+	s.Logger.Info("Workspace restored from snapshot", "session_id", sessionID, "snapshot_id", snapshotID)
+	return nil
+}
+
+// checkSnapshot 创建 session 时检查种子快照是否存在
+func (s *Service) checkSnapshot(ctx context.Context, snapshotID string) error {
+	if s.Snapshots == nil {
+		return fmt.Errorf("invalid snapshot_id: snapshot store not initialized")
+	}
+	if _, err := s.Snapshots.Stat(ctx, snapshotID); err != nil {
+		if errors.Is(err, snapshot.ErrNotFound) {
+			return fmt.Errorf("invalid snapshot_id %q: no such snapshot", snapshotID)
+		}
+		return err
+	}
+	return nil
+}
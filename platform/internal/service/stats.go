@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"platform/internal/sandbox"
+)
+
+// StreamSessionStats 订阅 session 容器的实时资源使用，ctx 取消时停止
+func (s *Service) StreamSessionStats(ctx context.Context, sessionID string) (<-chan sandbox.ResourceStats, error) {
+	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	if sess.ContainerID == "" {
+		return nil, fmt.Errorf("session is not ready: no container")
+	}
+
+	c := sandbox.NewContainer(s.dockerFor(sess), sandbox.ContainerConfig{
+		SessionID:       sess.ID,
+		ProjectID:       sess.ProjectID,
+		UseAnonymousVol: true,
+	}, "", s.Logger)
+	c.ID = sess.ContainerID
+
+	return c.Stats(ctx)
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+
+	"platform/internal/eventbus"
+	"platform/internal/sandbox"
+	"platform/internal/session"
+)
+
+// SuspendSession 停止长时间无活动的 session 的容器，容器和 session 都保留；
+// ResumeSession 重新启动容器并重放对话。挂起期间容器不占用 CPU 和内存
+func (s *Service) SuspendSession(ctx context.Context, sessionID string) error {
+	return s.withSessionLock(ctx, sessionID, "suspend", func() error {
+		return s.suspendSession(ctx, sessionID)
+	})
+}
+
+func (s *Service) suspendSession(ctx context.Context, sessionID string) error {
+	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("session not found: %w", err)
+	}
+	if sess.Status == session.StatusSuspended {
+		return fmt.Errorf("session already suspended")
+	}
+	if sess.Status != session.StatusReady && sess.Status != session.StatusRunning {
+		return fmt.Errorf("session is not ready (status: %s)", sess.Status)
+	}
+	if sess.ContainerID == "" {
+		return fmt.Errorf("session is not ready: no container")
+	}
+
+	volatile, err := s.workspaceOnTmpfs(ctx, sess)
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]any{"last_active": sess.LastActive()}
+	if volatile {
+		snapshotID, err := s.suspendToSnapshot(ctx, sess)
+		if err != nil {
+			return err
+		}
+		payload["snapshot_id"] = snapshotID
+	} else {
+		s.Dispatcher.CleanUp(sessionID)
+
+		timeout := sandbox.CurrentRemovalPolicy().StopTimeoutSeconds()
+		if err := s.dockerFor(sess).ContainerStop(ctx, sess.ContainerID, container.StopOptions{Timeout: &timeout}); err != nil {
+			return fmt.Errorf("failed to stop container: %w", err)
+		}
+	}
+	if err := s.SessionRepo.UpdateSessionStatus(ctx, sessionID, session.StatusSuspended); err != nil {
+		return fmt.Errorf("failed to update session status: %w", err)
+	}
+
+	if s.Bus != nil {
+		s.Bus.Publish(ctx, sessionID, eventbus.Event{
+			Type:      eventbus.EventSessionSuspended,
+			SessionID: sessionID,
+			Payload:   payload,
+			Timestamp: time.Now(),
+		})
+	}
+	s.Logger.Info("Session suspended", "session_id", sessionID, "container_id", sess.ContainerID, "last_active", sess.LastActive())
+	return nil
+}
+
+// workspaceOnTmpfs 返回 session 的工作区是否在 tmpfs 后端的卷上（设置了 POOL_CONTAINER_DISK_MB 的预热容器），
+// 这类工作区在容器停止时丢失
+func (s *Service) workspaceOnTmpfs(ctx context.Context, sess *session.Session) (bool, error) {
+	docker := s.dockerFor(sess)
+	inspect, err := docker.ContainerInspect(ctx, sess.ContainerID)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	workspace := sandbox.DefaultMountPath(sess.ProjectID)
+	for _, m := range inspect.Mounts {
+		if m.Destination != workspace || m.Type != mount.TypeVolume {
+			continue
+		}
+		vol, err := docker.VolumeInspect(ctx, m.Name)
+		if err != nil {
+			return false, fmt.Errorf("failed to inspect workspace volume: %w", err)
+		}
+		return vol.Options["type"] == "tmpfs", nil
+	}
+	return false, nil
+}
+
+// suspendToSnapshot 挂起工作区在 tmpfs 上的 session：先把工作区保存为快照并记入创建参数，再释放容器，
+// 恢复时没有容器，由 worker 按创建参数取新容器并用快照初始化工作区。返回快照 ID
+func (s *Service) suspendToSnapshot(ctx context.Context, sess *session.Session) (string, error) {
+	if s.Snapshots == nil || sess.Spec == nil {
+		return "", fmt.Errorf("session workspace is memory-backed and cannot be suspended without a snapshot store")
+	}
+	info, err := s.SnapshotWorkspace(ctx, sess.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to snapshot workspace before suspending: %w", err)
+	}
+	spec := *sess.Spec
+	spec.SnapshotID = info.ID
+	spec.Git = nil
+	if err := s.SessionRepo.UpdateSessionSpec(ctx, sess.ID, &spec); err != nil {
+		return "", fmt.Errorf("failed to record workspace snapshot: %w", err)
+	}
+
+	s.Dispatcher.CleanUp(sess.ID)
+	s.endpoints.Forget(sess.ID)
+	s.releaseContainer(ctx, sess)
+	if err := s.SessionRepo.UpdateSessionContainerInfo(ctx, sess.ID, "", ""); err != nil {
+		s.Logger.Warn("Failed to clear container info", "session_id", sess.ID, "error", err)
+	}
+	return info.ID, nil
+}
+
+// resumeSuspended 重新启动挂起的 session 的容器，并从恢复时重新计算空闲时间
+func (s *Service) resumeSuspended(ctx context.Context, sess *session.Session) error {
+	if err := s.restartSession(ctx, sess.ID, false); err != nil {
+		return err
+	}
+	if s.Activity != nil {
+		s.Activity.Reset(ctx, sess.ID)
+	}
+	s.Logger.Info("Session resumed from suspension", "session_id", sess.ID, "container_id", sess.ContainerID)
+	return nil
+}
+
+// TouchSession 记录 session 的一次对话、终端或文件操作，未配置 Activity 时不记录
+func (s *Service) TouchSession(ctx context.Context, sessionID string) {
+	if s.Activity != nil {
+		s.Activity.Touch(ctx, sessionID)
+	}
+}
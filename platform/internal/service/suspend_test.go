@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"platform/internal/session"
+	"platform/internal/snapshot"
+)
+
+func TestSuspendToSnapshotRequiresSnapshotAndSpec(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cases := []struct {
+		name      string
+		snapshots *snapshot.Store
+		spec      *session.SessionCreatePayload
+	}{
+		{name: "no snapshot store", spec: &session.SessionCreatePayload{}},
+		{name: "no recorded spec", snapshots: snapshot.NewStore(nil)},
+	}
+	for _, tc := range cases {
+		sess := &session.Session{ID: "s1", ContainerID: "c1", Status: session.StatusReady, Spec: tc.spec}
+		repo := &statusRepo{sess: sess}
+		s := &Service{SessionRepo: repo, Snapshots: tc.snapshots, Logger: logger}
+
+		// 拿不到快照时不能停止容器，否则 tmpfs 上的工作区直接丢失
+		_, err := s.suspendToSnapshot(context.Background(), sess)
+		if err == nil || !strings.Contains(err.Error(), "memory-backed") {
+			t.Errorf("%s: err = %v, want memory-backed error", tc.name, err)
+		}
+		if len(repo.updates) != 0 {
+			t.Errorf("%s: status updates = %v, want none", tc.name, repo.updates)
+		}
+	}
+}
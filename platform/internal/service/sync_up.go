@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"platform/internal/filesync"
+)
+
+// SyncToContainer 将宿主机项目目录（或其子目录 subPath）增量同步到容器工作区的对应位置，
+// 只传输新增或内容变化的文件；deleteExtra 时删除容器中本地已不存在的文件
+func (s *Service) SyncToContainer(ctx context.Context, sessionID, subPath string, deleteExtra bool) (*filesync.Result, error) {
+	var result *filesync.Result
+	err := s.withSessionLock(ctx, sessionID, "sync_up", func() error {
+		var err error
+		result, err = s.syncToContainer(ctx, sessionID, subPath, deleteExtra)
+		return err
+	})
+	return result, err
+}
+
+func (s *Service) syncToContainer(ctx context.Context, sessionID, subPath string, deleteExtra bool) (*filesync.Result, error) {
+	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	if err := ensureActive(sess); err != nil {
+		return nil, err
+	}
+	if sess.ContainerID == "" {
+		return nil, fmt.Errorf("session has no container")
+	}
+
+	if strings.Contains(subPath, "..") {
+		return nil, fmt.Errorf("invalid path %q", subPath)
+	}
+	rel := path.Clean("/" + filepath.ToSlash(subPath))
+	hostSrc := filepath.Join(s.HostRoot, sess.ProjectID, filepath.FromSlash(rel))
+	if _, err := os.Stat(hostSrc); err != nil {
+		return nil, fmt.Errorf("host path not found: %s", rel)
+	}
+
+	c := s.sessionContainer(sess)
+	result, err := filesync.Sync(ctx, c, hostSrc, filesync.Options{
+		Dest:   path.Join(c.MountPath, rel),
+		Delete: deleteExtra,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Logger.Info("Files synced from host to container",
+		"session_id", sessionID,
+		"host_src", hostSrc,
+		"uploaded", result.Uploaded,
+		"unchanged", result.Unchanged,
+		"deleted", result.Deleted,
+		"bytes", result.Bytes,
+	)
+	return result, nil
+}
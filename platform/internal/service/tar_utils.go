@@ -2,15 +2,20 @@ package service
 
 import (
 	"archive/tar"
+	"bytes"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"platform/internal/sandbox/pathsafe"
+	"platform/internal/secretfile"
 )
 
-// extractTarToDir 将 tar 流解压到目标目录
-func extractTarToDir(r io.Reader, destDir string) error {
+// extractTarToDir 将 tar 流解压到 root 下的 destDir 目录，secrets 不为 nil 时凭据文件交由它处理。
+// 所有写入都经由 root 完成，宿主机上已有的符号链接指向 root 之外时写入失败
+func extractTarToDir(r io.Reader, root *os.Root, destDir string, secrets *hostSecrets) error {
 	tr := tar.NewReader(r)
 
 	for {
@@ -22,28 +27,32 @@ func extractTarToDir(r io.Reader, destDir string) error {
 			return fmt.Errorf("tar read error: %w", err)
 		}
 
-		cleanName := filepath.Clean(header.Name)
-		if strings.HasPrefix(cleanName, "..") {
+		// 第一级为被复制的目录本身
+		_, relPath, ok := strings.Cut(strings.TrimPrefix(header.Name, "/"), "/")
+		if !ok {
 			continue
 		}
-
-		parts := strings.SplitN(cleanName, string(os.PathSeparator), 2)
-		if len(parts) < 2 {
+		target, err := pathsafe.HostJoin(destDir, relPath)
+		if err != nil {
 			continue
 		}
-		relPath := parts[1]
-		target := filepath.Join(destDir, relPath)
 
 		switch header.Typeflag {
 		case tar.TypeDir:
-			if err := os.MkdirAll(target, os.FileMode(header.Mode)); err != nil {
+			if err := root.MkdirAll(target, os.FileMode(header.Mode)); err != nil {
 				return err
 			}
 		case tar.TypeReg:
-			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			if err := root.MkdirAll(filepath.Dir(target), 0755); err != nil {
 				return err
 			}
-			f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(header.Mode))
+			if secrets != nil && secrets.match.Match(relPath) {
+				if err := secrets.write(root, target, tr); err != nil {
+					return err
+				}
+				continue
+			}
+			f, err := root.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(header.Mode))
 			if err != nil {
 				return err
 			}
@@ -56,20 +65,31 @@ func extractTarToDir(r io.Reader, destDir string) error {
 	}
 }
 
-// extractFirstFileFromTar 读取 tar 流并将第一个文件写入 w
-func extractFirstFileFromTar(r io.Reader, w io.Writer) error {
-	tr := tar.NewReader(r)
-	for {
-		header, err := tr.Next()
-		if err == io.EOF {
-			return fmt.Errorf("no file found in archive")
-		}
-		if err != nil {
-			return fmt.Errorf("tar read error: %w", err)
-		}
-		if header.Typeflag == tar.TypeReg {
-			_, err := io.Copy(w, tr)
-			return err
-		}
+// hostSecrets 同步回宿主机时对凭据文件的处理：sealer 为 nil 时跳过，否则按租户加密写为 <name>.enc
+type hostSecrets struct {
+	match  *secretfile.Matcher
+	sealer *secretfile.Sealer
+	tenant string
+}
+
+func (h *hostSecrets) write(root *os.Root, target string, r io.Reader) error {
+	if h.sealer == nil {
+		return nil
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	sealed, err := h.sealer.Seal(h.tenant, data)
+	if err != nil {
+		return err
+	}
+	if err := root.WriteFile(target+secretfile.SealedSuffix, sealed, 0600); err != nil {
+		return err
+	}
+	// 以前同步下来的明文副本与容器中内容相同时安全删除，用户自己放置的其他内容不动
+	if old, err := root.ReadFile(target); err == nil && bytes.Equal(old, data) {
+		return secretfile.SecureDeleteIn(root, target)
 	}
+	return nil
 }
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func tarOf(t *testing.T, files map[string]string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestExtractTarToDirStaysInRoot(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   string
+		wantErr bool
+	}{
+		{"plain file", "src/dir/ok.txt", false},
+		{"symlinked directory", "src/escape/pwned", true},
+		{"symlinked file", "src/link.txt", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectRoot, outside := t.TempDir(), t.TempDir()
+			// Agent 在挂载的工作区中放置的指向宿主机其他位置的符号链接
+			if err := os.Symlink(outside, filepath.Join(projectRoot, "escape")); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Symlink(filepath.Join(outside, "target.txt"), filepath.Join(projectRoot, "link.txt")); err != nil {
+				t.Fatal(err)
+			}
+			root, err := os.OpenRoot(projectRoot)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer root.Close()
+
+			err = extractTarToDir(tarOf(t, map[string]string{tt.entry: "data"}), root, ".", nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("extractTarToDir error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+				t.Errorf("extraction wrote outside the project root: %v", entries)
+			}
+			if !tt.wantErr {
+				if data, err := os.ReadFile(filepath.Join(projectRoot, "dir", "ok.txt")); err != nil || string(data) != "data" {
+					t.Errorf("extracted file = %q, %v", data, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// DefaultTerminalShell 未指定命令时启动的 shell，镜像没有 bash 时回退到 sh
+var DefaultTerminalShell = []string{"/bin/sh", "-c", "if command -v bash >/dev/null 2>&1; then exec bash; else exec sh; fi"}
+
+// TerminalOptions 打开终端时的初始参数
+type TerminalOptions struct {
+	Cmd  []string
+	Cols uint
+	Rows uint
+}
+
+// Terminal session 容器内的交互式 TTY exec。TTY 模式下 stdout 和 stderr 合并为一个流。
+type Terminal struct {
+	execID string
+	resp   types.HijackedResponse
+	docker *client.Client
+}
+
+// OpenTerminal 在 session 容器的工作目录中启动带 TTY 的 exec
+func (s *Service) OpenTerminal(ctx context.Context, sessionID string, opts TerminalOptions) (*Terminal, error) {
+	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	if err := ensureActive(sess); err != nil {
+		return nil, err
+	}
+	if sess.ContainerID == "" {
+		return nil, fmt.Errorf("session is not ready: no container")
+	}
+
+	cmd := opts.Cmd
+	if len(cmd) == 0 {
+		cmd = DefaultTerminalShell
+	}
+
+	// 策略只检查启动命令，进入交互式 shell 后输入的命令不经过平台
+	env, err := s.enforceExecPolicy(ctx, sessionID, cmd, "/app/workspace", []string{"TERM=xterm-256color"})
+	if err != nil {
+		return nil, err
+	}
+
+	execOpts := container.ExecOptions{
+		Cmd:          cmd,
+		Env:          env,
+		WorkingDir:   "/app/workspace",
+		Tty:          true,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	}
+	if opts.Cols > 0 && opts.Rows > 0 {
+		execOpts.ConsoleSize = &[2]uint{opts.Rows, opts.Cols}
+	}
+
+	docker := s.dockerFor(sess)
+	created, err := docker.ContainerExecCreate(ctx, sess.ContainerID, execOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create terminal exec: %w", err)
+	}
+
+	resp, err := docker.ContainerExecAttach(ctx, created.ID, container.ExecAttachOptions{
+		Tty:         true,
+		ConsoleSize: execOpts.ConsoleSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach terminal exec: %w", err)
+	}
+
+	s.Logger.Info("Terminal opened", "session_id", sessionID, "exec_id", created.ID)
+	return &Terminal{execID: created.ID, resp: resp, docker: docker}, nil
+}
+
+// Read 读取终端输出
+func (t *Terminal) Read(p []byte) (int, error) {
+	return t.resp.Reader.Read(p)
+}
+
+// Write 向终端写入输入
+func (t *Terminal) Write(p []byte) (int, error) {
+	return t.resp.Conn.Write(p)
+}
+
+// Resize 调整终端窗口大小
+func (t *Terminal) Resize(ctx context.Context, cols, rows uint) error {
+	return t.docker.ContainerExecResize(ctx, t.execID, container.ResizeOptions{Width: cols, Height: rows})
+}
+
+// ExitCode 返回终端进程的退出码，进程仍在运行时 running 为 true
+func (t *Terminal) ExitCode(ctx context.Context) (code int, running bool, err error) {
+	inspect, err := t.docker.ContainerExecInspect(ctx, t.execID)
+	if err != nil {
+		return 0, false, err
+	}
+	return inspect.ExitCode, inspect.Running, nil
+}
+
+// Close 关闭连接，shell 收到 EOF / SIGHUP 后退出
+func (t *Terminal) Close() error {
+	t.resp.Close()
+	return nil
+}
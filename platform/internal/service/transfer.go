@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"platform/internal/eventbus"
+	"platform/internal/filesync"
+	"platform/internal/orchestrator"
+	"platform/internal/session"
+)
+
+// TransferContainer 将 fromID 租用的预热容器直接转给仍在排队初始化的 toID（如高优先级 session
+// 抢占已结束但尚未释放的 session），省去一次容器获取。fromID 随之终止，其伴随服务等资源被清理；
+// 工作区按 toID 的项目目录重新同步，Agent 进程沿用，按 session 隔离的对话状态从空白开始
+func (s *Service) TransferContainer(ctx context.Context, fromID, toID string) (*session.Session, error) {
+	if fromID == toID {
+		return nil, fmt.Errorf("invalid transfer: source and target are the same session")
+	}
+
+	// 按固定顺序获取两把锁，避免相向转移时互相等待
+	first, second := fromID, toID
+	if second < first {
+		first, second = second, first
+	}
+	var to *session.Session
+	err := s.withSessionLock(ctx, first, "transfer", func() error {
+		return s.withSessionLock(ctx, second, "transfer", func() error {
+			var err error
+			to, err = s.transferContainer(ctx, fromID, toID)
+			return err
+		})
+	})
+	return to, err
+}
+
+func (s *Service) transferContainer(ctx context.Context, fromID, toID string) (*session.Session, error) {
+	from, err := s.SessionMgr.GetSession(ctx, fromID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	to, err := s.SessionMgr.GetSession(ctx, toID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+
+	// 冷容器按 session 的镜像、挂载和网络策略创建，只有池中的预热容器可以转移
+	if from.ContainerStrategy() != orchestrator.WarmStrategyType || to.Strategy != orchestrator.WarmStrategyType {
+		return nil, fmt.Errorf("invalid transfer: both sessions must use %s", orchestrator.WarmStrategyType)
+	}
+	if err := ensureActive(from); err != nil {
+		return nil, err
+	}
+	if from.ContainerID == "" {
+		return nil, fmt.Errorf("session has no container")
+	}
+	if to.Status != session.StatusInitializing || to.ContainerID != "" {
+		return nil, fmt.Errorf("invalid transfer target: session %s is %s", toID, to.Status)
+	}
+
+	c := s.sessionContainer(from)
+	if _, err := s.Dispatcher.Stop(ctx, c, fromID); err != nil {
+		s.Logger.Warn("Failed to stop agent before transfer", "session_id", fromID, "error", err)
+	}
+	s.releaseSessionResources(ctx, fromID)
+
+	if err := s.SessionMgr.TransferContainer(ctx, from, to, c); err != nil {
+		return nil, err
+	}
+	s.Logger.Info("Container transferred", "container_id", c.ID, "from_session", fromID, "to_session", toID)
+
+	s.Bus.Publish(ctx, fromID, eventbus.Event{
+		Type:      eventbus.EventSessionClosed,
+		SessionID: fromID,
+		Payload:   map[string]string{"reason": "transferred", "session_id": toID},
+		Timestamp: time.Now(),
+	})
+
+	// 清掉上一个 session 的工作区（包括 .env），换成目标项目的文件
+	projectRoot := filepath.Join(s.HostRoot, to.ProjectID)
+	if err := os.MkdirAll(projectRoot, 0755); err != nil {
+		s.Logger.Warn("Failed to ensure project dir", "path", projectRoot, "error", err)
+	}
+	result, err := filesync.Sync(ctx, c, projectRoot, filesync.Options{Dest: c.MountPath, Delete: true})
+	if err != nil {
+		s.Logger.Error("Failed to sync workspace after transfer", "session_id", toID, "error", err)
+		if err := s.SessionRepo.UpdateSessionStatus(ctx, toID, session.StatusError); err != nil {
+			s.Logger.Warn("Failed to mark session as error", "session_id", toID, "error", err)
+		}
+		s.Bus.Publish(ctx, toID, eventbus.Event{
+			Type:      eventbus.EventSessionError,
+			SessionID: toID,
+			Payload:   fmt.Sprintf("failed to sync project after transfer: %v", err),
+			Timestamp: time.Now(),
+		})
+		return nil, fmt.Errorf("failed to sync workspace: %w", err)
+	}
+	s.Logger.Info("Workspace reset after transfer", "session_id", toID,
+		"uploaded", result.Uploaded, "deleted", result.Deleted, "bytes", result.Bytes)
+
+	s.Bus.Publish(ctx, toID, eventbus.Event{
+		Type:      eventbus.EventSessionReady,
+		SessionID: toID,
+		Payload: map[string]string{
+			"container_id": c.ID,
+			"node_ip":      c.IP,
+		},
+		Timestamp: time.Now(),
+	})
+
+	return s.SessionMgr.GetSession(ctx, toID)
+}
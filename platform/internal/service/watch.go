@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/mount"
+
+	"platform/internal/nodes"
+	"platform/internal/sandbox"
+	"platform/internal/sandbox/pathsafe"
+	"platform/internal/session"
+)
+
+// filePollInterval 匿名卷工作区的轮询间隔
+const filePollInterval = 2 * time.Second
+
+// WatchContainerFiles 监听工作区中 dir 下的文件变更，ctx 结束时关闭返回的通道。
+// 工作区为宿主机 bind mount 时使用 fsnotify，匿名卷等宿主机不可见的工作区退化为定期列出容器内文件并比较
+func (s *Service) WatchContainerFiles(ctx context.Context, sessionID, dir string) (<-chan sandbox.FileEvent, error) {
+	rel, err := pathsafe.Rel(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := s.SessionMgr.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	if sess.Status == session.StatusPaused {
+		return nil, ErrSessionPaused
+	}
+	if sess.ContainerID == "" {
+		return nil, fmt.Errorf("session has no container")
+	}
+
+	workspace := s.sessionContainer(sess).MountPath
+	if rel, err = s.resolveWorkspacePath(ctx, sess.ContainerID, workspace, rel); err != nil {
+		return nil, err
+	}
+	if hostDir := s.workspaceHostDir(ctx, sess, workspace); hostDir != "" {
+		target := filepath.Join(hostDir, filepath.FromSlash(rel))
+		if info, err := os.Stat(target); err == nil && info.IsDir() {
+			ch, err := sandbox.WatchHostDir(ctx, target)
+			if err == nil {
+				s.Logger.Info("Watching workspace on host", "session_id", sessionID, "path", target)
+				return ch, nil
+			}
+			s.Logger.Warn("Failed to watch workspace on host, falling back to polling", "session_id", sessionID, "error", err)
+		}
+	}
+
+	target := path.Join(workspace, rel)
+	containerID := sess.ContainerID
+	return sandbox.PollFiles(ctx, filePollInterval, func(ctx context.Context) ([]sandbox.FileInfo, error) {
+		stdout, stderr, exitCode, err := s.execQuiet(ctx, containerID, sandbox.FindCommand(target, true))
+		if err != nil {
+			return nil, err
+		}
+		if exitCode != 0 && len(stdout) == 0 {
+			if strings.Contains(stderr, "No such file") {
+				return nil, fmt.Errorf("path not found: %s", "/"+rel)
+			}
+			return nil, fmt.Errorf("failed to list files: %s", strings.TrimSpace(stderr))
+		}
+		return sandbox.ParseFindOutput(stdout)
+	})
+}
+
+// workspaceHostDir 返回容器工作区 bind mount 的宿主机目录，工作区不是 bind mount、容器在远程节点或无法检查时返回空串
+func (s *Service) workspaceHostDir(ctx context.Context, sess *session.Session, workspace string) string {
+	if sess.NodeID != "" && sess.NodeID != nodes.LocalNode {
+		return ""
+	}
+	inspect, err := s.Docker.ContainerInspect(ctx, sess.ContainerID)
+	if err != nil {
+		return ""
+	}
+	for _, m := range inspect.Mounts {
+		if m.Destination == workspace && m.Type == mount.TypeBind {
+			return m.Source
+		}
+	}
+	return ""
+}
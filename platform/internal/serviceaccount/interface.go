@@ -0,0 +1,18 @@
+package serviceaccount
+
+import (
+	"context"
+	"time"
+)
+
+type Store interface {
+	Create(ctx context.Context, account *Account) error
+	Get(ctx context.Context, id string) (*Account, error)
+	// GetByTokenHash 按当前令牌或轮换宽限期内的旧令牌摘要查找
+	GetByTokenHash(ctx context.Context, hash string) (*Account, error)
+	List(ctx context.Context) ([]*Account, error)
+	// UpdateToken 保存轮换后的令牌摘要
+	UpdateToken(ctx context.Context, account *Account) error
+	TouchLastUsed(ctx context.Context, id string, at time.Time) error
+	Delete(ctx context.Context, id string) error
+}
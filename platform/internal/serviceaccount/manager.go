@@ -0,0 +1,198 @@
+package serviceaccount
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"platform/internal/auth"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TokenPrefix 服务账号令牌的固定前缀，用于和 OIDC JWT 区分
+const TokenPrefix = "sat_"
+
+// lastUsedResolution last_used_at 的更新粒度，避免每个请求都写库
+const lastUsedResolution = time.Minute
+
+var ErrInvalidParams = errors.New("invalid service account")
+
+type CreateParams struct {
+	Name   string
+	UserID string
+	Scopes []auth.Scope
+	// TTL 令牌有效期，0 表示不过期
+	TTL time.Duration
+}
+
+// Manager 管理服务账号的创建、令牌轮换和认证
+type Manager struct {
+	store  Store
+	logger *slog.Logger
+}
+
+func NewManager(store Store, logger *slog.Logger) *Manager {
+	return &Manager{
+		store:  store,
+		logger: logger.With("component", "service-account"),
+	}
+}
+
+// IsToken 判断 Bearer 凭证是否为服务账号令牌
+func IsToken(raw string) bool {
+	return strings.HasPrefix(raw, TokenPrefix)
+}
+
+// Create 创建服务账号并返回明文令牌，明文令牌只在此处返回一次
+func (m *Manager) Create(ctx context.Context, params CreateParams) (*Account, string, error) {
+	if params.Name == "" || params.UserID == "" {
+		return nil, "", fmt.Errorf("%w: name and user_id are required", ErrInvalidParams)
+	}
+	if err := validateScopes(params.Scopes); err != nil {
+		return nil, "", err
+	}
+
+	token, hash, err := newToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	account := &Account{
+		ID:          uuid.New().String(),
+		Name:        params.Name,
+		UserID:      params.UserID,
+		Scopes:      params.Scopes,
+		TokenPrefix: token[:len(TokenPrefix)+8],
+		CreatedAt:   now,
+		tokenHash:   hash,
+	}
+	if params.TTL > 0 {
+		account.ExpiresAt = now.Add(params.TTL)
+	}
+
+	if err := m.store.Create(ctx, account); err != nil {
+		return nil, "", err
+	}
+
+	m.logger.Info("Service account created", "id", account.ID, "name", account.Name, "scopes", account.Scopes)
+	return account, token, nil
+}
+
+// Rotate 签发新令牌。grace > 0 时旧令牌在宽限期内仍然有效，便于调用方无缝切换；
+// ttl > 0 时同时重置过期时间。
+func (m *Manager) Rotate(ctx context.Context, id string, grace, ttl time.Duration) (*Account, string, error) {
+	account, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, hash, err := newToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	account.previousTokenHash = ""
+	account.previousExpiresAt = time.Time{}
+	if grace > 0 {
+		account.previousTokenHash = account.tokenHash
+		account.previousExpiresAt = now.Add(grace)
+	}
+	account.tokenHash = hash
+	account.TokenPrefix = token[:len(TokenPrefix)+8]
+	account.RotatedAt = now
+	if ttl > 0 {
+		account.ExpiresAt = now.Add(ttl)
+	}
+
+	if err := m.store.UpdateToken(ctx, account); err != nil {
+		return nil, "", err
+	}
+
+	m.logger.Info("Service account token rotated", "id", account.ID, "grace", grace)
+	return account, token, nil
+}
+
+// Authenticate 校验令牌并返回服务账号身份，失败时返回 auth.ErrInvalidToken
+func (m *Manager) Authenticate(ctx context.Context, raw string) (*auth.Identity, error) {
+	account, err := m.store.GetByTokenHash(ctx, hashToken(raw))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: unknown service account token", auth.ErrInvalidToken)
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if account.tokenHash != hashToken(raw) && now.After(account.previousExpiresAt) {
+		return nil, fmt.Errorf("%w: service account token has been rotated", auth.ErrInvalidToken)
+	}
+	if account.expired(now) {
+		return nil, fmt.Errorf("%w: service account token expired", auth.ErrInvalidToken)
+	}
+
+	if now.Sub(account.LastUsedAt) > lastUsedResolution {
+		if err := m.store.TouchLastUsed(ctx, account.ID, now); err != nil {
+			m.logger.Warn("Failed to update last_used_at", "id", account.ID, "error", err)
+		}
+	}
+
+	return &auth.Identity{
+		UserID:           account.UserID,
+		Name:             account.Name,
+		Subject:          "serviceaccount:" + account.ID,
+		ExpiresAt:        account.ExpiresAt,
+		ServiceAccountID: account.ID,
+		Scopes:           account.Scopes,
+	}, nil
+}
+
+func (m *Manager) Get(ctx context.Context, id string) (*Account, error) {
+	return m.store.Get(ctx, id)
+}
+
+func (m *Manager) List(ctx context.Context) ([]*Account, error) {
+	return m.store.List(ctx)
+}
+
+// Delete 删除服务账号，其令牌立即失效
+func (m *Manager) Delete(ctx context.Context, id string) error {
+	if err := m.store.Delete(ctx, id); err != nil {
+		return err
+	}
+	m.logger.Info("Service account deleted", "id", id)
+	return nil
+}
+
+func validateScopes(scopes []auth.Scope) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("%w: at least one scope is required", ErrInvalidParams)
+	}
+	for _, s := range scopes {
+		if !auth.ValidScope(s) {
+			return fmt.Errorf("%w: unknown scope %q", ErrInvalidParams, s)
+		}
+	}
+	return nil
+}
+
+func newToken() (token, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = TokenPrefix + hex.EncodeToString(buf)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
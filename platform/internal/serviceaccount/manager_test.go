@@ -0,0 +1,147 @@
+package serviceaccount
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"platform/internal/auth"
+)
+
+type memStore struct {
+	accounts map[string]*Account
+}
+
+func newMemStore() *memStore {
+	return &memStore{accounts: make(map[string]*Account)}
+}
+
+func (s *memStore) Create(ctx context.Context, a *Account) error {
+	cp := *a
+	s.accounts[a.ID] = &cp
+	return nil
+}
+
+func (s *memStore) Get(ctx context.Context, id string) (*Account, error) {
+	a, ok := s.accounts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *a
+	return &cp, nil
+}
+
+func (s *memStore) GetByTokenHash(ctx context.Context, hash string) (*Account, error) {
+	for _, a := range s.accounts {
+		if a.tokenHash == hash || (a.previousTokenHash != "" && a.previousTokenHash == hash) {
+			cp := *a
+			return &cp, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memStore) List(ctx context.Context) ([]*Account, error) {
+	var out []*Account
+	for _, a := range s.accounts {
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+func (s *memStore) UpdateToken(ctx context.Context, a *Account) error {
+	return s.Create(ctx, a)
+}
+
+func (s *memStore) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	if a, ok := s.accounts[id]; ok {
+		a.LastUsedAt = at
+	}
+	return nil
+}
+
+func (s *memStore) Delete(ctx context.Context, id string) error {
+	if _, ok := s.accounts[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.accounts, id)
+	return nil
+}
+
+func TestManagerAuthenticateAndScopes(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	m := NewManager(store, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+
+	if _, _, err := m.Create(ctx, CreateParams{Name: "ci", UserID: "u1", Scopes: []auth.Scope{"root"}}); !errors.Is(err, ErrInvalidParams) {
+		t.Fatalf("Expected unknown scope to be rejected, got %v", err)
+	}
+
+	account, token, err := m.Create(ctx, CreateParams{
+		Name:   "ci-bot",
+		UserID: "u1",
+		Scopes: []auth.Scope{auth.ScopeSessionsCreate, auth.ScopeChat},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if !IsToken(token) {
+		t.Fatalf("Token %q lacks prefix", token)
+	}
+
+	ident, err := m.Authenticate(ctx, token)
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if ident.UserID != "u1" || ident.ServiceAccountID != account.ID {
+		t.Fatalf("Unexpected identity: %+v", ident)
+	}
+	if !ident.HasScope(auth.ScopeChat) || ident.HasScope(auth.ScopeFilesRead) {
+		t.Errorf("Unexpected scopes: %v", ident.Scopes)
+	}
+	if store.accounts[account.ID].LastUsedAt.IsZero() {
+		t.Errorf("Expected last_used_at to be recorded")
+	}
+
+	if _, err := m.Authenticate(ctx, TokenPrefix+"bogus"); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken for unknown token, got %v", err)
+	}
+}
+
+func TestManagerRotateAndExpiry(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	m := NewManager(store, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+
+	account, oldToken, err := m.Create(ctx, CreateParams{Name: "bot", UserID: "u1", Scopes: []auth.Scope{auth.ScopeChat}})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	_, newToken, err := m.Rotate(ctx, account.ID, time.Hour, 0)
+	if err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if _, err := m.Authenticate(ctx, oldToken); err != nil {
+		t.Errorf("Old token should be valid during grace period: %v", err)
+	}
+	if _, err := m.Authenticate(ctx, newToken); err != nil {
+		t.Errorf("New token should be valid: %v", err)
+	}
+
+	_, latest, err := m.Rotate(ctx, account.ID, 0, 0)
+	if err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if _, err := m.Authenticate(ctx, newToken); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Errorf("Token rotated without grace should be rejected, got %v", err)
+	}
+
+	store.accounts[account.ID].ExpiresAt = time.Now().Add(-time.Minute)
+	if _, err := m.Authenticate(ctx, latest); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Errorf("Expired token should be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package serviceaccount
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+var ErrNotFound = errors.New("service account not found")
+
+var _ Store = (*PGStore)(nil)
+
+type PGStore struct {
+	db *pg.DB
+}
+
+func NewPGStore(db *pg.DB) *PGStore {
+	return &PGStore{db: db}
+}
+
+// Migrate 创建 service_accounts 表
+func Migrate(db *pg.DB) error {
+	if err := db.Model(&AccountModel{}).CreateTable(&orm.CreateTableOptions{
+		IfNotExists: true,
+	}); err != nil {
+		return fmt.Errorf("create service_accounts table: %w", err)
+	}
+	return nil
+}
+
+func (s *PGStore) Create(ctx context.Context, account *Account) error {
+	_, err := s.db.ModelContext(ctx, newAccountModel(account)).Insert()
+	return err
+}
+
+func (s *PGStore) Get(ctx context.Context, id string) (*Account, error) {
+	model := &AccountModel{ID: id}
+	if err := s.db.ModelContext(ctx, model).WherePK().Select(); err != nil {
+		if errors.Is(err, pg.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return model.toAccount(), nil
+}
+
+func (s *PGStore) GetByTokenHash(ctx context.Context, hash string) (*Account, error) {
+	model := &AccountModel{}
+	err := s.db.ModelContext(ctx, model).
+		Where("token_hash = ?", hash).
+		WhereOr("previous_token_hash = ?", hash).
+		Limit(1).
+		Select()
+	if err != nil {
+		if errors.Is(err, pg.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return model.toAccount(), nil
+}
+
+func (s *PGStore) List(ctx context.Context) ([]*Account, error) {
+	var models []AccountModel
+	if err := s.db.ModelContext(ctx, &models).Order("created_at ASC").Select(); err != nil {
+		return nil, err
+	}
+
+	accounts := make([]*Account, 0, len(models))
+	for i := range models {
+		accounts = append(accounts, models[i].toAccount())
+	}
+	return accounts, nil
+}
+
+func (s *PGStore) UpdateToken(ctx context.Context, account *Account) error {
+	res, err := s.db.ModelContext(ctx, newAccountModel(account)).
+		Column("token_prefix", "token_hash", "previous_token_hash", "previous_expires_at", "rotated_at", "expires_at").
+		WherePK().
+		Update()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *PGStore) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	_, err := s.db.ModelContext(ctx, (*AccountModel)(nil)).
+		Set("last_used_at = ?", at).
+		Where("id = ?", id).
+		Update()
+	return err
+}
+
+func (s *PGStore) Delete(ctx context.Context, id string) error {
+	res, err := s.db.ModelContext(ctx, &AccountModel{ID: id}).WherePK().Delete()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
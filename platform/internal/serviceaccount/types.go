@@ -0,0 +1,79 @@
+package serviceaccount
+
+import (
+	"platform/internal/auth"
+	"time"
+)
+
+// Account 用于自动化调用（CI、机器人）的服务账号，凭令牌以 UserID 的身份访问 API
+type Account struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	UserID      string       `json:"user_id"`
+	Scopes      []auth.Scope `json:"scopes"`
+	TokenPrefix string       `json:"token_prefix"` // 令牌前缀，便于识别，不可用于认证
+	CreatedAt   time.Time    `json:"created_at"`
+	ExpiresAt   time.Time    `json:"expires_at,omitempty"`
+	RotatedAt   time.Time    `json:"rotated_at,omitempty"`
+	LastUsedAt  time.Time    `json:"last_used_at,omitempty"`
+
+	tokenHash         string
+	previousTokenHash string
+	previousExpiresAt time.Time
+}
+
+func (a *Account) expired(now time.Time) bool {
+	return !a.ExpiresAt.IsZero() && now.After(a.ExpiresAt)
+}
+
+// AccountModel 对应 service_accounts 表，只保存令牌的 SHA-256 摘要
+type AccountModel struct {
+	tableName struct{} `pg:"service_accounts"`
+
+	ID                string       `pg:"id,pk"`
+	Name              string       `pg:"name,notnull"`
+	UserID            string       `pg:"user_id,notnull"`
+	Scopes            []auth.Scope `pg:"scopes,array"`
+	TokenPrefix       string       `pg:"token_prefix"`
+	TokenHash         string       `pg:"token_hash,notnull,unique"`
+	PreviousTokenHash string       `pg:"previous_token_hash"`
+	PreviousExpiresAt time.Time    `pg:"previous_expires_at"`
+	CreatedAt         time.Time    `pg:"created_at,notnull"`
+	ExpiresAt         time.Time    `pg:"expires_at"`
+	RotatedAt         time.Time    `pg:"rotated_at"`
+	LastUsedAt        time.Time    `pg:"last_used_at"`
+}
+
+func newAccountModel(a *Account) *AccountModel {
+	return &AccountModel{
+		ID:                a.ID,
+		Name:              a.Name,
+		UserID:            a.UserID,
+		Scopes:            a.Scopes,
+		TokenPrefix:       a.TokenPrefix,
+		TokenHash:         a.tokenHash,
+		PreviousTokenHash: a.previousTokenHash,
+		PreviousExpiresAt: a.previousExpiresAt,
+		CreatedAt:         a.CreatedAt,
+		ExpiresAt:         a.ExpiresAt,
+		RotatedAt:         a.RotatedAt,
+		LastUsedAt:        a.LastUsedAt,
+	}
+}
+
+func (m *AccountModel) toAccount() *Account {
+	return &Account{
+		ID:                m.ID,
+		Name:              m.Name,
+		UserID:            m.UserID,
+		Scopes:            m.Scopes,
+		TokenPrefix:       m.TokenPrefix,
+		CreatedAt:         m.CreatedAt,
+		ExpiresAt:         m.ExpiresAt,
+		RotatedAt:         m.RotatedAt,
+		LastUsedAt:        m.LastUsedAt,
+		tokenHash:         m.TokenHash,
+		previousTokenHash: m.PreviousTokenHash,
+		previousExpiresAt: m.PreviousExpiresAt,
+	}
+}
@@ -0,0 +1,38 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	cancelKeyPrefix = "session:cancel:"
+	// cancelTTL 取消标记的保留时间，应长于创建任务的最长重试周期
+	cancelTTL = 24 * time.Hour
+)
+
+// CancelStore 记录被取消的初始化中 session。删除排队中的创建任务无法覆盖已在处理的任务，
+// worker 在任务结束时检查标记，归还已取得的容器并保持 session 为 terminated
+type CancelStore struct {
+	redis redis.Cmdable
+}
+
+func NewCancelStore(redis redis.Cmdable) *CancelStore {
+	return &CancelStore{redis: redis}
+}
+
+// Cancel 标记 session 的创建已被取消
+func (c *CancelStore) Cancel(ctx context.Context, sessionID string) error {
+	return c.redis.Set(ctx, cancelKeyPrefix+sessionID, 1, cancelTTL).Err()
+}
+
+// Cancelled session 的创建是否已被取消，读取失败时视为未取消
+func (c *CancelStore) Cancelled(ctx context.Context, sessionID string) bool {
+	if c == nil {
+		return false
+	}
+	n, err := c.redis.Exists(ctx, cancelKeyPrefix+sessionID).Result()
+	return err == nil && n > 0
+}
@@ -74,12 +74,8 @@ func (c *SessionCleaner) cleanup() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// 查找所有处于 Initializing 或 Running 状态的 session
-	staleSessions, err := c.repo.ListByStatus(ctx, []SessionStatus{
-		StatusInitializing,
-		StatusRunning,
-		StatusReady,
-	})
+	// 查找所有未终止的 session，挂起、休眠和暂停的 session 同样受 MaxAge 限制
+	staleSessions, err := c.repo.ListByStatus(ctx, LiveStatuses())
 	if err != nil {
 		c.logger.Error("Failed to list stale sessions", "error", err)
 		return
@@ -114,6 +110,27 @@ func (c *SessionCleaner) cleanup() {
 	}
 }
 
+// TerminateLeased 强制终止预热池关闭时仍租用容器的 session，leased 的 key 为容器 ID，value 为 session ID。
+// 作为 Pool.OnDrainTimeout 的处理函数，终止流程会把容器归还预热池并删除
+func (c *SessionCleaner) TerminateLeased(ctx context.Context, leased map[string]string) {
+	for containerID, sessionID := range leased {
+		if sessionID == "" {
+			continue
+		}
+		c.logger.Warn("Force terminating session holding a leased container",
+			"session_id", sessionID,
+			"container_id", containerID,
+		)
+		if err := c.terminateFn(ctx, sessionID); err != nil {
+			c.logger.Error("Failed to force terminate session",
+				"session_id", sessionID,
+				"container_id", containerID,
+				"error", err,
+			)
+		}
+	}
+}
+
 // CleanupAllActive 在平台关闭时清理所有活跃 session。
 // 这确保所有容器、compose stack、companion 都被正确释放。
 func CleanupAllActive(
@@ -122,11 +139,7 @@ func CleanupAllActive(
 	terminateFn func(ctx context.Context, sessionID string) error,
 	logger *slog.Logger,
 ) {
-	sessions, err := repo.ListByStatus(ctx, []SessionStatus{
-		StatusInitializing,
-		StatusReady,
-		StatusRunning,
-	})
+	sessions, err := repo.ListByStatus(ctx, LiveStatuses())
 	if err != nil {
 		logger.Error("Failed to list active sessions for shutdown cleanup", "error", err)
 		return
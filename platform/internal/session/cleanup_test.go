@@ -0,0 +1,79 @@
+package session
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+)
+
+// statusRepo 按状态过滤的内存仓库
+type statusRepo struct {
+	SessionRepository
+	sessions []*Session
+}
+
+func (r *statusRepo) ListByStatus(_ context.Context, statuses []SessionStatus) ([]*Session, error) {
+	var out []*Session
+	for _, s := range r.sessions {
+		if slices.Contains(statuses, s.Status) {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func TestLiveStatusesAreNotTerminal(t *testing.T) {
+	for _, st := range LiveStatuses() {
+		if st.IsTerminal() {
+			t.Errorf("%s is terminal", st)
+		}
+	}
+	for _, st := range []SessionStatus{StatusPaused, StatusHibernated, StatusSuspended} {
+		if !slices.Contains(LiveStatuses(), st) {
+			t.Errorf("LiveStatuses() is missing %s", st)
+		}
+	}
+}
+
+func TestSessionCleanerExpiresLiveSessions(t *testing.T) {
+	old := time.Now().Add(-2 * time.Hour)
+	repo := &statusRepo{sessions: []*Session{
+		{ID: "ready", Status: StatusReady, CreatedAt: old},
+		{ID: "suspended", Status: StatusSuspended, CreatedAt: old},
+		{ID: "hibernated", Status: StatusHibernated, CreatedAt: old},
+		{ID: "young", Status: StatusSuspended, CreatedAt: time.Now()},
+		{ID: "terminated", Status: StatusTerminated, CreatedAt: old},
+	}}
+	var terminated []string
+	c := NewSessionCleaner(repo, func(_ context.Context, id string) error {
+		terminated = append(terminated, id)
+		return nil
+	}, CleanupConfig{MaxAge: time.Hour}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	c.cleanup()
+
+	if want := []string{"ready", "suspended", "hibernated"}; !slices.Equal(terminated, want) {
+		t.Fatalf("terminated = %v, want %v", terminated, want)
+	}
+}
+
+func TestCleanupAllActiveIncludesSuspended(t *testing.T) {
+	repo := &statusRepo{sessions: []*Session{
+		{ID: "running", Status: StatusRunning},
+		{ID: "suspended", Status: StatusSuspended},
+		{ID: "paused", Status: StatusPaused},
+		{ID: "error", Status: StatusError},
+	}}
+	var terminated []string
+	CleanupAllActive(context.Background(), repo, func(_ context.Context, id string) error {
+		terminated = append(terminated, id)
+		return nil
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if want := []string{"running", "suspended", "paused"}; !slices.Equal(terminated, want) {
+		t.Fatalf("terminated = %v, want %v", terminated, want)
+	}
+}
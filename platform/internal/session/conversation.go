@@ -0,0 +1,29 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// 对话记录中的消息角色
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	// RoleConfigure 最近一次 Configure 请求（JSON），重放时先用它恢复 Agent 配置
+	RoleConfigure = "configure"
+)
+
+// Message session 对话记录中的一条消息
+type Message struct {
+	SessionID string    `json:"-"`
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ConversationRepository 持久化 session 的对话记录，Agent 容器被替换后据此恢复上下文
+type ConversationRepository interface {
+	AppendMessage(ctx context.Context, msg *Message) error
+	// ListMessages 返回角色属于 roles 的最近 limit 条消息，按时间正序
+	ListMessages(ctx context.Context, sessionID string, roles []string, limit int) ([]Message, error)
+}
@@ -0,0 +1,79 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// GitSource 创建 session 时克隆到工作区的 Git 仓库
+type GitSource struct {
+	URL string `json:"url"`
+	// Ref 分支、标签或提交 SHA，为空时使用远端默认分支
+	Ref string `json:"ref,omitempty"`
+	// Username / Token 用于 HTTPS 认证，Username 为空时使用 x-access-token（GitHub / GitLab 的 token 约定）。
+	// Token 不随创建任务、outbox 和 session 记录序列化，经 GitTokenStore 交给 worker
+	Username string `json:"username,omitempty"`
+	Token    string `json:"-"`
+}
+
+// Validate 只允许 http(s) 地址，ref 不能以 - 开头以免被 git 当作选项
+func (g *GitSource) Validate() error {
+	u, err := url.Parse(g.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("invalid git_url %q: only http(s) URLs are supported", g.URL)
+	}
+	if u.User != nil {
+		return fmt.Errorf("invalid git_url: pass credentials in git_username / git_token instead of the URL")
+	}
+	if strings.HasPrefix(g.Ref, "-") || strings.ContainsAny(g.Ref, " \t\n\\:~^?*[") || strings.Contains(g.Ref, "..") {
+		return fmt.Errorf("invalid git ref %q", g.Ref)
+	}
+	return nil
+}
+
+const (
+	gitTokenKeyPrefix = "session:git_token:"
+	// gitTokenTTL 克隆凭据的保留时间，覆盖任务排队和重试；过期后重新分配容器时只能克隆公开仓库
+	gitTokenTTL = time.Hour
+)
+
+// GitTokenStore 在创建请求和 worker 之间短期存放克隆私有仓库的 token，按 session ID 存取。
+// 克隆完成后即删除，token 不会写入数据库、任务队列或 session 缓存
+type GitTokenStore struct {
+	redis redis.Cmdable
+}
+
+func NewGitTokenStore(redis redis.Cmdable) *GitTokenStore {
+	return &GitTokenStore{redis: redis}
+}
+
+// Put 保存 session 的克隆 token
+func (g *GitTokenStore) Put(ctx context.Context, sessionID, token string) error {
+	return g.redis.Set(ctx, gitTokenKeyPrefix+sessionID, token, gitTokenTTL).Err()
+}
+
+// Token 返回 session 的克隆 token，没有保存或已过期时返回空串
+func (g *GitTokenStore) Token(ctx context.Context, sessionID string) (string, error) {
+	if g == nil {
+		return "", nil
+	}
+	token, err := g.redis.Get(ctx, gitTokenKeyPrefix+sessionID).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return token, err
+}
+
+// Delete 删除 session 的克隆 token
+func (g *GitTokenStore) Delete(ctx context.Context, sessionID string) error {
+	if g == nil {
+		return nil
+	}
+	return g.redis.Del(ctx, gitTokenKeyPrefix+sessionID).Err()
+}
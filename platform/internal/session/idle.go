@@ -0,0 +1,156 @@
+package session
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultActivityInterval 同一 session 两次写入活动时间的最小间隔
+const DefaultActivityInterval = time.Minute
+
+// ActivityTracker 记录 session 最近一次活动的时间。活动很频繁（每次读文件都算），
+// 同一 session 在间隔内只写一次数据库，空闲判断的精度因此为该间隔
+type ActivityTracker struct {
+	repo     SessionRepository
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+func NewActivityTracker(repo SessionRepository, interval time.Duration, logger *slog.Logger) *ActivityTracker {
+	if interval <= 0 {
+		interval = DefaultActivityInterval
+	}
+	return &ActivityTracker{
+		repo:     repo,
+		interval: interval,
+		logger:   logger.With("component", "session-activity"),
+		last:     make(map[string]time.Time),
+	}
+}
+
+// Touch 把 session 的活动时间更新为当前时间，距上次写入不足间隔时跳过
+func (t *ActivityTracker) Touch(ctx context.Context, sessionID string) {
+	now := time.Now()
+	t.mu.Lock()
+	if last, ok := t.last[sessionID]; ok && now.Sub(last) < t.interval {
+		t.mu.Unlock()
+		return
+	}
+	// 顺带清理早已过期的记录，避免已结束的 session 一直留在内存中
+	for id, last := range t.last {
+		if now.Sub(last) >= t.interval {
+			delete(t.last, id)
+		}
+	}
+	t.last[sessionID] = now
+	t.mu.Unlock()
+
+	if err := t.repo.UpdateSessionActiveAt(ctx, sessionID, now); err != nil {
+		t.logger.Warn("Failed to record session activity", "session_id", sessionID, "error", err)
+	}
+}
+
+// Reset 立即写入活动时间，不受间隔限制；用于恢复 session 后重新开始计算空闲时间
+func (t *ActivityTracker) Reset(ctx context.Context, sessionID string) {
+	t.mu.Lock()
+	delete(t.last, sessionID)
+	t.mu.Unlock()
+	t.Touch(ctx, sessionID)
+}
+
+// IdleConfig 空闲 session 挂起配置
+type IdleConfig struct {
+	Interval time.Duration // 检查间隔
+	Timeout  time.Duration // 无活动超过此时长的 session 被挂起
+}
+
+// IdleReaper 定期挂起长时间无活动的就绪 session：停止容器但保留 session，
+// 之后可通过 POST /sessions/:id/resume 恢复。与 SessionCleaner 按创建时间终止 session 不同
+type IdleReaper struct {
+	repo      SessionRepository
+	suspendFn func(ctx context.Context, sessionID string) error
+	logger    *slog.Logger
+	config    IdleConfig
+	stopCh    chan struct{}
+}
+
+// NewIdleReaper 创建空闲 session 挂起器，suspendFn 通常传入 service.Service.SuspendSession
+func NewIdleReaper(
+	repo SessionRepository,
+	suspendFn func(ctx context.Context, sessionID string) error,
+	config IdleConfig,
+	logger *slog.Logger,
+) *IdleReaper {
+	if config.Interval <= 0 {
+		config.Interval = time.Minute
+	}
+	return &IdleReaper{
+		repo:      repo,
+		suspendFn: suspendFn,
+		logger:    logger.With("component", "idle-reaper"),
+		config:    config,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// Start 启动检查循环（阻塞，应在 goroutine 中调用）
+func (r *IdleReaper) Start() {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	r.logger.Info("Idle reaper started", "interval", r.config.Interval, "timeout", r.config.Timeout)
+
+	for {
+		select {
+		case <-r.stopCh:
+			r.logger.Info("Idle reaper stopped")
+			return
+		case <-ticker.C:
+			r.reap()
+		}
+	}
+}
+
+// Stop 停止检查循环
+func (r *IdleReaper) Stop() {
+	select {
+	case <-r.stopCh:
+	default:
+		close(r.stopCh)
+	}
+}
+
+func (r *IdleReaper) reap() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	sessions, err := r.repo.ListByStatus(ctx, []SessionStatus{StatusReady, StatusRunning})
+	if err != nil {
+		r.logger.Error("Failed to list sessions", "error", err)
+		return
+	}
+
+	cutoff := time.Now().Add(-r.config.Timeout)
+	suspended := 0
+	for _, sess := range sessions {
+		last := sess.LastActive()
+		if !last.Before(cutoff) {
+			continue
+		}
+		r.logger.Info("Suspending idle session", "session_id", sess.ID, "last_active", last, "idle", time.Since(last).Round(time.Second))
+		if err := r.suspendFn(ctx, sess.ID); err != nil {
+			r.logger.Warn("Failed to suspend idle session", "session_id", sess.ID, "error", err)
+			continue
+		}
+		suspended++
+	}
+
+	if suspended > 0 {
+		r.logger.Info("Idle sessions suspended", "count", suspended)
+	}
+}
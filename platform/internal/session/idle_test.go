@@ -0,0 +1,63 @@
+package session
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type fakeIdleRepo struct {
+	SessionRepository
+	sessions []*Session
+	touched  []string
+}
+
+func (r *fakeIdleRepo) ListByStatus(context.Context, []SessionStatus) ([]*Session, error) {
+	return r.sessions, nil
+}
+
+func (r *fakeIdleRepo) UpdateSessionActiveAt(_ context.Context, id string, _ time.Time) error {
+	r.touched = append(r.touched, id)
+	return nil
+}
+
+func TestIdleReaperSuspendsIdleSessions(t *testing.T) {
+	now := time.Now()
+	repo := &fakeIdleRepo{sessions: []*Session{
+		{ID: "idle", CreatedAt: now.Add(-2 * time.Hour), ActiveAt: now.Add(-time.Hour)},
+		{ID: "active", CreatedAt: now.Add(-2 * time.Hour), ActiveAt: now.Add(-time.Minute)},
+		{ID: "never-used", CreatedAt: now.Add(-time.Hour)},
+		{ID: "new", CreatedAt: now.Add(-time.Minute)},
+	}}
+	var suspended []string
+	r := NewIdleReaper(repo, func(_ context.Context, id string) error {
+		suspended = append(suspended, id)
+		return nil
+	}, IdleConfig{Timeout: 30 * time.Minute}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	r.reap()
+
+	if len(suspended) != 2 || suspended[0] != "idle" || suspended[1] != "never-used" {
+		t.Fatalf("suspended = %v, want [idle never-used]", suspended)
+	}
+}
+
+func TestActivityTrackerThrottlesWrites(t *testing.T) {
+	repo := &fakeIdleRepo{}
+	tracker := NewActivityTracker(repo, time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	tracker.Touch(ctx, "s1")
+	tracker.Touch(ctx, "s1")
+	tracker.Touch(ctx, "s2")
+	if len(repo.touched) != 2 {
+		t.Fatalf("writes = %v, want one per session", repo.touched)
+	}
+
+	tracker.Reset(ctx, "s1")
+	if len(repo.touched) != 3 || repo.touched[2] != "s1" {
+		t.Fatalf("writes after Reset = %v", repo.touched)
+	}
+}
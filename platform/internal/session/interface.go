@@ -1,12 +1,33 @@
 package session
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"platform/internal/orchestrator"
+)
+
+// ErrNotFound 由 SessionRepository.GetByID 在 session 不存在时返回
+var ErrNotFound = errors.New("session not found")
 
 type SessionRepository interface {
 	Create(ctx context.Context, session *Session) error
 	GetByID(ctx context.Context, id string) (*Session, error)
 	UpdateSessionStatus(ctx context.Context, id string, status SessionStatus) error
 	UpdateSessionContainerInfo(ctx context.Context, id string, containerID, nodeIP string) error
+	// UpdateSessionNode 记录容器所在的节点
+	UpdateSessionNode(ctx context.Context, id string, nodeID string) error
+	// UpdateSessionDebugBundle 记录失败 session 的调试包
+	UpdateSessionDebugBundle(ctx context.Context, id string, key string) error
+	// UpdateSessionEffectiveStrategy 记录创建时改用的策略
+	UpdateSessionEffectiveStrategy(ctx context.Context, id string, strategy orchestrator.StrategyType) error
+	// UpdateSessionActiveAt 记录 session 最近一次活动的时间
+	UpdateSessionActiveAt(ctx context.Context, id string, at time.Time) error
+	// UpdateSessionCheckpoint 记录休眠检查点的位置，name 为空表示清除
+	UpdateSessionCheckpoint(ctx context.Context, id string, name, dir string) error
+	// UpdateSessionSpec 更新重新分配容器时使用的创建参数
+	UpdateSessionSpec(ctx context.Context, id string, spec *SessionCreatePayload) error
 	ListByStatus(ctx context.Context, statuses []SessionStatus) ([]*Session, error)
 	ListByProject(ctx context.Context, projectID string) ([]*Session, error)
 }
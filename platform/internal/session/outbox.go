@@ -0,0 +1,173 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"platform/internal/monitor"
+
+	"github.com/hibiken/asynq"
+)
+
+// OutboxTask 与会话记录在同一事务中写入的待投递任务
+type OutboxTask struct {
+	ID           int64
+	TaskID       string // asynq 任务 ID，用于投递去重
+	TaskType     string
+	Payload      []byte
+	Attempts     int
+	LastError    string
+	CreatedAt    time.Time
+	DispatchedAt time.Time
+}
+
+// OutboxRepository 事务性 outbox 存储。
+// CreateWithTask 必须在同一个数据库事务中写入会话和任务，保证两者同时存在或同时不存在。
+type OutboxRepository interface {
+	CreateWithTask(ctx context.Context, session *Session, task *OutboxTask) error
+	ListPendingTasks(ctx context.Context, olderThan time.Time, limit int) ([]*OutboxTask, error)
+	MarkTaskDispatched(ctx context.Context, id int64) error
+	MarkTaskFailed(ctx context.Context, id int64, reason string) error
+	PurgeDispatchedTasks(ctx context.Context, before time.Time) (int, error)
+}
+
+// taskEnqueuer asynq.Client 中 relay 用到的部分，便于测试替换
+type taskEnqueuer interface {
+	EnqueueContext(ctx context.Context, task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error)
+}
+
+// enqueueOutboxTask 以 outbox 中记录的 TaskID 投递任务。
+// 同 ID 的任务已在队列中时视为投递成功，避免重复创建容器。
+func enqueueOutboxTask(ctx context.Context, client taskEnqueuer, t *OutboxTask) error {
+	_, err := client.EnqueueContext(ctx, asynq.NewTask(t.TaskType, t.Payload), asynq.TaskID(t.TaskID))
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return err
+	}
+	return nil
+}
+
+// OutboxConfig outbox relay 配置
+type OutboxConfig struct {
+	Interval time.Duration // 扫描间隔
+	// Grace 任务写入后多久仍未投递才由 relay 接管，避免与 CreateSession 的即时投递竞争
+	Grace     time.Duration
+	BatchSize int
+	// Retention 已投递记录的保留时长
+	Retention time.Duration
+}
+
+// OutboxRelay 定期把 outbox 中未投递的任务写入 asynq 队列。
+// CreateSession 在事务提交后会立即尝试投递，relay 负责兜底进程崩溃或 Redis 短暂不可用的情况。
+type OutboxRelay struct {
+	repo   OutboxRepository
+	client taskEnqueuer
+	config OutboxConfig
+	logger *slog.Logger
+	stopCh chan struct{}
+}
+
+func NewOutboxRelay(repo OutboxRepository, client *asynq.Client, config OutboxConfig, logger *slog.Logger) *OutboxRelay {
+	return newOutboxRelay(repo, client, config, logger)
+}
+
+func newOutboxRelay(repo OutboxRepository, client taskEnqueuer, config OutboxConfig, logger *slog.Logger) *OutboxRelay {
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.Retention <= 0 {
+		config.Retention = 24 * time.Hour
+	}
+	return &OutboxRelay{
+		repo:   repo,
+		client: client,
+		config: config,
+		logger: logger.With("component", "outbox-relay"),
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Start 启动投递循环（阻塞，应在 goroutine 中调用）
+func (r *OutboxRelay) Start() {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	r.logger.Info("Outbox relay started", "interval", r.config.Interval, "grace", r.config.Grace)
+
+	lastPurge := time.Now()
+	for {
+		select {
+		case <-r.stopCh:
+			r.logger.Info("Outbox relay stopped")
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			r.RelayOnce(ctx)
+			if time.Since(lastPurge) > time.Hour {
+				r.purge(ctx)
+				lastPurge = time.Now()
+			}
+			cancel()
+		}
+	}
+}
+
+// Stop 停止投递循环
+func (r *OutboxRelay) Stop() {
+	select {
+	case <-r.stopCh:
+	default:
+		close(r.stopCh)
+	}
+}
+
+// RelayOnce 投递一批未投递的任务，返回成功投递的数量
+func (r *OutboxRelay) RelayOnce(ctx context.Context) int {
+	tasks, err := r.repo.ListPendingTasks(ctx, time.Now().Add(-r.config.Grace), r.config.BatchSize)
+	if err != nil {
+		r.logger.Error("Failed to list pending outbox tasks", "error", err)
+		return 0
+	}
+
+	dispatched := 0
+	for _, t := range tasks {
+		if err := enqueueOutboxTask(ctx, r.client, t); err != nil {
+			r.logger.Warn("Failed to relay outbox task",
+				"outbox_id", t.ID,
+				"task_id", t.TaskID,
+				"attempts", t.Attempts+1,
+				"error", err,
+			)
+			monitor.OutboxRelayed.WithLabelValues("error").Inc()
+			if err := r.repo.MarkTaskFailed(ctx, t.ID, err.Error()); err != nil {
+				r.logger.Error("Failed to record outbox failure", "outbox_id", t.ID, "error", err)
+			}
+			continue
+		}
+
+		if err := r.repo.MarkTaskDispatched(ctx, t.ID); err != nil {
+			// 下一轮会重新投递，TaskID 冲突保证不会重复入队
+			r.logger.Error("Failed to mark outbox task dispatched", "outbox_id", t.ID, "error", err)
+			continue
+		}
+		r.logger.Info("Relayed outbox task", "task_id", t.TaskID, "type", t.TaskType)
+		monitor.OutboxRelayed.WithLabelValues("ok").Inc()
+		dispatched++
+	}
+	return dispatched
+}
+
+func (r *OutboxRelay) purge(ctx context.Context) {
+	n, err := r.repo.PurgeDispatchedTasks(ctx, time.Now().Add(-r.config.Retention))
+	if err != nil {
+		r.logger.Warn("Failed to purge dispatched outbox tasks", "error", err)
+		return
+	}
+	if n > 0 {
+		r.logger.Info("Purged dispatched outbox tasks", "count", n)
+	}
+}
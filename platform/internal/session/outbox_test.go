@@ -0,0 +1,112 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+type fakeOutbox struct {
+	OutboxRepository
+	tasks []*OutboxTask
+}
+
+func (f *fakeOutbox) ListPendingTasks(ctx context.Context, olderThan time.Time, limit int) ([]*OutboxTask, error) {
+	var out []*OutboxTask
+	for _, t := range f.tasks {
+		if t.DispatchedAt.IsZero() && !t.CreatedAt.After(olderThan) {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeOutbox) MarkTaskDispatched(ctx context.Context, id int64) error {
+	for _, t := range f.tasks {
+		if t.ID == id {
+			t.DispatchedAt = time.Now()
+			t.Attempts++
+		}
+	}
+	return nil
+}
+
+func (f *fakeOutbox) MarkTaskFailed(ctx context.Context, id int64, reason string) error {
+	for _, t := range f.tasks {
+		if t.ID == id {
+			t.Attempts++
+			t.LastError = reason
+		}
+	}
+	return nil
+}
+
+type fakeEnqueuer struct {
+	enqueued map[string]int
+	failing  map[string]error
+}
+
+func (f *fakeEnqueuer) EnqueueContext(ctx context.Context, task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	var id string
+	for _, o := range opts {
+		if o.Type() == asynq.TaskIDOpt {
+			id = o.Value().(string)
+		}
+	}
+	if err := f.failing[id]; err != nil {
+		return nil, err
+	}
+	f.enqueued[id]++
+	if f.enqueued[id] > 1 {
+		return nil, asynq.ErrTaskIDConflict
+	}
+	return &asynq.TaskInfo{ID: id}, nil
+}
+
+func TestOutboxRelay(t *testing.T) {
+	old := time.Now().Add(-time.Minute)
+	store := &fakeOutbox{tasks: []*OutboxTask{
+		{ID: 1, TaskID: "s-1", TaskType: SessionCreateTask, CreatedAt: old},
+		{ID: 2, TaskID: "s-2", TaskType: SessionCreateTask, CreatedAt: old},
+		{ID: 3, TaskID: "s-3", TaskType: SessionCreateTask, CreatedAt: time.Now()}, // 仍在宽限期内
+	}}
+	client := &fakeEnqueuer{
+		enqueued: map[string]int{"s-2": 1}, // CreateSession 已投递但未来得及标记
+		failing:  map[string]error{},
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	relay := newOutboxRelay(store, client, OutboxConfig{Grace: 10 * time.Second}, logger)
+
+	if n := relay.RelayOnce(context.Background()); n != 2 {
+		t.Fatalf("Expected 2 relayed tasks, got %d", n)
+	}
+	if store.tasks[0].DispatchedAt.IsZero() || store.tasks[1].DispatchedAt.IsZero() {
+		t.Error("Expected task conflicts to count as dispatched")
+	}
+	if !store.tasks[2].DispatchedAt.IsZero() {
+		t.Error("Task within grace period should not be relayed")
+	}
+
+	// 入队失败的任务保留在 outbox 中，下一轮重试
+	store.tasks[2].CreatedAt = old
+	client.failing["s-3"] = errors.New("redis unavailable")
+	if n := relay.RelayOnce(context.Background()); n != 0 {
+		t.Fatalf("Expected no relayed tasks, got %d", n)
+	}
+	if store.tasks[2].Attempts != 1 || store.tasks[2].LastError == "" {
+		t.Errorf("Expected failure to be recorded, got %+v", store.tasks[2])
+	}
+
+	delete(client.failing, "s-3")
+	if n := relay.RelayOnce(context.Background()); n != 1 {
+		t.Fatalf("Expected retried task to be relayed, got %d", n)
+	}
+	if client.enqueued["s-1"] != 1 || client.enqueued["s-3"] != 1 {
+		t.Errorf("Expected each task enqueued once, got %v", client.enqueued)
+	}
+}
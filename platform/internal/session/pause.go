@@ -0,0 +1,38 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// PauseWindow 一次暂停的时间段，ResumedAt 为零值表示仍处于暂停中
+type PauseWindow struct {
+	PausedAt  time.Time `json:"paused_at"`
+	ResumedAt time.Time `json:"resumed_at,omitzero"`
+}
+
+// Duration 暂停时长，仍在暂停中的按 now 计算
+func (w PauseWindow) Duration(now time.Time) time.Duration {
+	if w.ResumedAt.IsZero() {
+		return now.Sub(w.PausedAt)
+	}
+	return w.ResumedAt.Sub(w.PausedAt)
+}
+
+// PauseRepository 记录 session 的暂停时间段，用于用量统计时扣除暂停期间
+type PauseRepository interface {
+	// OpenPauseWindow 开始一个暂停时间段
+	OpenPauseWindow(ctx context.Context, sessionID string, at time.Time) error
+	// ClosePauseWindow 结束 session 当前未结束的暂停时间段，没有时为空操作
+	ClosePauseWindow(ctx context.Context, sessionID string, at time.Time) error
+	ListPauseWindows(ctx context.Context, sessionID string) ([]PauseWindow, error)
+}
+
+// TotalPaused 所有暂停时间段的总时长
+func TotalPaused(windows []PauseWindow, now time.Time) time.Duration {
+	var total time.Duration
+	for _, w := range windows {
+		total += w.Duration(now)
+	}
+	return total
+}
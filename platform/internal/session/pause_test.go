@@ -0,0 +1,23 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTotalPaused(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	windows := []PauseWindow{
+		{PausedAt: base, ResumedAt: base.Add(10 * time.Minute)},
+		// 仍在暂停中，按 now 计算
+		{PausedAt: base.Add(time.Hour)},
+	}
+
+	got := TotalPaused(windows, base.Add(time.Hour+5*time.Minute))
+	if want := 15 * time.Minute; got != want {
+		t.Fatalf("TotalPaused = %v, want %v", got, want)
+	}
+	if got := TotalPaused(nil, base); got != 0 {
+		t.Fatalf("TotalPaused(nil) = %v, want 0", got)
+	}
+}
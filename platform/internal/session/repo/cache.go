@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"platform/internal/monitor"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// cacheName 指标中 session 缓存的标签值
+const cacheName = "session"
+
+// cacheGet 读取 session 缓存，未命中或出错时返回 nil；结果计入 hit/miss/error 指标，
+// 运维可以据此判断 Redis 缓存是否真正减少了数据库读取
+func (r *Repository) cacheGet(ctx context.Context, id string) *cacheSession {
+	start := time.Now()
+	val, err := r.redis.Get(ctx, sessionCacheKey(id)).Result()
+	monitor.CacheLatency.WithLabelValues(cacheName, "get").Observe(time.Since(start).Seconds())
+
+	if errors.Is(err, redis.Nil) {
+		monitor.CacheLookups.WithLabelValues(cacheName, "miss").Inc()
+		return nil
+	}
+	if err != nil {
+		monitor.CacheLookups.WithLabelValues(cacheName, "error").Inc()
+		return nil
+	}
+
+	var cached cacheSession
+	if err := json.Unmarshal([]byte(val), &cached); err != nil {
+		monitor.CacheLookups.WithLabelValues(cacheName, "error").Inc()
+		return nil
+	}
+	monitor.CacheLookups.WithLabelValues(cacheName, "hit").Inc()
+	return &cached
+}
+
+func (r *Repository) cacheSet(ctx context.Context, model *SessionModel) {
+	b, err := json.Marshal(newCacheSession(model))
+	if err != nil {
+		monitor.CacheErrors.WithLabelValues(cacheName, "set").Inc()
+		return
+	}
+
+	start := time.Now()
+	err = r.redis.Set(ctx, sessionCacheKey(model.ID), b, sessionCacheTTL).Err()
+	monitor.CacheLatency.WithLabelValues(cacheName, "set").Observe(time.Since(start).Seconds())
+	if err != nil {
+		monitor.CacheErrors.WithLabelValues(cacheName, "set").Inc()
+	}
+}
+
+// cacheInvalidate 删除缓存失败会导致后续读到旧状态，单独计数便于告警
+func (r *Repository) cacheInvalidate(ctx context.Context, id string) {
+	start := time.Now()
+	err := r.redis.Del(ctx, sessionCacheKey(id)).Err()
+	monitor.CacheLatency.WithLabelValues(cacheName, "del").Observe(time.Since(start).Seconds())
+	if err != nil {
+		monitor.CacheErrors.WithLabelValues(cacheName, "del").Inc()
+	}
+}
@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+
+	"platform/internal/session"
+)
+
+var _ session.ConversationRepository = (*Repository)(nil)
+
+// MessageModel session 对话记录中的一条消息
+type MessageModel struct {
+	tableName struct{} `pg:"session_messages"`
+
+	ID        int64     `pg:"id,pk"`
+	SessionID string    `pg:"session_id,notnull"`
+	Role      string    `pg:"role,notnull"`
+	Content   string    `pg:"content"`
+	CreatedAt time.Time `pg:"created_at,notnull"`
+}
+
+func (r *Repository) AppendMessage(ctx context.Context, msg *session.Message) error {
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now()
+	}
+	_, err := r.db.ModelContext(ctx, &MessageModel{
+		SessionID: msg.SessionID,
+		Role:      msg.Role,
+		Content:   msg.Content,
+		CreatedAt: msg.CreatedAt,
+	}).Insert()
+	return err
+}
+
+func (r *Repository) ListMessages(ctx context.Context, sessionID string, roles []string, limit int) ([]session.Message, error) {
+	var models []MessageModel
+	if err := r.db.ModelContext(ctx, &models).
+		Where("session_id = ?", sessionID).
+		Where("role IN (?)", pg.In(roles)).
+		Order("id DESC").
+		Limit(limit).
+		Select(); err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(models)
+	msgs := make([]session.Message, len(models))
+	for i, m := range models {
+		msgs[i] = session.Message{SessionID: m.SessionID, Role: m.Role, Content: m.Content, CreatedAt: m.CreatedAt}
+	}
+	return msgs, nil
+}
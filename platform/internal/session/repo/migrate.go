@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"fmt"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// sessionColumnMigrations 补齐旧版本表中缺失的列。
+// CreateTable(IfNotExists) 不会修改已存在的表，新增列需要在这里显式 ALTER。
+var sessionColumnMigrations = []string{
+	`ALTER TABLE session_models ADD COLUMN IF NOT EXISTS terminated_at timestamptz`,
+	`ALTER TABLE session_models ADD COLUMN IF NOT EXISTS checkpoint text`,
+	`ALTER TABLE session_models ADD COLUMN IF NOT EXISTS checkpoint_dir text`,
+	`ALTER TABLE session_models ADD COLUMN IF NOT EXISTS node_id text`,
+	`ALTER TABLE session_models ADD COLUMN IF NOT EXISTS debug_bundle text`,
+	`ALTER TABLE session_models ADD COLUMN IF NOT EXISTS effective_strategy text`,
+	`ALTER TABLE session_models ADD COLUMN IF NOT EXISTS active_at timestamptz`,
+	`ALTER TABLE session_models ADD COLUMN IF NOT EXISTS spec jsonb`,
+	`ALTER TABLE session_models ADD COLUMN IF NOT EXISTS parent_session_id text`,
+	`CREATE INDEX IF NOT EXISTS task_outbox_pending_idx ON task_outbox (id) WHERE dispatched_at IS NULL`,
+	`CREATE INDEX IF NOT EXISTS session_pauses_session_idx ON session_pauses (session_id)`,
+	`CREATE INDEX IF NOT EXISTS session_messages_session_idx ON session_messages (session_id, role, id)`,
+}
+
+// Migrate 创建 session 表、任务 outbox 表、暂停记录表和对话记录表并执行增量列迁移
+func Migrate(db *pg.DB) error {
+	if err := db.Model(&SessionModel{}).CreateTable(&orm.CreateTableOptions{
+		IfNotExists: true,
+	}); err != nil {
+		return fmt.Errorf("create session table: %w", err)
+	}
+
+	if err := db.Model(&OutboxModel{}).CreateTable(&orm.CreateTableOptions{
+		IfNotExists: true,
+	}); err != nil {
+		return fmt.Errorf("create task outbox table: %w", err)
+	}
+
+	if err := db.Model(&PauseWindowModel{}).CreateTable(&orm.CreateTableOptions{
+		IfNotExists: true,
+	}); err != nil {
+		return fmt.Errorf("create session pauses table: %w", err)
+	}
+
+	if err := db.Model(&MessageModel{}).CreateTable(&orm.CreateTableOptions{
+		IfNotExists: true,
+	}); err != nil {
+		return fmt.Errorf("create session messages table: %w", err)
+	}
+
+	for _, stmt := range sessionColumnMigrations {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("migrate session table (%s): %w", stmt, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"platform/internal/session"
+
+	"github.com/go-pg/pg/v10"
+)
+
+var _ session.OutboxRepository = (*Repository)(nil)
+
+// OutboxModel 待投递的异步任务，与会话记录在同一事务中写入
+type OutboxModel struct {
+	tableName struct{} `pg:"task_outbox"`
+
+	ID           int64     `pg:"id,pk"`
+	TaskID       string    `pg:"task_id,notnull,unique"`
+	TaskType     string    `pg:"task_type,notnull"`
+	Payload      []byte    `pg:"payload"`
+	Attempts     int       `pg:"attempts,use_zero"`
+	LastError    string    `pg:"last_error"`
+	CreatedAt    time.Time `pg:"created_at,notnull"`
+	DispatchedAt time.Time `pg:"dispatched_at"`
+}
+
+func (m *OutboxModel) toTask() *session.OutboxTask {
+	return &session.OutboxTask{
+		ID:           m.ID,
+		TaskID:       m.TaskID,
+		TaskType:     m.TaskType,
+		Payload:      m.Payload,
+		Attempts:     m.Attempts,
+		LastError:    m.LastError,
+		CreatedAt:    m.CreatedAt,
+		DispatchedAt: m.DispatchedAt,
+	}
+}
+
+// CreateWithTask 在同一事务中写入会话和 outbox 任务
+func (r *Repository) CreateWithTask(ctx context.Context, s *session.Session, task *session.OutboxTask) error {
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = time.Now()
+	}
+
+	return r.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if _, err := tx.Model(newSessionModel(s)).Insert(); err != nil {
+			return err
+		}
+
+		m := &OutboxModel{
+			TaskID:    task.TaskID,
+			TaskType:  task.TaskType,
+			Payload:   task.Payload,
+			CreatedAt: task.CreatedAt,
+		}
+		if _, err := tx.Model(m).Returning("id").Insert(); err != nil {
+			return err
+		}
+		task.ID = m.ID
+		return nil
+	})
+}
+
+// ListPendingTasks 返回 olderThan 之前写入且尚未投递的任务，按写入顺序排列
+func (r *Repository) ListPendingTasks(ctx context.Context, olderThan time.Time, limit int) ([]*session.OutboxTask, error) {
+	var models []OutboxModel
+	err := r.db.ModelContext(ctx, &models).
+		Where("dispatched_at IS NULL").
+		Where("created_at <= ?", olderThan).
+		Order("id ASC").
+		Limit(limit).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*session.OutboxTask, 0, len(models))
+	for i := range models {
+		tasks = append(tasks, models[i].toTask())
+	}
+	return tasks, nil
+}
+
+func (r *Repository) MarkTaskDispatched(ctx context.Context, id int64) error {
+	_, err := r.db.ModelContext(ctx, &OutboxModel{}).
+		Set("dispatched_at = ?", time.Now()).
+		Set("attempts = attempts + 1").
+		Where("id = ?", id).
+		Update()
+	return err
+}
+
+func (r *Repository) MarkTaskFailed(ctx context.Context, id int64, reason string) error {
+	_, err := r.db.ModelContext(ctx, &OutboxModel{}).
+		Set("attempts = attempts + 1").
+		Set("last_error = ?", reason).
+		Where("id = ?", id).
+		Update()
+	return err
+}
+
+// PurgeDispatchedTasks 删除 before 之前已投递的记录
+func (r *Repository) PurgeDispatchedTasks(ctx context.Context, before time.Time) (int, error) {
+	res, err := r.db.ModelContext(ctx, &OutboxModel{}).
+		Where("dispatched_at IS NOT NULL").
+		Where("dispatched_at < ?", before).
+		Delete()
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
@@ -0,0 +1,50 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"platform/internal/session"
+)
+
+var _ session.PauseRepository = (*Repository)(nil)
+
+// PauseWindowModel session 的一次暂停
+type PauseWindowModel struct {
+	tableName struct{} `pg:"session_pauses"`
+
+	ID        int64     `pg:"id,pk"`
+	SessionID string    `pg:"session_id,notnull"`
+	PausedAt  time.Time `pg:"paused_at,notnull"`
+	ResumedAt time.Time `pg:"resumed_at"`
+}
+
+func (r *Repository) OpenPauseWindow(ctx context.Context, sessionID string, at time.Time) error {
+	_, err := r.db.ModelContext(ctx, &PauseWindowModel{SessionID: sessionID, PausedAt: at}).Insert()
+	return err
+}
+
+func (r *Repository) ClosePauseWindow(ctx context.Context, sessionID string, at time.Time) error {
+	_, err := r.db.ModelContext(ctx, &PauseWindowModel{}).
+		Set("resumed_at = ?", at).
+		Where("session_id = ?", sessionID).
+		Where("resumed_at IS NULL").
+		Update()
+	return err
+}
+
+func (r *Repository) ListPauseWindows(ctx context.Context, sessionID string) ([]session.PauseWindow, error) {
+	var models []PauseWindowModel
+	if err := r.db.ModelContext(ctx, &models).
+		Where("session_id = ?", sessionID).
+		Order("paused_at ASC").
+		Select(); err != nil {
+		return nil, err
+	}
+
+	windows := make([]session.PauseWindow, len(models))
+	for i, m := range models {
+		windows[i] = session.PauseWindow{PausedAt: m.PausedAt, ResumedAt: m.ResumedAt}
+	}
+	return windows, nil
+}
@@ -2,8 +2,10 @@ package repo
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"platform/internal/orchestrator"
 	"platform/internal/session"
+	"time"
 
 	"github.com/go-pg/pg/v10"
 	"github.com/redis/go-redis/v9"
@@ -13,7 +15,7 @@ var _ session.SessionRepository = (*Repository)(nil)
 
 type Repository struct {
 	db    *pg.DB
-	redis redis.Cmdable
+	redis redis.Cmdable // 为 nil 时不使用缓存，直接读数据库
 }
 
 func NewRepository(db *pg.DB, redis redis.Cmdable) *Repository {
@@ -24,16 +26,7 @@ func NewRepository(db *pg.DB, redis redis.Cmdable) *Repository {
 }
 
 func (r *Repository) Create(ctx context.Context, session *session.Session) error {
-	sessionModel := &SessionModel{
-		ID:            session.ID,
-		ProjectID:     session.ProjectID,
-		UserID:        session.UserID,
-		SessionStatus: session.Status,
-		Strategy:      session.Strategy,
-		CreatedAt:     session.CreatedAt,
-	}
-
-	_, err := r.db.Model(sessionModel).Insert()
+	_, err := r.db.Model(newSessionModel(session)).Insert()
 	if err != nil {
 		return err
 	}
@@ -43,94 +36,157 @@ func (r *Repository) Create(ctx context.Context, session *session.Session) error
 
 func (r *Repository) GetByID(ctx context.Context, id string) (*session.Session, error) {
 	if r.redis != nil {
-		key := sessionCacheKey(id)
-		val, err := r.redis.Get(ctx, key).Result()
-		if err == nil {
-			var cachedSession cacheSession
-			if err := json.Unmarshal([]byte(val), &cachedSession); err == nil {
-				sess := &session.Session{
-					ID:          cachedSession.ID,
-					ProjectID:   cachedSession.ProjectID,
-					UserID:      cachedSession.UserID,
-					ContainerID: cachedSession.ContainerID,
-					NodeIP:      cachedSession.NodeIP,
-					Status:      cachedSession.Status,
-					Strategy:    cachedSession.Strategy,
-					CreatedAt:   cachedSession.CreatedAt,
-				}
-
-				return sess, nil
-			}
+		if cached := r.cacheGet(ctx, id); cached != nil {
+			return cached.toSession(), nil
 		}
 	}
 
 	sessionModel := &SessionModel{ID: id}
 	err := r.db.Model(sessionModel).WherePK().Select()
 	if err != nil {
+		if errors.Is(err, pg.ErrNoRows) {
+			return nil, session.ErrNotFound
+		}
 		return nil, err
 	}
 
-	sess := &session.Session{
-		ID:          sessionModel.ID,
-		ProjectID:   sessionModel.ProjectID,
-		UserID:      sessionModel.UserID,
-		ContainerID: sessionModel.ContainerID,
-		NodeIP:      sessionModel.NodeIP,
-		Status:      sessionModel.SessionStatus,
-		Strategy:    sessionModel.Strategy,
-		CreatedAt:   sessionModel.CreatedAt,
+	if r.redis != nil {
+		r.cacheSet(ctx, sessionModel)
+	}
+
+	return sessionModel.toSession(), nil
+}
+
+func (r *Repository) UpdateSessionStatus(ctx context.Context, id string, status session.SessionStatus) error {
+	q := r.db.Model(&SessionModel{}).
+		Set("session_status = ?", status).
+		Where("id = ?", id)
+
+	// 记录进入终态的时间，供宿主机目录 GC 判断保留期
+	if status.IsTerminal() {
+		q = q.Set("terminated_at = ?", time.Now())
+	}
+
+	if _, err := q.Update(); err != nil {
+		return err
 	}
 
+	// Invalidate cache
 	if r.redis != nil {
-		key := sessionCacheKey(id)
-		cachedSession := &cacheSession{
-			ID:          sessionModel.ID,
-			ProjectID:   sessionModel.ProjectID,
-			UserID:      sessionModel.UserID,
-			ContainerID: sessionModel.ContainerID,
-			NodeIP:      sessionModel.NodeIP,
-			Status:      sessionModel.SessionStatus,
-			Strategy:    sessionModel.Strategy,
-			CreatedAt:   sessionModel.CreatedAt,
-		}
+		r.cacheInvalidate(ctx, id)
+	}
 
-		if b, err := json.Marshal(cachedSession); err == nil {
-			_ = r.redis.Set(ctx, key, b, sessionCacheTTL).Err()
-		}
+	return nil
+}
+
+func (r *Repository) UpdateSessionContainerInfo(ctx context.Context, id string, containerID, nodeIP string) error {
+	_, err := r.db.Model(&SessionModel{}).
+		Set("container_id = ?, node_ip = ?", containerID, nodeIP).
+		Where("id = ?", id).
+		Update()
+	if err != nil {
+		return err
+	}
+
+	// 缓存失效
+	if r.redis != nil {
+		r.cacheInvalidate(ctx, id)
 	}
 
-	return sess, nil
+	return nil
 }
 
-func (r *Repository) UpdateSessionStatus(ctx context.Context, id string, status session.SessionStatus) error {
+func (r *Repository) UpdateSessionNode(ctx context.Context, id string, nodeID string) error {
 	_, err := r.db.Model(&SessionModel{}).
-		Set("session_status = ?", status).
+		Set("node_id = ?", nodeID).
 		Where("id = ?", id).
 		Update()
 	if err != nil {
 		return err
 	}
 
-	// Invalidate cache
 	if r.redis != nil {
-		_ = r.redis.Del(ctx, sessionCacheKey(id)).Err()
+		r.cacheInvalidate(ctx, id)
 	}
 
 	return nil
 }
 
-func (r *Repository) UpdateSessionContainerInfo(ctx context.Context, id string, containerID, nodeIP string) error {
+func (r *Repository) UpdateSessionDebugBundle(ctx context.Context, id string, key string) error {
 	_, err := r.db.Model(&SessionModel{}).
-		Set("container_id = ?, node_ip = ?", containerID, nodeIP).
+		Set("debug_bundle = ?", key).
 		Where("id = ?", id).
 		Update()
 	if err != nil {
 		return err
 	}
 
-	// 缓存失效
 	if r.redis != nil {
-		_ = r.redis.Del(ctx, sessionCacheKey(id)).Err()
+		r.cacheInvalidate(ctx, id)
+	}
+
+	return nil
+}
+
+func (r *Repository) UpdateSessionEffectiveStrategy(ctx context.Context, id string, strategy orchestrator.StrategyType) error {
+	_, err := r.db.Model(&SessionModel{}).
+		Set("effective_strategy = ?", strategy).
+		Where("id = ?", id).
+		Update()
+	if err != nil {
+		return err
+	}
+
+	if r.redis != nil {
+		r.cacheInvalidate(ctx, id)
+	}
+
+	return nil
+}
+
+func (r *Repository) UpdateSessionActiveAt(ctx context.Context, id string, at time.Time) error {
+	_, err := r.db.Model(&SessionModel{}).
+		Set("active_at = ?", at).
+		Where("id = ?", id).
+		Update()
+	if err != nil {
+		return err
+	}
+
+	if r.redis != nil {
+		r.cacheInvalidate(ctx, id)
+	}
+
+	return nil
+}
+
+func (r *Repository) UpdateSessionCheckpoint(ctx context.Context, id string, name, dir string) error {
+	_, err := r.db.Model(&SessionModel{}).
+		Set("checkpoint = ?, checkpoint_dir = ?", name, dir).
+		Where("id = ?", id).
+		Update()
+	if err != nil {
+		return err
+	}
+
+	if r.redis != nil {
+		r.cacheInvalidate(ctx, id)
+	}
+
+	return nil
+}
+
+func (r *Repository) UpdateSessionSpec(ctx context.Context, id string, spec *session.SessionCreatePayload) error {
+	_, err := r.db.Model(&SessionModel{}).
+		Set("spec = ?", spec).
+		Where("id = ?", id).
+		Update()
+	if err != nil {
+		return err
+	}
+
+	if r.redis != nil {
+		r.cacheInvalidate(ctx, id)
 	}
 
 	return nil
@@ -146,20 +202,7 @@ func (r *Repository) ListByStatus(ctx context.Context, statuses []session.Sessio
 		return nil, err
 	}
 
-	sessions := make([]*session.Session, 0, len(models))
-	for _, m := range models {
-		sessions = append(sessions, &session.Session{
-			ID:          m.ID,
-			ProjectID:   m.ProjectID,
-			UserID:      m.UserID,
-			ContainerID: m.ContainerID,
-			NodeIP:      m.NodeIP,
-			Status:      m.SessionStatus,
-			Strategy:    m.Strategy,
-			CreatedAt:   m.CreatedAt,
-		})
-	}
-	return sessions, nil
+	return toSessions(models), nil
 }
 
 func (r *Repository) ListByProject(ctx context.Context, projectID string) ([]*session.Session, error) {
@@ -173,18 +216,13 @@ func (r *Repository) ListByProject(ctx context.Context, projectID string) ([]*se
 		return nil, err
 	}
 
+	return toSessions(models), nil
+}
+
+func toSessions(models []SessionModel) []*session.Session {
 	sessions := make([]*session.Session, 0, len(models))
-	for _, m := range models {
-		sessions = append(sessions, &session.Session{
-			ID:          m.ID,
-			ProjectID:   m.ProjectID,
-			UserID:      m.UserID,
-			ContainerID: m.ContainerID,
-			NodeIP:      m.NodeIP,
-			Status:      m.SessionStatus,
-			Strategy:    m.Strategy,
-			CreatedAt:   m.CreatedAt,
-		})
-	}
-	return sessions, nil
+	for i := range models {
+		sessions = append(sessions, models[i].toSession())
+	}
+	return sessions
 }
@@ -0,0 +1,54 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"platform/internal/session"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+var _ session.TransferRepository = (*Repository)(nil)
+
+func (r *Repository) TransferContainer(ctx context.Context, fromID, toID, containerID, nodeIP string) error {
+	err := r.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		res, err := tx.Model(&SessionModel{}).
+			Set("container_id = '', node_ip = ''").
+			Set("session_status = ?", session.StatusTerminated).
+			Set("terminated_at = ?", time.Now()).
+			Where("id = ?", fromID).
+			Where("container_id = ?", containerID).
+			Update()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() != 1 {
+			return fmt.Errorf("container %s is already released by session %s", containerID, fromID)
+		}
+
+		res, err = tx.Model(&SessionModel{}).
+			Set("container_id = ?, node_ip = ?", containerID, nodeIP).
+			Set("session_status = ?", session.StatusReady).
+			Where("id = ?", toID).
+			Where("session_status = ?", session.StatusInitializing).
+			Where("coalesce(container_id, '') = ''").
+			Update()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() != 1 {
+			return fmt.Errorf("session %s already has a container or is no longer initializing", toID)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if r.redis != nil {
+		r.cacheInvalidate(ctx, fromID)
+		r.cacheInvalidate(ctx, toID)
+	}
+	return nil
+}
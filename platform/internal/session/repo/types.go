@@ -13,21 +13,130 @@ type SessionModel struct {
 	ProjectID     string                    `json:"project_id" pg:"project_id,notnull"`
 	UserID        string                    `json:"user_id" pg:"user_id,notnull"`
 	NodeIP        string                    `json:"node_ip" pg:"node_ip"`
+	NodeID        string                    `json:"node_id" pg:"node_id"`
 	ContainerID   string                    `json:"container_id" pg:"container_id"`
 	SessionStatus session.SessionStatus     `json:"session_status" pg:"session_status,notnull"`
 	Strategy      orchestrator.StrategyType `json:"strategy" pg:"strategy"`
 	CreatedAt     time.Time                 `json:"created_at" pg:"created_at,notnull"`
+	TerminatedAt  time.Time                 `json:"terminated_at" pg:"terminated_at"`
+	Checkpoint    string                    `json:"checkpoint" pg:"checkpoint"`
+	CheckpointDir string                    `json:"checkpoint_dir" pg:"checkpoint_dir"`
+	DebugBundle   string                    `json:"debug_bundle" pg:"debug_bundle"`
+	// EffectiveStrategy 创建时改用另一种策略后实际使用的策略
+	EffectiveStrategy orchestrator.StrategyType `json:"effective_strategy" pg:"effective_strategy"`
+	ActiveAt          time.Time                 `json:"active_at" pg:"active_at"`
+	ParentSessionID   string                    `json:"parent_session_id" pg:"parent_session_id"`
+	// Spec 创建时的容器参数，重新分配容器时使用
+	Spec *session.SessionCreatePayload `json:"spec" pg:"spec,type:jsonb"`
+}
+
+func newSessionModel(s *session.Session) *SessionModel {
+	return &SessionModel{
+		ID:            s.ID,
+		ProjectID:     s.ProjectID,
+		UserID:        s.UserID,
+		SessionStatus: s.Status,
+		Strategy:      s.Strategy,
+		CreatedAt:     s.CreatedAt,
+		Spec:          s.Spec,
+
+		ParentSessionID: s.ParentSessionID,
+	}
+}
+
+func (m *SessionModel) toSession() *session.Session {
+	return &session.Session{
+		ID:           m.ID,
+		ProjectID:    m.ProjectID,
+		UserID:       m.UserID,
+		ContainerID:  m.ContainerID,
+		NodeIP:       m.NodeIP,
+		NodeID:       m.NodeID,
+		Status:       m.SessionStatus,
+		Strategy:     m.Strategy,
+		CreatedAt:    m.CreatedAt,
+		TerminatedAt: m.TerminatedAt,
+
+		Checkpoint:    m.Checkpoint,
+		CheckpointDir: m.CheckpointDir,
+		DebugBundle:   m.DebugBundle,
+
+		EffectiveStrategy: m.EffectiveStrategy,
+		ActiveAt:          m.ActiveAt,
+		ParentSessionID:   m.ParentSessionID,
+		Spec:              m.Spec,
+	}
 }
 
 type cacheSession struct {
-	ID          string                    `json:"id"`
-	ProjectID   string                    `json:"project_id"`
-	UserID      string                    `json:"user_id"`
-	NodeIP      string                    `json:"node_ip"`
-	ContainerID string                    `json:"container_id"`
-	Status      session.SessionStatus     `json:"status"`
-	Strategy    orchestrator.StrategyType `json:"strategy"`
-	CreatedAt   time.Time                 `json:"created_at"`
+	ID           string                    `json:"id"`
+	ProjectID    string                    `json:"project_id"`
+	UserID       string                    `json:"user_id"`
+	NodeIP       string                    `json:"node_ip"`
+	NodeID       string                    `json:"node_id,omitempty"`
+	ContainerID  string                    `json:"container_id"`
+	Status       session.SessionStatus     `json:"status"`
+	Strategy     orchestrator.StrategyType `json:"strategy"`
+	CreatedAt    time.Time                 `json:"created_at"`
+	TerminatedAt time.Time                 `json:"terminated_at"`
+
+	Checkpoint    string `json:"checkpoint,omitempty"`
+	CheckpointDir string `json:"checkpoint_dir,omitempty"`
+	DebugBundle   string `json:"debug_bundle,omitempty"`
+
+	EffectiveStrategy orchestrator.StrategyType `json:"effective_strategy,omitempty"`
+	ActiveAt          time.Time                 `json:"active_at,omitzero"`
+	ParentSessionID   string                    `json:"parent_session_id,omitempty"`
+
+	Spec *session.SessionCreatePayload `json:"spec,omitempty"`
+}
+
+func newCacheSession(m *SessionModel) *cacheSession {
+	return &cacheSession{
+		ID:           m.ID,
+		ProjectID:    m.ProjectID,
+		UserID:       m.UserID,
+		ContainerID:  m.ContainerID,
+		NodeIP:       m.NodeIP,
+		NodeID:       m.NodeID,
+		Status:       m.SessionStatus,
+		Strategy:     m.Strategy,
+		CreatedAt:    m.CreatedAt,
+		TerminatedAt: m.TerminatedAt,
+
+		Checkpoint:    m.Checkpoint,
+		CheckpointDir: m.CheckpointDir,
+		DebugBundle:   m.DebugBundle,
+
+		EffectiveStrategy: m.EffectiveStrategy,
+		ActiveAt:          m.ActiveAt,
+		ParentSessionID:   m.ParentSessionID,
+		Spec:              m.Spec,
+	}
+}
+
+func (c *cacheSession) toSession() *session.Session {
+	return &session.Session{
+		ID:           c.ID,
+		ProjectID:    c.ProjectID,
+		UserID:       c.UserID,
+		ContainerID:  c.ContainerID,
+		NodeIP:       c.NodeIP,
+		NodeID:       c.NodeID,
+		Status:       c.Status,
+		Strategy:     c.Strategy,
+		CreatedAt:    c.CreatedAt,
+		TerminatedAt: c.TerminatedAt,
+
+		Checkpoint:    c.Checkpoint,
+		CheckpointDir: c.CheckpointDir,
+		DebugBundle:   c.DebugBundle,
+
+		EffectiveStrategy: c.EffectiveStrategy,
+		ActiveAt:          c.ActiveAt,
+		ParentSessionID:   c.ParentSessionID,
+		Spec:              c.Spec,
+	}
 }
 
 func sessionCacheKey(sessionID string) string {
@@ -0,0 +1,31 @@
+package session
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+type fakeStatusRepo struct {
+	SessionRepository
+	statuses []SessionStatus
+}
+
+func (r *fakeStatusRepo) UpdateSessionStatus(_ context.Context, _ string, status SessionStatus) error {
+	r.statuses = append(r.statuses, status)
+	return nil
+}
+
+func TestReprovisionRequiresSpec(t *testing.T) {
+	repo := &fakeStatusRepo{}
+	mgr := NewSessionManager(nil, repo, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	// 旧版本创建的 session 没有记录创建参数，不能修改其状态
+	if err := mgr.Reprovision(context.Background(), &Session{ID: "a", Status: StatusReady}, "snap"); err == nil {
+		t.Fatal("expected reprovision without spec to fail")
+	}
+	if len(repo.statuses) != 0 {
+		t.Fatalf("statuses = %v, want untouched", repo.statuses)
+	}
+}
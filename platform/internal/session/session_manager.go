@@ -3,8 +3,13 @@ package session
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"platform/internal/orchestrator"
+	"platform/internal/quota"
+	"platform/internal/reqid"
+	"platform/internal/sandbox"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,20 +22,35 @@ type SessionManager struct {
 	repo        SessionRepository
 	cache       redis.Cmdable
 	queueClient *asynq.Client
+	outbox      OutboxRepository
 	logger      *slog.Logger
+
+	// Quota 用户和项目的并发 session 配额，nil 时不限制
+	Quota *quota.ConcurrencyLimiter
+	// GitTokens 克隆私有仓库的 token 的存放处，nil 时不接受带 token 的 Git 仓库
+	GitTokens *GitTokenStore
 }
 
 func NewSessionManager(pool orchestrator.IPool, repo SessionRepository, cache redis.Cmdable, queueClient *asynq.Client, logger *slog.Logger) *SessionManager {
-	return &SessionManager{
+	m := &SessionManager{
 		pool:        pool,
 		repo:        repo,
 		cache:       cache,
 		queueClient: queueClient,
 		logger:      logger,
 	}
+	// 存储支持 outbox 时，会话和创建任务在同一事务中写入
+	if outbox, ok := repo.(OutboxRepository); ok {
+		m.outbox = outbox
+	}
+	return m
 }
 
 func (s *SessionManager) CreateSession(ctx context.Context, params SessionParams) (*Session, error) {
+	if params.Strategy == orchestrator.WarmStrategyType && !s.pool.HasWarmPool(params.ContainerOpts.Image) {
+		return nil, fmt.Errorf("invalid image %q for %s: no warm pool is configured for it", params.ContainerOpts.Image, params.Strategy)
+	}
+
 	session := &Session{
 		ID:        uuid.New().String(),
 		ProjectID: params.ProjectID,
@@ -38,37 +58,203 @@ func (s *SessionManager) CreateSession(ctx context.Context, params SessionParams
 		Status:    StatusInitializing,
 		Strategy:  params.Strategy,
 		CreatedAt: time.Now(),
+
+		ParentSessionID: params.ParentSessionID,
+	}
+
+	if params.Git != nil && params.Git.Token != "" {
+		if s.GitTokens == nil {
+			return nil, fmt.Errorf("invalid request: git_token is not supported by this deployment")
+		}
+		// 先于任务写入，worker 取任务时 token 已就位；创建失败时随 TTL 过期
+		if err := s.GitTokens.Put(ctx, session.ID, params.Git.Token); err != nil {
+			return nil, fmt.Errorf("failed to store git token: %w", err)
+		}
 	}
 
-	if err := s.repo.Create(ctx, session); err != nil {
+	lease := s.Quota.Lease(session.ID, session.UserID, session.ProjectID)
+	if err := s.acquireQuota(ctx, lease); err != nil {
 		return nil, err
 	}
+	created := false
+	defer func() {
+		if !created {
+			s.releaseQuota(ctx, lease)
+		}
+	}()
 
-	payload, _ := json.Marshal(SessionCreatePayload{
+	spec := SessionCreatePayload{
 		SessionID: session.ID,
 		ProjectID: session.ProjectID,
 		UserID:    session.UserID,
 		Image:     params.ContainerOpts.Image,
 		Strategy:  session.Strategy,
 		EnvVars:   params.EnvVars,
-	})
+		RequestID: reqid.FromContext(ctx),
+
+		NetworkPolicy: params.ContainerOpts.NetworkPolicy,
+		GPUCount:      params.ContainerOpts.GPUCount,
+		GPUDeviceIDs:  params.ContainerOpts.GPUDeviceIDs,
+		Mounts:        params.ContainerOpts.Mounts,
+		SnapshotID:    params.SnapshotID,
+		Git:           params.Git,
+		Priority:      params.ContainerOpts.Priority,
+		Fallback:      params.Fallback != nil && *params.Fallback,
+	}
+	session.Spec = &spec
+	payload, _ := json.Marshal(spec)
 
-	task := asynq.NewTask(SessionCreateTask, payload)
+	if s.outbox == nil {
+		if err := s.repo.Create(ctx, session); err != nil {
+			return nil, err
+		}
+		info, err := s.queueClient.Enqueue(asynq.NewTask(SessionCreateTask, payload), asynq.TaskID(session.ID))
+		if err != nil {
+			return nil, err
+		}
+		created = true
+		s.logger.Info("Session created", slog.String("session_id", session.ID), slog.String("task_id", info.ID))
+		return session, nil
+	}
 
-	info, err := s.queueClient.Enqueue(task)
-	if err != nil {
-		// TODO：错误处理
+	task := &OutboxTask{
+		TaskID:   session.ID,
+		TaskType: SessionCreateTask,
+		Payload:  payload,
+	}
+	if err := s.outbox.CreateWithTask(ctx, session, task); err != nil {
 		return nil, err
 	}
+	created = true
 
-	s.logger.Info("Session created", slog.String("session_id", session.ID), slog.String("task_id", info.ID))
+	// 事务提交后立即投递；失败时任务留在 outbox 中，由 OutboxRelay 补投
+	if err := enqueueOutboxTask(ctx, s.queueClient, task); err != nil {
+		s.logger.Warn("Failed to enqueue session task, deferring to outbox relay",
+			"session_id", session.ID, "error", err)
+		_ = s.outbox.MarkTaskFailed(ctx, task.ID, err.Error())
+		return session, nil
+	}
+	if err := s.outbox.MarkTaskDispatched(ctx, task.ID); err != nil {
+		s.logger.Warn("Failed to mark outbox task dispatched", "session_id", session.ID, "error", err)
+	}
+
+	s.logger.Info("Session created", slog.String("session_id", session.ID), slog.String("task_id", task.TaskID))
 	return session, nil
 }
 
+// Reprovision 按创建时的参数为 session 重新分配容器：清空容器信息、标记为初始化中并重新投递创建任务，
+// 由 worker 完成取容器、初始化工作区和启动 Agent。snapshotID 非空时用该快照代替 Git 仓库初始化工作区。
+// 调用方负责先释放旧容器
+func (s *SessionManager) Reprovision(ctx context.Context, sess *Session, snapshotID string) error {
+	if sess.Spec == nil {
+		return fmt.Errorf("session %s has no recorded create spec, cannot provision a new container", sess.ID)
+	}
+	spec := *sess.Spec
+	spec.RequestID = reqid.FromContext(ctx)
+	if snapshotID != "" {
+		spec.SnapshotID = snapshotID
+		spec.Git = nil
+	}
+	payload, _ := json.Marshal(spec)
+
+	if err := s.repo.UpdateSessionContainerInfo(ctx, sess.ID, "", ""); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateSessionNode(ctx, sess.ID, ""); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateSessionEffectiveStrategy(ctx, sess.ID, ""); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateSessionStatus(ctx, sess.ID, StatusInitializing); err != nil {
+		return err
+	}
+
+	if _, err := s.queueClient.EnqueueContext(ctx, asynq.NewTask(SessionCreateTask, payload), asynq.TaskID(sess.ID)); err != nil {
+		// 没有任务会再处理这个 session，不能让它停在初始化中
+		if uerr := s.repo.UpdateSessionStatus(ctx, sess.ID, StatusError); uerr != nil {
+			s.logger.Warn("Failed to mark session error", "session_id", sess.ID, "error", uerr)
+		}
+		return fmt.Errorf("failed to enqueue session task: %w", err)
+	}
+
+	s.logger.Info("Session reprovisioning", slog.String("session_id", sess.ID), slog.String("snapshot_id", snapshotID))
+	return nil
+}
+
 func (s *SessionManager) GetSession(ctx context.Context, id string) (*Session, error) {
 	return s.repo.GetByID(ctx, id)
 }
 
 func (s *SessionManager) TerminateSession(ctx context.Context, id string) error {
+	if s.Quota != nil {
+		if sess, err := s.repo.GetByID(ctx, id); err == nil {
+			s.releaseQuota(ctx, s.Quota.Lease(sess.ID, sess.UserID, sess.ProjectID))
+		}
+	}
 	return s.repo.UpdateSessionStatus(ctx, id, StatusTerminated)
 }
+
+// acquireQuota 登记 session 的并发配额。超限时先清理已结束但未释放的占用
+// （worker 失败等路径直接把 session 标记为 error，不经过 TerminateSession），再重试一次
+func (s *SessionManager) acquireQuota(ctx context.Context, lease quota.Lease) error {
+	err := s.Quota.Acquire(ctx, lease)
+	if !errors.Is(err, quota.ErrTooManySessions) {
+		return err
+	}
+	holders, herr := s.Quota.Holders(ctx, lease)
+	if herr != nil {
+		return err
+	}
+	pruned := 0
+	for _, id := range holders {
+		sess, gerr := s.repo.GetByID(ctx, id)
+		if gerr != nil || !sess.Status.IsTerminal() {
+			continue
+		}
+		s.releaseQuota(ctx, s.Quota.Lease(sess.ID, sess.UserID, sess.ProjectID))
+		pruned++
+	}
+	if pruned == 0 {
+		return err
+	}
+	return s.Quota.Acquire(ctx, lease)
+}
+
+func (s *SessionManager) releaseQuota(ctx context.Context, lease quota.Lease) {
+	if err := s.Quota.Release(ctx, lease); err != nil {
+		s.logger.Warn("Failed to release session quota", "session_id", lease.SessionID, "error", err)
+	}
+}
+
+// ReleaseContainer 将预热 session 租用的容器归还预热池
+func (s *SessionManager) ReleaseContainer(ctx context.Context, sess *Session) error {
+	if sess.ContainerStrategy() != orchestrator.WarmStrategyType || sess.ContainerID == "" {
+		return fmt.Errorf("session %s has no warm container", sess.ID)
+	}
+	if s.pool == nil {
+		return fmt.Errorf("warm pool not initialized")
+	}
+	return s.pool.ReleaseContainer(ctx, sess.ContainerID)
+}
+
+// TransferContainer 将 from 租用的预热容器转给仍在初始化的 to：先更新容器归属，再原子地更新两条记录；
+// 记录更新失败时恢复容器归属
+func (s *SessionManager) TransferContainer(ctx context.Context, from, to *Session, c *sandbox.Container) error {
+	repo, ok := s.repo.(TransferRepository)
+	if !ok {
+		return fmt.Errorf("container transfer not supported by session store")
+	}
+
+	if err := s.pool.Transfer(ctx, c, to.ID); err != nil {
+		return err
+	}
+	if err := repo.TransferContainer(ctx, from.ID, to.ID, c.ID, c.IP); err != nil {
+		if rbErr := s.pool.Transfer(context.Background(), c, from.ID); rbErr != nil {
+			s.logger.Error("Failed to restore container owner after failed transfer",
+				"container_id", c.ID, "session_id", from.ID, "error", rbErr)
+		}
+		return err
+	}
+	return nil
+}
@@ -101,7 +101,7 @@ func (h *SessionTestHarness) setupConnections() {
 
 func (h *SessionTestHarness) initSchema(ctx context.Context) {
 	// Clean table before test
-	_, err := h.pgDB.ExecContext(ctx, "DROP TABLE IF EXISTS session_models")
+	_, err := h.pgDB.ExecContext(ctx, "DROP TABLE IF EXISTS session_models, task_outbox")
 	if err != nil {
 		h.t.Logf("Failed to drop table: %v", err)
 	}
@@ -112,6 +112,13 @@ func (h *SessionTestHarness) initSchema(ctx context.Context) {
 	if err != nil {
 		h.t.Fatalf("Failed to create session table: %v", err)
 	}
+
+	err = h.pgDB.Model(&repo.OutboxModel{}).CreateTable(&orm.CreateTableOptions{
+		IfNotExists: true,
+	})
+	if err != nil {
+		h.t.Fatalf("Failed to create outbox table: %v", err)
+	}
 }
 
 func (h *SessionTestHarness) ensureImage(ctx context.Context, imageName string) {
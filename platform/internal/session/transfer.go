@@ -0,0 +1,10 @@
+package session
+
+import "context"
+
+// TransferRepository 将容器从一个 session 的记录原子地转移到另一个 session
+type TransferRepository interface {
+	// TransferContainer 在同一事务中将 fromID 终止并清空容器信息、将 toID 标记为 ready 并记录容器。
+	// fromID 必须仍持有 containerID，toID 必须仍在初始化且没有容器，否则不做任何修改
+	TransferContainer(ctx context.Context, fromID, toID, containerID, nodeIP string) error
+}
@@ -0,0 +1,56 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"platform/internal/orchestrator"
+	"platform/internal/sandbox"
+)
+
+type fakeTransferPool struct {
+	orchestrator.IPool
+	owners []string
+}
+
+func (p *fakeTransferPool) Transfer(_ context.Context, c *sandbox.Container, sessionID string) error {
+	c.Config.SessionID = sessionID
+	p.owners = append(p.owners, sessionID)
+	return nil
+}
+
+type fakeTransferRepo struct {
+	SessionRepository
+	err error
+}
+
+func (r *fakeTransferRepo) TransferContainer(context.Context, string, string, string, string) error {
+	return r.err
+}
+
+func TestTransferContainerRestoresOwnerOnFailure(t *testing.T) {
+	pool := &fakeTransferPool{}
+	repo := &fakeTransferRepo{err: errors.New("session b already has a container or is no longer initializing")}
+	mgr := NewSessionManager(pool, repo, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	c := &sandbox.Container{ID: "c1", Config: sandbox.ContainerConfig{SessionID: "a"}}
+	err := mgr.TransferContainer(context.Background(), &Session{ID: "a"}, &Session{ID: "b"}, c)
+	if err == nil {
+		t.Fatal("expected transfer to fail")
+	}
+	if c.Config.SessionID != "a" || len(pool.owners) != 2 || pool.owners[0] != "b" {
+		t.Fatalf("owner = %s, transfers = %v, want restored to a", c.Config.SessionID, pool.owners)
+	}
+
+	repo.err = nil
+	pool.owners = nil
+	if err := mgr.TransferContainer(context.Background(), &Session{ID: "a"}, &Session{ID: "b"}, c); err != nil {
+		t.Fatalf("TransferContainer: %v", err)
+	}
+	if c.Config.SessionID != "b" || len(pool.owners) != 1 {
+		t.Fatalf("owner = %s, transfers = %v", c.Config.SessionID, pool.owners)
+	}
+}
@@ -2,6 +2,7 @@ package session
 
 import (
 	"platform/internal/orchestrator"
+	"platform/internal/sandbox"
 	"time"
 )
 
@@ -13,18 +14,73 @@ const (
 	StatusRunning      SessionStatus = "running"
 	StatusTerminated   SessionStatus = "terminated"
 	StatusError        SessionStatus = "error"
+	// StatusHibernated 容器已通过检查点冻结并停止，可以恢复
+	StatusHibernated SessionStatus = "hibernated"
+	// StatusPaused 容器进程已冻结但仍占用内存，可以立即恢复
+	StatusPaused SessionStatus = "paused"
+	// StatusSuspended 长时间无活动后容器已停止，恢复时重新启动容器并重放对话
+	StatusSuspended SessionStatus = "suspended"
 )
 
+// IsTerminal 返回会话是否已进入终态（不会再被调度）
+func (s SessionStatus) IsTerminal() bool {
+	return s == StatusTerminated || s == StatusError
+}
+
+// LiveStatuses 返回所有非终态。处于这些状态的 session 仍可能持有容器、端口和目录，
+// 按状态查询需要清理或保护的 session 时使用
+func LiveStatuses() []SessionStatus {
+	return []SessionStatus{
+		StatusInitializing,
+		StatusReady,
+		StatusRunning,
+		StatusPaused,
+		StatusHibernated,
+		StatusSuspended,
+	}
+}
+
 type Session struct {
 	ID          string                    `json:"id"`
 	ProjectID   string                    `json:"project_id"`
 	UserID      string                    `json:"user_id"`
-	ContainerID string                    `json:"container_id"` // 挂载容器 ID
-	NodeIP      string                    `json:"node_ip"`      // gRPC 通信
+	ContainerID string                    `json:"container_id"`      // 挂载容器 ID
+	NodeIP      string                    `json:"node_ip"`           // gRPC 通信
+	NodeID      string                    `json:"node_id,omitempty"` // 容器所在的 Docker 节点，为空表示本机
 	Status      SessionStatus             `json:"status"`
 	Strategy    orchestrator.StrategyType `json:"strategy"`
 	CreatedAt   time.Time                 `json:"created_at"`
-	ActiveAt    time.Time                 `json:"active_at"`
+	// ActiveAt 最近一次对话、终端或文件操作的时间，用于判断 session 是否空闲
+	ActiveAt time.Time `json:"active_at"`
+	// 进入终态（terminated / error）的时间
+	TerminatedAt time.Time `json:"terminated_at"`
+	// Checkpoint / CheckpointDir 休眠时保存的检查点名称和目录（为空表示 Docker 默认目录）
+	Checkpoint    string `json:"checkpoint,omitempty"`
+	CheckpointDir string `json:"checkpoint_dir,omitempty"`
+	// DebugBundle 进入 error 状态时收集的调试包在存储中的 key
+	DebugBundle string `json:"debug_bundle,omitempty"`
+	// EffectiveStrategy 创建时改用另一种策略后实际使用的策略，未改用时为空
+	EffectiveStrategy orchestrator.StrategyType `json:"effective_strategy,omitempty"`
+	// ParentSessionID 由 fork 创建时的源 session
+	ParentSessionID string `json:"parent_session_id,omitempty"`
+	// Spec 创建时的容器参数，重启时重新分配容器复用；旧版本创建的 session 为 nil
+	Spec *SessionCreatePayload `json:"-"`
+}
+
+// ContainerStrategy 容器实际使用的策略，决定容器归还预热池还是直接删除
+func (s *Session) ContainerStrategy() orchestrator.StrategyType {
+	if s.EffectiveStrategy != "" {
+		return s.EffectiveStrategy
+	}
+	return s.Strategy
+}
+
+// LastActive 返回 session 最近一次活动的时间，从未有过活动时为创建时间
+func (s *Session) LastActive() time.Time {
+	if s.ActiveAt.After(s.CreatedAt) {
+		return s.ActiveAt
+	}
+	return s.CreatedAt
 }
 
 type SessionParams struct {
@@ -33,6 +89,14 @@ type SessionParams struct {
 	Strategy      orchestrator.StrategyType
 	EnvVars       []string
 	ContainerOpts orchestrator.ContainerOptions
+	// SnapshotID 非空时容器启动后用该工作区快照初始化工作区
+	SnapshotID string
+	// Git 非空时容器启动后将仓库浅克隆到工作区，代替预先放在宿主机上的项目目录
+	Git *GitSource
+	// Fallback 首选策略取不到容器时是否改用另一种策略，nil 时使用平台配置
+	Fallback *bool
+	// ParentSessionID fork 的源 session
+	ParentSessionID string
 }
 
 const SessionCreateTask = "session:create"
@@ -44,4 +108,17 @@ type SessionCreatePayload struct {
 	Image     string                    `json:"image"`
 	Strategy  orchestrator.StrategyType `json:"strategy"`
 	EnvVars   []string                  `json:"env_vars"`
+	// RequestID 创建会话的 API 请求 ID，worker 处理任务时恢复到 context 中
+	RequestID string `json:"request_id,omitempty"`
+	// NetworkPolicy 沙箱的网络隔离策略，零值表示不限制
+	NetworkPolicy sandbox.NetworkPolicy `json:"network_policy,omitzero"`
+	GPUCount      int                   `json:"gpu_count,omitempty"`
+	GPUDeviceIDs  []string              `json:"gpu_device_ids,omitempty"`
+	Mounts        []sandbox.MountSpec   `json:"mounts,omitempty"`
+	SnapshotID    string                `json:"snapshot_id,omitempty"`
+	Git           *GitSource            `json:"git,omitempty"`
+	// Priority 预热池名额用尽时的排队优先级
+	Priority orchestrator.Priority `json:"priority,omitempty"`
+	// Fallback 预热池取不到容器时改用冷启动，冷启动镜像不可用时改用默认预热池
+	Fallback bool `json:"fallback,omitempty"`
 }
@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	"platform/internal/orchestrator"
+	"platform/internal/sandbox"
+	"platform/internal/session"
+)
+
+// acquisition 任务中已取得的容器，任务被取消时由 HandleSessionCreate 归还
+type acquisition struct {
+	container *sandbox.Container
+	strategy  orchestrator.ContainerStrategy
+}
+
+type acquisitionKey struct{}
+
+// recordAcquired 记录任务取得的容器
+func recordAcquired(ctx context.Context, c *sandbox.Container, strategy orchestrator.ContainerStrategy) {
+	if held, ok := ctx.Value(acquisitionKey{}).(*acquisition); ok {
+		held.container, held.strategy = c, strategy
+	}
+}
+
+// HandleSessionCreate 处理会话创建任务。session 在创建期间被取消（DELETE 初始化中的 session）时，
+// 任务可能因 ctx 被取消而失败，也可能已经把 session 标记为 ready：这两种情况都归还已取得的容器、
+// 把 session 改回 terminated，并且不再重试
+func (w *SessionTaskWorker) HandleSessionCreate(ctx context.Context, task *asynq.Task) error {
+	held := &acquisition{}
+	err := w.handleSessionCreate(context.WithValue(ctx, acquisitionKey{}, held), task)
+
+	var payload session.SessionCreatePayload
+	if w.Cancels == nil || json.Unmarshal(task.Payload(), &payload) != nil {
+		return err
+	}
+	cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+	if !w.Cancels.Cancelled(cleanupCtx, payload.SessionID) {
+		return err
+	}
+
+	w.logger.Info("Session creation cancelled, releasing resources", "session_id", payload.SessionID, "task_error", err)
+	if held.container != nil {
+		held.strategy.Release(cleanupCtx, w.pool, held.container)
+	}
+	if w.Quota != nil {
+		if err := w.Quota.Release(cleanupCtx, w.Quota.Lease(payload.SessionID, payload.UserID, payload.ProjectID)); err != nil {
+			w.logger.Warn("Failed to release session quota", "session_id", payload.SessionID, "error", err)
+		}
+	}
+	if err := w.repo.UpdateSessionStatus(cleanupCtx, payload.SessionID, session.StatusTerminated); err != nil {
+		w.logger.Error("Failed to mark cancelled session terminated", "session_id", payload.SessionID, "error", err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"platform/internal/session"
+	"platform/internal/taskstatus"
+)
+
+// debugBundleTimeout 收集调试包的超时，容器无响应时不长时间占用 worker
+const debugBundleTimeout = 30 * time.Second
+
+// collectDebugBundle 任务结束时 session 处于 error 状态且尚未收集过调试包时收集一份并记录到 session。
+// 任务 ctx 可能已超时，收集使用独立的超时
+func (w *SessionTaskWorker) collectDebugBundle(ctx context.Context, sessionID string, progress *taskstatus.Task) {
+	if w.Debug == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), debugBundleTimeout)
+	defer cancel()
+
+	sess, err := w.repo.GetByID(ctx, sessionID)
+	if err != nil || sess.Status != session.StatusError || sess.DebugBundle != "" {
+		return
+	}
+	key, err := w.Debug.Collect(ctx, sess, progress.Timings())
+	if err != nil {
+		w.logger.Warn("Failed to collect debug bundle", "session_id", sessionID, "error", err)
+		return
+	}
+	if err := w.repo.UpdateSessionDebugBundle(ctx, sessionID, key); err != nil {
+		w.logger.Warn("Failed to record debug bundle", "session_id", sessionID, "key", key, "error", err)
+	}
+}
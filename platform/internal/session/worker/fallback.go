@@ -0,0 +1,99 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"platform/internal/orchestrator"
+	"platform/internal/quota"
+	"platform/internal/sandbox"
+	"platform/internal/session"
+)
+
+// defaultFallbackWait 允许改用冷启动时从预热池等待容器的默认时长
+const defaultFallbackWait = 10 * time.Second
+
+// acquireContainer 按首选策略取容器，payload.Fallback 为 true 时在失败后改用另一种策略：
+// 预热池取不到容器（名额用尽、等待超时等）时冷启动同一镜像，冷启动拉取镜像失败时改从预热池取。
+// 改用成功后记录到 session 的 effective_strategy，返回实际使用的策略；改用也失败时返回首选策略的错误
+func (w *SessionTaskWorker) acquireContainer(ctx context.Context, payload *session.SessionCreatePayload, strategy orchestrator.ContainerStrategy, opts orchestrator.ContainerOptions) (*sandbox.Container, orchestrator.ContainerStrategy, error) {
+	primaryCtx := ctx
+	if payload.Fallback && strategy.Name() == orchestrator.WarmStrategyType {
+		wait := w.config.FallbackWait
+		if wait <= 0 {
+			wait = defaultFallbackWait
+		}
+		var cancel context.CancelFunc
+		primaryCtx, cancel = context.WithTimeout(ctx, wait)
+		defer cancel()
+	}
+
+	container, err := strategy.Get(primaryCtx, w.pool, opts)
+	if err == nil || !payload.Fallback || ctx.Err() != nil {
+		return container, strategy, err
+	}
+	next, ok := fallbackFor(payload, strategy.Name(), err)
+	if !ok {
+		return nil, strategy, err
+	}
+
+	var alt orchestrator.ContainerStrategy
+	altOpts := opts
+	switch next {
+	case orchestrator.ColdStrategyType:
+		// 冷启动预热池对应的镜像，而不是把预热池名称当作镜像
+		image, ok := w.pool.WarmPoolImage(opts.Image)
+		if !ok {
+			return nil, strategy, err
+		}
+		altOpts.Image = image
+		alt = &orchestrator.ColdStrategy{}
+	case orchestrator.WarmStrategyType:
+		if !w.pool.HasWarmPool(opts.Image) {
+			if !w.pool.HasWarmPool("") {
+				return nil, strategy, err
+			}
+			altOpts.Image = ""
+		}
+		alt = &orchestrator.WarmStrategy{}
+	}
+
+	w.logger.Warn("Failed to acquire container, falling back to another strategy",
+		"session_id", payload.SessionID,
+		"strategy", strategy.Name(),
+		"fallback", alt.Name(),
+		"image", altOpts.Image,
+		"error", err)
+	container, altErr := alt.Get(ctx, w.pool, altOpts)
+	if altErr != nil {
+		w.logger.Warn("Fallback strategy failed", "session_id", payload.SessionID, "fallback", alt.Name(), "error", altErr)
+		return nil, strategy, err
+	}
+	if err := w.repo.UpdateSessionEffectiveStrategy(ctx, payload.SessionID, alt.Name()); err != nil {
+		alt.Release(context.WithoutCancel(ctx), w.pool, container)
+		return nil, strategy, err
+	}
+	return container, alt, nil
+}
+
+// fallbackFor 判断首选策略失败后能否改用另一种策略。配额超限、预热池关闭和无效请求不改用；
+// 冷启动只在镜像不可用时改用预热池，且请求不能带有预热容器无法满足的 GPU、挂载或网络限制
+func fallbackFor(payload *session.SessionCreatePayload, primary orchestrator.StrategyType, err error) (orchestrator.StrategyType, bool) {
+	if errors.Is(err, quota.ErrTooManySessions) || errors.Is(err, orchestrator.ErrPoolDraining) {
+		return "", false
+	}
+	switch primary {
+	case orchestrator.WarmStrategyType:
+		return orchestrator.ColdStrategyType, true
+	case orchestrator.ColdStrategyType:
+		if !errors.Is(err, sandbox.ErrImagePullFailed) {
+			return "", false
+		}
+		if payload.GPUCount != 0 || len(payload.GPUDeviceIDs) > 0 || len(payload.Mounts) > 0 || payload.NetworkPolicy.Restricted() {
+			return "", false
+		}
+		return orchestrator.WarmStrategyType, true
+	}
+	return "", false
+}
@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"platform/internal/orchestrator"
+	"platform/internal/quota"
+	"platform/internal/sandbox"
+	"platform/internal/session"
+)
+
+func TestFallbackFor(t *testing.T) {
+	plain := &session.SessionCreatePayload{}
+	gpu := &session.SessionCreatePayload{GPUCount: 1}
+	restricted := &session.SessionCreatePayload{NetworkPolicy: sandbox.NetworkPolicy{Mode: sandbox.NetworkNone}}
+	pullErr := fmt.Errorf("%w: manifest unknown", sandbox.ErrImagePullFailed)
+
+	tests := []struct {
+		name    string
+		payload *session.SessionCreatePayload
+		primary orchestrator.StrategyType
+		err     error
+		want    orchestrator.StrategyType
+		ok      bool
+	}{
+		{"warm timeout", plain, orchestrator.WarmStrategyType, context.DeadlineExceeded, orchestrator.ColdStrategyType, true},
+		{"warm quota", plain, orchestrator.WarmStrategyType, quota.ErrTooManySessions, "", false},
+		{"warm draining", plain, orchestrator.WarmStrategyType, orchestrator.ErrPoolDraining, "", false},
+		{"cold pull failed", plain, orchestrator.ColdStrategyType, pullErr, orchestrator.WarmStrategyType, true},
+		{"cold start failed", plain, orchestrator.ColdStrategyType, sandbox.ErrContainerStartFailed, "", false},
+		{"cold gpu", gpu, orchestrator.ColdStrategyType, pullErr, "", false},
+		{"cold restricted network", restricted, orchestrator.ColdStrategyType, pullErr, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := fallbackFor(tt.payload, tt.primary, tt.err)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("fallbackFor() = %q, %v; want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"platform/internal/sandbox"
+	"platform/internal/session"
+)
+
+// gitCloneTimeout 克隆仓库的最长时间
+const gitCloneTimeout = 5 * time.Minute
+
+// gitCloneScript 在沙箱内浅克隆仓库到临时目录，再复制进工作区，工作区中的同名文件被覆盖。
+// 用 init + fetch --depth 1 代替 clone --branch，ref 可以是分支、标签或提交 SHA。
+// 参数：$1 仓库地址，$2 ref，$3 工作区
+const gitCloneScript = `set -e
+command -v git > /dev/null || { echo "git is not installed in the sandbox image" >&2; exit 127; }
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+cd "$tmp"
+git init --quiet
+git remote add origin "$1"
+git fetch --quiet --depth 1 origin "$2"
+git checkout --quiet FETCH_HEAD
+cp -a "$tmp"/. "$3"/`
+
+// seedFromGit 在沙箱容器内将 payload.Git 克隆到工作区。克隆受 session 的网络策略约束，
+// 平台主机不会替用户访问仓库地址。token 从 GitTokens 取出，克隆成功后删除
+func (w *SessionTaskWorker) seedFromGit(ctx context.Context, c *sandbox.Container, payload *session.SessionCreatePayload) error {
+	src := *payload.Git
+	token, err := w.GitTokens.Token(ctx, payload.SessionID)
+	if err != nil {
+		return fmt.Errorf("failed to load git token: %w", err)
+	}
+	src.Token = token
+
+	ctx, cancel := context.WithTimeout(ctx, gitCloneTimeout)
+	defer cancel()
+	result, err := c.Exec(ctx, gitCloneCmd(&src, c.MountPath), gitEnv(&src), "/")
+	if err != nil {
+		return fmt.Errorf("git clone failed: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("git clone failed (exit code %d): %s", result.ExitCode, redact(strings.TrimSpace(result.Stderr), src.Token))
+	}
+
+	if err := w.GitTokens.Delete(ctx, payload.SessionID); err != nil {
+		w.logger.Warn("Failed to delete git token", "session_id", payload.SessionID, "error", err)
+	}
+	return nil
+}
+
+func gitCloneCmd(src *session.GitSource, workspace string) []string {
+	ref := src.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	return []string{"sh", "-c", gitCloneScript, "sh", src.URL, ref, workspace}
+}
+
+// gitEnv 凭据通过 GIT_CONFIG_* 环境变量以 http.extraHeader 传入，不出现在命令行参数和 .git/config 中
+func gitEnv(src *session.GitSource) []string {
+	env := []string{"GIT_TERMINAL_PROMPT=0"}
+	if src.Token != "" {
+		user := src.Username
+		if user == "" {
+			user = "x-access-token"
+		}
+		auth := base64.StdEncoding.EncodeToString([]byte(user + ":" + src.Token))
+		env = append(env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+auth,
+		)
+	}
+	return env
+}
+
+func redact(s, secret string) string {
+	if secret == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, secret, "***")
+}
@@ -1,15 +1,26 @@
 package worker
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"path/filepath"
+	"platform/internal/debugbundle"
 	"platform/internal/eventbus"
+	"platform/internal/featureflag"
+	"platform/internal/filesync"
 	"platform/internal/orchestrator"
+	"platform/internal/quota"
+	"platform/internal/reqid"
 	"platform/internal/sandbox"
 	"platform/internal/session"
+	"platform/internal/snapshot"
+	"platform/internal/storage"
+	"platform/internal/taskstatus"
 	"time"
 
 	"github.com/hibiken/asynq"
@@ -18,9 +29,10 @@ import (
 var _ SessionWorker = (*SessionTaskWorker)(nil)
 
 type WorkerConfig struct {
-	ProjectDir      string // 项目存储根目录，如 "/.../agent-platform/projects"
-	PlatformAPIURL  string // 容器内 Agent 回调 Platform 的地址
-	ContainerLogDir string // 容器日志存放目录
+	ProjectDir      string        // 项目存储根目录，如 "/.../agent-platform/projects"
+	PlatformAPIURL  string        // 容器内 Agent 回调 Platform 的地址
+	ContainerLogDir string        // 容器日志存放目录
+	FallbackWait    time.Duration // 允许改用冷启动时从预热池等待容器的最长时间，0 时使用 defaultFallbackWait
 }
 
 type SessionTaskWorker struct {
@@ -29,6 +41,23 @@ type SessionTaskWorker struct {
 	bus    eventbus.EventBus
 	config WorkerConfig
 	logger *slog.Logger
+
+	// Tracker 上报任务阶段和心跳，为 nil 时不上报
+	Tracker *taskstatus.Tracker
+	// Snapshots 用于按 payload.SnapshotID 初始化工作区，为 nil 时带快照的任务失败
+	Snapshots *snapshot.Store
+	// Projects 项目文件的对象存储，为 nil 时只从本地 ProjectDir 打包
+	Projects *storage.ProjectStore
+	// Flags 功能开关，nil 时使用内置默认值
+	Flags *featureflag.Flags
+	// Quota 并发 session 配额，不为 nil 时从预热池取容器前再次检查 session 的占用
+	Quota *quota.ConcurrencyLimiter
+	// Debug 任务结束时 session 处于 error 状态则收集调试包，为 nil 时不收集
+	Debug *debugbundle.Collector
+	// Cancels 创建期间被取消的 session，为 nil 时任务不检查取消
+	Cancels *session.CancelStore
+	// GitTokens 克隆私有仓库的 token，为 nil 时按公开仓库克隆
+	GitTokens *session.GitTokenStore
 }
 
 func NewSessionTaskWorker(pool orchestrator.IPool, repo session.SessionRepository, bus eventbus.EventBus, config WorkerConfig, logger *slog.Logger) *SessionTaskWorker {
@@ -41,7 +70,7 @@ func NewSessionTaskWorker(pool orchestrator.IPool, repo session.SessionRepositor
 	}
 }
 
-func (w *SessionTaskWorker) HandleSessionCreate(ctx context.Context, task *asynq.Task) error {
+func (w *SessionTaskWorker) handleSessionCreate(ctx context.Context, task *asynq.Task) error {
 	w.logger.Info("Processing session create task")
 
 	var payload session.SessionCreatePayload
@@ -49,13 +78,30 @@ func (w *SessionTaskWorker) HandleSessionCreate(ctx context.Context, task *asynq
 		w.logger.Error("Failed to unmarshal payload", "error", err)
 		return fmt.Errorf("json unmarshal error: %w", err)
 	}
+	// 恢复创建请求的 ID，后续事件和 exec 日志都能关联到原始 API 请求
+	ctx = reqid.WithContext(ctx, payload.RequestID)
 
 	w.logger.Info("Deserialized payload",
 		"session_id", payload.SessionID,
+		"request_id", payload.RequestID,
 		"project_id", payload.ProjectID,
 		"strategy", payload.Strategy,
 		"image", payload.Image)
 
+	// outbox relay 可能重复投递同一会话的任务，只处理仍在初始化中的会话
+	if sess, err := w.repo.GetByID(ctx, payload.SessionID); err == nil && sess.Status != session.StatusInitializing {
+		w.logger.Info("Session already past initializing, skipping task",
+			"session_id", payload.SessionID, "status", sess.Status)
+		return nil
+	}
+
+	taskID, _ := asynq.GetTaskID(ctx)
+	retry, _ := asynq.GetRetryCount(ctx)
+	progress := w.Tracker.Begin(payload.SessionID, taskID, task.Type(), retry)
+	defer progress.Finish()
+	// 先于 Finish 执行，阶段耗时包含失败时所处的阶段
+	defer w.collectDebugBundle(ctx, payload.SessionID, progress)
+
 	// 自动将 PLATFORM_API_URL 注入环境变量
 	// 方便容器内 Agent 回调 Platform API（如创建服务、文件同步等）。
 	// 如果用户已在 EnvVars 中设置，则不覆盖。
@@ -86,16 +132,40 @@ func (w *SessionTaskWorker) HandleSessionCreate(ctx context.Context, task *asynq
 	containerOptions := orchestrator.ContainerOptions{
 		ProjectID: payload.ProjectID,
 		SessionID: payload.SessionID,
+		Tenant:    payload.UserID,
 		EnvVars:   payload.EnvVars,
 		Image:     payload.Image,
+
+		NetworkPolicy: payload.NetworkPolicy,
+		GPUCount:      payload.GPUCount,
+		GPUDeviceIDs:  payload.GPUDeviceIDs,
+		Mounts:        payload.Mounts,
+		Priority:      payload.Priority,
+
+		OnPullProgress: w.publishPullProgress(ctx, payload.SessionID),
 	}
 
 	w.logger.Info("Acquiring container", "strategy", strategy.Name())
-	container, err := strategy.Get(ctx, w.pool, containerOptions)
+	progress.SetPhase(taskstatus.PhaseAcquiring)
+	acquireCtx := ctx
+	if w.Quota != nil {
+		acquireCtx = quota.WithLease(ctx, w.Quota.Lease(payload.SessionID, payload.UserID, payload.ProjectID))
+	}
+	container, strategy, err := w.acquireContainer(acquireCtx, &payload, strategy, containerOptions)
 	if err != nil {
+		// 暂时性错误（Docker 不可用、超时等）保持 Session 初始化中，交给 asynq 重试
+		if maxRetry, ok := asynq.GetMaxRetry(ctx); ok && retry < maxRetry && sandbox.IsRetryable(err) {
+			w.logger.Warn("Failed to acquire container, will retry",
+				"session_id", payload.SessionID,
+				"strategy", strategy.Name(),
+				"retry", retry,
+				"error", err)
+			return err
+		}
 		w.logger.Error("Failed to acquire container",
 			"session_id", payload.SessionID,
 			"strategy", strategy.Name(),
+			"category", sandbox.CategoryOf(err),
 			"error", err)
 		// 标记 Session Error
 		w.repo.UpdateSessionStatus(ctx, payload.SessionID, session.StatusError)
@@ -104,9 +174,12 @@ func (w *SessionTaskWorker) HandleSessionCreate(ctx context.Context, task *asynq
 			Payload: err.Error(),
 		})
 
-		return err
+		// Session 已标记 Error，重试会被跳过，直接结束任务
+		return fmt.Errorf("%w: %w", err, asynq.SkipRetry)
 	}
 
+	recordAcquired(ctx, container, strategy)
+
 	w.logger.Info("Container acquired",
 		"session_id", payload.SessionID,
 		"container_id", container.ID,
@@ -118,12 +191,20 @@ func (w *SessionTaskWorker) HandleSessionCreate(ctx context.Context, task *asynq
 		w.repo.UpdateSessionStatus(ctx, payload.SessionID, session.StatusError)
 		return err
 	}
+	if container.NodeID != "" {
+		if err := w.repo.UpdateSessionNode(ctx, payload.SessionID, container.NodeID); err != nil {
+			w.logger.Error("Failed to update container node", "session_id", payload.SessionID, "node_id", container.NodeID, "error", err)
+			w.repo.UpdateSessionStatus(ctx, payload.SessionID, session.StatusError)
+			return err
+		}
+	}
 
 	// 对于 Cold Strategy，等待容器内自启动的 gRPC 服务器就绪 
 	if _, ok := strategy.(*orchestrator.ColdStrategy); ok {
 		w.logger.Info("Waiting for cold container agent server to become ready",
 			"session_id", payload.SessionID, "container_id", container.ID)
-		if err := waitForAgentServer(ctx, container, 30*time.Second); err != nil {
+		progress.SetPhase(taskstatus.PhaseWaitingAgent)
+		if err := waitForAgentServer(w.execOutputContext(ctx, &payload, taskstatus.PhaseWaitingAgent), container, 30*time.Second); err != nil {
 			w.logger.Error("Cold container agent server not ready",
 				"session_id", payload.SessionID, "error", err)
 			w.repo.UpdateSessionStatus(ctx, payload.SessionID, session.StatusError)
@@ -134,19 +215,37 @@ func (w *SessionTaskWorker) HandleSessionCreate(ctx context.Context, task *asynq
 			return err
 		}
 		w.logger.Info("Cold container agent server is ready", "session_id", payload.SessionID)
+
+		// 冷容器的工作区来自宿主机目录或匿名卷，指定了 Git 仓库时在这里克隆进去
+		if payload.Git != nil {
+			progress.SetPhase(taskstatus.PhaseSyncingFiles)
+			if err := w.seedFromGit(w.execOutputContext(ctx, &payload, taskstatus.PhaseSyncingFiles), container, &payload); err != nil {
+				w.logger.Error("Failed to seed workspace from git", "error", err, "session_id", payload.SessionID)
+				w.repo.UpdateSessionStatus(ctx, payload.SessionID, session.StatusError)
+				w.bus.Publish(ctx, payload.SessionID, eventbus.Event{
+					Type:      eventbus.EventSessionError,
+					SessionID: payload.SessionID,
+					Payload:   fmt.Sprintf("failed to seed workspace from git: %v", err),
+					Timestamp: time.Now(),
+				})
+				return err
+			}
+		}
 	}
 
 	// 对于 Warm Strategy，先同步文件、启动 Agent，再标记 Ready
 	if _, ok := strategy.(*orchestrator.WarmStrategy); ok {
 		projectRoot := filepath.Join(w.config.ProjectDir, payload.ProjectID)
 		w.logger.Info("Syncing project files", "project_root", projectRoot, "session_id", payload.SessionID)
+		progress.SetPhase(taskstatus.PhaseSyncingFiles)
+		syncCtx := w.execOutputContext(ctx, &payload, taskstatus.PhaseSyncingFiles)
 
-		// 项目目录可能尚不存在，创建空目录以避免 TarContext 失败
+		// 项目目录可能尚不存在，创建空目录以避免扫描失败
 		if err := ensureDir(projectRoot); err != nil {
 			w.logger.Warn("Failed to ensure project dir", "path", projectRoot, "error", err)
 		}
 
-		tarReader, err := TarContext(projectRoot)
+		tarReader, err := w.projectArchive(ctx, &payload, projectRoot)
 		if err != nil {
 			w.logger.Error("Failed to tar project", "error", err, "session_id", payload.SessionID)
 			w.repo.UpdateSessionStatus(ctx, payload.SessionID, session.StatusError)
@@ -157,7 +256,20 @@ func (w *SessionTaskWorker) HandleSessionCreate(ctx context.Context, task *asynq
 			return err
 		}
 
-		if err := container.UploadArchive(ctx, "/", tarReader); err != nil {
+		if payload.Git != nil {
+			err = w.seedFromGit(syncCtx, container, &payload)
+		} else if tarReader == nil {
+			// 项目文件在本地目录：只传输容器中缺失或内容不同的文件，并以流的方式打包
+			var result *filesync.Result
+			result, err = filesync.Sync(syncCtx, container, projectRoot, filesync.Options{Dest: container.MountPath})
+			if err == nil {
+				w.logger.Info("Project files synced", "session_id", payload.SessionID,
+					"uploaded", result.Uploaded, "unchanged", result.Unchanged, "bytes", result.Bytes)
+			}
+		} else {
+			err = container.UploadArchive(syncCtx, "/", tarReader)
+		}
+		if err != nil {
 			w.logger.Error("Failed to sync project", "error", err, "session_id", payload.SessionID)
 			w.repo.UpdateSessionStatus(ctx, payload.SessionID, session.StatusError)
 			w.bus.Publish(ctx, payload.SessionID, eventbus.Event{
@@ -168,7 +280,7 @@ func (w *SessionTaskWorker) HandleSessionCreate(ctx context.Context, task *asynq
 		}
 
 		envReader := GenerateEnvFile(payload.EnvVars)
-		if err := container.CopyToContainer(ctx, ".env", envReader); err != nil {
+		if err := container.CopyToContainer(syncCtx, ".env", envReader); err != nil {
 			w.logger.Error("Failed to write .env", "error", err, "session_id", payload.SessionID)
 			w.repo.UpdateSessionStatus(ctx, payload.SessionID, session.StatusError)
 			w.bus.Publish(ctx, payload.SessionID, eventbus.Event{
@@ -180,7 +292,8 @@ func (w *SessionTaskWorker) HandleSessionCreate(ctx context.Context, task *asynq
 
 		// 在 Warm Container 中启动 gRPC 服务器
 		w.logger.Info("Starting agent server", "session_id", payload.SessionID, "container_id", container.ID)
-		if err := startAgentServer(ctx, container); err != nil {
+		progress.SetPhase(taskstatus.PhaseStartingAgent)
+		if err := startAgentServer(w.execOutputContext(ctx, &payload, taskstatus.PhaseStartingAgent), container); err != nil {
 			w.logger.Error("Failed to start agent server", "error", err, "session_id", payload.SessionID)
 			w.repo.UpdateSessionStatus(ctx, payload.SessionID, session.StatusError)
 			w.bus.Publish(ctx, payload.SessionID, eventbus.Event{
@@ -192,11 +305,25 @@ func (w *SessionTaskWorker) HandleSessionCreate(ctx context.Context, task *asynq
 		w.logger.Info("Agent server started successfully", "session_id", payload.SessionID)
 	}
 
+	if payload.SnapshotID != "" {
+		if err := w.seedFromSnapshot(w.execOutputContext(ctx, &payload, taskstatus.PhaseSyncingFiles), container, payload.SnapshotID); err != nil {
+			w.logger.Error("Failed to seed workspace from snapshot", "error", err, "session_id", payload.SessionID)
+			w.repo.UpdateSessionStatus(ctx, payload.SessionID, session.StatusError)
+			w.bus.Publish(ctx, payload.SessionID, eventbus.Event{
+				Type:    eventbus.EventSessionError,
+				Payload: fmt.Sprintf("failed to seed workspace from snapshot: %v", err),
+			})
+			return err
+		}
+	}
+
 	// Agent gRPC 服务器已就绪，标记 Session 为 Ready
+	progress.SetPhase(taskstatus.PhaseFinalizing)
 	if err := w.repo.UpdateSessionStatus(ctx, payload.SessionID, session.StatusReady); err != nil {
 		w.logger.Error("Failed to update session status to ready", "session_id", payload.SessionID, "error", err)
 		return err
 	}
+	progress.Succeed()
 
 	w.bus.Publish(ctx, payload.SessionID, eventbus.Event{
 		Type: eventbus.EventSessionReady,
@@ -211,18 +338,64 @@ func (w *SessionTaskWorker) HandleSessionCreate(ctx context.Context, task *asynq
 	return nil
 }
 
+// projectArchive 配置了对象存储时优先使用其中的项目归档。返回 nil 表示项目文件只在本地目录中，由调用方增量同步；
+// 指定了 Git 仓库时工作区由 seedFromGit 在容器内克隆，同样返回 nil
+func (w *SessionTaskWorker) projectArchive(ctx context.Context, payload *session.SessionCreatePayload, projectRoot string) (io.Reader, error) {
+	if payload.Git != nil {
+		return nil, nil
+	}
+	if w.Projects != nil {
+		archive, err := w.Projects.Load(ctx, payload.ProjectID)
+		if err == nil {
+			defer archive.Close()
+			var buf bytes.Buffer
+			if _, err := io.Copy(&buf, archive); err != nil {
+				return nil, fmt.Errorf("failed to download project archive: %w", err)
+			}
+			return &buf, nil
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			return nil, fmt.Errorf("failed to load project archive: %w", err)
+		}
+	}
+	return nil, nil
+}
+
+// seedFromSnapshot 将工作区快照解压到容器工作区，快照条目的路径相对于工作区
+func (w *SessionTaskWorker) seedFromSnapshot(ctx context.Context, c *sandbox.Container, snapshotID string) error {
+	if w.Snapshots == nil {
+		return fmt.Errorf("snapshot store not configured")
+	}
+	archive, err := w.Snapshots.Open(ctx, snapshotID)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	return c.UploadArchive(ctx, "/", archive)
+}
+
+var agentProbeCmd = []string{
+	"python3", "-c",
+	"import socket; s=socket.socket(); s.settimeout(1); s.connect(('127.0.0.1',50051)); s.close()",
+}
+
+func agentServerListening(ctx context.Context, c *sandbox.Container) bool {
+	// 探测失败的报错没有意义，不转发到 exec 输出
+	result, err := c.Exec(sandbox.WithExecOutput(ctx, nil), agentProbeCmd, nil, "/")
+	return err == nil && result.ExitCode == 0
+}
+
 func waitForAgentServer(ctx context.Context, c *sandbox.Container, timeout time.Duration) error {
 	waitCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	probeCmd := []string{
-		"python3", "-c",
-		"import socket; s=socket.socket(); s.settimeout(1); s.connect(('127.0.0.1',50051)); s.close()",
-	}
-
+	var logOffset int
 	for {
-		result, err := c.Exec(waitCtx, probeCmd, nil, "/")
-		if err == nil && result.ExitCode == 0 {
+		if sandbox.ExecOutputFrom(waitCtx) != nil {
+			logOffset = followAgentLog(waitCtx, c, logOffset)
+		}
+		if agentServerListening(waitCtx, c) {
 			return nil
 		}
 
@@ -252,9 +425,24 @@ func waitForAgentServer(ctx context.Context, c *sandbox.Container, timeout time.
 	}
 }
 
+// followAgentLog 读取 agent 日志中 offset 之后新增的内容，输出经 ctx 上的回调转发，返回新的偏移量
+func followAgentLog(ctx context.Context, c *sandbox.Container, offset int) int {
+	cmd := []string{"sh", "-c", `tail -c +"$1" /tmp/agent.log 2>/dev/null`, "sh", fmt.Sprint(offset + 1)}
+	result, err := c.Exec(ctx, cmd, nil, "/")
+	if err != nil {
+		return offset
+	}
+	return offset + len(result.Stdout)
+}
+
 func startAgentServer(ctx context.Context, c *sandbox.Container) error {
+	// 预热容器通过自己的 ENTRYPOINT / Cmd 启动了 agent 服务器时不再重复启动
+	if agentServerListening(ctx, c) {
+		return nil
+	}
+
 	// 在后台启动 agent 服务器。
-	// warm 容器的主进程是 "tail -f /dev/null"，用于保持容器存活。
+	// warm 容器默认的主进程是 "tail -f /dev/null"，用于保持容器存活。
 	startCmd := []string{
 		"sh", "-c",
 		"PYTHONPATH=/app nohup python -m src.main > /tmp/agent.log 2>&1 &",
@@ -265,3 +453,35 @@ func startAgentServer(ctx context.Context, c *sandbox.Container) error {
 
 	return waitForAgentServer(ctx, c, 30*time.Second)
 }
+
+// execOutputContext 开关 platform_exec_output 对 session 开启时，ctx 上执行的平台 exec 输出
+// 以 platform.exec_output 事件发布到 session 的事件通道，step 标明所处的创建阶段
+func (w *SessionTaskWorker) execOutputContext(ctx context.Context, payload *session.SessionCreatePayload, step taskstatus.Phase) context.Context {
+	if !w.Flags.Enabled(featureflag.PlatformExecOutput, featureflag.Scope{Tenant: payload.UserID, Project: payload.ProjectID}) {
+		return ctx
+	}
+	return sandbox.WithExecOutput(ctx, func(stream string, data []byte) {
+		if err := w.bus.Publish(ctx, payload.SessionID, eventbus.Event{
+			Type:      eventbus.EventPlatformExecOutput,
+			SessionID: payload.SessionID,
+			Payload:   eventbus.ExecOutput{Step: string(step), Stream: stream, Data: string(data)},
+			Timestamp: time.Now(),
+		}); err != nil {
+			w.logger.Warn("Failed to publish exec output", "session_id", payload.SessionID, "error", err)
+		}
+	})
+}
+
+// publishPullProgress 将镜像拉取进度发布到 session 的事件通道，避免大镜像冷启动期间客户端看不到任何反馈
+func (w *SessionTaskWorker) publishPullProgress(ctx context.Context, sessionID string) func(sandbox.PullProgress) {
+	return func(p sandbox.PullProgress) {
+		if err := w.bus.Publish(ctx, sessionID, eventbus.Event{
+			Type:      eventbus.EventImagePullProgress,
+			SessionID: sessionID,
+			Payload:   p,
+			Timestamp: time.Now(),
+		}); err != nil {
+			w.logger.Warn("Failed to publish pull progress", "session_id", sessionID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package slo
+
+import "context"
+
+type Store interface {
+	// Add 把一个事件计入 objective 在 minute（Unix 分钟数）的计数，bucket 为耗时所在的 LatencyBuckets 下标
+	Add(ctx context.Context, objective string, minute int64, failed, slow bool, bucket int) error
+	// Load 返回 objective 在 [from, to] 各分钟计数之和
+	Load(ctx context.Context, objective string, from, to int64) (Counts, error)
+}
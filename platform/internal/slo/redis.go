@@ -0,0 +1,89 @@
+package slo
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix 每个目标每分钟的计数存放在一个 hash 中：slo:<objective>:<minute>
+const keyPrefix = "slo:"
+
+// 计数 hash 的 field，耗时桶的 field 为 "l<下标>"
+const (
+	fieldTotal  = "total"
+	fieldFailed = "failed"
+	fieldSlow   = "slow"
+)
+
+var _ Store = (*RedisStore)(nil)
+
+// RedisStore 多个实例共享的计数，按分钟分桶，超过 retention 的桶由 Redis 过期删除
+type RedisStore struct {
+	redis     redis.Cmdable
+	retention time.Duration
+}
+
+func NewRedisStore(redis redis.Cmdable, retention time.Duration) *RedisStore {
+	return &RedisStore{redis: redis, retention: retention + time.Minute}
+}
+
+func minuteKey(objective string, minute int64) string {
+	return keyPrefix + objective + ":" + strconv.FormatInt(minute, 10)
+}
+
+func (s *RedisStore) Add(ctx context.Context, objective string, minute int64, failed, slow bool, bucket int) error {
+	key := minuteKey(objective, minute)
+	pipe := s.redis.TxPipeline()
+	pipe.HIncrBy(ctx, key, fieldTotal, 1)
+	if failed {
+		pipe.HIncrBy(ctx, key, fieldFailed, 1)
+	} else {
+		if slow {
+			pipe.HIncrBy(ctx, key, fieldSlow, 1)
+		}
+		pipe.HIncrBy(ctx, key, "l"+strconv.Itoa(bucket), 1)
+	}
+	pipe.Expire(ctx, key, s.retention)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisStore) Load(ctx context.Context, objective string, from, to int64) (Counts, error) {
+	pipe := s.redis.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, 0, to-from+1)
+	for m := from; m <= to; m++ {
+		cmds = append(cmds, pipe.HGetAll(ctx, minuteKey(objective, m)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return Counts{}, err
+	}
+
+	c := Counts{Latency: make([]int64, len(LatencyBuckets)+1)}
+	for _, cmd := range cmds {
+		for field, v := range cmd.Val() {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				continue
+			}
+			switch field {
+			case fieldTotal:
+				c.Total += n
+			case fieldFailed:
+				c.Failed += n
+			case fieldSlow:
+				c.Slow += n
+			default:
+				if len(field) < 2 || field[0] != 'l' {
+					continue
+				}
+				if i, err := strconv.Atoi(field[1:]); err == nil && i >= 0 && i < len(c.Latency) {
+					c.Latency[i] += n
+				}
+			}
+		}
+	}
+	return c, nil
+}
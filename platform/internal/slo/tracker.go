@@ -0,0 +1,228 @@
+package slo
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"platform/internal/monitor"
+)
+
+// DefaultWindows 未配置时统计的时间窗口
+var DefaultWindows = []time.Duration{5 * time.Minute, time.Hour, 6 * time.Hour}
+
+// DefaultTarget 目标不在 (0, 1) 内时使用的成功率目标
+const DefaultTarget = 0.99
+
+type Config struct {
+	Objectives []Objective
+	// Windows 计算成功率和 burn rate 的时间窗口，精度为分钟
+	Windows []time.Duration
+	// Interval 刷新 burn rate 指标的间隔
+	Interval time.Duration
+}
+
+// Tracker 记录各目标的事件结果并按窗口汇总，计数保存在 Store 中，多个实例的事件汇总在一起
+type Tracker struct {
+	store  Store
+	config Config
+	logger *slog.Logger
+	stopCh chan struct{}
+
+	now func() time.Time
+}
+
+func NewTracker(store Store, config Config, logger *slog.Logger) *Tracker {
+	if len(config.Windows) == 0 {
+		config.Windows = DefaultWindows
+	}
+	config.Windows = slices.Clone(config.Windows)
+	slices.Sort(config.Windows)
+	if config.Interval <= 0 {
+		config.Interval = 30 * time.Second
+	}
+	logger = logger.With("component", "slo")
+	// 目标为 100% 时没有错误预算，burn rate 无法计算
+	config.Objectives = slices.Clone(config.Objectives)
+	for i, o := range config.Objectives {
+		if o.Target <= 0 || o.Target >= 1 {
+			logger.Warn("Invalid SLO target, using default", "objective", o.Name, "target", o.Target, "default", DefaultTarget)
+			config.Objectives[i].Target = DefaultTarget
+		}
+	}
+	return &Tracker{
+		store:  store,
+		config: config,
+		logger: logger,
+		stopCh: make(chan struct{}),
+		now:    time.Now,
+	}
+}
+
+// MaxWindow 最长的统计窗口，Store 至少保留这么久的计数
+func (c Config) MaxWindow() time.Duration {
+	if len(c.Windows) == 0 {
+		return slices.Max(DefaultWindows)
+	}
+	return slices.Max(c.Windows)
+}
+
+func (t *Tracker) objective(name string) (Objective, bool) {
+	for _, o := range t.config.Objectives {
+		if o.Name == name {
+			return o, true
+		}
+	}
+	return Objective{}, false
+}
+
+// Record 记录 objective 的一个事件，Tracker 为 nil 或目标未配置时忽略。写入失败只记录日志
+func (t *Tracker) Record(ctx context.Context, objective string, failed bool, latency time.Duration) {
+	if t == nil {
+		return
+	}
+	o, ok := t.objective(objective)
+	if !ok {
+		return
+	}
+	slow := !failed && o.LatencyTarget > 0 && latency > o.LatencyTarget
+	result := "good"
+	switch {
+	case failed:
+		result = "failed"
+	case slow:
+		result = "slow"
+	}
+	monitor.SLOEvents.WithLabelValues(objective, result).Inc()
+
+	minute := t.now().Unix() / 60
+	if err := t.store.Add(ctx, objective, minute, failed, slow, bucketOf(latency)); err != nil {
+		t.logger.Warn("Failed to record SLO event", "objective", objective, "error", err)
+	}
+}
+
+// bucketOf 返回 latency 所在的 LatencyBuckets 下标，超过最大上界时为 len(LatencyBuckets)
+func bucketOf(latency time.Duration) int {
+	i, _ := slices.BinarySearch(LatencyBuckets, latency)
+	return i
+}
+
+// Report 返回各目标在各窗口内的统计
+func (t *Tracker) Report(ctx context.Context) ([]Report, error) {
+	now := t.now().Unix() / 60
+	out := make([]Report, 0, len(t.config.Objectives))
+	for _, o := range t.config.Objectives {
+		r := Report{
+			Objective:       o.Name,
+			Target:          o.Target,
+			LatencyTargetMs: o.LatencyTarget.Milliseconds(),
+			Windows:         make([]WindowReport, 0, len(t.config.Windows)),
+		}
+		for _, w := range t.config.Windows {
+			minutes := max(int64(w/time.Minute), 1)
+			c, err := t.store.Load(ctx, o.Name, now-minutes+1, now)
+			if err != nil {
+				return nil, err
+			}
+			r.Windows = append(r.Windows, summarize(o, w, c))
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func summarize(o Objective, window time.Duration, c Counts) WindowReport {
+	wr := WindowReport{
+		Window:          windowLabel(window),
+		Total:           c.Total,
+		Failed:          c.Failed,
+		Slow:            c.Slow,
+		SuccessRate:     1,
+		Compliance:      1,
+		BudgetRemaining: 1,
+	}
+	if c.Total > 0 {
+		bad := float64(c.Failed + c.Slow)
+		wr.SuccessRate = float64(c.Total-c.Failed) / float64(c.Total)
+		wr.Compliance = 1 - bad/float64(c.Total)
+		wr.BurnRate = bad / float64(c.Total) / (1 - o.Target)
+		wr.BudgetRemaining = 1 - wr.BurnRate
+	}
+	wr.P50Ms = percentile(c.Latency, 0.5).Milliseconds()
+	wr.P90Ms = percentile(c.Latency, 0.9).Milliseconds()
+	wr.P99Ms = percentile(c.Latency, 0.99).Milliseconds()
+	return wr
+}
+
+// windowLabel 窗口的简短名称，如 5m、6h，用作指标标签
+func windowLabel(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return strconv.FormatInt(int64(d/time.Hour), 10) + "h"
+	}
+	return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+}
+
+// percentile 按耗时桶估算 q 分位数，取所在桶的上界；没有样本时返回 0
+func percentile(buckets []int64, q float64) time.Duration {
+	var total int64
+	for _, n := range buckets {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(float64(total) * q))
+	var seen int64
+	for i, n := range buckets {
+		seen += n
+		if seen >= rank {
+			return LatencyBuckets[min(i, len(LatencyBuckets)-1)]
+		}
+	}
+	return LatencyBuckets[len(LatencyBuckets)-1]
+}
+
+// Start 定期刷新 burn rate 和剩余错误预算指标（阻塞，应在 goroutine 中调用）
+func (t *Tracker) Start() {
+	ticker := time.NewTicker(t.config.Interval)
+	defer ticker.Stop()
+
+	t.refresh()
+	for {
+		select {
+		case <-t.stopCh:
+			return
+		case <-ticker.C:
+			t.refresh()
+		}
+	}
+}
+
+// Stop 停止刷新指标
+func (t *Tracker) Stop() {
+	select {
+	case <-t.stopCh:
+	default:
+		close(t.stopCh)
+	}
+}
+
+func (t *Tracker) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	reports, err := t.Report(ctx)
+	if err != nil {
+		t.logger.Warn("Failed to compute SLO report", "error", err)
+		return
+	}
+	for _, r := range reports {
+		for _, w := range r.Windows {
+			monitor.SLOBurnRate.WithLabelValues(r.Objective, w.Window).Set(w.BurnRate)
+			monitor.SLOErrorBudgetRemaining.WithLabelValues(r.Objective, w.Window).Set(w.BudgetRemaining)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package slo
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memStore struct {
+	mu      sync.Mutex
+	minutes map[string]map[int64]*Counts
+}
+
+func newMemStore() *memStore {
+	return &memStore{minutes: make(map[string]map[int64]*Counts)}
+}
+
+func (s *memStore) Add(ctx context.Context, objective string, minute int64, failed, slow bool, bucket int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.minutes[objective] == nil {
+		s.minutes[objective] = make(map[int64]*Counts)
+	}
+	c := s.minutes[objective][minute]
+	if c == nil {
+		c = &Counts{Latency: make([]int64, len(LatencyBuckets)+1)}
+		s.minutes[objective][minute] = c
+	}
+	c.Total++
+	if failed {
+		c.Failed++
+		return nil
+	}
+	if slow {
+		c.Slow++
+	}
+	c.Latency[bucket]++
+	return nil
+}
+
+func (s *memStore) Load(ctx context.Context, objective string, from, to int64) (Counts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := Counts{Latency: make([]int64, len(LatencyBuckets)+1)}
+	for m, c := range s.minutes[objective] {
+		if m < from || m > to {
+			continue
+		}
+		out.Total += c.Total
+		out.Failed += c.Failed
+		out.Slow += c.Slow
+		for i, n := range c.Latency {
+			out.Latency[i] += n
+		}
+	}
+	return out, nil
+}
+
+func newTestTracker(now *time.Time) *Tracker {
+	t := NewTracker(newMemStore(), Config{
+		Objectives: []Objective{{Name: ObjectiveSessionCreate, Target: 0.9, LatencyTarget: 10 * time.Second}},
+		Windows:    []time.Duration{time.Hour, 5 * time.Minute},
+	}, slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	t.now = func() time.Time { return *now }
+	return t
+}
+
+func TestTrackerReportWindows(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tr := newTestTracker(&now)
+
+	// 30 分钟前：10 次成功、2 次失败，只计入 1h 窗口
+	now = now.Add(-30 * time.Minute)
+	for range 10 {
+		tr.Record(ctx, ObjectiveSessionCreate, false, time.Second)
+	}
+	tr.Record(ctx, ObjectiveSessionCreate, true, 0)
+	tr.Record(ctx, ObjectiveSessionCreate, true, 0)
+
+	// 当前：3 次成功、1 次超时
+	now = now.Add(30 * time.Minute)
+	for range 3 {
+		tr.Record(ctx, ObjectiveSessionCreate, false, 2*time.Second)
+	}
+	tr.Record(ctx, ObjectiveSessionCreate, false, 20*time.Second)
+	// 未配置的目标被忽略
+	tr.Record(ctx, ObjectiveChatDispatch, true, 0)
+
+	reports, err := tr.Report(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || reports[0].Objective != ObjectiveSessionCreate {
+		t.Fatalf("reports = %+v", reports)
+	}
+	windows := reports[0].Windows
+	if len(windows) != 2 || windows[0].Window != "5m" || windows[1].Window != "1h" {
+		t.Fatalf("windows = %+v", windows)
+	}
+
+	short := windows[0]
+	if short.Total != 4 || short.Failed != 0 || short.Slow != 1 {
+		t.Fatalf("5m counts = %+v", short)
+	}
+	if short.SuccessRate != 1 || short.Compliance != 0.75 {
+		t.Fatalf("5m rates = %+v", short)
+	}
+	// 不达标比例 25%，错误预算 10%
+	if math.Abs(short.BurnRate-2.5) > 1e-9 || math.Abs(short.BudgetRemaining+1.5) > 1e-9 {
+		t.Fatalf("5m burn rate = %v, budget remaining = %v", short.BurnRate, short.BudgetRemaining)
+	}
+	if short.P50Ms != 2500 || short.P99Ms != 30000 {
+		t.Fatalf("5m percentiles = %+v", short)
+	}
+
+	long := windows[1]
+	if long.Total != 16 || long.Failed != 2 || long.Slow != 1 {
+		t.Fatalf("1h counts = %+v", long)
+	}
+	if math.Abs(long.BurnRate-3.0/16/0.1) > 1e-9 {
+		t.Fatalf("1h burn rate = %v", long.BurnRate)
+	}
+	if long.P50Ms != 1000 {
+		t.Fatalf("1h p50 = %d", long.P50Ms)
+	}
+}
+
+func TestTrackerEmptyWindow(t *testing.T) {
+	now := time.Now()
+	reports, err := newTestTracker(&now).Report(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := reports[0].Windows[0]
+	if w.Total != 0 || w.SuccessRate != 1 || w.BurnRate != 0 || w.BudgetRemaining != 1 || w.P50Ms != 0 {
+		t.Fatalf("empty window = %+v", w)
+	}
+}
+
+func TestNewTrackerInvalidTarget(t *testing.T) {
+	tr := NewTracker(newMemStore(), Config{
+		Objectives: []Objective{{Name: ObjectiveChatDispatch, Target: 1}},
+	}, slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	if o, _ := tr.objective(ObjectiveChatDispatch); o.Target != DefaultTarget {
+		t.Fatalf("target = %v, want %v", o.Target, DefaultTarget)
+	}
+	if len(tr.config.Windows) != len(DefaultWindows) {
+		t.Fatalf("windows = %v", tr.config.Windows)
+	}
+}
+
+func TestBucketOf(t *testing.T) {
+	for _, tc := range []struct {
+		latency time.Duration
+		want    int
+	}{
+		{0, 0},
+		{50 * time.Millisecond, 0},
+		{51 * time.Millisecond, 1},
+		{time.Second, 4},
+		{time.Hour, len(LatencyBuckets)},
+	} {
+		if got := bucketOf(tc.latency); got != tc.want {
+			t.Errorf("bucketOf(%v) = %d, want %d", tc.latency, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Package slo 按时间窗口统计会话创建和对话分发的成功率与耗时分位数，并计算错误预算的消耗速度（burn rate），
+// 运维可以按错误预算告警，而不是按原始的失败计数告警
+package slo
+
+import "time"
+
+// 内置的服务等级目标
+const (
+	ObjectiveSessionCreate = "session_create"
+	ObjectiveChatDispatch  = "chat_dispatch"
+)
+
+// LatencyBuckets 耗时分布的桶上界，分位数按所在桶的上界估算，超过最后一个上界的按最后一个上界计
+var LatencyBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	2 * time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+}
+
+// Objective 一个服务等级目标
+type Objective struct {
+	Name string
+	// Target 达标事件比例的目标（0-1），如 0.99 表示错误预算为 1%
+	Target float64
+	// LatencyTarget 成功但耗时超过此值的事件同样计为不达标，0 表示不考虑耗时
+	LatencyTarget time.Duration
+}
+
+// Counts 一段时间内的事件计数
+type Counts struct {
+	Total  int64
+	Failed int64
+	// Slow 成功但超过耗时目标的事件数
+	Slow int64
+	// Latency 各耗时桶的事件数，与 LatencyBuckets 一一对应，多出的最后一项为超过最大上界的事件数
+	Latency []int64
+}
+
+// WindowReport 一个目标在一个时间窗口内的统计
+type WindowReport struct {
+	Window string `json:"window"`
+	Total  int64  `json:"total"`
+	Failed int64  `json:"failed"`
+	Slow   int64  `json:"slow"`
+	// SuccessRate 未失败事件的比例，Compliance 达标（未失败且未超时）事件的比例，窗口内没有事件时均为 1
+	SuccessRate float64 `json:"success_rate"`
+	Compliance  float64 `json:"compliance"`
+	// BurnRate 不达标比例除以错误预算比例，1 表示恰好在窗口内耗尽预算
+	BurnRate float64 `json:"burn_rate"`
+	// BudgetRemaining 窗口内剩余的错误预算比例，耗尽后为负数
+	BudgetRemaining float64 `json:"budget_remaining"`
+	P50Ms           int64   `json:"p50_ms"`
+	P90Ms           int64   `json:"p90_ms"`
+	P99Ms           int64   `json:"p99_ms"`
+}
+
+// Report 一个目标在各时间窗口内的统计
+type Report struct {
+	Objective       string         `json:"objective"`
+	Target          float64        `json:"target"`
+	LatencyTargetMs int64          `json:"latency_target_ms,omitempty"`
+	Windows         []WindowReport `json:"windows"`
+}
@@ -0,0 +1,106 @@
+// Package snapshot 将工作区 tar 归档按内容寻址保存在对象存储中，用于从已知的工作区状态分叉新的 session
+package snapshot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"platform/internal/storage"
+)
+
+// ErrNotFound 快照不存在
+var ErrNotFound = errors.New("snapshot not found")
+
+// Info 已保存的快照，ID 为归档内容的 SHA-256，相同内容的快照只保存一份
+type Info struct {
+	ID        string    `json:"snapshot_id"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store 快照保存为对象存储中的 snapshots/<id>.tar
+type Store struct {
+	blob storage.Blob
+}
+
+func NewStore(blob storage.Blob) *Store {
+	return &Store{blob: blob}
+}
+
+// Put 先写入临时文件并计算摘要，对象存储中已有相同摘要的快照时不再上传
+func (s *Store) Put(ctx context.Context, r io.Reader) (*Info, error) {
+	tmp, err := os.CreateTemp("", "snapshot-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	id := hex.EncodeToString(h.Sum(nil))
+	if info, err := s.Stat(ctx, id); err == nil {
+		return info, nil
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err := s.blob.Put(ctx, key(id), tmp); err != nil {
+		return nil, fmt.Errorf("failed to store snapshot: %w", err)
+	}
+	return &Info{ID: id, Size: size, CreatedAt: time.Now()}, nil
+}
+
+// Open 打开快照归档，调用方负责关闭
+func (s *Store) Open(ctx context.Context, id string) (io.ReadCloser, error) {
+	if err := ValidateID(id); err != nil {
+		return nil, err
+	}
+	r, err := s.blob.Get(ctx, key(id))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return r, err
+}
+
+// Stat 返回快照信息，不存在时返回 ErrNotFound
+func (s *Store) Stat(ctx context.Context, id string) (*Info, error) {
+	if err := ValidateID(id); err != nil {
+		return nil, err
+	}
+	obj, err := s.blob.Stat(ctx, key(id))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &Info{ID: id, Size: obj.Size, CreatedAt: obj.LastModified}, nil
+}
+
+// ValidateID 快照 ID 必须是 64 位小写十六进制
+func ValidateID(id string) error {
+	if len(id) != sha256.Size*2 {
+		return fmt.Errorf("invalid snapshot id %q", id)
+	}
+	for _, c := range id {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return fmt.Errorf("invalid snapshot id %q", id)
+		}
+	}
+	return nil
+}
+
+func key(id string) string {
+	return "snapshots/" + id + ".tar"
+}
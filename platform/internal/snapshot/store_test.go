@@ -0,0 +1,52 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"platform/internal/storage"
+)
+
+func TestStorePutIsContentAddressed(t *testing.T) {
+	s := NewStore(storage.NewLocalBlob(t.TempDir()))
+	ctx := context.Background()
+
+	a, err := s.Put(ctx, strings.NewReader("workspace archive"))
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	b, err := s.Put(ctx, strings.NewReader("workspace archive"))
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if a.ID != b.ID || a.Size != int64(len("workspace archive")) {
+		t.Fatalf("got %+v and %+v, want the same id and size", a, b)
+	}
+
+	r, err := s.Open(ctx, a.ID)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer r.Close()
+	data, _ := io.ReadAll(r)
+	if string(data) != "workspace archive" {
+		t.Fatalf("content = %q", data)
+	}
+}
+
+func TestStoreOpen(t *testing.T) {
+	s := NewStore(storage.NewLocalBlob(t.TempDir()))
+	ctx := context.Background()
+
+	if _, err := s.Open(ctx, strings.Repeat("a", 64)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Open(missing) = %v, want ErrNotFound", err)
+	}
+	for _, id := range []string{"", "../../etc/passwd", strings.Repeat("A", 64)} {
+		if _, err := s.Open(ctx, id); err == nil || !strings.Contains(err.Error(), "invalid") {
+			t.Fatalf("Open(%q) = %v, want invalid id error", id, err)
+		}
+	}
+}
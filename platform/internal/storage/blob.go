@@ -0,0 +1,39 @@
+// Package storage 提供可插拔的对象存储（本地目录、S3 / MinIO），
+// 平台节点是临时的，项目文件和工作区快照需要保存在节点之外
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrNotFound 对象不存在
+var ErrNotFound = errors.New("object not found")
+
+// ObjectInfo 对象的元数据
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Blob 对象存储驱动。key 为以 / 分隔的相对路径，Get 返回的 ReadCloser 由调用方关闭
+type Blob interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// validateKey 拒绝空 key、绝对路径和包含 .. 的 key，避免本地驱动写出根目录
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return fmt.Errorf("invalid object key %q", key)
+	}
+	return nil
+}
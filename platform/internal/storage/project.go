@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ProjectKey 项目文件归档在对象存储中的 key
+func ProjectKey(projectID string) string {
+	return "projects/" + projectID + ".tar"
+}
+
+// ProjectStore 以 tar 归档的形式在对象存储中保存项目目录，条目路径相对于项目根目录，
+// 与 worker.TarContext 的格式一致，可直接上传到容器的工作区
+type ProjectStore struct {
+	blob Blob
+}
+
+func NewProjectStore(blob Blob) *ProjectStore {
+	return &ProjectStore{blob: blob}
+}
+
+// Load 返回项目归档，项目尚未保存过时返回 ErrNotFound
+func (p *ProjectStore) Load(ctx context.Context, projectID string) (io.ReadCloser, error) {
+	return p.blob.Get(ctx, ProjectKey(projectID))
+}
+
+// SaveDir 打包本地项目目录并上传，覆盖之前的归档
+func (p *ProjectStore) SaveDir(ctx context.Context, projectID, dir string) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeTar(pw, dir))
+	}()
+	if err := p.blob.Put(ctx, ProjectKey(projectID), pr); err != nil {
+		pr.CloseWithError(err)
+		return fmt.Errorf("failed to upload project %s: %w", projectID, err)
+	}
+	return nil
+}
+
+// writeTar 只打包目录、普通文件和符号链接
+func writeTar(w io.Writer, dir string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(dir, func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		var link string
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		} else if !fi.Mode().IsRegular() && !fi.IsDir() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
@@ -0,0 +1,16 @@
+package taskstatus
+
+import (
+	"context"
+	"time"
+)
+
+type Store interface {
+	Save(ctx context.Context, hb *Heartbeat) error
+	Delete(ctx context.Context, sessionID string) error
+	List(ctx context.Context) ([]*Heartbeat, error)
+	// RecordDuration 记录一次成功任务的耗时，只保留最近的 DurationSamples 条
+	RecordDuration(ctx context.Context, taskType string, d time.Duration) error
+	// Durations 返回最近成功任务的耗时
+	Durations(ctx context.Context, taskType string) ([]time.Duration, error)
+}
@@ -0,0 +1,47 @@
+package taskstatus
+
+import (
+	"context"
+	"math"
+	"slices"
+	"time"
+)
+
+// DurationSamples 每类任务保留的最近成功耗时条数
+const DurationSamples = 200
+
+// Latency 最近成功任务耗时的分位数
+type Latency struct {
+	P50 time.Duration
+	P90 time.Duration
+	// Samples 参与统计的任务数，为 0 时分位数不可用
+	Samples int
+}
+
+// Latency 返回 taskType 类任务最近成功耗时的分位数
+func (t *Tracker) Latency(ctx context.Context, taskType string) (Latency, error) {
+	durations, err := t.store.Durations(ctx, taskType)
+	if err != nil {
+		return Latency{}, err
+	}
+	return latencyOf(durations), nil
+}
+
+func latencyOf(durations []time.Duration) Latency {
+	if len(durations) == 0 {
+		return Latency{}
+	}
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	return Latency{
+		P50:     percentile(sorted, 0.5),
+		P90:     percentile(sorted, 0.9),
+		Samples: len(sorted),
+	}
+}
+
+// percentile 取已排序样本的 q 分位数（最近秩法）
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	idx := int(math.Ceil(float64(len(sorted))*q)) - 1
+	return sorted[max(0, min(idx, len(sorted)-1))]
+}
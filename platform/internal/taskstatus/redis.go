@@ -0,0 +1,81 @@
+package taskstatus
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tasksKey 所有进行中任务的心跳存放在同一个 hash 中，field 为 sessionID
+const tasksKey = "worker:tasks"
+
+// durationsKeyPrefix 各类任务最近的成功耗时（纳秒）存放在 list 中，新记录在前
+const durationsKeyPrefix = "worker:task_durations:"
+
+var _ Store = (*RedisStore)(nil)
+
+type RedisStore struct {
+	redis redis.Cmdable
+}
+
+func NewRedisStore(redis redis.Cmdable) *RedisStore {
+	return &RedisStore{redis: redis}
+}
+
+func (s *RedisStore) Save(ctx context.Context, hb *Heartbeat) error {
+	b, err := json.Marshal(hb)
+	if err != nil {
+		return err
+	}
+	return s.redis.HSet(ctx, tasksKey, hb.SessionID, b).Err()
+}
+
+func (s *RedisStore) Delete(ctx context.Context, sessionID string) error {
+	return s.redis.HDel(ctx, tasksKey, sessionID).Err()
+}
+
+func (s *RedisStore) List(ctx context.Context) ([]*Heartbeat, error) {
+	vals, err := s.redis.HGetAll(ctx, tasksKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*Heartbeat, 0, len(vals))
+	for _, v := range vals {
+		var hb Heartbeat
+		if err := json.Unmarshal([]byte(v), &hb); err != nil {
+			continue
+		}
+		out = append(out, &hb)
+	}
+	return out, nil
+}
+
+func (s *RedisStore) RecordDuration(ctx context.Context, taskType string, d time.Duration) error {
+	key := durationsKeyPrefix + taskType
+	pipe := s.redis.TxPipeline()
+	pipe.LPush(ctx, key, int64(d))
+	pipe.LTrim(ctx, key, 0, DurationSamples-1)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisStore) Durations(ctx context.Context, taskType string) ([]time.Duration, error) {
+	vals, err := s.redis.LRange(ctx, durationsKeyPrefix+taskType, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]time.Duration, 0, len(vals))
+	for _, v := range vals {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			continue
+		}
+		out = append(out, time.Duration(n))
+	}
+	return out, nil
+}
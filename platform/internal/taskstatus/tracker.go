@@ -0,0 +1,204 @@
+package taskstatus
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	// Interval 心跳刷新间隔
+	Interval time.Duration
+	// StaleAfter 超过此时长未刷新心跳的任务标记为 stale
+	StaleAfter time.Duration
+	// Expire 超过此时长未刷新心跳的记录在列表时被清理（Worker 崩溃后遗留）
+	Expire time.Duration
+}
+
+// Tracker 记录 Worker 正在处理的任务及其阶段，供运维排查卡住的会话创建
+type Tracker struct {
+	store  Store
+	config Config
+	worker string
+	logger *slog.Logger
+}
+
+func NewTracker(store Store, config Config, logger *slog.Logger) *Tracker {
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Second
+	}
+	if config.StaleAfter <= 0 {
+		config.StaleAfter = 6 * config.Interval
+	}
+	if config.Expire <= 0 {
+		config.Expire = time.Hour
+	}
+	host, _ := os.Hostname()
+	return &Tracker{
+		store:  store,
+		config: config,
+		worker: fmt.Sprintf("%s:%d", host, os.Getpid()),
+		logger: logger.With("component", "task-status"),
+	}
+}
+
+// Task 一个进行中任务的心跳句柄，Tracker 为 nil 时所有方法均为空操作
+type Task struct {
+	tracker *Tracker
+	mu      sync.Mutex
+	hb      Heartbeat
+	stopCh  chan struct{}
+	doneCh  chan struct{}
+
+	// phases 已结束的阶段及耗时
+	phases []PhaseTiming
+	// succeeded 任务成功完成，Finish 时记录耗时用于估算排队 session 的等待时间
+	succeeded bool
+}
+
+// Begin 登记一个任务并在后台定期刷新心跳，任务结束时必须调用 Finish
+func (t *Tracker) Begin(sessionID, taskID, taskType string, retry int) *Task {
+	if t == nil {
+		return nil
+	}
+
+	now := time.Now()
+	task := &Task{
+		tracker: t,
+		hb: Heartbeat{
+			SessionID:  sessionID,
+			TaskID:     taskID,
+			TaskType:   taskType,
+			Worker:     t.worker,
+			Phase:      PhaseStarted,
+			Retry:      retry,
+			StartedAt:  now,
+			PhaseSince: now,
+		},
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+	task.beat()
+
+	go task.loop()
+	return task
+}
+
+// SetPhase 切换任务阶段并立即上报
+func (k *Task) SetPhase(phase Phase) {
+	if k == nil {
+		return
+	}
+	now := time.Now()
+	k.mu.Lock()
+	k.phases = append(k.phases, PhaseTiming{Phase: k.hb.Phase, StartedAt: k.hb.PhaseSince, Duration: now.Sub(k.hb.PhaseSince)})
+	k.hb.Phase = phase
+	k.hb.PhaseSince = now
+	k.mu.Unlock()
+	k.beat()
+}
+
+// Timings 返回任务各阶段的耗时，最后一项为当前阶段截至现在的耗时
+func (k *Task) Timings() []PhaseTiming {
+	if k == nil {
+		return nil
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	timings := append([]PhaseTiming(nil), k.phases...)
+	return append(timings, PhaseTiming{Phase: k.hb.Phase, StartedAt: k.hb.PhaseSince, Duration: time.Since(k.hb.PhaseSince)})
+}
+
+// Succeed 标记任务成功完成，Finish 时记录任务耗时
+func (k *Task) Succeed() {
+	if k == nil {
+		return
+	}
+	k.mu.Lock()
+	k.succeeded = true
+	k.mu.Unlock()
+}
+
+// Finish 停止心跳并移除任务记录
+func (k *Task) Finish() {
+	if k == nil {
+		return
+	}
+	close(k.stopCh)
+	<-k.doneCh
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := k.tracker.store.Delete(ctx, k.hb.SessionID); err != nil {
+		k.tracker.logger.Warn("Failed to clear task heartbeat", "session_id", k.hb.SessionID, "error", err)
+	}
+	k.mu.Lock()
+	succeeded := k.succeeded
+	k.mu.Unlock()
+	if succeeded {
+		if err := k.tracker.store.RecordDuration(ctx, k.hb.TaskType, time.Since(k.hb.StartedAt)); err != nil {
+			k.tracker.logger.Warn("Failed to record task duration", "session_id", k.hb.SessionID, "error", err)
+		}
+	}
+}
+
+func (k *Task) loop() {
+	defer close(k.doneCh)
+	ticker := time.NewTicker(k.tracker.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-k.stopCh:
+			return
+		case <-ticker.C:
+			k.beat()
+		}
+	}
+}
+
+func (k *Task) beat() {
+	k.mu.Lock()
+	k.hb.HeartbeatAt = time.Now()
+	hb := k.hb
+	k.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := k.tracker.store.Save(ctx, &hb); err != nil {
+		k.tracker.logger.Warn("Failed to report task heartbeat", "session_id", hb.SessionID, "error", err)
+	}
+}
+
+// List 返回所有进行中的任务，按开始时间排序，并清理过期记录
+func (t *Tracker) List(ctx context.Context) ([]TaskView, error) {
+	heartbeats, err := t.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	views := make([]TaskView, 0, len(heartbeats))
+	for _, hb := range heartbeats {
+		silence := now.Sub(hb.HeartbeatAt)
+		if silence > t.config.Expire {
+			_ = t.store.Delete(ctx, hb.SessionID)
+			continue
+		}
+		views = append(views, TaskView{
+			Heartbeat:    *hb,
+			Elapsed:      now.Sub(hb.StartedAt).Round(time.Second).String(),
+			PhaseElapsed: now.Sub(hb.PhaseSince).Round(time.Second).String(),
+			Stale:        silence > t.config.StaleAfter,
+		})
+	}
+
+	sort.Slice(views, func(i, j int) bool {
+		return views[i].StartedAt.Before(views[j].StartedAt)
+	})
+	return views, nil
+}
@@ -0,0 +1,142 @@
+package taskstatus
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memStore struct {
+	mu        sync.Mutex
+	beats     map[string]Heartbeat
+	saves     int
+	durations []time.Duration
+}
+
+func (s *memStore) Save(ctx context.Context, hb *Heartbeat) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.beats[hb.SessionID] = *hb
+	s.saves++
+	return nil
+}
+
+func (s *memStore) Delete(ctx context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.beats, sessionID)
+	return nil
+}
+
+func (s *memStore) List(ctx context.Context) ([]*Heartbeat, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []*Heartbeat
+	for _, hb := range s.beats {
+		hb := hb
+		out = append(out, &hb)
+	}
+	return out, nil
+}
+
+func (s *memStore) RecordDuration(ctx context.Context, taskType string, d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.durations = append(s.durations, d)
+	return nil
+}
+
+func (s *memStore) Durations(ctx context.Context, taskType string) ([]time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]time.Duration(nil), s.durations...), nil
+}
+
+func TestTrackerLifecycle(t *testing.T) {
+	store := &memStore{beats: make(map[string]Heartbeat)}
+	tracker := NewTracker(store, Config{Interval: 10 * time.Millisecond}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+
+	task := tracker.Begin("s1", "t1", "session:create", 0)
+	task.SetPhase(PhaseAcquiring)
+	time.Sleep(50 * time.Millisecond)
+
+	views, err := tracker.List(context.Background())
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(views) != 1 || views[0].Phase != PhaseAcquiring || views[0].Stale {
+		t.Fatalf("Unexpected views: %+v", views)
+	}
+	store.mu.Lock()
+	saves := store.saves
+	store.mu.Unlock()
+	if saves < 3 {
+		t.Errorf("Expected periodic heartbeats, got %d saves", saves)
+	}
+
+	timings := task.Timings()
+	if len(timings) != 2 || timings[0].Phase != PhaseStarted || timings[1].Phase != PhaseAcquiring {
+		t.Fatalf("Unexpected timings: %+v", timings)
+	}
+	if timings[1].Duration < 50*time.Millisecond {
+		t.Errorf("Expected current phase duration to include elapsed time, got %s", timings[1].Duration)
+	}
+
+	task.Finish()
+	if views, _ := tracker.List(context.Background()); len(views) != 0 {
+		t.Errorf("Expected finished task to be removed, got %+v", views)
+	}
+	if len(store.durations) != 0 {
+		t.Errorf("Expected unfinished task not to record a duration, got %v", store.durations)
+	}
+
+	task = tracker.Begin("s2", "t2", "session:create", 0)
+	task.Succeed()
+	task.Finish()
+	if len(store.durations) != 1 {
+		t.Errorf("Expected successful task to record its duration, got %v", store.durations)
+	}
+}
+
+func TestLatencyPercentiles(t *testing.T) {
+	var durations []time.Duration
+	for i := 10; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Second)
+	}
+	got := latencyOf(durations)
+	if got.P50 != 5*time.Second || got.P90 != 9*time.Second || got.Samples != 10 {
+		t.Errorf("latencyOf() = %+v, want p50=5s p90=9s samples=10", got)
+	}
+	if got := latencyOf(nil); got.Samples != 0 {
+		t.Errorf("latencyOf(nil) = %+v, want no samples", got)
+	}
+}
+
+func TestTrackerStaleAndExpired(t *testing.T) {
+	now := time.Now()
+	store := &memStore{beats: map[string]Heartbeat{
+		"live":    {SessionID: "live", StartedAt: now.Add(-time.Minute), HeartbeatAt: now},
+		"stuck":   {SessionID: "stuck", StartedAt: now.Add(-10 * time.Minute), HeartbeatAt: now.Add(-5 * time.Minute)},
+		"expired": {SessionID: "expired", StartedAt: now.Add(-3 * time.Hour), HeartbeatAt: now.Add(-2 * time.Hour)},
+	}}
+	tracker := NewTracker(store, Config{Interval: 5 * time.Second}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+
+	views, err := tracker.List(context.Background())
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(views) != 2 || views[0].SessionID != "stuck" || !views[0].Stale || views[1].Stale {
+		t.Fatalf("Unexpected views: %+v", views)
+	}
+	if _, ok := store.beats["expired"]; ok {
+		t.Error("Expected expired heartbeat to be pruned")
+	}
+
+	var nilTracker *Tracker
+	task := nilTracker.Begin("s", "t", "x", 0)
+	task.SetPhase(PhaseFinalizing)
+	task.Finish()
+}
@@ -0,0 +1,44 @@
+package taskstatus
+
+import "time"
+
+// Phase 会话创建任务所处阶段
+type Phase string
+
+const (
+	PhaseStarted       Phase = "started"
+	PhaseAcquiring     Phase = "acquiring_container"
+	PhaseWaitingAgent  Phase = "waiting_agent"
+	PhaseSyncingFiles  Phase = "syncing_files"
+	PhaseStartingAgent Phase = "starting_agent"
+	PhaseFinalizing    Phase = "finalizing"
+)
+
+// Heartbeat Worker 正在处理的一个任务的最近状态
+type Heartbeat struct {
+	SessionID   string    `json:"session_id"`
+	TaskID      string    `json:"task_id,omitempty"`
+	TaskType    string    `json:"task_type"`
+	Worker      string    `json:"worker"` // hostname:pid
+	Phase       Phase     `json:"phase"`
+	Retry       int       `json:"retry"`
+	StartedAt   time.Time `json:"started_at"`
+	PhaseSince  time.Time `json:"phase_since"`
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+}
+
+// PhaseTiming 任务在一个阶段停留的时间
+type PhaseTiming struct {
+	Phase     Phase         `json:"phase"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration_ns"`
+}
+
+// TaskView 管理接口返回的任务视图
+type TaskView struct {
+	Heartbeat
+	Elapsed      string `json:"elapsed"`
+	PhaseElapsed string `json:"phase_elapsed"`
+	// Stale 超过阈值未收到心跳，通常意味着 Worker 崩溃或卡死
+	Stale bool `json:"stale"`
+}